@@ -0,0 +1,239 @@
+// Copyright (c) Microsoft Corporation. All rights reserved.
+// Licensed under the MIT license.
+
+package cmd
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+
+	"github.com/Azure/aks-engine/pkg/api"
+	"github.com/Azure/aks-engine/pkg/engine"
+	"github.com/Azure/aks-engine/pkg/helpers"
+	"github.com/Azure/aks-engine/pkg/i18n"
+	"github.com/Azure/aks-engine/pkg/operations"
+	"github.com/Azure/aks-engine/pkg/operations/kubernetesaddons"
+	"github.com/Azure/go-autorest/autorest/to"
+	"github.com/leonelquinteros/gotext"
+	"github.com/pkg/errors"
+	log "github.com/sirupsen/logrus"
+	"github.com/spf13/cobra"
+)
+
+const (
+	addonsName                  = "addons"
+	addonsShortDescription      = "Manage the addons of an existing Kubernetes cluster"
+	addonsLongDescription       = "Manage the addons of an existing Kubernetes cluster without upgrading it"
+	addonsApplyName             = "apply"
+	addonsApplyShortDescription = "Update the addons of an existing Kubernetes cluster to match the api model"
+	addonsApplyLongDescription  = "Render the addon manifests for the api model, write them to every master over SSH and apply the ones that changed. The objects of the disabled addons are deleted. The api model is saved with the changes made by --enable and --disable."
+)
+
+type addonsApplyCmd struct {
+	hostKeyArgs
+
+	// user input
+	location     string
+	apiModelPath string
+	sshFilepath  string
+	masterFQDN   string
+	enable       []string
+	disable      []string
+
+	// derived
+	containerService *api.ContainerService
+	apiVersion       string
+	locale           *gotext.Locale
+	applier          *kubernetesaddons.AddonsApplier
+}
+
+func newAddonsCmd() *cobra.Command {
+	command := &cobra.Command{
+		Use:   addonsName,
+		Short: addonsShortDescription,
+		Long:  addonsLongDescription,
+	}
+	command.AddCommand(newAddonsApplyCmd())
+	return command
+}
+
+func newAddonsApplyCmd() *cobra.Command {
+	ac := addonsApplyCmd{}
+
+	command := &cobra.Command{
+		Use:   addonsApplyName,
+		Short: addonsApplyShortDescription,
+		Long:  addonsApplyLongDescription,
+		RunE:  ac.run,
+	}
+
+	f := command.Flags()
+	f.StringVarP(&ac.location, "location", "l", "", "location the cluster is deployed in (required unless --master-FQDN is set)")
+	f.StringVarP(&ac.apiModelPath, "api-model", "m", "", "path to the generated apimodel.json file (required)")
+	f.StringVar(&ac.sshFilepath, "ssh", "", "the filepath of a valid private ssh key to access the cluster's masters (required)")
+	f.StringVar(&ac.masterFQDN, "master-FQDN", "", "FQDN for the master load balancer (derived from the api model if absent)")
+	addHostKeyFlags(&ac.hostKeyArgs, f, "masters")
+	f.StringSliceVar(&ac.enable, "enable", nil, "names of the addons to enable in the api model before applying it")
+	f.StringSliceVar(&ac.disable, "disable", nil, "names of the addons to disable in the api model before applying it")
+
+	return command
+}
+
+func (ac *addonsApplyCmd) validate(cmd *cobra.Command) error {
+	var err error
+
+	ac.locale, err = i18n.LoadTranslations()
+	if err != nil {
+		return errors.Wrap(err, "error loading translation files")
+	}
+
+	if ac.apiModelPath == "" {
+		cmd.Usage()
+		return errors.New("--api-model must be specified")
+	}
+
+	if ac.sshFilepath == "" {
+		cmd.Usage()
+		return errors.New("--ssh must be specified")
+	}
+
+	if ac.masterFQDN == "" && ac.location == "" {
+		cmd.Usage()
+		return errors.New("--location must be specified")
+	}
+	ac.location = helpers.NormalizeAzureRegion(ac.location)
+
+	for _, name := range ac.enable {
+		for _, disabled := range ac.disable {
+			if name == disabled {
+				return errors.Errorf("addon %s cannot be both enabled and disabled", name)
+			}
+		}
+	}
+
+	return nil
+}
+
+func (ac *addonsApplyCmd) loadCluster() error {
+	var err error
+
+	if _, err = os.Stat(ac.apiModelPath); os.IsNotExist(err) {
+		return errors.Errorf("specified api model does not exist (%s)", ac.apiModelPath)
+	}
+
+	apiloader := &api.Apiloader{
+		Translator: &i18n.Translator{
+			Locale: ac.locale,
+		},
+	}
+	ac.containerService, ac.apiVersion, err = apiloader.LoadContainerServiceFromFile(ac.apiModelPath, true, true, nil)
+	if err != nil {
+		return errors.Wrap(err, "error parsing the api model")
+	}
+
+	if ac.containerService.Location == "" {
+		ac.containerService.Location = ac.location
+	} else if ac.location != "" && ac.containerService.Location != ac.location {
+		return errors.New("--location does not match api model location")
+	}
+
+	knownHostsFile, err := ac.getKnownHostsFile()
+	if err != nil {
+		return err
+	}
+	ac.applier, err = getAddonsApplier(ac.containerService, ac.containerService.Location, ac.masterFQDN, ac.sshFilepath, knownHostsFile)
+	return err
+}
+
+func (ac *addonsApplyCmd) run(cmd *cobra.Command, args []string) error {
+	if err := ac.validate(cmd); err != nil {
+		return errors.Wrap(err, "validating addons apply command")
+	}
+	if err := ac.loadCluster(); err != nil {
+		return errors.Wrap(err, "loading existing cluster")
+	}
+
+	kubernetesConfig := ac.containerService.Properties.OrchestratorProfile.KubernetesConfig
+	if kubernetesConfig == nil {
+		kubernetesConfig = &api.KubernetesConfig{}
+		ac.containerService.Properties.OrchestratorProfile.KubernetesConfig = kubernetesConfig
+	}
+	for _, name := range ac.enable {
+		setAddonEnabled(kubernetesConfig, name, true)
+	}
+	for _, name := range ac.disable {
+		setAddonEnabled(kubernetesConfig, name, false)
+	}
+
+	// the api model is saved as the user wrote it, without the defaults needed to render the manifests
+	apiloader := &api.Apiloader{
+		Translator: &i18n.Translator{
+			Locale: ac.locale,
+		},
+	}
+	b, err := apiloader.SerializeContainerService(ac.containerService, ac.apiVersion)
+	if err != nil {
+		return err
+	}
+
+	if _, err = ac.containerService.SetPropertiesDefaults(false, true); err != nil {
+		return errors.Wrapf(err, "error in SetPropertiesDefaults template %s", ac.apiModelPath)
+	}
+	manifests, err := engine.GetAddonManifests(ac.containerService)
+	if err != nil {
+		return errors.Wrap(err, "rendering addon manifests")
+	}
+	if err = ac.applier.Apply(manifests); err != nil {
+		return errors.Wrap(err, "applying addons")
+	}
+
+	f := helpers.FileSaver{
+		Translator: &i18n.Translator{
+			Locale: ac.locale,
+		},
+	}
+	dir, file := filepath.Split(ac.apiModelPath)
+	return f.SaveFile(dir, file, b)
+}
+
+// setAddonEnabled enables or disables an addon in the Kubernetes config, adding the addon if absent
+func setAddonEnabled(kubernetesConfig *api.KubernetesConfig, name string, enabled bool) {
+	for i := range kubernetesConfig.Addons {
+		if kubernetesConfig.Addons[i].Name == name {
+			kubernetesConfig.Addons[i].Enabled = to.BoolPtr(enabled)
+			return
+		}
+	}
+	kubernetesConfig.Addons = append(kubernetesConfig.Addons, api.KubernetesAddon{
+		Name:    name,
+		Enabled: to.BoolPtr(enabled),
+	})
+}
+
+// getAddonsApplier returns an AddonsApplier reaching the masters of the cluster over SSH with the private key at sshFilepath
+func getAddonsApplier(cs *api.ContainerService, location, masterFQDN, sshFilepath, knownHostsFile string) (*kubernetesaddons.AddonsApplier, error) {
+	properties := cs.Properties
+	if !properties.OrchestratorProfile.IsKubernetes() || properties.MasterProfile == nil {
+		return nil, errors.New("addons can only be applied to Kubernetes clusters with a master profile")
+	}
+	if properties.MasterProfile.IsVirtualMachineScaleSets() {
+		return nil, errors.New("addons cannot be applied to clusters with master scale sets")
+	}
+
+	sshKey, err := ioutil.ReadFile(sshFilepath)
+	if err != nil {
+		return nil, errors.Wrapf(err, "reading ssh key %s", sshFilepath)
+	}
+	if masterFQDN == "" {
+		masterFQDN = api.FormatProdFQDNByLocation(properties.MasterProfile.DNSPrefix, location, properties.GetCustomCloudName())
+	}
+	return &kubernetesaddons.AddonsApplier{
+		Logger:         log.NewEntry(log.New()),
+		MasterFQDN:     masterFQDN,
+		SSHPorts:       operations.MasterSSHPorts(properties.MasterProfile.Count),
+		SSHUser:        properties.LinuxProfile.AdminUsername,
+		SSHKey:         sshKey,
+		KnownHostsFile: knownHostsFile,
+	}, nil
+}
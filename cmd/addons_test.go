@@ -0,0 +1,139 @@
+// Copyright (c) Microsoft Corporation. All rights reserved.
+// Licensed under the MIT license.
+
+package cmd
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/Azure/aks-engine/pkg/api"
+	"github.com/Azure/go-autorest/autorest/to"
+	. "github.com/onsi/gomega"
+	"github.com/pkg/errors"
+	"github.com/spf13/cobra"
+)
+
+func TestNewAddonsCmd(t *testing.T) {
+	g := NewGomegaWithT(t)
+	command := newAddonsCmd()
+
+	g.Expect(command.Use).Should(Equal(addonsName))
+	g.Expect(command.Short).Should(Equal(addonsShortDescription))
+	g.Expect(command.Long).Should(Equal(addonsLongDescription))
+
+	subCommands := command.Commands()
+	g.Expect(subCommands).To(HaveLen(1))
+	g.Expect(subCommands[0].Use).To(Equal(addonsApplyName))
+	for _, f := range []string{"location", "api-model", "ssh", "master-FQDN", "known-hosts", "enable", "disable"} {
+		g.Expect(subCommands[0].Flags().Lookup(f)).NotTo(BeNil())
+	}
+}
+
+func TestAddonsApplyCmdShouldBeValidated(t *testing.T) {
+	g := NewGomegaWithT(t)
+	r := &cobra.Command{}
+
+	cases := []struct {
+		ac          *addonsApplyCmd
+		expectedErr error
+	}{
+		{
+			ac: &addonsApplyCmd{
+				location:    "westus",
+				sshFilepath: "_test_ssh",
+			},
+			expectedErr: errors.New("--api-model must be specified"),
+		},
+		{
+			ac: &addonsApplyCmd{
+				apiModelPath: "./not/used",
+				location:     "westus",
+			},
+			expectedErr: errors.New("--ssh must be specified"),
+		},
+		{
+			ac: &addonsApplyCmd{
+				apiModelPath: "./not/used",
+				sshFilepath:  "_test_ssh",
+			},
+			expectedErr: errors.New("--location must be specified"),
+		},
+		{
+			ac: &addonsApplyCmd{
+				apiModelPath: "./not/used",
+				sshFilepath:  "_test_ssh",
+				location:     "westus",
+				enable:       []string{"tiller", "cluster-autoscaler"},
+				disable:      []string{"tiller"},
+			},
+			expectedErr: errors.New("addon tiller cannot be both enabled and disabled"),
+		},
+		{
+			ac: &addonsApplyCmd{
+				apiModelPath: "./not/used",
+				sshFilepath:  "_test_ssh",
+				masterFQDN:   "testcluster.westus.cloudapp.azure.com",
+				enable:       []string{"cluster-autoscaler"},
+				disable:      []string{"tiller"},
+			},
+			expectedErr: nil,
+		},
+	}
+
+	for _, c := range cases {
+		err := c.ac.validate(r)
+		if c.expectedErr != nil {
+			g.Expect(err).To(HaveOccurred())
+			g.Expect(err.Error()).To(Equal(c.expectedErr.Error()))
+		} else {
+			g.Expect(err).NotTo(HaveOccurred())
+		}
+	}
+}
+
+func TestSetAddonEnabled(t *testing.T) {
+	g := NewGomegaWithT(t)
+	kubernetesConfig := &api.KubernetesConfig{
+		Addons: []api.KubernetesAddon{
+			{
+				Name:    "tiller",
+				Enabled: to.BoolPtr(true),
+				Config:  map[string]string{"max-history": "5"},
+			},
+		},
+	}
+
+	setAddonEnabled(kubernetesConfig, "tiller", false)
+	setAddonEnabled(kubernetesConfig, "cluster-autoscaler", true)
+
+	g.Expect(kubernetesConfig.Addons).To(HaveLen(2))
+	g.Expect(kubernetesConfig.Addons[0].Enabled).To(Equal(to.BoolPtr(false)))
+	g.Expect(kubernetesConfig.Addons[0].Config).To(HaveKeyWithValue("max-history", "5"))
+	g.Expect(kubernetesConfig.Addons[1].Name).To(Equal("cluster-autoscaler"))
+	g.Expect(kubernetesConfig.Addons[1].Enabled).To(Equal(to.BoolPtr(true)))
+}
+
+func TestGetAddonsApplier(t *testing.T) {
+	g := NewGomegaWithT(t)
+	dir, err := ioutil.TempDir("", "addons")
+	g.Expect(err).NotTo(HaveOccurred())
+	defer os.RemoveAll(dir)
+	sshFilepath := filepath.Join(dir, "id_rsa")
+	g.Expect(ioutil.WriteFile(sshFilepath, []byte("key"), 0600)).To(Succeed())
+
+	cs := api.CreateMockContainerService("testcluster", "1.13.5", 3, 2, false)
+	applier, err := getAddonsApplier(cs, "westus", "", sshFilepath, "")
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(applier.MasterFQDN).To(Equal(api.FormatProdFQDNByLocation(cs.Properties.MasterProfile.DNSPrefix, "westus", "")))
+	g.Expect(applier.SSHPorts).To(Equal([]int{22, 2201, 2202}))
+	g.Expect(applier.SSHUser).To(Equal(cs.Properties.LinuxProfile.AdminUsername))
+	g.Expect(string(applier.SSHKey)).To(Equal("key"))
+
+	cs.Properties.MasterProfile = nil
+	_, err = getAddonsApplier(cs, "westus", "", sshFilepath, "")
+	g.Expect(err).To(HaveOccurred())
+	g.Expect(err.Error()).To(Equal("addons can only be applied to Kubernetes clusters with a master profile"))
+}
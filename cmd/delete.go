@@ -0,0 +1,316 @@
+// Copyright (c) Microsoft Corporation. All rights reserved.
+// Licensed under the MIT license.
+
+package cmd
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/Azure/aks-engine/pkg/api"
+	"github.com/Azure/aks-engine/pkg/armhelpers"
+	"github.com/Azure/aks-engine/pkg/i18n"
+	"github.com/Azure/aks-engine/pkg/operations/clusterdelete"
+	"github.com/leonelquinteros/gotext"
+	"github.com/pkg/errors"
+	log "github.com/sirupsen/logrus"
+	"github.com/spf13/cobra"
+)
+
+const (
+	deleteName             = "delete"
+	deleteShortDescription = "Delete an existing Kubernetes cluster"
+	deleteLongDescription  = "Delete the resource group of an existing Kubernetes cluster, or only the resources of the cluster with --cluster-resources-only, along with the role assignments of its identities and the service principal aks-engine created for it, and then the output directory of the cluster"
+	// deploymentTemplateFilename is the file identifying the output directories of aks-engine
+	deploymentTemplateFilename = "azuredeploy.json"
+)
+
+type deleteCmd struct {
+	authProvider
+
+	// user input
+	resourceGroupName    string
+	apiModelPath         string
+	clusterResourcesOnly bool
+	includeUntagged      bool
+	outputDirectory      string
+	keepOutputDirectory  bool
+	force                bool
+	// stdin and stdout are where the confirmation is asked
+	stdin  io.Reader
+	stdout io.Writer
+
+	// derived
+	containerService *api.ContainerService
+	apiVersion       string
+	client           armhelpers.AKSEngineClient
+	locale           *gotext.Locale
+	deleter          *clusterdelete.Deleter
+	// removedDirectory is the output directory of the cluster removed, if any
+	removedDirectory string
+}
+
+func newDeleteCmd() *cobra.Command {
+	dc := deleteCmd{
+		authProvider: &authArgs{},
+		stdin:        os.Stdin,
+		stdout:       os.Stdout,
+	}
+
+	command := &cobra.Command{
+		Use:   deleteName,
+		Short: deleteShortDescription,
+		Long:  deleteLongDescription,
+		RunE:  dc.run,
+	}
+
+	f := command.Flags()
+	f.StringVarP(&dc.resourceGroupName, "resource-group", "g", "", "the resource group where the cluster is deployed (required)")
+	f.StringVarP(&dc.apiModelPath, "api-model", "m", "", "path to the generated apimodel.json file (required)")
+	f.BoolVar(&dc.clusterResourcesOnly, "cluster-resources-only", false, "delete only the resources of the cluster instead of the resource group, when the group holds other resources such as a custom VNET")
+	f.BoolVar(&dc.includeUntagged, "include-untagged", false, "with --cluster-resources-only, delete the resources named after the cluster that are not tagged with its ID too, instead of asking")
+	f.StringVarP(&dc.outputDirectory, "output-directory", "o", "", "output directory of the cluster to remove (the directory of --api-model if it is _output/<dnsPrefix> and absent)")
+	f.BoolVar(&dc.keepOutputDirectory, "keep-output-directory", false, "keep the output directory of the cluster")
+	f.BoolVarP(&dc.force, "force", "f", false, "delete without asking for confirmation")
+	addAuthFlags(dc.getAuthArgs(), f)
+
+	return command
+}
+
+func (dc *deleteCmd) validate(cmd *cobra.Command) error {
+	var err error
+
+	dc.locale, err = i18n.LoadTranslations()
+	if err != nil {
+		return errors.Wrap(err, "error loading translation files")
+	}
+
+	if dc.resourceGroupName == "" {
+		cmd.Usage()
+		return errors.New("--resource-group must be specified")
+	}
+
+	if dc.apiModelPath == "" {
+		cmd.Usage()
+		return errors.New("--api-model must be specified")
+	}
+
+	if dc.outputDirectory != "" && dc.keepOutputDirectory {
+		cmd.Usage()
+		return errors.New("--output-directory and --keep-output-directory cannot both be specified")
+	}
+
+	return nil
+}
+
+func (dc *deleteCmd) loadCluster() error {
+	var err error
+
+	if _, err = os.Stat(dc.apiModelPath); os.IsNotExist(err) {
+		return errors.Errorf("specified api model does not exist (%s)", dc.apiModelPath)
+	}
+
+	apiloader := &api.Apiloader{
+		Translator: &i18n.Translator{
+			Locale: dc.locale,
+		},
+	}
+	dc.containerService, dc.apiVersion, err = apiloader.LoadContainerServiceFromFile(dc.apiModelPath, true, true, nil)
+	if err != nil {
+		return errors.Wrap(err, "error parsing the api model")
+	}
+
+	if dc.containerService.Properties.IsAzureStackCloud() {
+		writeCustomCloudProfile(dc.containerService)
+		err = dc.containerService.Properties.SetAzureStackCloudSpec()
+		if err != nil {
+			return errors.Wrap(err, "error parsing the api model")
+		}
+	}
+
+	if err = dc.getAuthArgs().validateAuthArgs(); err != nil {
+		return err
+	}
+
+	if dc.client, err = dc.authProvider.getClient(); err != nil {
+		return errors.Wrap(err, "failed to get client")
+	}
+
+	dc.deleter = getDeleter(dc.containerService, dc.client, dc.getAuthArgs().SubscriptionID.String(), dc.resourceGroupName, dc.clusterResourcesOnly)
+	dc.deleter.IncludeCandidates = dc.includeUntagged
+	if !dc.keepOutputDirectory {
+		if dc.removedDirectory, err = getOutputDirectory(dc.apiModelPath, dc.outputDirectory, getDNSPrefix(dc.containerService)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (dc *deleteCmd) run(cmd *cobra.Command, args []string) error {
+	if err := dc.validate(cmd); err != nil {
+		return errors.Wrap(err, "validating delete command")
+	}
+	if err := dc.loadCluster(); err != nil {
+		return errors.Wrap(err, "loading existing cluster")
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), armhelpers.DefaultARMOperationTimeout)
+	defer cancel()
+
+	plan, err := dc.deleter.Plan(ctx)
+	if err != nil {
+		return errors.Wrap(err, "listing what to delete")
+	}
+	stdin := bufio.NewReader(dc.stdin)
+	if len(plan.Candidates) > 0 && !dc.force {
+		printCandidates(dc.stdout, plan.Candidates)
+		included, err := confirm(stdin, dc.stdout, "Delete them with the cluster? [y/N]: ")
+		if err != nil {
+			return err
+		}
+		if included {
+			dc.deleter.IncludeCandidates = true
+			if plan, err = dc.deleter.Plan(ctx); err != nil {
+				return errors.Wrap(err, "listing what to delete")
+			}
+		}
+	}
+	printDeletePlan(dc.stdout, plan, dc.removedDirectory)
+	if !dc.force {
+		confirmed, err := confirm(stdin, dc.stdout, "Are you sure you want to delete the cluster? [y/N]: ")
+		if err != nil {
+			return err
+		}
+		if !confirmed {
+			log.Infoln("Deletion cancelled")
+			return nil
+		}
+	}
+
+	if err = dc.deleter.Delete(ctx, plan); err != nil {
+		return err
+	}
+
+	if dc.removedDirectory != "" {
+		log.Infof("Removing output directory %s", dc.removedDirectory)
+		if err = os.RemoveAll(dc.removedDirectory); err != nil {
+			return errors.Wrapf(err, "removing output directory %s", dc.removedDirectory)
+		}
+	}
+	return nil
+}
+
+// getDeleter returns a Deleter deleting the cluster of cs, and its service principal if aks-engine created it
+func getDeleter(cs *api.ContainerService, client armhelpers.AKSEngineClient, subscriptionID, resourceGroup string, clusterResourcesOnly bool) *clusterdelete.Deleter {
+	properties := cs.Properties
+	deleter := &clusterdelete.Deleter{
+		Client:               client,
+		Logger:               log.NewEntry(log.New()),
+		SubscriptionID:       subscriptionID,
+		ResourceGroup:        resourceGroup,
+		ClusterResourcesOnly: clusterResourcesOnly,
+		ClusterID:            properties.GetClusterID(),
+	}
+	if properties.OrchestratorProfile != nil && properties.OrchestratorProfile.KubernetesConfig != nil &&
+		properties.OrchestratorProfile.KubernetesConfig.UserAssignedIDEnabled() {
+		deleter.ResourceNames = append(deleter.ResourceNames, properties.OrchestratorProfile.KubernetesConfig.UserAssignedID)
+	}
+	if spp := properties.ServicePrincipalProfile; spp != nil && spp.ApplicationObjectID != "" {
+		deleter.ServicePrincipalObjectID = spp.ObjectID
+		deleter.ApplicationObjectID = spp.ApplicationObjectID
+		if properties.MasterProfile != nil {
+			deleter.ApplicationName = properties.MasterProfile.DNSPrefix
+		}
+	}
+	return deleter
+}
+
+// getDNSPrefix returns the DNS prefix of the cluster, its output directory being _output/<dnsPrefix> by default
+func getDNSPrefix(cs *api.ContainerService) string {
+	if cs.Properties.MasterProfile != nil {
+		return cs.Properties.MasterProfile.DNSPrefix
+	}
+	if cs.Properties.HostedMasterProfile != nil {
+		return cs.Properties.HostedMasterProfile.DNSPrefix
+	}
+	return ""
+}
+
+// getOutputDirectory returns the output directory of the cluster to remove: outputDirectory if set, or else the
+// directory of the api model if it is the _output/<dnsPrefix> directory aks-engine generates. It must hold the
+// deployment template aks-engine writes to the output directories.
+func getOutputDirectory(apiModelPath, outputDirectory, dnsPrefix string) (string, error) {
+	if outputDirectory != "" {
+		if _, err := os.Stat(filepath.Join(outputDirectory, deploymentTemplateFilename)); err != nil {
+			return "", errors.Errorf("--output-directory %s is not an output directory of aks-engine, it has no %s", outputDirectory, deploymentTemplateFilename)
+		}
+		return outputDirectory, nil
+	}
+
+	dir, err := filepath.Abs(filepath.Dir(apiModelPath))
+	if err != nil || dnsPrefix == "" || filepath.Base(dir) != dnsPrefix || filepath.Base(filepath.Dir(dir)) != "_output" {
+		log.Infof("Keeping the directory of the api model, which is not the _output/%s directory aks-engine generates, use --output-directory to remove it", dnsPrefix)
+		return "", nil
+	}
+	if _, err := os.Stat(filepath.Join(dir, deploymentTemplateFilename)); err != nil {
+		return "", nil
+	}
+	return filepath.Dir(apiModelPath), nil
+}
+
+// printDeletePlan writes what plan deletes to w, and the output directory removed if any
+func printDeletePlan(w io.Writer, plan *clusterdelete.Plan, outputDirectory string) {
+	fmt.Fprintln(w, "The following will be deleted:")
+	if plan.ResourceGroup != "" {
+		fmt.Fprintf(w, "  resource group %s, with its resources:\n", plan.ResourceGroup)
+	} else {
+		fmt.Fprintln(w, "  resources:")
+	}
+	for _, group := range plan.Resources {
+		for _, id := range group {
+			fmt.Fprintf(w, "    %s\n", id)
+		}
+	}
+	if len(plan.RoleAssignments) > 0 {
+		fmt.Fprintln(w, "  role assignments:")
+		for _, id := range plan.RoleAssignments {
+			fmt.Fprintf(w, "    %s\n", id)
+		}
+	}
+	if plan.ApplicationObjectID != "" {
+		fmt.Fprintf(w, "  application %s and its service principal\n", plan.ApplicationObjectID)
+	}
+	if outputDirectory != "" {
+		fmt.Fprintf(w, "  output directory %s\n", outputDirectory)
+	}
+	if len(plan.Candidates) > 0 {
+		fmt.Fprintln(w, "The following resources named after the cluster will not be deleted:")
+		for _, id := range plan.Candidates {
+			fmt.Fprintf(w, "    %s\n", id)
+		}
+	}
+}
+
+// printCandidates writes the candidates of a plan to w, before asking whether to delete them
+func printCandidates(w io.Writer, candidates []string) {
+	fmt.Fprintln(w, "The following resources are named after the cluster, but are not tagged with its ID:")
+	for _, id := range candidates {
+		fmt.Fprintf(w, "    %s\n", id)
+	}
+}
+
+// confirm asks question on out, and returns whether the answer read from in is yes
+func confirm(in *bufio.Reader, out io.Writer, question string) (bool, error) {
+	fmt.Fprint(out, question)
+	answer, err := in.ReadString('\n')
+	if err != nil && err != io.EOF {
+		return false, errors.Wrap(err, "reading the confirmation")
+	}
+	answer = strings.ToLower(strings.TrimSpace(answer))
+	return answer == "y" || answer == "yes", nil
+}
@@ -0,0 +1,302 @@
+// Copyright (c) Microsoft Corporation. All rights reserved.
+// Licensed under the MIT license.
+
+package cmd
+
+import (
+	"bufio"
+	"bytes"
+	"io/ioutil"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/Azure/aks-engine/pkg/api"
+	"github.com/Azure/aks-engine/pkg/armhelpers"
+	"github.com/Azure/aks-engine/pkg/i18n"
+	"github.com/Azure/aks-engine/pkg/operations/clusterdelete"
+	"github.com/Azure/azure-sdk-for-go/services/resources/mgmt/2018-05-01/resources"
+	"github.com/Azure/go-autorest/autorest/to"
+	. "github.com/onsi/gomega"
+	"github.com/pkg/errors"
+	"github.com/spf13/cobra"
+)
+
+func TestNewDeleteCmd(t *testing.T) {
+	g := NewGomegaWithT(t)
+	command := newDeleteCmd()
+
+	g.Expect(command.Use).Should(Equal(deleteName))
+	g.Expect(command.Short).Should(Equal(deleteShortDescription))
+	g.Expect(command.Long).Should(Equal(deleteLongDescription))
+	for _, f := range []string{"resource-group", "api-model", "cluster-resources-only", "include-untagged", "output-directory", "keep-output-directory", "force", "subscription-id", "auth-method"} {
+		g.Expect(command.Flags().Lookup(f)).NotTo(BeNil())
+	}
+}
+
+func TestDeleteCmdShouldBeValidated(t *testing.T) {
+	g := NewGomegaWithT(t)
+	r := &cobra.Command{}
+
+	cases := []struct {
+		dc          *deleteCmd
+		expectedErr error
+	}{
+		{
+			dc:          &deleteCmd{apiModelPath: "./not/used"},
+			expectedErr: errors.New("--resource-group must be specified"),
+		},
+		{
+			dc:          &deleteCmd{resourceGroupName: "rg"},
+			expectedErr: errors.New("--api-model must be specified"),
+		},
+		{
+			dc:          &deleteCmd{resourceGroupName: "rg", apiModelPath: "./not/used", outputDirectory: "_output/testcluster", keepOutputDirectory: true},
+			expectedErr: errors.New("--output-directory and --keep-output-directory cannot both be specified"),
+		},
+	}
+
+	for _, c := range cases {
+		err := c.dc.validate(r)
+		g.Expect(err).To(HaveOccurred())
+		g.Expect(err.Error()).To(Equal(c.expectedErr.Error()))
+	}
+
+	dc := &deleteCmd{resourceGroupName: "rg", apiModelPath: "./not/used"}
+	g.Expect(dc.validate(r)).To(Succeed())
+}
+
+// testDNSPrefix is the DNS prefix of the api model of newTestOutputDirectory
+const testDNSPrefix = "kubernetes-southcentralus-7764"
+
+// newTestOutputDirectory returns the _output/<dnsPrefix> output directory with the api model of a cluster, in a
+// temporary directory to remove
+func newTestOutputDirectory(g *GomegaWithT) (string, string) {
+	tmp, err := ioutil.TempDir("", "delete")
+	g.Expect(err).NotTo(HaveOccurred())
+	dir := filepath.Join(tmp, "_output", testDNSPrefix)
+	g.Expect(os.MkdirAll(dir, 0700)).To(Succeed())
+	apiModel, err := ioutil.ReadFile("../pkg/engine/testdata/key-vault-certs/kubernetes.json")
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(ioutil.WriteFile(filepath.Join(dir, apiModelFilename), apiModel, 0600)).To(Succeed())
+	g.Expect(ioutil.WriteFile(filepath.Join(dir, deploymentTemplateFilename), []byte("{}"), 0600)).To(Succeed())
+	return tmp, dir
+}
+
+func newTestDeleteCmd(dir, answer string) (*deleteCmd, *bytes.Buffer) {
+	var out bytes.Buffer
+	dc := &deleteCmd{
+		authProvider: &mockAuthProvider{
+			authArgs: &authArgs{
+				RawAzureEnvironment: "AzurePublicCloud",
+				rawSubscriptionID:   "6dc93fae-9a76-421f-bbe5-cc6460ea81cb",
+				AuthMethod:          "cli",
+			},
+			getClientMock: &armhelpers.MockAKSEngineClient{},
+		},
+		resourceGroupName: "rg",
+		apiModelPath:      filepath.Join(dir, apiModelFilename),
+		stdin:             strings.NewReader(answer),
+		stdout:            &out,
+	}
+	return dc, &out
+}
+
+func TestDeleteCmdRun(t *testing.T) {
+	g := NewGomegaWithT(t)
+	tmp, dir := newTestOutputDirectory(g)
+	defer os.RemoveAll(tmp)
+
+	dc, out := newTestDeleteCmd(dir, "y\n")
+	g.Expect(dc.run(&cobra.Command{}, []string{})).To(Succeed())
+	g.Expect(out.String()).To(ContainSubstring("resource group rg, with its resources:"))
+	g.Expect(out.String()).To(ContainSubstring("output directory " + dir))
+	_, err := os.Stat(dir)
+	g.Expect(os.IsNotExist(err)).To(BeTrue())
+}
+
+func TestDeleteCmdRunShouldAskForConfirmation(t *testing.T) {
+	g := NewGomegaWithT(t)
+	tmp, dir := newTestOutputDirectory(g)
+	defer os.RemoveAll(tmp)
+
+	dc, out := newTestDeleteCmd(dir, "n\n")
+	g.Expect(dc.run(&cobra.Command{}, []string{})).To(Succeed())
+	g.Expect(out.String()).To(HaveSuffix("Are you sure you want to delete the cluster? [y/N]: "))
+	_, err := os.Stat(dir)
+	g.Expect(err).NotTo(HaveOccurred())
+
+	dc, out = newTestDeleteCmd(dir, "")
+	dc.force = true
+	dc.keepOutputDirectory = true
+	g.Expect(dc.run(&cobra.Command{}, []string{})).To(Succeed())
+	g.Expect(out.String()).NotTo(ContainSubstring("Are you sure"))
+	_, err = os.Stat(dir)
+	g.Expect(err).NotTo(HaveOccurred())
+}
+
+func TestDeleteCmdRunShouldAskForTheUntaggedResources(t *testing.T) {
+	g := NewGomegaWithT(t)
+	tmp, dir := newTestOutputDirectory(g)
+	defer os.RemoveAll(tmp)
+	apiloader := &api.Apiloader{Translator: &i18n.Translator{}}
+	cs, _, err := apiloader.LoadContainerServiceFromFile(filepath.Join(dir, apiModelFilename), true, true, nil)
+	g.Expect(err).NotTo(HaveOccurred())
+	lb := "/subscriptions/sub/resourceGroups/rg/providers/Microsoft.Network/loadBalancers/k8s-master-lb-" + cs.Properties.GetClusterID()
+	client := &armhelpers.MockAKSEngineClient{
+		FakeListResourcesResult: func() []resources.GenericResource {
+			return []resources.GenericResource{{ID: to.StringPtr(lb), Name: to.StringPtr(path.Base(lb))}}
+		},
+	}
+
+	dc, out := newTestDeleteCmd(dir, "n\nn\n")
+	dc.clusterResourcesOnly = true
+	dc.authProvider.(*mockAuthProvider).getClientMock = client
+	g.Expect(dc.run(&cobra.Command{}, []string{})).To(Succeed())
+	g.Expect(out.String()).To(HavePrefix("The following resources are named after the cluster, but are not tagged with its ID:\n    " + lb + "\nDelete them with the cluster? [y/N]: "))
+	g.Expect(out.String()).To(ContainSubstring("The following resources named after the cluster will not be deleted:\n    " + lb + "\n"))
+
+	dc, out = newTestDeleteCmd(dir, "y\nn\n")
+	dc.clusterResourcesOnly = true
+	dc.authProvider.(*mockAuthProvider).getClientMock = client
+	g.Expect(dc.run(&cobra.Command{}, []string{})).To(Succeed())
+	g.Expect(out.String()).To(ContainSubstring("  resources:\n    " + lb + "\n"))
+	g.Expect(out.String()).NotTo(ContainSubstring("will not be deleted"))
+
+	// the untagged resources are never deleted without asking
+	dc, out = newTestDeleteCmd(dir, "")
+	dc.clusterResourcesOnly = true
+	dc.force = true
+	dc.keepOutputDirectory = true
+	dc.authProvider.(*mockAuthProvider).getClientMock = client
+	g.Expect(dc.run(&cobra.Command{}, []string{})).To(Succeed())
+	g.Expect(out.String()).To(ContainSubstring("will not be deleted"))
+}
+
+func TestDeleteCmdRunShouldKeepTheOutputDirectoryOnFailure(t *testing.T) {
+	g := NewGomegaWithT(t)
+	tmp, dir := newTestOutputDirectory(g)
+	defer os.RemoveAll(tmp)
+
+	dc, _ := newTestDeleteCmd(dir, "")
+	dc.force = true
+	dc.authProvider.(*mockAuthProvider).getClientMock = &armhelpers.MockAKSEngineClient{FailDeleteResourceGroup: true}
+	err := dc.run(&cobra.Command{}, []string{})
+	g.Expect(err).To(HaveOccurred())
+	g.Expect(err.Error()).To(Equal("deleting the cluster: deleting resource group rg: DeleteResourceGroup failed"))
+	_, err = os.Stat(dir)
+	g.Expect(err).NotTo(HaveOccurred())
+}
+
+func TestGetDeleter(t *testing.T) {
+	g := NewGomegaWithT(t)
+	client := &armhelpers.MockAKSEngineClient{}
+	cs := api.CreateMockContainerService("testcluster", "1.13.5", 3, 2, false)
+	cs.Properties.ServicePrincipalProfile = &api.ServicePrincipalProfile{ClientID: "client-id", Secret: "secret", ObjectID: "sp-object-id"}
+
+	deleter := getDeleter(cs, client, "sub", "rg", true)
+	g.Expect(deleter.Client).To(Equal(client))
+	g.Expect(deleter.SubscriptionID).To(Equal("sub"))
+	g.Expect(deleter.ResourceGroup).To(Equal("rg"))
+	g.Expect(deleter.ClusterResourcesOnly).To(BeTrue())
+	g.Expect(deleter.ClusterID).To(Equal(cs.Properties.GetClusterID()))
+	g.Expect(deleter.ResourceNames).To(BeEmpty())
+	// the service principal was not created by aks-engine
+	g.Expect(deleter.ApplicationObjectID).To(BeEmpty())
+
+	cs.Properties.ServicePrincipalProfile.ApplicationObjectID = "app-object-id"
+	cs.Properties.OrchestratorProfile.KubernetesConfig.UseManagedIdentity = true
+	cs.Properties.OrchestratorProfile.KubernetesConfig.UserAssignedID = "testcluster-identity"
+	deleter = getDeleter(cs, client, "sub", "rg", false)
+	g.Expect(deleter.ResourceNames).To(Equal([]string{"testcluster-identity"}))
+	g.Expect(deleter.ServicePrincipalObjectID).To(Equal("sp-object-id"))
+	g.Expect(deleter.ApplicationObjectID).To(Equal("app-object-id"))
+	g.Expect(deleter.ApplicationName).To(Equal(cs.Properties.MasterProfile.DNSPrefix))
+}
+
+func TestGetOutputDirectory(t *testing.T) {
+	g := NewGomegaWithT(t)
+	tmp, dir := newTestOutputDirectory(g)
+	defer os.RemoveAll(tmp)
+
+	outputDirectory, err := getOutputDirectory(filepath.Join(dir, apiModelFilename), "", testDNSPrefix)
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(outputDirectory).To(Equal(dir))
+
+	// only the _output/<dnsPrefix> directories are removed by default
+	for _, dnsPrefix := range []string{"other", ""} {
+		outputDirectory, err = getOutputDirectory(filepath.Join(dir, apiModelFilename), "", dnsPrefix)
+		g.Expect(err).NotTo(HaveOccurred())
+		g.Expect(outputDirectory).To(BeEmpty())
+	}
+	outputDirectory, err = getOutputDirectory(filepath.Join(tmp, apiModelFilename), "", filepath.Base(tmp))
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(outputDirectory).To(BeEmpty())
+
+	outputDirectory, err = getOutputDirectory(filepath.Join(tmp, apiModelFilename), dir, testDNSPrefix)
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(outputDirectory).To(Equal(dir))
+	_, err = getOutputDirectory(filepath.Join(dir, apiModelFilename), tmp, testDNSPrefix)
+	g.Expect(err).To(HaveOccurred())
+	g.Expect(err.Error()).To(Equal("--output-directory " + tmp + " is not an output directory of aks-engine, it has no azuredeploy.json"))
+
+	g.Expect(os.Remove(filepath.Join(dir, deploymentTemplateFilename))).To(Succeed())
+	outputDirectory, err = getOutputDirectory(filepath.Join(dir, apiModelFilename), "", testDNSPrefix)
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(outputDirectory).To(BeEmpty())
+}
+
+func TestPrintDeletePlan(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	var out bytes.Buffer
+	printDeletePlan(&out, &clusterdelete.Plan{
+		Resources:           [][]string{{"vm-1", "vm-2"}, {"lb"}},
+		Candidates:          []string{"nsg"},
+		RoleAssignments:     []string{"role-assignment"},
+		ApplicationObjectID: "app-object-id",
+	}, "_output/testcluster")
+	g.Expect(out.String()).To(Equal(`The following will be deleted:
+  resources:
+    vm-1
+    vm-2
+    lb
+  role assignments:
+    role-assignment
+  application app-object-id and its service principal
+  output directory _output/testcluster
+The following resources named after the cluster will not be deleted:
+    nsg
+`))
+
+	out.Reset()
+	printDeletePlan(&out, &clusterdelete.Plan{ResourceGroup: "rg", Resources: [][]string{{"vm-1"}}}, "")
+	g.Expect(out.String()).To(Equal(`The following will be deleted:
+  resource group rg, with its resources:
+    vm-1
+`))
+}
+
+func TestConfirm(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	for answer, expected := range map[string]bool{"y\n": true, "Yes\n": true, " y ": true, "n\n": false, "\n": false, "": false, "yep\n": false} {
+		var out bytes.Buffer
+		confirmed, err := confirm(bufio.NewReader(strings.NewReader(answer)), &out, "Are you sure? [y/N]: ")
+		g.Expect(err).NotTo(HaveOccurred())
+		g.Expect(confirmed).To(Equal(expected), "answer %q", answer)
+		g.Expect(out.String()).To(Equal("Are you sure? [y/N]: "))
+	}
+
+	// the answers are read one line after the other
+	in := bufio.NewReader(strings.NewReader("n\ny\n"))
+	var out bytes.Buffer
+	confirmed, err := confirm(in, &out, "")
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(confirmed).To(BeFalse())
+	confirmed, err = confirm(in, &out, "")
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(confirmed).To(BeTrue())
+}
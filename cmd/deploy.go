@@ -28,6 +28,7 @@ import (
 	"github.com/Azure/aks-engine/pkg/helpers"
 	"github.com/Azure/aks-engine/pkg/i18n"
 	"github.com/Azure/azure-sdk-for-go/services/graphrbac/1.6/graphrbac"
+	azStorage "github.com/Azure/azure-sdk-for-go/storage"
 	"github.com/Azure/go-autorest/autorest/to"
 	"github.com/pkg/errors"
 )
@@ -36,6 +37,10 @@ const (
 	deployName             = "deploy"
 	deployShortDescription = "Deploy an Azure Resource Manager template"
 	deployLongDescription  = "Deploy an Azure Resource Manager template, parameters file and other assets for a cluster"
+	// extensionsContainerName is the prefix of the container of the storage account the files of extensions are uploaded to
+	extensionsContainerName = "extensions"
+	// defaultExtensionsSASHours is how long the SAS token of the uploaded extensions is valid by default, a week
+	defaultExtensionsSASHours = 168
 )
 
 type deployCmd struct {
@@ -49,6 +54,10 @@ type deployCmd struct {
 	caPrivateKeyPath  string
 	parametersOnly    bool
 	set               []string
+	// extensionsStorageAccount is the storage account in the resource group extensions are uploaded to
+	extensionsStorageAccount string
+	// extensionsSASHours is how long the SAS token to download the uploaded extensions is valid, in hours
+	extensionsSASHours int
 
 	// derived
 	containerService *api.ContainerService
@@ -98,6 +107,8 @@ func newDeployCmd() *cobra.Command {
 	f.StringVarP(&dc.location, "location", "l", "", "location to deploy to (required)")
 	f.BoolVarP(&dc.forceOverwrite, "force-overwrite", "f", false, "automatically overwrite existing files in the output directory")
 	f.StringArrayVar(&dc.set, "set", []string{}, "set values on the command line (can specify multiple or separate values with commas: key1=val1,key2=val2)")
+	f.StringVar(&dc.extensionsStorageAccount, "extensions-storage-account", "", "storage account in the resource group to upload the extensions with upload set to")
+	f.IntVar(&dc.extensionsSASHours, "extensions-sas-hours", defaultExtensionsSASHours, "how long the uploaded extensions can be downloaded after the deployment, in hours")
 
 	addAuthFlags(dc.getAuthArgs(), f)
 
@@ -340,9 +351,10 @@ func autofillApimodel(dc *deployCmd) error {
 			}
 
 			dc.containerService.Properties.ServicePrincipalProfile = &api.ServicePrincipalProfile{
-				ClientID: applicationID,
-				Secret:   secret,
-				ObjectID: servicePrincipalObjectID,
+				ClientID:            applicationID,
+				Secret:              secret,
+				ObjectID:            servicePrincipalObjectID,
+				ApplicationObjectID: to.String(applicationResp.ObjectID),
 			}
 		} else if (dc.containerService.Properties.ServicePrincipalProfile == nil || ((dc.containerService.Properties.ServicePrincipalProfile.ClientID == "" || dc.containerService.Properties.ServicePrincipalProfile.ClientID == "00000000-0000-0000-0000-000000000000") && dc.containerService.Properties.ServicePrincipalProfile.Secret == "")) && dc.getAuthArgs().ClientID.String() != "" && dc.getAuthArgs().ClientSecret != "" {
 			dc.containerService.Properties.ServicePrincipalProfile = &api.ServicePrincipalProfile{
@@ -395,6 +407,10 @@ func (dc *deployCmd) run() error {
 		return errors.Wrapf(err, "in SetPropertiesDefaults template %s", dc.apimodelPath)
 	}
 
+	if err = dc.uploadExtensions(); err != nil {
+		return errors.Wrap(err, "uploading extensions")
+	}
+
 	template, parameters, err := templateGenerator.GenerateTemplateV2(dc.containerService, engine.DefaultGeneratorCode, BuildTag)
 	if err != nil {
 		return errors.Wrapf(err, "generating template %s", dc.apimodelPath)
@@ -449,3 +465,57 @@ func (dc *deployCmd) run() error {
 
 	return nil
 }
+
+// uploadExtensions uploads the files of the local and OCI extensions with upload set to a private container of the
+// extensions storage account named after the DNS prefix of the cluster, and points the extensions at the uploaded files.
+// Azure Resource Manager and the nodes download them with a read-only SAS token of the container, which is only valid
+// for extensionsSASHours: SAS tokens cannot be limited to a blob prefix, hence a container per cluster.
+func (dc *deployCmd) uploadExtensions() error {
+	ctx, cancel := context.WithTimeout(context.Background(), armhelpers.DefaultARMOperationTimeout)
+	defer cancel()
+	var storageClient armhelpers.AKSStorageClient
+	var sasToken string
+	containerName := extensionsContainerName + "-" + strings.ToLower(dc.containerService.Properties.MasterProfile.DNSPrefix)
+	for _, extension := range dc.containerService.Properties.ExtensionProfiles {
+		if !extension.Upload {
+			continue
+		}
+		if dc.extensionsStorageAccount == "" {
+			return errors.Errorf("--extensions-storage-account must be specified to upload extension %s", extension.Name)
+		}
+		if dc.extensionsSASHours <= 0 {
+			return errors.Errorf("--extensions-sas-hours must be positive to upload extension %s", extension.Name)
+		}
+		files, err := engine.GetExtensionFiles(extension)
+		if err != nil {
+			return err
+		}
+
+		if storageClient == nil {
+			if storageClient, err = dc.client.GetStorageClient(ctx, dc.resourceGroup, dc.extensionsStorageAccount); err != nil {
+				return errors.Wrapf(err, "getting a client of storage account %s", dc.extensionsStorageAccount)
+			}
+			if _, err = storageClient.CreateContainer(containerName, &azStorage.CreateContainerOptions{Access: azStorage.ContainerAccessTypePrivate}); err != nil {
+				return errors.Wrapf(err, "creating container %s", containerName)
+			}
+			expiry := time.Now().Add(time.Duration(dc.extensionsSASHours) * time.Hour)
+			if sasToken, err = storageClient.GetContainerSASToken(containerName, expiry); err != nil {
+				return errors.Wrapf(err, "getting a SAS token of container %s", containerName)
+			}
+		}
+
+		for fileName, contents := range files {
+			blobName := path.Join("extensions", extension.Name, extension.Version, fileName)
+			log.Infof("Uploading %s to container %s of storage account %s", blobName, containerName, dc.extensionsStorageAccount)
+			if err = storageClient.SaveBlockBlob(containerName, blobName, contents, nil); err != nil {
+				return errors.Wrapf(err, "uploading %s", blobName)
+			}
+		}
+		extension.RootURL = storageClient.GetContainerURL(containerName) + "/"
+		extension.URLQuery = sasToken
+		extension.LocalPath = ""
+		extension.OCIReference = ""
+		extension.Upload = false
+	}
+	return nil
+}
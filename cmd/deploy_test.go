@@ -5,14 +5,20 @@ package cmd
 
 import (
 	"fmt"
+	"io/ioutil"
+	"net/url"
 	"path"
+	"path/filepath"
 	"strconv"
+	"strings"
 	"testing"
+	"time"
 
 	"os"
 
 	"github.com/Azure/aks-engine/pkg/api"
 	"github.com/Azure/aks-engine/pkg/armhelpers"
+	azStorage "github.com/Azure/azure-sdk-for-go/storage"
 	"github.com/gofrs/uuid"
 	"github.com/pkg/errors"
 	"github.com/spf13/cobra"
@@ -97,7 +103,7 @@ func TestNewDeployCmd(t *testing.T) {
 		t.Fatalf("deploy command should have use %s equal %s, short %s equal %s and long %s equal to %s", command.Use, deployName, command.Short, deployShortDescription, command.Long, versionLongDescription)
 	}
 
-	expectedFlags := []string{"api-model", "dns-prefix", "auto-suffix", "output-directory", "ca-private-key-path", "resource-group", "location", "force-overwrite"}
+	expectedFlags := []string{"api-model", "dns-prefix", "auto-suffix", "output-directory", "ca-private-key-path", "resource-group", "location", "force-overwrite", "extensions-storage-account", "extensions-sas-hours"}
 	for _, f := range expectedFlags {
 		if command.Flags().Lookup(f) == nil {
 			t.Fatalf("deploy command should have flag %s", f)
@@ -505,6 +511,14 @@ func testAutodeployCredentialHandling(t *testing.T, useManagedIdentity bool, cli
 			cs.Properties.ServicePrincipalProfile.ClientID == "" || cs.Properties.ServicePrincipalProfile.Secret == "" {
 			t.Fatalf("Credentials were missing even though MSI was not active.")
 		}
+		// the application is recorded only when it was created
+		expectedApplicationObjectID := ""
+		if clientID == "" {
+			expectedApplicationObjectID = "app-object-id"
+		}
+		if cs.Properties.ServicePrincipalProfile.ApplicationObjectID != expectedApplicationObjectID {
+			t.Fatalf("expected application object ID %q but got %q", expectedApplicationObjectID, cs.Properties.ServicePrincipalProfile.ApplicationObjectID)
+		}
 	}
 }
 
@@ -586,6 +600,104 @@ func TestDeployCmdRun(t *testing.T) {
 	}
 }
 
+func TestDeployCmdUploadExtensions(t *testing.T) {
+	dir, err := ioutil.TempDir("", "extensions")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	extensionDir := filepath.Join(dir, "extensions", "hello", "v1")
+	if err = os.MkdirAll(extensionDir, 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err = ioutil.WriteFile(filepath.Join(extensionDir, "hello.sh"), []byte("echo hello"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	cs := api.CreateMockContainerService("testcluster", "1.13.5", 3, 2, false)
+	cs.Properties.ExtensionProfiles = []*api.ExtensionProfile{
+		{
+			Name:      "hello",
+			Version:   "v1",
+			LocalPath: dir,
+			Upload:    true,
+			Checksums: map[string]string{"hello.sh": "584a331fd6b02dcb1ecbe2eba731f609a2e1e3dac0bb73ae998dfad14c309a77"},
+		},
+		{
+			Name:    "remote",
+			Version: "v1",
+			RootURL: "https://mystorageaccount.blob.core.windows.net/",
+		},
+	}
+	storageClient := &armhelpers.MockStorageClient{}
+	d := &deployCmd{
+		client:           &armhelpers.MockAKSEngineClient{MockStorageClient: storageClient},
+		containerService: cs,
+		resourceGroup:    "rg",
+	}
+
+	err = d.uploadExtensions()
+	expected := "--extensions-storage-account must be specified to upload extension hello"
+	if err == nil || err.Error() != expected {
+		t.Fatalf("expected error %q, but got %v", expected, err)
+	}
+
+	d.extensionsStorageAccount = "fakestorageaccount"
+	err = d.uploadExtensions()
+	expected = "--extensions-sas-hours must be positive to upload extension hello"
+	if err == nil || err.Error() != expected {
+		t.Fatalf("expected error %q, but got %v", expected, err)
+	}
+
+	d.extensionsSASHours = defaultExtensionsSASHours
+	if err = d.uploadExtensions(); err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	containerName := "extensions-" + strings.ToLower(cs.Properties.MasterProfile.DNSPrefix)
+	if options, ok := storageClient.CreatedContainers[containerName]; !ok || options.Access != azStorage.ContainerAccessTypePrivate {
+		t.Errorf("expected private container %s to be created, but got %v", containerName, storageClient.CreatedContainers)
+	}
+	blobName := containerName + "/extensions/hello/v1/hello.sh"
+	if len(storageClient.SavedBlobs) != 1 || string(storageClient.SavedBlobs[blobName]) != "echo hello" {
+		t.Errorf("expected %s to be uploaded, but got %v", blobName, storageClient.SavedBlobs)
+	}
+	extension := cs.Properties.ExtensionProfiles[0]
+	expectedRootURL := "https://fakestorageaccount.blob.core.windows.net/" + containerName + "/"
+	if extension.RootURL != expectedRootURL || extension.LocalPath != "" || extension.Upload {
+		t.Errorf("expected extension hello to point at %s, but got %+v", expectedRootURL, extension)
+	}
+	query, err := url.ParseQuery(extension.URLQuery)
+	if err != nil || query.Get("sp") != "r" || query.Get("sr") != "c" {
+		t.Errorf("expected extension hello to have a read-only container SAS token, but got %q", extension.URLQuery)
+	}
+	if expiry, err := time.Parse(time.RFC3339, query.Get("se")); err != nil || expiry.Before(time.Now().Add(167*time.Hour)) {
+		t.Errorf("expected the SAS token of extension hello to expire in %d hours, but got %q", defaultExtensionsSASHours, query.Get("se"))
+	}
+	if cs.Properties.ExtensionProfiles[1].URLQuery != "" {
+		t.Errorf("expected extension remote not to get a SAS token, but got %q", cs.Properties.ExtensionProfiles[1].URLQuery)
+	}
+	if cs.Properties.ExtensionProfiles[1].RootURL != "https://mystorageaccount.blob.core.windows.net/" {
+		t.Errorf("expected extension remote not to be changed, but got %+v", cs.Properties.ExtensionProfiles[1])
+	}
+
+	cs.Properties.ExtensionProfiles = []*api.ExtensionProfile{
+		{
+			Name:      "hello",
+			Version:   "v1",
+			LocalPath: dir,
+			Upload:    true,
+			Checksums: map[string]string{"hello.sh": "0000000000000000000000000000000000000000000000000000000000000000"},
+		},
+	}
+	storageClient.SavedBlobs = nil
+	if err = d.uploadExtensions(); err == nil {
+		t.Error("expected an error uploading a file with an invalid checksum")
+	}
+	if len(storageClient.SavedBlobs) != 0 {
+		t.Errorf("expected nothing to be uploaded, but got %v", storageClient.SavedBlobs)
+	}
+}
+
 func TestOutputDirectoryWithDNSPrefix(t *testing.T) {
 	apiloader := &api.Apiloader{
 		Translator: nil,
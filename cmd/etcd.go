@@ -0,0 +1,318 @@
+// Copyright (c) Microsoft Corporation. All rights reserved.
+// Licensed under the MIT license.
+
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/Azure/aks-engine/pkg/api"
+	"github.com/Azure/aks-engine/pkg/armhelpers"
+	"github.com/Azure/aks-engine/pkg/helpers"
+	"github.com/Azure/aks-engine/pkg/i18n"
+	"github.com/Azure/aks-engine/pkg/operations"
+	"github.com/Azure/aks-engine/pkg/operations/etcdbackup"
+	"github.com/leonelquinteros/gotext"
+	"github.com/pkg/errors"
+	log "github.com/sirupsen/logrus"
+	"github.com/spf13/cobra"
+	flag "github.com/spf13/pflag"
+)
+
+const (
+	etcdName                    = "etcd"
+	etcdShortDescription        = "Back up and restore etcd on an existing Kubernetes cluster"
+	etcdLongDescription         = "Take snapshots of the etcd cluster running on the masters of a cluster built with AKS Engine, and rebuild the etcd cluster from a snapshot"
+	etcdBackupName              = "backup"
+	etcdBackupShortDescription  = "Take a snapshot of etcd"
+	etcdBackupLongDescription   = "Take a snapshot of etcd from a healthy member over SSH, and save it to a local file or to a storage account"
+	etcdRestoreName             = "restore"
+	etcdRestoreShortDescription = "Restore etcd from a snapshot"
+	etcdRestoreLongDescription  = "Stop the API servers and etcd on all the masters, rebuild every etcd member from a snapshot taken with 'etcd backup' and start them again. All the cluster changes made after the snapshot are lost."
+	defaultEtcdStorageContainer = "etcd-backups"
+	etcdSnapshotTimestampFormat = "20060102T150405Z"
+)
+
+type etcdCmd struct {
+	authProvider
+	hostKeyArgs
+
+	// user input
+	resourceGroupName string
+	location          string
+	apiModelPath      string
+	sshFilepath       string
+	masterFQDN        string
+	snapshot          string
+	storageAccount    string
+	storageContainer  string
+
+	// derived
+	containerService *api.ContainerService
+	apiVersion       string
+	locale           *gotext.Locale
+	client           armhelpers.AKSEngineClient
+	etcdBackup       *etcdbackup.EtcdBackup
+}
+
+func newEtcdCmd() *cobra.Command {
+	command := &cobra.Command{
+		Use:   etcdName,
+		Short: etcdShortDescription,
+		Long:  etcdLongDescription,
+	}
+	command.AddCommand(newEtcdBackupCmd())
+	command.AddCommand(newEtcdRestoreCmd())
+	return command
+}
+
+func newEtcdBackupCmd() *cobra.Command {
+	ec := etcdCmd{
+		authProvider: &authArgs{},
+	}
+
+	command := &cobra.Command{
+		Use:   etcdBackupName,
+		Short: etcdBackupShortDescription,
+		Long:  etcdBackupLongDescription,
+		RunE:  ec.runBackup,
+	}
+
+	f := command.Flags()
+	ec.addFlags(f)
+	f.StringVar(&ec.snapshot, "snapshot", "", "path of the snapshot file, or name of the blob if --storage-account is set (defaults to etcd-snapshot-<timestamp>.db)")
+
+	return command
+}
+
+func newEtcdRestoreCmd() *cobra.Command {
+	ec := etcdCmd{
+		authProvider: &authArgs{},
+	}
+
+	command := &cobra.Command{
+		Use:   etcdRestoreName,
+		Short: etcdRestoreShortDescription,
+		Long:  etcdRestoreLongDescription,
+		RunE:  ec.runRestore,
+	}
+
+	f := command.Flags()
+	ec.addFlags(f)
+	f.StringVar(&ec.snapshot, "snapshot", "", "path of the snapshot file, or name of the blob if --storage-account is set (required)")
+
+	return command
+}
+
+func (ec *etcdCmd) addFlags(f *flag.FlagSet) {
+	f.StringVarP(&ec.location, "location", "l", "", "location the cluster is deployed in (required unless --master-FQDN is set)")
+	f.StringVarP(&ec.resourceGroupName, "resource-group", "g", "", "the resource group of the storage account (required if --storage-account is set)")
+	f.StringVarP(&ec.apiModelPath, "api-model", "m", "", "path to the generated apimodel.json file (required)")
+	f.StringVar(&ec.sshFilepath, "ssh", "", "the filepath of a valid private ssh key to access the cluster's masters (required)")
+	f.StringVar(&ec.masterFQDN, "master-FQDN", "", "FQDN for the master load balancer (derived from the api model if absent)")
+	addHostKeyFlags(&ec.hostKeyArgs, f, "masters")
+	f.StringVar(&ec.storageAccount, "storage-account", "", "storage account holding the snapshots, the snapshots are local files if absent")
+	f.StringVar(&ec.storageContainer, "storage-container", defaultEtcdStorageContainer, "storage account container holding the snapshots")
+	addAuthFlags(ec.getAuthArgs(), f)
+}
+
+func (ec *etcdCmd) validate(cmd *cobra.Command) error {
+	var err error
+
+	ec.locale, err = i18n.LoadTranslations()
+	if err != nil {
+		return errors.Wrap(err, "error loading translation files")
+	}
+
+	if ec.apiModelPath == "" {
+		cmd.Usage()
+		return errors.New("--api-model must be specified")
+	}
+
+	if ec.sshFilepath == "" {
+		cmd.Usage()
+		return errors.New("--ssh must be specified")
+	}
+
+	if ec.masterFQDN == "" && ec.location == "" {
+		cmd.Usage()
+		return errors.New("--location must be specified")
+	}
+	ec.location = helpers.NormalizeAzureRegion(ec.location)
+
+	if ec.storageAccount != "" && ec.resourceGroupName == "" {
+		cmd.Usage()
+		return errors.New("--resource-group must be specified")
+	}
+
+	return nil
+}
+
+func (ec *etcdCmd) loadCluster() error {
+	var err error
+
+	if _, err = os.Stat(ec.apiModelPath); os.IsNotExist(err) {
+		return errors.Errorf("specified api model does not exist (%s)", ec.apiModelPath)
+	}
+
+	apiloader := &api.Apiloader{
+		Translator: &i18n.Translator{
+			Locale: ec.locale,
+		},
+	}
+	ec.containerService, ec.apiVersion, err = apiloader.LoadContainerServiceFromFile(ec.apiModelPath, true, true, nil)
+	if err != nil {
+		return errors.Wrap(err, "error parsing the api model")
+	}
+
+	knownHostsFile, err := ec.getKnownHostsFile()
+	if err != nil {
+		return err
+	}
+	ec.etcdBackup, err = getEtcdBackup(ec.containerService, ec.location, ec.masterFQDN, ec.sshFilepath, knownHostsFile)
+	if err != nil {
+		return err
+	}
+
+	if ec.storageAccount != "" {
+		if err = ec.getAuthArgs().validateAuthArgs(); err != nil {
+			return err
+		}
+		if ec.client, err = ec.getAuthArgs().getClient(); err != nil {
+			return errors.Wrap(err, "failed to get client")
+		}
+	}
+	return nil
+}
+
+func (ec *etcdCmd) runBackup(cmd *cobra.Command, args []string) error {
+	if err := ec.validate(cmd); err != nil {
+		return errors.Wrap(err, "validating etcd backup command")
+	}
+	if err := ec.loadCluster(); err != nil {
+		return errors.Wrap(err, "loading existing cluster")
+	}
+
+	snapshot, err := ec.etcdBackup.Save()
+	if err != nil {
+		return errors.Wrap(err, "taking etcd snapshot")
+	}
+
+	if ec.snapshot == "" {
+		ec.snapshot = fmt.Sprintf("etcd-snapshot-%s.db", time.Now().UTC().Format(etcdSnapshotTimestampFormat))
+	}
+	if ec.storageAccount == "" {
+		if err = saveEtcdSnapshot(snapshot, ec.snapshot, ec.locale); err != nil {
+			return errors.Wrap(err, "saving etcd snapshot")
+		}
+		log.Infof("etcd snapshot saved to %s", ec.snapshot)
+		return nil
+	}
+
+	storageClient, err := ec.getStorageClient()
+	if err != nil {
+		return err
+	}
+	if _, err = storageClient.CreateContainer(ec.storageContainer, nil); err != nil {
+		return errors.Wrapf(err, "creating storage container %s", ec.storageContainer)
+	}
+	if err = storageClient.SaveBlockBlob(ec.storageContainer, ec.snapshot, snapshot, nil); err != nil {
+		return errors.Wrap(err, "uploading etcd snapshot")
+	}
+	log.Infof("etcd snapshot saved to blob %s/%s of storage account %s", ec.storageContainer, ec.snapshot, ec.storageAccount)
+	return nil
+}
+
+func (ec *etcdCmd) runRestore(cmd *cobra.Command, args []string) error {
+	if err := ec.validate(cmd); err != nil {
+		return errors.Wrap(err, "validating etcd restore command")
+	}
+	if ec.snapshot == "" {
+		cmd.Usage()
+		return errors.New("--snapshot must be specified")
+	}
+	if err := ec.loadCluster(); err != nil {
+		return errors.Wrap(err, "loading existing cluster")
+	}
+
+	var snapshot []byte
+	var err error
+	if ec.storageAccount == "" {
+		snapshot, err = ioutil.ReadFile(ec.snapshot)
+	} else {
+		var storageClient armhelpers.AKSStorageClient
+		if storageClient, err = ec.getStorageClient(); err != nil {
+			return err
+		}
+		snapshot, err = storageClient.GetBlockBlob(ec.storageContainer, ec.snapshot, nil)
+	}
+	if err != nil {
+		return errors.Wrapf(err, "reading etcd snapshot %s", ec.snapshot)
+	}
+
+	if err = ec.etcdBackup.Restore(snapshot); err != nil {
+		return errors.Wrap(err, "restoring etcd snapshot")
+	}
+	log.Infof("etcd restored from snapshot %s", ec.snapshot)
+	return nil
+}
+
+func (ec *etcdCmd) getStorageClient() (armhelpers.AKSStorageClient, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), armhelpers.DefaultARMOperationTimeout)
+	defer cancel()
+	storageClient, err := ec.client.GetStorageClient(ctx, ec.resourceGroupName, ec.storageAccount)
+	if err != nil {
+		return nil, errors.Wrapf(err, "getting a client for storage account %s", ec.storageAccount)
+	}
+	return storageClient, nil
+}
+
+// getEtcdBackup returns an EtcdBackup reaching the masters of the cluster over SSH with the private key at sshFilepath
+func getEtcdBackup(cs *api.ContainerService, location, masterFQDN, sshFilepath, knownHostsFile string) (*etcdbackup.EtcdBackup, error) {
+	properties := cs.Properties
+	if !properties.OrchestratorProfile.IsKubernetes() || properties.MasterProfile == nil {
+		return nil, errors.New("etcd backups are only supported on Kubernetes clusters with a master profile")
+	}
+	if properties.MasterProfile.IsVirtualMachineScaleSets() {
+		return nil, errors.New("etcd backups are not supported on clusters with master scale sets")
+	}
+	// the masters of a private cluster have no SSH NAT rules on a public load balancer
+	if properties.OrchestratorProfile.IsPrivateCluster() {
+		return nil, errors.New("etcd backups are not supported on private clusters")
+	}
+
+	sshKey, err := ioutil.ReadFile(sshFilepath)
+	if err != nil {
+		return nil, errors.Wrapf(err, "reading ssh key %s", sshFilepath)
+	}
+	if masterFQDN == "" {
+		masterFQDN = api.FormatProdFQDNByLocation(properties.MasterProfile.DNSPrefix, location, properties.GetCustomCloudName())
+	}
+	return &etcdbackup.EtcdBackup{
+		Logger:         log.NewEntry(log.New()),
+		MasterFQDN:     masterFQDN,
+		SSHPorts:       operations.MasterSSHPorts(properties.MasterProfile.Count),
+		SSHUser:        properties.LinuxProfile.AdminUsername,
+		SSHKey:         sshKey,
+		KnownHostsFile: knownHostsFile,
+	}, nil
+}
+
+// saveEtcdSnapshot saves an etcd snapshot to a local file, readable by the current user only
+func saveEtcdSnapshot(snapshot []byte, path string, locale *gotext.Locale) error {
+	f := helpers.FileSaver{
+		Translator: &i18n.Translator{
+			Locale: locale,
+		},
+	}
+	dir, file := filepath.Split(path)
+	if dir == "" {
+		dir = "."
+	}
+	return f.SaveFile(dir, file, snapshot)
+}
@@ -0,0 +1,146 @@
+// Copyright (c) Microsoft Corporation. All rights reserved.
+// Licensed under the MIT license.
+
+package cmd
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/Azure/aks-engine/pkg/api"
+	"github.com/Azure/go-autorest/autorest/to"
+	. "github.com/onsi/gomega"
+	"github.com/pkg/errors"
+	"github.com/spf13/cobra"
+)
+
+func TestNewEtcdCmd(t *testing.T) {
+	g := NewGomegaWithT(t)
+	command := newEtcdCmd()
+
+	g.Expect(command.Use).Should(Equal(etcdName))
+	g.Expect(command.Short).Should(Equal(etcdShortDescription))
+	g.Expect(command.Long).Should(Equal(etcdLongDescription))
+
+	subCommands := command.Commands()
+	g.Expect(subCommands).To(HaveLen(2))
+	g.Expect(subCommands[0].Use).To(Equal(etcdBackupName))
+	g.Expect(subCommands[1].Use).To(Equal(etcdRestoreName))
+	for _, c := range subCommands {
+		for _, f := range []string{"location", "resource-group", "api-model", "ssh", "master-FQDN", "known-hosts", "snapshot", "storage-account", "storage-container"} {
+			g.Expect(c.Flags().Lookup(f)).NotTo(BeNil())
+		}
+	}
+}
+
+func TestEtcdCmdShouldBeValidated(t *testing.T) {
+	g := NewGomegaWithT(t)
+	r := &cobra.Command{}
+
+	cases := []struct {
+		ec          *etcdCmd
+		expectedErr error
+	}{
+		{
+			ec: &etcdCmd{
+				location:    "westus",
+				sshFilepath: "_test_ssh",
+			},
+			expectedErr: errors.New("--api-model must be specified"),
+		},
+		{
+			ec: &etcdCmd{
+				apiModelPath: "./not/used",
+				location:     "westus",
+			},
+			expectedErr: errors.New("--ssh must be specified"),
+		},
+		{
+			ec: &etcdCmd{
+				apiModelPath: "./not/used",
+				sshFilepath:  "_test_ssh",
+			},
+			expectedErr: errors.New("--location must be specified"),
+		},
+		{
+			ec: &etcdCmd{
+				apiModelPath:   "./not/used",
+				sshFilepath:    "_test_ssh",
+				location:       "westus",
+				storageAccount: "backups",
+			},
+			expectedErr: errors.New("--resource-group must be specified"),
+		},
+		{
+			ec: &etcdCmd{
+				apiModelPath: "./not/used",
+				sshFilepath:  "_test_ssh",
+				masterFQDN:   "testcluster.westus.cloudapp.azure.com",
+			},
+			expectedErr: nil,
+		},
+	}
+
+	for _, c := range cases {
+		err := c.ec.validate(r)
+		if c.expectedErr != nil {
+			g.Expect(err).To(HaveOccurred())
+			g.Expect(err.Error()).To(Equal(c.expectedErr.Error()))
+		} else {
+			g.Expect(err).NotTo(HaveOccurred())
+		}
+	}
+}
+
+func TestGetEtcdBackup(t *testing.T) {
+	g := NewGomegaWithT(t)
+	dir, err := ioutil.TempDir("", "etcd")
+	g.Expect(err).NotTo(HaveOccurred())
+	defer os.RemoveAll(dir)
+	sshFilepath := filepath.Join(dir, "id_rsa")
+	g.Expect(ioutil.WriteFile(sshFilepath, []byte("key"), 0600)).To(Succeed())
+
+	cs := api.CreateMockContainerService("testcluster", "1.13.5", 3, 2, false)
+	etcdBackup, err := getEtcdBackup(cs, "westus", "", sshFilepath, "")
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(etcdBackup.MasterFQDN).To(Equal(api.FormatProdFQDNByLocation(cs.Properties.MasterProfile.DNSPrefix, "westus", "")))
+	g.Expect(etcdBackup.SSHPorts).To(Equal([]int{22, 2201, 2202}))
+	g.Expect(etcdBackup.SSHUser).To(Equal(cs.Properties.LinuxProfile.AdminUsername))
+	g.Expect(string(etcdBackup.SSHKey)).To(Equal("key"))
+
+	etcdBackup, err = getEtcdBackup(cs, "westus", "master.contoso.com", sshFilepath, "")
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(etcdBackup.MasterFQDN).To(Equal("master.contoso.com"))
+
+	_, err = getEtcdBackup(cs, "westus", "", filepath.Join(dir, "missing"), "")
+	g.Expect(err).To(HaveOccurred())
+
+	cs.Properties.MasterProfile.AvailabilityProfile = api.VirtualMachineScaleSets
+	_, err = getEtcdBackup(cs, "westus", "", sshFilepath, "")
+	g.Expect(err).To(HaveOccurred())
+	g.Expect(err.Error()).To(Equal("etcd backups are not supported on clusters with master scale sets"))
+
+	cs.Properties.MasterProfile.AvailabilityProfile = api.AvailabilitySet
+	cs.Properties.OrchestratorProfile.KubernetesConfig.PrivateCluster = &api.PrivateCluster{Enabled: to.BoolPtr(true)}
+	_, err = getEtcdBackup(cs, "westus", "", sshFilepath, "")
+	g.Expect(err).To(HaveOccurred())
+	g.Expect(err.Error()).To(Equal("etcd backups are not supported on private clusters"))
+}
+
+func TestSaveEtcdSnapshot(t *testing.T) {
+	g := NewGomegaWithT(t)
+	dir, err := ioutil.TempDir("", "etcd")
+	g.Expect(err).NotTo(HaveOccurred())
+	defer os.RemoveAll(dir)
+
+	path := filepath.Join(dir, "backups", "snapshot.db")
+	g.Expect(saveEtcdSnapshot([]byte("snapshot"), path, nil)).To(Succeed())
+	b, err := ioutil.ReadFile(path)
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(string(b)).To(Equal("snapshot"))
+	info, err := os.Stat(path)
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(info.Mode().Perm()).To(Equal(os.FileMode(0600)))
+}
@@ -0,0 +1,301 @@
+// Copyright (c) Microsoft Corporation. All rights reserved.
+// Licensed under the MIT license.
+
+package cmd
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/Azure/aks-engine/pkg/api"
+	"github.com/Azure/aks-engine/pkg/helpers"
+	"github.com/Azure/aks-engine/pkg/i18n"
+	"github.com/Azure/aks-engine/pkg/operations"
+	"github.com/Azure/aks-engine/pkg/operations/clusterexec"
+	"github.com/leonelquinteros/gotext"
+	"github.com/pkg/errors"
+	log "github.com/sirupsen/logrus"
+	"github.com/spf13/cobra"
+	"k8s.io/apimachinery/pkg/labels"
+)
+
+const (
+	execName             = "exec"
+	execShortDescription = "Run a command or a script on nodes of an existing Kubernetes cluster"
+	execLongDescription  = "Run a command or a script over SSH on the masters, the agents of some pools, the nodes of an operating system or the nodes matching a label selector, and report the output and the exit code of every node as a table or as JSON"
+	execOutputTable      = "table"
+	execOutputJSON       = "json"
+)
+
+type execCmd struct {
+	hostKeyArgs
+
+	// user input
+	location     string
+	apiModelPath string
+	sshFilepath  string
+	masterFQDN   string
+	masters      bool
+	nodePools    []string
+	os           string
+	selector     string
+	command      string
+	scriptPath   string
+	concurrency  int
+	output       string
+
+	// derived
+	containerService *api.ContainerService
+	apiVersion       string
+	locale           *gotext.Locale
+	labelSelector    labels.Selector
+	script           []byte
+	executor         *clusterexec.Executor
+}
+
+func newExecCmd() *cobra.Command {
+	ec := execCmd{}
+
+	command := &cobra.Command{
+		Use:   execName,
+		Short: execShortDescription,
+		Long:  execLongDescription,
+		RunE:  ec.run,
+	}
+
+	f := command.Flags()
+	f.StringVarP(&ec.location, "location", "l", "", "location the cluster is deployed in (required unless --master-FQDN is set)")
+	f.StringVarP(&ec.apiModelPath, "api-model", "m", "", "path to the generated apimodel.json file (required)")
+	f.StringVar(&ec.sshFilepath, "ssh", "", "the filepath of a valid private ssh key to access the cluster's nodes (required)")
+	f.StringVar(&ec.masterFQDN, "master-FQDN", "", "FQDN for the master load balancer (derived from the api model if absent)")
+	addHostKeyFlags(&ec.hostKeyArgs, f, "nodes")
+	f.BoolVar(&ec.masters, "masters", false, "run on the masters")
+	f.StringSliceVar(&ec.nodePools, "node-pool", []string{}, "run on the agents of this pool, can be repeated (every node if neither --masters nor --node-pool is set)")
+	f.StringVar(&ec.os, "os", "", "run only on the nodes of this operating system, linux or windows")
+	f.StringVar(&ec.selector, "selector", "", "run only on the nodes matching this label selector, e.g. agentpool=pool1,storageprofile=managed")
+	f.StringVar(&ec.command, "command", "", "the command to run")
+	f.StringVar(&ec.scriptPath, "script", "", "path to a script to run instead of a command, with bash on Linux nodes and PowerShell on Windows nodes")
+	f.IntVar(&ec.concurrency, "concurrency", clusterexec.DefaultConcurrency, "number of nodes to run on at the same time")
+	f.StringVarP(&ec.output, "output", "o", execOutputTable, "output format, table or json")
+
+	return command
+}
+
+func (ec *execCmd) validate(cmd *cobra.Command) error {
+	var err error
+
+	ec.locale, err = i18n.LoadTranslations()
+	if err != nil {
+		return errors.Wrap(err, "error loading translation files")
+	}
+
+	if ec.apiModelPath == "" {
+		cmd.Usage()
+		return errors.New("--api-model must be specified")
+	}
+
+	if ec.sshFilepath == "" {
+		cmd.Usage()
+		return errors.New("--ssh must be specified")
+	}
+
+	if ec.masterFQDN == "" && ec.location == "" {
+		cmd.Usage()
+		return errors.New("--location must be specified")
+	}
+	ec.location = helpers.NormalizeAzureRegion(ec.location)
+
+	if ec.command == "" && ec.scriptPath == "" {
+		cmd.Usage()
+		return errors.New("--command or --script must be specified")
+	}
+	if ec.command != "" && ec.scriptPath != "" {
+		cmd.Usage()
+		return errors.New("--command and --script cannot both be specified")
+	}
+
+	ec.os = strings.ToLower(ec.os)
+	if ec.os != "" && ec.os != "linux" && ec.os != "windows" {
+		cmd.Usage()
+		return errors.New("--os must be linux or windows")
+	}
+
+	if ec.output != execOutputTable && ec.output != execOutputJSON {
+		cmd.Usage()
+		return errors.Errorf("--output must be %s or %s", execOutputTable, execOutputJSON)
+	}
+
+	if ec.concurrency < 1 {
+		cmd.Usage()
+		return errors.New("--concurrency must be at least 1")
+	}
+
+	if ec.labelSelector, err = labels.Parse(ec.selector); err != nil {
+		return errors.Wrap(err, "parsing --selector")
+	}
+
+	return nil
+}
+
+func (ec *execCmd) loadCluster() error {
+	var err error
+
+	if _, err = os.Stat(ec.apiModelPath); os.IsNotExist(err) {
+		return errors.Errorf("specified api model does not exist (%s)", ec.apiModelPath)
+	}
+
+	apiloader := &api.Apiloader{
+		Translator: &i18n.Translator{
+			Locale: ec.locale,
+		},
+	}
+	ec.containerService, ec.apiVersion, err = apiloader.LoadContainerServiceFromFile(ec.apiModelPath, true, true, nil)
+	if err != nil {
+		return errors.Wrap(err, "error parsing the api model")
+	}
+
+	if ec.scriptPath != "" {
+		if ec.script, err = ioutil.ReadFile(ec.scriptPath); err != nil {
+			return errors.Wrapf(err, "reading script %s", ec.scriptPath)
+		}
+	}
+
+	ec.executor, err = getExecutor(ec.containerService, ec.location, ec.masterFQDN, ec.sshFilepath)
+	if err != nil {
+		return err
+	}
+	if ec.executor.KnownHostsFile, err = ec.getKnownHostsFile(); err != nil {
+		return err
+	}
+	ec.executor.Concurrency = ec.concurrency
+	return nil
+}
+
+func (ec *execCmd) run(cmd *cobra.Command, args []string) error {
+	if err := ec.validate(cmd); err != nil {
+		return errors.Wrap(err, "validating exec command")
+	}
+	if err := ec.loadCluster(); err != nil {
+		return errors.Wrap(err, "loading existing cluster")
+	}
+
+	nodes, err := ec.executor.ListNodes(clusterexec.Selector{
+		Masters: ec.masters,
+		Pools:   ec.nodePools,
+		OS:      ec.os,
+		Labels:  ec.labelSelector,
+	})
+	if err != nil {
+		return err
+	}
+	if len(nodes) == 0 {
+		return errors.New("no node matches the selection")
+	}
+
+	results := ec.executor.Exec(nodes, ec.command, ec.script)
+	if err := printExecResults(os.Stdout, results, ec.output); err != nil {
+		return err
+	}
+
+	failed := []string{}
+	for _, result := range results {
+		if result.Error != "" {
+			failed = append(failed, result.Node)
+		}
+	}
+	if len(failed) > 0 {
+		return errors.Errorf("the command failed on %s", strings.Join(failed, ", "))
+	}
+	return nil
+}
+
+// printExecResults writes results to w as JSON, or as a table with a row per line of output
+func printExecResults(w io.Writer, results []clusterexec.Result, output string) error {
+	if output == execOutputJSON {
+		b, err := json.MarshalIndent(results, "", "  ")
+		if err != nil {
+			return errors.Wrap(err, "marshaling the results")
+		}
+		_, err = fmt.Fprintln(w, string(b))
+		return err
+	}
+
+	var table bytes.Buffer
+	tw := tabwriter.NewWriter(&table, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(tw, "NODE\tEXIT CODE\tSTDOUT\tSTDERR")
+	for _, result := range results {
+		stdout := outputLines(result.Stdout)
+		stderr := outputLines(result.Stderr)
+		if result.ExitCode == -1 {
+			stderr = append(stderr, result.Error)
+		}
+		for i := 0; i == 0 || i < len(stdout) || i < len(stderr); i++ {
+			node, exitCode, stdoutLine, stderrLine := "", "", "", ""
+			if i == 0 {
+				node, exitCode = result.Node, fmt.Sprintf("%d", result.ExitCode)
+			}
+			if i < len(stdout) {
+				stdoutLine = stdout[i]
+			}
+			if i < len(stderr) {
+				stderrLine = stderr[i]
+			}
+			fmt.Fprintf(tw, "%s\t%s\t%s\t%s\n", node, exitCode, stdoutLine, stderrLine)
+		}
+	}
+	if err := tw.Flush(); err != nil {
+		return err
+	}
+	// the empty cells at the end of the rows are padded
+	for _, line := range strings.Split(strings.TrimSuffix(table.String(), "\n"), "\n") {
+		if _, err := fmt.Fprintln(w, strings.TrimRight(line, " ")); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func outputLines(output string) []string {
+	output = strings.TrimRight(output, "\r\n")
+	if output == "" {
+		return nil
+	}
+	return strings.Split(strings.Replace(output, "\r\n", "\n", -1), "\n")
+}
+
+// getExecutor returns an Executor reaching the nodes of the cluster over SSH with the private key at sshFilepath
+func getExecutor(cs *api.ContainerService, location, masterFQDN, sshFilepath string) (*clusterexec.Executor, error) {
+	properties := cs.Properties
+	if !properties.OrchestratorProfile.IsKubernetes() || properties.MasterProfile == nil {
+		return nil, errors.New("commands can only be run on Kubernetes clusters with a master profile")
+	}
+	if properties.MasterProfile.IsVirtualMachineScaleSets() {
+		return nil, errors.New("commands cannot be run on clusters with master scale sets")
+	}
+
+	sshKey, err := ioutil.ReadFile(sshFilepath)
+	if err != nil {
+		return nil, errors.Wrapf(err, "reading ssh key %s", sshFilepath)
+	}
+	if masterFQDN == "" {
+		masterFQDN = api.FormatProdFQDNByLocation(properties.MasterProfile.DNSPrefix, location, properties.GetCustomCloudName())
+	}
+	executor := &clusterexec.Executor{
+		Logger:         log.NewEntry(log.New()),
+		MasterFQDN:     masterFQDN,
+		SSHPorts:       operations.MasterSSHPorts(properties.MasterProfile.Count),
+		MasterVMPrefix: properties.GetMasterVMPrefix(),
+		LinuxUser:      properties.LinuxProfile.AdminUsername,
+		SSHKey:         sshKey,
+	}
+	if properties.WindowsProfile != nil {
+		executor.WindowsUser = properties.WindowsProfile.AdminUsername
+	}
+	return executor, nil
+}
@@ -0,0 +1,165 @@
+// Copyright (c) Microsoft Corporation. All rights reserved.
+// Licensed under the MIT license.
+
+package cmd
+
+import (
+	"bytes"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/Azure/aks-engine/pkg/api"
+	"github.com/Azure/aks-engine/pkg/operations/clusterexec"
+	. "github.com/onsi/gomega"
+	"github.com/pkg/errors"
+	"github.com/spf13/cobra"
+)
+
+func TestNewExecCmd(t *testing.T) {
+	g := NewGomegaWithT(t)
+	command := newExecCmd()
+
+	g.Expect(command.Use).Should(Equal(execName))
+	g.Expect(command.Short).Should(Equal(execShortDescription))
+	g.Expect(command.Long).Should(Equal(execLongDescription))
+	for _, f := range []string{"location", "api-model", "ssh", "master-FQDN", "known-hosts", "masters", "node-pool", "os", "selector", "command", "script", "concurrency", "output"} {
+		g.Expect(command.Flags().Lookup(f)).NotTo(BeNil())
+	}
+}
+
+func TestExecCmdShouldBeValidated(t *testing.T) {
+	g := NewGomegaWithT(t)
+	r := &cobra.Command{}
+
+	valid := func() *execCmd {
+		return &execCmd{
+			apiModelPath: "./not/used",
+			sshFilepath:  "_test_ssh",
+			location:     "westus",
+			command:      "uptime",
+			concurrency:  clusterexec.DefaultConcurrency,
+			output:       execOutputTable,
+		}
+	}
+	cases := []struct {
+		ec          func(ec *execCmd)
+		expectedErr error
+	}{
+		{
+			ec:          func(ec *execCmd) { ec.apiModelPath = "" },
+			expectedErr: errors.New("--api-model must be specified"),
+		},
+		{
+			ec:          func(ec *execCmd) { ec.sshFilepath = "" },
+			expectedErr: errors.New("--ssh must be specified"),
+		},
+		{
+			ec:          func(ec *execCmd) { ec.location = "" },
+			expectedErr: errors.New("--location must be specified"),
+		},
+		{
+			ec:          func(ec *execCmd) { ec.command = "" },
+			expectedErr: errors.New("--command or --script must be specified"),
+		},
+		{
+			ec:          func(ec *execCmd) { ec.scriptPath = "script.sh" },
+			expectedErr: errors.New("--command and --script cannot both be specified"),
+		},
+		{
+			ec:          func(ec *execCmd) { ec.os = "darwin" },
+			expectedErr: errors.New("--os must be linux or windows"),
+		},
+		{
+			ec:          func(ec *execCmd) { ec.output = "yaml" },
+			expectedErr: errors.New("--output must be table or json"),
+		},
+		{
+			ec:          func(ec *execCmd) { ec.concurrency = 0 },
+			expectedErr: errors.New("--concurrency must be at least 1"),
+		},
+		{
+			ec:          func(ec *execCmd) { ec.selector = "=pool1" },
+			expectedErr: errors.New("parsing --selector: found '=', expected: !, identifier, or 'end of string'"),
+		},
+	}
+
+	for _, c := range cases {
+		ec := valid()
+		c.ec(ec)
+		err := ec.validate(r)
+		g.Expect(err).To(HaveOccurred())
+		g.Expect(err.Error()).To(Equal(c.expectedErr.Error()))
+	}
+
+	ec := valid()
+	ec.location = ""
+	ec.masterFQDN = "testcluster.westus.cloudapp.azure.com"
+	ec.os = "Windows"
+	ec.selector = "agentpool=pool1"
+	g.Expect(ec.validate(r)).To(Succeed())
+	g.Expect(ec.os).To(Equal("windows"))
+	g.Expect(ec.labelSelector.String()).To(Equal("agentpool=pool1"))
+}
+
+func TestPrintExecResults(t *testing.T) {
+	g := NewGomegaWithT(t)
+	results := []clusterexec.Result{
+		{Node: "k8s-master-12345678-0", Stdout: "line 1\nline 2\n"},
+		{Node: "k8s-agentpool1-12345678-0", ExitCode: 2, Stdout: "out\n", Stderr: "err 1\r\nerr 2\r\n", Error: "Process exited with status 2"},
+		{Node: "k8s-agentpool1-12345678-1", ExitCode: -1, Error: "unreachable"},
+	}
+
+	var out bytes.Buffer
+	g.Expect(printExecResults(&out, results, execOutputTable)).To(Succeed())
+	g.Expect(out.String()).To(Equal(`NODE                       EXIT CODE  STDOUT  STDERR
+k8s-master-12345678-0      0          line 1
+                                      line 2
+k8s-agentpool1-12345678-0  2          out     err 1
+                                              err 2
+k8s-agentpool1-12345678-1  -1                 unreachable
+`))
+
+	out.Reset()
+	g.Expect(printExecResults(&out, results[2:], execOutputJSON)).To(Succeed())
+	g.Expect(out.String()).To(Equal(`[
+  {
+    "node": "k8s-agentpool1-12345678-1",
+    "exitCode": -1,
+    "stdout": "",
+    "stderr": "",
+    "error": "unreachable"
+  }
+]
+`))
+}
+
+func TestGetExecutor(t *testing.T) {
+	g := NewGomegaWithT(t)
+	dir, err := ioutil.TempDir("", "exec")
+	g.Expect(err).NotTo(HaveOccurred())
+	defer os.RemoveAll(dir)
+	sshFilepath := filepath.Join(dir, "id_rsa")
+	g.Expect(ioutil.WriteFile(sshFilepath, []byte("key"), 0600)).To(Succeed())
+
+	cs := api.CreateMockContainerService("testcluster", "1.13.5", 3, 2, false)
+	cs.Properties.WindowsProfile = &api.WindowsProfile{AdminUsername: "azureuser-windows"}
+	executor, err := getExecutor(cs, "westus", "", sshFilepath)
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(executor.MasterFQDN).To(Equal(api.FormatProdFQDNByLocation(cs.Properties.MasterProfile.DNSPrefix, "westus", "")))
+	g.Expect(executor.SSHPorts).To(Equal([]int{22, 2201, 2202}))
+	g.Expect(executor.MasterVMPrefix).To(Equal(cs.Properties.GetMasterVMPrefix()))
+	g.Expect(executor.LinuxUser).To(Equal(cs.Properties.LinuxProfile.AdminUsername))
+	g.Expect(executor.WindowsUser).To(Equal("azureuser-windows"))
+	g.Expect(string(executor.SSHKey)).To(Equal("key"))
+
+	executor, err = getExecutor(cs, "", "testcluster.example.com", sshFilepath)
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(executor.MasterFQDN).To(Equal("testcluster.example.com"))
+
+	cs.Properties.MasterProfile.AvailabilityProfile = api.VirtualMachineScaleSets
+	_, err = getExecutor(cs, "westus", "", sshFilepath)
+	g.Expect(err).To(HaveOccurred())
+	g.Expect(err.Error()).To(Equal("commands cannot be run on clusters with master scale sets"))
+}
@@ -0,0 +1,243 @@
+// Copyright (c) Microsoft Corporation. All rights reserved.
+// Licensed under the MIT license.
+
+package cmd
+
+import (
+	"context"
+	"io/ioutil"
+	"os"
+	"path"
+	"path/filepath"
+	"time"
+
+	"github.com/Azure/aks-engine/pkg/api"
+	"github.com/Azure/aks-engine/pkg/armhelpers"
+	"github.com/Azure/aks-engine/pkg/helpers"
+	"github.com/Azure/aks-engine/pkg/i18n"
+	"github.com/Azure/aks-engine/pkg/operations"
+	"github.com/Azure/aks-engine/pkg/operations/clusterlogs"
+	"github.com/leonelquinteros/gotext"
+	"github.com/pkg/errors"
+	log "github.com/sirupsen/logrus"
+	"github.com/spf13/cobra"
+)
+
+const (
+	getLogsName                    = "get-logs"
+	getLogsShortDescription        = "Collect the logs of the nodes of an existing Kubernetes cluster"
+	getLogsLongDescription         = "Collect the provisioning logs, the kubelet, docker and etcd journals, the cloud-init logs and the redacted /etc/kubernetes/azure.json of every Linux node over SSH, as a tarball per node. The agents are listed from Azure Resource Manager"
+	defaultGetLogsStorageContainer = "cluster-logs"
+	getLogsTimestampFormat         = "20060102T150405Z"
+)
+
+type getLogsCmd struct {
+	authProvider
+	hostKeyArgs
+
+	// user input
+	resourceGroupName           string
+	location                    string
+	apiModelPath                string
+	sshFilepath                 string
+	masterFQDN                  string
+	outputDirectory             string
+	storageAccount              string
+	storageAccountResourceGroup string
+	storageContainer            string
+
+	// derived
+	containerService *api.ContainerService
+	apiVersion       string
+	locale           *gotext.Locale
+	client           armhelpers.AKSEngineClient
+	collector        *clusterlogs.LogsCollector
+}
+
+func newGetLogsCmd() *cobra.Command {
+	glc := getLogsCmd{
+		authProvider: &authArgs{},
+	}
+
+	command := &cobra.Command{
+		Use:   getLogsName,
+		Short: getLogsShortDescription,
+		Long:  getLogsLongDescription,
+		RunE:  glc.run,
+	}
+
+	f := command.Flags()
+	f.StringVarP(&glc.location, "location", "l", "", "location the cluster is deployed in (required unless --master-FQDN is set)")
+	f.StringVarP(&glc.resourceGroupName, "resource-group", "g", "", "the resource group where the cluster is deployed (required)")
+	f.StringVarP(&glc.apiModelPath, "api-model", "m", "", "path to the generated apimodel.json file (required)")
+	f.StringVar(&glc.sshFilepath, "ssh", "", "the filepath of a valid private ssh key to access the cluster's nodes (required)")
+	f.StringVar(&glc.masterFQDN, "master-FQDN", "", "FQDN for the master load balancer (derived from the api model if absent)")
+	addHostKeyFlags(&glc.hostKeyArgs, f, "nodes")
+	f.StringVarP(&glc.outputDirectory, "output-directory", "o", "", "directory to save the logs to (defaults to the logs directory next to the api model)")
+	f.StringVar(&glc.storageAccount, "storage-account", "", "storage account to upload the logs to, in addition to saving them locally")
+	f.StringVar(&glc.storageAccountResourceGroup, "storage-account-resource-group", "", "the resource group of the storage account (defaults to --resource-group)")
+	f.StringVar(&glc.storageContainer, "storage-container", defaultGetLogsStorageContainer, "storage account container to upload the logs to")
+	addAuthFlags(glc.getAuthArgs(), f)
+
+	return command
+}
+
+func (glc *getLogsCmd) validate(cmd *cobra.Command) error {
+	var err error
+
+	glc.locale, err = i18n.LoadTranslations()
+	if err != nil {
+		return errors.Wrap(err, "error loading translation files")
+	}
+
+	if glc.apiModelPath == "" {
+		cmd.Usage()
+		return errors.New("--api-model must be specified")
+	}
+
+	if glc.sshFilepath == "" {
+		cmd.Usage()
+		return errors.New("--ssh must be specified")
+	}
+
+	if glc.masterFQDN == "" && glc.location == "" {
+		cmd.Usage()
+		return errors.New("--location must be specified")
+	}
+	glc.location = helpers.NormalizeAzureRegion(glc.location)
+
+	if glc.resourceGroupName == "" {
+		cmd.Usage()
+		return errors.New("--resource-group must be specified")
+	}
+
+	if glc.storageAccountResourceGroup == "" {
+		glc.storageAccountResourceGroup = glc.resourceGroupName
+	}
+
+	if glc.outputDirectory == "" {
+		glc.outputDirectory = filepath.Join(filepath.Dir(glc.apiModelPath), "logs")
+	}
+
+	return nil
+}
+
+func (glc *getLogsCmd) loadCluster() error {
+	var err error
+
+	if _, err = os.Stat(glc.apiModelPath); os.IsNotExist(err) {
+		return errors.Errorf("specified api model does not exist (%s)", glc.apiModelPath)
+	}
+
+	apiloader := &api.Apiloader{
+		Translator: &i18n.Translator{
+			Locale: glc.locale,
+		},
+	}
+	glc.containerService, glc.apiVersion, err = apiloader.LoadContainerServiceFromFile(glc.apiModelPath, true, true, nil)
+	if err != nil {
+		return errors.Wrap(err, "error parsing the api model")
+	}
+
+	// the agents are listed from Azure Resource Manager
+	if err = glc.getAuthArgs().validateAuthArgs(); err != nil {
+		return err
+	}
+	if glc.client, err = glc.getAuthArgs().getClient(); err != nil {
+		return errors.Wrap(err, "failed to get client")
+	}
+
+	knownHostsFile, err := glc.getKnownHostsFile()
+	if err != nil {
+		return err
+	}
+	glc.collector, err = getLogsCollector(glc.containerService, glc.location, glc.masterFQDN, glc.sshFilepath, knownHostsFile)
+	if err != nil {
+		return err
+	}
+	glc.collector.Client = glc.client
+	glc.collector.ResourceGroup = glc.resourceGroupName
+	return nil
+}
+
+func (glc *getLogsCmd) run(cmd *cobra.Command, args []string) error {
+	if err := glc.validate(cmd); err != nil {
+		return errors.Wrap(err, "validating get-logs command")
+	}
+	if err := glc.loadCluster(); err != nil {
+		return errors.Wrap(err, "loading existing cluster")
+	}
+
+	var storageClient armhelpers.AKSStorageClient
+	var blobPrefix string
+	if glc.storageAccount != "" {
+		ctx, cancel := context.WithTimeout(context.Background(), armhelpers.DefaultARMOperationTimeout)
+		defer cancel()
+		var err error
+		if storageClient, err = glc.client.GetStorageClient(ctx, glc.storageAccountResourceGroup, glc.storageAccount); err != nil {
+			return errors.Wrapf(err, "getting a client for storage account %s", glc.storageAccount)
+		}
+		if _, err = storageClient.CreateContainer(glc.storageContainer, nil); err != nil {
+			return errors.Wrapf(err, "creating storage container %s", glc.storageContainer)
+		}
+		blobPrefix = path.Join(glc.containerService.Properties.MasterProfile.DNSPrefix, time.Now().UTC().Format(getLogsTimestampFormat))
+	}
+
+	f := helpers.FileSaver{
+		Translator: &i18n.Translator{
+			Locale: glc.locale,
+		},
+	}
+	err := glc.collector.Collect(func(node string, logs []byte) error {
+		file := node + ".tar.gz"
+		if err := f.SaveFile(glc.outputDirectory, file, logs); err != nil {
+			return err
+		}
+		if storageClient != nil {
+			return storageClient.SaveBlockBlob(glc.storageContainer, path.Join(blobPrefix, file), logs, nil)
+		}
+		return nil
+	})
+	log.Infof("Logs saved to %s", glc.outputDirectory)
+	if storageClient != nil {
+		log.Infof("Logs uploaded to %s/%s of storage account %s", glc.storageContainer, blobPrefix, glc.storageAccount)
+	}
+	return err
+}
+
+// getLogsCollector returns a LogsCollector reaching the nodes of the cluster over SSH with the private key at sshFilepath
+func getLogsCollector(cs *api.ContainerService, location, masterFQDN, sshFilepath, knownHostsFile string) (*clusterlogs.LogsCollector, error) {
+	properties := cs.Properties
+	if !properties.OrchestratorProfile.IsKubernetes() || properties.MasterProfile == nil {
+		return nil, errors.New("logs can only be collected from Kubernetes clusters with a master profile")
+	}
+	if properties.MasterProfile.IsVirtualMachineScaleSets() {
+		return nil, errors.New("logs cannot be collected from clusters with master scale sets")
+	}
+
+	sshKey, err := ioutil.ReadFile(sshFilepath)
+	if err != nil {
+		return nil, errors.Wrapf(err, "reading ssh key %s", sshFilepath)
+	}
+	if masterFQDN == "" {
+		masterFQDN = api.FormatProdFQDNByLocation(properties.MasterProfile.DNSPrefix, location, properties.GetCustomCloudName())
+	}
+	agentPools := []clusterlogs.AgentPool{}
+	for i, agentPool := range properties.AgentPoolProfiles {
+		agentPools = append(agentPools, clusterlogs.AgentPool{
+			VMPrefix:                  properties.GetAgentVMPrefix(agentPool, i),
+			IsVirtualMachineScaleSets: agentPool.IsVirtualMachineScaleSets(),
+			IsWindows:                 agentPool.IsWindows(),
+		})
+	}
+	return &clusterlogs.LogsCollector{
+		Logger:         log.NewEntry(log.New()),
+		MasterFQDN:     masterFQDN,
+		SSHPorts:       operations.MasterSSHPorts(properties.MasterProfile.Count),
+		MasterVMPrefix: properties.GetMasterVMPrefix(),
+		AgentPools:     agentPools,
+		SSHUser:        properties.LinuxProfile.AdminUsername,
+		SSHKey:         sshKey,
+		KnownHostsFile: knownHostsFile,
+	}, nil
+}
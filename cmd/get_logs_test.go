@@ -0,0 +1,124 @@
+// Copyright (c) Microsoft Corporation. All rights reserved.
+// Licensed under the MIT license.
+
+package cmd
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/Azure/aks-engine/pkg/api"
+	"github.com/Azure/aks-engine/pkg/operations/clusterlogs"
+	. "github.com/onsi/gomega"
+	"github.com/pkg/errors"
+	"github.com/spf13/cobra"
+)
+
+func TestNewGetLogsCmd(t *testing.T) {
+	g := NewGomegaWithT(t)
+	command := newGetLogsCmd()
+
+	g.Expect(command.Use).Should(Equal(getLogsName))
+	g.Expect(command.Short).Should(Equal(getLogsShortDescription))
+	g.Expect(command.Long).Should(Equal(getLogsLongDescription))
+	for _, f := range []string{"location", "resource-group", "api-model", "ssh", "master-FQDN", "known-hosts", "output-directory", "storage-account", "storage-account-resource-group", "storage-container"} {
+		g.Expect(command.Flags().Lookup(f)).NotTo(BeNil())
+	}
+}
+
+func TestGetLogsCmdShouldBeValidated(t *testing.T) {
+	g := NewGomegaWithT(t)
+	r := &cobra.Command{}
+
+	cases := []struct {
+		glc         *getLogsCmd
+		expectedErr error
+	}{
+		{
+			glc: &getLogsCmd{
+				resourceGroupName: "rg",
+				location:          "westus",
+				sshFilepath:       "_test_ssh",
+			},
+			expectedErr: errors.New("--api-model must be specified"),
+		},
+		{
+			glc: &getLogsCmd{
+				resourceGroupName: "rg",
+				apiModelPath:      "./not/used",
+				location:          "westus",
+			},
+			expectedErr: errors.New("--ssh must be specified"),
+		},
+		{
+			glc: &getLogsCmd{
+				resourceGroupName: "rg",
+				apiModelPath:      "./not/used",
+				sshFilepath:       "_test_ssh",
+			},
+			expectedErr: errors.New("--location must be specified"),
+		},
+		{
+			glc: &getLogsCmd{
+				apiModelPath: "./not/used",
+				sshFilepath:  "_test_ssh",
+				location:     "westus",
+			},
+			expectedErr: errors.New("--resource-group must be specified"),
+		},
+	}
+
+	for _, c := range cases {
+		err := c.glc.validate(r)
+		g.Expect(err).To(HaveOccurred())
+		g.Expect(err.Error()).To(Equal(c.expectedErr.Error()))
+	}
+
+	glc := &getLogsCmd{
+		resourceGroupName: "rg",
+		apiModelPath:      "_output/testcluster/apimodel.json",
+		sshFilepath:       "_test_ssh",
+		masterFQDN:        "testcluster.westus.cloudapp.azure.com",
+	}
+	g.Expect(glc.validate(r)).To(Succeed())
+	g.Expect(glc.outputDirectory).To(Equal(filepath.Join("_output", "testcluster", "logs")))
+	g.Expect(glc.storageAccountResourceGroup).To(Equal("rg"))
+
+	glc.outputDirectory = "/tmp/logs"
+	g.Expect(glc.validate(r)).To(Succeed())
+	g.Expect(glc.outputDirectory).To(Equal("/tmp/logs"))
+}
+
+func TestGetLogsCollector(t *testing.T) {
+	g := NewGomegaWithT(t)
+	dir, err := ioutil.TempDir("", "logs")
+	g.Expect(err).NotTo(HaveOccurred())
+	defer os.RemoveAll(dir)
+	sshFilepath := filepath.Join(dir, "id_rsa")
+	g.Expect(ioutil.WriteFile(sshFilepath, []byte("key"), 0600)).To(Succeed())
+
+	cs := api.CreateMockContainerService("testcluster", "1.13.5", 3, 2, false)
+	cs.Properties.AgentPoolProfiles = append(cs.Properties.AgentPoolProfiles, &api.AgentPoolProfile{
+		Name:                "windowspool2",
+		OSType:              api.Windows,
+		AvailabilityProfile: api.VirtualMachineScaleSets,
+	})
+	collector, err := getLogsCollector(cs, "westus", "", sshFilepath, "")
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(collector.MasterFQDN).To(Equal(api.FormatProdFQDNByLocation(cs.Properties.MasterProfile.DNSPrefix, "westus", "")))
+	g.Expect(collector.SSHPorts).To(Equal([]int{22, 2201, 2202}))
+	g.Expect(collector.MasterVMPrefix).To(Equal(cs.Properties.GetMasterVMPrefix()))
+	g.Expect(collector.SSHUser).To(Equal(cs.Properties.LinuxProfile.AdminUsername))
+	g.Expect(string(collector.SSHKey)).To(Equal("key"))
+	g.Expect(collector.AgentPools).To(Equal([]clusterlogs.AgentPool{
+		{VMPrefix: cs.Properties.GetAgentVMPrefix(cs.Properties.AgentPoolProfiles[0], 0)},
+		{VMPrefix: cs.Properties.GetAgentVMPrefix(cs.Properties.AgentPoolProfiles[1], 1), IsVirtualMachineScaleSets: true, IsWindows: true},
+	}))
+
+	cs.Properties.MasterProfile.AvailabilityProfile = api.VirtualMachineScaleSets
+	_, err = getLogsCollector(cs, "westus", "", sshFilepath, "")
+	g.Expect(err).To(HaveOccurred())
+	g.Expect(err.Error()).To(Equal("logs cannot be collected from clusters with master scale sets"))
+}
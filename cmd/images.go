@@ -0,0 +1,179 @@
+// Copyright (c) Microsoft Corporation. All rights reserved.
+// Licensed under the MIT license.
+
+package cmd
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/Azure/aks-engine/pkg/api"
+	"github.com/Azure/aks-engine/pkg/helpers"
+	"github.com/Azure/aks-engine/pkg/i18n"
+	"github.com/leonelquinteros/gotext"
+	"github.com/pkg/errors"
+	log "github.com/sirupsen/logrus"
+	"github.com/spf13/cobra"
+)
+
+const (
+	imagesName             = "images"
+	imagesShortDescription = "List the container images and downloads a cluster needs"
+	imagesLongDescription  = "Lists every container image and file download a cluster built from an API model pulls, and optionally rewrites the API model to pull them from a private registry and blob mirror"
+)
+
+type imagesCmd struct {
+	apimodelPath string
+	output       string
+	mirror       string
+	blobMirror   string
+	outputFile   string
+
+	// derived
+	locale *gotext.Locale
+}
+
+func newImagesCmd() *cobra.Command {
+	ic := imagesCmd{}
+
+	imagesCmd := &cobra.Command{
+		Use:   imagesName,
+		Short: imagesShortDescription,
+		Long:  imagesLongDescription,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if err := ic.validate(cmd, args); err != nil {
+				return errors.Wrap(err, "validating imagesCmd")
+			}
+			return ic.run()
+		},
+	}
+
+	f := imagesCmd.Flags()
+	f.StringVarP(&ic.apimodelPath, "api-model", "m", "", "path to your cluster definition file")
+	imagesCmdDescription := fmt.Sprintf("Output format. Allowed values: %s",
+		strings.Join(outputFormatOptions, ", "))
+	f.StringVarP(&ic.output, "output", "o", "human", imagesCmdDescription)
+	f.StringVar(&ic.mirror, "mirror", "", "container registry to pull every image from, e.g. myregistry.azurecr.io")
+	f.StringVar(&ic.blobMirror, "blob-mirror", "", "scheme and host to download every file from, e.g. https://mirror.example.com (requires --mirror)")
+	f.StringVar(&ic.outputFile, "output-file", "", "path to write the mirrored API model to (default is <api-model>.mirrored.json)")
+
+	return imagesCmd
+}
+
+func (ic *imagesCmd) validate(cmd *cobra.Command, args []string) error {
+	if ic.apimodelPath == "" {
+		if len(args) == 1 {
+			ic.apimodelPath = args[0]
+		} else if len(args) > 1 {
+			cmd.Usage()
+			return errors.New("too many arguments were provided to 'images'")
+		} else {
+			cmd.Usage()
+			return errors.New("--api-model was not supplied, nor was one specified as a positional argument")
+		}
+	}
+
+	if _, err := os.Stat(ic.apimodelPath); os.IsNotExist(err) {
+		return errors.Errorf("specified api model does not exist (%s)", ic.apimodelPath)
+	}
+
+	if ic.output != "human" && ic.output != "json" {
+		return errors.Errorf(`output format "%s" is not supported`, ic.output)
+	}
+
+	if ic.mirror == "" && (ic.blobMirror != "" || ic.outputFile != "") {
+		return errors.New("--blob-mirror and --output-file can only be used together with --mirror")
+	}
+	if ic.mirror != "" && ic.outputFile == "" {
+		ext := filepath.Ext(ic.apimodelPath)
+		ic.outputFile = strings.TrimSuffix(ic.apimodelPath, ext) + ".mirrored" + ext
+	}
+
+	var err error
+	ic.locale, err = i18n.LoadTranslations()
+	if err != nil {
+		return errors.Wrap(err, "error loading translation files")
+	}
+
+	return nil
+}
+
+func (ic *imagesCmd) loadAPIModel() (*api.ContainerService, string, error) {
+	apiloader := &api.Apiloader{
+		Translator: &i18n.Translator{
+			Locale: ic.locale,
+		},
+	}
+	cs, apiVersion, err := apiloader.LoadContainerServiceFromFile(ic.apimodelPath, true, false, nil)
+	if err != nil {
+		return nil, "", errors.Wrap(err, "error parsing the api model")
+	}
+	return cs, apiVersion, nil
+}
+
+func (ic *imagesCmd) run() error {
+	defaults, _, err := ic.loadAPIModel()
+	if err != nil {
+		return err
+	}
+	if _, err = defaults.SetPropertiesDefaults(false, false); err != nil {
+		return errors.Wrap(err, "error setting api model defaults")
+	}
+	manifest, err := defaults.GetArtifactManifest()
+	if err != nil {
+		return err
+	}
+
+	switch ic.output {
+	case "json":
+		data, err := helpers.JSONMarshalIndent(manifest, "", "  ", false)
+		if err != nil {
+			return err
+		}
+		fmt.Println(string(data))
+	default:
+		w := tabwriter.NewWriter(os.Stdout, 0, 4, 1, ' ', tabwriter.FilterHTML)
+		fmt.Fprintln(w, "Type\tReference")
+		for _, image := range manifest.Images {
+			fmt.Fprintf(w, "image\t%s\n", image)
+		}
+		for _, download := range manifest.Downloads {
+			fmt.Fprintf(w, "download\t%s\n", download)
+		}
+		w.Flush()
+	}
+
+	if ic.mirror == "" {
+		return nil
+	}
+	return ic.writeMirroredAPIModel(defaults)
+}
+
+func (ic *imagesCmd) writeMirroredAPIModel(defaults *api.ContainerService) error {
+	cs, apiVersion, err := ic.loadAPIModel()
+	if err != nil {
+		return err
+	}
+	if err = cs.MirrorArtifacts(defaults, ic.mirror, ic.blobMirror); err != nil {
+		return errors.Wrap(err, "error mirroring artifacts")
+	}
+
+	apiloader := &api.Apiloader{
+		Translator: &i18n.Translator{
+			Locale: ic.locale,
+		},
+	}
+	b, err := apiloader.SerializeContainerService(cs, apiVersion)
+	if err != nil {
+		return errors.Wrap(err, "error serializing the mirrored api model")
+	}
+	if err = ioutil.WriteFile(ic.outputFile, b, 0600); err != nil {
+		return errors.Wrapf(err, "error writing %s", ic.outputFile)
+	}
+	log.Infof("wrote mirrored api model to %s", ic.outputFile)
+	return nil
+}
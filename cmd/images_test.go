@@ -0,0 +1,97 @@
+// Copyright (c) Microsoft Corporation. All rights reserved.
+// Licensed under the MIT license.
+
+package cmd
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/spf13/cobra"
+)
+
+func TestNewImagesCmd(t *testing.T) {
+	command := newImagesCmd()
+	if command.Use != imagesName || command.Short != imagesShortDescription || command.Long != imagesLongDescription {
+		t.Fatalf("images command should have use %s equal %s, short %s equal %s and long %s equal to %s", command.Use, imagesName, command.Short, imagesShortDescription, command.Long, imagesLongDescription)
+	}
+
+	expectedFlags := []string{"api-model", "output", "mirror", "blob-mirror", "output-file"}
+	for _, f := range expectedFlags {
+		if command.Flags().Lookup(f) == nil {
+			t.Fatalf("images command should have flag %s", f)
+		}
+	}
+
+	command.SetArgs([]string{})
+	if err := command.Execute(); err == nil {
+		t.Fatalf("expected an error when calling images with no arguments")
+	}
+}
+
+func TestImagesCmdValidate(t *testing.T) {
+	r := &cobra.Command{}
+
+	i := &imagesCmd{output: "human"}
+	if err := i.validate(r, []string{"../pkg/engine/testdata/simple/kubernetes.json"}); err != nil {
+		t.Fatalf("unexpected error validating 1 arg: %s", err.Error())
+	}
+
+	i = &imagesCmd{output: "human"}
+	if err := i.validate(r, []string{"../pkg/engine/testdata/simple/kubernetes.json", "arg1"}); err == nil {
+		t.Fatalf("expected error validating multiple args")
+	}
+
+	i = &imagesCmd{output: "yaml"}
+	if err := i.validate(r, []string{"../pkg/engine/testdata/simple/kubernetes.json"}); err == nil {
+		t.Fatalf("expected error validating an unsupported output format")
+	}
+
+	i = &imagesCmd{output: "human", blobMirror: "https://mirror.example.com"}
+	if err := i.validate(r, []string{"../pkg/engine/testdata/simple/kubernetes.json"}); err == nil {
+		t.Fatalf("expected error validating --blob-mirror without --mirror")
+	}
+
+	i = &imagesCmd{output: "human", mirror: "mirror.example.com"}
+	if err := i.validate(r, []string{"../pkg/engine/testdata/simple/kubernetes.json"}); err != nil {
+		t.Fatalf("unexpected error validating --mirror: %s", err.Error())
+	}
+	if i.outputFile != "../pkg/engine/testdata/simple/kubernetes.mirrored.json" {
+		t.Fatalf("unexpected default output file %s", i.outputFile)
+	}
+}
+
+func TestImagesCmdRun(t *testing.T) {
+	dir, err := ioutil.TempDir("", "aks-engine-images")
+	if err != nil {
+		t.Fatalf("unexpected error creating temp dir: %s", err)
+	}
+	defer os.RemoveAll(dir)
+
+	r := &cobra.Command{}
+	for _, output := range []string{"human", "json"} {
+		i := &imagesCmd{
+			apimodelPath: "../pkg/engine/testdata/simple/kubernetes.json",
+			output:       output,
+			mirror:       "mirror.example.com",
+			blobMirror:   "https://blobs.example.com",
+			outputFile:   filepath.Join(dir, output+".json"),
+		}
+		if err := i.validate(r, []string{}); err != nil {
+			t.Fatalf("unexpected error validating images: %s", err)
+		}
+		if err := i.run(); err != nil {
+			t.Fatalf("unexpected error running images with %s output: %s", output, err)
+		}
+		b, err := ioutil.ReadFile(i.outputFile)
+		if err != nil {
+			t.Fatalf("expected the mirrored api model to be written to %s: %s", i.outputFile, err)
+		}
+		if !strings.Contains(string(b), `"kubernetesImageBase": "mirror.example.com/"`) {
+			t.Fatalf("expected the mirrored api model to pull from the mirror registry, got %s", string(b))
+		}
+	}
+}
@@ -0,0 +1,165 @@
+// Copyright (c) Microsoft Corporation. All rights reserved.
+// Licensed under the MIT license.
+
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/Azure/aks-engine/pkg/api"
+	"github.com/Azure/aks-engine/pkg/helpers"
+	"github.com/Azure/aks-engine/pkg/i18n"
+	"github.com/Azure/aks-engine/pkg/lint"
+	"github.com/pkg/errors"
+	log "github.com/sirupsen/logrus"
+	"github.com/spf13/cobra"
+)
+
+const (
+	lintName             = "lint"
+	lintShortDescription = "Check an API model for risky settings"
+	lintLongDescription  = "Validates an API model and reports best-practice warnings for settings that are valid but not recommended"
+)
+
+type lintCmd struct {
+	apimodelPath string
+	output       string
+	listRules    bool
+
+	// derived
+	containerService *api.ContainerService
+}
+
+func newLintCmd() *cobra.Command {
+	lc := lintCmd{}
+
+	lintCmd := &cobra.Command{
+		Use:   lintName,
+		Short: lintShortDescription,
+		Long:  lintLongDescription,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if lc.listRules {
+				return lc.printRules()
+			}
+			if err := lc.validate(cmd, args); err != nil {
+				return errors.Wrap(err, "validating lintCmd")
+			}
+			if err := lc.loadAPIModel(); err != nil {
+				return errors.Wrap(err, "loading API model in lintCmd")
+			}
+			return lc.run()
+		},
+	}
+
+	f := lintCmd.Flags()
+	f.StringVarP(&lc.apimodelPath, "api-model", "m", "", "path to your cluster definition file")
+	f.BoolVar(&lc.listRules, "list-rules", false, "list the available rules and exit")
+	lintCmdDescription := fmt.Sprintf("Output format. Allowed values: %s",
+		strings.Join(outputFormatOptions, ", "))
+	f.StringVarP(&lc.output, "output", "o", "human", lintCmdDescription)
+
+	return lintCmd
+}
+
+func (lc *lintCmd) validate(cmd *cobra.Command, args []string) error {
+	if lc.apimodelPath == "" {
+		if len(args) == 1 {
+			lc.apimodelPath = args[0]
+		} else if len(args) > 1 {
+			cmd.Usage()
+			return errors.New("too many arguments were provided to 'lint'")
+		} else {
+			cmd.Usage()
+			return errors.New("--api-model was not supplied, nor was one specified as a positional argument")
+		}
+	}
+
+	if _, err := os.Stat(lc.apimodelPath); os.IsNotExist(err) {
+		return errors.Errorf("specified api model does not exist (%s)", lc.apimodelPath)
+	}
+
+	if lc.output != "human" && lc.output != "json" {
+		return errors.Errorf(`output format "%s" is not supported`, lc.output)
+	}
+
+	return nil
+}
+
+func (lc *lintCmd) loadAPIModel() error {
+	locale, err := i18n.LoadTranslations()
+	if err != nil {
+		return errors.Wrap(err, "error loading translation files")
+	}
+
+	apiloader := &api.Apiloader{
+		Translator: &i18n.Translator{
+			Locale: locale,
+		},
+	}
+	// hard validation errors are reported before any lint rule runs
+	lc.containerService, _, err = apiloader.LoadContainerServiceFromFile(lc.apimodelPath, true, false, nil)
+	if err != nil {
+		return errors.Wrap(err, "error parsing the api model")
+	}
+	return nil
+}
+
+func (lc *lintCmd) run() error {
+	findings := lint.NewLinter().Run(lc.containerService)
+
+	switch lc.output {
+	case "json":
+		data, err := helpers.JSONMarshalIndent(findings, "", "  ", false)
+		if err != nil {
+			return err
+		}
+		fmt.Println(string(data))
+	default:
+		if len(findings) == 0 {
+			log.Infof("no problems found in %s", lc.apimodelPath)
+			break
+		}
+		w := tabwriter.NewWriter(os.Stdout, 0, 4, 1, ' ', tabwriter.FilterHTML)
+		fmt.Fprintln(w, "Rule\tSeverity\tMessage")
+		for _, f := range findings {
+			fmt.Fprintf(w, "%s\t%s\t%s\n", f.RuleID, f.Severity, f.Message)
+		}
+		w.Flush()
+	}
+
+	if lint.HasErrors(findings) {
+		return errors.Errorf("%s has lint errors", lc.apimodelPath)
+	}
+	return nil
+}
+
+func (lc *lintCmd) printRules() error {
+	rules := lint.NewLinter().Rules()
+	if lc.output == "json" {
+		type ruleInfo struct {
+			ID          string        `json:"id"`
+			Severity    lint.Severity `json:"severity"`
+			Description string        `json:"description"`
+		}
+		infos := []ruleInfo{}
+		for _, r := range rules {
+			infos = append(infos, ruleInfo{ID: r.ID, Severity: r.Severity, Description: r.Description})
+		}
+		data, err := helpers.JSONMarshalIndent(infos, "", "  ", false)
+		if err != nil {
+			return err
+		}
+		fmt.Println(string(data))
+		return nil
+	}
+	w := tabwriter.NewWriter(os.Stdout, 0, 4, 1, ' ', tabwriter.FilterHTML)
+	fmt.Fprintln(w, "Rule\tSeverity\tDescription")
+	for _, r := range rules {
+		fmt.Fprintf(w, "%s\t%s\t%s\n", r.ID, r.Severity, r.Description)
+	}
+	w.Flush()
+	return nil
+}
@@ -0,0 +1,74 @@
+// Copyright (c) Microsoft Corporation. All rights reserved.
+// Licensed under the MIT license.
+
+package cmd
+
+import (
+	"testing"
+
+	"github.com/spf13/cobra"
+)
+
+func TestNewLintCmd(t *testing.T) {
+	command := newLintCmd()
+	if command.Use != lintName || command.Short != lintShortDescription || command.Long != lintLongDescription {
+		t.Fatalf("lint command should have use %s equal %s, short %s equal %s and long %s equal to %s", command.Use, lintName, command.Short, lintShortDescription, command.Long, lintLongDescription)
+	}
+
+	expectedFlags := []string{"api-model", "output", "list-rules"}
+	for _, f := range expectedFlags {
+		if command.Flags().Lookup(f) == nil {
+			t.Fatalf("lint command should have flag %s", f)
+		}
+	}
+
+	command.SetArgs([]string{})
+	if err := command.Execute(); err == nil {
+		t.Fatalf("expected an error when calling lint with no arguments")
+	}
+}
+
+func TestLintCmdValidate(t *testing.T) {
+	r := &cobra.Command{}
+
+	l := &lintCmd{output: "human"}
+	if err := l.validate(r, []string{"../pkg/engine/testdata/simple/kubernetes.json"}); err != nil {
+		t.Fatalf("unexpected error validating 1 arg: %s", err.Error())
+	}
+
+	l = &lintCmd{output: "human"}
+	if err := l.validate(r, []string{"../pkg/engine/testdata/simple/kubernetes.json", "arg1"}); err == nil {
+		t.Fatalf("expected error validating multiple args")
+	}
+
+	l = &lintCmd{output: "yaml"}
+	if err := l.validate(r, []string{"../pkg/engine/testdata/simple/kubernetes.json"}); err == nil {
+		t.Fatalf("expected error validating an unsupported output format")
+	}
+
+	l = &lintCmd{output: "human"}
+	if err := l.validate(r, []string{"./this/file/does/not/exist.json"}); err == nil {
+		t.Fatalf("expected error validating a missing api model")
+	}
+}
+
+func TestLintCmdRun(t *testing.T) {
+	for _, output := range []string{"human", "json"} {
+		l := &lintCmd{
+			apimodelPath: "../pkg/engine/testdata/simple/kubernetes.json",
+			output:       output,
+		}
+		if err := l.loadAPIModel(); err != nil {
+			t.Fatalf("unexpected error loading api model: %s", err)
+		}
+		if l.containerService == nil {
+			t.Fatalf("expected the api model to be loaded")
+		}
+		if err := l.run(); err != nil {
+			t.Fatalf("unexpected error running lint with %s output: %s", output, err)
+		}
+		if err := l.printRules(); err != nil {
+			t.Fatalf("unexpected error listing rules with %s output: %s", output, err)
+		}
+	}
+}
@@ -0,0 +1,140 @@
+// Copyright (c) Microsoft Corporation. All rights reserved.
+// Licensed under the MIT license.
+
+package cmd
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/Azure/aks-engine/pkg/api"
+	"github.com/Azure/aks-engine/pkg/api/vlabs"
+	"github.com/Azure/aks-engine/pkg/i18n"
+	"github.com/leonelquinteros/gotext"
+	"github.com/pkg/errors"
+	log "github.com/sirupsen/logrus"
+	"github.com/spf13/cobra"
+)
+
+const (
+	migrateName             = "migrate"
+	migrateShortDescription = "Convert an API model to a newer API version"
+	migrateLongDescription  = "Loads an API model of any supported API version, writes it out as the target version and reports fields that were dropped, defaulted or renamed"
+)
+
+type migrateCmd struct {
+	apimodelPath string
+	toVersion    string
+	outputFile   string
+	force        bool
+
+	// derived
+	locale *gotext.Locale
+}
+
+func newMigrateCmd() *cobra.Command {
+	mc := migrateCmd{}
+
+	migrateCmd := &cobra.Command{
+		Use:   migrateName,
+		Short: migrateShortDescription,
+		Long:  migrateLongDescription,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if err := mc.validate(cmd, args); err != nil {
+				return errors.Wrap(err, "validating migrateCmd")
+			}
+			return mc.run()
+		},
+	}
+
+	f := migrateCmd.Flags()
+	f.StringVarP(&mc.apimodelPath, "api-model", "m", "", "path to the API model to migrate")
+	f.StringVar(&mc.toVersion, "to", vlabs.APIVersion, "API version to migrate to")
+	f.StringVarP(&mc.outputFile, "output-file", "o", "", "path to write the migrated API model to (default is <api-model>.<version>.json)")
+	f.BoolVar(&mc.force, "force", false, "write the migrated API model even if fields were dropped")
+
+	return migrateCmd
+}
+
+func (mc *migrateCmd) validate(cmd *cobra.Command, args []string) error {
+	if mc.apimodelPath == "" {
+		if len(args) == 1 {
+			mc.apimodelPath = args[0]
+		} else if len(args) > 1 {
+			cmd.Usage()
+			return errors.New("too many arguments were provided to 'migrate'")
+		} else {
+			cmd.Usage()
+			return errors.New("--api-model was not supplied, nor was one specified as a positional argument")
+		}
+	}
+
+	if _, err := os.Stat(mc.apimodelPath); os.IsNotExist(err) {
+		return errors.Errorf("specified api model does not exist (%s)", mc.apimodelPath)
+	}
+
+	if mc.toVersion == "" {
+		return errors.New("--to must not be empty")
+	}
+
+	if mc.outputFile == "" {
+		ext := filepath.Ext(mc.apimodelPath)
+		mc.outputFile = fmt.Sprintf("%s.%s%s", strings.TrimSuffix(mc.apimodelPath, ext), mc.toVersion, ext)
+	}
+	if filepath.Clean(mc.outputFile) == filepath.Clean(mc.apimodelPath) {
+		return errors.New("--output-file must not overwrite the source api model")
+	}
+
+	var err error
+	mc.locale, err = i18n.LoadTranslations()
+	if err != nil {
+		return errors.Wrap(err, "error loading translation files")
+	}
+
+	return nil
+}
+
+func (mc *migrateCmd) run() error {
+	contents, err := ioutil.ReadFile(mc.apimodelPath)
+	if err != nil {
+		return errors.Wrapf(err, "error reading %s", mc.apimodelPath)
+	}
+
+	apiloader := &api.Apiloader{
+		Translator: &i18n.Translator{
+			Locale: mc.locale,
+		},
+	}
+	result, err := apiloader.MigrateContainerService(contents, mc.toVersion)
+	if err != nil {
+		return errors.Wrap(err, "error migrating the api model")
+	}
+
+	log.Infof("migrating %s from %s to %s", mc.apimodelPath, result.FromVersion, result.ToVersion)
+	if len(result.Changes) > 0 {
+		w := tabwriter.NewWriter(os.Stdout, 0, 4, 1, ' ', tabwriter.FilterHTML)
+		fmt.Fprintln(w, "Change\tField\tNew Field\tValue")
+		for _, c := range result.Changes {
+			value := fmt.Sprintf("%v", c.Value)
+			if c.Type == api.FieldChanged {
+				value = fmt.Sprintf("%v -> %v", c.Value, c.NewValue)
+			}
+			fmt.Fprintf(w, "%s\t%s\t%s\t%s\n", c.Type, c.Path, c.NewPath, value)
+		}
+		w.Flush()
+	}
+
+	if result.IsLossy() && !mc.force {
+		return errors.Errorf("migrating %s to %s would drop fields, use --force to write it anyway", mc.apimodelPath, mc.toVersion)
+	}
+
+	if err := ioutil.WriteFile(mc.outputFile, result.Contents, 0600); err != nil {
+		return errors.Wrapf(err, "error writing %s", mc.outputFile)
+	}
+	log.Infof("wrote migrated api model to %s", mc.outputFile)
+	return nil
+}
@@ -0,0 +1,83 @@
+// Copyright (c) Microsoft Corporation. All rights reserved.
+// Licensed under the MIT license.
+
+package cmd
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/spf13/cobra"
+)
+
+func TestNewMigrateCmd(t *testing.T) {
+	command := newMigrateCmd()
+	if command.Use != migrateName || command.Short != migrateShortDescription || command.Long != migrateLongDescription {
+		t.Fatalf("migrate command should have use %s equal %s, short %s equal %s and long %s equal to %s", command.Use, migrateName, command.Short, migrateShortDescription, command.Long, migrateLongDescription)
+	}
+
+	expectedFlags := []string{"api-model", "to", "output-file", "force"}
+	for _, f := range expectedFlags {
+		if command.Flags().Lookup(f) == nil {
+			t.Fatalf("migrate command should have flag %s", f)
+		}
+	}
+
+	command.SetArgs([]string{})
+	if err := command.Execute(); err == nil {
+		t.Fatalf("expected an error when calling migrate with no arguments")
+	}
+}
+
+func TestMigrateCmdValidate(t *testing.T) {
+	r := &cobra.Command{}
+
+	m := &migrateCmd{toVersion: "vlabs"}
+	if err := m.validate(r, []string{"../pkg/engine/testdata/v20170701/kubernetes.json"}); err != nil {
+		t.Fatalf("unexpected error validating 1 arg: %s", err.Error())
+	}
+	if m.outputFile != "../pkg/engine/testdata/v20170701/kubernetes.vlabs.json" {
+		t.Fatalf("unexpected default output file %s", m.outputFile)
+	}
+
+	m = &migrateCmd{toVersion: "vlabs"}
+	if err := m.validate(r, []string{"../pkg/engine/testdata/v20170701/kubernetes.json", "arg1"}); err == nil {
+		t.Fatalf("expected error validating multiple args")
+	}
+
+	m = &migrateCmd{toVersion: "vlabs"}
+	if err := m.validate(r, []string{"./this/file/does/not/exist.json"}); err == nil {
+		t.Fatalf("expected error validating a missing api model")
+	}
+
+	m = &migrateCmd{toVersion: "vlabs", outputFile: "../pkg/engine/testdata/v20170701/kubernetes.json"}
+	if err := m.validate(r, []string{"../pkg/engine/testdata/v20170701/kubernetes.json"}); err == nil {
+		t.Fatalf("expected error validating an output file that overwrites the source")
+	}
+}
+
+func TestMigrateCmdRun(t *testing.T) {
+	dir, err := ioutil.TempDir("", "aks-engine-migrate")
+	if err != nil {
+		t.Fatalf("unexpected error creating temp dir: %s", err)
+	}
+	defer os.RemoveAll(dir)
+
+	r := &cobra.Command{}
+	m := &migrateCmd{
+		apimodelPath: "../pkg/engine/testdata/v20170701/kubernetes.json",
+		toVersion:    "vlabs",
+		outputFile:   filepath.Join(dir, "kubernetes.json"),
+	}
+	if err := m.validate(r, []string{}); err != nil {
+		t.Fatalf("unexpected error validating migrate: %s", err)
+	}
+	if err := m.run(); err != nil {
+		t.Fatalf("unexpected error running migrate: %s", err)
+	}
+	if _, err := os.Stat(m.outputFile); err != nil {
+		t.Fatalf("expected the migrated api model to be written to %s: %s", m.outputFile, err)
+	}
+}
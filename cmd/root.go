@@ -33,8 +33,10 @@ const (
 )
 
 var (
-	debug            bool
-	dumpDefaultModel bool
+	debug                bool
+	dumpDefaultModel     bool
+	versionCatalog       string
+	versionCatalogSHA256 string
 )
 
 // NewRootCmd returns the root command for AKS Engine.
@@ -43,10 +45,11 @@ func NewRootCmd() *cobra.Command {
 		Use:   rootName,
 		Short: rootShortDescription,
 		Long:  rootLongDescription,
-		PersistentPreRun: func(cmd *cobra.Command, args []string) {
+		PersistentPreRunE: func(cmd *cobra.Command, args []string) error {
 			if debug {
 				log.SetLevel(log.DebugLevel)
 			}
+			return loadVersionCatalog()
 		},
 		RunE: func(cmd *cobra.Command, args []string) error {
 			if dumpDefaultModel {
@@ -58,6 +61,8 @@ func NewRootCmd() *cobra.Command {
 
 	p := rootCmd.PersistentFlags()
 	p.BoolVar(&debug, "debug", false, "enable verbose debug logs")
+	p.StringVar(&versionCatalog, "version-catalog", "", "path or URL of a version catalog that adds Kubernetes versions, component versions and deprecations")
+	p.StringVar(&versionCatalogSHA256, "version-catalog-sha256", "", "expected sha256 digest of the version catalog (required with --version-catalog)")
 
 	f := rootCmd.Flags()
 	f.BoolVar(&dumpDefaultModel, "show-default-model", false, "Dump the default API model to stdout")
@@ -65,16 +70,42 @@ func NewRootCmd() *cobra.Command {
 	rootCmd.AddCommand(newVersionCmd())
 	rootCmd.AddCommand(newGenerateCmd())
 	rootCmd.AddCommand(newDeployCmd())
+	rootCmd.AddCommand(newDeleteCmd())
 	rootCmd.AddCommand(newGetVersionsCmd())
 	rootCmd.AddCommand(newOrchestratorsCmd())
 	rootCmd.AddCommand(newUpgradeCmd())
 	rootCmd.AddCommand(newScaleCmd())
 	rootCmd.AddCommand(newRotateCertsCmd())
+	rootCmd.AddCommand(newEtcdCmd())
+	rootCmd.AddCommand(newAddonsCmd())
+	rootCmd.AddCommand(newGetLogsCmd())
+	rootCmd.AddCommand(newExecCmd())
+	rootCmd.AddCommand(newImagesCmd())
+	rootCmd.AddCommand(newLintCmd())
+	rootCmd.AddCommand(newMigrateCmd())
 	rootCmd.AddCommand(getCompletionCmd(rootCmd))
 
 	return rootCmd
 }
 
+func loadVersionCatalog() error {
+	if versionCatalog == "" {
+		if versionCatalogSHA256 != "" {
+			return errors.New("--version-catalog-sha256 can only be used together with --version-catalog")
+		}
+		return nil
+	}
+	catalog, err := api.LoadVersionCatalog(versionCatalog, versionCatalogSHA256)
+	if err != nil {
+		return err
+	}
+	if err = catalog.Apply(); err != nil {
+		return err
+	}
+	log.Debugf("loaded version catalog %s", versionCatalog)
+	return nil
+}
+
 func writeDefaultModel(out io.Writer) error {
 	meta, p := api.LoadDefaultContainerServiceProperties()
 	type withMeta struct {
@@ -165,6 +196,33 @@ func (authArgs *authArgs) validateAuthArgs() error {
 	return nil
 }
 
+// hostKeyArgs are the arguments of the commands connecting to the nodes over SSH
+type hostKeyArgs struct {
+	knownHostsFile                  string
+	insecureSkipHostKeyVerification bool
+}
+
+func addHostKeyFlags(hostKeyArgs *hostKeyArgs, f *flag.FlagSet, nodes string) {
+	f.StringVar(&hostKeyArgs.knownHostsFile, "known-hosts", "", "known_hosts file to verify the host keys of the "+nodes+" against (default ~/.ssh/known_hosts)")
+	f.BoolVar(&hostKeyArgs.insecureSkipHostKeyVerification, "insecure-skip-host-key-verification", false, "do not verify the host keys of the "+nodes+", insecure")
+}
+
+// getKnownHostsFile returns the known_hosts file the host keys of the nodes are verified against, or an empty
+// string if their verification is skipped with --insecure-skip-host-key-verification
+func (hostKeyArgs *hostKeyArgs) getKnownHostsFile() (string, error) {
+	if hostKeyArgs.insecureSkipHostKeyVerification {
+		if hostKeyArgs.knownHostsFile != "" {
+			return "", errors.New("--known-hosts and --insecure-skip-host-key-verification cannot both be specified")
+		}
+		log.Warnln("The host keys of the nodes are not verified because --insecure-skip-host-key-verification is set, the connections may be intercepted")
+		return "", nil
+	}
+	if hostKeyArgs.knownHostsFile != "" {
+		return hostKeyArgs.knownHostsFile, nil
+	}
+	return filepath.Join(helpers.GetHomeDir(), ".ssh", "known_hosts"), nil
+}
+
 func getSubFromAzDir(root string) (uuid.UUID, error) {
 	subConfig, err := ini.Load(filepath.Join(root, "clouds.config"))
 	if err != nil {
@@ -6,10 +6,12 @@ package cmd
 import (
 	"io/ioutil"
 	"os"
+	"path/filepath"
 	"testing"
 
 	"github.com/Azure/aks-engine/pkg/api"
 	"github.com/Azure/aks-engine/pkg/armhelpers"
+	"github.com/Azure/aks-engine/pkg/helpers"
 	"github.com/Azure/go-autorest/autorest/azure"
 	"github.com/gofrs/uuid"
 	"github.com/spf13/cobra"
@@ -38,7 +40,7 @@ func TestNewRootCmd(t *testing.T) {
 	if command.Use != rootName || command.Short != rootShortDescription || command.Long != rootLongDescription {
 		t.Fatalf("root command should have use %s equal %s, short %s equal %s and long %s equal to %s", command.Use, rootName, command.Short, rootShortDescription, command.Long, rootLongDescription)
 	}
-	expectedCommands := []*cobra.Command{getCompletionCmd(command), newDeployCmd(), newGenerateCmd(), newGetVersionsCmd(), newOrchestratorsCmd(), newRotateCertsCmd(), newScaleCmd(), newUpgradeCmd(), newVersionCmd()}
+	expectedCommands := []*cobra.Command{newAddonsCmd(), getCompletionCmd(command), newDeleteCmd(), newDeployCmd(), newEtcdCmd(), newExecCmd(), newGenerateCmd(), newGetLogsCmd(), newGetVersionsCmd(), newImagesCmd(), newLintCmd(), newMigrateCmd(), newOrchestratorsCmd(), newRotateCertsCmd(), newScaleCmd(), newUpgradeCmd(), newVersionCmd()}
 	rc := command.Commands()
 	for i, c := range expectedCommands {
 		if rc[i].Use != c.Use {
@@ -73,6 +75,31 @@ func TestDebugArg(t *testing.T) {
 	// TODO: examine command output
 }
 
+func TestVersionCatalogArgs(t *testing.T) {
+	defer func() {
+		versionCatalog = ""
+		versionCatalogSHA256 = ""
+	}()
+
+	command := NewRootCmd()
+	command.SetArgs([]string{"--version-catalog-sha256", "abc"})
+	if err := command.Execute(); err == nil {
+		t.Fatalf("expected an error using --version-catalog-sha256 without --version-catalog")
+	}
+
+	command = NewRootCmd()
+	command.SetArgs([]string{"--version-catalog", "./this/file/does/not/exist.json", "--version-catalog-sha256", "abc"})
+	if err := command.Execute(); err == nil {
+		t.Fatalf("expected an error loading a missing version catalog")
+	}
+
+	command = NewRootCmd()
+	command.SetArgs([]string{"--version-catalog", "./this/file/does/not/exist.json"})
+	if err := command.Execute(); err == nil {
+		t.Fatalf("expected an error loading a version catalog without a digest")
+	}
+}
+
 func TestCompletionCommand(t *testing.T) {
 	command := getCompletionCmd(NewRootCmd())
 	command.SetArgs([]string{})
@@ -168,6 +195,30 @@ func TestGetCloudSubFromAzConfig(t *testing.T) {
 	}
 }
 
+func TestGetKnownHostsFile(t *testing.T) {
+	for _, test := range []struct {
+		desc   string
+		args   hostKeyArgs
+		expect string
+		err    bool
+	}{
+		{"default", hostKeyArgs{}, filepath.Join(helpers.GetHomeDir(), ".ssh", "known_hosts"), false},
+		{"known hosts file", hostKeyArgs{knownHostsFile: "/tmp/known_hosts"}, "/tmp/known_hosts", false},
+		{"verification skipped", hostKeyArgs{insecureSkipHostKeyVerification: true}, "", false},
+		{"known hosts file and verification skipped", hostKeyArgs{knownHostsFile: "/tmp/known_hosts", insecureSkipHostKeyVerification: true}, "", true},
+	} {
+		t.Run(test.desc, func(t *testing.T) {
+			knownHostsFile, err := test.args.getKnownHostsFile()
+			if test.err != (err != nil) {
+				t.Fatalf("expected err=%v, got: %v", test.err, err)
+			}
+			if knownHostsFile != test.expect {
+				t.Fatalf("expected %q, got %q", test.expect, knownHostsFile)
+			}
+		})
+	}
+}
+
 func TestWriteCustomCloudProfile(t *testing.T) {
 	const (
 		name                         = "azurestackcloud"
@@ -19,6 +19,7 @@ import (
 	"github.com/Azure/aks-engine/pkg/engine"
 	"github.com/Azure/aks-engine/pkg/engine/transform"
 	"github.com/Azure/aks-engine/pkg/i18n"
+	"github.com/Azure/aks-engine/pkg/operations/remotessh"
 	"github.com/leonelquinteros/gotext"
 	"github.com/pkg/errors"
 	log "github.com/sirupsen/logrus"
@@ -36,6 +37,7 @@ const (
 
 type rotateCertsCmd struct {
 	authProvider
+	hostKeyArgs
 
 	// user input
 	resourceGroupName string
@@ -75,6 +77,7 @@ func newRotateCertsCmd() *cobra.Command {
 	f.StringVarP(&rcc.apiModelPath, "api-model", "m", "", "path to the generated apimodel.json file (required)")
 	f.StringVarP(&rcc.sshFilepath, "ssh", "", "", "the filepath of a valid private ssh key to access the cluster's nodes (required)")
 	f.StringVar(&rcc.masterFQDN, "master-FQDN", "", "FQDN for the master load balancer (required)")
+	addHostKeyFlags(&rcc.hostKeyArgs, f, "nodes")
 	f.StringVarP(&rcc.outputDirectory, "output-directory", "o", "", "output directory where generated TLS artifacts will be saved (derived from DNS prefix if absent)")
 	addAuthFlags(rcc.getAuthArgs(), f)
 
@@ -151,7 +154,9 @@ func (rcc *rotateCertsCmd) run(cmd *cobra.Command, args []string) error {
 	if _, err = os.Stat(rcc.sshFilepath); os.IsNotExist(err) {
 		return errors.Errorf("specified ssh filepath does not exist (%s)", rcc.sshFilepath)
 	}
-	rcc.setSSHConfig()
+	if err = rcc.setSSHConfig(); err != nil {
+		return err
+	}
 
 	log.Infoln("Rotating apiserver certificate")
 
@@ -445,59 +450,33 @@ func (rcc *rotateCertsCmd) rotateKubelet() error {
 	return nil
 }
 
-func (rcc *rotateCertsCmd) setSSHConfig() {
-	rcc.sshConfig = &ssh.ClientConfig{
-		HostKeyCallback: ssh.InsecureIgnoreHostKey(),
-		User:            "azureuser",
-		Auth: []ssh.AuthMethod{
-			publicKeyFile(rcc.sshFilepath),
-		},
-	}
-}
-
-func publicKeyFile(file string) ssh.AuthMethod {
-	buffer, err := ioutil.ReadFile(file)
+func (rcc *rotateCertsCmd) setSSHConfig() error {
+	sshKey, err := ioutil.ReadFile(rcc.sshFilepath)
 	if err != nil {
-		return nil
+		return errors.Wrapf(err, "reading ssh key %s", rcc.sshFilepath)
 	}
-	key, err := ssh.ParsePrivateKey(buffer)
+	knownHostsFile, err := rcc.getKnownHostsFile()
 	if err != nil {
-		return nil
+		return err
 	}
-	return ssh.PublicKeys(key)
+	rcc.sshConfig, err = remotessh.ClientConfig("azureuser", sshKey, knownHostsFile)
+	return err
 }
 
 func executeCmd(command, masterFQDN, hostname string, port string, config *ssh.ClientConfig) (string, error) {
-	// Dial connection to the master via public load balancer
-	lbClient, err := ssh.Dial("tcp", fmt.Sprintf("%s:%s", masterFQDN, port), config)
-	if err != nil {
-		return "", errors.Wrap(err, "Dialing LB")
-	}
-
-	// Dial a connection to the agent host, from the master
-	conn, err := lbClient.Dial("tcp", fmt.Sprintf("%s:%s", hostname, port))
+	p, err := strconv.Atoi(port)
 	if err != nil {
-		return "", errors.Wrap(err, "Dialing host")
+		return "", errors.Wrapf(err, "parsing port %s", port)
 	}
-
-	ncc, chans, reqs, err := ssh.NewClientConn(conn, hostname, config)
-	if err != nil {
-		return "", errors.Wrap(err, "starting new client connection to host")
-	}
-
-	sClient := ssh.NewClient(ncc, chans, reqs)
-
-	session, err := sClient.NewSession()
-
-	if err != nil {
-		return "", errors.Wrap(err, "opening SSH session")
+	// Connect to the host from the master reached through the public load balancer
+	host := &remotessh.Host{
+		Address:  hostname,
+		Port:     p,
+		JumpHost: &remotessh.Host{Address: masterFQDN, Port: p},
 	}
-	defer session.Close()
 
 	var stdoutBuf bytes.Buffer
-	session.Stdout = &stdoutBuf
-
-	err = session.Run(command)
+	err = remotessh.Run(host, config, command, nil, &stdoutBuf, nil)
 	if err != nil {
 		return fmt.Sprintf("%s -> %s", hostname, stdoutBuf.String()), errors.Wrap(err, "running command")
 	}
@@ -5,6 +5,11 @@ package cmd
 
 import (
 	"context"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/pem"
+	"io/ioutil"
 	"os"
 	"testing"
 
@@ -33,7 +38,7 @@ func TestNewRotateCertsCmd(t *testing.T) {
 		t.Fatalf("rotate-certs command should have use %s equal %s, short %s equal %s and long %s equal to %s", output.Use, rotateCertsName, output.Short, rotateCertsShortDescription, output.Long, rotateCertsLongDescription)
 	}
 
-	expectedFlags := []string{"location", "resource-group", "master-FQDN", "api-model", "ssh"}
+	expectedFlags := []string{"location", "resource-group", "master-FQDN", "api-model", "ssh", "known-hosts"}
 	for _, f := range expectedFlags {
 		if output.Flags().Lookup(f) == nil {
 			t.Fatalf("rotate-certs command should have flag %s", f)
@@ -54,6 +59,8 @@ func TestRotateCertsCmdRun(t *testing.T) {
 		sshFilepath:        "_test_ssh",
 		sshCommandExecuter: mockExecuteCmd,
 		masterFQDN:         "valid",
+		// the ssh commands are mocked
+		hostKeyArgs: hostKeyArgs{insecureSkipHostKeyVerification: true},
 	}
 
 	r := &cobra.Command{}
@@ -61,7 +68,11 @@ func TestRotateCertsCmdRun(t *testing.T) {
 
 	addAuthFlags(rcc.getAuthArgs(), f)
 
-	_, err := os.Create(rcc.sshFilepath)
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatalf("unable to generate test ssh key: %s", err.Error())
+	}
+	err = ioutil.WriteFile(rcc.sshFilepath, pem.EncodeToMemory(&pem.Block{Type: "RSA PRIVATE KEY", Bytes: x509.MarshalPKCS1PrivateKey(key)}), 0600)
 	if err != nil {
 		t.Fatalf("unable to create test sshFilepath: %s", err.Error())
 	}
@@ -8,9 +8,11 @@ import (
 	"fmt"
 	"os"
 	"path/filepath"
+	"strings"
 	"time"
 
 	"github.com/Azure/aks-engine/pkg/api"
+	"github.com/Azure/aks-engine/pkg/api/common"
 	"github.com/Azure/aks-engine/pkg/armhelpers"
 	"github.com/Azure/aks-engine/pkg/engine"
 	"github.com/Azure/aks-engine/pkg/helpers"
@@ -31,6 +33,7 @@ const (
 
 type upgradeCmd struct {
 	authProvider
+	hostKeyArgs
 
 	// user input
 	resourceGroupName   string
@@ -40,6 +43,13 @@ type upgradeCmd struct {
 	location            string
 	timeoutInMinutes    int
 	force               bool
+	nodeImageOnly       bool
+	controlPlaneOnly    bool
+	nodePools           []string
+	autoPath            bool
+	skipPreflight       bool
+	etcdBackupPath      string
+	sshFilepath         string
 
 	// derived
 	containerService    *api.ContainerService
@@ -48,6 +58,8 @@ type upgradeCmd struct {
 	locale              *gotext.Locale
 	nameSuffix          string
 	agentPoolsToUpgrade map[string]bool
+	currentVersion      string
+	upgradePath         []string
 	timeout             *time.Duration
 }
 
@@ -68,9 +80,17 @@ func newUpgradeCmd() *cobra.Command {
 	f.StringVarP(&uc.resourceGroupName, "resource-group", "g", "", "the resource group where the cluster is deployed (required)")
 	f.StringVarP(&uc.apiModelPath, "api-model", "m", "", "path to the generated apimodel.json file")
 	f.StringVar(&uc.deploymentDirectory, "deployment-dir", "", "the location of the output from `generate`")
-	f.StringVarP(&uc.upgradeVersion, "upgrade-version", "k", "", "desired kubernetes version (required)")
+	f.StringVarP(&uc.upgradeVersion, "upgrade-version", "k", "", "desired kubernetes version (required unless --node-image-only is set)")
 	f.IntVar(&uc.timeoutInMinutes, "vm-timeout", -1, "how long to wait for each vm to be upgraded in minutes")
 	f.BoolVarP(&uc.force, "force", "f", false, "force upgrading the cluster to desired version. Allows same version upgrades and downgrades.")
+	f.BoolVar(&uc.nodeImageOnly, "node-image-only", false, "roll the agent nodes onto the latest OS image without changing the Kubernetes version. Masters are not upgraded")
+	f.BoolVar(&uc.controlPlaneOnly, "control-plane-only", false, "upgrade the masters only, the agent pools keep their current version")
+	f.StringSliceVar(&uc.nodePools, "node-pools", []string{}, "comma-separated names of the agent pools to upgrade, the masters and other agent pools keep their current version")
+	f.StringVar(&uc.etcdBackupPath, "etcd-backup", "", "path of a file to save an etcd snapshot to before the masters are upgraded, requires --ssh")
+	f.StringVar(&uc.sshFilepath, "ssh", "", "the filepath of a valid private ssh key to access the cluster's masters")
+	addHostKeyFlags(&uc.hostKeyArgs, f, "masters when --etcd-backup is set")
+	f.BoolVar(&uc.autoPath, "auto-path", false, "upgrade through the intermediate Kubernetes versions needed to reach --upgrade-version, one at a time")
+	f.BoolVar(&uc.skipPreflight, "skip-preflight", false, "do not run the upgrade preflight checks")
 	addAuthFlags(uc.getAuthArgs(), f)
 
 	f.MarkDeprecated("deployment-dir", "deployment-dir is no longer required for scale or upgrade. Please use --api-model.")
@@ -102,11 +122,37 @@ func (uc *upgradeCmd) validate(cmd *cobra.Command) error {
 		uc.timeout = &timeout
 	}
 
-	if uc.upgradeVersion == "" {
+	if uc.upgradeVersion == "" && !uc.nodeImageOnly {
 		cmd.Usage()
 		return errors.New("--upgrade-version must be specified")
 	}
 
+	if uc.controlPlaneOnly && len(uc.nodePools) > 0 {
+		cmd.Usage()
+		return errors.New("--control-plane-only and --node-pools are mutually exclusive")
+	}
+
+	if uc.controlPlaneOnly && uc.nodeImageOnly {
+		cmd.Usage()
+		return errors.New("--node-image-only does not upgrade the masters, it cannot be used with --control-plane-only")
+	}
+
+	if uc.autoPath && (uc.nodeImageOnly || uc.controlPlaneOnly || len(uc.nodePools) > 0 || uc.force) {
+		cmd.Usage()
+		return errors.New("--auto-path upgrades the whole cluster, it cannot be used with --node-image-only, --control-plane-only, --node-pools or --force")
+	}
+
+	if uc.etcdBackupPath != "" {
+		if uc.nodeImageOnly || len(uc.nodePools) > 0 {
+			cmd.Usage()
+			return errors.New("--etcd-backup cannot be used with --node-image-only or --node-pools, the masters are not upgraded")
+		}
+		if uc.sshFilepath == "" {
+			cmd.Usage()
+			return errors.New("--ssh must be specified with --etcd-backup")
+		}
+	}
+
 	if uc.apiModelPath == "" && uc.deploymentDirectory == "" {
 		cmd.Usage()
 		return errors.New("--api-model must be specified")
@@ -202,23 +248,158 @@ func (uc *upgradeCmd) initialize() error {
 		return errors.New("--location does not match api model location")
 	}
 
-	if !uc.force {
-		err := uc.validateTargetVersion()
+	agentPools, err := uc.getAgentPoolsToUpgrade()
+	if err != nil {
+		return err
+	}
+
+	currentVersion := uc.containerService.Properties.OrchestratorProfile.OrchestratorVersion
+	uc.currentVersion = currentVersion
+	switch {
+	case uc.nodeImageOnly:
+		if uc.upgradeVersion != "" && uc.upgradeVersion != currentVersion {
+			return errors.Errorf("--node-image-only does not change the Kubernetes version, --upgrade-version must be omitted or match the current version %s", currentVersion)
+		}
+		uc.upgradeVersion = currentVersion
+	case len(uc.nodePools) > 0:
+		if err = uc.validateAgentPoolsTargetVersion(agentPools); err != nil {
+			return err
+		}
+		if uc.upgradeVersion != currentVersion {
+			if err = uc.validateAgentPoolsOwnVersion(agentPools); err != nil {
+				return err
+			}
+		}
+		for _, agentPool := range agentPools {
+			// an agent pool that catches up with the control plane no longer records a version of its own
+			if uc.upgradeVersion == currentVersion {
+				agentPool.OrchestratorVersion = ""
+			} else {
+				agentPool.OrchestratorVersion = uc.upgradeVersion
+			}
+		}
+	case uc.autoPath:
+		uc.upgradePath, err = api.GetKubernetesUpgradePath(currentVersion, uc.upgradeVersion, uc.containerService.Properties.HasWindows())
 		if err != nil {
-			return errors.Wrap(err, "Invalid upgrade target version. Consider using --force if you really want to proceed")
+			return errors.Wrap(err, "Invalid upgrade target version")
+		}
+		log.Infof("Upgrade path: %s -> %s", currentVersion, strings.Join(uc.upgradePath, " -> "))
+		for _, agentPool := range uc.containerService.Properties.AgentPoolProfiles {
+			agentPool.OrchestratorVersion = ""
+		}
+	default:
+		if !uc.force {
+			if err = uc.validateTargetVersion(); err != nil {
+				return errors.Wrap(err, "Invalid upgrade target version. Consider using --force if you really want to proceed")
+			}
 		}
+		if uc.controlPlaneOnly {
+			if err = uc.validateAgentPoolsOwnVersion(agentPools); err != nil {
+				return err
+			}
+		}
+		for _, agentPool := range uc.containerService.Properties.AgentPoolProfiles {
+			if uc.controlPlaneOnly {
+				// record the version the agent pools keep
+				agentPool.OrchestratorVersion = uc.containerService.Properties.GetAgentPoolOrchestratorVersion(agentPool)
+				if !common.IsSupportedKubernetesVersionSkew(uc.upgradeVersion, agentPool.OrchestratorVersion) {
+					return errors.Errorf("agent pool %s on Kubernetes version %s cannot run against a control plane on version %s, upgrade the agent pool first with --node-pools", agentPool.Name, agentPool.OrchestratorVersion, uc.upgradeVersion)
+				}
+			} else {
+				agentPool.OrchestratorVersion = ""
+			}
+		}
+		uc.containerService.Properties.OrchestratorProfile.OrchestratorVersion = uc.upgradeVersion
 	}
-	uc.containerService.Properties.OrchestratorProfile.OrchestratorVersion = uc.upgradeVersion
 
 	//allows to identify VMs in the resource group that belong to this cluster.
 	uc.nameSuffix = uc.containerService.Properties.GetClusterID()
 
-	log.Infoln(fmt.Sprintf("Upgrading cluster with name suffix: %s", uc.nameSuffix))
+	if uc.nodeImageOnly {
+		log.Infoln(fmt.Sprintf("Refreshing node images of cluster with name suffix: %s", uc.nameSuffix))
+	} else {
+		log.Infoln(fmt.Sprintf("Upgrading cluster with name suffix: %s", uc.nameSuffix))
+	}
 
 	uc.agentPoolsToUpgrade = make(map[string]bool)
-	uc.agentPoolsToUpgrade[kubernetesupgrade.MasterPoolName] = true
-	for _, agentPool := range uc.containerService.Properties.AgentPoolProfiles {
-		uc.agentPoolsToUpgrade[agentPool.Name] = true
+	if !uc.nodeImageOnly && len(uc.nodePools) == 0 {
+		uc.agentPoolsToUpgrade[kubernetesupgrade.MasterPoolName] = true
+	}
+	if !uc.controlPlaneOnly {
+		for _, agentPool := range agentPools {
+			uc.agentPoolsToUpgrade[agentPool.Name] = true
+		}
+	}
+	return nil
+}
+
+// getAgentPoolsToUpgrade returns the agent pools selected with --node-pools, or all the agent pools
+func (uc *upgradeCmd) getAgentPoolsToUpgrade() ([]*api.AgentPoolProfile, error) {
+	if len(uc.nodePools) == 0 {
+		return uc.containerService.Properties.AgentPoolProfiles, nil
+	}
+	var agentPools []*api.AgentPoolProfile
+	for _, name := range uc.nodePools {
+		var found *api.AgentPoolProfile
+		for _, agentPool := range uc.containerService.Properties.AgentPoolProfiles {
+			if agentPool.Name == name {
+				found = agentPool
+				break
+			}
+		}
+		if found == nil {
+			return nil, errors.Errorf("agent pool %s does not exist in the api model", name)
+		}
+		agentPools = append(agentPools, found)
+	}
+	return agentPools, nil
+}
+
+// validateAgentPoolsTargetVersion validates the version the agent pools selected with --node-pools are upgraded to,
+// which cannot be newer than the control plane
+func (uc *upgradeCmd) validateAgentPoolsTargetVersion(agentPools []*api.AgentPoolProfile) error {
+	properties := uc.containerService.Properties
+	controlPlaneVersion := properties.OrchestratorProfile.OrchestratorVersion
+	if uc.upgradeVersion != controlPlaneVersion && !common.IsSupportedKubernetesVersionSkew(controlPlaneVersion, uc.upgradeVersion) {
+		return errors.Errorf("agent pools cannot be upgraded to Kubernetes version %s with a control plane on version %s, upgrade the control plane first with --control-plane-only", uc.upgradeVersion, controlPlaneVersion)
+	}
+	if uc.force {
+		return nil
+	}
+	for _, agentPool := range agentPools {
+		poolVersion := &api.OrchestratorProfile{
+			OrchestratorType:    api.Kubernetes,
+			OrchestratorVersion: properties.GetAgentPoolOrchestratorVersion(agentPool),
+		}
+		orchestratorInfo, err := api.GetOrchestratorVersionProfile(poolVersion, properties.HasWindows())
+		if err != nil {
+			return errors.Wrap(err, "error getting list of available upgrades")
+		}
+		found := false
+		for _, up := range orchestratorInfo.Upgrades {
+			if up.OrchestratorVersion == uc.upgradeVersion {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return errors.Errorf("Invalid upgrade target version. Upgrading agent pool %s from Kubernetes version %s to version %s is not supported. Consider using --force if you really want to proceed", agentPool.Name, poolVersion.OrchestratorVersion, uc.upgradeVersion)
+		}
+	}
+	return nil
+}
+
+// validateAgentPoolsOwnVersion validates that the agent pools can run a Kubernetes version other than the control plane one,
+// which is recorded in their orchestratorVersion and not supported for Windows agent pools or with customHyperkubeImage
+func (uc *upgradeCmd) validateAgentPoolsOwnVersion(agentPools []*api.AgentPoolProfile) error {
+	kubernetesConfig := uc.containerService.Properties.OrchestratorProfile.KubernetesConfig
+	if kubernetesConfig != nil && kubernetesConfig.CustomHyperkubeImage != "" {
+		return errors.New("agent pools cannot run a Kubernetes version other than the control plane one with customHyperkubeImage, upgrade the masters and agent pools together")
+	}
+	for _, agentPool := range agentPools {
+		if agentPool.IsWindows() {
+			return errors.Errorf("Windows agent pool %s cannot run a Kubernetes version other than the control plane one, upgrade the masters and agent pools together", agentPool.Name)
+		}
 	}
 	return nil
 }
@@ -234,6 +415,99 @@ func (uc *upgradeCmd) run(cmd *cobra.Command, args []string) error {
 		return errors.Wrap(err, "loading existing cluster")
 	}
 
+	err = uc.runPreflightChecks()
+	if err != nil {
+		return errors.Wrap(err, "running upgrade preflight checks")
+	}
+
+	if uc.etcdBackupPath != "" {
+		err = uc.backupEtcd()
+		if err != nil {
+			return errors.Wrap(err, "backing up etcd")
+		}
+	}
+
+	if !uc.autoPath {
+		return uc.upgrade()
+	}
+	for i, version := range uc.upgradePath {
+		log.Infof("Upgrading to Kubernetes version %s (%d of %d)", version, i+1, len(uc.upgradePath))
+		uc.containerService.Properties.OrchestratorProfile.OrchestratorVersion = version
+		if err = uc.upgrade(); err != nil {
+			return errors.Wrapf(err, "upgrade to Kubernetes version %s failed. The api model records the last completed upgrade, run the same command again to resume", version)
+		}
+	}
+	return nil
+}
+
+// runPreflightChecks reports the conditions that are likely to break the upgrade, which is blocked unless --force is set.
+// The checks are not run at all if --skip-preflight is set
+func (uc *upgradeCmd) runPreflightChecks() error {
+	if uc.skipPreflight {
+		log.Warnln("Skipping the upgrade preflight checks because --skip-preflight is set")
+		return nil
+	}
+	kubeConfig, err := engine.GenerateKubeConfig(uc.containerService.Properties, uc.location)
+	if err != nil {
+		return errors.Wrap(err, "generating kubeconfig")
+	}
+	kubeClient, err := uc.client.GetKubernetesClient("", kubeConfig, time.Second*1, time.Duration(60)*time.Minute)
+	if err != nil {
+		return errors.Wrap(err, "failed to get a Kubernetes client")
+	}
+
+	targetVersions := uc.upgradePath
+	if len(targetVersions) == 0 {
+		targetVersions = []string{uc.upgradeVersion}
+	}
+	preflight := &kubernetesupgrade.UpgradePreflight{
+		Client:         kubeClient,
+		DataModel:      uc.containerService,
+		CurrentVersion: uc.currentVersion,
+		TargetVersions: targetVersions,
+	}
+	issues, err := preflight.Run()
+	if err != nil {
+		return err
+	}
+	if len(issues) == 0 {
+		log.Infoln("Upgrade preflight checks passed")
+		return nil
+	}
+	for _, issue := range issues {
+		log.Warnln(issue)
+	}
+	if uc.force {
+		log.Warnln("Proceeding with the upgrade despite the preflight issues because --force is set")
+		return nil
+	}
+	return errors.New("the cluster has issues that are likely to break the upgrade, fix them, or use --skip-preflight or --force to proceed anyway")
+}
+
+// backupEtcd saves an etcd snapshot to the file given with --etcd-backup
+func (uc *upgradeCmd) backupEtcd() error {
+	knownHostsFile, err := uc.getKnownHostsFile()
+	if err != nil {
+		return err
+	}
+	etcdBackup, err := getEtcdBackup(uc.containerService, uc.location, "", uc.sshFilepath, knownHostsFile)
+	if err != nil {
+		return err
+	}
+	snapshot, err := etcdBackup.Save()
+	if err != nil {
+		return errors.Wrap(err, "taking etcd snapshot")
+	}
+	if err = saveEtcdSnapshot(snapshot, uc.etcdBackupPath, uc.locale); err != nil {
+		return errors.Wrap(err, "saving etcd snapshot")
+	}
+	log.Infof("etcd snapshot saved to %s", uc.etcdBackupPath)
+	return nil
+}
+
+// upgrade runs a single upgrade of the cluster to the version of the api model,
+// and saves the api model once it completes
+func (uc *upgradeCmd) upgrade() error {
 	upgradeCluster := kubernetesupgrade.UpgradeCluster{
 		Translator: &i18n.Translator{
 			Locale: uc.locale,
@@ -250,6 +524,10 @@ func (uc *upgradeCmd) run(cmd *cobra.Command, args []string) error {
 	upgradeCluster.NameSuffix = uc.nameSuffix
 	upgradeCluster.AgentPoolsToUpgrade = uc.agentPoolsToUpgrade
 	upgradeCluster.Force = uc.force
+	upgradeCluster.NodeImageOnly = uc.nodeImageOnly
+	upgradeCluster.ControlPlaneOnly = uc.controlPlaneOnly
+	upgradeCluster.AgentPoolsOnly = len(uc.nodePools) > 0
+	upgradeCluster.ValidateUpgrade = uc.autoPath
 
 	kubeConfig, err := engine.GenerateKubeConfig(uc.containerService.Properties, uc.location)
 	if err != nil {
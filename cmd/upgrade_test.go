@@ -9,7 +9,10 @@ import (
 	"github.com/Azure/aks-engine/pkg/api/common"
 
 	"github.com/Azure/aks-engine/pkg/api"
+	"github.com/Azure/aks-engine/pkg/api/vlabs"
 	"github.com/Azure/aks-engine/pkg/armhelpers"
+	"github.com/Azure/aks-engine/pkg/i18n"
+	"github.com/Azure/aks-engine/pkg/operations/kubernetesupgrade"
 
 	. "github.com/onsi/gomega"
 	"github.com/pkg/errors"
@@ -100,6 +103,63 @@ func TestUpgradeCommandShouldBeValidated(t *testing.T) {
 			},
 			expectedErr: nil,
 		},
+		{
+			uc: &upgradeCmd{
+				resourceGroupName:   "test",
+				apiModelPath:        "./not/used",
+				deploymentDirectory: "",
+				upgradeVersion:      "",
+				location:            "southcentralus",
+				nodeImageOnly:       true,
+			},
+			expectedErr: nil,
+		},
+		{
+			uc: &upgradeCmd{
+				resourceGroupName:   "test",
+				apiModelPath:        "./not/used",
+				deploymentDirectory: "",
+				upgradeVersion:      "1.9.0",
+				location:            "southcentralus",
+				controlPlaneOnly:    true,
+				nodePools:           []string{"agentpool1"},
+			},
+			expectedErr: errors.New("--control-plane-only and --node-pools are mutually exclusive"),
+		},
+		{
+			uc: &upgradeCmd{
+				resourceGroupName:   "test",
+				apiModelPath:        "./not/used",
+				deploymentDirectory: "",
+				location:            "southcentralus",
+				controlPlaneOnly:    true,
+				nodeImageOnly:       true,
+			},
+			expectedErr: errors.New("--node-image-only does not upgrade the masters, it cannot be used with --control-plane-only"),
+		},
+		{
+			uc: &upgradeCmd{
+				resourceGroupName:   "test",
+				apiModelPath:        "./not/used",
+				deploymentDirectory: "",
+				upgradeVersion:      "1.9.0",
+				location:            "southcentralus",
+				autoPath:            true,
+				nodePools:           []string{"agentpool1"},
+			},
+			expectedErr: errors.New("--auto-path upgrades the whole cluster, it cannot be used with --node-image-only, --control-plane-only, --node-pools or --force"),
+		},
+		{
+			uc: &upgradeCmd{
+				resourceGroupName:   "test",
+				apiModelPath:        "./not/used",
+				deploymentDirectory: "",
+				upgradeVersion:      "1.9.0",
+				location:            "southcentralus",
+				etcdBackupPath:      "snapshot.db",
+			},
+			expectedErr: errors.New("--ssh must be specified with --etcd-backup"),
+		},
 	}
 
 	for _, c := range cases {
@@ -271,3 +331,245 @@ func TestUpgradeForceDowngradeShouldSetVersionOnContainerService(t *testing.T) {
 	g.Expect(upgradeCmd.containerService.Properties.OrchestratorProfile.OrchestratorVersion).To(Equal("1.10.12"))
 	resetValidVersions()
 }
+
+func TestUpgradeNodeImageOnlyShouldKeepVersionAndSkipMasters(t *testing.T) {
+	g := NewGomegaWithT(t)
+	upgradeCmd := &upgradeCmd{
+		resourceGroupName: "rg",
+		apiModelPath:      "./not/used",
+		location:          "centralus",
+		timeoutInMinutes:  60,
+		nodeImageOnly:     true,
+
+		client: &armhelpers.MockAKSEngineClient{},
+	}
+
+	containerServiceMock := api.CreateMockContainerService("testcluster", "1.10.13", 3, 2, false)
+	containerServiceMock.Location = "centralus"
+	upgradeCmd.containerService = containerServiceMock
+	err := upgradeCmd.initialize()
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(upgradeCmd.upgradeVersion).To(Equal("1.10.13"))
+	g.Expect(upgradeCmd.containerService.Properties.OrchestratorProfile.OrchestratorVersion).To(Equal("1.10.13"))
+	g.Expect(upgradeCmd.agentPoolsToUpgrade).NotTo(HaveKey(kubernetesupgrade.MasterPoolName))
+	g.Expect(upgradeCmd.agentPoolsToUpgrade).To(HaveKey(containerServiceMock.Properties.AgentPoolProfiles[0].Name))
+}
+
+func TestUpgradeNodeImageOnlyShouldFailForDifferentVersion(t *testing.T) {
+	g := NewGomegaWithT(t)
+	upgradeCmd := &upgradeCmd{
+		resourceGroupName: "rg",
+		apiModelPath:      "./not/used",
+		upgradeVersion:    "1.11.10",
+		location:          "centralus",
+		timeoutInMinutes:  60,
+		nodeImageOnly:     true,
+
+		client: &armhelpers.MockAKSEngineClient{},
+	}
+
+	containerServiceMock := api.CreateMockContainerService("testcluster", "1.10.13", 3, 2, false)
+	containerServiceMock.Location = "centralus"
+	upgradeCmd.containerService = containerServiceMock
+	err := upgradeCmd.initialize()
+	g.Expect(err).To(HaveOccurred())
+	g.Expect(err.Error()).To(ContainSubstring("--node-image-only does not change the Kubernetes version"))
+}
+
+func TestUpgradeControlPlaneOnlyShouldRecordAgentPoolVersions(t *testing.T) {
+	setupValidVersions(map[string]bool{
+		"1.10.12": true,
+		"1.10.13": true,
+	})
+	defer resetValidVersions()
+	g := NewGomegaWithT(t)
+	upgradeCmd := &upgradeCmd{
+		resourceGroupName: "rg",
+		apiModelPath:      "./not/used",
+		upgradeVersion:    "1.10.13",
+		location:          "centralus",
+		timeoutInMinutes:  60,
+		controlPlaneOnly:  true,
+
+		client: &armhelpers.MockAKSEngineClient{},
+	}
+
+	containerServiceMock := api.CreateMockContainerService("testcluster", "1.10.12", 3, 2, false)
+	containerServiceMock.Location = "centralus"
+	upgradeCmd.containerService = containerServiceMock
+	err := upgradeCmd.initialize()
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(containerServiceMock.Properties.OrchestratorProfile.OrchestratorVersion).To(Equal("1.10.13"))
+	g.Expect(containerServiceMock.Properties.AgentPoolProfiles[0].OrchestratorVersion).To(Equal("1.10.12"))
+	g.Expect(upgradeCmd.agentPoolsToUpgrade).To(Equal(map[string]bool{kubernetesupgrade.MasterPoolName: true}))
+}
+
+func TestUpgradeControlPlaneOnlyShouldWriteValidAPIModel(t *testing.T) {
+	setupValidVersions(map[string]bool{
+		"1.13.5": true,
+		"1.14.1": true,
+	})
+	defer resetValidVersions()
+	g := NewGomegaWithT(t)
+	apiloader := &api.Apiloader{
+		Translator: &i18n.Translator{
+			Locale: nil,
+		},
+	}
+
+	newUpgradeCmd := func() *upgradeCmd {
+		containerServiceMock := api.CreateMockContainerService("testcluster", "1.13.5", 3, 2, false)
+		containerServiceMock.Location = "centralus"
+		return &upgradeCmd{
+			resourceGroupName: "rg",
+			apiModelPath:      "./not/used",
+			upgradeVersion:    "1.14.1",
+			location:          "centralus",
+			timeoutInMinutes:  60,
+			controlPlaneOnly:  true,
+			containerService:  containerServiceMock,
+
+			client: &armhelpers.MockAKSEngineClient{},
+		}
+	}
+
+	uc := newUpgradeCmd()
+	err := uc.initialize()
+	g.Expect(err).NotTo(HaveOccurred())
+	b, err := apiloader.SerializeContainerService(uc.containerService, vlabs.APIVersion)
+	g.Expect(err).NotTo(HaveOccurred())
+	cs, _, err := apiloader.DeserializeContainerService(b, true, true, nil)
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(cs.Properties.OrchestratorProfile.OrchestratorVersion).To(Equal("1.14.1"))
+	g.Expect(cs.Properties.AgentPoolProfiles[0].OrchestratorVersion).To(Equal("1.13.5"))
+
+	uc = newUpgradeCmd()
+	uc.containerService.Properties.AgentPoolProfiles[0].OSType = api.Windows
+	err = uc.initialize()
+	g.Expect(err).To(HaveOccurred())
+	g.Expect(err.Error()).To(Equal("Windows agent pool agentpool1 cannot run a Kubernetes version other than the control plane one, upgrade the masters and agent pools together"))
+	g.Expect(uc.containerService.Properties.AgentPoolProfiles[0].OrchestratorVersion).To(BeEmpty())
+
+	uc = newUpgradeCmd()
+	uc.containerService.Properties.OrchestratorProfile.KubernetesConfig.CustomHyperkubeImage = "example.azurecr.io/hyperkube-amd64:custom"
+	err = uc.initialize()
+	g.Expect(err).To(HaveOccurred())
+	g.Expect(err.Error()).To(Equal("agent pools cannot run a Kubernetes version other than the control plane one with customHyperkubeImage, upgrade the masters and agent pools together"))
+}
+
+func TestUpgradeNodePools(t *testing.T) {
+	setupValidVersions(map[string]bool{
+		"1.10.12": true,
+		"1.10.13": true,
+		"1.11.9":  true,
+	})
+	defer resetValidVersions()
+	g := NewGomegaWithT(t)
+
+	newUpgradeCmd := func(upgradeVersion string, nodePools ...string) *upgradeCmd {
+		containerServiceMock := api.CreateMockContainerService("testcluster", "1.10.13", 3, 2, false)
+		containerServiceMock.Location = "centralus"
+		containerServiceMock.Properties.AgentPoolProfiles[0].OrchestratorVersion = "1.10.12"
+		return &upgradeCmd{
+			resourceGroupName: "rg",
+			apiModelPath:      "./not/used",
+			upgradeVersion:    upgradeVersion,
+			location:          "centralus",
+			timeoutInMinutes:  60,
+			nodePools:         nodePools,
+			containerService:  containerServiceMock,
+
+			client: &armhelpers.MockAKSEngineClient{},
+		}
+	}
+
+	uc := newUpgradeCmd("1.10.13", "agentpool1")
+	err := uc.initialize()
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(uc.containerService.Properties.OrchestratorProfile.OrchestratorVersion).To(Equal("1.10.13"))
+	g.Expect(uc.containerService.Properties.AgentPoolProfiles[0].OrchestratorVersion).To(BeEmpty())
+	g.Expect(uc.agentPoolsToUpgrade).To(Equal(map[string]bool{"agentpool1": true}))
+
+	uc = newUpgradeCmd("1.11.9", "agentpool1")
+	err = uc.initialize()
+	g.Expect(err).To(HaveOccurred())
+	g.Expect(err.Error()).To(ContainSubstring("upgrade the control plane first with --control-plane-only"))
+
+	uc = newUpgradeCmd("1.10.13", "missingpool")
+	err = uc.initialize()
+	g.Expect(err).To(HaveOccurred())
+	g.Expect(err.Error()).To(Equal("agent pool missingpool does not exist in the api model"))
+}
+
+func TestUpgradeAutoPath(t *testing.T) {
+	setupValidVersions(map[string]bool{
+		"1.10.12": true,
+		"1.10.13": true,
+		"1.11.9":  true,
+		"1.12.8":  true,
+	})
+	defer resetValidVersions()
+	g := NewGomegaWithT(t)
+
+	newUpgradeCmd := func(upgradeVersion string) *upgradeCmd {
+		containerServiceMock := api.CreateMockContainerService("testcluster", "1.10.12", 3, 2, false)
+		containerServiceMock.Location = "centralus"
+		containerServiceMock.Properties.AgentPoolProfiles[0].OrchestratorVersion = "1.10.12"
+		return &upgradeCmd{
+			resourceGroupName: "rg",
+			apiModelPath:      "./not/used",
+			upgradeVersion:    upgradeVersion,
+			location:          "centralus",
+			timeoutInMinutes:  60,
+			autoPath:          true,
+			containerService:  containerServiceMock,
+
+			client: &armhelpers.MockAKSEngineClient{},
+		}
+	}
+
+	uc := newUpgradeCmd("1.12.8")
+	err := uc.initialize()
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(uc.upgradePath).To(Equal([]string{"1.11.9", "1.12.8"}))
+	g.Expect(uc.containerService.Properties.OrchestratorProfile.OrchestratorVersion).To(Equal("1.10.12"))
+	g.Expect(uc.containerService.Properties.AgentPoolProfiles[0].OrchestratorVersion).To(BeEmpty())
+	g.Expect(uc.agentPoolsToUpgrade).To(HaveKey(kubernetesupgrade.MasterPoolName))
+
+	uc = newUpgradeCmd("1.13.5")
+	err = uc.initialize()
+	g.Expect(err).To(HaveOccurred())
+	g.Expect(err.Error()).To(ContainSubstring("there is no upgrade path from Kubernetes version 1.10.12 to version 1.13.5"))
+}
+
+func TestUpgradePreflightChecks(t *testing.T) {
+	g := NewGomegaWithT(t)
+	containerServiceMock := api.CreateMockContainerService("testcluster", "1.10.13", 3, 2, false)
+	containerServiceMock.Location = "centralus"
+	upgradeCmd := &upgradeCmd{
+		resourceGroupName: "rg",
+		apiModelPath:      "./not/used",
+		upgradeVersion:    "1.10.13",
+		location:          "centralus",
+		currentVersion:    "1.10.13",
+		containerService:  containerServiceMock,
+
+		client: &armhelpers.MockAKSEngineClient{},
+	}
+
+	// the mock cluster has a node that is not Ready
+	err := upgradeCmd.runPreflightChecks()
+	g.Expect(err).To(HaveOccurred())
+	g.Expect(err.Error()).To(ContainSubstring("use --skip-preflight or --force to proceed anyway"))
+
+	upgradeCmd.force = true
+	err = upgradeCmd.runPreflightChecks()
+	g.Expect(err).NotTo(HaveOccurred())
+
+	// --auto-path cannot be combined with --force, the checks are skipped instead
+	upgradeCmd.force = false
+	upgradeCmd.autoPath = true
+	upgradeCmd.skipPreflight = true
+	err = upgradeCmd.runPreflightChecks()
+	g.Expect(err).NotTo(HaveOccurred())
+}
@@ -28,6 +28,22 @@ systemctlEnableAndStart() {
     fi
 }
 
+configureHTTPProxy() {
+    HTTP_PROXY_CA_FILE=/usr/local/share/ca-certificates/http-proxy-ca.crt
+    if [ -f $HTTP_PROXY_CA_FILE ]; then
+        update-ca-certificates || exit $ERR_HTTP_PROXY_CA_UPDATE_FAIL
+    fi
+    # export the proxy settings to the remaining provisioning steps
+    set -a
+    source $HTTP_PROXY_ENV_FILE
+    set +a
+    # login sessions get the proxy settings from /etc/environment, the docker, containerd and kubelet
+    # units from their own drop-ins
+    if ! grep -q "^HTTP_PROXY=\|^HTTPS_PROXY=" /etc/environment; then
+        grep -v "^\s*$" $HTTP_PROXY_ENV_FILE | sed 's/^\s*//' >> /etc/environment
+    fi
+}
+
 configureEtcdUser(){
     useradd -U "etcd"
     usermod -p "$(head -c 32 /dev/urandom | base64)" "etcd"
@@ -165,6 +181,7 @@ configureK8s() {
     "vnetName": "${VIRTUAL_NETWORK}",
     "vnetResourceGroup": "${VIRTUAL_NETWORK_RESOURCE_GROUP}",
     "routeTableName": "${ROUTE_TABLE}",
+    "routeTableResourceGroup": "${ROUTE_TABLE_RESOURCE_GROUP:-${RESOURCE_GROUP}}",
     "primaryAvailabilitySetName": "${PRIMARY_AVAILABILITY_SET}",
     "primaryScaleSetName": "${PRIMARY_SCALE_SET}",
     "cloudProviderBackoff": ${CLOUDPROVIDER_BACKOFF},
@@ -283,6 +300,16 @@ ensureKMS() {
     systemctlEnableAndStart kms || exit $ERR_SYSTEMCTL_START_FAIL
 }
 
+ensureManagedTaintsAnnotation() {
+    # records the agent pool taints the node registers with, so that upgrades can tell them apart from taints added later on
+    MANAGED_TAINTS=$(grep "^KUBELET_REGISTER_WITH_TAINTS=" /etc/default/kubelet | sed "s/^KUBELET_REGISTER_WITH_TAINTS=--register-with-taints=//")
+    if [[ -z "${MANAGED_TAINTS}" ]]; then
+        return
+    fi
+    NODE_NAME=$(hostname | tr '[:upper:]' '[:lower:]')
+    retrycmd_if_failure 60 5 25 $KUBECTL --kubeconfig=/var/lib/kubelet/kubeconfig annotate node ${NODE_NAME} --overwrite "kubernetes.azure.com/managed-taints=${MANAGED_TAINTS}" || echo "failed to record the managed taints of node ${NODE_NAME}"
+}
+
 ensureKubelet() {
     KUBELET_DEFAULT_FILE=/etc/default/kubelet
     wait_for_file 1200 1 $KUBELET_DEFAULT_FILE || exit $ERR_FILE_WATCH_TIMEOUT
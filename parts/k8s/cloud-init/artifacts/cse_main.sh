@@ -36,6 +36,7 @@ if [[ "${TARGET_ENVIRONMENT,,}" == "${AZURE_STACK_ENV}"  ]]; then
 fi
 
 CUSTOM_SEARCH_DOMAIN_SCRIPT=/opt/azure/containers/setup-custom-search-domains.sh
+HTTP_PROXY_ENV_FILE=/etc/default/http-proxy
 
 set +x
 ETCD_PEER_CERT=$(echo ${ETCD_PEER_CERTIFICATES} | cut -d'[' -f 2 | cut -d']' -f 1 | cut -d',' -f $((${NODE_INDEX}+1)))
@@ -63,6 +64,10 @@ else
     FULL_INSTALL_REQUIRED=true
 fi
 
+if [ -f $HTTP_PROXY_ENV_FILE ]; then
+    configureHTTPProxy
+fi
+
 if [[ $OS == $UBUNTU_OS_NAME ]] && [ "$FULL_INSTALL_REQUIRED" = "true" ]; then
     cis_sysctl=/etc/sysctl.d/60-CIS.conf
     wait_for_file 3600 1 $cis_sysctl || exit $ERR_FILE_WATCH_TIMEOUT
@@ -161,6 +166,9 @@ fi
 
 ensureKubelet
 ensureJournal
+if [[ -z "${MASTER_NODE}" ]]; then
+    ensureManagedTaintsAnnotation
+fi
 
 if [[ ! -z "${MASTER_NODE}" ]]; then
     writeKubeConfig
@@ -203,6 +203,12 @@
       },
       "type": "string"
     },
+    "kubernetesKubeProxySpec": {
+      "metadata": {
+        "description": "The container spec for kube-proxy."
+      },
+      "type": "string"
+    },
     "privateAzureRegistryServer": {
       "defaultValue": "",
       "metadata": {
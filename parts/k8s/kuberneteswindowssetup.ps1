@@ -71,9 +71,10 @@ $global:ResourceGroup = "{{WrapAsVariable "resourceGroup"}}"
 $global:VmType = "{{WrapAsVariable "vmType"}}"
 $global:SubnetName = "{{WrapAsVariable "subnetName"}}"
 $global:MasterSubnet = "{{WrapAsParameter "masterSubnet"}}"
-$global:SecurityGroupName = "{{WrapAsVariable "nsgName"}}"
+$global:SecurityGroupName = "{{if .HasOwnNetworkSecurityGroup}}{{WrapAsVariable (print .Name "NSGName")}}{{else}}{{WrapAsVariable "nsgName"}}{{end}}"
 $global:VNetName = "{{WrapAsVariable "virtualNetworkName"}}"
-$global:RouteTableName = "{{WrapAsVariable "routeTableName"}}"
+$global:RouteTableName = "{{if .HasOwnRouteTable}}{{WrapAsVariable (print .Name "RouteTableName")}}{{else}}{{WrapAsVariable "routeTableName"}}{{end}}"
+$global:RouteTableResourceGroup = "{{if .HasOwnRouteTable}}{{WrapAsVariable (print .Name "RouteTableResourceGroup")}}{{else}}{{WrapAsVariable "resourceGroup"}}{{end}}"
 $global:PrimaryAvailabilitySetName = "{{WrapAsVariable "primaryAvailabilitySetName"}}"
 $global:PrimaryScaleSetName = "{{WrapAsVariable "primaryScaleSetName"}}"
 
@@ -81,7 +82,7 @@ $global:KubeClusterCIDR = "{{WrapAsParameter "kubeClusterCidr"}}"
 $global:KubeServiceCIDR = "{{WrapAsParameter "kubeServiceCidr"}}"
 $global:VNetCIDR = "{{WrapAsParameter "vnetCidr"}}"
 $global:KubeletNodeLabels = "{{GetAgentKubernetesLabels . "',variables('labelResourceGroup'),'"}}"
-$global:KubeletConfigArgs = @( {{GetKubeletConfigKeyValsPsh .KubernetesConfig }} )
+$global:KubeletConfigArgs = @( {{GetKubeletConfigKeyValsPsh .KubernetesConfig }}{{if .Taints}}, "--register-with-taints={{GetAgentKubernetesTaints .}}"{{end}} )
 
 $global:UseManagedIdentityExtension = "{{WrapAsVariable "useManagedIdentityExtension"}}"
 $global:UserAssignedClientID = "{{WrapAsVariable "userAssignedClientID"}}"
@@ -186,6 +187,7 @@ try
             -SecurityGroupName $global:SecurityGroupName `
             -VNetName $global:VNetName `
             -RouteTableName $global:RouteTableName `
+            -RouteTableResourceGroup $global:RouteTableResourceGroup `
             -PrimaryAvailabilitySetName $global:PrimaryAvailabilitySetName `
             -PrimaryScaleSetName $global:PrimaryScaleSetName `
             -UseManagedIdentityExtension $global:UseManagedIdentityExtension `
@@ -247,6 +249,11 @@ try
             -KubeServiceCIDR $global:KubeServiceCIDR `
             -HNSModule $global:HNSModule `
             -KubeletNodeLabels $global:KubeletNodeLabels
+{{if .Taints}}
+
+        Write-Log "Record the agent pool taints the node registers with"
+        Register-ManagedTaintsAnnotation -Taints "{{GetAgentKubernetesTaints .}}" -KubeDir $global:KubeDir
+{{end}}
 
         # Install OpenSSH if SSH enabled
         $sshEnabled = [System.Convert]::ToBoolean("{{ WindowsSSHEnabled }}")
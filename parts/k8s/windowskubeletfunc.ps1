@@ -24,6 +24,8 @@ Write-AzureConfig {
         $VNetName,
         [Parameter(Mandatory = $true)][string]
         $RouteTableName,
+        [Parameter(Mandatory = $true)][string]
+        $RouteTableResourceGroup,
         [Parameter(Mandatory = $false)][string] # Need one of these configured
         $PrimaryAvailabilitySetName,
         [Parameter(Mandatory = $false)][string] # Need one of these configured
@@ -61,6 +63,7 @@ Write-AzureConfig {
     "securityGroupName": "$SecurityGroupName",
     "vnetName": "$VNetName",
     "routeTableName": "$RouteTableName",
+    "routeTableResourceGroup": "$RouteTableResourceGroup",
     "primaryAvailabilitySetName": "$PrimaryAvailabilitySetName",
     "primaryScaleSetName": "$PrimaryScaleSetName",
     "useManagedIdentityExtension": $UseManagedIdentityExtension,
@@ -130,6 +133,38 @@ users:
     $kubeConfig | Out-File -encoding ASCII -filepath "$kubeConfigFile"
 }
 
+function
+Register-ManagedTaintsAnnotation {
+    Param(
+        [Parameter(Mandatory = $true)][string]
+        $Taints,
+        [Parameter(Mandatory = $true)][string]
+        $KubeDir
+    )
+    # Records the agent pool taints the node registers with, so that upgrades can tell them apart from taints added later on.
+    # The node only registers after the reboot that ends the setup, so a startup task annotates it and then removes itself.
+    $taskName = "annotate-managed-taints"
+    $scriptFile = [io.path]::Combine($KubeDir, "annotatemanagedtaints.ps1")
+
+    $script = @"
+`$nodeName = `$env:computername.ToLower()
+while (`$true) {
+    & $KubeDir\kubectl.exe --kubeconfig=$KubeDir\config annotate node `$nodeName --overwrite "kubernetes.azure.com/managed-taints=$Taints"
+    if (`$LASTEXITCODE -eq 0) {
+        break
+    }
+    Start-Sleep -Seconds 10
+}
+Unregister-ScheduledTask -TaskName "$taskName" -Confirm:`$false
+"@
+
+    $script | Out-File -encoding ASCII -filepath "$scriptFile"
+    $action = New-ScheduledTaskAction -Execute "powershell.exe" -Argument "-NoProfile -ExecutionPolicy Bypass -File $scriptFile"
+    $trigger = New-ScheduledTaskTrigger -AtStartup
+    $principal = New-ScheduledTaskPrincipal -UserId SYSTEM -LogonType ServiceAccount -RunLevel Highest
+    Register-ScheduledTask -TaskName $taskName -Action $action -Trigger $trigger -Principal $principal | Out-Null
+}
+
 function
 New-InfraContainer {
     Param(
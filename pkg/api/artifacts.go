@@ -0,0 +1,240 @@
+// Copyright (c) Microsoft Corporation. All rights reserved.
+// Licensed under the MIT license.
+
+package api
+
+import (
+	"net/url"
+	"sort"
+	"strings"
+
+	"github.com/Azure/aks-engine/pkg/api/common"
+	"github.com/Azure/go-autorest/autorest/to"
+	"github.com/pkg/errors"
+)
+
+// ArtifactManifest lists every container image and file download a cluster pulls while it is provisioned
+type ArtifactManifest struct {
+	Images    []string `json:"images"`
+	Downloads []string `json:"downloads"`
+}
+
+// GetArtifactManifest returns the container images and download URLs used by a Kubernetes cluster.
+// It expects SetPropertiesDefaults to have been called on the container service.
+func (cs *ContainerService) GetArtifactManifest() (*ArtifactManifest, error) {
+	if cs.Properties == nil || cs.Properties.OrchestratorProfile == nil || !cs.Properties.OrchestratorProfile.IsKubernetes() {
+		return nil, errors.New("an artifact manifest can only be built for Kubernetes clusters")
+	}
+	o := cs.Properties.OrchestratorProfile
+	k := o.KubernetesConfig
+	if k == nil {
+		return nil, errors.New("orchestratorProfile.kubernetesConfig must be set, were the api model defaults applied?")
+	}
+	cloudSpecConfig := cs.GetCloudSpecConfig()
+	k8sComponents := o.GetK8sComponents()
+
+	imageBase := k.KubernetesImageBase
+	if cs.Properties.IsAzureStackCloud() {
+		imageBase = cloudSpecConfig.KubernetesSpecConfig.KubernetesImageBase
+	}
+
+	images := map[string]bool{}
+	downloads := map[string]bool{}
+
+	hyperkube := imageBase + k8sComponents["hyperkube"]
+	if k.CustomHyperkubeImage != "" {
+		hyperkube = k.CustomHyperkubeImage
+	}
+	images[hyperkube] = true
+	if kubeProxy, ok := k8sComponents["kube-proxy"]; ok {
+		images[imageBase+kubeProxy] = true
+	}
+	if to.Bool(k.UseCloudControllerManager) {
+		ccm := imageBase + k8sComponents["ccm"]
+		if k.CustomCcmImage != "" {
+			ccm = k.CustomCcmImage
+		}
+		images[ccm] = true
+	}
+	images[imageBase+k8sComponents["addonmanager"]] = true
+	images[imageBase+k8sComponents["pause"]] = true
+	if o.NeedsExecHealthz() {
+		images[imageBase+k8sComponents["exechealthz"]] = true
+	}
+	if common.IsKubernetesVersionGe(o.OrchestratorVersion, "1.12.0") {
+		images[imageBase+k8sComponents["coredns"]] = true
+	} else {
+		images[imageBase+k8sComponents["kube-dns"]] = true
+		images[imageBase+k8sComponents["dnsmasq"]] = true
+		images[imageBase+k8sComponents["k8s-dns-sidecar"]] = true
+	}
+	for _, addon := range k.Addons {
+		if !addon.IsEnabled(false) {
+			continue
+		}
+		for _, c := range addon.Containers {
+			if c.Image != "" {
+				images[c.Image] = true
+			}
+		}
+	}
+
+	if cs.Properties.MasterProfile != nil {
+		downloads[strings.TrimSuffix(k.GetEtcdDownloadURLBase(cloudSpecConfig), "/")+"/etcd-v"+o.GetEtcdVersion()+"-linux-amd64.tar.gz"] = true
+	}
+	downloads[k.GetCNIPluginsURL(cloudSpecConfig)] = true
+	if k.NetworkPlugin == NetworkPluginAzure {
+		downloads[k.GetAzureCNIURLLinux(cloudSpecConfig)] = true
+		if cs.Properties.HasWindows() {
+			downloads[k.GetAzureCNIURLWindows(cloudSpecConfig)] = true
+		}
+	}
+	if k.ContainerRuntime == Containerd || k.ContainerRuntime == KataContainers {
+		downloads[k.GetContainerdDownloadURLBase(cloudSpecConfig)+"cri-containerd-"+k.ContainerdVersion+".linux-amd64.tar.gz"] = true
+	}
+	if cs.Properties.HasWindows() {
+		windowsPackage := k.CustomWindowsPackageURL
+		if windowsPackage == "" {
+			windowsPackage = cloudSpecConfig.KubernetesSpecConfig.KubeBinariesSASURLBase + k8sComponents["windowszip"]
+		}
+		downloads[windowsPackage] = true
+		if k.WindowsNodeBinariesURL != "" {
+			downloads[k.WindowsNodeBinariesURL] = true
+		}
+	}
+
+	return &ArtifactManifest{
+		Images:    sortedKeys(images),
+		Downloads: sortedKeys(downloads),
+	}, nil
+}
+
+// MirrorArtifacts rewrites the API model so every container image is pulled from registry and, if blobURL
+// is not empty, every file is downloaded from blobURL. defaults must be a copy of the same API model with
+// SetPropertiesDefaults applied; it is used to find the artifacts that are otherwise chosen implicitly.
+func (cs *ContainerService) MirrorArtifacts(defaults *ContainerService, registry, blobURL string) error {
+	if cs.Properties.IsAzureStackCloud() {
+		return errors.New("artifacts of custom clouds are mirrored through customCloudProfile.azureEnvironmentSpecConfig")
+	}
+	registry = strings.TrimSuffix(registry, "/")
+	if registry == "" {
+		return errors.New("a mirror registry must be provided")
+	}
+	var blob *url.URL
+	if blobURL != "" {
+		var err error
+		blob, err = url.Parse(blobURL)
+		if err != nil {
+			return errors.Wrapf(err, "invalid blob mirror URL %s", blobURL)
+		}
+		// the provisioning scripts expect downloads to keep their original path depth
+		if blob.Scheme == "" || blob.Host == "" || strings.Trim(blob.Path, "/") != "" {
+			return errors.Errorf("blob mirror URL %s must only have a scheme and a host, e.g. https://mirror.example.com", blobURL)
+		}
+	}
+
+	if cs.Properties.OrchestratorProfile.KubernetesConfig == nil {
+		cs.Properties.OrchestratorProfile.KubernetesConfig = &KubernetesConfig{}
+	}
+	k := cs.Properties.OrchestratorProfile.KubernetesConfig
+	d := defaults.Properties.OrchestratorProfile.KubernetesConfig
+	cloudSpecConfig := defaults.GetCloudSpecConfig()
+
+	k.KubernetesImageBase = mirrorImage(d.KubernetesImageBase, registry)
+	if d.CustomHyperkubeImage != "" {
+		k.CustomHyperkubeImage = mirrorImage(d.CustomHyperkubeImage, registry)
+	}
+	if d.CustomCcmImage != "" {
+		k.CustomCcmImage = mirrorImage(d.CustomCcmImage, registry)
+	}
+	for _, addon := range d.Addons {
+		if !addon.IsEnabled(false) {
+			continue
+		}
+		i := getAddonsIndexByName(k.Addons, addon.Name)
+		if i < 0 {
+			k.Addons = append(k.Addons, KubernetesAddon{Name: addon.Name})
+			i = len(k.Addons) - 1
+		}
+		for _, c := range addon.Containers {
+			if c.Image == "" {
+				continue
+			}
+			j := k.Addons[i].GetAddonContainersIndexByName(c.Name)
+			if j < 0 {
+				k.Addons[i].Containers = append(k.Addons[i].Containers, KubernetesContainerSpec{Name: c.Name})
+				j = len(k.Addons[i].Containers) - 1
+			}
+			k.Addons[i].Containers[j].Image = mirrorImage(c.Image, registry)
+		}
+	}
+
+	if blob == nil {
+		return nil
+	}
+	var err error
+	if k.EtcdDownloadURLBase, err = mirrorURL(d.GetEtcdDownloadURLBase(cloudSpecConfig), blob); err != nil {
+		return err
+	}
+	if k.CNIPluginsURL, err = mirrorURL(d.GetCNIPluginsURL(cloudSpecConfig), blob); err != nil {
+		return err
+	}
+	if k.ContainerdDownloadURLBase, err = mirrorURL(d.GetContainerdDownloadURLBase(cloudSpecConfig), blob); err != nil {
+		return err
+	}
+	if k.AzureCNIURLLinux, err = mirrorURL(d.GetAzureCNIURLLinux(cloudSpecConfig), blob); err != nil {
+		return err
+	}
+	if k.AzureCNIURLWindows, err = mirrorURL(d.GetAzureCNIURLWindows(cloudSpecConfig), blob); err != nil {
+		return err
+	}
+	if defaults.Properties.HasWindows() {
+		windowsPackage := d.CustomWindowsPackageURL
+		if windowsPackage == "" {
+			windowsPackage = cloudSpecConfig.KubernetesSpecConfig.KubeBinariesSASURLBase + defaults.Properties.OrchestratorProfile.GetK8sComponents()["windowszip"]
+		}
+		if k.CustomWindowsPackageURL, err = mirrorURL(windowsPackage, blob); err != nil {
+			return err
+		}
+		if d.WindowsNodeBinariesURL != "" {
+			if k.WindowsNodeBinariesURL, err = mirrorURL(d.WindowsNodeBinariesURL, blob); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// mirrorImage replaces the registry of an image reference, or of an image base such as "k8s.gcr.io/".
+// Images without a registry host are Docker Hub images and keep their full repository path.
+func mirrorImage(image, registry string) string {
+	parts := strings.SplitN(image, "/", 2)
+	if len(parts) == 2 && (strings.ContainsAny(parts[0], ".:") || parts[0] == "localhost") {
+		image = parts[1]
+	}
+	return registry + "/" + image
+}
+
+// mirrorURL keeps the path of a download URL and replaces its scheme and host with those of the mirror
+func mirrorURL(rawURL string, mirror *url.URL) (string, error) {
+	if rawURL == "" {
+		return "", nil
+	}
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return "", errors.Wrapf(err, "invalid download URL %s", rawURL)
+	}
+	u.Scheme = mirror.Scheme
+	u.Host = mirror.Host
+	u.User = mirror.User
+	return u.String(), nil
+}
+
+func sortedKeys(m map[string]bool) []string {
+	keys := []string{}
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
@@ -0,0 +1,159 @@
+// Copyright (c) Microsoft Corporation. All rights reserved.
+// Licensed under the MIT license.
+
+package api
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/Azure/aks-engine/pkg/api/common"
+	"github.com/Azure/go-autorest/autorest/azure"
+	"github.com/Azure/go-autorest/autorest/to"
+)
+
+func TestGetArtifactManifest(t *testing.T) {
+	cs := CreateMockContainerService("testcluster", common.GetDefaultKubernetesVersion(false), 3, 2, false)
+	cs.Properties.OrchestratorProfile.KubernetesConfig.NetworkPlugin = NetworkPluginAzure
+	cs.Properties.OrchestratorProfile.KubernetesConfig.ContainerRuntime = Containerd
+	if _, err := cs.SetPropertiesDefaults(false, false); err != nil {
+		t.Fatalf("unexpected error setting defaults: %s", err)
+	}
+	k := cs.Properties.OrchestratorProfile.KubernetesConfig
+	cloudSpecConfig := cs.GetCloudSpecConfig()
+
+	manifest, err := cs.GetArtifactManifest()
+	if err != nil {
+		t.Fatalf("unexpected error building the artifact manifest: %s", err)
+	}
+
+	expectedImages := []string{
+		k.KubernetesImageBase + "hyperkube-amd64:v" + cs.Properties.OrchestratorProfile.OrchestratorVersion,
+		k.KubernetesImageBase + K8sComponentsByVersionMap[cs.Properties.OrchestratorProfile.OrchestratorVersion]["pause"],
+		k.GetAddonByName(DefaultMetricsServerAddonName).Containers[0].Image,
+	}
+	for _, image := range expectedImages {
+		if !contains(manifest.Images, image) {
+			t.Fatalf("expected image %s in the manifest, got %v", image, manifest.Images)
+		}
+	}
+	expectedDownloads := []string{
+		cloudSpecConfig.KubernetesSpecConfig.EtcdDownloadURLBase + "/etcd-v" + k.EtcdVersion + "-linux-amd64.tar.gz",
+		cloudSpecConfig.KubernetesSpecConfig.CNIPluginsDownloadURL,
+		cloudSpecConfig.KubernetesSpecConfig.VnetCNILinuxPluginsDownloadURL,
+		cloudSpecConfig.KubernetesSpecConfig.ContainerdDownloadURLBase + "cri-containerd-" + k.ContainerdVersion + ".linux-amd64.tar.gz",
+	}
+	for _, download := range expectedDownloads {
+		if !contains(manifest.Downloads, download) {
+			t.Fatalf("expected download %s in the manifest, got %v", download, manifest.Downloads)
+		}
+	}
+	if contains(manifest.Downloads, cloudSpecConfig.KubernetesSpecConfig.VnetCNIWindowsPluginsDownloadURL) {
+		t.Fatalf("expected no Windows downloads for a Linux cluster, got %v", manifest.Downloads)
+	}
+
+	k.ComponentOverrides = map[string]string{"kube-proxy": "kube-proxy:v1.13.5", "etcd": "3.3.10"}
+	if manifest, err = cs.GetArtifactManifest(); err != nil {
+		t.Fatalf("unexpected error building the artifact manifest: %s", err)
+	}
+	if !contains(manifest.Images, k.KubernetesImageBase+"kube-proxy:v1.13.5") {
+		t.Fatalf("expected the kube-proxy override in the manifest, got %v", manifest.Images)
+	}
+	if !contains(manifest.Downloads, cloudSpecConfig.KubernetesSpecConfig.EtcdDownloadURLBase+"/etcd-v3.3.10-linux-amd64.tar.gz") {
+		t.Fatalf("expected the etcd override in the manifest, got %v", manifest.Downloads)
+	}
+
+	azureStackCloudSpec := AzureCloudSpec
+	azureStackCloudSpec.KubernetesSpecConfig.KubernetesImageBase = "azurestack.example.com/"
+	AzureCloudSpecEnvMap[AzureStackCloud] = azureStackCloudSpec
+	defer delete(AzureCloudSpecEnvMap, AzureStackCloud)
+	cs.Properties.CustomCloudProfile = &CustomCloudProfile{Environment: &azure.Environment{Name: AzureStackCloud}}
+	if manifest, err = cs.GetArtifactManifest(); err != nil {
+		t.Fatalf("unexpected error building the artifact manifest: %s", err)
+	}
+	imageBase := azureStackCloudSpec.KubernetesSpecConfig.KubernetesImageBase
+	for _, image := range []string{imageBase + "hyperkube-amd64:v" + cs.Properties.OrchestratorProfile.OrchestratorVersion, imageBase + "kube-proxy:v1.13.5"} {
+		if !contains(manifest.Images, image) {
+			t.Fatalf("expected image %s from the Azure Stack image base in the manifest, got %v", image, manifest.Images)
+		}
+	}
+	cs.Properties.CustomCloudProfile = nil
+
+	cs.Properties.OrchestratorProfile.OrchestratorType = DCOS
+	if _, err := cs.GetArtifactManifest(); err == nil {
+		t.Fatalf("expected an error building the artifact manifest of a DCOS cluster")
+	}
+}
+
+func TestMirrorArtifacts(t *testing.T) {
+	newContainerService := func() *ContainerService {
+		cs := CreateMockContainerService("testcluster", common.GetDefaultKubernetesVersion(false), 3, 2, false)
+		cs.Properties.OrchestratorProfile.KubernetesConfig.NetworkPlugin = NetworkPluginAzure
+		cs.Properties.OrchestratorProfile.KubernetesConfig.UseCloudControllerManager = to.BoolPtr(true)
+		cs.Properties.OrchestratorProfile.KubernetesConfig.CustomHyperkubeImage = "docker.io/private/hyperkube:custom"
+		return cs
+	}
+
+	defaults := newContainerService()
+	if _, err := defaults.SetPropertiesDefaults(false, false); err != nil {
+		t.Fatalf("unexpected error setting defaults: %s", err)
+	}
+	cs := newContainerService()
+	if err := cs.MirrorArtifacts(defaults, "mirror.example.com/", "https://blobs.example.com"); err != nil {
+		t.Fatalf("unexpected error mirroring artifacts: %s", err)
+	}
+	if cs.Properties.OrchestratorProfile.KubernetesConfig.CustomHyperkubeImage != "mirror.example.com/private/hyperkube:custom" {
+		t.Fatalf("unexpected mirrored hyperkube image %s", cs.Properties.OrchestratorProfile.KubernetesConfig.CustomHyperkubeImage)
+	}
+
+	if _, err := cs.SetPropertiesDefaults(false, false); err != nil {
+		t.Fatalf("unexpected error setting defaults on the mirrored api model: %s", err)
+	}
+	manifest, err := cs.GetArtifactManifest()
+	if err != nil {
+		t.Fatalf("unexpected error building the artifact manifest: %s", err)
+	}
+	for _, image := range manifest.Images {
+		if !strings.HasPrefix(image, "mirror.example.com/") {
+			t.Fatalf("expected image %s to be pulled from the mirror registry", image)
+		}
+	}
+	for _, download := range manifest.Downloads {
+		if !strings.HasPrefix(download, "https://blobs.example.com/") {
+			t.Fatalf("expected download %s to come from the blob mirror", download)
+		}
+	}
+
+	cs = newContainerService()
+	if err := cs.MirrorArtifacts(defaults, "", ""); err == nil {
+		t.Fatalf("expected an error mirroring to an empty registry")
+	}
+	if err := cs.MirrorArtifacts(defaults, "mirror.example.com", "https://blobs.example.com/path"); err == nil {
+		t.Fatalf("expected an error mirroring to a blob URL with a path")
+	}
+}
+
+func TestMirrorImage(t *testing.T) {
+	cases := map[string]string{
+		"k8s.gcr.io/":                    "mirror.io/",
+		"k8s.gcr.io/hyperkube-amd64:v1":  "mirror.io/hyperkube-amd64:v1",
+		"gcr.io/kubernetes-helm/tiller":  "mirror.io/kubernetes-helm/tiller",
+		"microsoft/virtual-kubelet:1.0":  "mirror.io/microsoft/virtual-kubelet:1.0",
+		"localhost/pause:3.1":            "mirror.io/pause:3.1",
+		"registry:5000/team/image:1.2.3": "mirror.io/team/image:1.2.3",
+	}
+	for image, expected := range cases {
+		if actual := mirrorImage(image, "mirror.io"); actual != expected {
+			t.Fatalf("expected %s to be mirrored as %s, got %s", image, expected, actual)
+		}
+	}
+}
+
+func contains(list []string, s string) bool {
+	for _, item := range list {
+		if item == s {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,196 @@
+// Copyright (c) Microsoft Corporation. All rights reserved.
+// Licensed under the MIT license.
+
+package api
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/Azure/aks-engine/pkg/api/common"
+	"github.com/Azure/aks-engine/pkg/api/vlabs"
+	"github.com/blang/semver"
+	"github.com/pkg/errors"
+)
+
+// VersionCatalogSchemaVersion is the only version catalog schema this release of aks-engine understands
+const VersionCatalogSchemaVersion = 1
+
+// VersionCatalog adds Kubernetes versions, component versions and deprecations to the built-in version tables
+type VersionCatalog struct {
+	CatalogVersion     int                        `json:"catalogVersion"`
+	KubernetesVersions []VersionCatalogKubernetes `json:"kubernetesVersions,omitempty"`
+	DeprecatedVersions []string                   `json:"deprecatedVersions,omitempty"`
+}
+
+// VersionCatalogKubernetes describes a Kubernetes version added or updated by a version catalog
+type VersionCatalogKubernetes struct {
+	Version string `json:"version"`
+	// BaseRelease is a built-in major.minor release whose components are used for a release aks-engine does not know yet
+	BaseRelease string            `json:"baseRelease,omitempty"`
+	LinuxOnly   bool              `json:"linuxOnly,omitempty"`
+	Components  map[string]string `json:"components,omitempty"`
+}
+
+// LoadVersionCatalog reads a version catalog from a local path or an http(s) URL.
+// The catalog is only accepted if its SHA-256 digest matches sha256Digest.
+func LoadVersionCatalog(source, sha256Digest string) (*VersionCatalog, error) {
+	if sha256Digest == "" {
+		return nil, errors.New("a sha256 digest must be provided to pin the version catalog")
+	}
+	var contents []byte
+	var err error
+	if strings.HasPrefix(source, "http://") || strings.HasPrefix(source, "https://") {
+		contents, err = downloadVersionCatalog(source)
+	} else {
+		contents, err = ioutil.ReadFile(source)
+	}
+	if err != nil {
+		return nil, errors.Wrapf(err, "error reading version catalog %s", source)
+	}
+
+	digest := sha256.Sum256(contents)
+	if actual := hex.EncodeToString(digest[:]); !strings.EqualFold(actual, sha256Digest) {
+		return nil, errors.Errorf("version catalog %s has sha256 digest %s, expected %s", source, actual, sha256Digest)
+	}
+
+	catalog := &VersionCatalog{}
+	if err = json.Unmarshal(contents, catalog); err != nil {
+		return nil, errors.Wrapf(err, "error parsing version catalog %s", source)
+	}
+	if catalog.CatalogVersion != VersionCatalogSchemaVersion {
+		return nil, errors.Errorf("version catalog %s has catalogVersion %d, this release of aks-engine supports catalogVersion %d", source, catalog.CatalogVersion, VersionCatalogSchemaVersion)
+	}
+	return catalog, nil
+}
+
+func downloadVersionCatalog(catalogURL string) ([]byte, error) {
+	httpClient := &http.Client{
+		Timeout: 30 * time.Second,
+	}
+	resp, err := httpClient.Get(catalogURL)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, errors.Errorf("unexpected status %s", resp.Status)
+	}
+	return ioutil.ReadAll(resp.Body)
+}
+
+// Apply merges the catalog over the built-in version tables. Added versions may be used to create new clusters,
+// deprecated versions may only be upgraded from. Nothing is changed if the catalog is invalid.
+func (c *VersionCatalog) Apply() error {
+	linux := copyVersionMap(common.AllKubernetesSupportedVersions)
+	windows := copyVersionMap(common.AllKubernetesWindowsSupportedVersions)
+	components := map[string]map[string]string{}
+	for version, versionComponents := range K8sComponentsByVersionMap {
+		components[version] = versionComponents
+	}
+
+	validComponents := map[string]bool{}
+	for _, name := range vlabs.ComponentOverrideNames {
+		validComponents[name] = true
+	}
+	// etcd and kube-proxy are only overridden per cluster, a catalog entry would take precedence over etcdVersion
+	delete(validComponents, "etcd")
+	delete(validComponents, "kube-proxy")
+	seen := map[string]bool{}
+	for _, v := range c.KubernetesVersions {
+		if _, err := semver.Make(v.Version); err != nil {
+			return errors.Errorf("version catalog: invalid Kubernetes version %q", v.Version)
+		}
+		if seen[v.Version] {
+			return errors.Errorf("version catalog: Kubernetes version %s is listed more than once", v.Version)
+		}
+		seen[v.Version] = true
+		for name, value := range v.Components {
+			if !validComponents[name] {
+				return errors.Errorf("version catalog: Kubernetes version %s has unknown component %q", v.Version, name)
+			}
+			if value == "" {
+				return errors.Errorf("version catalog: Kubernetes version %s has an empty value for component %q", v.Version, name)
+			}
+		}
+
+		versionComponents, err := getCatalogVersionComponents(v)
+		if err != nil {
+			return err
+		}
+		components[v.Version] = versionComponents
+		if _, ok := linux[v.Version]; !ok {
+			linux[v.Version] = true
+			if !v.LinuxOnly {
+				windows[v.Version] = true
+			}
+		}
+	}
+
+	for _, version := range c.DeprecatedVersions {
+		if _, ok := linux[version]; !ok {
+			return errors.Errorf("version catalog: cannot deprecate unknown Kubernetes version %s", version)
+		}
+		linux[version] = false
+		if _, ok := windows[version]; ok {
+			windows[version] = false
+		}
+	}
+
+	if getLatestSupportedPatch(common.KubernetesDefaultRelease, linux) == "" {
+		return errors.Errorf("version catalog: no supported patch version of the default release %s is left", common.KubernetesDefaultRelease)
+	}
+	if getLatestSupportedPatch(common.KubernetesDefaultReleaseWindows, windows) == "" {
+		return errors.Errorf("version catalog: no supported patch version of the default Windows release %s is left", common.KubernetesDefaultReleaseWindows)
+	}
+
+	common.AllKubernetesSupportedVersions = linux
+	common.AllKubernetesWindowsSupportedVersions = windows
+	K8sComponentsByVersionMap = components
+	return nil
+}
+
+// getCatalogVersionComponents returns the components of a catalog version, starting from the built-in components
+// of its release, or of its base release if aks-engine does not know the release
+func getCatalogVersionComponents(v VersionCatalogKubernetes) (map[string]string, error) {
+	ret := getK8sVersionComponents(v.Version, getVersionOverrides(v.Version))
+	if ret == nil {
+		if v.BaseRelease == "" {
+			return nil, errors.Errorf("version catalog: Kubernetes version %s belongs to a release aks-engine does not know, baseRelease must be set", v.Version)
+		}
+		ret = getK8sVersionComponents(v.BaseRelease+".0", nil)
+		if ret == nil {
+			return nil, errors.Errorf("version catalog: Kubernetes version %s has unknown baseRelease %s", v.Version, v.BaseRelease)
+		}
+		ret["hyperkube"] = "hyperkube-amd64:v" + v.Version
+		ret["ccm"] = "cloud-controller-manager-amd64:v" + v.Version
+		ret["windowszip"] = "v" + v.Version + "-1int.zip"
+	}
+	for name, value := range v.Components {
+		ret[name] = value
+	}
+	return ret, nil
+}
+
+func getLatestSupportedPatch(release string, versions map[string]bool) string {
+	var supported []string
+	for version, ok := range versions {
+		if ok {
+			supported = append(supported, version)
+		}
+	}
+	return common.GetLatestPatchVersion(release, supported)
+}
+
+func copyVersionMap(m map[string]bool) map[string]bool {
+	ret := make(map[string]bool, len(m))
+	for k, v := range m {
+		ret[k] = v
+	}
+	return ret
+}
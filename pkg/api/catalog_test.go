@@ -0,0 +1,165 @@
+// Copyright (c) Microsoft Corporation. All rights reserved.
+// Licensed under the MIT license.
+
+package api
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/Azure/aks-engine/pkg/api/common"
+)
+
+func restoreVersionTables() func() {
+	linux := common.AllKubernetesSupportedVersions
+	windows := common.AllKubernetesWindowsSupportedVersions
+	components := K8sComponentsByVersionMap
+	return func() {
+		common.AllKubernetesSupportedVersions = linux
+		common.AllKubernetesWindowsSupportedVersions = windows
+		K8sComponentsByVersionMap = components
+	}
+}
+
+func sha256Hex(b []byte) string {
+	digest := sha256.Sum256(b)
+	return hex.EncodeToString(digest[:])
+}
+
+func TestLoadVersionCatalog(t *testing.T) {
+	dir, err := ioutil.TempDir("", "aks-engine-catalog")
+	if err != nil {
+		t.Fatalf("unexpected error creating temp dir: %s", err)
+	}
+	defer os.RemoveAll(dir)
+
+	contents := []byte(`{"catalogVersion": 1, "kubernetesVersions": [{"version": "1.14.2"}], "deprecatedVersions": ["1.14.0"]}`)
+	path := filepath.Join(dir, "catalog.json")
+	if err = ioutil.WriteFile(path, contents, 0600); err != nil {
+		t.Fatalf("unexpected error writing catalog: %s", err)
+	}
+
+	catalog, err := LoadVersionCatalog(path, sha256Hex(contents))
+	if err != nil {
+		t.Fatalf("unexpected error loading catalog: %s", err)
+	}
+	if len(catalog.KubernetesVersions) != 1 || catalog.KubernetesVersions[0].Version != "1.14.2" || len(catalog.DeprecatedVersions) != 1 {
+		t.Fatalf("unexpected catalog %+v", catalog)
+	}
+
+	if _, err = LoadVersionCatalog(path, ""); err == nil {
+		t.Fatalf("expected an error loading a catalog without a digest")
+	}
+	if _, err = LoadVersionCatalog(path, sha256Hex([]byte("tampered"))); err == nil {
+		t.Fatalf("expected an error loading a catalog with a mismatched digest")
+	}
+	if _, err = LoadVersionCatalog(filepath.Join(dir, "missing.json"), sha256Hex(contents)); err == nil {
+		t.Fatalf("expected an error loading a missing catalog")
+	}
+
+	future := []byte(`{"catalogVersion": 2}`)
+	if err = ioutil.WriteFile(path, future, 0600); err != nil {
+		t.Fatalf("unexpected error writing catalog: %s", err)
+	}
+	if _, err = LoadVersionCatalog(path, sha256Hex(future)); err == nil {
+		t.Fatalf("expected an error loading a catalog with an unsupported catalogVersion")
+	}
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write(contents)
+	}))
+	defer server.Close()
+	if _, err = LoadVersionCatalog(server.URL+"/catalog.json", sha256Hex(contents)); err != nil {
+		t.Fatalf("unexpected error downloading catalog: %s", err)
+	}
+}
+
+func TestVersionCatalogApply(t *testing.T) {
+	defer restoreVersionTables()()
+
+	catalog := &VersionCatalog{
+		CatalogVersion: VersionCatalogSchemaVersion,
+		KubernetesVersions: []VersionCatalogKubernetes{
+			{Version: "1.14.99", Components: map[string]string{"coredns": "coredns:1.5.0"}},
+			{Version: "1.99.0", BaseRelease: "1.14", LinuxOnly: true},
+			{Version: "1.14.1", Components: map[string]string{"pause": "pause-amd64:3.2"}},
+		},
+		DeprecatedVersions: []string{"1.14.0"},
+	}
+	if err := catalog.Apply(); err != nil {
+		t.Fatalf("unexpected error applying catalog: %s", err)
+	}
+
+	if !common.AllKubernetesSupportedVersions["1.14.99"] || !common.AllKubernetesWindowsSupportedVersions["1.14.99"] {
+		t.Fatalf("expected 1.14.99 to be supported on Linux and Windows")
+	}
+	if !common.AllKubernetesSupportedVersions["1.99.0"] || common.AllKubernetesWindowsSupportedVersions["1.99.0"] {
+		t.Fatalf("expected 1.99.0 to be supported on Linux only")
+	}
+	if common.AllKubernetesSupportedVersions["1.14.0"] || !common.IsSupportedKubernetesVersion("1.14.0", true, false) {
+		t.Fatalf("expected 1.14.0 to be deprecated but still upgradable")
+	}
+	if !common.IsSupportedKubernetesVersion("1.14.99", false, false) {
+		t.Fatalf("expected validation to accept 1.14.99")
+	}
+
+	if K8sComponentsByVersionMap["1.14.99"]["coredns"] != "coredns:1.5.0" || K8sComponentsByVersionMap["1.14.99"]["hyperkube"] != "hyperkube-amd64:v1.14.99" {
+		t.Fatalf("unexpected components for 1.14.99: %v", K8sComponentsByVersionMap["1.14.99"])
+	}
+	if K8sComponentsByVersionMap["1.99.0"]["hyperkube"] != "hyperkube-amd64:v1.99.0" || K8sComponentsByVersionMap["1.99.0"]["pause"] != k8sComponentVersions["1.14"]["pause"] {
+		t.Fatalf("unexpected components for 1.99.0: %v", K8sComponentsByVersionMap["1.99.0"])
+	}
+	if K8sComponentsByVersionMap["1.14.1"]["pause"] != "pause-amd64:3.2" || K8sComponentsByVersionMap["1.14.1"]["coredns"] != k8sComponentVersions["1.14"]["coredns"] {
+		t.Fatalf("unexpected components for 1.14.1: %v", K8sComponentsByVersionMap["1.14.1"])
+	}
+}
+
+func TestVersionCatalogKeepsEtcdVersion(t *testing.T) {
+	defer restoreVersionTables()()
+
+	catalog := &VersionCatalog{KubernetesVersions: []VersionCatalogKubernetes{{Version: "1.13.5", Components: map[string]string{"etcd": "3.3.10"}}}}
+	expected := `version catalog: Kubernetes version 1.13.5 has unknown component "etcd"`
+	if err := catalog.Apply(); err == nil || err.Error() != expected {
+		t.Fatalf("expected error %q applying a catalog with an etcd component, got %v", expected, err)
+	}
+	o := &OrchestratorProfile{
+		OrchestratorType:    Kubernetes,
+		OrchestratorVersion: "1.13.5",
+		KubernetesConfig:    &KubernetesConfig{EtcdVersion: "3.2.25"},
+	}
+	if v := o.GetEtcdVersion(); v != "3.2.25" {
+		t.Fatalf("expected GetEtcdVersion() to return etcdVersion, got %s", v)
+	}
+}
+
+func TestVersionCatalogApplyInvalid(t *testing.T) {
+	defer restoreVersionTables()()
+
+	cases := map[string]*VersionCatalog{
+		"invalid version":      {KubernetesVersions: []VersionCatalogKubernetes{{Version: "latest"}}},
+		"duplicate version":    {KubernetesVersions: []VersionCatalogKubernetes{{Version: "1.14.99"}, {Version: "1.14.99"}}},
+		"unknown component":    {KubernetesVersions: []VersionCatalogKubernetes{{Version: "1.14.99", Components: map[string]string{"kube-apiserver": "kube-apiserver:v1.14.99"}}}},
+		"etcd component":       {KubernetesVersions: []VersionCatalogKubernetes{{Version: "1.14.99", Components: map[string]string{"etcd": "3.3.10"}}}},
+		"kube-proxy component": {KubernetesVersions: []VersionCatalogKubernetes{{Version: "1.14.99", Components: map[string]string{"kube-proxy": "kube-proxy:v1.14.99"}}}},
+		"empty component":      {KubernetesVersions: []VersionCatalogKubernetes{{Version: "1.14.99", Components: map[string]string{"coredns": ""}}}},
+		"unknown release":      {KubernetesVersions: []VersionCatalogKubernetes{{Version: "1.99.0"}}},
+		"unknown base release": {KubernetesVersions: []VersionCatalogKubernetes{{Version: "1.99.0", BaseRelease: "1.98"}}},
+		"unknown deprecation":  {DeprecatedVersions: []string{"1.99.0"}},
+		"default release":      {DeprecatedVersions: common.GetAllSupportedKubernetesVersions(false, false)},
+	}
+	for name, catalog := range cases {
+		linux := common.AllKubernetesSupportedVersions
+		if err := catalog.Apply(); err == nil {
+			t.Fatalf("expected an error applying a catalog with %s", name)
+		}
+		if len(linux) != len(common.AllKubernetesSupportedVersions) {
+			t.Fatalf("expected the version tables to be left unchanged applying a catalog with %s", name)
+		}
+	}
+}
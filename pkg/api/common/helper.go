@@ -226,3 +226,45 @@ func IsSgxEnabledSKU(vmSize string) bool {
 	}
 	return false
 }
+
+// Taint is a Kubernetes node taint in the syntax of the kubelet --register-with-taints flag
+type Taint struct {
+	Key    string
+	Value  string
+	Effect string
+}
+
+// TaintEffects are the effects a Kubernetes node taint may have
+var TaintEffects = []string{"NoSchedule", "PreferNoSchedule", "NoExecute"}
+
+// ParseTaint parses a taint of the form key=value:Effect or key:Effect
+func ParseTaint(s string) (Taint, error) {
+	var t Taint
+	i := strings.LastIndex(s, ":")
+	if i < 0 {
+		return t, errors.Errorf("Taint '%s' is invalid. Taints must be of the form key=value:Effect or key:Effect", s)
+	}
+	t.Effect = s[i+1:]
+	keyValue := strings.SplitN(s[:i], "=", 2)
+	t.Key = keyValue[0]
+	if len(keyValue) == 2 {
+		t.Value = keyValue[1]
+	}
+	if t.Key == "" {
+		return t, errors.Errorf("Taint '%s' is invalid. Taints must be of the form key=value:Effect or key:Effect", s)
+	}
+	for _, effect := range TaintEffects {
+		if t.Effect == effect {
+			return t, nil
+		}
+	}
+	return t, errors.Errorf("Taint '%s' has an invalid effect '%s', valid effects are %s", s, t.Effect, strings.Join(TaintEffects, ", "))
+}
+
+// String returns the taint in the syntax of the kubelet --register-with-taints flag
+func (t Taint) String() string {
+	if t.Value == "" {
+		return t.Key + ":" + t.Effect
+	}
+	return t.Key + "=" + t.Value + ":" + t.Effect
+}
@@ -113,3 +113,39 @@ func TestIsSGXEnabledSKU(t *testing.T) {
 		}
 	}
 }
+
+func TestParseTaint(t *testing.T) {
+	cases := []struct {
+		taint    string
+		expected Taint
+		isErr    bool
+	}{
+		{"dedicated=gpu:NoSchedule", Taint{Key: "dedicated", Value: "gpu", Effect: "NoSchedule"}, false},
+		{"example.com/spot:PreferNoSchedule", Taint{Key: "example.com/spot", Effect: "PreferNoSchedule"}, false},
+		{"key=a=b:NoExecute", Taint{Key: "key", Value: "a=b", Effect: "NoExecute"}, false},
+		{"dedicated=gpu", Taint{}, true},
+		{"dedicated=gpu:Never", Taint{}, true},
+		{"=gpu:NoSchedule", Taint{}, true},
+		{"", Taint{}, true},
+	}
+
+	for _, c := range cases {
+		taint, err := ParseTaint(c.taint)
+		if c.isErr {
+			if err == nil {
+				t.Errorf("expected ParseTaint(%q) to return an error", c.taint)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("expected ParseTaint(%q) not to return an error, got %s", c.taint, err)
+			continue
+		}
+		if taint != c.expected {
+			t.Errorf("expected ParseTaint(%q) to return %+v, got %+v", c.taint, c.expected, taint)
+		}
+		if taint.String() != c.taint {
+			t.Errorf("expected %+v to format as %q, got %q", taint, c.taint, taint.String())
+		}
+	}
+}
@@ -6,6 +6,7 @@ package common
 import (
 	"net"
 	"regexp"
+	"strings"
 
 	"github.com/pkg/errors"
 )
@@ -46,6 +47,19 @@ func IP4BroadcastAddress(n *net.IPNet) net.IP {
 	return last
 }
 
+// GetIPv4CIDR returns the IPv4 CIDR of a comma separated list of CIDRs, such as the "10.244.0.0/16,fc00::/48"
+// form used by IPv4/IPv6 dual-stack clusters. The first CIDR is returned if none of them is IPv4.
+func GetIPv4CIDR(cidrs string) string {
+	list := strings.Split(cidrs, ",")
+	for _, cidr := range list {
+		ip, _, err := net.ParseCIDR(strings.TrimSpace(cidr))
+		if err == nil && ip.To4() != nil {
+			return strings.TrimSpace(cidr)
+		}
+	}
+	return strings.TrimSpace(list[0])
+}
+
 // GetVNETSubnetIDComponents extract subscription, resourcegroup, vnetname, subnetname from the vnetSubnetID
 func GetVNETSubnetIDComponents(vnetSubnetID string) (string, string, string, string, error) {
 	vnetSubnetIDRegex := `^\/subscriptions\/([^\/]*)\/resourceGroups\/([^\/]*)\/providers\/Microsoft.Network\/virtualNetworks\/([^\/]*)\/subnets\/([^\/]*)$`
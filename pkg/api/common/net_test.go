@@ -60,6 +60,33 @@ func Test_IP4BroadcastAddress(t *testing.T) {
 	}
 }
 
+func Test_GetIPv4CIDR(t *testing.T) {
+	scenarios := []cidrTest{
+		{
+			cidr:     "10.0.0.0/16",
+			expected: "10.0.0.0/16",
+		},
+		{
+			cidr:     "10.244.0.0/16,fc00::/48",
+			expected: "10.244.0.0/16",
+		},
+		{
+			cidr:     "fd00::/108, 10.0.0.0/16",
+			expected: "10.0.0.0/16",
+		},
+		{
+			cidr:     "fd00::/108",
+			expected: "fd00::/108",
+		},
+	}
+
+	for _, scenario := range scenarios {
+		if ipv4 := GetIPv4CIDR(scenario.cidr); ipv4 != scenario.expected {
+			t.Errorf("expected IPv4 CIDR of %v to be %v but was %v", scenario.cidr, scenario.expected, ipv4)
+		}
+	}
+}
+
 func Test_GetVNETSubnetIDComponents(t *testing.T) {
 	scenarios := []vnetSubnetIDTest{
 		{
@@ -379,6 +379,20 @@ func IsKubernetesVersionGe(actualVersion, version string) bool {
 	return v1.GE(v2)
 }
 
+// IsSupportedKubernetesVersionSkew returns true if nodes of nodeVersion may run against a control plane of controlPlaneVersion,
+// i.e. the nodes are not newer than the control plane and lag it by at most two minor versions
+func IsSupportedKubernetesVersionSkew(controlPlaneVersion, nodeVersion string) bool {
+	cp, err := semver.Make(controlPlaneVersion)
+	if err != nil {
+		return false
+	}
+	node, err := semver.Make(nodeVersion)
+	if err != nil {
+		return false
+	}
+	return node.LTE(cp) && node.Major == cp.Major && cp.Minor-node.Minor <= 2
+}
+
 // GetLatestPatchVersion gets the most recent patch version from a list of semver versions given a major.minor string
 func GetLatestPatchVersion(majorMinor string, versionsList []string) (version string) {
 	// Try to get latest version matching the release
@@ -148,6 +148,45 @@ func TestIsKubernetesVersionGe(t *testing.T) {
 	}
 }
 
+func TestIsSupportedKubernetesVersionSkew(t *testing.T) {
+	cases := []struct {
+		controlPlaneVersion string
+		nodeVersion         string
+		expectedResult      bool
+	}{
+		{
+			controlPlaneVersion: "1.14.1",
+			nodeVersion:         "1.14.1",
+			expectedResult:      true,
+		},
+		{
+			controlPlaneVersion: "1.14.1",
+			nodeVersion:         "1.12.8",
+			expectedResult:      true,
+		},
+		{
+			controlPlaneVersion: "1.14.1",
+			nodeVersion:         "1.11.9",
+			expectedResult:      false,
+		},
+		{
+			controlPlaneVersion: "1.13.5",
+			nodeVersion:         "1.14.1",
+			expectedResult:      false,
+		},
+		{
+			controlPlaneVersion: "1.14.1",
+			nodeVersion:         "not-a-version",
+			expectedResult:      false,
+		},
+	}
+	for _, c := range cases {
+		if actual := IsSupportedKubernetesVersionSkew(c.controlPlaneVersion, c.nodeVersion); actual != c.expectedResult {
+			t.Errorf("expected IsSupportedKubernetesVersionSkew(%s, %s) to return %t, got %t", c.controlPlaneVersion, c.nodeVersion, c.expectedResult, actual)
+		}
+	}
+}
+
 func TestGetVersionsLt(t *testing.T) {
 	versions := []string{"1.1.0", "1.2.0-rc.1", "1.2.0", "1.2.1"}
 	expected := []string{"1.1.0", "1.2.0"}
@@ -118,6 +118,14 @@ const (
 	ManagedDisks = "ManagedDisks"
 )
 
+// OS disk types
+const (
+	// OSDiskTypeManaged means that the nodes use a persistent managed OS disk
+	OSDiskTypeManaged = "Managed"
+	// OSDiskTypeEphemeral means that the nodes use an OS disk on the local VM cache, which is lost on reimage or deallocation
+	OSDiskTypeEphemeral = "Ephemeral"
+)
+
 // To identify programmatically generated public agent pools
 const publicAgentPoolSuffix = "-public"
 
@@ -152,6 +160,12 @@ const (
 	DefaultLoadBalancerSku = "Basic"
 	// DefaultExcludeMasterFromStandardLB determines the aks-engine provided default for excluding master nodes from standard load balancer.
 	DefaultExcludeMasterFromStandardLB = true
+	// DefaultEgressOutboundIPCount is the number of public IP addresses allocated to the agent load balancer outbound rule
+	DefaultEgressOutboundIPCount = 1
+	// DefaultEgressIdleTimeoutInMinutes is the TCP idle timeout of the agent load balancer outbound rule
+	DefaultEgressIdleTimeoutInMinutes = 30
+	// DefaultEgressEnableTCPReset determines if the agent load balancer outbound rule sends TCP resets on idle timeout
+	DefaultEgressEnableTCPReset = true
 	// DefaultSecureKubeletEnabled determines the aks-engine provided default for securing kubelet communications
 	DefaultSecureKubeletEnabled = true
 	// DefaultMetricsServerAddonEnabled determines the aks-engine provided default for enabling kubernetes metrics-server addon
@@ -194,6 +208,8 @@ const (
 	IPMASQAgentAddonName = "ip-masq-agent"
 	// DefaultPrivateClusterEnabled determines the aks-engine provided default for enabling kubernetes Private Cluster
 	DefaultPrivateClusterEnabled = false
+	// DefaultPrivateDNSZonePrefix is the first label of the private DNS zone created for the API server of a private cluster
+	DefaultPrivateDNSZonePrefix = "privatelink"
 	// NetworkPolicyAzure is the string expression for Azure CNI network policy manager
 	NetworkPolicyAzure = "azure"
 	// NetworkPolicyNone is the string expression for the deprecated NetworkPolicy usage pattern "none"
@@ -321,6 +337,13 @@ const (
 	DefaultKubernetesSubnet = "10.240.0.0/12"
 	// DefaultVNETCIDR is the default CIDR block for the VNET
 	DefaultVNETCIDR = "10.0.0.0/8"
+	// DefaultVNETCIDRIPv6 is the default IPv6 CIDR block for the VNET of IPv4/IPv6 dual-stack clusters
+	DefaultVNETCIDRIPv6 = "2001:1234:5678:9a00::/56"
+	// DefaultKubernetesMasterSubnetIPv6 specifies the default IPv6 subnet for masters and agents of IPv4/IPv6 dual-stack clusters.
+	// Except when master VMSS is used, this specifies the default IPv6 subnet for masters.
+	DefaultKubernetesMasterSubnetIPv6 = "2001:1234:5678:9abc::/64"
+	// DefaultKubernetesAgentSubnetVMSSIPv6 specifies the default IPv6 subnet for agents of IPv4/IPv6 dual-stack clusters when master is VMSS
+	DefaultKubernetesAgentSubnetVMSSIPv6 = "2001:1234:5678:9abd::/64"
 	// DefaultKubernetesMaxPods is the maximum number of pods to run on a node.
 	DefaultKubernetesMaxPods = 110
 	// DefaultKubernetesMaxPodsVNETIntegrated is the maximum number of pods to run on a node when VNET integration is enabled.
@@ -404,6 +427,10 @@ const (
 	DefaultKubernetesClusterSubnet = "10.244.0.0/16"
 	// DefaultKubernetesServiceCIDR specifies the IP subnet that kubernetes will create Service IPs within.
 	DefaultKubernetesServiceCIDR = "10.0.0.0/16"
+	// DefaultKubernetesClusterSubnetIPv6 specifies the default IPv6 subnet for pods in IPv4/IPv6 dual-stack clusters.
+	DefaultKubernetesClusterSubnetIPv6 = "fc00::/48"
+	// DefaultKubernetesServiceCIDRIPv6 specifies the default IPv6 subnet for Service IPs in IPv4/IPv6 dual-stack clusters.
+	DefaultKubernetesServiceCIDRIPv6 = "fd00::/108"
 	// DefaultKubernetesDNSServiceIP specifies the IP address that kube-dns listens on by default. must by in the default Service CIDR range.
 	DefaultKubernetesDNSServiceIP = "10.0.0.10"
 	// DefaultMobyVersion specifies the default Azure build version of Moby to install.
@@ -486,6 +486,21 @@ func convertPropertiesToVLabs(api *Properties, vlabsProps *vlabs.Properties) {
 		vlabsProps.CustomCloudProfile = &vlabs.CustomCloudProfile{}
 		convertCloudProfileToVLabs(api.CustomCloudProfile, vlabsProps.CustomCloudProfile)
 	}
+
+	if api.LintProfile != nil {
+		vlabsProps.LintProfile = &vlabs.LintProfile{}
+		convertLintProfileToVLabs(api.LintProfile, vlabsProps.LintProfile)
+	}
+
+	if api.NetworkSecurityProfile != nil {
+		vlabsProps.NetworkSecurityProfile = &vlabs.NetworkSecurityProfile{}
+		convertNetworkSecurityProfileToVLabs(api.NetworkSecurityProfile, vlabsProps.NetworkSecurityProfile)
+	}
+
+	if api.HTTPProxyConfig != nil {
+		vlabsProps.HTTPProxyConfig = &vlabs.HTTPProxyConfig{}
+		convertHTTPProxyConfigToVLabs(api.HTTPProxyConfig, vlabsProps.HTTPProxyConfig)
+	}
 }
 
 func convertLinuxProfileToV20160930(api *LinuxProfile, obj *v20160930.LinuxProfile) {
@@ -530,6 +545,15 @@ func convertExtensionProfileToVLabs(api *ExtensionProfile, obj *vlabs.ExtensionP
 	obj.RootURL = api.RootURL
 	obj.Script = api.Script
 	obj.URLQuery = api.URLQuery
+	obj.LocalPath = api.LocalPath
+	obj.OCIReference = api.OCIReference
+	obj.Upload = api.Upload
+	if api.Checksums != nil {
+		obj.Checksums = map[string]string{}
+		for k, v := range api.Checksums {
+			obj.Checksums[k] = v
+		}
+	}
 }
 
 func convertExtensionToVLabs(api *Extension, vlabs *vlabs.Extension) {
@@ -745,6 +769,9 @@ func convertKubernetesConfigToVLabs(apiCfg *KubernetesConfig, vlabsCfg *vlabs.Ku
 	vlabsCfg.AzureCNIVersion = apiCfg.AzureCNIVersion
 	vlabsCfg.AzureCNIURLLinux = apiCfg.AzureCNIURLLinux
 	vlabsCfg.AzureCNIURLWindows = apiCfg.AzureCNIURLWindows
+	vlabsCfg.CNIPluginsURL = apiCfg.CNIPluginsURL
+	vlabsCfg.EtcdDownloadURLBase = apiCfg.EtcdDownloadURLBase
+	vlabsCfg.ContainerdDownloadURLBase = apiCfg.ContainerdDownloadURLBase
 	vlabsCfg.KeyVaultSku = apiCfg.KeyVaultSku
 	vlabsCfg.MaximumLoadBalancerRuleCount = apiCfg.MaximumLoadBalancerRuleCount
 	vlabsCfg.ProxyMode = vlabs.KubeProxyMode(apiCfg.ProxyMode)
@@ -756,7 +783,9 @@ func convertKubernetesConfigToVLabs(apiCfg *KubernetesConfig, vlabsCfg *vlabs.Ku
 	convertAPIServerConfigToVlabs(apiCfg, vlabsCfg)
 	convertSchedulerConfigToVlabs(apiCfg, vlabsCfg)
 	convertPrivateClusterToVlabs(apiCfg, vlabsCfg)
+	convertEgressProfileToVlabs(apiCfg, vlabsCfg)
 	convertPodSecurityPolicyConfigToVlabs(apiCfg, vlabsCfg)
+	convertComponentOverridesToVlabs(apiCfg, vlabsCfg)
 }
 
 func convertKubeletConfigToVlabs(a *KubernetesConfig, v *vlabs.KubernetesConfig) {
@@ -813,6 +842,13 @@ func convertPodSecurityPolicyConfigToVlabs(a *KubernetesConfig, v *vlabs.Kuberne
 	}
 }
 
+func convertComponentOverridesToVlabs(a *KubernetesConfig, v *vlabs.KubernetesConfig) {
+	v.ComponentOverrides = map[string]string{}
+	for key, val := range a.ComponentOverrides {
+		v.ComponentOverrides[key] = val
+	}
+}
+
 func convertPrivateClusterToVlabs(a *KubernetesConfig, v *vlabs.KubernetesConfig) {
 	if a.PrivateCluster != nil {
 		v.PrivateCluster = &vlabs.PrivateCluster{}
@@ -821,6 +857,28 @@ func convertPrivateClusterToVlabs(a *KubernetesConfig, v *vlabs.KubernetesConfig
 			v.PrivateCluster.JumpboxProfile = &vlabs.PrivateJumpboxProfile{}
 			convertPrivateJumpboxProfileToVlabs(a.PrivateCluster.JumpboxProfile, v.PrivateCluster.JumpboxProfile)
 		}
+		if a.PrivateCluster.PrivateDNSZone != nil {
+			v.PrivateCluster.PrivateDNSZone = &vlabs.PrivateDNSZone{
+				Enabled:           a.PrivateCluster.PrivateDNSZone.Enabled,
+				Name:              a.PrivateCluster.PrivateDNSZone.Name,
+				ID:                a.PrivateCluster.PrivateDNSZone.ID,
+				VirtualNetworkIDs: a.PrivateCluster.PrivateDNSZone.VirtualNetworkIDs,
+			}
+		}
+	}
+}
+
+func convertEgressProfileToVlabs(a *KubernetesConfig, v *vlabs.KubernetesConfig) {
+	if a.EgressProfile != nil {
+		v.EgressProfile = &vlabs.EgressProfile{
+			Type:                   vlabs.EgressType(a.EgressProfile.Type),
+			OutboundIPCount:        a.EgressProfile.OutboundIPCount,
+			OutboundIPPrefixID:     a.EgressProfile.OutboundIPPrefixID,
+			AllocatedOutboundPorts: a.EgressProfile.AllocatedOutboundPorts,
+			IdleTimeoutInMinutes:   a.EgressProfile.IdleTimeoutInMinutes,
+			EnableTCPReset:         a.EgressProfile.EnableTCPReset,
+			FirewallIPAddress:      a.EgressProfile.FirewallIPAddress,
+		}
 	}
 }
 
@@ -907,6 +965,10 @@ func convertMasterProfileToVLabs(api *MasterProfile, vlabsProfile *vlabs.MasterP
 	vlabsProfile.SetSubnet(api.Subnet)
 	vlabsProfile.FQDN = api.FQDN
 	vlabsProfile.StorageProfile = api.StorageProfile
+	vlabsProfile.DiskEncryptionSetID = api.DiskEncryptionSetID
+	vlabsProfile.ProximityPlacementGroupID = api.ProximityPlacementGroupID
+	vlabsProfile.EnableProximityPlacementGroup = api.EnableProximityPlacementGroup
+	vlabsProfile.HostGroupID = api.HostGroupID
 	if api.PreprovisionExtension != nil {
 		vlabsExtension := &vlabs.Extension{}
 		convertExtensionToVLabs(api.PreprovisionExtension, vlabsExtension)
@@ -997,6 +1059,7 @@ func convertAgentPoolProfileToVLabs(api *AgentPoolProfile, p *vlabs.AgentPoolPro
 	p.Count = api.Count
 	p.VMSize = api.VMSize
 	p.OSDiskSizeGB = api.OSDiskSizeGB
+	p.OSDiskType = api.OSDiskType
 	p.DNSPrefix = api.DNSPrefix
 	p.OSType = vlabs.OSType(api.OSType)
 	p.Ports = []int{}
@@ -1007,6 +1070,11 @@ func convertAgentPoolProfileToVLabs(api *AgentPoolProfile, p *vlabs.AgentPoolPro
 	p.StorageProfile = api.StorageProfile
 	p.DiskSizesGB = []int{}
 	p.DiskSizesGB = append(p.DiskSizesGB, api.DiskSizesGB...)
+	p.DiskEncryptionSetID = api.DiskEncryptionSetID
+	p.ProximityPlacementGroupID = api.ProximityPlacementGroupID
+	p.EnableProximityPlacementGroup = api.EnableProximityPlacementGroup
+	p.HostGroupID = api.HostGroupID
+	p.OrchestratorVersion = api.OrchestratorVersion
 	p.VnetSubnetID = api.VnetSubnetID
 	p.SetSubnet(api.Subnet)
 	p.FQDN = api.FQDN
@@ -1017,10 +1085,16 @@ func convertAgentPoolProfileToVLabs(api *AgentPoolProfile, p *vlabs.AgentPoolPro
 	p.AvailabilityZones = api.AvailabilityZones
 	p.SinglePlacementGroup = api.SinglePlacementGroup
 	p.EnableVMSSNodePublicIP = api.EnableVMSSNodePublicIP
+	p.NetworkSecurityGroupID = api.NetworkSecurityGroupID
+	p.EnableDedicatedNetworkSecurityGroup = api.EnableDedicatedNetworkSecurityGroup
+	p.RouteTableID = api.RouteTableID
 
 	for k, v := range api.CustomNodeLabels {
 		p.CustomNodeLabels[k] = v
 	}
+	if api.Taints != nil {
+		p.Taints = append([]string{}, api.Taints...)
+	}
 
 	if api.PreprovisionExtension != nil {
 		vlabsExtension := &vlabs.Extension{}
@@ -1140,6 +1214,7 @@ func convertServicePrincipalProfileToVLabs(api *ServicePrincipalProfile, v *vlab
 	v.ClientID = api.ClientID
 	v.Secret = api.Secret
 	v.ObjectID = api.ObjectID
+	v.ApplicationObjectID = api.ApplicationObjectID
 	if api.KeyvaultSecretRef != nil {
 		v.KeyvaultSecretRef = &vlabs.KeyvaultSecretRef{
 			VaultID:       api.KeyvaultSecretRef.VaultID,
@@ -1176,6 +1251,39 @@ func convertAADProfileToVLabs(api *AADProfile, vlabs *vlabs.AADProfile) {
 func convertFeatureFlagsToVLabs(api *FeatureFlags, vlabs *vlabs.FeatureFlags) {
 	vlabs.EnableCSERunInBackground = api.EnableCSERunInBackground
 	vlabs.BlockOutboundInternet = api.BlockOutboundInternet
+	vlabs.EnableIPv6DualStack = api.EnableIPv6DualStack
+}
+
+func convertLintProfileToVLabs(api *LintProfile, vlabs *vlabs.LintProfile) {
+	vlabs.SuppressedRules = api.SuppressedRules
+}
+
+func convertNetworkSecurityProfileToVLabs(api *NetworkSecurityProfile, vlabsProfile *vlabs.NetworkSecurityProfile) {
+	vlabsProfile.DisableSSH = api.DisableSSH
+	vlabsProfile.SSHSourceAddressPrefixes = api.SSHSourceAddressPrefixes
+	vlabsProfile.APIServerSourceAddressPrefixes = api.APIServerSourceAddressPrefixes
+	vlabsProfile.RDPSourceAddressPrefixes = api.RDPSourceAddressPrefixes
+	for _, r := range api.SecurityRules {
+		vlabsProfile.SecurityRules = append(vlabsProfile.SecurityRules, vlabs.NetworkSecurityRule{
+			Name:                       r.Name,
+			Description:                r.Description,
+			Priority:                   r.Priority,
+			Direction:                  r.Direction,
+			Access:                     r.Access,
+			Protocol:                   r.Protocol,
+			SourceAddressPrefixes:      r.SourceAddressPrefixes,
+			SourcePortRange:            r.SourcePortRange,
+			DestinationAddressPrefixes: r.DestinationAddressPrefixes,
+			DestinationPortRange:       r.DestinationPortRange,
+		})
+	}
+}
+
+func convertHTTPProxyConfigToVLabs(api *HTTPProxyConfig, vlabsConfig *vlabs.HTTPProxyConfig) {
+	vlabsConfig.HTTPProxy = api.HTTPProxy
+	vlabsConfig.HTTPSProxy = api.HTTPSProxy
+	vlabsConfig.NoProxy = api.NoProxy
+	vlabsConfig.TrustedCA = api.TrustedCA
 }
 
 func convertCloudProfileToVLabs(api *CustomCloudProfile, vlabsccp *vlabs.CustomCloudProfile) {
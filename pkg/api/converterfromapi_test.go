@@ -5,6 +5,7 @@ package api
 
 import (
 	"net/url"
+	"reflect"
 	"testing"
 
 	"github.com/Azure/go-autorest/autorest/to"
@@ -351,6 +352,208 @@ func TestConvertContainerServiceToVLabs(t *testing.T) {
 	}
 }
 
+func TestConvertLintProfileToVLabs(t *testing.T) {
+	cs := getDefaultContainerService()
+	cs.Properties.LintProfile = &LintProfile{
+		SuppressedRules: []string{"AKSE005"},
+	}
+	vlabsCS := ConvertContainerServiceToVLabs(cs)
+	if vlabsCS.Properties.LintProfile == nil {
+		t.Fatalf("expected the lintProfile to be converted")
+	}
+	if len(vlabsCS.Properties.LintProfile.SuppressedRules) != 1 || vlabsCS.Properties.LintProfile.SuppressedRules[0] != "AKSE005" {
+		t.Errorf("expected suppressedRules to be [AKSE005], got %v", vlabsCS.Properties.LintProfile.SuppressedRules)
+	}
+}
+
+func TestConvertNetworkSecurityProfileToVLabs(t *testing.T) {
+	cs := getDefaultContainerService()
+	cs.Properties.NetworkSecurityProfile = &NetworkSecurityProfile{
+		DisableSSH:                     true,
+		APIServerSourceAddressPrefixes: []string{"10.0.0.0/8"},
+		SecurityRules: []NetworkSecurityRule{
+			{
+				Name:                 "allow_prometheus",
+				Priority:             200,
+				Direction:            "Inbound",
+				Access:               "Allow",
+				Protocol:             "Tcp",
+				DestinationPortRange: "9090",
+			},
+		},
+	}
+	vlabsCS := ConvertContainerServiceToVLabs(cs)
+	nsp := vlabsCS.Properties.NetworkSecurityProfile
+	if nsp == nil {
+		t.Fatalf("expected the networkSecurityProfile to be converted")
+	}
+	if !nsp.DisableSSH || len(nsp.APIServerSourceAddressPrefixes) != 1 || len(nsp.SecurityRules) != 1 {
+		t.Fatalf("unexpected networkSecurityProfile %+v", nsp)
+	}
+	if nsp.SecurityRules[0].Name != "allow_prometheus" || nsp.SecurityRules[0].Priority != 200 || nsp.SecurityRules[0].DestinationPortRange != "9090" {
+		t.Errorf("unexpected security rule %+v", nsp.SecurityRules[0])
+	}
+}
+
+func TestConvertHTTPProxyConfigToVLabs(t *testing.T) {
+	cs := getDefaultContainerService()
+	cs.Properties.HTTPProxyConfig = &HTTPProxyConfig{
+		HTTPProxy: "http://proxy.contoso.com:3128",
+		NoProxy:   []string{"contoso.com"},
+		TrustedCA: "Zm9vYmFy",
+	}
+	vlabsCS := ConvertContainerServiceToVLabs(cs)
+	hpc := vlabsCS.Properties.HTTPProxyConfig
+	if hpc == nil {
+		t.Fatalf("expected the httpProxyConfig to be converted")
+	}
+	if hpc.HTTPProxy != "http://proxy.contoso.com:3128" || hpc.HTTPSProxy != "" || len(hpc.NoProxy) != 1 || hpc.TrustedCA != "Zm9vYmFy" {
+		t.Errorf("unexpected httpProxyConfig %+v", hpc)
+	}
+}
+
+func TestConvertPrivateDNSZoneToVLabs(t *testing.T) {
+	cs := getDefaultContainerService()
+	cs.Properties.OrchestratorProfile.KubernetesConfig.PrivateCluster = &PrivateCluster{
+		Enabled: to.BoolPtr(true),
+		PrivateDNSZone: &PrivateDNSZone{
+			Enabled: to.BoolPtr(true),
+			ID:      "/subscriptions/SUB_ID/resourceGroups/RG_NAME/providers/Microsoft.Network/privateDnsZones/privatelink.contoso.com",
+		},
+	}
+	vlabsCS := ConvertContainerServiceToVLabs(cs)
+	zone := vlabsCS.Properties.OrchestratorProfile.KubernetesConfig.PrivateCluster.PrivateDNSZone
+	if zone == nil {
+		t.Fatalf("expected the privateDnsZone to be converted")
+	}
+	if !to.Bool(zone.Enabled) || zone.ID != cs.Properties.OrchestratorProfile.KubernetesConfig.PrivateCluster.PrivateDNSZone.ID || zone.Name != "" {
+		t.Errorf("unexpected privateDnsZone %+v", zone)
+	}
+}
+
+func TestConvertExtensionProfileSourceToVLabs(t *testing.T) {
+	cs := getDefaultContainerService()
+	cs.Properties.ExtensionProfiles = []*ExtensionProfile{
+		{
+			Name:      "hello",
+			LocalPath: "/extensions",
+			Checksums: map[string]string{"hello.sh": "sha256"},
+		},
+	}
+	vlabsCS := ConvertContainerServiceToVLabs(cs)
+	extension := vlabsCS.Properties.ExtensionProfiles[0]
+	if extension.LocalPath != "/extensions" || extension.Upload || extension.Checksums["hello.sh"] != "sha256" {
+		t.Errorf("unexpected extension profile %+v", extension)
+	}
+}
+
+func TestConvertEgressProfileToVLabs(t *testing.T) {
+	cs := getDefaultContainerService()
+	cs.Properties.OrchestratorProfile.KubernetesConfig.EgressProfile = &EgressProfile{
+		Type:                   EgressTypeLoadBalancer,
+		OutboundIPCount:        2,
+		AllocatedOutboundPorts: 1024,
+		IdleTimeoutInMinutes:   15,
+		EnableTCPReset:         to.BoolPtr(false),
+	}
+	vlabsCS := ConvertContainerServiceToVLabs(cs)
+	ep := vlabsCS.Properties.OrchestratorProfile.KubernetesConfig.EgressProfile
+	if ep == nil {
+		t.Fatalf("expected the egressProfile to be converted")
+	}
+	if ep.Type != vlabs.EgressTypeLoadBalancer || ep.OutboundIPCount != 2 || ep.AllocatedOutboundPorts != 1024 || ep.IdleTimeoutInMinutes != 15 {
+		t.Errorf("unexpected egressProfile %+v", ep)
+	}
+	if ep.EnableTCPReset == nil || *ep.EnableTCPReset {
+		t.Errorf("expected enableTCPReset to be false, got %v", ep.EnableTCPReset)
+	}
+}
+
+func TestConvertAgentPoolNetworkIsolationToVLabs(t *testing.T) {
+	api := &AgentPoolProfile{
+		Name:                                "pool1",
+		NetworkSecurityGroupID:              "/subscriptions/SUB_ID/resourceGroups/RG_NAME/providers/Microsoft.Network/networkSecurityGroups/pool1-nsg",
+		RouteTableID:                        "/subscriptions/SUB_ID/resourceGroups/RG_NAME/providers/Microsoft.Network/routeTables/pool1-routetable",
+		EnableDedicatedNetworkSecurityGroup: to.BoolPtr(false),
+	}
+	p := &vlabs.AgentPoolProfile{}
+	convertAgentPoolProfileToVLabs(api, p)
+	if p.NetworkSecurityGroupID != api.NetworkSecurityGroupID || p.RouteTableID != api.RouteTableID {
+		t.Errorf("unexpected agent pool network isolation %+v", p)
+	}
+	if p.EnableDedicatedNetworkSecurityGroup == nil || *p.EnableDedicatedNetworkSecurityGroup {
+		t.Errorf("unexpected enableDedicatedNetworkSecurityGroup %v", p.EnableDedicatedNetworkSecurityGroup)
+	}
+}
+
+func TestConvertAgentPoolTaintsToVLabs(t *testing.T) {
+	api := &AgentPoolProfile{
+		Name:   "pool1",
+		Taints: []string{"dedicated=gpu:NoSchedule", "example.com/spot:PreferNoSchedule"},
+	}
+	p := &vlabs.AgentPoolProfile{}
+	convertAgentPoolProfileToVLabs(api, p)
+	if !reflect.DeepEqual(p.Taints, api.Taints) {
+		t.Errorf("expected taints %v, got %v", api.Taints, p.Taints)
+	}
+	api.Taints[0] = "dedicated=cpu:NoSchedule"
+	if p.Taints[0] != "dedicated=gpu:NoSchedule" {
+		t.Errorf("expected the vlabs taints to be a copy of the API model taints")
+	}
+}
+
+func TestConvertAgentPoolOSDiskTypeToVLabs(t *testing.T) {
+	api := &AgentPoolProfile{
+		Name:       "pool1",
+		OSDiskType: OSDiskTypeEphemeral,
+	}
+	p := &vlabs.AgentPoolProfile{}
+	convertAgentPoolProfileToVLabs(api, p)
+	if p.OSDiskType != vlabs.OSDiskTypeEphemeral {
+		t.Errorf("expected OSDiskType %s, got %s", vlabs.OSDiskTypeEphemeral, p.OSDiskType)
+	}
+}
+
+func TestConvertDiskEncryptionSetIDToVLabs(t *testing.T) {
+	desID := "/subscriptions/SUB_ID/resourceGroups/RG_NAME/providers/Microsoft.Compute/diskEncryptionSets/des"
+	vlabsMaster := &vlabs.MasterProfile{}
+	convertMasterProfileToVLabs(&MasterProfile{DiskEncryptionSetID: desID}, vlabsMaster)
+	if vlabsMaster.DiskEncryptionSetID != desID {
+		t.Errorf("expected master DiskEncryptionSetID %s, got %s", desID, vlabsMaster.DiskEncryptionSetID)
+	}
+	p := &vlabs.AgentPoolProfile{}
+	convertAgentPoolProfileToVLabs(&AgentPoolProfile{Name: "pool1", DiskEncryptionSetID: desID}, p)
+	if p.DiskEncryptionSetID != desID {
+		t.Errorf("expected agent pool DiskEncryptionSetID %s, got %s", desID, p.DiskEncryptionSetID)
+	}
+}
+
+func TestConvertPlacementToVLabs(t *testing.T) {
+	ppgID := "/subscriptions/SUB_ID/resourceGroups/RG_NAME/providers/Microsoft.Compute/proximityPlacementGroups/ppg"
+	hostGroupID := "/subscriptions/SUB_ID/resourceGroups/RG_NAME/providers/Microsoft.Compute/hostGroups/hg"
+	vlabsMaster := &vlabs.MasterProfile{}
+	convertMasterProfileToVLabs(&MasterProfile{ProximityPlacementGroupID: ppgID}, vlabsMaster)
+	if vlabsMaster.ProximityPlacementGroupID != ppgID {
+		t.Errorf("expected master ProximityPlacementGroupID %s, got %s", ppgID, vlabsMaster.ProximityPlacementGroupID)
+	}
+	p := &vlabs.AgentPoolProfile{}
+	convertAgentPoolProfileToVLabs(&AgentPoolProfile{Name: "pool1", EnableProximityPlacementGroup: to.BoolPtr(true), HostGroupID: hostGroupID}, p)
+	if !to.Bool(p.EnableProximityPlacementGroup) {
+		t.Errorf("expected agent pool EnableProximityPlacementGroup to be true")
+	}
+	if p.HostGroupID != hostGroupID {
+		t.Errorf("expected agent pool HostGroupID %s, got %s", hostGroupID, p.HostGroupID)
+	}
+}
+
+func TestConvertAgentPoolOrchestratorVersionToVLabs(t *testing.T) {
+	p := &vlabs.AgentPoolProfile{}
+	convertAgentPoolProfileToVLabs(&AgentPoolProfile{Name: "pool1", OrchestratorVersion: "1.14.7"}, p)
+	if p.OrchestratorVersion != "1.14.7" {
+		t.Errorf("expected agent pool OrchestratorVersion 1.14.7, got %s", p.OrchestratorVersion)
+	}
+}
+
 func getDefaultContainerService() *ContainerService {
 	u, _ := url.Parse("http://foobar.com/search")
 	return &ContainerService{
@@ -458,6 +661,7 @@ func getDefaultContainerService() *ContainerService {
 			FeatureFlags: &FeatureFlags{
 				EnableCSERunInBackground: true,
 				BlockOutboundInternet:    false,
+				EnableIPv6DualStack:      true,
 			},
 			AADProfile: &AADProfile{
 				ClientAppID:     "SampleClientAppID",
@@ -568,6 +772,9 @@ func getDefaultContainerService() *ContainerService {
 					AzureCNIVersion:                 "1.0.18",
 					AzureCNIURLLinux:                "https://mirror.azk8s.cn/kubernetes/azure-container-networking/linux",
 					AzureCNIURLWindows:              "https://mirror.azk8s.cn/kubernetes/azure-container-networking/windows",
+					CNIPluginsURL:                   "https://mirror.azk8s.cn/kubernetes/containernetworking-plugins/cni-plugins-amd64-v0.7.1.tgz",
+					EtcdDownloadURLBase:             "https://mirror.azk8s.cn/kubernetes/etcd",
+					ContainerdDownloadURLBase:       "https://mirror.azk8s.cn/kubernetes/containerd/",
 					KeyVaultSku:                     "Basic",
 					MaximumLoadBalancerRuleCount:    3,
 					ProxyMode:                       KubeProxyModeIPTables,
@@ -618,6 +825,9 @@ func getDefaultContainerService() *ContainerService {
 					PodSecurityPolicyConfig: map[string]string{
 						"samplePSPConfigKey": "samplePSPConfigVal",
 					},
+					ComponentOverrides: map[string]string{
+						"coredns": "coredns:1.3.1",
+					},
 				},
 			},
 			AgentPoolProfiles: []*AgentPoolProfile{
@@ -414,12 +414,60 @@ func convertVLabsProperties(vlabs *vlabs.Properties, api *Properties, isUpdate b
 		convertVLabsCustomCloudProfile(vlabs.CustomCloudProfile, api.CustomCloudProfile)
 	}
 
+	if vlabs.LintProfile != nil {
+		api.LintProfile = &LintProfile{}
+		convertVLabsLintProfile(vlabs.LintProfile, api.LintProfile)
+	}
+
+	if vlabs.NetworkSecurityProfile != nil {
+		api.NetworkSecurityProfile = &NetworkSecurityProfile{}
+		convertVLabsNetworkSecurityProfile(vlabs.NetworkSecurityProfile, api.NetworkSecurityProfile)
+	}
+
+	if vlabs.HTTPProxyConfig != nil {
+		api.HTTPProxyConfig = &HTTPProxyConfig{}
+		convertVLabsHTTPProxyConfig(vlabs.HTTPProxyConfig, api.HTTPProxyConfig)
+	}
+
 	return nil
 }
 
 func convertVLabsFeatureFlags(vlabs *vlabs.FeatureFlags, api *FeatureFlags) {
 	api.EnableCSERunInBackground = vlabs.EnableCSERunInBackground
 	api.BlockOutboundInternet = vlabs.BlockOutboundInternet
+	api.EnableIPv6DualStack = vlabs.EnableIPv6DualStack
+}
+
+func convertVLabsLintProfile(vlabs *vlabs.LintProfile, api *LintProfile) {
+	api.SuppressedRules = vlabs.SuppressedRules
+}
+
+func convertVLabsNetworkSecurityProfile(vlabs *vlabs.NetworkSecurityProfile, api *NetworkSecurityProfile) {
+	api.DisableSSH = vlabs.DisableSSH
+	api.SSHSourceAddressPrefixes = vlabs.SSHSourceAddressPrefixes
+	api.APIServerSourceAddressPrefixes = vlabs.APIServerSourceAddressPrefixes
+	api.RDPSourceAddressPrefixes = vlabs.RDPSourceAddressPrefixes
+	for _, r := range vlabs.SecurityRules {
+		api.SecurityRules = append(api.SecurityRules, NetworkSecurityRule{
+			Name:                       r.Name,
+			Description:                r.Description,
+			Priority:                   r.Priority,
+			Direction:                  r.Direction,
+			Access:                     r.Access,
+			Protocol:                   r.Protocol,
+			SourceAddressPrefixes:      r.SourceAddressPrefixes,
+			SourcePortRange:            r.SourcePortRange,
+			DestinationAddressPrefixes: r.DestinationAddressPrefixes,
+			DestinationPortRange:       r.DestinationPortRange,
+		})
+	}
+}
+
+func convertVLabsHTTPProxyConfig(vlabs *vlabs.HTTPProxyConfig, api *HTTPProxyConfig) {
+	api.HTTPProxy = vlabs.HTTPProxy
+	api.HTTPSProxy = vlabs.HTTPSProxy
+	api.NoProxy = vlabs.NoProxy
+	api.TrustedCA = vlabs.TrustedCA
 }
 
 func convertV20160930LinuxProfile(obj *v20160930.LinuxProfile, api *LinuxProfile) {
@@ -462,6 +510,15 @@ func convertVLabsExtensionProfile(vlabs *vlabs.ExtensionProfile, api *ExtensionP
 	api.RootURL = vlabs.RootURL
 	api.Script = vlabs.Script
 	api.URLQuery = vlabs.URLQuery
+	api.LocalPath = vlabs.LocalPath
+	api.OCIReference = vlabs.OCIReference
+	api.Upload = vlabs.Upload
+	if vlabs.Checksums != nil {
+		api.Checksums = map[string]string{}
+		for k, v := range vlabs.Checksums {
+			api.Checksums[k] = v
+		}
+	}
 }
 
 func convertVLabsExtension(vlabs *vlabs.Extension, api *Extension) {
@@ -708,6 +765,9 @@ func convertVLabsKubernetesConfig(vlabs *vlabs.KubernetesConfig, api *Kubernetes
 	api.AzureCNIVersion = vlabs.AzureCNIVersion
 	api.AzureCNIURLLinux = vlabs.AzureCNIURLLinux
 	api.AzureCNIURLWindows = vlabs.AzureCNIURLWindows
+	api.CNIPluginsURL = vlabs.CNIPluginsURL
+	api.EtcdDownloadURLBase = vlabs.EtcdDownloadURLBase
+	api.ContainerdDownloadURLBase = vlabs.ContainerdDownloadURLBase
 	api.KeyVaultSku = vlabs.KeyVaultSku
 	api.MaximumLoadBalancerRuleCount = vlabs.MaximumLoadBalancerRuleCount
 	api.ProxyMode = KubeProxyMode(vlabs.ProxyMode)
@@ -719,7 +779,9 @@ func convertVLabsKubernetesConfig(vlabs *vlabs.KubernetesConfig, api *Kubernetes
 	convertAPIServerConfigToAPI(vlabs, api)
 	convertSchedulerConfigToAPI(vlabs, api)
 	convertPrivateClusterToAPI(vlabs, api)
+	convertEgressProfileToAPI(vlabs, api)
 	convertPodSecurityPolicyConfigToAPI(vlabs, api)
+	convertComponentOverridesToAPI(vlabs, api)
 }
 
 func setVlabsKubernetesDefaults(vp *vlabs.Properties, api *OrchestratorProfile) {
@@ -832,6 +894,13 @@ func convertPodSecurityPolicyConfigToAPI(v *vlabs.KubernetesConfig, a *Kubernete
 	}
 }
 
+func convertComponentOverridesToAPI(v *vlabs.KubernetesConfig, a *KubernetesConfig) {
+	a.ComponentOverrides = map[string]string{}
+	for key, val := range v.ComponentOverrides {
+		a.ComponentOverrides[key] = val
+	}
+}
+
 func convertPrivateClusterToAPI(v *vlabs.KubernetesConfig, a *KubernetesConfig) {
 	if v.PrivateCluster != nil {
 		a.PrivateCluster = &PrivateCluster{}
@@ -840,6 +909,28 @@ func convertPrivateClusterToAPI(v *vlabs.KubernetesConfig, a *KubernetesConfig)
 			a.PrivateCluster.JumpboxProfile = &PrivateJumpboxProfile{}
 			convertPrivateJumpboxProfileToAPI(v.PrivateCluster.JumpboxProfile, a.PrivateCluster.JumpboxProfile)
 		}
+		if v.PrivateCluster.PrivateDNSZone != nil {
+			a.PrivateCluster.PrivateDNSZone = &PrivateDNSZone{
+				Enabled:           v.PrivateCluster.PrivateDNSZone.Enabled,
+				Name:              v.PrivateCluster.PrivateDNSZone.Name,
+				ID:                v.PrivateCluster.PrivateDNSZone.ID,
+				VirtualNetworkIDs: v.PrivateCluster.PrivateDNSZone.VirtualNetworkIDs,
+			}
+		}
+	}
+}
+
+func convertEgressProfileToAPI(v *vlabs.KubernetesConfig, a *KubernetesConfig) {
+	if v.EgressProfile != nil {
+		a.EgressProfile = &EgressProfile{
+			Type:                   EgressType(v.EgressProfile.Type),
+			OutboundIPCount:        v.EgressProfile.OutboundIPCount,
+			OutboundIPPrefixID:     v.EgressProfile.OutboundIPPrefixID,
+			AllocatedOutboundPorts: v.EgressProfile.AllocatedOutboundPorts,
+			IdleTimeoutInMinutes:   v.EgressProfile.IdleTimeoutInMinutes,
+			EnableTCPReset:         v.EgressProfile.EnableTCPReset,
+			FirewallIPAddress:      v.EgressProfile.FirewallIPAddress,
+		}
 	}
 }
 
@@ -909,6 +1000,10 @@ func convertVLabsMasterProfile(vlabs *vlabs.MasterProfile, api *MasterProfile) {
 	api.IPAddressCount = vlabs.IPAddressCount
 	api.FQDN = vlabs.FQDN
 	api.StorageProfile = vlabs.StorageProfile
+	api.DiskEncryptionSetID = vlabs.DiskEncryptionSetID
+	api.ProximityPlacementGroupID = vlabs.ProximityPlacementGroupID
+	api.EnableProximityPlacementGroup = vlabs.EnableProximityPlacementGroup
+	api.HostGroupID = vlabs.HostGroupID
 	api.HTTPSourceAddressPrefix = vlabs.HTTPSourceAddressPrefix
 	api.OAuthEnabled = vlabs.OAuthEnabled
 	// by default vlabs will use managed disks as it has encryption at rest
@@ -1013,6 +1108,7 @@ func convertVLabsAgentPoolProfile(vlabs *vlabs.AgentPoolProfile, api *AgentPoolP
 	api.Count = vlabs.Count
 	api.VMSize = vlabs.VMSize
 	api.OSDiskSizeGB = vlabs.OSDiskSizeGB
+	api.OSDiskType = vlabs.OSDiskType
 	api.DNSPrefix = vlabs.DNSPrefix
 	api.OSType = OSType(vlabs.OSType)
 	api.Ports = []int{}
@@ -1023,6 +1119,11 @@ func convertVLabsAgentPoolProfile(vlabs *vlabs.AgentPoolProfile, api *AgentPoolP
 	api.StorageProfile = vlabs.StorageProfile
 	api.DiskSizesGB = []int{}
 	api.DiskSizesGB = append(api.DiskSizesGB, vlabs.DiskSizesGB...)
+	api.DiskEncryptionSetID = vlabs.DiskEncryptionSetID
+	api.ProximityPlacementGroupID = vlabs.ProximityPlacementGroupID
+	api.EnableProximityPlacementGroup = vlabs.EnableProximityPlacementGroup
+	api.HostGroupID = vlabs.HostGroupID
+	api.OrchestratorVersion = vlabs.OrchestratorVersion
 	api.VnetSubnetID = vlabs.VnetSubnetID
 	api.Subnet = vlabs.GetSubnet()
 	api.IPAddressCount = vlabs.IPAddressCount
@@ -1033,11 +1134,17 @@ func convertVLabsAgentPoolProfile(vlabs *vlabs.AgentPoolProfile, api *AgentPoolP
 	api.AvailabilityZones = vlabs.AvailabilityZones
 	api.SinglePlacementGroup = vlabs.SinglePlacementGroup
 	api.EnableVMSSNodePublicIP = vlabs.EnableVMSSNodePublicIP
+	api.NetworkSecurityGroupID = vlabs.NetworkSecurityGroupID
+	api.EnableDedicatedNetworkSecurityGroup = vlabs.EnableDedicatedNetworkSecurityGroup
+	api.RouteTableID = vlabs.RouteTableID
 
 	api.CustomNodeLabels = map[string]string{}
 	for k, v := range vlabs.CustomNodeLabels {
 		api.CustomNodeLabels[k] = v
 	}
+	if vlabs.Taints != nil {
+		api.Taints = append([]string{}, vlabs.Taints...)
+	}
 
 	if vlabs.PreProvisionExtension != nil {
 		apiExtension := &Extension{}
@@ -1158,6 +1265,7 @@ func convertVLabsServicePrincipalProfile(vlabs *vlabs.ServicePrincipalProfile, a
 	api.ClientID = vlabs.ClientID
 	api.Secret = vlabs.Secret
 	api.ObjectID = vlabs.ObjectID
+	api.ApplicationObjectID = vlabs.ApplicationObjectID
 	if vlabs.KeyvaultSecretRef != nil {
 		api.KeyvaultSecretRef = &KeyvaultSecretRef{
 			VaultID:       vlabs.KeyvaultSecretRef.VaultID,
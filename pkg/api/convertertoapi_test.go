@@ -224,6 +224,20 @@ func TestConvertVLabsKubernetesConfigProfile(t *testing.T) {
 				WindowsNodeBinariesURL: "http://test/test.tar.gz",
 			},
 		},
+		"EgressProfile": {
+			props: &vlabs.KubernetesConfig{
+				EgressProfile: &vlabs.EgressProfile{
+					Type:              vlabs.EgressTypeUserDefinedRouting,
+					FirewallIPAddress: "10.241.0.4",
+				},
+			},
+			expect: &KubernetesConfig{
+				EgressProfile: &EgressProfile{
+					Type:              EgressTypeUserDefinedRouting,
+					FirewallIPAddress: "10.241.0.4",
+				},
+			},
+		},
 	}
 
 	for name, test := range tests {
@@ -236,6 +250,232 @@ func TestConvertVLabsKubernetesConfigProfile(t *testing.T) {
 	}
 }
 
+func TestConvertVLabsLintProfile(t *testing.T) {
+	vp := makeKubernetesPropertiesVlabs()
+	vp.LintProfile = &vlabs.LintProfile{
+		SuppressedRules: []string{"AKSE001", "AKSE003"},
+	}
+	ap := &Properties{}
+	if err := convertVLabsProperties(vp, ap, false); err != nil {
+		t.Fatalf("unexpected error converting vlabs properties: %s", err)
+	}
+	expected := &LintProfile{
+		SuppressedRules: []string{"AKSE001", "AKSE003"},
+	}
+	if !equality.Semantic.DeepEqual(expected, ap.LintProfile) {
+		t.Errorf(spew.Sprintf("Expected:\n%+v\nGot:\n%+v", expected, ap.LintProfile))
+	}
+}
+
+func TestConvertVLabsNetworkSecurityProfile(t *testing.T) {
+	vp := makeKubernetesPropertiesVlabs()
+	vp.NetworkSecurityProfile = &vlabs.NetworkSecurityProfile{
+		SSHSourceAddressPrefixes: []string{"203.0.113.0/24"},
+		RDPSourceAddressPrefixes: []string{"203.0.113.10"},
+		SecurityRules: []vlabs.NetworkSecurityRule{
+			{
+				Name:                       "deny_smtp",
+				Description:                "Block outbound SMTP",
+				Priority:                   300,
+				Direction:                  "Outbound",
+				Access:                     "Deny",
+				Protocol:                   "Tcp",
+				DestinationAddressPrefixes: []string{"Internet"},
+				DestinationPortRange:       "25",
+			},
+		},
+	}
+	ap := &Properties{}
+	if err := convertVLabsProperties(vp, ap, false); err != nil {
+		t.Fatalf("unexpected error converting vlabs properties: %s", err)
+	}
+	expected := &NetworkSecurityProfile{
+		SSHSourceAddressPrefixes: []string{"203.0.113.0/24"},
+		RDPSourceAddressPrefixes: []string{"203.0.113.10"},
+		SecurityRules: []NetworkSecurityRule{
+			{
+				Name:                       "deny_smtp",
+				Description:                "Block outbound SMTP",
+				Priority:                   300,
+				Direction:                  "Outbound",
+				Access:                     "Deny",
+				Protocol:                   "Tcp",
+				DestinationAddressPrefixes: []string{"Internet"},
+				DestinationPortRange:       "25",
+			},
+		},
+	}
+	if !equality.Semantic.DeepEqual(expected, ap.NetworkSecurityProfile) {
+		t.Errorf(spew.Sprintf("Expected:\n%+v\nGot:\n%+v", expected, ap.NetworkSecurityProfile))
+	}
+}
+
+func TestConvertVLabsHTTPProxyConfig(t *testing.T) {
+	vp := makeKubernetesPropertiesVlabs()
+	vp.HTTPProxyConfig = &vlabs.HTTPProxyConfig{
+		HTTPProxy:  "http://proxy.contoso.com:3128",
+		HTTPSProxy: "https://proxy.contoso.com:3129",
+		NoProxy:    []string{"contoso.com", "10.1.0.0/16"},
+		TrustedCA:  "Zm9vYmFy",
+	}
+	ap := &Properties{}
+	if err := convertVLabsProperties(vp, ap, false); err != nil {
+		t.Fatalf("unexpected error converting vlabs properties: %s", err)
+	}
+	expected := &HTTPProxyConfig{
+		HTTPProxy:  "http://proxy.contoso.com:3128",
+		HTTPSProxy: "https://proxy.contoso.com:3129",
+		NoProxy:    []string{"contoso.com", "10.1.0.0/16"},
+		TrustedCA:  "Zm9vYmFy",
+	}
+	if !equality.Semantic.DeepEqual(expected, ap.HTTPProxyConfig) {
+		t.Errorf(spew.Sprintf("Expected:\n%+v\nGot:\n%+v", expected, ap.HTTPProxyConfig))
+	}
+}
+
+func TestConvertVLabsPrivateDNSZone(t *testing.T) {
+	vk := &vlabs.KubernetesConfig{
+		PrivateCluster: &vlabs.PrivateCluster{
+			Enabled: to.BoolPtr(true),
+			PrivateDNSZone: &vlabs.PrivateDNSZone{
+				Enabled:           to.BoolPtr(true),
+				Name:              "privatelink.contoso.com",
+				VirtualNetworkIDs: []string{"/subscriptions/SUB_ID/resourceGroups/RG_NAME/providers/Microsoft.Network/virtualNetworks/hub"},
+			},
+		},
+	}
+	ak := &KubernetesConfig{}
+	convertPrivateClusterToAPI(vk, ak)
+	expected := &PrivateDNSZone{
+		Enabled:           to.BoolPtr(true),
+		Name:              "privatelink.contoso.com",
+		VirtualNetworkIDs: []string{"/subscriptions/SUB_ID/resourceGroups/RG_NAME/providers/Microsoft.Network/virtualNetworks/hub"},
+	}
+	if !equality.Semantic.DeepEqual(expected, ak.PrivateCluster.PrivateDNSZone) {
+		t.Errorf(spew.Sprintf("Expected:\n%+v\nGot:\n%+v", expected, ak.PrivateCluster.PrivateDNSZone))
+	}
+}
+
+func TestConvertVLabsAgentPoolNetworkIsolation(t *testing.T) {
+	vlabsProfile := &vlabs.AgentPoolProfile{
+		Name:                                "pool1",
+		RouteTableID:                        "/subscriptions/SUB_ID/resourceGroups/RG_NAME/providers/Microsoft.Network/routeTables/pool1-routetable",
+		EnableDedicatedNetworkSecurityGroup: to.BoolPtr(true),
+	}
+	apiProfile := &AgentPoolProfile{}
+	convertVLabsAgentPoolProfile(vlabsProfile, apiProfile)
+	expected := &AgentPoolProfile{
+		Name:                                "pool1",
+		RouteTableID:                        "/subscriptions/SUB_ID/resourceGroups/RG_NAME/providers/Microsoft.Network/routeTables/pool1-routetable",
+		EnableDedicatedNetworkSecurityGroup: to.BoolPtr(true),
+	}
+	if apiProfile.RouteTableID != expected.RouteTableID || apiProfile.NetworkSecurityGroupID != "" {
+		t.Errorf(spew.Sprintf("Expected:\n%+v\nGot:\n%+v", expected, apiProfile))
+	}
+	if !equality.Semantic.DeepEqual(expected.EnableDedicatedNetworkSecurityGroup, apiProfile.EnableDedicatedNetworkSecurityGroup) {
+		t.Errorf("expected enableDedicatedNetworkSecurityGroup to be converted, got %v", apiProfile.EnableDedicatedNetworkSecurityGroup)
+	}
+}
+
+func TestConvertVLabsAgentPoolTaints(t *testing.T) {
+	vlabsProfile := &vlabs.AgentPoolProfile{
+		Name:   "pool1",
+		Taints: []string{"dedicated=gpu:NoSchedule"},
+	}
+	apiProfile := &AgentPoolProfile{}
+	convertVLabsAgentPoolProfile(vlabsProfile, apiProfile)
+	expected := []string{"dedicated=gpu:NoSchedule"}
+	if !equality.Semantic.DeepEqual(expected, apiProfile.Taints) {
+		t.Errorf(spew.Sprintf("Expected:\n%+v\nGot:\n%+v", expected, apiProfile.Taints))
+	}
+
+	apiProfile = &AgentPoolProfile{}
+	convertVLabsAgentPoolProfile(&vlabs.AgentPoolProfile{Name: "pool1"}, apiProfile)
+	if apiProfile.Taints != nil {
+		t.Errorf("expected no taints, got %v", apiProfile.Taints)
+	}
+}
+
+func TestConvertVLabsAgentPoolOSDiskType(t *testing.T) {
+	vlabsProfile := &vlabs.AgentPoolProfile{
+		Name:         "pool1",
+		OSDiskSizeGB: 50,
+		OSDiskType:   vlabs.OSDiskTypeEphemeral,
+	}
+	apiProfile := &AgentPoolProfile{}
+	convertVLabsAgentPoolProfile(vlabsProfile, apiProfile)
+	if apiProfile.OSDiskType != OSDiskTypeEphemeral {
+		t.Errorf("expected OSDiskType %s, got %s", OSDiskTypeEphemeral, apiProfile.OSDiskType)
+	}
+	if !apiProfile.IsEphemeralOSDisk() {
+		t.Errorf("expected IsEphemeralOSDisk() to return true")
+	}
+}
+
+func TestConvertVLabsDiskEncryptionSetID(t *testing.T) {
+	desID := "/subscriptions/SUB_ID/resourceGroups/RG_NAME/providers/Microsoft.Compute/diskEncryptionSets/des"
+	apiMaster := &MasterProfile{}
+	convertVLabsMasterProfile(&vlabs.MasterProfile{DiskEncryptionSetID: desID}, apiMaster)
+	if apiMaster.DiskEncryptionSetID != desID {
+		t.Errorf("expected master DiskEncryptionSetID %s, got %s", desID, apiMaster.DiskEncryptionSetID)
+	}
+	apiProfile := &AgentPoolProfile{}
+	convertVLabsAgentPoolProfile(&vlabs.AgentPoolProfile{Name: "pool1", DiskEncryptionSetID: desID}, apiProfile)
+	if apiProfile.DiskEncryptionSetID != desID {
+		t.Errorf("expected agent pool DiskEncryptionSetID %s, got %s", desID, apiProfile.DiskEncryptionSetID)
+	}
+}
+
+func TestConvertVLabsPlacement(t *testing.T) {
+	ppgID := "/subscriptions/SUB_ID/resourceGroups/RG_NAME/providers/Microsoft.Compute/proximityPlacementGroups/ppg"
+	hostGroupID := "/subscriptions/SUB_ID/resourceGroups/RG_NAME/providers/Microsoft.Compute/hostGroups/hg"
+	apiMaster := &MasterProfile{}
+	convertVLabsMasterProfile(&vlabs.MasterProfile{EnableProximityPlacementGroup: to.BoolPtr(true), HostGroupID: hostGroupID}, apiMaster)
+	if !apiMaster.IsProximityPlacementGroupEnabled() {
+		t.Errorf("expected master IsProximityPlacementGroupEnabled() to return true")
+	}
+	if apiMaster.HostGroupID != hostGroupID {
+		t.Errorf("expected master HostGroupID %s, got %s", hostGroupID, apiMaster.HostGroupID)
+	}
+	apiProfile := &AgentPoolProfile{}
+	convertVLabsAgentPoolProfile(&vlabs.AgentPoolProfile{Name: "pool1", ProximityPlacementGroupID: ppgID, HostGroupID: hostGroupID}, apiProfile)
+	if apiProfile.ProximityPlacementGroupID != ppgID {
+		t.Errorf("expected agent pool ProximityPlacementGroupID %s, got %s", ppgID, apiProfile.ProximityPlacementGroupID)
+	}
+	if apiProfile.IsProximityPlacementGroupEnabled() {
+		t.Errorf("expected agent pool IsProximityPlacementGroupEnabled() to return false")
+	}
+	if apiProfile.HostGroupID != hostGroupID {
+		t.Errorf("expected agent pool HostGroupID %s, got %s", hostGroupID, apiProfile.HostGroupID)
+	}
+}
+
+func TestConvertVLabsAgentPoolOrchestratorVersion(t *testing.T) {
+	apiProfile := &AgentPoolProfile{}
+	convertVLabsAgentPoolProfile(&vlabs.AgentPoolProfile{Name: "pool1", OrchestratorVersion: "1.14.7"}, apiProfile)
+	if apiProfile.OrchestratorVersion != "1.14.7" {
+		t.Errorf("expected agent pool OrchestratorVersion 1.14.7, got %s", apiProfile.OrchestratorVersion)
+	}
+}
+
+func TestConvertVLabsExtensionProfileSource(t *testing.T) {
+	apiProfile := &ExtensionProfile{}
+	vlabsProfile := &vlabs.ExtensionProfile{
+		Name:         "hello",
+		OCIReference: "myregistry.azurecr.io/extensions/hello:v1",
+		Upload:       true,
+		Checksums:    map[string]string{"hello.sh": "sha256"},
+	}
+	convertVLabsExtensionProfile(vlabsProfile, apiProfile)
+	if apiProfile.OCIReference != vlabsProfile.OCIReference || !apiProfile.Upload || apiProfile.Checksums["hello.sh"] != "sha256" {
+		t.Errorf("unexpected extension profile %+v", apiProfile)
+	}
+	vlabsProfile.Checksums["hello.sh"] = "changed"
+	if apiProfile.Checksums["hello.sh"] != "sha256" {
+		t.Errorf("expected the checksums to be copied")
+	}
+}
+
 func makeKubernetesProperties() *Properties {
 	ap := &Properties{}
 	ap.OrchestratorProfile = &OrchestratorProfile{}
@@ -882,6 +1122,7 @@ func TestConvertVLabsContainerService(t *testing.T) {
 			FeatureFlags: &vlabs.FeatureFlags{
 				EnableCSERunInBackground: true,
 				BlockOutboundInternet:    false,
+				EnableIPv6DualStack:      true,
 			},
 			AADProfile: &vlabs.AADProfile{
 				ClientAppID:  "SampleClientAppID",
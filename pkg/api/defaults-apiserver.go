@@ -129,6 +129,11 @@ func (cs *ContainerService) setAPIServerConfig() {
 		}
 	}
 
+	// Enable IPv4/IPv6 dual-stack services
+	if cs.Properties.FeatureFlags.IsFeatureEnabled("EnableIPv6DualStack") {
+		addDefaultFeatureGates(o.KubernetesConfig.APIServerConfig, o.OrchestratorVersion, "1.16.0", "IPv6DualStack=true")
+	}
+
 	// We don't support user-configurable values for the following,
 	// so any of the value assignments below will override user-provided values
 	for key, val := range staticAPIServerConfig {
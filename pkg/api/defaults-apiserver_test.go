@@ -470,3 +470,27 @@ func TestAPIServerWeakCipherSuites(t *testing.T) {
 		}
 	}
 }
+
+func TestAPIServerConfigIPv6DualStack(t *testing.T) {
+	cs := CreateMockContainerService("testcluster", "1.16.0", 3, 2, false)
+	cs.Properties.FeatureFlags = &FeatureFlags{EnableIPv6DualStack: true}
+	cs.Properties.OrchestratorProfile.KubernetesConfig.ServiceCIDR = "10.0.0.0/16,fd00::/108"
+	cs.setAPIServerConfig()
+	a := cs.Properties.OrchestratorProfile.KubernetesConfig.APIServerConfig
+	if a["--feature-gates"] != "IPv6DualStack=true" {
+		t.Fatalf("got unexpected '--feature-gates' API server config value for EnableIPv6DualStack=true: %s",
+			a["--feature-gates"])
+	}
+	if a["--service-cluster-ip-range"] != "10.0.0.0/16,fd00::/108" {
+		t.Fatalf("got unexpected '--service-cluster-ip-range' API server config value for EnableIPv6DualStack=true: %s",
+			a["--service-cluster-ip-range"])
+	}
+
+	cs = CreateMockContainerService("testcluster", "1.16.0", 3, 2, false)
+	cs.setAPIServerConfig()
+	a = cs.Properties.OrchestratorProfile.KubernetesConfig.APIServerConfig
+	if _, ok := a["--feature-gates"]; ok {
+		t.Fatalf("got unexpected '--feature-gates' API server config value for EnableIPv6DualStack=false: %s",
+			a["--feature-gates"])
+	}
+}
@@ -105,6 +105,22 @@ func TestControllerManagerConfigDefaultFeatureGates(t *testing.T) {
 	}
 }
 
+func TestControllerManagerConfigIPv6DualStack(t *testing.T) {
+	cs := CreateMockContainerService("testcluster", "1.16.0", 3, 2, false)
+	cs.Properties.FeatureFlags = &FeatureFlags{EnableIPv6DualStack: true}
+	cs.Properties.OrchestratorProfile.KubernetesConfig.ClusterSubnet = "10.244.0.0/16,fc00::/48"
+	cs.setControllerManagerConfig()
+	cm := cs.Properties.OrchestratorProfile.KubernetesConfig.ControllerManagerConfig
+	if cm["--feature-gates"] != "IPv6DualStack=true,LocalStorageCapacityIsolation=true,ServiceNodeExclusion=true" {
+		t.Fatalf("got unexpected '--feature-gates' Controller Manager config value for EnableIPv6DualStack=true: %s",
+			cm["--feature-gates"])
+	}
+	if cm["--cluster-cidr"] != "10.244.0.0/16,fc00::/48" {
+		t.Fatalf("got unexpected '--cluster-cidr' Controller Manager config value for EnableIPv6DualStack=true: %s",
+			cm["--cluster-cidr"])
+	}
+}
+
 func TestControllerManagerConfigHostedMasterProfile(t *testing.T) {
 	cs := CreateMockContainerService("testcluster", defaultTestClusterVer, 3, 2, false)
 	cs.Properties.MasterProfile = nil
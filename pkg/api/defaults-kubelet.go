@@ -59,14 +59,16 @@ func (cs *ContainerService) setKubeletConfig() {
 	staticWindowsKubeletConfig["--resolv-conf"] = "\"\"\"\""
 	staticWindowsKubeletConfig["--eviction-hard"] = "\"\"\"\""
 
+	k8sComponents := o.GetK8sComponents()
+
 	// Default Kubelet config
 	defaultKubeletConfig := map[string]string{
 		"--cluster-domain":                    "cluster.local",
 		"--network-plugin":                    "cni",
-		"--pod-infra-container-image":         o.KubernetesConfig.KubernetesImageBase + K8sComponentsByVersionMap[o.OrchestratorVersion]["pause"],
+		"--pod-infra-container-image":         o.KubernetesConfig.KubernetesImageBase + k8sComponents["pause"],
 		"--max-pods":                          strconv.Itoa(DefaultKubernetesMaxPods),
 		"--eviction-hard":                     DefaultKubernetesHardEvictionThreshold,
-		"--node-status-update-frequency":      K8sComponentsByVersionMap[o.OrchestratorVersion]["nodestatusfreq"],
+		"--node-status-update-frequency":      k8sComponents["nodestatusfreq"],
 		"--image-gc-high-threshold":           strconv.Itoa(DefaultKubernetesGCHighThreshold),
 		"--image-gc-low-threshold":            strconv.Itoa(DefaultKubernetesGCLowThreshold),
 		"--non-masquerade-cidr":               DefaultNonMasqueradeCIDR,
@@ -83,7 +85,7 @@ func (cs *ContainerService) setKubeletConfig() {
 
 	// Set --non-masquerade-cidr if ip-masq-agent is disabled on AKS
 	if !cs.Properties.IsIPMasqAgentEnabled() {
-		defaultKubeletConfig["--non-masquerade-cidr"] = cs.Properties.OrchestratorProfile.KubernetesConfig.ClusterSubnet
+		defaultKubeletConfig["--non-masquerade-cidr"] = common.GetIPv4CIDR(cs.Properties.OrchestratorProfile.KubernetesConfig.ClusterSubnet)
 	}
 
 	// Apply Azure CNI-specific --max-pods value
@@ -93,7 +95,6 @@ func (cs *ContainerService) setKubeletConfig() {
 
 	// If no user-configurable kubelet config values exists, use the defaults
 	setMissingKubeletValues(o.KubernetesConfig, defaultKubeletConfig)
-	addDefaultFeatureGates(o.KubernetesConfig.KubeletConfig, o.OrchestratorVersion, "1.8.0", "PodPriority=true")
 
 	// Override default cloud-provider?
 	if to.Bool(o.KubernetesConfig.UseCloudControllerManager) {
@@ -120,7 +121,14 @@ func (cs *ContainerService) setKubeletConfig() {
 		}
 	}
 
-	removeKubeletFlags(o.KubernetesConfig.KubeletConfig, o.OrchestratorVersion)
+	// Agent pools may run another Kubernetes version than the control plane, so they start from
+	// the cluster kubelet config before any version specific defaults are applied
+	agentKubeletConfig := make(map[string]string, len(o.KubernetesConfig.KubeletConfig))
+	for key, val := range o.KubernetesConfig.KubeletConfig {
+		agentKubeletConfig[key] = val
+	}
+
+	cs.setKubeletVersionDefaults(o.KubernetesConfig.KubeletConfig, o.OrchestratorVersion)
 
 	// Master-specific kubelet config changes go here
 	if cs.Properties.MasterProfile != nil {
@@ -151,13 +159,14 @@ func (cs *ContainerService) setKubeletConfig() {
 			}
 		}
 
-		setMissingKubeletValues(profile.KubernetesConfig, o.KubernetesConfig.KubeletConfig)
+		setMissingKubeletValues(profile.KubernetesConfig, agentKubeletConfig)
+		agentPoolVersion := cs.Properties.GetAgentPoolOrchestratorVersion(profile)
 
 		// For N Series (GPU) VMs
 		if strings.Contains(profile.VMSize, "Standard_N") {
-			if !cs.Properties.IsNVIDIADevicePluginEnabled() && !common.IsKubernetesVersionGe(o.OrchestratorVersion, "1.11.0") {
+			if !cs.Properties.IsNVIDIADevicePluginEnabled() && !common.IsKubernetesVersionGe(agentPoolVersion, "1.11.0") {
 				// enabling accelerators for Kubernetes >= 1.6 to <= 1.9
-				addDefaultFeatureGates(profile.KubernetesConfig.KubeletConfig, o.OrchestratorVersion, "1.6.0", "Accelerators=true")
+				addDefaultFeatureGates(profile.KubernetesConfig.KubeletConfig, agentPoolVersion, "1.6.0", "Accelerators=true")
 			}
 		}
 
@@ -165,8 +174,20 @@ func (cs *ContainerService) setKubeletConfig() {
 			profile.KubernetesConfig.KubeletConfig["--resolv-conf"] = "/run/systemd/resolve/resolv.conf"
 		}
 
-		removeKubeletFlags(profile.KubernetesConfig.KubeletConfig, o.OrchestratorVersion)
+		cs.setKubeletVersionDefaults(profile.KubernetesConfig.KubeletConfig, agentPoolVersion)
+	}
+}
+
+// setKubeletVersionDefaults applies the kubelet defaults that depend on the Kubernetes version the kubelet runs
+func (cs *ContainerService) setKubeletVersionDefaults(k map[string]string, v string) {
+	addDefaultFeatureGates(k, v, "1.8.0", "PodPriority=true")
+
+	// Enable IPv4/IPv6 dual-stack pod networking
+	if cs.Properties.FeatureFlags.IsFeatureEnabled("EnableIPv6DualStack") {
+		addDefaultFeatureGates(k, v, "1.16.0", "IPv6DualStack=true")
 	}
+
+	removeKubeletFlags(k, v)
 }
 
 func removeKubeletFlags(k map[string]string, v string) {
@@ -5,6 +5,7 @@ package api
 
 import (
 	"strconv"
+	"strings"
 	"testing"
 
 	"github.com/Azure/go-autorest/autorest/to"
@@ -42,6 +43,28 @@ func TestKubeletConfigDefaults(t *testing.T) {
 	}
 }
 
+func TestKubeletConfigIPv6DualStack(t *testing.T) {
+	cs := CreateMockContainerService("testcluster", "1.16.0", 3, 2, false)
+	cs.Properties.FeatureFlags = &FeatureFlags{EnableIPv6DualStack: true}
+	cs.Properties.OrchestratorProfile.KubernetesConfig.ClusterSubnet = "10.244.0.0/16,fc00::/48"
+	cs.setKubeletConfig()
+	k := cs.Properties.OrchestratorProfile.KubernetesConfig.KubeletConfig
+	if !strings.Contains(k["--feature-gates"], "IPv6DualStack=true") {
+		t.Fatalf("got unexpected '--feature-gates' kubelet config value for EnableIPv6DualStack=true: %s",
+			k["--feature-gates"])
+	}
+	if !cs.Properties.IsIPMasqAgentEnabled() && k["--non-masquerade-cidr"] != "10.244.0.0/16" {
+		t.Fatalf("got unexpected '--non-masquerade-cidr' kubelet config value for EnableIPv6DualStack=true: %s",
+			k["--non-masquerade-cidr"])
+	}
+	for _, profile := range cs.Properties.AgentPoolProfiles {
+		if !strings.Contains(profile.KubernetesConfig.KubeletConfig["--feature-gates"], "IPv6DualStack=true") {
+			t.Fatalf("got unexpected agent pool '--feature-gates' kubelet config value for EnableIPv6DualStack=true: %s",
+				profile.KubernetesConfig.KubeletConfig["--feature-gates"])
+		}
+	}
+}
+
 func TestKubeletConfigUseCloudControllerManager(t *testing.T) {
 	// Test UseCloudControllerManager = true
 	cs := CreateMockContainerService("testcluster", defaultTestClusterVer, 3, 2, false)
@@ -504,3 +527,25 @@ func TestKubeletConfigDefaultFeatureGates(t *testing.T) {
 			k["--feature-gates"])
 	}
 }
+
+func TestKubeletConfigAgentPoolOrchestratorVersion(t *testing.T) {
+	cs := CreateMockContainerService("testcluster", "1.12.8", 3, 2, false)
+	cs.Properties.AgentPoolProfiles[0].OrchestratorVersion = "1.7.12"
+	cs.setKubeletConfig()
+
+	k := cs.Properties.OrchestratorProfile.KubernetesConfig.KubeletConfig
+	if _, ok := k["--cadvisor-port"]; ok {
+		t.Fatalf("got unexpected '--cadvisor-port' kubelet config value for a 1.12 control plane: %s", k["--cadvisor-port"])
+	}
+
+	k = cs.Properties.AgentPoolProfiles[0].KubernetesConfig.KubeletConfig
+	if k["--feature-gates"] != "" {
+		t.Fatalf("got unexpected '--feature-gates' kubelet config value for a 1.7 agent pool: %s", k["--feature-gates"])
+	}
+	if _, ok := k["--pod-max-pids"]; ok {
+		t.Fatalf("got unexpected '--pod-max-pids' kubelet config value for a 1.7 agent pool: %s", k["--pod-max-pids"])
+	}
+	if k["--cadvisor-port"] != DefaultKubeletCadvisorPort {
+		t.Fatalf("got unexpected '--cadvisor-port' kubelet config value for a 1.7 agent pool: %s", k["--cadvisor-port"])
+	}
+}
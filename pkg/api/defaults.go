@@ -121,6 +121,11 @@ func (cs *ContainerService) setOrchestratorDefaults(isUpdate bool) {
 			if o.KubernetesConfig.NetworkPlugin == "" {
 				o.KubernetesConfig.NetworkPlugin = DefaultNetworkPluginWindows
 			}
+		} else if a.FeatureFlags.IsFeatureEnabled("EnableIPv6DualStack") {
+			// Azure CNI does not assign IPv6 addresses to pods
+			if o.KubernetesConfig.NetworkPlugin == "" {
+				o.KubernetesConfig.NetworkPlugin = NetworkPluginKubenet
+			}
 		} else {
 			if o.KubernetesConfig.NetworkPlugin == "" {
 				o.KubernetesConfig.NetworkPlugin = DefaultNetworkPlugin
@@ -144,6 +149,8 @@ func (cs *ContainerService) setOrchestratorDefaults(isUpdate bool) {
 				// When Azure CNI is enabled, all masters, agents and pods share the same large subnet.
 				// Except when master is VMSS, then masters and agents have separate subnets within the same large subnet.
 				o.KubernetesConfig.ClusterSubnet = DefaultKubernetesSubnet
+			} else if a.FeatureFlags.IsFeatureEnabled("EnableIPv6DualStack") {
+				o.KubernetesConfig.ClusterSubnet = strings.Join([]string{DefaultKubernetesClusterSubnet, DefaultKubernetesClusterSubnetIPv6}, ",")
 			} else {
 				o.KubernetesConfig.ClusterSubnet = DefaultKubernetesClusterSubnet
 			}
@@ -161,7 +168,11 @@ func (cs *ContainerService) setOrchestratorDefaults(isUpdate bool) {
 			o.KubernetesConfig.DockerBridgeSubnet = DefaultDockerBridgeSubnet
 		}
 		if o.KubernetesConfig.ServiceCIDR == "" {
-			o.KubernetesConfig.ServiceCIDR = DefaultKubernetesServiceCIDR
+			if a.FeatureFlags.IsFeatureEnabled("EnableIPv6DualStack") {
+				o.KubernetesConfig.ServiceCIDR = strings.Join([]string{DefaultKubernetesServiceCIDR, DefaultKubernetesServiceCIDRIPv6}, ",")
+			} else {
+				o.KubernetesConfig.ServiceCIDR = DefaultKubernetesServiceCIDR
+			}
 		}
 
 		if o.KubernetesConfig.CloudProviderBackoff == nil {
@@ -184,6 +195,16 @@ func (cs *ContainerService) setOrchestratorDefaults(isUpdate bool) {
 			o.KubernetesConfig.PrivateCluster.Enabled = to.BoolPtr(DefaultPrivateClusterEnabled)
 		}
 
+		if zone := o.KubernetesConfig.PrivateCluster.PrivateDNSZone; o.KubernetesConfig.IsPrivateDNSZoneEnabled() && zone.Name == "" {
+			if zone.IsExistingZone() {
+				zone.Name = zone.ID[strings.LastIndex(zone.ID, "/")+1:]
+			} else if cs.Location != "" {
+				zone.Name = fmt.Sprintf("%s.%s.%s", DefaultPrivateDNSZonePrefix, strings.ToLower(cs.Location), cloudSpecConfig.EndpointConfig.ResourceManagerVMDNSSuffix)
+			} else {
+				zone.Name = fmt.Sprintf("%s.%s", DefaultPrivateDNSZonePrefix, cloudSpecConfig.EndpointConfig.ResourceManagerVMDNSSuffix)
+			}
+		}
+
 		if "" == a.OrchestratorProfile.KubernetesConfig.EtcdDiskSizeGB {
 			switch {
 			case a.TotalNodes() > 20:
@@ -246,6 +267,10 @@ func (cs *ContainerService) setOrchestratorDefaults(isUpdate bool) {
 			a.OrchestratorProfile.KubernetesConfig.ExcludeMasterFromStandardLB = to.BoolPtr(DefaultExcludeMasterFromStandardLB)
 		}
 
+		if a.OrchestratorProfile.KubernetesConfig.EgressProfile != nil {
+			a.setEgressProfileDefaults()
+		}
+
 		if a.OrchestratorProfile.IsAzureCNI() {
 			if a.HasWindows() {
 				a.OrchestratorProfile.KubernetesConfig.AzureCNIVersion = AzureCniPluginVerWindows
@@ -258,7 +283,12 @@ func (cs *ContainerService) setOrchestratorDefaults(isUpdate bool) {
 			a.OrchestratorProfile.KubernetesConfig.MaximumLoadBalancerRuleCount = DefaultMaximumLoadBalancerRuleCount
 		}
 		if a.OrchestratorProfile.KubernetesConfig.ProxyMode == "" {
-			a.OrchestratorProfile.KubernetesConfig.ProxyMode = DefaultKubeProxyMode
+			if a.FeatureFlags.IsFeatureEnabled("EnableIPv6DualStack") {
+				// kube-proxy only programs IPv4/IPv6 dual-stack services in ipvs mode
+				a.OrchestratorProfile.KubernetesConfig.ProxyMode = KubeProxyModeIPVS
+			} else {
+				a.OrchestratorProfile.KubernetesConfig.ProxyMode = DefaultKubeProxyMode
+			}
 		}
 
 		// Configure addons
@@ -296,7 +326,7 @@ func (p *Properties) setExtensionDefaults() {
 		return
 	}
 	for _, extension := range p.ExtensionProfiles {
-		if extension.RootURL == "" {
+		if extension.RootURL == "" && !extension.IsLocal() && !extension.IsOCI() {
 			extension.RootURL = DefaultExtensionsRootURL
 		}
 	}
@@ -561,6 +591,25 @@ func (p *Properties) setHostedMasterProfileDefaults() {
 	p.HostedMasterProfile.Subnet = DefaultKubernetesMasterSubnet
 }
 
+func (p *Properties) setEgressProfileDefaults() {
+	e := p.OrchestratorProfile.KubernetesConfig.EgressProfile
+	if e.Type == "" {
+		e.Type = EgressTypeLoadBalancer
+	}
+	if e.Type != EgressTypeLoadBalancer {
+		return
+	}
+	if e.OutboundIPCount == 0 && e.OutboundIPPrefixID == "" {
+		e.OutboundIPCount = DefaultEgressOutboundIPCount
+	}
+	if e.IdleTimeoutInMinutes == 0 {
+		e.IdleTimeoutInMinutes = DefaultEgressIdleTimeoutInMinutes
+	}
+	if e.EnableTCPReset == nil {
+		e.EnableTCPReset = to.BoolPtr(DefaultEgressEnableTCPReset)
+	}
+}
+
 // SetDefaultCerts generates and sets defaults for the container certificateProfile, returns true if certs are generated
 func (cs *ContainerService) SetDefaultCerts() (bool, []net.IP, error) {
 	p := cs.Properties
@@ -580,6 +629,9 @@ func (cs *ContainerService) SetDefaultCerts() (bool, []net.IP, error) {
 	}
 
 	masterExtraFQDNs := append(azureProdFQDNs, p.MasterProfile.SubjectAltNames...)
+	if privateFQDN := p.GetPrivateAPIServerFQDN(); privateFQDN != "" {
+		masterExtraFQDNs = append(masterExtraFQDNs, privateFQDN)
+	}
 	masterExtraFQDNs = append(masterExtraFQDNs, "localhost")
 	firstMasterIP := net.ParseIP(p.MasterProfile.FirstConsecutiveStaticIP).To4()
 	localhostIP := net.ParseIP("127.0.0.1").To4()
@@ -629,7 +681,7 @@ func (cs *ContainerService) SetDefaultCerts() (bool, []net.IP, error) {
 		p.CertificateProfile.CaPrivateKey = caPair.PrivateKeyPem
 	}
 
-	cidrFirstIP, err := common.CidrStringFirstIP(p.OrchestratorProfile.KubernetesConfig.ServiceCIDR)
+	cidrFirstIP, err := common.CidrStringFirstIP(common.GetIPv4CIDR(p.OrchestratorProfile.KubernetesConfig.ServiceCIDR))
 	if err != nil {
 		return false, ips, err
 	}
@@ -4,8 +4,10 @@
 package api
 
 import (
+	"crypto/x509"
 	"encoding/base64"
 	"encoding/binary"
+	"encoding/pem"
 	"fmt"
 	"net"
 	"net/http"
@@ -247,6 +249,32 @@ func TestAssignDefaultAddonImages(t *testing.T) {
 	}
 }
 
+func TestComponentOverrideDefaults(t *testing.T) {
+	mockCS := getMockBaseContainerService("1.10.8")
+	mockCS.Properties.OrchestratorProfile.OrchestratorType = Kubernetes
+	mockCS.Properties.OrchestratorProfile.KubernetesConfig.ComponentOverrides = map[string]string{
+		DefaultMetricsServerAddonName: "metrics-server-amd64:v0.3.1",
+		"pause":                       "pause-amd64:3.1-custom",
+	}
+	mockCS.SetPropertiesDefaults(false, false)
+	k := mockCS.Properties.OrchestratorProfile.KubernetesConfig
+
+	metricsServer := k.GetAddonByName(DefaultMetricsServerAddonName)
+	if metricsServer.Containers[0].Image != "k8s.gcr.io/metrics-server-amd64:v0.3.1" {
+		t.Errorf("expected componentOverrides to set the metrics-server image, got %s", metricsServer.Containers[0].Image)
+	}
+	if k.KubeletConfig["--pod-infra-container-image"] != "k8s.gcr.io/pause-amd64:3.1-custom" {
+		t.Errorf("expected componentOverrides to set the pod infra container image, got %s", k.KubeletConfig["--pod-infra-container-image"])
+	}
+
+	// overrides are applied again when addon images are reset during an upgrade
+	mockCS.SetPropertiesDefaults(true, false)
+	metricsServer = k.GetAddonByName(DefaultMetricsServerAddonName)
+	if metricsServer.Containers[0].Image != "k8s.gcr.io/metrics-server-amd64:v0.3.1" {
+		t.Errorf("expected componentOverrides to survive an upgrade, got %s", metricsServer.Containers[0].Image)
+	}
+}
+
 func TestAssignDefaultAddonVals(t *testing.T) {
 	addonName := "testaddon"
 	customImage := "myimage"
@@ -1560,6 +1588,76 @@ func TestProxyModeDefaults(t *testing.T) {
 		t.Fatalf("ProxyMode string not the expected default value, got %s, expected %s", properties.OrchestratorProfile.KubernetesConfig.ProxyMode, KubeProxyModeIPVS)
 	}
 }
+func TestIPv6DualStackDefaults(t *testing.T) {
+	mockCS := getMockBaseContainerService("1.16.0")
+	properties := mockCS.Properties
+	properties.OrchestratorProfile.OrchestratorType = Kubernetes
+	properties.FeatureFlags = &FeatureFlags{EnableIPv6DualStack: true}
+	properties.MasterProfile.Count = 1
+	mockCS.setOrchestratorDefaults(false)
+
+	k := properties.OrchestratorProfile.KubernetesConfig
+	if k.ClusterSubnet != DefaultKubernetesClusterSubnet+","+DefaultKubernetesClusterSubnetIPv6 {
+		t.Fatalf("ClusterSubnet string not the expected dual-stack default value, got %s", k.ClusterSubnet)
+	}
+	if k.ServiceCIDR != DefaultKubernetesServiceCIDR+","+DefaultKubernetesServiceCIDRIPv6 {
+		t.Fatalf("ServiceCIDR string not the expected dual-stack default value, got %s", k.ServiceCIDR)
+	}
+	if k.ProxyMode != KubeProxyModeIPVS {
+		t.Fatalf("ProxyMode string not the expected dual-stack default value, got %s, expected %s", k.ProxyMode, KubeProxyModeIPVS)
+	}
+	if k.NetworkPlugin != NetworkPluginKubenet {
+		t.Fatalf("NetworkPlugin string not the expected dual-stack default value, got %s, expected %s", k.NetworkPlugin, NetworkPluginKubenet)
+	}
+}
+
+func TestEgressProfileDefaults(t *testing.T) {
+	mockCS := getMockBaseContainerService("1.13.7")
+	properties := mockCS.Properties
+	properties.OrchestratorProfile.OrchestratorType = Kubernetes
+	properties.OrchestratorProfile.KubernetesConfig.LoadBalancerSku = "Standard"
+	properties.OrchestratorProfile.KubernetesConfig.EgressProfile = &EgressProfile{}
+	mockCS.setOrchestratorDefaults(false)
+
+	e := properties.OrchestratorProfile.KubernetesConfig.EgressProfile
+	if e.Type != EgressTypeLoadBalancer {
+		t.Fatalf("EgressProfile.Type not the expected default value, got %s, expected %s", e.Type, EgressTypeLoadBalancer)
+	}
+	if e.OutboundIPCount != DefaultEgressOutboundIPCount {
+		t.Fatalf("EgressProfile.OutboundIPCount not the expected default value, got %d, expected %d", e.OutboundIPCount, DefaultEgressOutboundIPCount)
+	}
+	if e.IdleTimeoutInMinutes != DefaultEgressIdleTimeoutInMinutes {
+		t.Fatalf("EgressProfile.IdleTimeoutInMinutes not the expected default value, got %d, expected %d", e.IdleTimeoutInMinutes, DefaultEgressIdleTimeoutInMinutes)
+	}
+	if to.Bool(e.EnableTCPReset) != DefaultEgressEnableTCPReset {
+		t.Fatalf("EgressProfile.EnableTCPReset not the expected default value, got %v, expected %v", to.Bool(e.EnableTCPReset), DefaultEgressEnableTCPReset)
+	}
+
+	mockCS = getMockBaseContainerService("1.13.7")
+	properties = mockCS.Properties
+	properties.OrchestratorProfile.OrchestratorType = Kubernetes
+	properties.OrchestratorProfile.KubernetesConfig.LoadBalancerSku = "Standard"
+	properties.OrchestratorProfile.KubernetesConfig.EgressProfile = &EgressProfile{
+		OutboundIPPrefixID: "/subscriptions/SUB_ID/resourceGroups/RG_NAME/providers/Microsoft.Network/publicIPPrefixes/PREFIX_NAME",
+	}
+	mockCS.setOrchestratorDefaults(false)
+	if e = properties.OrchestratorProfile.KubernetesConfig.EgressProfile; e.OutboundIPCount != 0 {
+		t.Fatalf("EgressProfile.OutboundIPCount should not be defaulted when outboundIPPrefixID is set, got %d", e.OutboundIPCount)
+	}
+
+	mockCS = getMockBaseContainerService("1.13.7")
+	properties = mockCS.Properties
+	properties.OrchestratorProfile.OrchestratorType = Kubernetes
+	properties.OrchestratorProfile.KubernetesConfig.EgressProfile = &EgressProfile{
+		Type:              EgressTypeUserDefinedRouting,
+		FirewallIPAddress: "10.241.0.4",
+	}
+	mockCS.setOrchestratorDefaults(false)
+	if e = properties.OrchestratorProfile.KubernetesConfig.EgressProfile; e.OutboundIPCount != 0 || e.IdleTimeoutInMinutes != 0 || e.EnableTCPReset != nil {
+		t.Fatalf("EgressProfile load balancer settings should not be defaulted for userDefinedRouting, got %+v", e)
+	}
+}
+
 func TestSetCustomCloudProfileDefaults(t *testing.T) {
 
 	// Test that the ResourceManagerVMDNSSuffix is set in EndpointConfig
@@ -1959,3 +2057,135 @@ func getKubernetesConfigWithFeatureGates(featureGates string) *KubernetesConfig
 		KubeletConfig: map[string]string{"--feature-gates": featureGates},
 	}
 }
+
+func TestPrivateDNSZoneDefaults(t *testing.T) {
+	cases := []struct {
+		name         string
+		location     string
+		zone         *PrivateDNSZone
+		expectedName string
+	}{
+		{
+			name:         "zone created in the cluster location",
+			location:     "WestUS2",
+			zone:         &PrivateDNSZone{Enabled: to.BoolPtr(true)},
+			expectedName: "privatelink.westus2.cloudapp.azure.com",
+		},
+		{
+			name:         "zone created without location",
+			zone:         &PrivateDNSZone{Enabled: to.BoolPtr(true)},
+			expectedName: "privatelink.cloudapp.azure.com",
+		},
+		{
+			name:         "zone name set",
+			location:     "westus2",
+			zone:         &PrivateDNSZone{Enabled: to.BoolPtr(true), Name: "k8s.contoso.com"},
+			expectedName: "k8s.contoso.com",
+		},
+		{
+			name:         "existing zone",
+			location:     "westus2",
+			zone:         &PrivateDNSZone{Enabled: to.BoolPtr(true), ID: "/subscriptions/SUB_ID/resourceGroups/RG_NAME/providers/Microsoft.Network/privateDnsZones/k8s.contoso.com"},
+			expectedName: "k8s.contoso.com",
+		},
+		{
+			name:         "disabled zone",
+			location:     "westus2",
+			zone:         &PrivateDNSZone{Enabled: to.BoolPtr(false)},
+			expectedName: "",
+		},
+	}
+
+	for _, c := range cases {
+		c := c
+		t.Run(c.name, func(t *testing.T) {
+			t.Parallel()
+			mockCS := getMockBaseContainerService("1.13.7")
+			mockCS.Location = c.location
+			mockCS.Properties.OrchestratorProfile.OrchestratorType = Kubernetes
+			mockCS.Properties.OrchestratorProfile.KubernetesConfig.PrivateCluster = &PrivateCluster{
+				Enabled:        to.BoolPtr(true),
+				PrivateDNSZone: c.zone,
+			}
+			mockCS.setOrchestratorDefaults(false)
+			if c.zone.Name != c.expectedName {
+				t.Errorf("expected private DNS zone name %q, but got %q", c.expectedName, c.zone.Name)
+			}
+		})
+	}
+}
+
+func TestSetCertDefaultsPrivateDNSZone(t *testing.T) {
+	cs := &ContainerService{
+		Location: "westus2",
+		Properties: &Properties{
+			ServicePrincipalProfile: &ServicePrincipalProfile{
+				ClientID: "barClientID",
+				Secret:   "bazSecret",
+			},
+			MasterProfile: &MasterProfile{
+				Count:     1,
+				DNSPrefix: "MyPrefix1",
+				VMSize:    "Standard_DS2_v2",
+			},
+			OrchestratorProfile: &OrchestratorProfile{
+				OrchestratorType:    Kubernetes,
+				OrchestratorVersion: "1.13.7",
+				KubernetesConfig: &KubernetesConfig{
+					NetworkPlugin: NetworkPluginAzure,
+					PrivateCluster: &PrivateCluster{
+						Enabled: to.BoolPtr(true),
+						PrivateDNSZone: &PrivateDNSZone{
+							Enabled: to.BoolPtr(true),
+						},
+					},
+				},
+			},
+		},
+	}
+
+	cs.setOrchestratorDefaults(false)
+	cs.Properties.setMasterProfileDefaults(false)
+	if _, _, err := cs.SetDefaultCerts(); err != nil {
+		t.Fatalf("unexpected error thrown while executing SetDefaultCerts %s", err.Error())
+	}
+
+	expectedFQDN := "myprefix1.privatelink.westus2.cloudapp.azure.com"
+	if fqdn := cs.Properties.GetPrivateAPIServerFQDN(); fqdn != expectedFQDN {
+		t.Fatalf("expected private API server FQDN %s, but got %s", expectedFQDN, fqdn)
+	}
+	block, _ := pem.Decode([]byte(cs.Properties.CertificateProfile.APIServerCertificate))
+	if block == nil {
+		t.Fatalf("expected a PEM encoded API server certificate")
+	}
+	cert, err := x509.ParseCertificate(block.Bytes)
+	if err != nil {
+		t.Fatalf("unexpected error parsing the API server certificate: %s", err)
+	}
+	found := false
+	for _, name := range cert.DNSNames {
+		if name == expectedFQDN {
+			found = true
+		}
+	}
+	if !found {
+		t.Errorf("expected the API server certificate SANs %v to include %s", cert.DNSNames, expectedFQDN)
+	}
+}
+
+func TestSetExtensionDefaults(t *testing.T) {
+	p := &Properties{
+		ExtensionProfiles: []*ExtensionProfile{
+			{Name: "github"},
+			{Name: "local", LocalPath: "/extensions"},
+			{Name: "oci", OCIReference: "myregistry.azurecr.io/extensions/oci:v1"},
+		},
+	}
+	p.setExtensionDefaults()
+	expected := []string{DefaultExtensionsRootURL, "", ""}
+	for i, extension := range p.ExtensionProfiles {
+		if extension.RootURL != expected[i] {
+			t.Errorf("expected extension %s to have rootURL %q, but got %q", extension.Name, expected[i], extension.RootURL)
+		}
+	}
+}
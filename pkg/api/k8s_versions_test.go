@@ -5,6 +5,8 @@ package api
 
 import (
 	"testing"
+
+	"github.com/Azure/aks-engine/pkg/api/vlabs"
 )
 
 func TestGetK8sVersionComponents(t *testing.T) {
@@ -408,3 +410,64 @@ func TestGetK8sVersionComponents(t *testing.T) {
 		t.Fatalf("getK8sVersionComponents() should return nil for unknown k8s version")
 	}
 }
+
+func TestComponentOverrideNames(t *testing.T) {
+	known := map[string]bool{}
+	for _, name := range vlabs.ComponentOverrideNames {
+		known[name] = true
+	}
+	for version, components := range K8sComponentsByVersionMap {
+		for name := range components {
+			if !known[name] {
+				t.Fatalf("component %s of Kubernetes %s is missing from vlabs.ComponentOverrideNames", name, version)
+			}
+		}
+	}
+}
+
+func TestGetK8sComponents(t *testing.T) {
+	o := &OrchestratorProfile{
+		OrchestratorType:    Kubernetes,
+		OrchestratorVersion: "1.13.5",
+	}
+	components := o.GetK8sComponents()
+	if components["coredns"] != K8sComponentsByVersionMap["1.13.5"]["coredns"] {
+		t.Fatalf("expected GetK8sComponents() to return the default coredns image, got %s", components["coredns"])
+	}
+
+	o.KubernetesConfig = &KubernetesConfig{
+		ComponentOverrides: map[string]string{
+			"coredns": "coredns:1.4.0",
+		},
+	}
+	components = o.GetK8sComponents()
+	if components["coredns"] != "coredns:1.4.0" {
+		t.Fatalf("expected GetK8sComponents() to return the overridden coredns image, got %s", components["coredns"])
+	}
+	if components["hyperkube"] != K8sComponentsByVersionMap["1.13.5"]["hyperkube"] {
+		t.Fatalf("expected GetK8sComponents() to keep components that are not overridden, got %s", components["hyperkube"])
+	}
+	if K8sComponentsByVersionMap["1.13.5"]["coredns"] == "coredns:1.4.0" {
+		t.Fatalf("expected GetK8sComponents() not to modify K8sComponentsByVersionMap")
+	}
+}
+
+func TestGetEtcdVersion(t *testing.T) {
+	o := &OrchestratorProfile{
+		OrchestratorType:    Kubernetes,
+		OrchestratorVersion: "1.13.5",
+		KubernetesConfig: &KubernetesConfig{
+			EtcdVersion: "3.2.25",
+		},
+	}
+	if v := o.GetEtcdVersion(); v != "3.2.25" {
+		t.Fatalf("expected GetEtcdVersion() to return etcdVersion, got %s", v)
+	}
+	o.KubernetesConfig.ComponentOverrides = map[string]string{"etcd": "3.3.10"}
+	if v := o.GetEtcdVersion(); v != "3.3.10" {
+		t.Fatalf("expected GetEtcdVersion() to return the etcd override, got %s", v)
+	}
+	if v := o.GetAPIServerEtcdAPIVersion(); v != "etcd3" {
+		t.Fatalf("expected GetAPIServerEtcdAPIVersion() to return etcd3, got %s", v)
+	}
+}
@@ -0,0 +1,214 @@
+// Copyright (c) Microsoft Corporation. All rights reserved.
+// Licensed under the MIT license.
+
+package api
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+// MigrationChangeType describes what happened to a field when an API model was migrated
+type MigrationChangeType string
+
+const (
+	// FieldDropped means the field has no equivalent in the target API version and was lost
+	FieldDropped MigrationChangeType = "dropped"
+	// FieldDefaulted means the field was not in the source API model and was filled in by the conversion
+	FieldDefaulted MigrationChangeType = "defaulted"
+	// FieldRenamed means the field value was kept but moved to a different path
+	FieldRenamed MigrationChangeType = "renamed"
+	// FieldChanged means the field kept its path but the conversion gave it a different value
+	FieldChanged MigrationChangeType = "changed"
+)
+
+// MigrationChange describes a single field that did not survive a migration unchanged
+type MigrationChange struct {
+	Type    MigrationChangeType `json:"type"`
+	Path    string              `json:"path"`
+	NewPath string              `json:"newPath,omitempty"`
+	Value   interface{}         `json:"value,omitempty"`
+	// NewValue is the value of a changed field in the target API version
+	NewValue interface{} `json:"newValue,omitempty"`
+}
+
+// MigrationResult is the outcome of converting an API model to a different API version
+type MigrationResult struct {
+	FromVersion string            `json:"fromVersion"`
+	ToVersion   string            `json:"toVersion"`
+	Changes     []MigrationChange `json:"changes"`
+	// Contents is the serialized API model in ToVersion
+	Contents []byte `json:"-"`
+}
+
+// IsLossy returns true if any field was dropped by the migration
+func (r *MigrationResult) IsLossy() bool {
+	for _, c := range r.Changes {
+		if c.Type == FieldDropped {
+			return true
+		}
+	}
+	return false
+}
+
+// MigrateContainerService loads an API model of any supported version and reserializes it as toVersion,
+// reporting every field that was dropped, defaulted, renamed or changed along the way
+func (a *Apiloader) MigrateContainerService(contents []byte, toVersion string) (*MigrationResult, error) {
+	cs, fromVersion, err := a.DeserializeContainerService(contents, false, false, nil)
+	if err != nil {
+		return nil, errors.Wrap(err, "error loading the api model")
+	}
+	out, err := a.SerializeContainerService(cs, toVersion)
+	if err != nil {
+		return nil, errors.Wrapf(err, "error serializing the api model as %s", toVersion)
+	}
+
+	changes, err := diffAPIModels(contents, out)
+	if err != nil {
+		return nil, err
+	}
+	return &MigrationResult{
+		FromVersion: fromVersion,
+		ToVersion:   toVersion,
+		Changes:     changes,
+		Contents:    out,
+	}, nil
+}
+
+// diffAPIModels compares the leaf values of two serialized API models
+func diffAPIModels(from, to []byte) ([]MigrationChange, error) {
+	var fromObj, toObj interface{}
+	if err := json.Unmarshal(from, &fromObj); err != nil {
+		return nil, errors.Wrap(err, "error parsing the source api model")
+	}
+	if err := json.Unmarshal(to, &toObj); err != nil {
+		return nil, errors.Wrap(err, "error parsing the migrated api model")
+	}
+	fromLeaves := map[string]interface{}{}
+	toLeaves := map[string]interface{}{}
+	flattenJSON("", fromObj, fromLeaves)
+	flattenJSON("", toObj, toLeaves)
+	// the apiVersion always changes and is reported separately
+	delete(fromLeaves, "apiVersion")
+	delete(toLeaves, "apiVersion")
+
+	var removed, added []string
+	for path, v := range fromLeaves {
+		if isZeroJSONValue(v) {
+			continue
+		}
+		if tv, ok := toLeaves[path]; !ok || !reflect.DeepEqual(v, tv) {
+			removed = append(removed, path)
+		}
+	}
+	for path, v := range toLeaves {
+		if isZeroJSONValue(v) {
+			continue
+		}
+		if fv, ok := fromLeaves[path]; !ok || !reflect.DeepEqual(v, fv) {
+			added = append(added, path)
+		}
+	}
+	sort.Strings(removed)
+	sort.Strings(added)
+
+	changes := []MigrationChange{}
+	matched := map[string]bool{}
+	for _, path := range removed {
+		if tv, ok := toLeaves[path]; ok && !isZeroJSONValue(tv) {
+			matched[path] = true
+			changes = append(changes, MigrationChange{Type: FieldChanged, Path: path, Value: fromLeaves[path], NewValue: tv})
+			continue
+		}
+		newPath := findRenamedPath(path, fromLeaves[path], added, toLeaves, matched)
+		if newPath != "" {
+			matched[newPath] = true
+			changes = append(changes, MigrationChange{Type: FieldRenamed, Path: path, NewPath: newPath, Value: fromLeaves[path]})
+			continue
+		}
+		changes = append(changes, MigrationChange{Type: FieldDropped, Path: path, Value: fromLeaves[path]})
+	}
+	for _, path := range added {
+		if matched[path] {
+			continue
+		}
+		changes = append(changes, MigrationChange{Type: FieldDefaulted, Path: path, Value: toLeaves[path]})
+	}
+	return changes, nil
+}
+
+// findRenamedPath looks for a new path holding the same value as a removed one. JSON keys are matched
+// case-insensitively on load, so a path that only differs in case is preferred over any other match.
+func findRenamedPath(path string, value interface{}, added []string, toLeaves map[string]interface{}, matched map[string]bool) string {
+	var candidate string
+	for _, p := range added {
+		if matched[p] || !reflect.DeepEqual(value, toLeaves[p]) {
+			continue
+		}
+		if strings.EqualFold(p, path) {
+			return p
+		}
+		if candidate == "" && strings.EqualFold(lastPathElement(p), lastPathElement(path)) {
+			candidate = p
+		}
+	}
+	return candidate
+}
+
+func lastPathElement(path string) string {
+	if i := strings.LastIndex(path, "."); i >= 0 {
+		return path[i+1:]
+	}
+	return path
+}
+
+func flattenJSON(prefix string, v interface{}, leaves map[string]interface{}) {
+	switch t := v.(type) {
+	case map[string]interface{}:
+		if len(t) == 0 {
+			leaves[prefix] = t
+			return
+		}
+		for k, child := range t {
+			p := k
+			if prefix != "" {
+				p = prefix + "." + k
+			}
+			flattenJSON(p, child, leaves)
+		}
+	case []interface{}:
+		if len(t) == 0 {
+			leaves[prefix] = t
+			return
+		}
+		for i, child := range t {
+			flattenJSON(fmt.Sprintf("%s[%d]", prefix, i), child, leaves)
+		}
+	default:
+		leaves[prefix] = t
+	}
+}
+
+func isZeroJSONValue(v interface{}) bool {
+	switch t := v.(type) {
+	case nil:
+		return true
+	case string:
+		return t == ""
+	case bool:
+		return !t
+	case float64:
+		return t == 0
+	case map[string]interface{}:
+		return len(t) == 0
+	case []interface{}:
+		return len(t) == 0
+	default:
+		return false
+	}
+}
@@ -0,0 +1,105 @@
+// Copyright (c) Microsoft Corporation. All rights reserved.
+// Licensed under the MIT license.
+
+package api
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"testing"
+
+	"github.com/Azure/aks-engine/pkg/api/vlabs"
+	"github.com/Azure/aks-engine/pkg/i18n"
+)
+
+func TestMigrateContainerService(t *testing.T) {
+	contents, err := ioutil.ReadFile("../engine/testdata/v20170701/kubernetes.json")
+	if err != nil {
+		t.Fatalf("unexpected error reading test data: %s", err)
+	}
+
+	apiloader := &Apiloader{
+		Translator: &i18n.Translator{},
+	}
+	result, err := apiloader.MigrateContainerService(contents, vlabs.APIVersion)
+	if err != nil {
+		t.Fatalf("unexpected error migrating api model: %s", err)
+	}
+	if result.FromVersion != "2017-07-01" || result.ToVersion != vlabs.APIVersion {
+		t.Fatalf("expected a migration from 2017-07-01 to vlabs, got %s to %s", result.FromVersion, result.ToVersion)
+	}
+	if result.IsLossy() {
+		t.Fatalf("expected a lossless migration, got %v", result.Changes)
+	}
+
+	var m map[string]interface{}
+	if err := json.Unmarshal(result.Contents, &m); err != nil {
+		t.Fatalf("unexpected error parsing migrated api model: %s", err)
+	}
+	if m["apiVersion"] != vlabs.APIVersion {
+		t.Fatalf("expected migrated api model to have apiVersion vlabs, got %v", m["apiVersion"])
+	}
+
+	if !hasChange(result.Changes, FieldRenamed, "properties.masterProfile.OSDiskSizeGB", "properties.masterProfile.osDiskSizeGB") {
+		t.Fatalf("expected OSDiskSizeGB to be reported as renamed, got %v", result.Changes)
+	}
+	if !hasChange(result.Changes, FieldDefaulted, "properties.orchestratorProfile.orchestratorRelease", "") {
+		t.Fatalf("expected orchestratorRelease to be reported as defaulted, got %v", result.Changes)
+	}
+
+	if _, err := apiloader.MigrateContainerService([]byte(`{"apiVersion": "vlabs"`), vlabs.APIVersion); err == nil {
+		t.Fatalf("expected an error migrating invalid JSON")
+	}
+	if _, err := apiloader.MigrateContainerService(contents, "2099-01-01"); err == nil {
+		t.Fatalf("expected an error migrating to an unknown api version")
+	}
+}
+
+func TestDiffAPIModels(t *testing.T) {
+	from := []byte(`{"apiVersion": "a", "properties": {"kept": "x", "gone": "y", "Moved": 3, "empty": "", "changed": "old"}}`)
+	to := []byte(`{"apiVersion": "b", "properties": {"kept": "x", "moved": 3, "added": true, "zero": false, "changed": "new"}}`)
+
+	changes, err := diffAPIModels(from, to)
+	if err != nil {
+		t.Fatalf("unexpected error diffing api models: %s", err)
+	}
+	if len(changes) != 4 {
+		t.Fatalf("expected 4 changes, got %d: %v", len(changes), changes)
+	}
+	if !hasChange(changes, FieldDropped, "properties.gone", "") {
+		t.Fatalf("expected properties.gone to be dropped, got %v", changes)
+	}
+	if !hasChange(changes, FieldRenamed, "properties.Moved", "properties.moved") {
+		t.Fatalf("expected properties.Moved to be renamed, got %v", changes)
+	}
+	if !hasChange(changes, FieldDefaulted, "properties.added", "") {
+		t.Fatalf("expected properties.added to be defaulted, got %v", changes)
+	}
+
+	if !hasChange(changes, FieldChanged, "properties.changed", "") {
+		t.Fatalf("expected properties.changed to be changed, got %v", changes)
+	}
+
+	r := &MigrationResult{Changes: changes}
+	if !r.IsLossy() {
+		t.Fatalf("expected a migration with dropped fields to be lossy")
+	}
+
+	changes, err = diffAPIModels([]byte(`{"properties": {"changed": "old"}}`), []byte(`{"properties": {"changed": "new"}}`))
+	if err != nil {
+		t.Fatalf("unexpected error diffing api models: %s", err)
+	}
+	r = &MigrationResult{Changes: changes}
+	if len(changes) != 1 || changes[0].Type != FieldChanged || changes[0].Value != "old" || changes[0].NewValue != "new" || r.IsLossy() {
+		t.Fatalf("expected a single change of properties.changed from old to new that is not lossy, got %v", changes)
+	}
+}
+
+func hasChange(changes []MigrationChange, changeType MigrationChangeType, path, newPath string) bool {
+	for _, c := range changes {
+		if c.Type == changeType && c.Path == path && c.NewPath == newPath {
+			return true
+		}
+	}
+	return false
+}
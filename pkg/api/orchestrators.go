@@ -220,6 +220,49 @@ func getKubernetesAvailableUpgradeVersions(orchestratorVersion string, supported
 
 }
 
+// GetKubernetesUpgradePath returns the chain of Kubernetes versions a cluster on currentVersion goes through to reach targetVersion,
+// each version being an available upgrade of the previous one. The chain ends with targetVersion.
+func GetKubernetesUpgradePath(currentVersion, targetVersion string, hasWindows bool) ([]string, error) {
+	target, err := semver.Make(targetVersion)
+	if err != nil {
+		return nil, err
+	}
+	supportedVersions := common.GetAllSupportedKubernetesVersions(false, hasWindows)
+	path := []string{}
+	version := currentVersion
+	for version != targetVersion {
+		upgradeVersions, err := getKubernetesAvailableUpgradeVersions(version, supportedVersions)
+		if err != nil {
+			return nil, err
+		}
+		// jump to the target if possible, otherwise as far as possible without going past the target
+		next := ""
+		var nextVersion semver.Version
+		for _, v := range upgradeVersions {
+			if v == targetVersion {
+				next = v
+				break
+			}
+			sv, err := semver.Make(v)
+			if err != nil {
+				return nil, err
+			}
+			if sv.LT(target) && (next == "" || sv.GT(nextVersion)) {
+				next, nextVersion = v, sv
+			}
+		}
+		if next == "" {
+			return nil, errors.Errorf("there is no upgrade path from Kubernetes version %s to version %s. To see a list of available upgrades, use 'aks-engine get-versions --version %s'", currentVersion, targetVersion, version)
+		}
+		path = append(path, next)
+		version = next
+	}
+	if len(path) == 0 {
+		return nil, errors.Errorf("the cluster is already on Kubernetes version %s", targetVersion)
+	}
+	return path, nil
+}
+
 func dcosInfo(csOrch *OrchestratorProfile, hasWindows bool) ([]*OrchestratorVersionProfile, error) {
 	orchs := []*OrchestratorVersionProfile{}
 	if csOrch.OrchestratorVersion == "" {
@@ -258,3 +258,59 @@ func TestGetKubernetesAvailableUpgradeVersions(t *testing.T) {
 		Expect(upgrades).To(Equal(c.expectedUpgrades))
 	}
 }
+
+func TestGetKubernetesUpgradePath(t *testing.T) {
+	RegisterTestingT(t)
+	cases := []struct {
+		currentVersion string
+		targetVersion  string
+		expectedPath   []string
+		expectError    bool
+	}{
+		{
+			currentVersion: "1.13.4",
+			targetVersion:  "1.13.5",
+			expectedPath:   []string{"1.13.5"},
+		},
+		{
+			currentVersion: "1.13.5",
+			targetVersion:  "1.14.1",
+			expectedPath:   []string{"1.14.1"},
+		},
+		{
+			currentVersion: "1.10.12",
+			targetVersion:  "1.13.5",
+			expectedPath:   []string{"1.11.9", "1.12.8", "1.13.5"},
+		},
+		{
+			currentVersion: "1.11.8",
+			targetVersion:  "1.14.0",
+			expectedPath:   []string{"1.12.8", "1.13.5", "1.14.0"},
+		},
+		{
+			currentVersion: "1.13.5",
+			targetVersion:  "1.13.5",
+			expectError:    true,
+		},
+		{
+			currentVersion: "1.14.1",
+			targetVersion:  "1.13.5",
+			expectError:    true,
+		},
+		{
+			currentVersion: "1.13.5",
+			targetVersion:  "1.13.99",
+			expectError:    true,
+		},
+	}
+
+	for _, c := range cases {
+		path, err := GetKubernetesUpgradePath(c.currentVersion, c.targetVersion, false)
+		if c.expectError {
+			Expect(err).NotTo(BeNil())
+			continue
+		}
+		Expect(err).To(BeNil())
+		Expect(path).To(Equal(c.expectedPath))
+	}
+}
@@ -89,6 +89,9 @@ type Properties struct {
 	AddonProfiles           map[string]AddonProfile  `json:"addonProfiles,omitempty"`
 	FeatureFlags            *FeatureFlags            `json:"featureFlags,omitempty"`
 	CustomCloudProfile      *CustomCloudProfile      `json:"customCloudProfile,omitempty"`
+	LintProfile             *LintProfile             `json:"lintProfile,omitempty"`
+	NetworkSecurityProfile  *NetworkSecurityProfile  `json:"networkSecurityProfile,omitempty"`
+	HTTPProxyConfig         *HTTPProxyConfig         `json:"httpProxyConfig,omitempty"`
 }
 
 // ClusterMetadata represents the metadata of the AKS cluster.
@@ -113,6 +116,43 @@ type AddonProfile struct {
 type FeatureFlags struct {
 	EnableCSERunInBackground bool `json:"enableCSERunInBackground,omitempty"`
 	BlockOutboundInternet    bool `json:"blockOutboundInternet,omitempty"`
+	EnableIPv6DualStack      bool `json:"enableIPv6DualStack,omitempty"`
+}
+
+// LintProfile configures the best-practice checks run against the cluster definition
+type LintProfile struct {
+	SuppressedRules []string `json:"suppressedRules,omitempty"`
+}
+
+// NetworkSecurityProfile configures the rules of the network security group in front of the cluster
+type NetworkSecurityProfile struct {
+	DisableSSH                     bool                  `json:"disableSSH,omitempty"`
+	SSHSourceAddressPrefixes       []string              `json:"sshSourceAddressPrefixes,omitempty"`
+	APIServerSourceAddressPrefixes []string              `json:"apiServerSourceAddressPrefixes,omitempty"`
+	RDPSourceAddressPrefixes       []string              `json:"rdpSourceAddressPrefixes,omitempty"`
+	SecurityRules                  []NetworkSecurityRule `json:"securityRules,omitempty"`
+}
+
+// HTTPProxyConfig configures the HTTP/HTTPS proxy used by cluster nodes for outbound traffic
+type HTTPProxyConfig struct {
+	HTTPProxy  string   `json:"httpProxy,omitempty"`
+	HTTPSProxy string   `json:"httpsProxy,omitempty"`
+	NoProxy    []string `json:"noProxy,omitempty"`
+	TrustedCA  string   `json:"trustedCa,omitempty"`
+}
+
+// NetworkSecurityRule is a custom rule added to the network security group
+type NetworkSecurityRule struct {
+	Name                       string   `json:"name"`
+	Description                string   `json:"description,omitempty"`
+	Priority                   int32    `json:"priority"`
+	Direction                  string   `json:"direction"`
+	Access                     string   `json:"access"`
+	Protocol                   string   `json:"protocol"`
+	SourceAddressPrefixes      []string `json:"sourceAddressPrefixes,omitempty"`
+	SourcePortRange            string   `json:"sourcePortRange,omitempty"`
+	DestinationAddressPrefixes []string `json:"destinationAddressPrefixes,omitempty"`
+	DestinationPortRange       string   `json:"destinationPortRange,omitempty"`
 }
 
 // ServicePrincipalProfile contains the client and secret used by the cluster for Azure Resource CRUD
@@ -121,6 +161,8 @@ type ServicePrincipalProfile struct {
 	Secret            string             `json:"secret,omitempty" conform:"redact"`
 	ObjectID          string             `json:"objectId,omitempty"`
 	KeyvaultSecretRef *KeyvaultSecretRef `json:"keyvaultSecretRef,omitempty"`
+	// ApplicationObjectID is the object ID of the application of the service principal, set when aks-engine created it
+	ApplicationObjectID string `json:"applicationObjectId,omitempty"`
 }
 
 // KeyvaultSecretRef specifies path to the Azure keyvault along with secret name and (optionaly) version
@@ -291,6 +333,15 @@ func (a KubernetesAddon) GetAddonContainersIndexByName(containerName string) int
 type PrivateCluster struct {
 	Enabled        *bool                  `json:"enabled,omitempty"`
 	JumpboxProfile *PrivateJumpboxProfile `json:"jumpboxProfile,omitempty"`
+	PrivateDNSZone *PrivateDNSZone        `json:"privateDnsZone,omitempty"`
+}
+
+// PrivateDNSZone configures the Azure Private DNS zone that resolves the API server FQDN of a private cluster
+type PrivateDNSZone struct {
+	Enabled           *bool    `json:"enabled,omitempty"`
+	Name              string   `json:"name,omitempty"`
+	ID                string   `json:"id,omitempty"`
+	VirtualNetworkIDs []string `json:"virtualNetworkIDs,omitempty"`
 }
 
 // PrivateJumpboxProfile represents a jumpbox definition
@@ -337,6 +388,28 @@ const (
 	KubeProxyModeIPVS KubeProxyMode = "ipvs"
 )
 
+// EgressType is for loadBalancer and userDefinedRouting
+type EgressType string
+
+// Agent egress goes either through the agent load balancer or through user-defined routes
+const (
+	// EgressTypeLoadBalancer sends agent egress traffic through outbound rules on the agent standard load balancer
+	EgressTypeLoadBalancer EgressType = "loadBalancer"
+	// EgressTypeUserDefinedRouting sends agent egress traffic to a user-provided firewall or network virtual appliance
+	EgressTypeUserDefinedRouting EgressType = "userDefinedRouting"
+)
+
+// EgressProfile configures the outbound connectivity of the agent nodes
+type EgressProfile struct {
+	Type                   EgressType `json:"type,omitempty"`
+	OutboundIPCount        int        `json:"outboundIPCount,omitempty"`
+	OutboundIPPrefixID     string     `json:"outboundIPPrefixID,omitempty"`
+	AllocatedOutboundPorts int        `json:"allocatedOutboundPorts,omitempty"`
+	IdleTimeoutInMinutes   int        `json:"idleTimeoutInMinutes,omitempty"`
+	EnableTCPReset         *bool      `json:"enableTCPReset,omitempty"`
+	FirewallIPAddress      string     `json:"firewallIPAddress,omitempty"`
+}
+
 // KubernetesConfig contains the Kubernetes config structure, containing
 // Kubernetes specific configuration
 type KubernetesConfig struct {
@@ -380,6 +453,7 @@ type KubernetesConfig struct {
 	APIServerConfig                  map[string]string `json:"apiServerConfig,omitempty"`
 	SchedulerConfig                  map[string]string `json:"schedulerConfig,omitempty"`
 	PodSecurityPolicyConfig          map[string]string `json:"podSecurityPolicyConfig,omitempty"`
+	ComponentOverrides               map[string]string `json:"componentOverrides,omitempty"`
 	CloudProviderBackoff             *bool             `json:"cloudProviderBackoff,omitempty"`
 	CloudProviderBackoffRetries      int               `json:"cloudProviderBackoffRetries,omitempty"`
 	CloudProviderBackoffJitter       float64           `json:"cloudProviderBackoffJitter,omitempty"`
@@ -396,9 +470,13 @@ type KubernetesConfig struct {
 	CtrlMgrRouteReconciliationPeriod string            `json:"ctrlMgrRouteReconciliationPeriod,omitempty"`
 	LoadBalancerSku                  string            `json:"loadBalancerSku,omitempty"`
 	ExcludeMasterFromStandardLB      *bool             `json:"excludeMasterFromStandardLB,omitempty"`
+	EgressProfile                    *EgressProfile    `json:"egressProfile,omitempty"`
 	AzureCNIVersion                  string            `json:"azureCNIVersion,omitempty"`
 	AzureCNIURLLinux                 string            `json:"azureCNIURLLinux,omitempty"`
 	AzureCNIURLWindows               string            `json:"azureCNIURLWindows,omitempty"`
+	CNIPluginsURL                    string            `json:"cniPluginsURL,omitempty"`
+	EtcdDownloadURLBase              string            `json:"etcdDownloadURLBase,omitempty"`
+	ContainerdDownloadURLBase        string            `json:"containerdDownloadURLBase,omitempty"`
 	KeyVaultSku                      string            `json:"keyVaultSku,omitempty"`
 	MaximumLoadBalancerRuleCount     int               `json:"maximumLoadBalancerRuleCount,omitempty"`
 	ProxyMode                        KubeProxyMode     `json:"kubeProxyMode,omitempty"`
@@ -448,6 +526,7 @@ type MasterProfile struct {
 	Subnet                   string            `json:"subnet"`
 	IPAddressCount           int               `json:"ipAddressCount,omitempty"`
 	StorageProfile           string            `json:"storageProfile,omitempty"`
+	DiskEncryptionSetID      string            `json:"diskEncryptionSetID,omitempty"`
 	HTTPSourceAddressPrefix  string            `json:"HTTPSourceAddressPrefix,omitempty"`
 	OAuthEnabled             bool              `json:"oauthEnabled"`
 	PreprovisionExtension    *Extension        `json:"preProvisionExtension"`
@@ -461,6 +540,10 @@ type MasterProfile struct {
 	AvailabilityZones        []string          `json:"availabilityZones,omitempty"`
 	SinglePlacementGroup     *bool             `json:"singlePlacementGroup,omitempty"`
 
+	ProximityPlacementGroupID     string `json:"proximityPlacementGroupID,omitempty"`
+	EnableProximityPlacementGroup *bool  `json:"enableProximityPlacementGroup,omitempty"`
+	HostGroupID                   string `json:"hostGroupID,omitempty"`
+
 	// Master LB public endpoint/FQDN with port
 	// The format will be FQDN:2376
 	// Not used during PUT, returned as part of GET
@@ -485,6 +568,15 @@ type ExtensionProfile struct {
 	// This is only needed for preprovision extensions and it needs to be a bash script
 	Script   string `json:"script,omitempty"`
 	URLQuery string `json:"urlQuery,omitempty"`
+	// LocalPath is a local directory holding the extension under extensions/<name>/<version>, the way RootURL does
+	LocalPath string `json:"localPath,omitempty"`
+	// OCIReference is an OCI artifact holding the files of the extension as layers titled with their file names
+	OCIReference string `json:"ociReference,omitempty"`
+	// Upload uploads the files of a local or OCI extension to a storage account when deploying, instead of embedding
+	// them into the template
+	Upload bool `json:"upload,omitempty"`
+	// Checksums are the sha256 digests of the files of the extension by file name, verified before the files are used
+	Checksums map[string]string `json:"checksums,omitempty"`
 }
 
 // Extension represents an extension definition in the master or agentPoolProfile
@@ -500,6 +592,7 @@ type AgentPoolProfile struct {
 	Count                               int                  `json:"count"`
 	VMSize                              string               `json:"vmSize"`
 	OSDiskSizeGB                        int                  `json:"osDiskSizeGB,omitempty"`
+	OSDiskType                          string               `json:"osDiskType,omitempty"`
 	DNSPrefix                           string               `json:"dnsPrefix,omitempty"`
 	OSType                              OSType               `json:"osType,omitempty"`
 	Ports                               []int                `json:"ports,omitempty"`
@@ -509,6 +602,7 @@ type AgentPoolProfile struct {
 	ScaleSetEvictionPolicy              string               `json:"scaleSetEvictionPolicy,omitempty"`
 	StorageProfile                      string               `json:"storageProfile,omitempty"`
 	DiskSizesGB                         []int                `json:"diskSizesGB,omitempty"`
+	DiskEncryptionSetID                 string               `json:"diskEncryptionSetID,omitempty"`
 	VnetSubnetID                        string               `json:"vnetSubnetID,omitempty"`
 	Subnet                              string               `json:"subnet"`
 	IPAddressCount                      int                  `json:"ipAddressCount,omitempty"`
@@ -519,6 +613,7 @@ type AgentPoolProfile struct {
 	VMSSOverProvisioningEnabled         *bool                `json:"vmssOverProvisioningEnabled,omitempty"`
 	FQDN                                string               `json:"fqdn,omitempty"`
 	CustomNodeLabels                    map[string]string    `json:"customNodeLabels,omitempty"`
+	Taints                              []string             `json:"taints,omitempty"`
 	PreprovisionExtension               *Extension           `json:"preProvisionExtension"`
 	Extensions                          []Extension          `json:"extensions"`
 	KubernetesConfig                    *KubernetesConfig    `json:"kubernetesConfig,omitempty"`
@@ -533,6 +628,12 @@ type AgentPoolProfile struct {
 	PreserveNodesProperties             *bool                `json:"preserveNodesProperties,omitempty"`
 	WindowsNameVersion                  string               `json:"windowsNameVersion,omitempty"`
 	EnableVMSSNodePublicIP              *bool                `json:"enableVMSSNodePublicIP,omitempty"`
+	NetworkSecurityGroupID              string               `json:"networkSecurityGroupID,omitempty"`
+	EnableDedicatedNetworkSecurityGroup *bool                `json:"enableDedicatedNetworkSecurityGroup,omitempty"`
+	RouteTableID                        string               `json:"routeTableID,omitempty"`
+	ProximityPlacementGroupID           string               `json:"proximityPlacementGroupID,omitempty"`
+	EnableProximityPlacementGroup       *bool                `json:"enableProximityPlacementGroup,omitempty"`
+	HostGroupID                         string               `json:"hostGroupID,omitempty"`
 }
 
 // AgentPoolProfileRole represents an agent role
@@ -1096,6 +1197,35 @@ func (p *Properties) HasUbuntu1804DistroNodes() bool {
 	return false
 }
 
+// IsProximityPlacementGroupEnabled returns true if aks-engine creates a proximity placement group for the cluster
+func (p *Properties) IsProximityPlacementGroupEnabled() bool {
+	if p.MasterProfile != nil && p.MasterProfile.IsProximityPlacementGroupEnabled() {
+		return true
+	}
+	for _, agentPoolProfile := range p.AgentPoolProfiles {
+		if agentPoolProfile.IsProximityPlacementGroupEnabled() {
+			return true
+		}
+	}
+	return false
+}
+
+// GetAgentPoolOrchestratorVersion returns the Kubernetes version of the agent pool, which defaults to the control plane version
+func (p *Properties) GetAgentPoolOrchestratorVersion(a *AgentPoolProfile) string {
+	if a.OrchestratorVersion != "" {
+		return a.OrchestratorVersion
+	}
+	if p.OrchestratorProfile != nil {
+		return p.OrchestratorProfile.OrchestratorVersion
+	}
+	return ""
+}
+
+// AgentPoolHasOwnOrchestratorVersion returns true if the agent pool runs a Kubernetes version other than the control plane's
+func (p *Properties) AgentPoolHasOwnOrchestratorVersion(a *AgentPoolProfile) bool {
+	return a.OrchestratorVersion != "" && p.OrchestratorProfile != nil && a.OrchestratorVersion != p.OrchestratorProfile.OrchestratorVersion
+}
+
 // HasAvailabilityZones returns true if the cluster contains a profile with zones
 func (p *Properties) HasAvailabilityZones() bool {
 	hasZones := p.MasterProfile != nil && p.MasterProfile.HasAvailabilityZones()
@@ -1121,7 +1251,7 @@ func (p *Properties) GetNonMasqueradeCIDR() string {
 				nonMasqCidr = DefaultVNETCIDR
 			}
 		} else {
-			nonMasqCidr = p.OrchestratorProfile.KubernetesConfig.ClusterSubnet
+			nonMasqCidr = common.GetIPv4CIDR(p.OrchestratorProfile.KubernetesConfig.ClusterSubnet)
 		}
 	}
 	return nonMasqCidr
@@ -1145,6 +1275,69 @@ func (p *Properties) GetMasterFQDN() string {
 	return p.MasterProfile.FQDN
 }
 
+// IsHTTPProxyEnabled returns true if cluster nodes should reach the internet through an HTTP/HTTPS proxy
+func (p *Properties) IsHTTPProxyEnabled() bool {
+	return p.HTTPProxyConfig != nil && (p.HTTPProxyConfig.HTTPProxy != "" || p.HTTPProxyConfig.HTTPSProxy != "")
+}
+
+// GetHTTPProxyNoProxy returns the destinations that bypass the HTTP/HTTPS proxy:
+// loopback, the Azure platform endpoints, the VNET, pod and service CIDRs, the in-cluster
+// DNS names, and the user-provided noProxy entries. The default VNET CIDR is only added when
+// aks-engine creates the VNET, a custom VNET is covered by masterProfile.vnetCidr or noProxy
+func (p *Properties) GetHTTPProxyNoProxy() []string {
+	noProxy := []string{
+		"localhost",
+		"127.0.0.1",
+		"169.254.169.254",
+		"168.63.129.16",
+	}
+	if p.MasterProfile != nil && p.MasterProfile.VnetCidr != "" {
+		noProxy = append(noProxy, p.MasterProfile.VnetCidr)
+	} else if p.MasterProfile == nil || !p.MasterProfile.IsCustomVNET() {
+		noProxy = append(noProxy, DefaultVNETCIDR)
+	}
+	if p.OrchestratorProfile != nil && p.OrchestratorProfile.KubernetesConfig != nil {
+		k := p.OrchestratorProfile.KubernetesConfig
+		for _, cidrs := range []string{k.ClusterSubnet, k.ServiceCIDR} {
+			for _, cidr := range strings.Split(cidrs, ",") {
+				if cidr != "" {
+					noProxy = append(noProxy, cidr)
+				}
+			}
+		}
+	}
+	noProxy = append(noProxy,
+		"kubernetes.default",
+		".svc",
+		"."+DefaultKubernetesClusterDomain,
+		".internal.cloudapp.net",
+	)
+	if p.HTTPProxyConfig != nil {
+		noProxy = append(noProxy, p.HTTPProxyConfig.NoProxy...)
+	}
+
+	seen := map[string]bool{}
+	var ret []string
+	for _, entry := range noProxy {
+		entry = strings.TrimSpace(entry)
+		if entry == "" || seen[entry] {
+			continue
+		}
+		seen[entry] = true
+		ret = append(ret, entry)
+	}
+	return ret
+}
+
+// GetPrivateAPIServerFQDN returns the FQDN of the API server in the private DNS zone of a private cluster,
+// or an empty string if the cluster has no private DNS zone
+func (p *Properties) GetPrivateAPIServerFQDN() string {
+	if p.MasterProfile == nil || p.OrchestratorProfile == nil || !p.OrchestratorProfile.KubernetesConfig.IsPrivateDNSZoneEnabled() {
+		return ""
+	}
+	return fmt.Sprintf("%s.%s", strings.ToLower(p.MasterProfile.DNSPrefix), p.OrchestratorProfile.KubernetesConfig.PrivateCluster.PrivateDNSZone.Name)
+}
+
 // IsCustomVNET returns true if the customer brought their own VNET
 func (m *MasterProfile) IsCustomVNET() bool {
 	return len(m.VnetSubnetID) > 0
@@ -1214,6 +1407,16 @@ func (m *MasterProfile) HasAvailabilityZones() bool {
 	return m.AvailabilityZones != nil && len(m.AvailabilityZones) > 0
 }
 
+// IsProximityPlacementGroupEnabled returns true if the masters are placed in the proximity placement group created by aks-engine
+func (m *MasterProfile) IsProximityPlacementGroupEnabled() bool {
+	return to.Bool(m.EnableProximityPlacementGroup)
+}
+
+// HasProximityPlacementGroup returns true if the masters are placed in a proximity placement group
+func (m *MasterProfile) HasProximityPlacementGroup() bool {
+	return m.ProximityPlacementGroupID != "" || m.IsProximityPlacementGroupEnabled()
+}
+
 // IsUbuntu1604 returns true if the master profile distro is based on Ubuntu 16.04
 func (m *MasterProfile) IsUbuntu1604() bool {
 	switch m.Distro {
@@ -1274,6 +1477,21 @@ func (a *AgentPoolProfile) IsVHDDistro() bool {
 	return a.Distro == AKS || a.Distro == AKS1804
 }
 
+// IsLocal returns true if the extension is read from a local directory
+func (e *ExtensionProfile) IsLocal() bool {
+	return e.LocalPath != ""
+}
+
+// IsOCI returns true if the extension is pulled from an OCI registry
+func (e *ExtensionProfile) IsOCI() bool {
+	return e.OCIReference != ""
+}
+
+// IsEmbedded returns true if the files of the extension are embedded into the template rather than downloaded from RootURL
+func (e *ExtensionProfile) IsEmbedded() bool {
+	return (e.IsLocal() || e.IsOCI()) && !e.Upload
+}
+
 // IsAvailabilitySets returns true if the customer specified disks
 func (a *AgentPoolProfile) IsAvailabilitySets() bool {
 	return a.AvailabilityProfile == AvailabilitySet
@@ -1299,6 +1517,11 @@ func (a *AgentPoolProfile) IsStorageAccount() bool {
 	return a.StorageProfile == StorageAccount
 }
 
+// IsEphemeralOSDisk returns true if the agent pool places its OS disk on the local VM cache
+func (a *AgentPoolProfile) IsEphemeralOSDisk() bool {
+	return a.OSDiskType == OSDiskTypeEphemeral
+}
+
 // HasDisks returns true if the customer specified disks
 func (a *AgentPoolProfile) HasDisks() bool {
 	return len(a.DiskSizesGB) > 0
@@ -1309,6 +1532,31 @@ func (a *AgentPoolProfile) HasAvailabilityZones() bool {
 	return a.AvailabilityZones != nil && len(a.AvailabilityZones) > 0
 }
 
+// IsDedicatedNetworkSecurityGroupEnabled returns true if aks-engine creates a network security group for the agent pool
+func (a *AgentPoolProfile) IsDedicatedNetworkSecurityGroupEnabled() bool {
+	return to.Bool(a.EnableDedicatedNetworkSecurityGroup)
+}
+
+// HasOwnNetworkSecurityGroup returns true if the agent pool uses a network security group other than the cluster one
+func (a *AgentPoolProfile) HasOwnNetworkSecurityGroup() bool {
+	return a.NetworkSecurityGroupID != "" || a.IsDedicatedNetworkSecurityGroupEnabled()
+}
+
+// HasOwnRouteTable returns true if the agent pool uses a route table other than the cluster one
+func (a *AgentPoolProfile) HasOwnRouteTable() bool {
+	return a.RouteTableID != ""
+}
+
+// IsProximityPlacementGroupEnabled returns true if the agent pool is placed in the proximity placement group created by aks-engine
+func (a *AgentPoolProfile) IsProximityPlacementGroupEnabled() bool {
+	return to.Bool(a.EnableProximityPlacementGroup)
+}
+
+// HasProximityPlacementGroup returns true if the agent pool is placed in a proximity placement group
+func (a *AgentPoolProfile) HasProximityPlacementGroup() bool {
+	return a.ProximityPlacementGroupID != "" || a.IsProximityPlacementGroupEnabled()
+}
+
 // IsUbuntu1604 returns true if the agent pool profile distro is based on Ubuntu 16.04
 func (a *AgentPoolProfile) IsUbuntu1604() bool {
 	if a.OSType != Windows {
@@ -1440,6 +1688,11 @@ func (o *OrchestratorProfile) RequireRouteTable() bool {
 	}
 }
 
+// HasRouteTable returns true if this deployment creates a routing table, either for cluster routes or for egress
+func (o *OrchestratorProfile) HasRouteTable() bool {
+	return o.RequireRouteTable() || (o.IsKubernetes() && o.KubernetesConfig.IsUserDefinedRoutingEgress())
+}
+
 // IsPrivateCluster returns true if this deployment is a private cluster
 func (o *OrchestratorProfile) IsPrivateCluster() bool {
 	if !o.IsKubernetes() {
@@ -1448,6 +1701,39 @@ func (o *OrchestratorProfile) IsPrivateCluster() bool {
 	return o.KubernetesConfig != nil && o.KubernetesConfig.PrivateCluster != nil && to.Bool(o.KubernetesConfig.PrivateCluster.Enabled)
 }
 
+// GetK8sComponents returns the Kubernetes components for the orchestrator version,
+// with any KubernetesConfig.ComponentOverrides taking precedence
+func (o *OrchestratorProfile) GetK8sComponents() map[string]string {
+	return o.GetK8sComponentsForVersion(o.OrchestratorVersion)
+}
+
+// GetK8sComponentsForVersion returns the Kubernetes components for version, e.g. the version of an agent pool,
+// with any KubernetesConfig.ComponentOverrides taking precedence
+func (o *OrchestratorProfile) GetK8sComponentsForVersion(version string) map[string]string {
+	components := map[string]string{}
+	for key, val := range K8sComponentsByVersionMap[version] {
+		components[key] = val
+	}
+	if o.KubernetesConfig != nil {
+		for key, val := range o.KubernetesConfig.ComponentOverrides {
+			components[key] = val
+		}
+	}
+	return components
+}
+
+// GetEtcdVersion returns the etcd version of the cluster, with any etcd component override taking precedence
+// over KubernetesConfig.EtcdVersion
+func (o *OrchestratorProfile) GetEtcdVersion() string {
+	if etcdVersion, ok := o.GetK8sComponents()["etcd"]; ok {
+		return etcdVersion
+	}
+	if o.KubernetesConfig == nil {
+		return ""
+	}
+	return o.KubernetesConfig.EtcdVersion
+}
+
 // NeedsExecHealthz returns whether or not we have a configuration that requires exechealthz pod anywhere
 func (o *OrchestratorProfile) NeedsExecHealthz() bool {
 	return o.IsKubernetes() &&
@@ -1464,7 +1750,7 @@ func (p *Properties) HasAadProfile() bool {
 func (o *OrchestratorProfile) GetAPIServerEtcdAPIVersion() string {
 	if o.KubernetesConfig != nil {
 		// if we are here, version has already been validated..
-		etcdVersion, _ := semver.Make(o.KubernetesConfig.EtcdVersion)
+		etcdVersion, _ := semver.Make(o.GetEtcdVersion())
 		return "etcd" + strconv.FormatUint(etcdVersion.Major, 10)
 	}
 	return ""
@@ -1701,6 +1987,27 @@ func (k *KubernetesConfig) PrivateJumpboxProvision() bool {
 	return false
 }
 
+// IsPrivateDNSZoneEnabled returns true if the API server of a private cluster is resolved through an Azure Private DNS zone
+func (k *KubernetesConfig) IsPrivateDNSZoneEnabled() bool {
+	return k != nil && k.PrivateCluster != nil && to.Bool(k.PrivateCluster.Enabled) &&
+		k.PrivateCluster.PrivateDNSZone != nil && to.Bool(k.PrivateCluster.PrivateDNSZone.Enabled)
+}
+
+// IsExistingZone returns true if the API server record is added to a private DNS zone that is not managed by the cluster
+func (z *PrivateDNSZone) IsExistingZone() bool {
+	return z.ID != ""
+}
+
+// IsLoadBalancerEgress checks if agent egress goes through outbound rules on the agent load balancer
+func (k *KubernetesConfig) IsLoadBalancerEgress() bool {
+	return k != nil && k.EgressProfile != nil && k.EgressProfile.Type == EgressTypeLoadBalancer
+}
+
+// IsUserDefinedRoutingEgress checks if agent egress is routed to a user-provided firewall or network virtual appliance
+func (k *KubernetesConfig) IsUserDefinedRoutingEgress() bool {
+	return k != nil && k.EgressProfile != nil && k.EgressProfile.Type == EgressTypeUserDefinedRouting
+}
+
 // RequiresDocker returns if the kubernetes settings require docker binary to be installed.
 func (k *KubernetesConfig) RequiresDocker() bool {
 	runtime := strings.ToLower(k.ContainerRuntime)
@@ -1749,6 +2056,30 @@ func (k *KubernetesConfig) GetAzureCNIURLWindows(cloudSpecConfig AzureEnvironmen
 	return cloudSpecConfig.KubernetesSpecConfig.VnetCNIWindowsPluginsDownloadURL
 }
 
+// GetCNIPluginsURL returns the full URL to source the reference CNI plugins from
+func (k *KubernetesConfig) GetCNIPluginsURL(cloudSpecConfig AzureEnvironmentSpecConfig) string {
+	if k.CNIPluginsURL != "" {
+		return k.CNIPluginsURL
+	}
+	return cloudSpecConfig.KubernetesSpecConfig.CNIPluginsDownloadURL
+}
+
+// GetEtcdDownloadURLBase returns the base URL to source etcd release tarballs from
+func (k *KubernetesConfig) GetEtcdDownloadURLBase(cloudSpecConfig AzureEnvironmentSpecConfig) string {
+	if k.EtcdDownloadURLBase != "" {
+		return k.EtcdDownloadURLBase
+	}
+	return cloudSpecConfig.KubernetesSpecConfig.EtcdDownloadURLBase
+}
+
+// GetContainerdDownloadURLBase returns the base URL to source containerd release tarballs from
+func (k *KubernetesConfig) GetContainerdDownloadURLBase(cloudSpecConfig AzureEnvironmentSpecConfig) string {
+	if k.ContainerdDownloadURLBase != "" {
+		return k.ContainerdDownloadURLBase
+	}
+	return cloudSpecConfig.KubernetesSpecConfig.ContainerdDownloadURLBase
+}
+
 // IsFeatureEnabled returns true if a feature flag is on for the provided feature
 func (f *FeatureFlags) IsFeatureEnabled(feature string) bool {
 	if f != nil {
@@ -1757,6 +2088,8 @@ func (f *FeatureFlags) IsFeatureEnabled(feature string) bool {
 			return f.EnableCSERunInBackground
 		case "BlockOutboundInternet":
 			return f.BlockOutboundInternet
+		case "EnableIPv6DualStack":
+			return f.EnableIPv6DualStack
 		default:
 			return false
 		}
@@ -256,6 +256,51 @@ func TestAgentPoolProfileIsVHDDistro(t *testing.T) {
 	}
 }
 
+func TestAgentPoolProfileHasOwnNetworkResources(t *testing.T) {
+	cases := []struct {
+		name               string
+		ap                 AgentPoolProfile
+		expectedNSG        bool
+		expectedRouteTable bool
+	}{
+		{
+			name: "cluster network resources",
+			ap:   AgentPoolProfile{},
+		},
+		{
+			name: "dedicated network security group explicitly disabled",
+			ap: AgentPoolProfile{
+				EnableDedicatedNetworkSecurityGroup: to.BoolPtr(false),
+			},
+		},
+		{
+			name: "dedicated network security group",
+			ap: AgentPoolProfile{
+				EnableDedicatedNetworkSecurityGroup: to.BoolPtr(true),
+			},
+			expectedNSG: true,
+		},
+		{
+			name: "existing network resources",
+			ap: AgentPoolProfile{
+				NetworkSecurityGroupID: "/subscriptions/SUB_ID/resourceGroups/RG_NAME/providers/Microsoft.Network/networkSecurityGroups/pool1-nsg",
+				RouteTableID:           "/subscriptions/SUB_ID/resourceGroups/RG_NAME/providers/Microsoft.Network/routeTables/pool1-routetable",
+			},
+			expectedNSG:        true,
+			expectedRouteTable: true,
+		},
+	}
+
+	for _, c := range cases {
+		if c.expectedNSG != c.ap.HasOwnNetworkSecurityGroup() {
+			t.Fatalf("%s: expected HasOwnNetworkSecurityGroup() to return %t", c.name, c.expectedNSG)
+		}
+		if c.expectedRouteTable != c.ap.HasOwnRouteTable() {
+			t.Fatalf("%s: expected HasOwnRouteTable() to return %t", c.name, c.expectedRouteTable)
+		}
+	}
+}
+
 func TestAgentPoolProfileIsUbuntuNonVHD(t *testing.T) {
 	cases := []struct {
 		ap       AgentPoolProfile
@@ -919,6 +964,107 @@ func TestHasAvailabilityZones(t *testing.T) {
 	}
 }
 
+func TestGetAgentPoolOrchestratorVersion(t *testing.T) {
+	p := Properties{
+		OrchestratorProfile: &OrchestratorProfile{OrchestratorType: Kubernetes, OrchestratorVersion: "1.15.4"},
+	}
+	cases := []struct {
+		name            string
+		a               *AgentPoolProfile
+		expectedVersion string
+		expectedOwn     bool
+	}{
+		{
+			name:            "no agent pool version",
+			a:               &AgentPoolProfile{},
+			expectedVersion: "1.15.4",
+			expectedOwn:     false,
+		},
+		{
+			name:            "same version as the control plane",
+			a:               &AgentPoolProfile{OrchestratorVersion: "1.15.4"},
+			expectedVersion: "1.15.4",
+			expectedOwn:     false,
+		},
+		{
+			name:            "agent pool lagging the control plane",
+			a:               &AgentPoolProfile{OrchestratorVersion: "1.14.7"},
+			expectedVersion: "1.14.7",
+			expectedOwn:     true,
+		},
+	}
+
+	for _, c := range cases {
+		if actual := p.GetAgentPoolOrchestratorVersion(c.a); actual != c.expectedVersion {
+			t.Errorf("%s: expected GetAgentPoolOrchestratorVersion() to return %s, got %s", c.name, c.expectedVersion, actual)
+		}
+		if actual := p.AgentPoolHasOwnOrchestratorVersion(c.a); actual != c.expectedOwn {
+			t.Errorf("%s: expected AgentPoolHasOwnOrchestratorVersion() to return %t, got %t", c.name, c.expectedOwn, actual)
+		}
+	}
+}
+
+func TestIsProximityPlacementGroupEnabled(t *testing.T) {
+	cases := []struct {
+		name     string
+		p        Properties
+		expected bool
+	}{
+		{
+			name: "no proximity placement group",
+			p: Properties{
+				MasterProfile:     &MasterProfile{Count: 1},
+				AgentPoolProfiles: []*AgentPoolProfile{{Count: 1}},
+			},
+			expected: false,
+		},
+		{
+			name: "existing proximity placement group",
+			p: Properties{
+				MasterProfile: &MasterProfile{Count: 1},
+				AgentPoolProfiles: []*AgentPoolProfile{
+					{
+						Count:                     1,
+						ProximityPlacementGroupID: "/subscriptions/SUB_ID/resourceGroups/RG_NAME/providers/Microsoft.Compute/proximityPlacementGroups/ppg",
+					},
+				},
+			},
+			expected: false,
+		},
+		{
+			name: "enabled on the masters",
+			p: Properties{
+				MasterProfile:     &MasterProfile{Count: 1, EnableProximityPlacementGroup: to.BoolPtr(true)},
+				AgentPoolProfiles: []*AgentPoolProfile{{Count: 1}},
+			},
+			expected: true,
+		},
+		{
+			name: "enabled on an agent pool",
+			p: Properties{
+				MasterProfile:     &MasterProfile{Count: 1},
+				AgentPoolProfiles: []*AgentPoolProfile{{Count: 1}, {Count: 1, EnableProximityPlacementGroup: to.BoolPtr(true)}},
+			},
+			expected: true,
+		},
+	}
+
+	for _, c := range cases {
+		c := c
+		t.Run(c.name, func(t *testing.T) {
+			t.Parallel()
+			if c.p.IsProximityPlacementGroupEnabled() != c.expected {
+				t.Fatalf("expected IsProximityPlacementGroupEnabled() to return %t but instead returned %t", c.expected, c.p.IsProximityPlacementGroupEnabled())
+			}
+			for _, agentPoolProfile := range c.p.AgentPoolProfiles {
+				if agentPoolProfile.HasProximityPlacementGroup() != (agentPoolProfile.ProximityPlacementGroupID != "" || to.Bool(agentPoolProfile.EnableProximityPlacementGroup)) {
+					t.Fatalf("unexpected HasProximityPlacementGroup() result for agent pool %+v", agentPoolProfile)
+				}
+			}
+		})
+	}
+}
+
 func TestMasterIsUbuntu(t *testing.T) {
 	cases := []struct {
 		p        Properties
@@ -1888,6 +2034,69 @@ func TestRequireRouteTable(t *testing.T) {
 	}
 }
 
+func TestHasRouteTable(t *testing.T) {
+	cases := []struct {
+		p        Properties
+		expected bool
+	}{
+		{
+			p: Properties{
+				OrchestratorProfile: &OrchestratorProfile{
+					OrchestratorType: Kubernetes,
+					KubernetesConfig: &KubernetesConfig{},
+				},
+			},
+			expected: true,
+		},
+		{
+			p: Properties{
+				OrchestratorProfile: &OrchestratorProfile{
+					OrchestratorType: Kubernetes,
+					KubernetesConfig: &KubernetesConfig{
+						NetworkPlugin: NetworkPluginAzure,
+					},
+				},
+			},
+			expected: false,
+		},
+		{
+			p: Properties{
+				OrchestratorProfile: &OrchestratorProfile{
+					OrchestratorType: Kubernetes,
+					KubernetesConfig: &KubernetesConfig{
+						NetworkPlugin: NetworkPluginAzure,
+						EgressProfile: &EgressProfile{
+							Type:              EgressTypeUserDefinedRouting,
+							FirewallIPAddress: "10.241.0.4",
+						},
+					},
+				},
+			},
+			expected: true,
+		},
+		{
+			p: Properties{
+				OrchestratorProfile: &OrchestratorProfile{
+					OrchestratorType: Kubernetes,
+					KubernetesConfig: &KubernetesConfig{
+						NetworkPlugin: NetworkPluginAzure,
+						EgressProfile: &EgressProfile{
+							Type: EgressTypeLoadBalancer,
+						},
+					},
+				},
+			},
+			expected: false,
+		},
+	}
+
+	for _, c := range cases {
+		if c.p.OrchestratorProfile.HasRouteTable() != c.expected {
+			t.Fatalf("expected HasRouteTable() to return %t but instead got %t", c.expected, c.p.OrchestratorProfile.HasRouteTable())
+		}
+	}
+}
+
 func TestIsPrivateCluster(t *testing.T) {
 	cases := []struct {
 		p        Properties
@@ -2893,6 +3102,38 @@ func TestGetAzureCNIURLFuncs(t *testing.T) {
 	}
 }
 
+func TestGetDownloadURLFuncs(t *testing.T) {
+	cs := CreateMockContainerService("testcluster", defaultTestClusterVer, 1, 3, false)
+	cs.Location = "eastus"
+	cloudSpecConfig := cs.GetCloudSpecConfig()
+
+	k := &KubernetesConfig{}
+	if url := k.GetCNIPluginsURL(cloudSpecConfig); url != cloudSpecConfig.KubernetesSpecConfig.CNIPluginsDownloadURL {
+		t.Fatalf("GetCNIPluginsURL() should return default %s, instead returned %s", cloudSpecConfig.KubernetesSpecConfig.CNIPluginsDownloadURL, url)
+	}
+	if url := k.GetEtcdDownloadURLBase(cloudSpecConfig); url != cloudSpecConfig.KubernetesSpecConfig.EtcdDownloadURLBase {
+		t.Fatalf("GetEtcdDownloadURLBase() should return default %s, instead returned %s", cloudSpecConfig.KubernetesSpecConfig.EtcdDownloadURLBase, url)
+	}
+	if url := k.GetContainerdDownloadURLBase(cloudSpecConfig); url != cloudSpecConfig.KubernetesSpecConfig.ContainerdDownloadURLBase {
+		t.Fatalf("GetContainerdDownloadURLBase() should return default %s, instead returned %s", cloudSpecConfig.KubernetesSpecConfig.ContainerdDownloadURLBase, url)
+	}
+
+	k = &KubernetesConfig{
+		CNIPluginsURL:             "https://custom-url/cni/cni-plugins-amd64-v0.0.1.tgz",
+		EtcdDownloadURLBase:       "https://custom-url/etcd",
+		ContainerdDownloadURLBase: "https://custom-url/containerd/",
+	}
+	if url := k.GetCNIPluginsURL(cloudSpecConfig); url != k.CNIPluginsURL {
+		t.Fatalf("GetCNIPluginsURL() should return custom URL %s, instead returned %s", k.CNIPluginsURL, url)
+	}
+	if url := k.GetEtcdDownloadURLBase(cloudSpecConfig); url != k.EtcdDownloadURLBase {
+		t.Fatalf("GetEtcdDownloadURLBase() should return custom URL %s, instead returned %s", k.EtcdDownloadURLBase, url)
+	}
+	if url := k.GetContainerdDownloadURLBase(cloudSpecConfig); url != k.ContainerdDownloadURLBase {
+		t.Fatalf("GetContainerdDownloadURLBase() should return custom URL %s, instead returned %s", k.ContainerdDownloadURLBase, url)
+	}
+}
+
 func TestCloudProviderDefaults(t *testing.T) {
 	// Test cloudprovider defaults when no user-provided values
 	v := "1.8.0"
@@ -4123,6 +4364,98 @@ func TestProperties_GetMasterVMPrefix(t *testing.T) {
 	}
 }
 
+func TestProperties_IsHTTPProxyEnabled(t *testing.T) {
+	cases := []struct {
+		name     string
+		config   *HTTPProxyConfig
+		expected bool
+	}{
+		{
+			name:     "nil config",
+			config:   nil,
+			expected: false,
+		},
+		{
+			name:     "only noProxy",
+			config:   &HTTPProxyConfig{NoProxy: []string{"contoso.com"}},
+			expected: false,
+		},
+		{
+			name:     "httpProxy",
+			config:   &HTTPProxyConfig{HTTPProxy: "http://proxy:3128"},
+			expected: true,
+		},
+		{
+			name:     "httpsProxy",
+			config:   &HTTPProxyConfig{HTTPSProxy: "https://proxy:3129"},
+			expected: true,
+		},
+	}
+
+	for _, c := range cases {
+		c := c
+		t.Run(c.name, func(t *testing.T) {
+			t.Parallel()
+			p := &Properties{HTTPProxyConfig: c.config}
+			if actual := p.IsHTTPProxyEnabled(); actual != c.expected {
+				t.Errorf("expected IsHTTPProxyEnabled to return %t, but got %t", c.expected, actual)
+			}
+		})
+	}
+}
+
+func TestProperties_GetHTTPProxyNoProxy(t *testing.T) {
+	p := &Properties{
+		OrchestratorProfile: &OrchestratorProfile{
+			OrchestratorType: Kubernetes,
+			KubernetesConfig: &KubernetesConfig{
+				ClusterSubnet: "10.244.0.0/16,fc00::/48",
+				ServiceCIDR:   "10.0.0.0/16",
+			},
+		},
+		MasterProfile: &MasterProfile{
+			VnetCidr: "10.239.0.0/16",
+		},
+		HTTPProxyConfig: &HTTPProxyConfig{
+			HTTPProxy: "http://proxy:3128",
+			NoProxy:   []string{"contoso.com", " 10.0.0.0/16 ", "localhost"},
+		},
+	}
+	expected := []string{
+		"localhost",
+		"127.0.0.1",
+		"169.254.169.254",
+		"168.63.129.16",
+		"10.239.0.0/16",
+		"10.244.0.0/16",
+		"fc00::/48",
+		"10.0.0.0/16",
+		"kubernetes.default",
+		".svc",
+		".cluster.local",
+		".internal.cloudapp.net",
+		"contoso.com",
+	}
+	if actual := p.GetHTTPProxyNoProxy(); !reflect.DeepEqual(expected, actual) {
+		t.Errorf("expected noProxy list %v, but got %v", expected, actual)
+	}
+
+	p.MasterProfile.VnetCidr = ""
+	p.HTTPProxyConfig = nil
+	actual := p.GetHTTPProxyNoProxy()
+	if actual[4] != DefaultVNETCIDR {
+		t.Errorf("expected the default VNET CIDR %s in the noProxy list, but got %s", DefaultVNETCIDR, actual[4])
+	}
+
+	p.MasterProfile.VnetSubnetID = "/subscriptions/SUB_ID/resourceGroups/RG_NAME/providers/Microsoft.Network/virtualNetworks/VNET_NAME/subnets/SUBNET_NAME"
+	p.HTTPProxyConfig = &HTTPProxyConfig{NoProxy: []string{"10.100.0.0/24"}}
+	for _, entry := range p.GetHTTPProxyNoProxy() {
+		if entry == DefaultVNETCIDR {
+			t.Errorf("expected no default VNET CIDR in the noProxy list of a custom VNET, but got %s", entry)
+		}
+	}
+}
+
 func TestIsFeatureEnabled(t *testing.T) {
 	tests := []struct {
 		name     string
@@ -4160,6 +4493,14 @@ func TestIsFeatureEnabled(t *testing.T) {
 			},
 			expected: false,
 		},
+		{
+			name:    "Enabled dual-stack feature",
+			feature: "EnableIPv6DualStack",
+			flags: &FeatureFlags{
+				EnableIPv6DualStack: true,
+			},
+			expected: true,
+		},
 		{
 			name:    "Non-existent feature",
 			feature: "Foo",
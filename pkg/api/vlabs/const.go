@@ -54,6 +54,10 @@ const (
 	MinDiskSizeGB = 1
 	// MaxDiskSizeGB specifies the maximum attached disk size
 	MaxDiskSizeGB = 1023
+	// DefaultLinuxOSDiskSizeGB is the OS disk size of the default Linux images
+	DefaultLinuxOSDiskSizeGB = 30
+	// DefaultWindowsOSDiskSizeGB is the OS disk size of the default Windows images
+	DefaultWindowsOSDiskSizeGB = 128
 	// MinIPAddressCount specifies the minimum number of IP addresses per network interface
 	MinIPAddressCount = 1
 	// MaxIPAddressCount specifies the maximum number of IP addresses per network interface
@@ -76,6 +80,14 @@ const (
 	ManagedDisks = "ManagedDisks"
 )
 
+// OS disk types
+const (
+	// OSDiskTypeManaged means that the nodes use a persistent managed OS disk
+	OSDiskTypeManaged = "Managed"
+	// OSDiskTypeEphemeral means that the nodes use an OS disk on the local VM cache, which is lost on reimage or deallocation
+	OSDiskTypeEphemeral = "Ephemeral"
+)
+
 // Supported container runtimes
 const (
 	Docker          = "docker"
@@ -95,6 +107,14 @@ var (
 	// ContainerRuntimeValues holds the valid values for container runtimes
 	ContainerRuntimeValues = [...]string{"", Docker, ClearContainers, KataContainers, Containerd}
 
+	// ComponentOverrideNames holds the Kubernetes component names that can be set in componentOverrides
+	ComponentOverrideNames = [...]string{"aci-connector", "addonmanager", "addonresizer", "azure-cni-networkmonitor",
+		"backoffduration", "backoffexponent", "backoffjitter", "backoffretries", "ccm", "cluster-autoscaler",
+		"container-monitoring", "coredns", "dnsmasq", "etcd", "exechealthz", "gchighthreshold", "gclowthreshold", "heapster",
+		"hyperkube", "k8s-dns-sidecar", "kube-dns", "kube-proxy", "kubernetes-dashboard", "metrics-server", "nodegraceperiod",
+		"nodestatusfreq", "nvidia-device-plugin", "pause", "podeviction", "ratelimitbucket", "ratelimitqps",
+		"rescheduler", "routeperiod", "tiller", "windowszip"}
+
 	// DistroValues holds the valid values for OS distros
 	DistroValues = []Distro{"", Ubuntu, Ubuntu1804, RHEL, CoreOS, AKS, AKS1804, ACC1604}
 
@@ -102,6 +122,29 @@ var (
 	DependenciesLocationValues = []DependenciesLocation{"", AzureStackDependenciesLocationPublic, AzureStackDependenciesLocationChina, AzureStackDependenciesLocationGerman, AzureStackDependenciesLocationUSGovernment}
 )
 
+// Network security group configuration
+const (
+	// NetworkSecurityRuleMinPriority is the lowest priority a custom network security rule may use, lower values are reserved for built-in rules
+	NetworkSecurityRuleMinPriority = 200
+	// NetworkSecurityRuleMaxPriority is the highest priority a custom network security rule may use, higher values are reserved for built-in rules
+	NetworkSecurityRuleMaxPriority = 4094
+)
+
+// Agent egress outbound rule limits
+const (
+	// MaxEgressOutboundIPCount is the maximum number of public IP addresses aks-engine creates for the agent load balancer outbound rule
+	MaxEgressOutboundIPCount = 100
+	// MaxEgressAllocatedOutboundPorts is the maximum number of SNAT ports an outbound rule may allocate per VM
+	MaxEgressAllocatedOutboundPorts = 64000
+	// MinEgressIdleTimeoutInMinutes is the minimum TCP idle timeout of an outbound rule
+	MinEgressIdleTimeoutInMinutes = 4
+	// MaxEgressIdleTimeoutInMinutes is the maximum TCP idle timeout of an outbound rule
+	MaxEgressIdleTimeoutInMinutes = 120
+)
+
+// ReservedNetworkSecurityRuleNames are the names of the built-in network security rules, custom rules may not use them
+var ReservedNetworkSecurityRuleNames = []string{"allow_ssh", "allow_kube_tls", "allow_rdp", "allow_vnet", "block_outbound", "allow_vnet_inbound", "allow_vnet_outbound"}
+
 // Kubernetes configuration
 const (
 	// KubernetesMinMaxPods is the minimum valid value for MaxPods, necessary for running kube-system pods
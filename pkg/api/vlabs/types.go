@@ -50,12 +50,52 @@ type Properties struct {
 	AADProfile              *AADProfile              `json:"aadProfile,omitempty"`
 	FeatureFlags            *FeatureFlags            `json:"featureFlags,omitempty"`
 	CustomCloudProfile      *CustomCloudProfile      `json:"customCloudProfile,omitempty"`
+	LintProfile             *LintProfile             `json:"lintProfile,omitempty"`
+	NetworkSecurityProfile  *NetworkSecurityProfile  `json:"networkSecurityProfile,omitempty"`
+	HTTPProxyConfig         *HTTPProxyConfig         `json:"httpProxyConfig,omitempty"`
 }
 
 // FeatureFlags defines feature-flag restricted functionality
 type FeatureFlags struct {
 	EnableCSERunInBackground bool `json:"enableCSERunInBackground,omitempty"`
 	BlockOutboundInternet    bool `json:"blockOutboundInternet,omitempty"`
+	EnableIPv6DualStack      bool `json:"enableIPv6DualStack,omitempty"`
+}
+
+// LintProfile configures the best-practice checks run by `aks-engine lint`
+type LintProfile struct {
+	SuppressedRules []string `json:"suppressedRules,omitempty"`
+}
+
+// NetworkSecurityProfile configures the rules of the network security group in front of the cluster
+type NetworkSecurityProfile struct {
+	DisableSSH                     bool                  `json:"disableSSH,omitempty"`
+	SSHSourceAddressPrefixes       []string              `json:"sshSourceAddressPrefixes,omitempty"`
+	APIServerSourceAddressPrefixes []string              `json:"apiServerSourceAddressPrefixes,omitempty"`
+	RDPSourceAddressPrefixes       []string              `json:"rdpSourceAddressPrefixes,omitempty"`
+	SecurityRules                  []NetworkSecurityRule `json:"securityRules,omitempty"`
+}
+
+// HTTPProxyConfig configures the HTTP/HTTPS proxy used by cluster nodes for outbound traffic
+type HTTPProxyConfig struct {
+	HTTPProxy  string   `json:"httpProxy,omitempty"`
+	HTTPSProxy string   `json:"httpsProxy,omitempty"`
+	NoProxy    []string `json:"noProxy,omitempty"`
+	TrustedCA  string   `json:"trustedCa,omitempty"`
+}
+
+// NetworkSecurityRule is a custom rule added to the network security group
+type NetworkSecurityRule struct {
+	Name                       string   `json:"name"`
+	Description                string   `json:"description,omitempty"`
+	Priority                   int32    `json:"priority"`
+	Direction                  string   `json:"direction"`
+	Access                     string   `json:"access"`
+	Protocol                   string   `json:"protocol"`
+	SourceAddressPrefixes      []string `json:"sourceAddressPrefixes,omitempty"`
+	SourcePortRange            string   `json:"sourcePortRange,omitempty"`
+	DestinationAddressPrefixes []string `json:"destinationAddressPrefixes,omitempty"`
+	DestinationPortRange       string   `json:"destinationPortRange,omitempty"`
 }
 
 // ServicePrincipalProfile contains the client and secret used by the cluster for Azure Resource CRUD
@@ -67,6 +107,8 @@ type ServicePrincipalProfile struct {
 	Secret            string             `json:"secret,omitempty"`
 	ObjectID          string             `json:"objectId,omitempty"`
 	KeyvaultSecretRef *KeyvaultSecretRef `json:"keyvaultSecretRef,omitempty"`
+	// ApplicationObjectID is the object ID of the application of the service principal, set when aks-engine created it
+	ApplicationObjectID string `json:"applicationObjectId,omitempty"`
 }
 
 // KeyvaultSecretRef is a reference to a secret in a keyvault.
@@ -255,6 +297,15 @@ func (a *KubernetesAddon) IsEnabled(ifNil bool) bool {
 type PrivateCluster struct {
 	Enabled        *bool                  `json:"enabled,omitempty"`
 	JumpboxProfile *PrivateJumpboxProfile `json:"jumpboxProfile,omitempty"`
+	PrivateDNSZone *PrivateDNSZone        `json:"privateDnsZone,omitempty"`
+}
+
+// PrivateDNSZone configures the Azure Private DNS zone that resolves the API server FQDN of a private cluster
+type PrivateDNSZone struct {
+	Enabled           *bool    `json:"enabled,omitempty"`
+	Name              string   `json:"name,omitempty"`
+	ID                string   `json:"id,omitempty"`
+	VirtualNetworkIDs []string `json:"virtualNetworkIDs,omitempty"`
 }
 
 // PrivateJumpboxProfile represents a jumpbox definition
@@ -276,6 +327,26 @@ const (
 	KubeProxyModeIPVS     KubeProxyMode = "ipvs"
 )
 
+// EgressType is for loadBalancer and userDefinedRouting
+type EgressType string
+
+// Agent egress goes either through the agent load balancer or through user-defined routes
+const (
+	EgressTypeLoadBalancer       EgressType = "loadBalancer"
+	EgressTypeUserDefinedRouting EgressType = "userDefinedRouting"
+)
+
+// EgressProfile configures the outbound connectivity of the agent nodes
+type EgressProfile struct {
+	Type                   EgressType `json:"type,omitempty"`
+	OutboundIPCount        int        `json:"outboundIPCount,omitempty"`
+	OutboundIPPrefixID     string     `json:"outboundIPPrefixID,omitempty"`
+	AllocatedOutboundPorts int        `json:"allocatedOutboundPorts,omitempty"`
+	IdleTimeoutInMinutes   int        `json:"idleTimeoutInMinutes,omitempty"`
+	EnableTCPReset         *bool      `json:"enableTCPReset,omitempty"`
+	FirewallIPAddress      string     `json:"firewallIPAddress,omitempty"`
+}
+
 // KubernetesConfig contains the Kubernetes config structure, containing
 // Kubernetes specific configuration
 type KubernetesConfig struct {
@@ -319,6 +390,7 @@ type KubernetesConfig struct {
 	APIServerConfig                 map[string]string `json:"apiServerConfig,omitempty"`
 	SchedulerConfig                 map[string]string `json:"schedulerConfig,omitempty"`
 	PodSecurityPolicyConfig         map[string]string `json:"podSecurityPolicyConfig,omitempty"`
+	ComponentOverrides              map[string]string `json:"componentOverrides,omitempty"`
 	CloudProviderBackoff            *bool             `json:"cloudProviderBackoff,omitempty"`
 	CloudProviderBackoffRetries     int               `json:"cloudProviderBackoffRetries,omitempty"`
 	CloudProviderBackoffJitter      float64           `json:"cloudProviderBackoffJitter,omitempty"`
@@ -329,9 +401,13 @@ type KubernetesConfig struct {
 	CloudProviderRateLimitBucket    int               `json:"cloudProviderRateLimitBucket,omitempty"`
 	LoadBalancerSku                 string            `json:"loadBalancerSku,omitempty"`
 	ExcludeMasterFromStandardLB     *bool             `json:"excludeMasterFromStandardLB,omitempty"`
+	EgressProfile                   *EgressProfile    `json:"egressProfile,omitempty"`
 	AzureCNIVersion                 string            `json:"azureCNIVersion,omitempty"`
 	AzureCNIURLLinux                string            `json:"azureCNIURLLinux,omitempty"`
 	AzureCNIURLWindows              string            `json:"azureCNIURLWindows,omitempty"`
+	CNIPluginsURL                   string            `json:"cniPluginsURL,omitempty"`
+	EtcdDownloadURLBase             string            `json:"etcdDownloadURLBase,omitempty"`
+	ContainerdDownloadURLBase       string            `json:"containerdDownloadURLBase,omitempty"`
 	KeyVaultSku                     string            `json:"keyVaultSku,omitempty"`
 	MaximumLoadBalancerRuleCount    int               `json:"maximumLoadBalancerRuleCount,omitempty"`
 	ProxyMode                       KubeProxyMode     `json:"kubeProxyMode,omitempty"`
@@ -380,6 +456,7 @@ type MasterProfile struct {
 	FirstConsecutiveStaticIP string            `json:"firstConsecutiveStaticIP,omitempty"`
 	IPAddressCount           int               `json:"ipAddressCount,omitempty" validate:"min=0,max=256"`
 	StorageProfile           string            `json:"storageProfile,omitempty" validate:"eq=StorageAccount|eq=ManagedDisks|len=0"`
+	DiskEncryptionSetID      string            `json:"diskEncryptionSetID,omitempty"`
 	HTTPSourceAddressPrefix  string            `json:"HTTPSourceAddressPrefix,omitempty"`
 	OAuthEnabled             bool              `json:"oauthEnabled"`
 	PreProvisionExtension    *Extension        `json:"preProvisionExtension"`
@@ -393,6 +470,10 @@ type MasterProfile struct {
 	AvailabilityZones        []string          `json:"availabilityZones,omitempty"`
 	SinglePlacementGroup     *bool             `json:"singlePlacementGroup,omitempty"`
 
+	ProximityPlacementGroupID     string `json:"proximityPlacementGroupID,omitempty"`
+	EnableProximityPlacementGroup *bool  `json:"enableProximityPlacementGroup,omitempty"`
+	HostGroupID                   string `json:"hostGroupID,omitempty"`
+
 	// subnet is internal
 	subnet string
 
@@ -421,6 +502,15 @@ type ExtensionProfile struct {
 	// This is only needed for preprovision extensions and it needs to be a bash script
 	Script   string `json:"script,omitempty"`
 	URLQuery string `json:"urlQuery,omitempty"`
+	// LocalPath is a local directory holding the extension under extensions/<name>/<version>, the way RootURL does
+	LocalPath string `json:"localPath,omitempty"`
+	// OCIReference is an OCI artifact holding the files of the extension as layers titled with their file names
+	OCIReference string `json:"ociReference,omitempty"`
+	// Upload uploads the files of a local or OCI extension to a storage account when deploying, instead of embedding
+	// them into the template
+	Upload bool `json:"upload,omitempty"`
+	// Checksums are the sha256 digests of the files of the extension by file name, verified before the files are used
+	Checksums map[string]string `json:"checksums,omitempty"`
 }
 
 // Extension represents an extension definition in the master or agentPoolProfile
@@ -436,6 +526,7 @@ type AgentPoolProfile struct {
 	Count                               int                  `json:"count" validate:"required,min=1,max=100"`
 	VMSize                              string               `json:"vmSize" validate:"required"`
 	OSDiskSizeGB                        int                  `json:"osDiskSizeGB,omitempty" validate:"min=0,max=1023"`
+	OSDiskType                          string               `json:"osDiskType,omitempty"`
 	DNSPrefix                           string               `json:"dnsPrefix,omitempty"`
 	OSType                              OSType               `json:"osType,omitempty"`
 	Ports                               []int                `json:"ports,omitempty" validate:"dive,min=1,max=65535"`
@@ -444,6 +535,7 @@ type AgentPoolProfile struct {
 	ScaleSetEvictionPolicy              string               `json:"scaleSetEvictionPolicy,omitempty" validate:"eq=Delete|eq=Deallocate|len=0"`
 	StorageProfile                      string               `json:"storageProfile" validate:"eq=StorageAccount|eq=ManagedDisks|len=0"`
 	DiskSizesGB                         []int                `json:"diskSizesGB,omitempty" validate:"max=4,dive,min=1,max=1023"`
+	DiskEncryptionSetID                 string               `json:"diskEncryptionSetID,omitempty"`
 	VnetSubnetID                        string               `json:"vnetSubnetID,omitempty"`
 	IPAddressCount                      int                  `json:"ipAddressCount,omitempty" validate:"min=0,max=256"`
 	Distro                              Distro               `json:"distro,omitempty"`
@@ -459,11 +551,22 @@ type AgentPoolProfile struct {
 
 	FQDN                   string            `json:"fqdn"`
 	CustomNodeLabels       map[string]string `json:"customNodeLabels,omitempty"`
+	Taints                 []string          `json:"taints,omitempty"`
 	PreProvisionExtension  *Extension        `json:"preProvisionExtension"`
 	Extensions             []Extension       `json:"extensions"`
 	SinglePlacementGroup   *bool             `json:"singlePlacementGroup,omitempty"`
 	AvailabilityZones      []string          `json:"availabilityZones,omitempty"`
 	EnableVMSSNodePublicIP *bool             `json:"enableVMSSNodePublicIP,omitempty"`
+
+	NetworkSecurityGroupID              string `json:"networkSecurityGroupID,omitempty"`
+	EnableDedicatedNetworkSecurityGroup *bool  `json:"enableDedicatedNetworkSecurityGroup,omitempty"`
+	RouteTableID                        string `json:"routeTableID,omitempty"`
+
+	ProximityPlacementGroupID     string `json:"proximityPlacementGroupID,omitempty"`
+	EnableProximityPlacementGroup *bool  `json:"enableProximityPlacementGroup,omitempty"`
+	HostGroupID                   string `json:"hostGroupID,omitempty"`
+
+	OrchestratorVersion string `json:"orchestratorVersion,omitempty"`
 }
 
 // AgentPoolProfileRole represents an agent role
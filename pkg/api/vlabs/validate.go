@@ -5,10 +5,12 @@ package vlabs
 
 import (
 	"encoding/base64"
+	"encoding/pem"
 	"fmt"
 	"net"
 	"net/url"
 	"regexp"
+	"strconv"
 	"strings"
 	"time"
 
@@ -28,6 +30,32 @@ var (
 	keyvaultIDRegex *regexp.Regexp
 	labelValueRegex *regexp.Regexp
 	labelKeyRegex   *regexp.Regexp
+	// networkSecurityRuleNameRegex matches the names Azure accepts for network security rules
+	networkSecurityRuleNameRegex *regexp.Regexp
+	// serviceTagRegex matches Azure service tags such as "VirtualNetwork" or "Storage.WestUS"
+	serviceTagRegex *regexp.Regexp
+	// publicIPPrefixIDRegex matches the resource ID of an existing public IP prefix
+	publicIPPrefixIDRegex *regexp.Regexp
+	// networkSecurityGroupIDRegex matches the resource ID of an existing network security group
+	networkSecurityGroupIDRegex *regexp.Regexp
+	// routeTableIDRegex matches the resource ID of an existing route table
+	routeTableIDRegex *regexp.Regexp
+	// privateDNSZoneIDRegex matches the resource ID of an existing private DNS zone
+	privateDNSZoneIDRegex *regexp.Regexp
+	// privateDNSZoneNameRegex matches a DNS zone name with at least two labels
+	privateDNSZoneNameRegex *regexp.Regexp
+	// virtualNetworkIDRegex matches the resource ID of an existing virtual network
+	virtualNetworkIDRegex *regexp.Regexp
+	// diskEncryptionSetIDRegex matches the resource ID of an existing disk encryption set
+	diskEncryptionSetIDRegex *regexp.Regexp
+	// proximityPlacementGroupIDRegex matches the resource ID of an existing proximity placement group
+	proximityPlacementGroupIDRegex *regexp.Regexp
+	// hostGroupIDRegex matches the resource ID of an existing dedicated host group
+	hostGroupIDRegex *regexp.Regexp
+	// ociReferenceRegex matches an OCI artifact reference with a registry host, a repository and a tag or a digest
+	ociReferenceRegex *regexp.Regexp
+	// sha256Regex matches a hex-encoded sha256 digest
+	sha256Regex *regexp.Regexp
 	// Any version has to be mirrored in https://acs-mirror.azureedge.net/github-coreos/etcd-v[Version]-linux-amd64.tar.gz
 	etcdValidVersions = [...]string{"2.2.5", "2.3.0", "2.3.1", "2.3.2", "2.3.3", "2.3.4", "2.3.5", "2.3.6", "2.3.7", "2.3.8",
 		"3.0.0", "3.0.1", "3.0.2", "3.0.3", "3.0.4", "3.0.5", "3.0.6", "3.0.7", "3.0.8", "3.0.9", "3.0.10", "3.0.11", "3.0.12", "3.0.13", "3.0.14", "3.0.15", "3.0.16", "3.0.17",
@@ -95,6 +123,8 @@ const (
 	labelKeyPrefixMaxLength = 253
 	labelValueFormat        = "^([A-Za-z0-9][-A-Za-z0-9_.]{0,61})?[A-Za-z0-9]$"
 	labelKeyFormat          = "^(([a-zA-Z0-9-]+[.])*[a-zA-Z0-9-]+[/])?([A-Za-z0-9][-A-Za-z0-9_.]{0,61})?[A-Za-z0-9]$"
+	// httpProxyInvalidChars are the characters that cannot be safely rendered into the node proxy configuration
+	httpProxyInvalidChars = " \t\r\n'\"`$|&;\\"
 )
 
 type k8sNetworkConfig struct {
@@ -107,6 +137,19 @@ func init() {
 	keyvaultIDRegex = regexp.MustCompile(`^/subscriptions/\S+/resourceGroups/\S+/providers/Microsoft.KeyVault/vaults/[^/\s]+$`)
 	labelValueRegex = regexp.MustCompile(labelValueFormat)
 	labelKeyRegex = regexp.MustCompile(labelKeyFormat)
+	networkSecurityRuleNameRegex = regexp.MustCompile(`^[a-zA-Z0-9]([a-zA-Z0-9._-]{0,78}[a-zA-Z0-9_])?$`)
+	serviceTagRegex = regexp.MustCompile(`^[a-zA-Z][a-zA-Z0-9]*(\.[a-zA-Z0-9]+)?$`)
+	publicIPPrefixIDRegex = regexp.MustCompile(`(?i)^/subscriptions/[^/\s]+/resourceGroups/[^/\s]+/providers/Microsoft.Network/publicIPPrefixes/[^/\s]+$`)
+	networkSecurityGroupIDRegex = regexp.MustCompile(`(?i)^/subscriptions/[^/\s]+/resourceGroups/[^/\s]+/providers/Microsoft.Network/networkSecurityGroups/[^/\s]+$`)
+	routeTableIDRegex = regexp.MustCompile(`(?i)^/subscriptions/[^/\s]+/resourceGroups/[^/\s]+/providers/Microsoft.Network/routeTables/[^/\s]+$`)
+	privateDNSZoneIDRegex = regexp.MustCompile(`(?i)^/subscriptions/[^/\s]+/resourceGroups/[^/\s]+/providers/Microsoft.Network/privateDnsZones/[^/\s]+$`)
+	privateDNSZoneNameRegex = regexp.MustCompile(`(?i)^([a-z0-9]([a-z0-9-]{0,61}[a-z0-9])?\.)+[a-z0-9]([a-z0-9-]{0,61}[a-z0-9])?$`)
+	virtualNetworkIDRegex = regexp.MustCompile(`(?i)^/subscriptions/[^/\s]+/resourceGroups/[^/\s]+/providers/Microsoft.Network/virtualNetworks/[^/\s]+$`)
+	diskEncryptionSetIDRegex = regexp.MustCompile(`(?i)^/subscriptions/[^/\s]+/resourceGroups/[^/\s]+/providers/Microsoft.Compute/diskEncryptionSets/[^/\s]+$`)
+	proximityPlacementGroupIDRegex = regexp.MustCompile(`(?i)^/subscriptions/[^/\s]+/resourceGroups/[^/\s]+/providers/Microsoft.Compute/proximityPlacementGroups/[^/\s]+$`)
+	hostGroupIDRegex = regexp.MustCompile(`(?i)^/subscriptions/[^/\s]+/resourceGroups/[^/\s]+/providers/Microsoft.Compute/hostGroups/[^/\s]+$`)
+	ociReferenceRegex = regexp.MustCompile(`^[a-zA-Z0-9.-]+(:[0-9]+)?/[a-z0-9]+([._/-][a-z0-9]+)*(:[a-zA-Z0-9_][a-zA-Z0-9_.-]{0,127}|@sha256:[a-f0-9]{64})$`)
+	sha256Regex = regexp.MustCompile(`^[a-fA-F0-9]{64}$`)
 }
 
 // Validate implements APIObject
@@ -149,6 +192,34 @@ func (a *Properties) validate(isUpdate bool) error {
 	if e := a.validateAADProfile(); e != nil {
 		return e
 	}
+
+	if e := a.validateNetworkSecurityProfile(); e != nil {
+		return e
+	}
+
+	if e := a.validateEgressProfile(); e != nil {
+		return e
+	}
+
+	if e := a.validateAgentPoolNetworkIsolation(); e != nil {
+		return e
+	}
+
+	if e := a.validatePlacement(); e != nil {
+		return e
+	}
+
+	if e := a.validateAgentPoolOrchestratorVersions(); e != nil {
+		return e
+	}
+
+	if e := a.validateHTTPProxyConfig(); e != nil {
+		return e
+	}
+
+	if e := a.validateIPv6DualStack(); e != nil {
+		return e
+	}
 	return nil
 }
 
@@ -365,6 +436,10 @@ func (a *Properties) validateMasterProfile(isUpdate bool) error {
 		return errors.New("singlePlacementGroup is only supported with VirtualMachineScaleSets")
 	}
 
+	if e := validateDiskEncryptionSetID(m.DiskEncryptionSetID, "masterProfile", m.StorageProfile, a.OrchestratorProfile.OrchestratorType, a.IsAzureStackCloud()); e != nil {
+		return e
+	}
+
 	distroValues := DistroValues
 	if isUpdate {
 		distroValues = append(distroValues, AKSDockerEngine)
@@ -437,6 +512,21 @@ func (a *Properties) validateAgentPoolProfiles(isUpdate bool) error {
 			return e
 		}
 
+		if e := agentPoolProfile.validateTaints(a.OrchestratorProfile); e != nil {
+			return e
+		}
+
+		if e := agentPoolProfile.validateOSDiskType(a.OrchestratorProfile.OrchestratorType, a.IsAzureStackCloud()); e != nil {
+			return e
+		}
+
+		if e := validateDiskEncryptionSetID(agentPoolProfile.DiskEncryptionSetID, fmt.Sprintf("agent pool '%s'", agentPoolProfile.Name), agentPoolProfile.StorageProfile, a.OrchestratorProfile.OrchestratorType, a.IsAzureStackCloud()); e != nil {
+			return e
+		}
+		if agentPoolProfile.DiskEncryptionSetID != "" && agentPoolProfile.OSDiskType == OSDiskTypeEphemeral {
+			return errors.Errorf("agent pool '%s' uses an ephemeral OS disk, which cannot be encrypted with diskEncryptionSetID", agentPoolProfile.Name)
+		}
+
 		if agentPoolProfile.AvailabilityProfile == VirtualMachineScaleSets {
 			e := validateVMSS(a.OrchestratorProfile, isUpdate, agentPoolProfile.StorageProfile)
 			if e != nil {
@@ -594,6 +684,37 @@ func (a *Properties) validateExtensions() error {
 				return errors.Errorf("Extension %s's keyvault secret reference is of incorrect format", extension.Name)
 			}
 		}
+		if e := extension.validateSource(); e != nil {
+			return e
+		}
+	}
+	return nil
+}
+
+// validateSource validates where the files of the extension come from and their checksums
+func (e *ExtensionProfile) validateSource() error {
+	sources := 0
+	for _, source := range []string{e.RootURL, e.LocalPath, e.OCIReference} {
+		if source != "" {
+			sources++
+		}
+	}
+	if sources > 1 {
+		return errors.Errorf("Extension %s must have only one of rootURL, localPath and ociReference", e.Name)
+	}
+	if e.Upload && e.LocalPath == "" && e.OCIReference == "" {
+		return errors.Errorf("Extension %s can only be uploaded from a localPath or an ociReference", e.Name)
+	}
+	if e.OCIReference != "" && !ociReferenceRegex.MatchString(e.OCIReference) {
+		return errors.Errorf("Extension %s has an invalid ociReference %s, expected <registry>/<repository>:<tag> or <registry>/<repository>@sha256:<digest>", e.Name, e.OCIReference)
+	}
+	if e.LocalPath != "" && len(e.Checksums) == 0 {
+		return errors.Errorf("Extension %s from a localPath must have the checksums of its files", e.Name)
+	}
+	for fileName, checksum := range e.Checksums {
+		if !sha256Regex.MatchString(checksum) {
+			return errors.Errorf("Extension %s has an invalid sha256 checksum for %s: %s", e.Name, fileName, checksum)
+		}
 	}
 	return nil
 }
@@ -741,6 +862,447 @@ func (a *Properties) validateAADProfile() error {
 	return nil
 }
 
+func (a *Properties) validateNetworkSecurityProfile() error {
+	profile := a.NetworkSecurityProfile
+	if profile == nil {
+		return nil
+	}
+	if a.OrchestratorProfile.OrchestratorType != Kubernetes {
+		return errors.Errorf("'networkSecurityProfile' is only supported by orchestrator '%v'", Kubernetes)
+	}
+	if profile.DisableSSH && len(profile.SSHSourceAddressPrefixes) > 0 {
+		return errors.New("networkSecurityProfile.sshSourceAddressPrefixes cannot be set when networkSecurityProfile.disableSSH is true")
+	}
+	if k := a.OrchestratorProfile.KubernetesConfig; profile.DisableSSH && k != nil && k.PrivateCluster != nil && to.Bool(k.PrivateCluster.Enabled) && k.PrivateCluster.JumpboxProfile != nil {
+		return errors.New("networkSecurityProfile.disableSSH cannot be true for a private cluster with a jumpboxProfile, the jumpbox is only reachable over SSH")
+	}
+	if len(profile.RDPSourceAddressPrefixes) > 0 && !a.HasWindows() {
+		return errors.New("networkSecurityProfile.rdpSourceAddressPrefixes can only be set for clusters with Windows agent pools")
+	}
+	for field, prefixes := range map[string][]string{
+		"sshSourceAddressPrefixes":       profile.SSHSourceAddressPrefixes,
+		"apiServerSourceAddressPrefixes": profile.APIServerSourceAddressPrefixes,
+		"rdpSourceAddressPrefixes":       profile.RDPSourceAddressPrefixes,
+	} {
+		if err := validateNetworkSecurityAddressPrefixes(prefixes); err != nil {
+			return errors.Wrapf(err, "networkSecurityProfile.%s is invalid", field)
+		}
+	}
+
+	reserved := map[string]bool{}
+	for _, name := range ReservedNetworkSecurityRuleNames {
+		reserved[name] = true
+	}
+	names := map[string]bool{}
+	priorities := map[string]string{}
+	for _, rule := range profile.SecurityRules {
+		if !networkSecurityRuleNameRegex.MatchString(rule.Name) {
+			return errors.Errorf("networkSecurityProfile.securityRules name '%s' is invalid, it must be 1-80 characters of letters, numbers, '.', '-' and '_', begin with a letter or number, and end with a letter, number or '_'", rule.Name)
+		}
+		if reserved[rule.Name] {
+			return errors.Errorf("networkSecurityProfile.securityRules name '%s' is reserved for a built-in rule", rule.Name)
+		}
+		if names[rule.Name] {
+			return errors.Errorf("networkSecurityProfile.securityRules name '%s' is used more than once", rule.Name)
+		}
+		names[rule.Name] = true
+		if rule.Priority < NetworkSecurityRuleMinPriority || rule.Priority > NetworkSecurityRuleMaxPriority {
+			return errors.Errorf("networkSecurityProfile.securityRules '%s' has priority %d, custom rules must use a priority between %d and %d", rule.Name, rule.Priority, NetworkSecurityRuleMinPriority, NetworkSecurityRuleMaxPriority)
+		}
+		if rule.Direction != "Inbound" && rule.Direction != "Outbound" {
+			return errors.Errorf("networkSecurityProfile.securityRules '%s' has direction '%s', valid values are 'Inbound' and 'Outbound'", rule.Name, rule.Direction)
+		}
+		key := fmt.Sprintf("%s/%d", rule.Direction, rule.Priority)
+		if other, ok := priorities[key]; ok {
+			return errors.Errorf("networkSecurityProfile.securityRules '%s' and '%s' have the same %s priority %d", other, rule.Name, strings.ToLower(rule.Direction), rule.Priority)
+		}
+		priorities[key] = rule.Name
+		if rule.Access != "Allow" && rule.Access != "Deny" {
+			return errors.Errorf("networkSecurityProfile.securityRules '%s' has access '%s', valid values are 'Allow' and 'Deny'", rule.Name, rule.Access)
+		}
+		if rule.Protocol != "Tcp" && rule.Protocol != "Udp" && rule.Protocol != "*" {
+			return errors.Errorf("networkSecurityProfile.securityRules '%s' has protocol '%s', valid values are 'Tcp', 'Udp' and '*'", rule.Name, rule.Protocol)
+		}
+		for _, portRange := range []string{rule.SourcePortRange, rule.DestinationPortRange} {
+			if err := validateNetworkSecurityPortRange(portRange); err != nil {
+				return errors.Wrapf(err, "networkSecurityProfile.securityRules '%s' is invalid", rule.Name)
+			}
+		}
+		for _, prefixes := range [][]string{rule.SourceAddressPrefixes, rule.DestinationAddressPrefixes} {
+			if err := validateNetworkSecurityAddressPrefixes(prefixes); err != nil {
+				return errors.Wrapf(err, "networkSecurityProfile.securityRules '%s' is invalid", rule.Name)
+			}
+		}
+	}
+	return nil
+}
+
+// validateNetworkSecurityAddressPrefixes accepts IP addresses, CIDRs, service tags such as "VirtualNetwork" and "*"
+func validateNetworkSecurityAddressPrefixes(prefixes []string) error {
+	for _, prefix := range prefixes {
+		if prefix == "*" || net.ParseIP(prefix) != nil || serviceTagRegex.MatchString(prefix) {
+			continue
+		}
+		if _, _, err := net.ParseCIDR(prefix); err != nil {
+			return errors.Errorf("address prefix '%s' is not an IP address, a CIDR, a service tag or '*'", prefix)
+		}
+	}
+	return nil
+}
+
+// validateNetworkSecurityPortRange accepts an empty value, "*", a port, or a range of ports such as "8000-8080"
+func validateNetworkSecurityPortRange(portRange string) error {
+	if portRange == "" || portRange == "*" {
+		return nil
+	}
+	bounds := strings.SplitN(portRange, "-", 2)
+	var ports []int
+	for _, bound := range bounds {
+		port, err := strconv.Atoi(bound)
+		if err != nil || port < 0 || port > 65535 {
+			return errors.Errorf("port range '%s' is invalid", portRange)
+		}
+		ports = append(ports, port)
+	}
+	if len(ports) == 2 && ports[0] > ports[1] {
+		return errors.Errorf("port range '%s' is invalid", portRange)
+	}
+	return nil
+}
+
+func (a *Properties) validateEgressProfile() error {
+	o := a.OrchestratorProfile
+	if o == nil || o.KubernetesConfig == nil || o.KubernetesConfig.EgressProfile == nil {
+		return nil
+	}
+	if o.OrchestratorType != Kubernetes {
+		return errors.Errorf("egressProfile is not supported with orchestratorType %s", o.OrchestratorType)
+	}
+
+	k := o.KubernetesConfig
+	e := k.EgressProfile
+	switch e.Type {
+	case "", EgressTypeLoadBalancer:
+		if a.IsAzureStackCloud() {
+			return errors.Errorf("egressProfile type %s is not supported on Azure Stack", EgressTypeLoadBalancer)
+		}
+		// availability zones default loadBalancerSku to Standard
+		if k.LoadBalancerSku != "Standard" && !(k.LoadBalancerSku == "" && a.HasAvailabilityZones()) {
+			return errors.Errorf("egressProfile type %s requires loadBalancerSku Standard, found loadBalancerSku '%s'", EgressTypeLoadBalancer, k.LoadBalancerSku)
+		}
+		if e.FirewallIPAddress != "" {
+			return errors.Errorf("egressProfile.firewallIPAddress can only be set with egressProfile type %s", EgressTypeUserDefinedRouting)
+		}
+		if e.OutboundIPCount != 0 && e.OutboundIPPrefixID != "" {
+			return errors.New("egressProfile.outboundIPCount and egressProfile.outboundIPPrefixID are mutually exclusive")
+		}
+		if e.OutboundIPCount < 0 || e.OutboundIPCount > MaxEgressOutboundIPCount {
+			return errors.Errorf("egressProfile.outboundIPCount must be between 1 and %d, found %d", MaxEgressOutboundIPCount, e.OutboundIPCount)
+		}
+		if e.OutboundIPPrefixID != "" && !publicIPPrefixIDRegex.MatchString(e.OutboundIPPrefixID) {
+			return errors.Errorf("egressProfile.outboundIPPrefixID '%s' is not a public IP prefix resource ID, expected /subscriptions/SUB_ID/resourceGroups/RG_NAME/providers/Microsoft.Network/publicIPPrefixes/PREFIX_NAME", e.OutboundIPPrefixID)
+		}
+		if e.AllocatedOutboundPorts < 0 || e.AllocatedOutboundPorts > MaxEgressAllocatedOutboundPorts || e.AllocatedOutboundPorts%8 != 0 {
+			return errors.Errorf("egressProfile.allocatedOutboundPorts must be a multiple of 8 between 0 and %d, found %d", MaxEgressAllocatedOutboundPorts, e.AllocatedOutboundPorts)
+		}
+		if e.IdleTimeoutInMinutes != 0 && (e.IdleTimeoutInMinutes < MinEgressIdleTimeoutInMinutes || e.IdleTimeoutInMinutes > MaxEgressIdleTimeoutInMinutes) {
+			return errors.Errorf("egressProfile.idleTimeoutInMinutes must be between %d and %d, found %d", MinEgressIdleTimeoutInMinutes, MaxEgressIdleTimeoutInMinutes, e.IdleTimeoutInMinutes)
+		}
+	case EgressTypeUserDefinedRouting:
+		if e.FirewallIPAddress == "" {
+			return errors.Errorf("egressProfile type %s requires egressProfile.firewallIPAddress", EgressTypeUserDefinedRouting)
+		}
+		if ip := net.ParseIP(e.FirewallIPAddress); ip == nil || ip.To4() == nil {
+			return errors.Errorf("egressProfile.firewallIPAddress '%s' is not a valid IPv4 address", e.FirewallIPAddress)
+		}
+		if e.OutboundIPCount != 0 || e.OutboundIPPrefixID != "" || e.AllocatedOutboundPorts != 0 || e.IdleTimeoutInMinutes != 0 || e.EnableTCPReset != nil {
+			return errors.Errorf("egressProfile outbound rule settings can only be set with egressProfile type %s", EgressTypeLoadBalancer)
+		}
+		if k.PrivateCluster == nil || !to.Bool(k.PrivateCluster.Enabled) {
+			log.Warnf("egressProfile type %s routes replies to the public master load balancer through %s, the firewall must allow them or the cluster should be private", EgressTypeUserDefinedRouting, e.FirewallIPAddress)
+		}
+	default:
+		return errors.Errorf("unknown egressProfile type '%s', allowed types are %s and %s", e.Type, EgressTypeLoadBalancer, EgressTypeUserDefinedRouting)
+	}
+	return nil
+}
+
+// validateAgentPoolNetworkIsolation validates the network security groups and route tables of agent pools
+// that do not use the cluster ones
+func (a *Properties) validateAgentPoolNetworkIsolation() error {
+	for _, agentPool := range a.AgentPoolProfiles {
+		hasOwnNSG := agentPool.NetworkSecurityGroupID != "" || to.Bool(agentPool.EnableDedicatedNetworkSecurityGroup)
+		if !hasOwnNSG && agentPool.RouteTableID == "" {
+			continue
+		}
+		if a.OrchestratorProfile.OrchestratorType != Kubernetes {
+			return errors.Errorf("agent pool %s: a network security group or route table per agent pool is not supported with orchestratorType %s", agentPool.Name, a.OrchestratorProfile.OrchestratorType)
+		}
+		if !agentPool.IsCustomVNET() {
+			return errors.Errorf("agent pool %s: a network security group or route table per agent pool requires a custom VNET, vnetSubnetID must be set", agentPool.Name)
+		}
+		if agentPool.NetworkSecurityGroupID != "" {
+			if to.Bool(agentPool.EnableDedicatedNetworkSecurityGroup) {
+				return errors.Errorf("agent pool %s: networkSecurityGroupID and enableDedicatedNetworkSecurityGroup are mutually exclusive", agentPool.Name)
+			}
+			if !networkSecurityGroupIDRegex.MatchString(agentPool.NetworkSecurityGroupID) {
+				return errors.Errorf("agent pool %s: networkSecurityGroupID '%s' is not a network security group resource ID, expected /subscriptions/SUB_ID/resourceGroups/RG_NAME/providers/Microsoft.Network/networkSecurityGroups/NSG_NAME", agentPool.Name, agentPool.NetworkSecurityGroupID)
+			}
+		}
+		if agentPool.RouteTableID != "" {
+			if !routeTableIDRegex.MatchString(agentPool.RouteTableID) {
+				return errors.Errorf("agent pool %s: routeTableID '%s' is not a route table resource ID, expected /subscriptions/SUB_ID/resourceGroups/RG_NAME/providers/Microsoft.Network/routeTables/ROUTE_TABLE_NAME", agentPool.Name, agentPool.RouteTableID)
+			}
+			// the cloud provider route controller programs kubenet pod routes into the cluster route table only,
+			// networkPlugin defaults to kubenet
+			if a.OrchestratorProfile.KubernetesConfig == nil || a.OrchestratorProfile.KubernetesConfig.NetworkPlugin == "" || a.OrchestratorProfile.KubernetesConfig.NetworkPlugin == "kubenet" {
+				return errors.Errorf("agent pool %s: a route table per agent pool is not supported with networkPlugin kubenet, pod routes are only programmed into the cluster route table", agentPool.Name)
+			}
+		}
+	}
+	return nil
+}
+
+// validatePlacement validates the proximity placement groups and dedicated host groups of the masters and agent pools
+func (a *Properties) validatePlacement() error {
+	if a.MasterProfile != nil {
+		m := a.MasterProfile
+		if e := a.validateProfilePlacement("masterProfile", m.ProximityPlacementGroupID, m.EnableProximityPlacementGroup, m.HostGroupID, m.AvailabilityProfile, m.AvailabilityZones); e != nil {
+			return e
+		}
+	}
+	for _, agentPool := range a.AgentPoolProfiles {
+		if e := a.validateProfilePlacement(fmt.Sprintf("agent pool '%s'", agentPool.Name), agentPool.ProximityPlacementGroupID, agentPool.EnableProximityPlacementGroup, agentPool.HostGroupID, agentPool.AvailabilityProfile, agentPool.AvailabilityZones); e != nil {
+			return e
+		}
+	}
+	return nil
+}
+
+// validateProfilePlacement validates the placement of the master or of an agent pool, described by profileName
+func (a *Properties) validateProfilePlacement(profileName, proximityPlacementGroupID string, enableProximityPlacementGroup *bool, hostGroupID, availabilityProfile string, availabilityZones []string) error {
+	hasProximityPlacementGroup := proximityPlacementGroupID != "" || to.Bool(enableProximityPlacementGroup)
+	if !hasProximityPlacementGroup && hostGroupID == "" {
+		return nil
+	}
+	if a.OrchestratorProfile.OrchestratorType != Kubernetes {
+		return errors.Errorf("%s: proximity placement groups and dedicated host groups are only supported for Kubernetes", profileName)
+	}
+	if a.IsAzureStackCloud() {
+		return errors.Errorf("%s: proximity placement groups and dedicated host groups are not supported on Azure Stack", profileName)
+	}
+	if hasProximityPlacementGroup {
+		if proximityPlacementGroupID != "" {
+			if to.Bool(enableProximityPlacementGroup) {
+				return errors.Errorf("%s: proximityPlacementGroupID and enableProximityPlacementGroup are mutually exclusive", profileName)
+			}
+			if !proximityPlacementGroupIDRegex.MatchString(proximityPlacementGroupID) {
+				return errors.Errorf("%s: proximityPlacementGroupID '%s' is not a proximity placement group resource ID, expected /subscriptions/SUB_ID/resourceGroups/RG_NAME/providers/Microsoft.Compute/proximityPlacementGroups/PPG_NAME", profileName, proximityPlacementGroupID)
+			}
+		}
+		// a proximity placement group is pinned to the datacenter of its first VM
+		if len(availabilityZones) > 1 {
+			return errors.Errorf("%s: a proximity placement group cannot span availability zones, at most one availability zone can be set", profileName)
+		}
+	}
+	if hostGroupID != "" {
+		if !hostGroupIDRegex.MatchString(hostGroupID) {
+			return errors.Errorf("%s: hostGroupID '%s' is not a dedicated host group resource ID, expected /subscriptions/SUB_ID/resourceGroups/RG_NAME/providers/Microsoft.Compute/hostGroups/HOST_GROUP_NAME", profileName, hostGroupID)
+		}
+		if availabilityProfile != VirtualMachineScaleSets {
+			return errors.Errorf("%s: hostGroupID is only supported with availabilityProfile %s", profileName, VirtualMachineScaleSets)
+		}
+		// a dedicated host group is pinned to at most one availability zone
+		if len(availabilityZones) > 1 {
+			return errors.Errorf("%s: a dedicated host group cannot span availability zones, at most one availability zone can be set", profileName)
+		}
+	}
+	return nil
+}
+
+// validateAgentPoolOrchestratorVersions validates the agent pools that run a Kubernetes version of their own,
+// which may lag the control plane by up to two minor versions
+func (a *Properties) validateAgentPoolOrchestratorVersions() error {
+	for _, agentPool := range a.AgentPoolProfiles {
+		if agentPool.OrchestratorVersion == "" {
+			continue
+		}
+		if a.OrchestratorProfile.OrchestratorType != Kubernetes {
+			return errors.Errorf("agent pool '%s': orchestratorVersion is only supported for Kubernetes", agentPool.Name)
+		}
+		if agentPool.OSType == Windows {
+			return errors.Errorf("agent pool '%s': orchestratorVersion is not supported for Windows agent pools", agentPool.Name)
+		}
+		if a.OrchestratorProfile.KubernetesConfig != nil && a.OrchestratorProfile.KubernetesConfig.CustomHyperkubeImage != "" {
+			return errors.Errorf("agent pool '%s': orchestratorVersion cannot be used with customHyperkubeImage", agentPool.Name)
+		}
+		if _, ok := common.AllKubernetesSupportedVersions[agentPool.OrchestratorVersion]; !ok {
+			return errors.Errorf("agent pool '%s': orchestratorVersion %s is not a Kubernetes version supported by this build of aks-engine", agentPool.Name, agentPool.OrchestratorVersion)
+		}
+		if a.OrchestratorProfile.OrchestratorVersion == "" {
+			continue
+		}
+		poolVersion, err := semver.Make(agentPool.OrchestratorVersion)
+		if err != nil {
+			return errors.Errorf("agent pool '%s': could not validate version %s", agentPool.Name, agentPool.OrchestratorVersion)
+		}
+		controlPlaneVersion, err := semver.Make(a.OrchestratorProfile.OrchestratorVersion)
+		if err != nil {
+			return errors.Errorf("could not validate version %s", a.OrchestratorProfile.OrchestratorVersion)
+		}
+		if poolVersion.GT(controlPlaneVersion) {
+			return errors.Errorf("agent pool '%s': orchestratorVersion %s is newer than the control plane version %s", agentPool.Name, agentPool.OrchestratorVersion, a.OrchestratorProfile.OrchestratorVersion)
+		}
+		if !common.IsSupportedKubernetesVersionSkew(a.OrchestratorProfile.OrchestratorVersion, agentPool.OrchestratorVersion) {
+			return errors.Errorf("agent pool '%s': orchestratorVersion %s is more than two minor versions older than the control plane version %s", agentPool.Name, agentPool.OrchestratorVersion, a.OrchestratorProfile.OrchestratorVersion)
+		}
+	}
+	return nil
+}
+
+// validateHTTPProxyConfig validates the proxy settings rendered into the node provisioning scripts,
+// the values end up in shell and YAML files so shell metacharacters are rejected
+func (a *Properties) validateHTTPProxyConfig() error {
+	config := a.HTTPProxyConfig
+	if config == nil {
+		return nil
+	}
+	if a.OrchestratorProfile.OrchestratorType != Kubernetes {
+		return errors.Errorf("'httpProxyConfig' is only supported by orchestrator '%v'", Kubernetes)
+	}
+	if config.HTTPProxy == "" && config.HTTPSProxy == "" {
+		return errors.New("httpProxyConfig requires httpProxy or httpsProxy to be set")
+	}
+	if a.HasWindows() {
+		return errors.New("httpProxyConfig is not supported for clusters with Windows agent pools")
+	}
+	if a.HasCoreOS() || (a.MasterProfile != nil && a.MasterProfile.IsCoreOS()) {
+		return errors.New("httpProxyConfig is not supported for clusters with CoreOS nodes")
+	}
+	for field, proxy := range map[string]string{
+		"httpProxy":  config.HTTPProxy,
+		"httpsProxy": config.HTTPSProxy,
+	} {
+		if proxy == "" {
+			continue
+		}
+		if strings.ContainsAny(proxy, httpProxyInvalidChars) {
+			return errors.Errorf("httpProxyConfig.%s '%s' contains whitespace or one of the invalid characters %s", field, proxy, httpProxyInvalidChars)
+		}
+		u, err := url.Parse(proxy)
+		if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+			return errors.Errorf("httpProxyConfig.%s '%s' is not a valid proxy URL, expected http://HOST:PORT or https://HOST:PORT", field, proxy)
+		}
+	}
+	for _, entry := range config.NoProxy {
+		if entry == "" || strings.ContainsAny(entry, httpProxyInvalidChars+",") {
+			return errors.Errorf("httpProxyConfig.noProxy entry '%s' is empty or contains whitespace, a comma or one of the invalid characters %s", entry, httpProxyInvalidChars)
+		}
+	}
+	if a.MasterProfile != nil && a.MasterProfile.IsCustomVNET() && a.MasterProfile.VnetCidr == "" {
+		// the node and master addresses must bypass the proxy, the custom VNET CIDR isn't known here
+		hasCIDR := false
+		for _, entry := range config.NoProxy {
+			if _, _, err := net.ParseCIDR(entry); err == nil {
+				hasCIDR = true
+				break
+			}
+		}
+		if !hasCIDR {
+			return errors.New("httpProxyConfig with a custom VNET requires masterProfile.vnetCidr, or the CIDRs of the VNET subnets in httpProxyConfig.noProxy")
+		}
+	}
+	if config.TrustedCA != "" {
+		ca, err := base64.StdEncoding.DecodeString(config.TrustedCA)
+		if err != nil {
+			return errors.Wrap(err, "httpProxyConfig.trustedCa must be a base64 encoded PEM certificate")
+		}
+		if block, _ := pem.Decode(ca); block == nil || block.Type != "CERTIFICATE" {
+			return errors.New("httpProxyConfig.trustedCa must be a base64 encoded PEM certificate")
+		}
+	}
+	return nil
+}
+
+func (a *Properties) validateIPv6DualStack() error {
+	o := a.OrchestratorProfile
+	if a.FeatureFlags == nil || !a.FeatureFlags.EnableIPv6DualStack {
+		if o != nil && o.KubernetesConfig != nil {
+			if strings.Contains(o.KubernetesConfig.ClusterSubnet, ",") || strings.Contains(o.KubernetesConfig.ServiceCidr, ",") {
+				return errors.New("OrchestratorProfile.KubernetesConfig.ClusterSubnet and ServiceCidr can only list an IPv4 and an IPv6 CIDR when the enableIPv6DualStack feature flag is set")
+			}
+		}
+		return nil
+	}
+
+	if o.OrchestratorType != Kubernetes {
+		return errors.Errorf("IPv4/IPv6 dual-stack is not supported with orchestratorType %s", o.OrchestratorType)
+	}
+	version := common.RationalizeReleaseAndVersion(
+		o.OrchestratorType,
+		o.OrchestratorRelease,
+		o.OrchestratorVersion,
+		false,
+		false)
+	if version == "" {
+		return errors.Errorf("the following user supplied OrchestratorProfile configuration is not supported: OrchestratorType: %s, OrchestratorRelease: %s, OrchestratorVersion: %s. Please check supported Release or Version for this build of aks-engine", o.OrchestratorType, o.OrchestratorRelease, o.OrchestratorVersion)
+	}
+	if !common.IsKubernetesVersionGe(version, "1.16.0") {
+		return errors.Errorf("IPv4/IPv6 dual-stack can only be used with Kubernetes 1.16.0 or above, the cluster is configured for Kubernetes %s", version)
+	}
+	if a.HasWindows() {
+		return errors.New("IPv4/IPv6 dual-stack is not supported with Windows agent pools")
+	}
+	if a.IsAzureStackCloud() {
+		return errors.New("IPv4/IPv6 dual-stack is not supported on Azure Stack")
+	}
+
+	k := o.KubernetesConfig
+	if k == nil {
+		return nil
+	}
+	if k.NetworkPlugin == "azure" {
+		return errors.New("IPv4/IPv6 dual-stack is not supported with networkPlugin azure, Azure CNI does not assign IPv6 addresses to pods. Use networkPlugin kubenet")
+	}
+	if k.NetworkPlugin != "" && k.NetworkPlugin != "kubenet" {
+		return errors.Errorf("IPv4/IPv6 dual-stack is only supported with networkPlugin kubenet, found networkPlugin '%s'", k.NetworkPlugin)
+	}
+	if k.NetworkPolicy != "" && k.NetworkPolicy != "none" {
+		return errors.Errorf("IPv4/IPv6 dual-stack is not supported with networkPolicy '%s'", k.NetworkPolicy)
+	}
+	if k.ProxyMode != "" && k.ProxyMode != KubeProxyModeIPVS {
+		return errors.Errorf("IPv4/IPv6 dual-stack requires kubeProxyMode %s, found kubeProxyMode %s", KubeProxyModeIPVS, k.ProxyMode)
+	}
+	if k.ClusterSubnet != "" {
+		if e := validateDualStackCIDRs("ClusterSubnet", k.ClusterSubnet); e != nil {
+			return e
+		}
+	}
+	if k.ServiceCidr != "" {
+		if e := validateDualStackCIDRs("ServiceCidr", k.ServiceCidr); e != nil {
+			return e
+		}
+	}
+	return nil
+}
+
+// validateDualStackCIDRs requires a comma separated pair made of an IPv4 CIDR followed by an IPv6 CIDR
+func validateDualStackCIDRs(name, cidrs string) error {
+	pair := strings.Split(cidrs, ",")
+	if len(pair) != 2 {
+		return errors.Errorf("OrchestratorProfile.KubernetesConfig.%s '%s' must list an IPv4 and an IPv6 CIDR for IPv4/IPv6 dual-stack", name, cidrs)
+	}
+	ipv4, _, err := net.ParseCIDR(pair[0])
+	if err != nil || ipv4.To4() == nil {
+		return errors.Errorf("OrchestratorProfile.KubernetesConfig.%s '%s' must list an IPv4 CIDR first for IPv4/IPv6 dual-stack", name, cidrs)
+	}
+	ipv6, _, err := net.ParseCIDR(pair[1])
+	if err != nil || ipv6.To4() != nil {
+		return errors.Errorf("OrchestratorProfile.KubernetesConfig.%s '%s' must list an IPv6 CIDR second for IPv4/IPv6 dual-stack", name, cidrs)
+	}
+	return nil
+}
+
 func (a *AgentPoolProfile) validateAvailabilityProfile(orchestratorType string) error {
 	switch a.AvailabilityProfile {
 	case AvailabilitySet:
@@ -806,6 +1368,95 @@ func (a *AgentPoolProfile) validateCustomNodeLabels(orchestratorType string) err
 	return nil
 }
 
+func (a *AgentPoolProfile) validateTaints(o *OrchestratorProfile) error {
+	if len(a.Taints) == 0 {
+		return nil
+	}
+	if o.OrchestratorType != Kubernetes {
+		return errors.New("Agent taints are only supported for Kubernetes")
+	}
+	for _, kc := range []*KubernetesConfig{o.KubernetesConfig, a.KubernetesConfig} {
+		if kc != nil {
+			if _, ok := kc.KubeletConfig["--register-with-taints"]; ok {
+				return errors.Errorf("agent pool '%s' sets taints, --register-with-taints must not be set in kubeletConfig as well", a.Name)
+			}
+		}
+	}
+	seen := map[string]bool{}
+	for _, taint := range a.Taints {
+		t, err := common.ParseTaint(taint)
+		if err != nil {
+			return err
+		}
+		if e := validateKubernetesLabelKey(t.Key); e != nil {
+			return e
+		}
+		if e := validateKubernetesLabelValue(t.Value); e != nil {
+			return e
+		}
+		if seen[t.Key+":"+t.Effect] {
+			return errors.Errorf("agent pool '%s' has more than one taint with key '%s' and effect '%s'", a.Name, t.Key, t.Effect)
+		}
+		seen[t.Key+":"+t.Effect] = true
+	}
+	return nil
+}
+
+func (a *AgentPoolProfile) validateOSDiskType(orchestratorType string, isAzureStack bool) error {
+	switch a.OSDiskType {
+	case "", OSDiskTypeManaged:
+		return nil
+	case OSDiskTypeEphemeral:
+	default:
+		return errors.Errorf("agent pool '%s' has an invalid osDiskType '%s', valid values are '%s' and '%s'", a.Name, a.OSDiskType, OSDiskTypeManaged, OSDiskTypeEphemeral)
+	}
+	if orchestratorType != Kubernetes {
+		return errors.New("Ephemeral OS disks are only supported for Kubernetes")
+	}
+	if isAzureStack {
+		return errors.Errorf("agent pool '%s' uses an ephemeral OS disk, which is not supported on Azure Stack", a.Name)
+	}
+	if a.StorageProfile == StorageAccount {
+		return errors.Errorf("agent pool '%s' uses an ephemeral OS disk, which is not supported with storageProfile %s. Please specify \"storageProfile\": \"%s\"", a.Name, StorageAccount, ManagedDisks)
+	}
+	osDiskSizeGB := a.OSDiskSizeGB
+	if osDiskSizeGB == 0 {
+		osDiskSizeGB = DefaultLinuxOSDiskSizeGB
+		if a.OSType == Windows {
+			osDiskSizeGB = DefaultWindowsOSDiskSizeGB
+		}
+	}
+	cacheSizeGB, ok := helpers.GetVMSizeCacheSizeGB(a.VMSize)
+	if !ok {
+		log.Warnf("agent pool '%s' uses an ephemeral OS disk, but the cache size of VM size %s is not known, the deployment will fail if it is smaller than the %dGB OS disk", a.Name, a.VMSize, osDiskSizeGB)
+		return nil
+	}
+	if osDiskSizeGB > cacheSizeGB {
+		return errors.Errorf("agent pool '%s' uses an ephemeral OS disk of %dGB, which does not fit in the %dGB cache of VM size %s. Please set a smaller osDiskSizeGB or choose a larger VM size", a.Name, osDiskSizeGB, cacheSizeGB, a.VMSize)
+	}
+	return nil
+}
+
+// validateDiskEncryptionSetID validates the disk encryption set of the master or of an agent pool, described by profileName
+func validateDiskEncryptionSetID(diskEncryptionSetID, profileName, storageProfile, orchestratorType string, isAzureStack bool) error {
+	if diskEncryptionSetID == "" {
+		return nil
+	}
+	if orchestratorType != Kubernetes {
+		return errors.New("diskEncryptionSetID is only supported for Kubernetes")
+	}
+	if isAzureStack {
+		return errors.Errorf("%s sets diskEncryptionSetID, which is not supported on Azure Stack", profileName)
+	}
+	if storageProfile == StorageAccount {
+		return errors.Errorf("%s sets diskEncryptionSetID, which is not supported with storageProfile %s. Please specify \"storageProfile\": \"%s\"", profileName, StorageAccount, ManagedDisks)
+	}
+	if !diskEncryptionSetIDRegex.MatchString(diskEncryptionSetID) {
+		return errors.Errorf("%s has an invalid diskEncryptionSetID '%s', expected the resource ID of a disk encryption set of the form /subscriptions/SUB_ID/resourceGroups/RG_NAME/providers/Microsoft.Compute/diskEncryptionSets/DES_NAME", profileName, diskEncryptionSetID)
+	}
+	return nil
+}
+
 func validateVMSS(o *OrchestratorProfile, isUpdate bool, storageProfile string) error {
 	if o.OrchestratorType == Kubernetes {
 		version := common.RationalizeReleaseAndVersion(
@@ -992,15 +1643,18 @@ func (k *KubernetesConfig) Validate(k8sVersion string, hasWindows bool) error {
 	const minKubeletRetries = 4
 
 	if k.ClusterSubnet != "" {
-		_, subnet, err := net.ParseCIDR(k.ClusterSubnet)
-		if err != nil {
-			return errors.Errorf("OrchestratorProfile.KubernetesConfig.ClusterSubnet '%s' is an invalid subnet", k.ClusterSubnet)
-		}
+		// IPv4/IPv6 dual-stack clusters list an IPv4 and an IPv6 subnet, see validateIPv6DualStack
+		for _, clusterSubnet := range strings.Split(k.ClusterSubnet, ",") {
+			_, subnet, err := net.ParseCIDR(clusterSubnet)
+			if err != nil {
+				return errors.Errorf("OrchestratorProfile.KubernetesConfig.ClusterSubnet '%s' is an invalid subnet", k.ClusterSubnet)
+			}
 
-		if k.NetworkPlugin == "azure" {
-			ones, bits := subnet.Mask.Size()
-			if bits-ones <= 8 {
-				return errors.Errorf("OrchestratorProfile.KubernetesConfig.ClusterSubnet '%s' must reserve at least 9 bits for nodes", k.ClusterSubnet)
+			if k.NetworkPlugin == "azure" {
+				ones, bits := subnet.Mask.Size()
+				if bits-ones <= 8 {
+					return errors.Errorf("OrchestratorProfile.KubernetesConfig.ClusterSubnet '%s' must reserve at least 9 bits for nodes", k.ClusterSubnet)
+				}
 			}
 		}
 	}
@@ -1081,10 +1735,13 @@ func (k *KubernetesConfig) Validate(k8sVersion string, hasWindows bool) error {
 			return errors.Errorf("OrchestratorProfile.KubernetesConfig.DNSServiceIP '%s' is an invalid IP address", k.DNSServiceIP)
 		}
 
-		_, serviceCidr, err := net.ParseCIDR(k.ServiceCidr)
-		if err != nil {
-			return errors.Errorf("OrchestratorProfile.KubernetesConfig.ServiceCidr '%s' is an invalid CIDR subnet", k.ServiceCidr)
+		for _, cidr := range strings.Split(k.ServiceCidr, ",") {
+			if _, _, err := net.ParseCIDR(cidr); err != nil {
+				return errors.Errorf("OrchestratorProfile.KubernetesConfig.ServiceCidr '%s' is an invalid CIDR subnet", k.ServiceCidr)
+			}
 		}
+		// The DNS service IP belongs to the IPv4 ServiceCidr of IPv4/IPv6 dual-stack clusters
+		_, serviceCidr, _ := net.ParseCIDR(common.GetIPv4CIDR(k.ServiceCidr))
 
 		// Finally validate that the DNS ip is within the subnet
 		if !serviceCidr.Contains(dnsIP) {
@@ -1138,6 +1795,9 @@ func (k *KubernetesConfig) Validate(k8sVersion string, hasWindows bool) error {
 		}
 	}
 
+	if e := k.validateComponentOverrides(); e != nil {
+		return e
+	}
 	if e := k.validateNetworkPlugin(); e != nil {
 		return e
 	}
@@ -1147,9 +1807,44 @@ func (k *KubernetesConfig) Validate(k8sVersion string, hasWindows bool) error {
 	if e := k.validateNetworkPluginPlusPolicy(); e != nil {
 		return e
 	}
+	if e := k.validatePrivateDNSZone(); e != nil {
+		return e
+	}
 	return k.validatePrivateAzureRegistryServer()
 }
 
+func (k *KubernetesConfig) validatePrivateDNSZone() error {
+	if k.PrivateCluster == nil || k.PrivateCluster.PrivateDNSZone == nil || !to.Bool(k.PrivateCluster.PrivateDNSZone.Enabled) {
+		return nil
+	}
+	if !to.Bool(k.PrivateCluster.Enabled) {
+		return errors.New("privateCluster.privateDnsZone can only be enabled for a private cluster, privateCluster.enabled must be true")
+	}
+	zone := k.PrivateCluster.PrivateDNSZone
+	if zone.ID != "" {
+		if zone.Name != "" {
+			return errors.New("privateCluster.privateDnsZone.name and privateCluster.privateDnsZone.id are mutually exclusive")
+		}
+		if !privateDNSZoneIDRegex.MatchString(zone.ID) {
+			return errors.Errorf("privateCluster.privateDnsZone.id '%s' is not a private DNS zone resource ID, expected /subscriptions/SUB_ID/resourceGroups/RG_NAME/providers/Microsoft.Network/privateDnsZones/ZONE_NAME", zone.ID)
+		}
+	}
+	if zone.Name != "" && (len(zone.Name) > 253 || !privateDNSZoneNameRegex.MatchString(zone.Name)) {
+		return errors.Errorf("privateCluster.privateDnsZone.name '%s' is not a valid DNS zone name with at least two labels", zone.Name)
+	}
+	seen := map[string]bool{}
+	for _, id := range zone.VirtualNetworkIDs {
+		if !virtualNetworkIDRegex.MatchString(id) {
+			return errors.Errorf("privateCluster.privateDnsZone.virtualNetworkIDs entry '%s' is not a virtual network resource ID, expected /subscriptions/SUB_ID/resourceGroups/RG_NAME/providers/Microsoft.Network/virtualNetworks/VNET_NAME", id)
+		}
+		if seen[strings.ToLower(id)] {
+			return errors.Errorf("privateCluster.privateDnsZone.virtualNetworkIDs entry '%s' is listed more than once", id)
+		}
+		seen[strings.ToLower(id)] = true
+	}
+	return nil
+}
+
 func (k *KubernetesConfig) validatePrivateAzureRegistryServer() error {
 
 	// Check PrivateAzureRegistryServer has a valid value.
@@ -1169,6 +1864,33 @@ func (k *KubernetesConfig) validatePrivateAzureRegistryServer() error {
 	return nil
 }
 
+func (k *KubernetesConfig) validateComponentOverrides() error {
+	for name, value := range k.ComponentOverrides {
+		switch name {
+		case "kube-apiserver", "kube-controller-manager", "kube-scheduler", "kubelet":
+			return errors.Errorf("componentOverrides key '%s' is not supported, %s runs from the hyperkube image, override 'hyperkube' instead", name, name)
+		case "etcd":
+			if e := validateEtcdVersion(value); e != nil {
+				return errors.Wrap(e, "componentOverrides key 'etcd'")
+			}
+		}
+		valid := false
+		for _, n := range ComponentOverrideNames {
+			if name == n {
+				valid = true
+				break
+			}
+		}
+		if !valid {
+			return errors.Errorf("unknown componentOverrides key '%s' specified, valid keys are %s", name, strings.Join(ComponentOverrideNames[:], ", "))
+		}
+		if value == "" {
+			return errors.Errorf("componentOverrides key '%s' must not have an empty value", name)
+		}
+	}
+	return nil
+}
+
 func (k *KubernetesConfig) validateNetworkPlugin() error {
 
 	networkPlugin := k.NetworkPlugin
@@ -121,6 +121,38 @@ func (az *AzureClient) DeleteVirtualMachineScaleSetVM(ctx context.Context, resou
 	return err
 }
 
+// ReimageVirtualMachineScaleSetVM reimages the OS disk of a VM in a VMSS
+func (az *AzureClient) ReimageVirtualMachineScaleSetVM(ctx context.Context, resourceGroup, virtualMachineScaleSet, instanceID string) error {
+	future, err := az.virtualMachineScaleSetVMsClient.Reimage(ctx, resourceGroup, virtualMachineScaleSet, instanceID)
+	if err != nil {
+		return err
+	}
+
+	if err = future.WaitForCompletionRef(ctx, az.virtualMachineScaleSetVMsClient.Client); err != nil {
+		return err
+	}
+
+	_, err = future.Result(az.virtualMachineScaleSetVMsClient)
+	return err
+}
+
+// UpdateVirtualMachineScaleSetVMs applies the latest VMSS model to the specified VMs
+func (az *AzureClient) UpdateVirtualMachineScaleSetVMs(ctx context.Context, resourceGroup, virtualMachineScaleSet string, instanceIDs []string) error {
+	future, err := az.virtualMachineScaleSetsClient.UpdateInstances(ctx, resourceGroup, virtualMachineScaleSet, compute.VirtualMachineScaleSetVMInstanceRequiredIDs{
+		InstanceIds: &instanceIDs,
+	})
+	if err != nil {
+		return err
+	}
+
+	if err = future.WaitForCompletionRef(ctx, az.virtualMachineScaleSetsClient.Client); err != nil {
+		return err
+	}
+
+	_, err = future.Result(az.virtualMachineScaleSetsClient)
+	return err
+}
+
 // DeleteVirtualMachineScaleSet deletes an entire VM Scale Set.
 func (az *AzureClient) DeleteVirtualMachineScaleSet(ctx context.Context, resourceGroup, vmssName string) error {
 	future, err := az.virtualMachineScaleSetsClient.Delete(ctx, resourceGroup, vmssName)
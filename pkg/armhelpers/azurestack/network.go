@@ -5,6 +5,9 @@ package azurestack
 
 import (
 	"context"
+	"fmt"
+
+	aznetwork "github.com/Azure/azure-sdk-for-go/services/network/mgmt/2018-08-01/network"
 )
 
 // DeleteNetworkInterface deletes the specified network interface.
@@ -21,3 +24,29 @@ func (az *AzureClient) DeleteNetworkInterface(ctx context.Context, resourceGroup
 	_, err = future.Result(az.interfacesClient)
 	return err
 }
+
+// GetNetworkInterface retrieves the specified network interface.
+func (az *AzureClient) GetNetworkInterface(ctx context.Context, resourceGroup, nicName string) (aznetwork.Interface, error) {
+	nic, err := az.interfacesClient.Get(ctx, resourceGroup, nicName, "")
+	azNic := aznetwork.Interface{}
+	if err != nil {
+		return azNic, fmt.Errorf("fail to get network interface, %s", err)
+	}
+	if err = DeepCopy(&azNic, nic); err != nil {
+		return azNic, fmt.Errorf("fail to convert network interface, %s", err)
+	}
+	return azNic, nil
+}
+
+// GetVirtualMachineScaleSetNetworkInterface retrieves the specified network interface of a VM in a VMSS.
+func (az *AzureClient) GetVirtualMachineScaleSetNetworkInterface(ctx context.Context, resourceGroup, virtualMachineScaleSet, instanceID, nicName string) (aznetwork.Interface, error) {
+	nic, err := az.interfacesClient.GetVirtualMachineScaleSetNetworkInterface(ctx, resourceGroup, virtualMachineScaleSet, instanceID, nicName, "")
+	azNic := aznetwork.Interface{}
+	if err != nil {
+		return azNic, fmt.Errorf("fail to get network interface, %s", err)
+	}
+	if err = DeepCopy(&azNic, nic); err != nil {
+		return azNic, fmt.Errorf("fail to convert network interface, %s", err)
+	}
+	return azNic, nil
+}
@@ -0,0 +1,34 @@
+// Copyright (c) Microsoft Corporation. All rights reserved.
+// Licensed under the MIT license.
+
+package azurestack
+
+import (
+	"context"
+
+	"github.com/Azure/aks-engine/pkg/armhelpers"
+	"github.com/pkg/errors"
+)
+
+// ListResources lists the resources of a resource group
+func (az *AzureClient) ListResources(ctx context.Context, resourceGroup string) (armhelpers.ResourceListResultPage, error) {
+	page, err := az.genericResourcesClient.ListByResourceGroup(ctx, resourceGroup, "", "", nil)
+	return &page, err
+}
+
+// DeleteResourceByID deletes the resource with the given ID, using the latest stable API version of its resource type
+func (az *AzureClient) DeleteResourceByID(ctx context.Context, resourceID string) error {
+	namespace, resourceType, err := armhelpers.ParseResourceType(resourceID)
+	if err != nil {
+		return err
+	}
+	provider, err := az.providersClient.Get(ctx, namespace, "")
+	if err != nil {
+		return errors.Wrapf(err, "getting resource provider %s", namespace)
+	}
+	apiVersion, err := armhelpers.LatestAPIVersion(provider, resourceType)
+	if err != nil {
+		return err
+	}
+	return armhelpers.DeleteGenericResource(ctx, az.genericResourcesClient, resourceID, apiVersion)
+}
@@ -6,6 +6,9 @@ package azurestack
 import (
 	"bytes"
 	"context"
+	"io/ioutil"
+	"net/url"
+	"time"
 
 	"github.com/Azure/aks-engine/pkg/armhelpers"
 	"github.com/Azure/azure-sdk-for-go/services/storage/mgmt/2017-10-01/storage"
@@ -70,6 +73,45 @@ func (as *AzureStorageClient) SaveBlockBlob(containerName, blobName string, b []
 	return blobRef.CreateBlockBlobFromReader(bytes.NewReader(b), options)
 }
 
+// GetBlockBlob returns the content of a block blob
+func (as *AzureStorageClient) GetBlockBlob(containerName, blobName string, options *azStorage.GetBlobOptions) ([]byte, error) {
+	containerRef := getContainerRef(as.client, containerName)
+	blobRef := containerRef.GetBlobReference(blobName)
+
+	reader, err := blobRef.Get(options)
+	if err != nil {
+		return nil, err
+	}
+	defer reader.Close()
+	return ioutil.ReadAll(reader)
+}
+
+// GetContainerURL returns the URL of a container
+func (as *AzureStorageClient) GetContainerURL(containerName string) string {
+	return getContainerRef(as.client, containerName).GetURL()
+}
+
+// GetContainerSASToken returns a read-only SAS token of a container, valid until expiry
+func (as *AzureStorageClient) GetContainerSASToken(containerName string, expiry time.Time) (string, error) {
+	sasURI, err := getContainerRef(as.client, containerName).GetSASURI(azStorage.ContainerSASOptions{
+		ContainerSASPermissions: azStorage.ContainerSASPermissions{
+			BlobServiceSASPermissions: azStorage.BlobServiceSASPermissions{Read: true},
+		},
+		SASOptions: azStorage.SASOptions{
+			Expiry:   expiry,
+			UseHTTPS: true,
+		},
+	})
+	if err != nil {
+		return "", err
+	}
+	u, err := url.Parse(sasURI)
+	if err != nil {
+		return "", err
+	}
+	return u.RawQuery, nil
+}
+
 func getContainerRef(client *azStorage.Client, containerName string) *azStorage.Container {
 	bs := client.GetBlobService()
 	return bs.GetContainerReference(containerName)
@@ -10,12 +10,15 @@ import (
 	"github.com/Azure/azure-sdk-for-go/services/authorization/mgmt/2015-07-01/authorization"
 	"github.com/Azure/azure-sdk-for-go/services/compute/mgmt/2018-10-01/compute"
 	"github.com/Azure/azure-sdk-for-go/services/graphrbac/1.6/graphrbac"
+	"github.com/Azure/azure-sdk-for-go/services/network/mgmt/2018-08-01/network"
 	"github.com/Azure/azure-sdk-for-go/services/preview/msi/mgmt/2015-08-31-preview/msi"
 	"github.com/Azure/azure-sdk-for-go/services/resources/mgmt/2018-05-01/resources"
 	azStorage "github.com/Azure/azure-sdk-for-go/storage"
 	"github.com/Azure/go-autorest/autorest"
 	log "github.com/sirupsen/logrus"
+	appsv1 "k8s.io/api/apps/v1"
 	v1 "k8s.io/api/core/v1"
+	policy "k8s.io/api/policy/v1beta1"
 )
 
 // VirtualMachineListResultPage is an interface for compute.VirtualMachineListResultPage to aid in mocking
@@ -69,6 +72,15 @@ type RoleAssignmentListResultPage interface {
 	Values() []authorization.RoleAssignment
 }
 
+// ResourceListResultPage is an interface for resources.ListResultPage to aid in mocking
+type ResourceListResultPage interface {
+	Next() error
+	NextWithContext(ctx context.Context) (err error)
+	NotDone() bool
+	Response() resources.ListResult
+	Values() []resources.GenericResource
+}
+
 // DiskListPage is an interface for compute.DiskListPage to aid in mocking
 type DiskListPage interface {
 	Next() error
@@ -97,6 +109,15 @@ type AKSEngineClient interface {
 	// EnsureResourceGroup ensures the specified resource group exists in the specified location
 	EnsureResourceGroup(ctx context.Context, resourceGroup, location string, managedBy *string) (*resources.Group, error)
 
+	// DeleteResourceGroup deletes the specified resource group and the resources it contains
+	DeleteResourceGroup(ctx context.Context, resourceGroup string) error
+
+	// ListResources lists the resources of the specified resource group
+	ListResources(ctx context.Context, resourceGroup string) (ResourceListResultPage, error)
+
+	// DeleteResourceByID deletes the resource with the given ID, whatever its type
+	DeleteResourceByID(ctx context.Context, resourceID string) error
+
 	//
 	// COMPUTE
 
@@ -124,6 +145,12 @@ type AKSEngineClient interface {
 	// DeleteVirtualMachineScaleSetVM deletes a VM in a VMSS
 	DeleteVirtualMachineScaleSetVM(ctx context.Context, resourceGroup, virtualMachineScaleSet, instanceID string) error
 
+	// ReimageVirtualMachineScaleSetVM reimages the OS disk of a VM in a VMSS
+	ReimageVirtualMachineScaleSetVM(ctx context.Context, resourceGroup, virtualMachineScaleSet, instanceID string) error
+
+	// UpdateVirtualMachineScaleSetVMs applies the latest VMSS model to the specified VMs
+	UpdateVirtualMachineScaleSetVMs(ctx context.Context, resourceGroup, virtualMachineScaleSet string, instanceIDs []string) error
+
 	// SetVirtualMachineScaleSetCapacity sets the VMSS capacity
 	SetVirtualMachineScaleSetCapacity(ctx context.Context, resourceGroup, virtualMachineScaleSet string, sku compute.Sku, location string) error
 
@@ -140,6 +167,12 @@ type AKSEngineClient interface {
 	// DeleteNetworkInterface deletes the specified network interface.
 	DeleteNetworkInterface(ctx context.Context, resourceGroup, nicName string) error
 
+	// GetNetworkInterface retrieves the specified network interface.
+	GetNetworkInterface(ctx context.Context, resourceGroup, nicName string) (network.Interface, error)
+
+	// GetVirtualMachineScaleSetNetworkInterface retrieves the specified network interface of a VM in a VMSS.
+	GetVirtualMachineScaleSetNetworkInterface(ctx context.Context, resourceGroup, virtualMachineScaleSet, instanceID, nicName string) (network.Interface, error)
+
 	//
 	// GRAPH
 
@@ -183,6 +216,12 @@ type AKSStorageClient interface {
 	CreateContainer(containerName string, options *azStorage.CreateContainerOptions) (bool, error)
 	// SaveBlockBlob initializes a block blob by taking the byte
 	SaveBlockBlob(containerName, blobName string, b []byte, options *azStorage.PutBlobOptions) error
+	// GetBlockBlob returns the content of a block blob
+	GetBlockBlob(containerName, blobName string, options *azStorage.GetBlobOptions) ([]byte, error)
+	// GetContainerURL returns the URL of a container
+	GetContainerURL(containerName string) string
+	// GetContainerSASToken returns a read-only SAS token of a container, valid until expiry
+	GetContainerSASToken(containerName string, expiry time.Time) (string, error)
 }
 
 // KubernetesClient interface models client for interacting with kubernetes api server
@@ -211,4 +250,12 @@ type KubernetesClient interface {
 	EvictPod(pod *v1.Pod, policyGroupVersion string) error
 	// WaitForDelete waits until all pods are deleted. Returns all pods not deleted and an error on failure.
 	WaitForDelete(logger *log.Entry, pods []v1.Pod, usingEviction bool) ([]v1.Pod, error)
+	// ListPodDisruptionBudgets returns the Pod Disruption Budgets of all namespaces.
+	ListPodDisruptionBudgets() (*policy.PodDisruptionBudgetList, error)
+	// ListDeployments returns the Deployments of all namespaces.
+	ListDeployments() (*appsv1.DeploymentList, error)
+	// ListDaemonSets returns the DaemonSets of all namespaces.
+	ListDaemonSets() (*appsv1.DaemonSetList, error)
+	// ListStatefulSets returns the StatefulSets of all namespaces.
+	ListStatefulSets() (*appsv1.StatefulSetList, error)
 }
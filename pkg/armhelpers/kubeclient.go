@@ -7,6 +7,7 @@ import (
 	"time"
 
 	log "github.com/sirupsen/logrus"
+	appsv1 "k8s.io/api/apps/v1"
 	v1 "k8s.io/api/core/v1"
 	policy "k8s.io/api/policy/v1beta1"
 	apierrors "k8s.io/apimachinery/pkg/api/errors"
@@ -169,3 +170,23 @@ func (c *KubernetesClientSetClient) WaitForDelete(logger *log.Entry, pods []v1.P
 	})
 	return pods, err
 }
+
+// ListPodDisruptionBudgets returns the Pod Disruption Budgets of all namespaces.
+func (c *KubernetesClientSetClient) ListPodDisruptionBudgets() (*policy.PodDisruptionBudgetList, error) {
+	return c.clientset.PolicyV1beta1().PodDisruptionBudgets(metav1.NamespaceAll).List(metav1.ListOptions{})
+}
+
+// ListDeployments returns the Deployments of all namespaces.
+func (c *KubernetesClientSetClient) ListDeployments() (*appsv1.DeploymentList, error) {
+	return c.clientset.AppsV1().Deployments(metav1.NamespaceAll).List(metav1.ListOptions{})
+}
+
+// ListDaemonSets returns the DaemonSets of all namespaces.
+func (c *KubernetesClientSetClient) ListDaemonSets() (*appsv1.DaemonSetList, error) {
+	return c.clientset.AppsV1().DaemonSets(metav1.NamespaceAll).List(metav1.ListOptions{})
+}
+
+// ListStatefulSets returns the StatefulSets of all namespaces.
+func (c *KubernetesClientSetClient) ListStatefulSets() (*appsv1.StatefulSetList, error) {
+	return c.clientset.AppsV1().StatefulSets(metav1.NamespaceAll).List(metav1.ListOptions{})
+}
@@ -10,6 +10,7 @@ import (
 	"fmt"
 	"io/ioutil"
 	"net/http"
+	"net/url"
 	"time"
 
 	"github.com/Azure/go-autorest/autorest/to"
@@ -17,12 +18,15 @@ import (
 	"github.com/Azure/azure-sdk-for-go/services/authorization/mgmt/2015-07-01/authorization"
 	"github.com/Azure/azure-sdk-for-go/services/compute/mgmt/2018-10-01/compute"
 	"github.com/Azure/azure-sdk-for-go/services/graphrbac/1.6/graphrbac"
+	"github.com/Azure/azure-sdk-for-go/services/network/mgmt/2018-08-01/network"
 	"github.com/Azure/azure-sdk-for-go/services/preview/msi/mgmt/2015-08-31-preview/msi"
 	"github.com/Azure/azure-sdk-for-go/services/resources/mgmt/2018-05-01/resources"
 	azStorage "github.com/Azure/azure-sdk-for-go/storage"
 	"github.com/Azure/go-autorest/autorest"
 	log "github.com/sirupsen/logrus"
+	appsv1 "k8s.io/api/apps/v1"
 	v1 "k8s.io/api/core/v1"
+	policy "k8s.io/api/policy/v1beta1"
 )
 
 const (
@@ -38,6 +42,9 @@ type MockAKSEngineClient struct {
 	FailDeployTemplateConflict              bool
 	FailDeployTemplateWithProperties        bool
 	FailEnsureResourceGroup                 bool
+	FailDeleteResourceGroup                 bool
+	FailListResources                       bool
+	FailDeleteResourceByID                  bool
 	FailListVirtualMachines                 bool
 	FailListVirtualMachinesTags             bool
 	FailListVirtualMachineScaleSets         bool
@@ -46,43 +53,66 @@ type MockAKSEngineClient struct {
 	FailRestartVirtualMachine               bool
 	FailDeleteVirtualMachine                bool
 	FailDeleteVirtualMachineScaleSetVM      bool
+	FailReimageVirtualMachineScaleSetVM     bool
+	FailUpdateVirtualMachineScaleSetVMs     bool
 	FailSetVirtualMachineScaleSetCapacity   bool
 	FailListVirtualMachineScaleSetVMs       bool
 	FailGetStorageClient                    bool
 	FailDeleteNetworkInterface              bool
+	FailGetNetworkInterface                 bool
+	FailDeleteManagedDisk                   bool
 	FailGetKubernetesClient                 bool
 	FailListProviders                       bool
 	ShouldSupportVMIdentity                 bool
+	ShouldUseEphemeralOSDisk                bool
 	FailDeleteRoleAssignment                bool
 	MockKubernetesClient                    *MockKubernetesClient
 	FakeListVirtualMachineScaleSetsResult   func() []compute.VirtualMachineScaleSet
 	FakeListVirtualMachineResult            func() []compute.VirtualMachine
 	FakeListVirtualMachineScaleSetVMsResult func() []compute.VirtualMachineScaleSetVM
+	FakeListResourcesResult                 func() []resources.GenericResource
+	// FakeGetNetworkInterfaceResult returns the network interfaces retrieved, virtualMachineScaleSet and instanceID
+	// are empty for the network interfaces of VMs outside of a VMSS
+	FakeGetNetworkInterfaceResult func(virtualMachineScaleSet, instanceID, nicName string) network.Interface
+	// MockStorageClient is the storage client returned by GetStorageClient, a new one if nil
+	MockStorageClient *MockStorageClient
 }
 
 //MockStorageClient mock implementation of StorageClient
 type MockStorageClient struct {
 	FailCreateContainer bool
 	FailSaveBlockBlob   bool
+	FailGetBlockBlob    bool
+	// SavedBlobs are the contents of the blobs saved, by container and blob name joined with a slash
+	SavedBlobs map[string][]byte
+	// CreatedContainers are the options of the containers created, by container name
+	CreatedContainers map[string]*azStorage.CreateContainerOptions
 }
 
 //MockKubernetesClient mock implementation of KubernetesClient
 type MockKubernetesClient struct {
-	FailListPods             bool
-	FailListNodes            bool
-	FailListServiceAccounts  bool
-	FailGetNode              bool
-	UpdateNodeFunc           func(*v1.Node) (*v1.Node, error)
-	FailUpdateNode           bool
-	FailDeleteNode           bool
-	FailDeleteServiceAccount bool
-	FailSupportEviction      bool
-	FailDeletePod            bool
-	FailEvictPod             bool
-	FailWaitForDelete        bool
-	ShouldSupportEviction    bool
-	PodsList                 *v1.PodList
-	ServiceAccountList       *v1.ServiceAccountList
+	FailListPods                 bool
+	FailListNodes                bool
+	FailListServiceAccounts      bool
+	FailGetNode                  bool
+	UpdateNodeFunc               func(*v1.Node) (*v1.Node, error)
+	FailUpdateNode               bool
+	FailDeleteNode               bool
+	FailDeleteServiceAccount     bool
+	FailSupportEviction          bool
+	FailDeletePod                bool
+	FailEvictPod                 bool
+	FailWaitForDelete            bool
+	ShouldSupportEviction        bool
+	FailListPodDisruptionBudgets bool
+	FailListWorkloads            bool
+	PodsList                     *v1.PodList
+	ServiceAccountList           *v1.ServiceAccountList
+	NodeList                     *v1.NodeList
+	PodDisruptionBudgetList      *policy.PodDisruptionBudgetList
+	DeploymentList               *appsv1.DeploymentList
+	DaemonSetList                *appsv1.DaemonSetList
+	StatefulSetList              *appsv1.StatefulSetList
 }
 
 // MockVirtualMachineListResultPage contains a page of VirtualMachine values.
@@ -237,6 +267,47 @@ func (page MockDeploymentOperationsListResultPage) Values() []resources.Deployme
 	return *page.Dolr.Value
 }
 
+// MockResourceListResultPage contains a page of GenericResource values.
+type MockResourceListResultPage struct {
+	Fn  func(resources.ListResult) (resources.ListResult, error)
+	Rlr resources.ListResult
+}
+
+// Next advances to the next page of values.  If there was an error making
+// the request the page does not advance and the error is returned.
+func (page *MockResourceListResultPage) Next() error {
+	return page.NextWithContext(context.Background())
+}
+
+// NextWithContext advances to the next page of values.  If there was an error making
+// the request the page does not advance and the error is returned.
+func (page *MockResourceListResultPage) NextWithContext(ctx context.Context) (err error) {
+	next, err := page.Fn(page.Rlr)
+	if err != nil {
+		return err
+	}
+	page.Rlr = next
+	return nil
+}
+
+// NotDone returns true if the page enumeration should be started or is not yet complete.
+func (page MockResourceListResultPage) NotDone() bool {
+	return !page.Rlr.IsEmpty()
+}
+
+// Response returns the raw server response from the last page request.
+func (page MockResourceListResultPage) Response() resources.ListResult {
+	return page.Rlr
+}
+
+// Values returns the slice of values for the current page or nil if there are no values.
+func (page MockResourceListResultPage) Values() []resources.GenericResource {
+	if page.Rlr.IsEmpty() {
+		return nil
+	}
+	return *page.Rlr.Value
+}
+
 // MockRoleAssignmentListResultPage contains a page of RoleAssignment values.
 type MockRoleAssignmentListResultPage struct {
 	Fn   func(authorization.RoleAssignmentListResult) (authorization.RoleAssignmentListResult, error)
@@ -299,6 +370,9 @@ func (mkc *MockKubernetesClient) ListNodes() (*v1.NodeList, error) {
 	if mkc.FailListNodes {
 		return nil, errors.New("ListNodes failed")
 	}
+	if mkc.NodeList != nil {
+		return mkc.NodeList, nil
+	}
 	node := &v1.Node{}
 	node.Name = "k8s-master-1234"
 	node.Status.Conditions = append(node.Status.Conditions, v1.NodeCondition{Type: v1.NodeReady, Status: v1.ConditionTrue})
@@ -414,6 +488,10 @@ func (msc *MockStorageClient) DeleteBlob(container, blob string, options *azStor
 //CreateContainer mock
 func (msc *MockStorageClient) CreateContainer(container string, options *azStorage.CreateContainerOptions) (bool, error) {
 	if !msc.FailCreateContainer {
+		if msc.CreatedContainers == nil {
+			msc.CreatedContainers = map[string]*azStorage.CreateContainerOptions{}
+		}
+		msc.CreatedContainers[container] = options
 		return true, nil
 	}
 	return false, errors.New("CreateContainer failed")
@@ -422,11 +500,33 @@ func (msc *MockStorageClient) CreateContainer(container string, options *azStora
 //SaveBlockBlob mock
 func (msc *MockStorageClient) SaveBlockBlob(container, blob string, b []byte, options *azStorage.PutBlobOptions) error {
 	if !msc.FailSaveBlockBlob {
+		if msc.SavedBlobs == nil {
+			msc.SavedBlobs = map[string][]byte{}
+		}
+		msc.SavedBlobs[container+"/"+blob] = b
 		return nil
 	}
 	return errors.New("SaveBlockBlob failed")
 }
 
+//GetBlockBlob mock
+func (msc *MockStorageClient) GetBlockBlob(container, blob string, options *azStorage.GetBlobOptions) ([]byte, error) {
+	if !msc.FailGetBlockBlob {
+		return []byte{}, nil
+	}
+	return nil, errors.New("GetBlockBlob failed")
+}
+
+//GetContainerURL mock
+func (msc *MockStorageClient) GetContainerURL(container string) string {
+	return "https://fakestorageaccount.blob.core.windows.net/" + container
+}
+
+//GetContainerSASToken mock
+func (msc *MockStorageClient) GetContainerSASToken(container string, expiry time.Time) (string, error) {
+	return "sp=r&sr=c&se=" + url.QueryEscape(expiry.UTC().Format(time.RFC3339)) + "&sig=fake", nil
+}
+
 //AddAcceptLanguages mock
 func (mc *MockAKSEngineClient) AddAcceptLanguages(languages []string) {}
 
@@ -516,6 +616,47 @@ func (mc *MockAKSEngineClient) EnsureResourceGroup(ctx context.Context, resource
 	return nil, nil
 }
 
+//DeleteResourceGroup mock
+func (mc *MockAKSEngineClient) DeleteResourceGroup(ctx context.Context, resourceGroup string) error {
+	if mc.FailDeleteResourceGroup {
+		return errors.New("DeleteResourceGroup failed")
+	}
+
+	return nil
+}
+
+//ListResources mock
+func (mc *MockAKSEngineClient) ListResources(ctx context.Context, resourceGroup string) (ResourceListResultPage, error) {
+	if mc.FailListResources {
+		return &MockResourceListResultPage{}, errors.New("ListResources failed")
+	}
+	if mc.FakeListResourcesResult == nil {
+		//return 0 resources by default
+		mc.FakeListResourcesResult = func() []resources.GenericResource {
+			return []resources.GenericResource{}
+		}
+	}
+
+	values := mc.FakeListResourcesResult()
+	return &MockResourceListResultPage{
+		Fn: func(lastResults resources.ListResult) (resources.ListResult, error) {
+			return resources.ListResult{}, nil
+		},
+		Rlr: resources.ListResult{
+			Value: &values,
+		},
+	}, nil
+}
+
+//DeleteResourceByID mock
+func (mc *MockAKSEngineClient) DeleteResourceByID(ctx context.Context, resourceID string) error {
+	if mc.FailDeleteResourceByID {
+		return errors.New("DeleteResourceByID failed")
+	}
+
+	return nil
+}
+
 //ListVirtualMachines mock
 func (mc *MockAKSEngineClient) ListVirtualMachines(ctx context.Context, resourceGroup string) (VirtualMachineListResultPage, error) {
 	if mc.FailListVirtualMachines {
@@ -677,16 +818,28 @@ func (mc *MockAKSEngineClient) MakeFakeVirtualMachine(vmName string, orchestrato
 		tags = nil
 	}
 
+	osDisk := &compute.OSDisk{
+		Vhd: &compute.VirtualHardDisk{
+			URI: &validOSDiskResourceName},
+	}
+	if mc.ShouldUseEphemeralOSDisk {
+		osDiskName := vm1Name + "_OsDisk"
+		osDisk = &compute.OSDisk{
+			Name:        &osDiskName,
+			ManagedDisk: &compute.ManagedDiskParameters{},
+			DiffDiskSettings: &compute.DiffDiskSettings{
+				Option: compute.Local,
+			},
+		}
+	}
+
 	return compute.VirtualMachine{
 		Name:     &vm1Name,
 		Tags:     tags,
 		Identity: vmIdentity,
 		VirtualMachineProperties: &compute.VirtualMachineProperties{
 			StorageProfile: &compute.StorageProfile{
-				OsDisk: &compute.OSDisk{
-					Vhd: &compute.VirtualHardDisk{
-						URI: &validOSDiskResourceName},
-				},
+				OsDisk: osDisk,
 			},
 			NetworkProfile: &compute.NetworkProfile{
 				NetworkInterfaces: &[]compute.NetworkInterfaceReference{
@@ -717,6 +870,24 @@ func (mc *MockAKSEngineClient) DeleteVirtualMachineScaleSetVM(ctx context.Contex
 	return nil
 }
 
+//ReimageVirtualMachineScaleSetVM mock
+func (mc *MockAKSEngineClient) ReimageVirtualMachineScaleSetVM(ctx context.Context, resourceGroup, virtualMachineScaleSet, instanceID string) error {
+	if mc.FailReimageVirtualMachineScaleSetVM {
+		return errors.New("ReimageVirtualMachineScaleSetVM failed")
+	}
+
+	return nil
+}
+
+//UpdateVirtualMachineScaleSetVMs mock
+func (mc *MockAKSEngineClient) UpdateVirtualMachineScaleSetVMs(ctx context.Context, resourceGroup, virtualMachineScaleSet string, instanceIDs []string) error {
+	if mc.FailUpdateVirtualMachineScaleSetVMs {
+		return errors.New("UpdateVirtualMachineScaleSetVMs failed")
+	}
+
+	return nil
+}
+
 //SetVirtualMachineScaleSetCapacity mock
 func (mc *MockAKSEngineClient) SetVirtualMachineScaleSetCapacity(ctx context.Context, resourceGroup, virtualMachineScaleSet string, sku compute.Sku, location string) error {
 	if mc.FailSetVirtualMachineScaleSetCapacity {
@@ -756,6 +927,9 @@ func (mc *MockAKSEngineClient) GetStorageClient(ctx context.Context, resourceGro
 	if mc.FailGetStorageClient {
 		return nil, errors.New("GetStorageClient failed")
 	}
+	if mc.MockStorageClient != nil {
+		return mc.MockStorageClient, nil
+	}
 
 	return &MockStorageClient{}, nil
 }
@@ -769,6 +943,46 @@ func (mc *MockAKSEngineClient) DeleteNetworkInterface(ctx context.Context, resou
 	return nil
 }
 
+//GetNetworkInterface mock
+func (mc *MockAKSEngineClient) GetNetworkInterface(ctx context.Context, resourceGroup, nicName string) (network.Interface, error) {
+	if mc.FailGetNetworkInterface {
+		return network.Interface{}, errors.New("GetNetworkInterface failed")
+	}
+	return mc.getFakeNetworkInterface("", "", nicName), nil
+}
+
+//GetVirtualMachineScaleSetNetworkInterface mock
+func (mc *MockAKSEngineClient) GetVirtualMachineScaleSetNetworkInterface(ctx context.Context, resourceGroup, virtualMachineScaleSet, instanceID, nicName string) (network.Interface, error) {
+	if mc.FailGetNetworkInterface {
+		return network.Interface{}, errors.New("GetVirtualMachineScaleSetNetworkInterface failed")
+	}
+	return mc.getFakeNetworkInterface(virtualMachineScaleSet, instanceID, nicName), nil
+}
+
+func (mc *MockAKSEngineClient) getFakeNetworkInterface(virtualMachineScaleSet, instanceID, nicName string) network.Interface {
+	if mc.FakeGetNetworkInterfaceResult != nil {
+		return mc.FakeGetNetworkInterfaceResult(virtualMachineScaleSet, instanceID, nicName)
+	}
+	return MakeFakeNetworkInterface(nicName, "10.240.0.4")
+}
+
+// MakeFakeNetworkInterface creates a fake network interface with a single IP configuration
+func MakeFakeNetworkInterface(nicName, privateIPAddress string) network.Interface {
+	return network.Interface{
+		Name: to.StringPtr(nicName),
+		InterfacePropertiesFormat: &network.InterfacePropertiesFormat{
+			IPConfigurations: &[]network.InterfaceIPConfiguration{
+				{
+					InterfaceIPConfigurationPropertiesFormat: &network.InterfaceIPConfigurationPropertiesFormat{
+						Primary:          to.BoolPtr(true),
+						PrivateIPAddress: to.StringPtr(privateIPAddress),
+					},
+				},
+			},
+		},
+	}
+}
+
 var validOSDiskResourceName = "https://00k71r4u927seqiagnt0.blob.core.windows.net/osdisk/k8s-agentpool1-12345678-0-osdisk.vhd"
 var validNicResourceName = "/subscriptions/DEC923E3-1EF1-4745-9516-37906D56DEC4/resourceGroups/acsK8sTest/providers/Microsoft.Network/networkInterfaces/k8s-agent-12345678-nic-0"
 
@@ -790,7 +1004,8 @@ func (mc *MockAKSEngineClient) CreateGraphPrincipal(ctx context.Context, service
 // CreateApp is a simpler method for creating an application
 func (mc *MockAKSEngineClient) CreateApp(ctx context.Context, applicationName, applicationURL string, replyURLs *[]string, requiredResourceAccess *[]graphrbac.RequiredResourceAccess) (result graphrbac.Application, servicePrincipalObjectID, secret string, err error) {
 	return graphrbac.Application{
-		AppID: to.StringPtr("app-id"),
+		AppID:    to.StringPtr("app-id"),
+		ObjectID: to.StringPtr("app-object-id"),
 	}, "client-id", "client-secret", nil
 }
 
@@ -820,6 +1035,9 @@ func (mc *MockAKSEngineClient) CreateRoleAssignmentSimple(ctx context.Context, a
 
 // DeleteManagedDisk is a wrapper around disksClient.Delete
 func (mc *MockAKSEngineClient) DeleteManagedDisk(ctx context.Context, resourceGroupName string, diskName string) error {
+	if mc.FailDeleteManagedDisk {
+		return errors.New("DeleteManagedDisk failed")
+	}
 	return nil
 }
 
@@ -943,3 +1161,47 @@ func (mc *MockAKSEngineClient) ListRoleAssignmentsForPrincipal(ctx context.Conte
 		},
 	}, nil
 }
+
+// ListPodDisruptionBudgets returns the Pod Disruption Budgets of all namespaces
+func (mkc *MockKubernetesClient) ListPodDisruptionBudgets() (*policy.PodDisruptionBudgetList, error) {
+	if mkc.FailListPodDisruptionBudgets {
+		return nil, errors.New("ListPodDisruptionBudgets failed")
+	}
+	if mkc.PodDisruptionBudgetList != nil {
+		return mkc.PodDisruptionBudgetList, nil
+	}
+	return &policy.PodDisruptionBudgetList{}, nil
+}
+
+// ListDeployments returns the Deployments of all namespaces
+func (mkc *MockKubernetesClient) ListDeployments() (*appsv1.DeploymentList, error) {
+	if mkc.FailListWorkloads {
+		return nil, errors.New("ListDeployments failed")
+	}
+	if mkc.DeploymentList != nil {
+		return mkc.DeploymentList, nil
+	}
+	return &appsv1.DeploymentList{}, nil
+}
+
+// ListDaemonSets returns the DaemonSets of all namespaces
+func (mkc *MockKubernetesClient) ListDaemonSets() (*appsv1.DaemonSetList, error) {
+	if mkc.FailListWorkloads {
+		return nil, errors.New("ListDaemonSets failed")
+	}
+	if mkc.DaemonSetList != nil {
+		return mkc.DaemonSetList, nil
+	}
+	return &appsv1.DaemonSetList{}, nil
+}
+
+// ListStatefulSets returns the StatefulSets of all namespaces
+func (mkc *MockKubernetesClient) ListStatefulSets() (*appsv1.StatefulSetList, error) {
+	if mkc.FailListWorkloads {
+		return nil, errors.New("ListStatefulSets failed")
+	}
+	if mkc.StatefulSetList != nil {
+		return mkc.StatefulSetList, nil
+	}
+	return &appsv1.StatefulSetList{}, nil
+}
@@ -5,6 +5,8 @@ package armhelpers
 
 import (
 	"context"
+
+	"github.com/Azure/azure-sdk-for-go/services/network/mgmt/2018-08-01/network"
 )
 
 // DeleteNetworkInterface deletes the specified network interface.
@@ -21,3 +23,13 @@ func (az *AzureClient) DeleteNetworkInterface(ctx context.Context, resourceGroup
 	_, err = future.Result(az.interfacesClient)
 	return err
 }
+
+// GetNetworkInterface retrieves the specified network interface.
+func (az *AzureClient) GetNetworkInterface(ctx context.Context, resourceGroup, nicName string) (network.Interface, error) {
+	return az.interfacesClient.Get(ctx, resourceGroup, nicName, "")
+}
+
+// GetVirtualMachineScaleSetNetworkInterface retrieves the specified network interface of a VM in a VMSS.
+func (az *AzureClient) GetVirtualMachineScaleSetNetworkInterface(ctx context.Context, resourceGroup, virtualMachineScaleSet, instanceID, nicName string) (network.Interface, error) {
+	return az.interfacesClient.GetVirtualMachineScaleSetNetworkInterface(ctx, resourceGroup, virtualMachineScaleSet, instanceID, nicName, "")
+}
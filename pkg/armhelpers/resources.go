@@ -0,0 +1,115 @@
+// Copyright (c) Microsoft Corporation. All rights reserved.
+// Licensed under the MIT license.
+
+package armhelpers
+
+import (
+	"context"
+	"net/http"
+	"strings"
+
+	"github.com/Azure/azure-sdk-for-go/services/resources/mgmt/2018-05-01/resources"
+	"github.com/Azure/go-autorest/autorest"
+	"github.com/Azure/go-autorest/autorest/azure"
+	"github.com/pkg/errors"
+)
+
+// ListResources lists the resources of a resource group
+func (az *AzureClient) ListResources(ctx context.Context, resourceGroup string) (ResourceListResultPage, error) {
+	page, err := az.genericResourcesClient.ListByResourceGroup(ctx, resourceGroup, "", "", nil)
+	return &page, err
+}
+
+// DeleteResourceByID deletes the resource with the given ID, using the latest stable API version of its resource type
+func (az *AzureClient) DeleteResourceByID(ctx context.Context, resourceID string) error {
+	namespace, resourceType, err := ParseResourceType(resourceID)
+	if err != nil {
+		return err
+	}
+	provider, err := az.providersClient.Get(ctx, namespace, "")
+	if err != nil {
+		return errors.Wrapf(err, "getting resource provider %s", namespace)
+	}
+	apiVersion, err := LatestAPIVersion(provider, resourceType)
+	if err != nil {
+		return err
+	}
+	return DeleteGenericResource(ctx, az.genericResourcesClient, resourceID, apiVersion)
+}
+
+// DeleteGenericResource deletes the resource with the given ID with apiVersion, the fixed API version of the resources
+// client being unknown to most resource types. A resource already gone is not an error.
+func DeleteGenericResource(ctx context.Context, client resources.Client, resourceID, apiVersion string) error {
+	req, err := client.DeleteByIDPreparer(ctx, strings.TrimPrefix(resourceID, "/"))
+	if err != nil {
+		return errors.Wrapf(err, "preparing the deletion of %s", resourceID)
+	}
+	query := req.URL.Query()
+	query.Set("api-version", apiVersion)
+	req.URL.RawQuery = query.Encode()
+
+	resp, err := autorest.SendWithSender(client, req,
+		autorest.DoRetryForStatusCodes(client.RetryAttempts, client.RetryDuration, autorest.StatusCodesForRetry...))
+	if err != nil {
+		return errors.Wrapf(err, "deleting %s", resourceID)
+	}
+	if resp.StatusCode == http.StatusNotFound {
+		resp.Body.Close()
+		return nil
+	}
+	future := resources.DeleteByIDFuture{}
+	if future.Future, err = azure.NewFutureFromResponse(resp); err != nil {
+		return errors.Wrapf(err, "deleting %s", resourceID)
+	}
+	if err = future.WaitForCompletionRef(ctx, client.Client); err != nil {
+		return errors.Wrapf(err, "deleting %s", resourceID)
+	}
+	_, err = future.Result(client)
+	return err
+}
+
+// ParseResourceType returns the resource provider namespace and the resource type of the resource with the given ID,
+// e.g. Microsoft.Network and virtualNetworks/subnets for a subnet
+func ParseResourceType(resourceID string) (namespace, resourceType string, err error) {
+	parts := strings.Split(strings.Trim(resourceID, "/"), "/")
+	for i := 0; i < len(parts)-2; i++ {
+		if !strings.EqualFold(parts[i], "providers") {
+			continue
+		}
+		types := []string{}
+		for j := i + 2; j < len(parts); j += 2 {
+			types = append(types, parts[j])
+		}
+		return parts[i+1], strings.Join(types, "/"), nil
+	}
+	return "", "", errors.Errorf("%s is not the ID of a resource", resourceID)
+}
+
+// LatestAPIVersion returns the latest API version of the resource type of provider, the latest stable one if any
+func LatestAPIVersion(provider resources.Provider, resourceType string) (string, error) {
+	if provider.ResourceTypes != nil {
+		for _, t := range *provider.ResourceTypes {
+			if t.ResourceType == nil || !strings.EqualFold(*t.ResourceType, resourceType) || t.APIVersions == nil || len(*t.APIVersions) == 0 {
+				continue
+			}
+			latest := ""
+			for _, version := range *t.APIVersions {
+				if strings.Contains(version, "-preview") {
+					continue
+				}
+				if version > latest {
+					latest = version
+				}
+			}
+			if latest == "" {
+				latest = (*t.APIVersions)[0]
+			}
+			return latest, nil
+		}
+	}
+	namespace := ""
+	if provider.Namespace != nil {
+		namespace = *provider.Namespace
+	}
+	return "", errors.Errorf("no API version of resource type %s/%s", namespace, resourceType)
+}
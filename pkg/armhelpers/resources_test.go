@@ -0,0 +1,105 @@
+// Copyright (c) Microsoft Corporation. All rights reserved.
+// Licensed under the MIT license.
+
+package armhelpers
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+
+	"github.com/Azure/azure-sdk-for-go/services/resources/mgmt/2018-05-01/resources"
+	"github.com/Azure/go-autorest/autorest/to"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("ParseResourceType tests", func() {
+	It("Should return the namespace and the type of a resource", func() {
+		namespace, resourceType, err := ParseResourceType("/subscriptions/sub/resourceGroups/rg/providers/Microsoft.Network/loadBalancers/k8s-master-lb-12345678")
+		Expect(err).To(BeNil())
+		Expect(namespace).To(Equal("Microsoft.Network"))
+		Expect(resourceType).To(Equal("loadBalancers"))
+	})
+
+	It("Should return the type of a nested resource", func() {
+		namespace, resourceType, err := ParseResourceType("/subscriptions/sub/resourceGroups/rg/providers/Microsoft.Network/virtualNetworks/k8s-vnet-12345678/subnets/k8s-subnet")
+		Expect(err).To(BeNil())
+		Expect(namespace).To(Equal("Microsoft.Network"))
+		Expect(resourceType).To(Equal("virtualNetworks/subnets"))
+	})
+
+	It("Should fail if the ID is not a resource ID", func() {
+		_, _, err := ParseResourceType("/subscriptions/sub/resourceGroups/rg")
+		Expect(err).NotTo(BeNil())
+		Expect(err.Error()).To(Equal("/subscriptions/sub/resourceGroups/rg is not the ID of a resource"))
+	})
+})
+
+var _ = Describe("LatestAPIVersion tests", func() {
+	provider := resources.Provider{
+		Namespace: to.StringPtr("Microsoft.Network"),
+		ResourceTypes: &[]resources.ProviderResourceType{
+			{ResourceType: to.StringPtr("virtualNetworks"), APIVersions: &[]string{"2019-02-01-preview", "2018-12-01", "2018-08-01"}},
+			{ResourceType: to.StringPtr("privateEndpoints"), APIVersions: &[]string{"2019-04-01-preview"}},
+		},
+	}
+
+	It("Should return the latest stable version", func() {
+		apiVersion, err := LatestAPIVersion(provider, "VirtualNetworks")
+		Expect(err).To(BeNil())
+		Expect(apiVersion).To(Equal("2018-12-01"))
+	})
+
+	It("Should return a preview version if there is no stable one", func() {
+		apiVersion, err := LatestAPIVersion(provider, "privateEndpoints")
+		Expect(err).To(BeNil())
+		Expect(apiVersion).To(Equal("2019-04-01-preview"))
+	})
+
+	It("Should fail if the resource type is unknown", func() {
+		_, err := LatestAPIVersion(provider, "loadBalancers")
+		Expect(err).NotTo(BeNil())
+		Expect(err.Error()).To(Equal("no API version of resource type Microsoft.Network/loadBalancers"))
+	})
+})
+
+var _ = Describe("DeleteGenericResource tests", func() {
+	const resourceID = "/subscriptions/sub/resourceGroups/rg/providers/Microsoft.Network/loadBalancers/k8s-master-lb-12345678"
+
+	It("Should delete the resource with the API version", func() {
+		var requests []*http.Request
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			requests = append(requests, r)
+			w.WriteHeader(http.StatusOK)
+		}))
+		defer server.Close()
+
+		err := DeleteGenericResource(context.Background(), resources.NewClientWithBaseURI(server.URL, "sub"), resourceID, "2018-12-01")
+		Expect(err).To(BeNil())
+		Expect(requests).To(HaveLen(1))
+		Expect(requests[0].Method).To(Equal(http.MethodDelete))
+		Expect(requests[0].URL.Path).To(Equal(resourceID))
+		Expect(requests[0].URL.Query().Get("api-version")).To(Equal("2018-12-01"))
+	})
+
+	It("Should not fail if the resource is already deleted", func() {
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(http.StatusNotFound)
+		}))
+		defer server.Close()
+
+		err := DeleteGenericResource(context.Background(), resources.NewClientWithBaseURI(server.URL, "sub"), resourceID, "2018-12-01")
+		Expect(err).To(BeNil())
+	})
+
+	It("Should fail if the deletion fails", func() {
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(http.StatusConflict)
+		}))
+		defer server.Close()
+
+		err := DeleteGenericResource(context.Background(), resources.NewClientWithBaseURI(server.URL, "sub"), resourceID, "2018-12-01")
+		Expect(err).NotTo(BeNil())
+	})
+})
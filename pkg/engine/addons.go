@@ -0,0 +1,217 @@
+// Copyright (c) Microsoft Corporation. All rights reserved.
+// Licensed under the MIT license.
+
+package engine
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/Azure/aks-engine/pkg/api"
+	"github.com/pkg/errors"
+)
+
+// AddonManifest is the manifest of an addon deployed to /etc/kubernetes/addons on the masters
+type AddonManifest struct {
+	// File is the name of the manifest in /etc/kubernetes/addons
+	File    string
+	Enabled bool
+	// Content is the manifest, with the values known at template generation time resolved. It is empty for disabled addons.
+	Content string
+}
+
+// elbSvcAddonFile creates a service with a random name at template generation time,
+// rendering it again would create a second service
+const elbSvcAddonFile = "elb-svc.yaml"
+
+// addonParameterPlaceholders maps the placeholders of the addon manifests to the template parameters
+// the master provisioning script replaces them with
+var addonParameterPlaceholders = map[string]map[string]string{
+	"kube-proxy-daemonset.yaml": {
+		"<img>":  "kubernetesKubeProxySpec",
+		"<CIDR>": "kubeClusterCidr",
+	},
+	"kube-dns-deployment.yaml": {
+		"<img>":        "kubernetesKubeDNSSpec",
+		"<imgMasq>":    "kubernetesDNSMasqSpec",
+		"<imgHealthz>": "kubernetesExecHealthzSpec",
+		"<imgSidecar>": "kubernetesDNSSidecarSpec",
+		"<domain>":     "kubernetesKubeletClusterDomain",
+		"<clustIP>":    "kubeDNSServiceIP",
+	},
+	"coredns.yaml": {
+		"<img>":     "kubernetesCoreDNSSpec",
+		"<domain>":  "kubernetesKubeletClusterDomain",
+		"<clustIP>": "kubeDNSServiceIP",
+	},
+	"aad-default-admin-group-rbac.yaml": {
+		"<gID>": "aadAdminGroupId",
+	},
+	"cluster-autoscaler-deployment.yaml": {
+		"<cloud>":              "kubernetesClusterAutoscalerAzureCloud",
+		"<useManagedIdentity>": "kubernetesClusterAutoscalerUseManagedIdentity",
+	},
+	"calico-daemonset.yaml": {
+		"<kubeClusterCidr>": "kubeClusterCidr",
+	},
+	"flannel-daemonset.yaml": {
+		"<kubeClusterCidr>": "kubeClusterCidr",
+	},
+}
+
+// GetAddonManifests returns the manifests of the addons of a Kubernetes cluster, enabled or not, in the order they are deployed.
+// The placeholders resolved by the master provisioning script from template parameters are resolved too, the ones
+// resolved from the master's /etc/kubernetes/azure.json are left for the caller.
+func GetAddonManifests(cs *api.ContainerService) ([]AddonManifest, error) {
+	properties := cs.Properties
+	if !properties.OrchestratorProfile.IsKubernetes() {
+		return nil, errors.New("addons are only supported on Kubernetes clusters")
+	}
+	params, err := getParameters(cs, DefaultGeneratorCode, "")
+	if err != nil {
+		return nil, errors.Wrap(err, "getting template parameters")
+	}
+
+	addons, err := getAddonManifests(properties, params)
+	if err != nil {
+		return nil, err
+	}
+	containerAddons, err := getContainerAddonManifests(properties, params)
+	if err != nil {
+		return nil, err
+	}
+	manifests := []AddonManifest{}
+	for _, m := range append(addons, containerAddons...) {
+		if m.File == elbSvcAddonFile {
+			continue
+		}
+		manifests = append(manifests, m)
+	}
+	return manifests, nil
+}
+
+// getAddonManifests returns the manifests of the addons of kubernetesAddonSettingsInit, enabled or not
+func getAddonManifests(properties *api.Properties, params paramsMap) ([]AddonManifest, error) {
+	versions := strings.Split(properties.OrchestratorProfile.OrchestratorVersion, ".")
+	manifests := []AddonManifest{}
+	for _, setting := range kubernetesAddonSettingsInit(properties) {
+		setting := setting
+		var err error
+		manifests, err = addAddonManifest(manifests, setting, params, properties, func() (string, error) {
+			if setting.rawScript != "" {
+				return getStringFromBase64(setting.rawScript)
+			}
+			b, err := Asset(getCustomDataFilePath(setting.sourceFile, "k8s/addons", versions[0]+"."+versions[1]))
+			return strings.Replace(string(b), "\r\n", "\n", -1), err
+		})
+		if err != nil {
+			return nil, err
+		}
+	}
+	return manifests, nil
+}
+
+// getContainerAddonManifests returns the manifests of the container addons, enabled or not, by addon name
+func getContainerAddonManifests(properties *api.Properties, params paramsMap) ([]AddonManifest, error) {
+	settingsMap := kubernetesContainerAddonSettingsInit(properties)
+	var addonNames []string
+	for addonName := range settingsMap {
+		addonNames = append(addonNames, addonName)
+	}
+	sort.Strings(addonNames)
+
+	manifests := []AddonManifest{}
+	for _, addonName := range addonNames {
+		addonName, setting := addonName, settingsMap[addonName]
+		var err error
+		manifests, err = addAddonManifest(manifests, setting, params, properties, func() (string, error) {
+			return getContainerAddonManifest(properties, addonName, setting, "k8s/containeraddons")
+		})
+		if err != nil {
+			return nil, err
+		}
+	}
+	return manifests, nil
+}
+
+// addAddonManifest adds the manifest of an addon setting to manifests, rendering it if the setting is enabled.
+// Several settings share a file when only one of them can be enabled.
+func addAddonManifest(manifests []AddonManifest, setting kubernetesFeatureSetting, params paramsMap, properties *api.Properties, getContent func() (string, error)) ([]AddonManifest, error) {
+	i := -1
+	for j, m := range manifests {
+		if m.File == setting.destinationFile {
+			i = j
+			break
+		}
+	}
+	if i < 0 {
+		i = len(manifests)
+		manifests = append(manifests, AddonManifest{File: setting.destinationFile})
+	}
+	if !setting.isEnabled || manifests[i].Enabled {
+		return manifests, nil
+	}
+	content, err := getContent()
+	if err != nil {
+		return nil, errors.Wrapf(err, "rendering addon manifest %s", setting.destinationFile)
+	}
+	manifests[i].Enabled = true
+	manifests[i].Content = substituteAddonPlaceholders(setting.destinationFile, content, params, properties)
+	return manifests, nil
+}
+
+// getKubeProxyCommand returns the command of the kube-proxy container, as a YAML flow sequence without brackets.
+// A kube-proxy image runs kube-proxy itself rather than the proxy command of hyperkube.
+func getKubeProxyCommand(o *api.OrchestratorProfile) string {
+	if _, ok := o.GetK8sComponents()["kube-proxy"]; ok {
+		return "/usr/local/bin/kube-proxy"
+	}
+	return "/hyperkube, proxy"
+}
+
+// substituteAddonPlaceholders resolves the placeholders of an addon manifest the same way the master provisioning script does
+func substituteAddonPlaceholders(file, content string, params paramsMap, properties *api.Properties) string {
+	kubernetesConfig := properties.OrchestratorProfile.KubernetesConfig
+	switch file {
+	case "kube-proxy-daemonset.yaml":
+		content = strings.Replace(content, "<kubeProxyCommand>", getKubeProxyCommand(properties.OrchestratorProfile), -1)
+		featureGates := "ExperimentalCriticalPodAnnotation=true"
+		if properties.FeatureFlags.IsFeatureEnabled("EnableIPv6DualStack") {
+			featureGates += ",IPv6DualStack=true"
+		}
+		content = strings.Replace(content, "<kubeProxyMode>", string(kubernetesConfig.ProxyMode), -1)
+		content = strings.Replace(content, "<kubeProxyFeatureGates>", featureGates, -1)
+	case "calico-daemonset.yaml":
+		if kubernetesConfig.NetworkPlugin == NetworkPluginAzure {
+			content = deleteLineRanges(content, "initContainers", "cni-net-dir")
+		} else {
+			content = strings.Replace(content, "<calicoIPAMConfig>", `{"type": "host-local", "subnet": "usePodCidr"}`, -1)
+			content = strings.Replace(content, "azv", "cali", -1)
+		}
+	}
+
+	for placeholder, name := range addonParameterPlaceholders[file] {
+		if param, ok := params[name].(paramsMap); ok {
+			content = strings.Replace(content, placeholder, fmt.Sprint(param["value"]), -1)
+		}
+	}
+	return content
+}
+
+// deleteLineRanges deletes the lines from each line containing start to the next line containing end, like sed "/start/,/end/d"
+func deleteLineRanges(content, start, end string) string {
+	var lines []string
+	inRange := false
+	for _, line := range strings.Split(content, "\n") {
+		switch {
+		case inRange:
+			inRange = !strings.Contains(line, end)
+		case strings.Contains(line, start):
+			inRange = true
+		default:
+			lines = append(lines, line)
+		}
+	}
+	return strings.Join(lines, "\n")
+}
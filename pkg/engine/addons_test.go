@@ -0,0 +1,142 @@
+// Copyright (c) Microsoft Corporation. All rights reserved.
+// Licensed under the MIT license.
+
+package engine
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/Azure/go-autorest/autorest/to"
+
+	"github.com/Azure/aks-engine/pkg/api"
+)
+
+func TestGetAddonManifests(t *testing.T) {
+	cs := api.CreateMockContainerService("testcluster", "1.13.5", 3, 2, false)
+	cs.Location = "westus2"
+	cs.Properties.OrchestratorProfile.KubernetesConfig.LoadBalancerSku = "Standard"
+	cs.Properties.OrchestratorProfile.KubernetesConfig.Addons = []api.KubernetesAddon{
+		{
+			Name:    DefaultTillerAddonName,
+			Enabled: to.BoolPtr(false),
+		},
+		{
+			Name:    DefaultClusterAutoscalerAddonName,
+			Enabled: to.BoolPtr(true),
+			Config: map[string]string{
+				"min-nodes": "1",
+				"max-nodes": "5",
+			},
+		},
+	}
+	if _, err := cs.SetPropertiesDefaults(false, false); err != nil {
+		t.Fatalf("unexpected error setting defaults: %s", err)
+	}
+
+	manifests, err := GetAddonManifests(cs)
+	if err != nil {
+		t.Fatalf("unexpected error getting addon manifests: %s", err)
+	}
+
+	byFile := map[string]AddonManifest{}
+	for _, m := range manifests {
+		if _, ok := byFile[m.File]; ok {
+			t.Errorf("manifest %s is returned more than once", m.File)
+		}
+		byFile[m.File] = m
+	}
+
+	if _, ok := byFile[elbSvcAddonFile]; ok {
+		t.Errorf("manifest %s should not be returned", elbSvcAddonFile)
+	}
+
+	kubeProxy := byFile["kube-proxy-daemonset.yaml"]
+	if !kubeProxy.Enabled {
+		t.Fatalf("kube-proxy should be enabled")
+	}
+	for _, placeholder := range []string{"<img>", "<kubeProxyCommand>", "<CIDR>", "<kubeProxyMode>", "<kubeProxyFeatureGates>"} {
+		if strings.Contains(kubeProxy.Content, placeholder) {
+			t.Errorf("kube-proxy placeholder %s was not resolved", placeholder)
+		}
+	}
+	if !strings.Contains(kubeProxy.Content, cs.Properties.OrchestratorProfile.KubernetesConfig.ClusterSubnet) {
+		t.Errorf("kube-proxy manifest should contain the cluster subnet")
+	}
+
+	if m := byFile["kube-dns-deployment.yaml"]; m.Enabled || m.Content != "" {
+		t.Errorf("kube-dns should be disabled on Kubernetes 1.13")
+	}
+	if m := byFile["coredns.yaml"]; !m.Enabled || strings.Contains(m.Content, "<clustIP>") {
+		t.Errorf("coredns should be enabled with its placeholders resolved")
+	}
+	if m := byFile["azure-storage-classes.yaml"]; !m.Enabled {
+		t.Errorf("azure storage classes should be enabled")
+	}
+	if m := byFile["kube-tiller-deployment.yaml"]; m.Enabled {
+		t.Errorf("tiller should be disabled")
+	}
+
+	autoscaler := byFile["cluster-autoscaler-deployment.yaml"]
+	if !autoscaler.Enabled {
+		t.Fatalf("cluster-autoscaler should be enabled")
+	}
+	if strings.Contains(autoscaler.Content, "<cloud>") || !strings.Contains(autoscaler.Content, "--nodes=1:5") {
+		t.Errorf("cluster-autoscaler manifest should be rendered with its settings")
+	}
+	if !strings.Contains(autoscaler.Content, "<clientID>") {
+		t.Errorf("cluster-autoscaler credentials should be left for the masters to resolve")
+	}
+}
+
+func TestGetAddonManifestsKubeProxyOverride(t *testing.T) {
+	cs := api.CreateMockContainerService("testcluster", "1.13.5", 3, 2, false)
+	cs.Location = "westus2"
+	cs.Properties.OrchestratorProfile.KubernetesConfig.ComponentOverrides = map[string]string{"kube-proxy": "kube-proxy:v1.13.5"}
+	if _, err := cs.SetPropertiesDefaults(false, false); err != nil {
+		t.Fatalf("unexpected error setting defaults: %s", err)
+	}
+
+	manifests, err := GetAddonManifests(cs)
+	if err != nil {
+		t.Fatalf("unexpected error getting addon manifests: %s", err)
+	}
+	for _, m := range manifests {
+		if m.File != "kube-proxy-daemonset.yaml" {
+			continue
+		}
+		if !strings.Contains(m.Content, "image: "+cs.Properties.OrchestratorProfile.KubernetesConfig.KubernetesImageBase+"kube-proxy:v1.13.5\n") {
+			t.Errorf("expected kube-proxy to run the overridden image")
+		}
+		if !strings.Contains(m.Content, "- command: [/usr/local/bin/kube-proxy]\n") {
+			t.Errorf("expected kube-proxy to run the kube-proxy binary of the image")
+		}
+	}
+}
+
+func TestGetKubeProxyCommand(t *testing.T) {
+	cs := api.CreateMockContainerService("testcluster", "1.13.5", 3, 2, false)
+	if command := getKubeProxyCommand(cs.Properties.OrchestratorProfile); command != "/hyperkube, proxy" {
+		t.Errorf("expected kube-proxy to run the proxy command of hyperkube, got %q", command)
+	}
+	cs.Properties.OrchestratorProfile.KubernetesConfig.ComponentOverrides = map[string]string{"kube-proxy": "kube-proxy:v1.13.5"}
+	if command := getKubeProxyCommand(cs.Properties.OrchestratorProfile); command != "/usr/local/bin/kube-proxy" {
+		t.Errorf("expected kube-proxy to run the kube-proxy binary, got %q", command)
+	}
+}
+
+func TestGetAddonManifestsNotKubernetes(t *testing.T) {
+	cs := api.CreateMockContainerService("testcluster", "1.13.5", 3, 2, false)
+	cs.Properties.OrchestratorProfile.OrchestratorType = api.DCOS
+	if _, err := GetAddonManifests(cs); err == nil {
+		t.Errorf("expected an error for a DCOS cluster")
+	}
+}
+
+func TestDeleteLineRanges(t *testing.T) {
+	content := "a\ninitContainers:\n- b\n  cni-net-dir\nc\ninitContainers:\nd"
+	expected := "a\nc"
+	if actual := deleteLineRanges(content, "initContainers", "cni-net-dir"); actual != expected {
+		t.Errorf("expected %q, got %q", expected, actual)
+	}
+}
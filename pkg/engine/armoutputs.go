@@ -80,6 +80,8 @@ func getMasterOutputs(cs *api.ContainerService) map[string]interface{} {
 
 	if !cs.Properties.OrchestratorProfile.IsPrivateCluster() {
 		masterFQDN = "[reference(concat('Microsoft.Network/publicIPAddresses/', variables('masterPublicIPAddressName'))).dnsSettings.fqdn]"
+	} else if cs.Properties.OrchestratorProfile.KubernetesConfig.IsPrivateDNSZoneEnabled() {
+		masterFQDN = "[variables('privateAPIServerFQDN')]"
 	}
 
 	outputs["masterFQDN"] = map[string]interface{}{
@@ -191,3 +191,36 @@ func TestK8sOutputsWithAvSets(t *testing.T) {
 		t.Errorf("unexpected error while comparing output maps: %s", diff)
 	}
 }
+
+func TestGetMasterOutputsPrivateDNSZone(t *testing.T) {
+	cs := &api.ContainerService{
+		Properties: &api.Properties{
+			MasterProfile: &api.MasterProfile{
+				Count:     1,
+				DNSPrefix: "blueorange",
+			},
+			OrchestratorProfile: &api.OrchestratorProfile{
+				OrchestratorType: api.Kubernetes,
+				KubernetesConfig: &api.KubernetesConfig{
+					PrivateCluster: &api.PrivateCluster{
+						Enabled: to.BoolPtr(true),
+					},
+				},
+			},
+		},
+	}
+
+	outputMap := getMasterOutputs(cs)
+	if actual := outputMap["masterFQDN"].(map[string]interface{})["value"]; actual != "" {
+		t.Errorf("expected an empty masterFQDN for a private cluster without a private DNS zone, got %s", actual)
+	}
+
+	cs.Properties.OrchestratorProfile.KubernetesConfig.PrivateCluster.PrivateDNSZone = &api.PrivateDNSZone{
+		Enabled: to.BoolPtr(true),
+		Name:    "privatelink.westus2.cloudapp.azure.com",
+	}
+	outputMap = getMasterOutputs(cs)
+	if actual := outputMap["masterFQDN"].(map[string]interface{})["value"]; actual != "[variables('privateAPIServerFQDN')]" {
+		t.Errorf("expected masterFQDN to be the private API server FQDN, got %s", actual)
+	}
+}
@@ -34,6 +34,10 @@ func GenerateARMResources(cs *api.ContainerService) []interface{} {
 		armResources = append(armResources, userAssignedID, msiRoleAssignment)
 	}
 
+	if cs.Properties.IsProximityPlacementGroupEnabled() {
+		armResources = append(armResources, createProximityPlacementGroup())
+	}
+
 	profiles := cs.Properties.AgentPoolProfiles
 
 	for _, profile := range profiles {
@@ -48,6 +52,22 @@ func GenerateARMResources(cs *api.ContainerService) []interface{} {
 		}
 	}
 
+	for _, profile := range profiles {
+		if profile.IsDedicatedNetworkSecurityGroupEnabled() {
+			armResources = append(armResources, createAgentPoolNetworkSecurityGroup(cs, profile))
+		}
+	}
+
+	if kubernetesConfig.IsLoadBalancerEgress() {
+		egressProfile := kubernetesConfig.EgressProfile
+		if egressProfile.OutboundIPPrefixID == "" {
+			for i := 0; i < egressProfile.OutboundIPCount; i++ {
+				armResources = append(armResources, createAgentOutboundPublicIPAddress(i))
+			}
+		}
+		armResources = append(armResources, CreateAgentLoadBalancer(cs))
+	}
+
 	isCustomVnet := cs.Properties.AreAgentProfilesCustomVNET()
 	isAzureCNI := cs.Properties.OrchestratorProfile.IsAzureCNI()
 
@@ -57,11 +77,11 @@ func GenerateARMResources(cs *api.ContainerService) []interface{} {
 			armResources = append(armResources, hostedMasterVnet)
 		}
 
-		if !isAzureCNI {
-			armResources = append(armResources, createRouteTable())
+		if !isAzureCNI || kubernetesConfig.IsUserDefinedRoutingEgress() {
+			armResources = append(armResources, createRouteTable(cs))
 		}
 
-		hostedMasterNsg := createHostedMasterNSG()
+		hostedMasterNsg := createHostedMasterNSG(cs)
 		armResources = append(armResources, hostedMasterNsg)
 	} else {
 		isMasterVMSS := cs.Properties.MasterProfile != nil && cs.Properties.MasterProfile.IsVirtualMachineScaleSets()
@@ -73,7 +93,7 @@ func GenerateARMResources(cs *api.ContainerService) []interface{} {
 		}
 
 		armResources = append(armResources, masterResources...)
-
+		armResources = append(armResources, createDiskEncryptionSetRoleAssignments(cs)...)
 	}
 
 	return armResources
@@ -113,6 +133,10 @@ func createKubernetesAgentVMASResources(cs *api.ContainerService, profile *api.A
 				AvailabilitySetProperties: &compute.AvailabilitySetProperties{},
 				Type:                      to.StringPtr("Microsoft.Compute/availabilitySets"),
 			},
+			ProximityPlacementGroupID: getAgentProximityPlacementGroupID(profile),
+		}
+		if profile.IsProximityPlacementGroupEnabled() {
+			avSet.DependsOn = []string{proximityPlacementGroupDependency}
 		}
 
 		agentVMASResources = append(agentVMASResources, avSet)
@@ -4,6 +4,7 @@
 package engine
 
 import (
+	"bytes"
 	"encoding/json"
 
 	"github.com/Azure/azure-sdk-for-go/services/cosmos-db/mgmt/2015-04-08/documentdb"
@@ -34,12 +35,36 @@ func (arm ARMResource) MarshalJSON() ([]byte, error) {
 type VirtualMachineARM struct {
 	ARMResource
 	compute.VirtualMachine
+	// The fields below are not part of the vendored compute API version, MarshalJSON adds them to the resource.
+	DiskEncryptionSetID       string `json:"-"`
+	ProximityPlacementGroupID string `json:"-"`
+}
+
+// MarshalJSON is the custom marshaler for VirtualMachineARM.
+func (vm VirtualMachineARM) MarshalJSON() ([]byte, error) {
+	type noMethod VirtualMachineARM
+	return marshalComputeResource(noMethod(vm), vm.DiskEncryptionSetID, []string{"properties", "storageProfile"}, map[string]string{
+		"proximityPlacementGroup": vm.ProximityPlacementGroupID,
+	})
 }
 
 // VirtualMachineScaleSetARM embeds the ARMResource type in compute.VirtualMachineScaleSet.
 type VirtualMachineScaleSetARM struct {
 	ARMResource
 	compute.VirtualMachineScaleSet
+	// The fields below are not part of the vendored compute API version, MarshalJSON adds them to the resource.
+	DiskEncryptionSetID       string `json:"-"`
+	ProximityPlacementGroupID string `json:"-"`
+	HostGroupID               string `json:"-"`
+}
+
+// MarshalJSON is the custom marshaler for VirtualMachineScaleSetARM.
+func (vmss VirtualMachineScaleSetARM) MarshalJSON() ([]byte, error) {
+	type noMethod VirtualMachineScaleSetARM
+	return marshalComputeResource(noMethod(vmss), vmss.DiskEncryptionSetID, []string{"properties", "virtualMachineProfile", "storageProfile"}, map[string]string{
+		"proximityPlacementGroup": vmss.ProximityPlacementGroupID,
+		"hostGroup":               vmss.HostGroupID,
+	})
 }
 
 // VirtualMachineExtensionARM embeds the ARMResource type in compute.VirtualMachineExtension.
@@ -58,6 +83,16 @@ type RoleAssignmentARM struct {
 type AvailabilitySetARM struct {
 	ARMResource
 	compute.AvailabilitySet
+	// ProximityPlacementGroupID is not part of the vendored compute API version, MarshalJSON adds it to the resource.
+	ProximityPlacementGroupID string `json:"-"`
+}
+
+// MarshalJSON is the custom marshaler for AvailabilitySetARM.
+func (as AvailabilitySetARM) MarshalJSON() ([]byte, error) {
+	type noMethod AvailabilitySetARM
+	return marshalComputeResource(noMethod(as), "", nil, map[string]string{
+		"proximityPlacementGroup": as.ProximityPlacementGroupID,
+	})
 }
 
 // StorageAccountARM embeds the ARMResource type in storage.Account.
@@ -131,3 +166,181 @@ type ImageARM struct {
 	ARMResource
 	compute.Image
 }
+
+// PrivateDNSZoneARM embeds the ARMResource type in PrivateDNSZone.
+type PrivateDNSZoneARM struct {
+	ARMResource
+	PrivateDNSZone
+}
+
+// PrivateDNSZoneRecordSetARM embeds the ARMResource type in PrivateDNSZoneRecordSet.
+type PrivateDNSZoneRecordSetARM struct {
+	ARMResource
+	PrivateDNSZoneRecordSet
+}
+
+// VirtualNetworkLinkARM embeds the ARMResource type in VirtualNetworkLink.
+type VirtualNetworkLinkARM struct {
+	ARMResource
+	VirtualNetworkLink
+}
+
+// DeploymentARM embeds the ARMResource type in Deployment.
+type DeploymentARM struct {
+	ARMResource
+	Deployment
+}
+
+// ProximityPlacementGroupARM embeds the ARMResource type in ProximityPlacementGroup.
+type ProximityPlacementGroupARM struct {
+	ARMResource
+	ProximityPlacementGroup
+}
+
+// The vendored SDK has no privatedns package, so the Microsoft.Network/privateDnsZones
+// resources below mirror the 2018-09-01 REST shapes.
+
+// PrivateDNSZone describes a Microsoft.Network/privateDnsZones resource.
+type PrivateDNSZone struct {
+	Name     *string `json:"name,omitempty"`
+	Type     *string `json:"type,omitempty"`
+	Location *string `json:"location,omitempty"`
+}
+
+// MarshalJSON is the custom marshaler for PrivateDNSZone.
+func (z PrivateDNSZone) MarshalJSON() ([]byte, error) {
+	type noMethod PrivateDNSZone
+	return json.Marshal(noMethod(z))
+}
+
+// PrivateDNSZoneRecordSet describes a record set of a private DNS zone.
+type PrivateDNSZoneRecordSet struct {
+	Name                               *string `json:"name,omitempty"`
+	Type                               *string `json:"type,omitempty"`
+	*PrivateDNSZoneRecordSetProperties `json:"properties,omitempty"`
+}
+
+// MarshalJSON is the custom marshaler for PrivateDNSZoneRecordSet.
+func (r PrivateDNSZoneRecordSet) MarshalJSON() ([]byte, error) {
+	type noMethod PrivateDNSZoneRecordSet
+	return json.Marshal(noMethod(r))
+}
+
+// PrivateDNSZoneRecordSetProperties holds the TTL and A records of a private DNS zone record set.
+type PrivateDNSZoneRecordSetProperties struct {
+	TTL      *int64                   `json:"ttl,omitempty"`
+	ARecords *[]PrivateDNSZoneARecord `json:"aRecords,omitempty"`
+}
+
+// PrivateDNSZoneARecord is an IPv4 address record.
+type PrivateDNSZoneARecord struct {
+	IPv4Address *string `json:"ipv4Address,omitempty"`
+}
+
+// VirtualNetworkLink describes the link of a private DNS zone to a virtual network.
+type VirtualNetworkLink struct {
+	Name                          *string `json:"name,omitempty"`
+	Type                          *string `json:"type,omitempty"`
+	Location                      *string `json:"location,omitempty"`
+	*VirtualNetworkLinkProperties `json:"properties,omitempty"`
+}
+
+// MarshalJSON is the custom marshaler for VirtualNetworkLink.
+func (l VirtualNetworkLink) MarshalJSON() ([]byte, error) {
+	type noMethod VirtualNetworkLink
+	return json.Marshal(noMethod(l))
+}
+
+// VirtualNetworkLinkProperties holds the linked virtual network and whether VMs in it auto-register.
+type VirtualNetworkLinkProperties struct {
+	VirtualNetwork      *network.SubResource `json:"virtualNetwork,omitempty"`
+	RegistrationEnabled *bool                `json:"registrationEnabled"`
+}
+
+// Deployment describes a nested Microsoft.Resources/deployments resource, which may target
+// another subscription and resource group.
+type Deployment struct {
+	Name           *string               `json:"name,omitempty"`
+	Type           *string               `json:"type,omitempty"`
+	SubscriptionID *string               `json:"subscriptionId,omitempty"`
+	ResourceGroup  *string               `json:"resourceGroup,omitempty"`
+	Properties     *DeploymentProperties `json:"properties,omitempty"`
+}
+
+// MarshalJSON is the custom marshaler for Deployment.
+func (d Deployment) MarshalJSON() ([]byte, error) {
+	type noMethod Deployment
+	return json.Marshal(noMethod(d))
+}
+
+// DeploymentProperties holds the mode and inline template of a nested deployment.
+type DeploymentProperties struct {
+	Mode     string                 `json:"mode"`
+	Template map[string]interface{} `json:"template"`
+}
+
+// ProximityPlacementGroup describes a Microsoft.Compute/proximityPlacementGroups resource, which the
+// vendored compute API version does not have.
+type ProximityPlacementGroup struct {
+	Name       *string                            `json:"name,omitempty"`
+	Type       *string                            `json:"type,omitempty"`
+	Location   *string                            `json:"location,omitempty"`
+	Properties *ProximityPlacementGroupProperties `json:"properties,omitempty"`
+}
+
+// MarshalJSON is the custom marshaler for ProximityPlacementGroup.
+func (g ProximityPlacementGroup) MarshalJSON() ([]byte, error) {
+	type noMethod ProximityPlacementGroup
+	return json.Marshal(noMethod(g))
+}
+
+// ProximityPlacementGroupProperties holds the type of a proximity placement group.
+type ProximityPlacementGroupProperties struct {
+	ProximityPlacementGroupType string `json:"proximityPlacementGroupType,omitempty"`
+}
+
+// marshalComputeResource marshals a VM, scale set or availability set and adds the properties that are not part of
+// the vendored compute API version: the disk encryption set of the managed disks of the storage profile found at
+// storageProfilePath, and the sub resources referenced by ID under properties.
+func marshalComputeResource(v interface{}, diskEncryptionSetID string, storageProfilePath []string, subResources map[string]string) ([]byte, error) {
+	b, err := json.Marshal(v)
+	if err != nil {
+		return nil, err
+	}
+	hasSubResources := false
+	for _, id := range subResources {
+		hasSubResources = hasSubResources || id != ""
+	}
+	if diskEncryptionSetID == "" && !hasSubResources {
+		return b, nil
+	}
+
+	var resource map[string]interface{}
+	decoder := json.NewDecoder(bytes.NewReader(b))
+	decoder.UseNumber()
+	if err = decoder.Decode(&resource); err != nil {
+		return nil, err
+	}
+
+	if diskEncryptionSetID != "" {
+		if err = setDiskEncryptionSet(resource, diskEncryptionSetID, storageProfilePath...); err != nil {
+			return nil, err
+		}
+	}
+	if hasSubResources {
+		properties, ok := resource["properties"].(map[string]interface{})
+		if !ok {
+			properties = map[string]interface{}{}
+			resource["properties"] = properties
+		}
+		for name, id := range subResources {
+			if id != "" {
+				properties[name] = map[string]string{
+					"id": id,
+				}
+			}
+		}
+	}
+
+	return json.Marshal(resource)
+}
@@ -365,12 +365,34 @@ func getK8sMasterVars(cs *api.ContainerService) (map[string]interface{}, error)
 	}
 	masterVars["primaryScaleSetName"] = cs.Properties.GetPrimaryScaleSetName()
 
+	if cs.Properties.IsProximityPlacementGroupEnabled() {
+		masterVars["proximityPlacementGroupName"] = "[concat(parameters('orchestratorName'), '-ppg-', parameters('nameSuffix'))]"
+		masterVars["proximityPlacementGroupID"] = "[resourceId('Microsoft.Compute/proximityPlacementGroups', variables('proximityPlacementGroupName'))]"
+	}
+
 	if isHostedMaster {
 		masterVars["kubernetesAPIServerIP"] = "[parameters('kubernetesEndpoint')]"
 		masterVars["agentNamePrefix"] = "[concat(parameters('orchestratorName'), '-agentpool-', parameters('nameSuffix'), '-')]"
 	} else {
 		if cs.Properties.OrchestratorProfile.IsPrivateCluster() {
 			masterVars["kubeconfigServer"] = "[concat('https://', variables('kubernetesAPIServerIP'), ':443')]"
+			if kubernetesConfig.IsPrivateDNSZoneEnabled() {
+				zone := kubernetesConfig.PrivateCluster.PrivateDNSZone
+				masterVars["privateDNSZoneName"] = zone.Name
+				masterVars["privateAPIServerFQDN"] = cs.Properties.GetPrivateAPIServerFQDN()
+				masterVars["kubeconfigServer"] = "[concat('https://', variables('privateAPIServerFQDN'), ':443')]"
+				if masterProfile.IsCustomVNET() {
+					masterVars["privateDNSZoneVnetID"] = "[substring(parameters('masterVnetSubnetID'), 0, indexOf(parameters('masterVnetSubnetID'), '/subnets/'))]"
+				} else {
+					masterVars["privateDNSZoneVnetID"] = "[variables('vnetID')]"
+				}
+				if zone.IsExistingZone() {
+					// /subscriptions/<sub>/resourceGroups/<rg>/providers/Microsoft.Network/privateDnsZones/<name>
+					zoneIDSegments := strings.Split(zone.ID, "/")
+					masterVars["privateDNSZoneSubscriptionID"] = zoneIDSegments[2]
+					masterVars["privateDNSZoneResourceGroup"] = zoneIDSegments[4]
+				}
+			}
 			if provisionJumpbox {
 				masterVars["jumpboxOSDiskName"] = "[concat(parameters('jumpboxVMName'), '-osdisk')]"
 				masterVars["jumpboxPublicIpAddressName"] = "[concat(parameters('jumpboxVMName'), '-ip')]"
@@ -402,6 +424,12 @@ func getK8sMasterVars(cs *api.ContainerService) (map[string]interface{}, error)
 			masterVars["masterLbIPConfigName"] = "[concat(parameters('orchestratorName'), '-master-lbFrontEnd-', parameters('nameSuffix'))]"
 			masterVars["masterLbName"] = "[concat(parameters('orchestratorName'), '-master-lb-', parameters('nameSuffix'))]"
 			masterVars["kubeconfigServer"] = "[concat('https://', variables('masterFqdnPrefix'), '.', variables('location'), '.', parameters('fqdnEndpointSuffix'))]"
+			if cs.Properties.FeatureFlags.IsFeatureEnabled("EnableIPv6DualStack") {
+				masterVars["masterPublicIPv6AddressName"] = "[concat(variables('masterPublicIPAddressName'), '-ipv6')]"
+				masterVars["masterLbIPv6ConfigName"] = "[concat(variables('masterLbIPConfigName'), '-ipv6')]"
+				masterVars["masterLbIPv6ConfigID"] = "[concat(variables('masterLbID'),'/frontendIPConfigurations/', variables('masterLbIPv6ConfigName'))]"
+				masterVars["masterLbIPv6BackendPoolName"] = "[concat(variables('masterLbBackendPoolName'), '-ipv6')]"
+			}
 		}
 
 		if masterProfile.Count > 1 {
@@ -468,6 +496,14 @@ func getK8sMasterVars(cs *api.ContainerService) (map[string]interface{}, error)
 		}
 	}
 
+	if kubernetesConfig.IsLoadBalancerEgress() {
+		// the cloud provider manages the load balancer named after the cluster, services and egress share it
+		masterVars["agentLbName"] = "[parameters('masterEndpointDNSNamePrefix')]"
+		masterVars["agentLbID"] = "[resourceId('Microsoft.Network/loadBalancers',variables('agentLbName'))]"
+		masterVars["agentLbBackendPoolName"] = "[parameters('masterEndpointDNSNamePrefix')]"
+		masterVars["agentOutboundIPNamePrefix"] = "[concat(parameters('orchestratorName'), '-agent-outbound-ip-', parameters('nameSuffix'), '-')]"
+	}
+
 	masterVars["subscriptionId"] = "[subscription().subscriptionId]"
 	masterVars["contributorRoleDefinitionId"] = "[concat('/subscriptions/', subscription().subscriptionId, '/providers/Microsoft.Authorization/roleDefinitions/', 'b24988ac-6180-42a0-ab88-20f7382dd24c')]"
 	masterVars["readerRoleDefinitionId"] = "[concat('/subscriptions/', subscription().subscriptionId, '/providers/Microsoft.Authorization/roleDefinitions/', 'acdd72a7-3385-48ef-bd42-f606fba81ae7')]"
@@ -542,6 +578,25 @@ func getK8sAgentVars(cs *api.ContainerService, profile *api.AgentPoolProfile) ma
 		agentVars[agentSubnetName] = fmt.Sprintf("[variables('subnetName')]")
 	}
 
+	if profile.HasOwnNetworkSecurityGroup() {
+		agentNSGName := fmt.Sprintf("%sNSGName", agentName)
+		agentNSGID := fmt.Sprintf("%sNSGID", agentName)
+		if profile.NetworkSecurityGroupID != "" {
+			agentVars[agentNSGName] = profile.NetworkSecurityGroupID[strings.LastIndex(profile.NetworkSecurityGroupID, "/")+1:]
+			agentVars[agentNSGID] = profile.NetworkSecurityGroupID
+		} else {
+			agentVars[agentNSGName] = fmt.Sprintf("[concat(parameters('orchestratorName'), '-%s-', parameters('nameSuffix'), '-nsg')]", agentName)
+			agentVars[agentNSGID] = fmt.Sprintf("[resourceId('Microsoft.Network/networkSecurityGroups',variables('%s'))]", agentNSGName)
+		}
+	}
+
+	if profile.HasOwnRouteTable() {
+		routeTableParts := strings.Split(profile.RouteTableID, "/")
+		agentVars[fmt.Sprintf("%sRouteTableName", agentName)] = routeTableParts[len(routeTableParts)-1]
+		agentVars[fmt.Sprintf("%sRouteTableResourceGroup", agentName)] = routeTableParts[4]
+		agentVars[fmt.Sprintf("%sRouteTableID", agentName)] = profile.RouteTableID
+	}
+
 	agentVars[agentOsImageOffer] = fmt.Sprintf("[parameters('%sosImageOffer')]", agentName)
 	agentVars[agentOsImageSku] = fmt.Sprintf("[parameters('%sosImageSKU')]", agentName)
 	agentVars[agentOsImagePublisher] = fmt.Sprintf("[parameters('%sosImagePublisher')]", agentName)
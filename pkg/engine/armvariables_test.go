@@ -6,6 +6,7 @@ package engine
 import (
 	"encoding/json"
 	"fmt"
+	"strings"
 	"testing"
 
 	"github.com/google/go-cmp/cmp"
@@ -800,3 +801,72 @@ func TestK8sVarsMastersOnly(t *testing.T) {
 		t.Errorf("unexpected diff while expecting equal structs: %s", diff)
 	}
 }
+
+func TestK8sAgentVarsNetworkIsolation(t *testing.T) {
+	cs := api.CreateMockContainerService("testcluster", "1.13.5", 3, 2, true)
+	profile := cs.Properties.AgentPoolProfiles[0]
+	profile.Name = "pool1"
+
+	actual := getK8sAgentVars(cs, profile)
+	for _, name := range []string{"pool1NSGName", "pool1NSGID", "pool1RouteTableName", "pool1RouteTableResourceGroup", "pool1RouteTableID"} {
+		if _, ok := actual[name]; ok {
+			t.Errorf("expected no %s variable for an agent pool using the cluster network resources", name)
+		}
+	}
+
+	profile.EnableDedicatedNetworkSecurityGroup = to.BoolPtr(true)
+	profile.RouteTableID = "/subscriptions/SUB_ID/resourceGroups/RG_NAME/providers/Microsoft.Network/routeTables/pool1-routetable"
+	actual = getK8sAgentVars(cs, profile)
+	expected := map[string]string{
+		"pool1NSGName":                 "[concat(parameters('orchestratorName'), '-pool1-', parameters('nameSuffix'), '-nsg')]",
+		"pool1NSGID":                   "[resourceId('Microsoft.Network/networkSecurityGroups',variables('pool1NSGName'))]",
+		"pool1RouteTableName":          "pool1-routetable",
+		"pool1RouteTableResourceGroup": "RG_NAME",
+		"pool1RouteTableID":            "/subscriptions/SUB_ID/resourceGroups/RG_NAME/providers/Microsoft.Network/routeTables/pool1-routetable",
+	}
+	for name, value := range expected {
+		if actual[name] != value {
+			t.Errorf("expected %s to be %s, got %v", name, value, actual[name])
+		}
+	}
+}
+
+func TestK8sVarsPrivateDNSZone(t *testing.T) {
+	cs := api.CreateMockContainerService("testcluster", "1.13.5", 1, 2, false)
+	cs.Properties.OrchestratorProfile.KubernetesConfig.PrivateCluster = &api.PrivateCluster{
+		Enabled: to.BoolPtr(true),
+		PrivateDNSZone: &api.PrivateDNSZone{
+			Enabled: to.BoolPtr(true),
+			ID:      "/subscriptions/SUB_ID/resourceGroups/dns/providers/Microsoft.Network/privateDnsZones/privatelink.westus2.cloudapp.azure.com",
+			Name:    "privatelink.westus2.cloudapp.azure.com",
+		},
+	}
+
+	actual, err := getK8sMasterVars(cs)
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	expected := map[string]string{
+		"privateDNSZoneName":           "privatelink.westus2.cloudapp.azure.com",
+		"privateAPIServerFQDN":         strings.ToLower(cs.Properties.MasterProfile.DNSPrefix) + ".privatelink.westus2.cloudapp.azure.com",
+		"kubeconfigServer":             "[concat('https://', variables('privateAPIServerFQDN'), ':443')]",
+		"privateDNSZoneVnetID":         "[variables('vnetID')]",
+		"privateDNSZoneSubscriptionID": "SUB_ID",
+		"privateDNSZoneResourceGroup":  "dns",
+	}
+	for name, value := range expected {
+		if actual[name] != value {
+			t.Errorf("expected %s to be %s, got %v", name, value, actual[name])
+		}
+	}
+
+	cs.Properties.MasterProfile.VnetSubnetID = "/subscriptions/SUB_ID/resourceGroups/RG_NAME/providers/Microsoft.Network/virtualNetworks/VNET_NAME/subnets/SUBNET_NAME"
+	actual, err = getK8sMasterVars(cs)
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	expectedVnetID := "[substring(parameters('masterVnetSubnetID'), 0, indexOf(parameters('masterVnetSubnetID'), '/subnets/'))]"
+	if actual["privateDNSZoneVnetID"] != expectedVnetID {
+		t.Errorf("expected privateDNSZoneVnetID to be %s, got %v", expectedVnetID, actual["privateDNSZoneVnetID"])
+	}
+}
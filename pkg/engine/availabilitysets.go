@@ -37,9 +37,14 @@ func CreateAvailabilitySet(cs *api.ContainerService, isManagedDisks bool) Availa
 		}
 	}
 
+	if cs.Properties.MasterProfile.IsProximityPlacementGroupEnabled() {
+		armResource.DependsOn = []string{proximityPlacementGroupDependency}
+	}
+
 	return AvailabilitySetARM{
-		ARMResource:     armResource,
-		AvailabilitySet: avSet,
+		ARMResource:               armResource,
+		AvailabilitySet:           avSet,
+		ProximityPlacementGroupID: getMasterProximityPlacementGroupID(cs.Properties.MasterProfile),
 	}
 }
 
@@ -64,8 +69,13 @@ func createAgentAvailabilitySets(profile *api.AgentPoolProfile) AvailabilitySetA
 		}
 	}
 
+	if profile.IsProximityPlacementGroupEnabled() {
+		armResource.DependsOn = []string{proximityPlacementGroupDependency}
+	}
+
 	return AvailabilitySetARM{
-		ARMResource:     armResource,
-		AvailabilitySet: avSet,
+		ARMResource:               armResource,
+		AvailabilitySet:           avSet,
+		ProximityPlacementGroupID: getAgentProximityPlacementGroupID(profile),
 	}
 }
@@ -0,0 +1,151 @@
+// Copyright (c) Microsoft Corporation. All rights reserved.
+// Licensed under the MIT license.
+
+package engine
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/Azure/aks-engine/pkg/api"
+	"github.com/Azure/azure-sdk-for-go/services/preview/authorization/mgmt/2018-09-01-preview/authorization"
+	"github.com/Azure/go-autorest/autorest/to"
+	"github.com/pkg/errors"
+)
+
+// apiVersionComputeDiskEncryptionSet is the first compute API version that accepts a disk encryption set on managed disks
+const apiVersionComputeDiskEncryptionSet = "2019-07-01"
+
+// readerRoleDefinitionID is the ID of the built-in Reader role
+const readerRoleDefinitionID = "acdd72a7-3385-48ef-bd42-f606fba81ae7"
+
+// setDiskEncryptionSet adds the disk encryption set to the managed OS and data disks of the storage profile found at
+// path in the unmarshaled VM or scale set.
+func setDiskEncryptionSet(resource map[string]interface{}, diskEncryptionSetID string, path ...string) error {
+	storageProfile := resource
+	for _, key := range path {
+		var ok bool
+		if storageProfile, ok = storageProfile[key].(map[string]interface{}); !ok {
+			return errors.Errorf("cannot set the disk encryption set, the resource has no %s", strings.Join(path, "."))
+		}
+	}
+
+	var disks []interface{}
+	if osDisk, ok := storageProfile["osDisk"]; ok {
+		disks = append(disks, osDisk)
+	}
+	if dataDisks, ok := storageProfile["dataDisks"].([]interface{}); ok {
+		disks = append(disks, dataDisks...)
+	}
+	for _, d := range disks {
+		disk, ok := d.(map[string]interface{})
+		if !ok {
+			continue
+		}
+		managedDisk, ok := disk["managedDisk"].(map[string]interface{})
+		if !ok {
+			managedDisk = map[string]interface{}{}
+			disk["managedDisk"] = managedDisk
+		}
+		managedDisk["diskEncryptionSet"] = map[string]string{
+			"id": diskEncryptionSetID,
+		}
+	}
+
+	return nil
+}
+
+// getDiskEncryptionSetIDs returns the distinct disk encryption sets of the master and agent pools, in order.
+func getDiskEncryptionSetIDs(cs *api.ContainerService) []string {
+	var ids []string
+	seen := map[string]bool{}
+	add := func(id string) {
+		if id != "" && !seen[strings.ToLower(id)] {
+			seen[strings.ToLower(id)] = true
+			ids = append(ids, id)
+		}
+	}
+	if cs.Properties.MasterProfile != nil {
+		add(cs.Properties.MasterProfile.DiskEncryptionSetID)
+	}
+	for _, profile := range cs.Properties.AgentPoolProfiles {
+		add(profile.DiskEncryptionSetID)
+	}
+	return ids
+}
+
+// createDiskEncryptionSetRoleAssignments grants the cluster's managed identity the Reader role on each disk
+// encryption set, which the cloud provider needs to create and scale VMs with encrypted disks. The disk encryption
+// sets may live in another subscription or resource group, so each role assignment is created by a nested deployment
+// scoped to the disk encryption set. Clusters using a service principal must grant it the role themselves.
+func createDiskEncryptionSetRoleAssignments(cs *api.ContainerService) []interface{} {
+	kubernetesConfig := cs.Properties.OrchestratorProfile.KubernetesConfig
+	if kubernetesConfig == nil || !kubernetesConfig.UseManagedIdentity {
+		return nil
+	}
+	userAssignedIDEnabled := kubernetesConfig.UserAssignedID != ""
+	isMasterVMSS := cs.Properties.MasterProfile != nil && cs.Properties.MasterProfile.IsVirtualMachineScaleSets()
+
+	var resources []interface{}
+	for i, id := range getDiskEncryptionSetIDs(cs) {
+		// /subscriptions/<sub>/resourceGroups/<rg>/providers/Microsoft.Compute/diskEncryptionSets/<name>
+		idSegments := strings.Split(id, "/")
+		subscriptionID, resourceGroup, name := idSegments[2], idSegments[4], idSegments[8]
+
+		roleAssignment := RoleAssignmentARM{
+			ARMResource: ARMResource{
+				APIVersion: "[variables('apiVersionAuthorizationUser')]",
+			},
+			RoleAssignment: authorization.RoleAssignment{
+				Type: to.StringPtr("Microsoft.Compute/diskEncryptionSets/providers/roleAssignments"),
+				RoleAssignmentPropertiesWithScope: &authorization.RoleAssignmentPropertiesWithScope{
+					RoleDefinitionID: to.StringPtr(fmt.Sprintf("/subscriptions/%s/providers/Microsoft.Authorization/roleDefinitions/%s", subscriptionID, readerRoleDefinitionID)),
+					PrincipalType:    authorization.ServicePrincipal,
+				},
+			},
+		}
+		deployment := DeploymentARM{
+			ARMResource: ARMResource{
+				APIVersion: apiVersionDeployments,
+			},
+			Deployment: Deployment{
+				Type:           to.StringPtr("Microsoft.Resources/deployments"),
+				SubscriptionID: to.StringPtr(subscriptionID),
+				ResourceGroup:  to.StringPtr(resourceGroup),
+			},
+		}
+
+		switch {
+		case userAssignedIDEnabled:
+			roleAssignment.Name = to.StringPtr(fmt.Sprintf("[concat('%s/Microsoft.Authorization/', guid(concat(variables('userAssignedID'), 'roleAssignment', '%s')))]", name, id))
+			roleAssignment.PrincipalID = to.StringPtr("[reference(resourceId('Microsoft.ManagedIdentity/userAssignedIdentities', variables('userAssignedID'))).principalId]")
+			deployment.DependsOn = []string{"[concat('Microsoft.ManagedIdentity/userAssignedIdentities/', variables('userAssignedID'))]"}
+			deployment.Name = to.StringPtr(fmt.Sprintf("[concat(variables('masterVMNamePrefix'), 'des-', %d)]", i))
+		case isMasterVMSS:
+			roleAssignment.Name = to.StringPtr(fmt.Sprintf("[concat('%s/Microsoft.Authorization/', guid(concat('Microsoft.Compute/virtualMachineScaleSets/', variables('masterVMNamePrefix'), 'vmss', 'vmidentity', '%s')))]", name, id))
+			roleAssignment.PrincipalID = to.StringPtr("[reference(resourceId('Microsoft.Compute/virtualMachineScaleSets', concat(variables('masterVMNamePrefix'), 'vmss')), '2017-03-30', 'Full').identity.principalId]")
+			deployment.DependsOn = []string{"[concat('Microsoft.Compute/virtualMachineScaleSets/', variables('masterVMNamePrefix'), 'vmss')]"}
+			deployment.Name = to.StringPtr(fmt.Sprintf("[concat(variables('masterVMNamePrefix'), 'vmss-des-', %d)]", i))
+		default:
+			roleAssignment.Name = to.StringPtr(fmt.Sprintf("[concat('%s/Microsoft.Authorization/', guid(concat('Microsoft.Compute/virtualMachines/', variables('masterVMNamePrefix'), copyIndex(variables('masterOffset')), 'vmidentity', '%s')))]", name, id))
+			roleAssignment.PrincipalID = to.StringPtr("[reference(resourceId('Microsoft.Compute/virtualMachines', concat(variables('masterVMNamePrefix'), copyIndex(variables('masterOffset')))), '2017-03-30', 'Full').identity.principalId]")
+			deployment.Copy = map[string]string{
+				"count": "[sub(variables('masterCount'), variables('masterOffset'))]",
+				"name":  fmt.Sprintf("desRoleAssignmentLoop%d", i),
+			}
+			deployment.DependsOn = []string{"[concat('Microsoft.Compute/virtualMachines/', variables('masterVMNamePrefix'), copyIndex(variables('masterOffset')))]"}
+			deployment.Name = to.StringPtr(fmt.Sprintf("[concat(variables('masterVMNamePrefix'), copyIndex(variables('masterOffset')), '-des-', %d)]", i))
+		}
+
+		deployment.Properties = &DeploymentProperties{
+			Mode: "Incremental",
+			Template: map[string]interface{}{
+				"$schema":        "https://schema.management.azure.com/schemas/2015-01-01/deploymentTemplate.json#",
+				"contentVersion": "1.0.0.0",
+				"resources":      []interface{}{roleAssignment},
+			},
+		}
+		resources = append(resources, deployment)
+	}
+	return resources
+}
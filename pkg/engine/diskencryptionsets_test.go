@@ -0,0 +1,225 @@
+// Copyright (c) Microsoft Corporation. All rights reserved.
+// Licensed under the MIT license.
+
+package engine
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/Azure/aks-engine/pkg/api"
+	"github.com/Azure/azure-sdk-for-go/services/compute/mgmt/2018-10-01/compute"
+	"github.com/Azure/azure-sdk-for-go/services/preview/authorization/mgmt/2018-09-01-preview/authorization"
+	"github.com/Azure/go-autorest/autorest/to"
+	"github.com/google/go-cmp/cmp"
+)
+
+const testDiskEncryptionSetID = "/subscriptions/SUB_ID/resourceGroups/RG_NAME/providers/Microsoft.Compute/diskEncryptionSets/des"
+
+func getDiskEncryptionSetContainerService(kubernetesConfig *api.KubernetesConfig) *api.ContainerService {
+	return &api.ContainerService{
+		Properties: &api.Properties{
+			MasterProfile: &api.MasterProfile{
+				Count:               3,
+				DNSPrefix:           "mycluster",
+				DiskEncryptionSetID: testDiskEncryptionSetID,
+			},
+			AgentPoolProfiles: []*api.AgentPoolProfile{
+				{
+					Name:                "pool1",
+					DiskEncryptionSetID: strings.ToUpper(testDiskEncryptionSetID),
+				},
+				{
+					Name: "pool2",
+				},
+			},
+			OrchestratorProfile: &api.OrchestratorProfile{
+				OrchestratorType: api.Kubernetes,
+				KubernetesConfig: kubernetesConfig,
+			},
+		},
+	}
+}
+
+func TestVirtualMachineARMMarshalJSONDiskEncryptionSet(t *testing.T) {
+	vm := VirtualMachineARM{
+		ARMResource: ARMResource{
+			APIVersion: apiVersionComputeDiskEncryptionSet,
+		},
+		VirtualMachine: compute.VirtualMachine{
+			Name: to.StringPtr("vm"),
+			VirtualMachineProperties: &compute.VirtualMachineProperties{
+				StorageProfile: &compute.StorageProfile{
+					OsDisk: &compute.OSDisk{
+						CreateOption: compute.DiskCreateOptionTypesFromImage,
+						DiskSizeGB:   to.Int32Ptr(128),
+					},
+					DataDisks: &[]compute.DataDisk{
+						{
+							CreateOption: compute.DiskCreateOptionTypesEmpty,
+							Lun:          to.Int32Ptr(0),
+							ManagedDisk: &compute.ManagedDiskParameters{
+								StorageAccountType: compute.StorageAccountTypesPremiumLRS,
+							},
+						},
+					},
+				},
+			},
+		},
+		DiskEncryptionSetID: testDiskEncryptionSetID,
+	}
+
+	b, err := json.Marshal(vm)
+	if err != nil {
+		t.Fatalf("unexpected error marshaling the VM: %s", err)
+	}
+	actual := string(b)
+	for _, expected := range []string{
+		`"apiVersion":"2019-07-01"`,
+		`"osDisk":{"createOption":"FromImage","diskSizeGB":128,"managedDisk":{"diskEncryptionSet":{"id":"` + testDiskEncryptionSetID + `"}}}`,
+		`"managedDisk":{"diskEncryptionSet":{"id":"` + testDiskEncryptionSetID + `"},"storageAccountType":"Premium_LRS"}`,
+	} {
+		if !strings.Contains(actual, expected) {
+			t.Errorf("expected %s to contain %s", actual, expected)
+		}
+	}
+	if strings.Contains(actual, "DiskEncryptionSetID") {
+		t.Errorf("expected %s not to contain the DiskEncryptionSetID field", actual)
+	}
+
+	vm.DiskEncryptionSetID = ""
+	b, err = json.Marshal(vm)
+	if err != nil {
+		t.Fatalf("unexpected error marshaling the VM: %s", err)
+	}
+	if strings.Contains(string(b), "diskEncryptionSet") {
+		t.Errorf("expected %s not to contain a disk encryption set", string(b))
+	}
+}
+
+func TestVirtualMachineScaleSetARMMarshalJSONDiskEncryptionSet(t *testing.T) {
+	vmss := VirtualMachineScaleSetARM{
+		VirtualMachineScaleSet: compute.VirtualMachineScaleSet{
+			VirtualMachineScaleSetProperties: &compute.VirtualMachineScaleSetProperties{
+				VirtualMachineProfile: &compute.VirtualMachineScaleSetVMProfile{
+					StorageProfile: &compute.VirtualMachineScaleSetStorageProfile{
+						OsDisk: &compute.VirtualMachineScaleSetOSDisk{
+							CreateOption: compute.DiskCreateOptionTypesFromImage,
+						},
+					},
+				},
+			},
+		},
+		DiskEncryptionSetID: testDiskEncryptionSetID,
+	}
+
+	b, err := json.Marshal(vmss)
+	if err != nil {
+		t.Fatalf("unexpected error marshaling the scale set: %s", err)
+	}
+	expected := `"osDisk":{"createOption":"FromImage","managedDisk":{"diskEncryptionSet":{"id":"` + testDiskEncryptionSetID + `"}}}`
+	if !strings.Contains(string(b), expected) {
+		t.Errorf("expected %s to contain %s", string(b), expected)
+	}
+
+	vmss.VirtualMachineScaleSetProperties = nil
+	if _, err = json.Marshal(vmss); err == nil {
+		t.Errorf("expected an error marshaling a scale set without a storage profile")
+	}
+}
+
+func TestGetDiskEncryptionSetIDs(t *testing.T) {
+	cs := getDiskEncryptionSetContainerService(&api.KubernetesConfig{})
+	cs.Properties.AgentPoolProfiles[1].DiskEncryptionSetID = "/subscriptions/SUB_ID/resourceGroups/RG_NAME/providers/Microsoft.Compute/diskEncryptionSets/other"
+
+	expected := []string{testDiskEncryptionSetID, "/subscriptions/SUB_ID/resourceGroups/RG_NAME/providers/Microsoft.Compute/diskEncryptionSets/other"}
+	if diff := cmp.Diff(getDiskEncryptionSetIDs(cs), expected); diff != "" {
+		t.Errorf("unexpected diff while comparing disk encryption sets: %s", diff)
+	}
+}
+
+func TestCreateDiskEncryptionSetRoleAssignments(t *testing.T) {
+	cs := getDiskEncryptionSetContainerService(&api.KubernetesConfig{})
+	if actual := createDiskEncryptionSetRoleAssignments(cs); actual != nil {
+		t.Errorf("expected no role assignments for a service principal, got %v", actual)
+	}
+
+	cs = getDiskEncryptionSetContainerService(&api.KubernetesConfig{
+		UseManagedIdentity: true,
+		UserAssignedID:     "myidentity",
+	})
+	actual := createDiskEncryptionSetRoleAssignments(cs)
+	expectedRoleAssignment := RoleAssignmentARM{
+		ARMResource: ARMResource{
+			APIVersion: "[variables('apiVersionAuthorizationUser')]",
+		},
+		RoleAssignment: authorization.RoleAssignment{
+			Name: to.StringPtr("[concat('des/Microsoft.Authorization/', guid(concat(variables('userAssignedID'), 'roleAssignment', '" + testDiskEncryptionSetID + "')))]"),
+			Type: to.StringPtr("Microsoft.Compute/diskEncryptionSets/providers/roleAssignments"),
+			RoleAssignmentPropertiesWithScope: &authorization.RoleAssignmentPropertiesWithScope{
+				RoleDefinitionID: to.StringPtr("/subscriptions/SUB_ID/providers/Microsoft.Authorization/roleDefinitions/acdd72a7-3385-48ef-bd42-f606fba81ae7"),
+				PrincipalID:      to.StringPtr("[reference(resourceId('Microsoft.ManagedIdentity/userAssignedIdentities', variables('userAssignedID'))).principalId]"),
+				PrincipalType:    authorization.ServicePrincipal,
+			},
+		},
+	}
+	expected := []interface{}{
+		DeploymentARM{
+			ARMResource: ARMResource{
+				APIVersion: "2018-05-01",
+				DependsOn:  []string{"[concat('Microsoft.ManagedIdentity/userAssignedIdentities/', variables('userAssignedID'))]"},
+			},
+			Deployment: Deployment{
+				Name:           to.StringPtr("[concat(variables('masterVMNamePrefix'), 'des-', 0)]"),
+				Type:           to.StringPtr("Microsoft.Resources/deployments"),
+				SubscriptionID: to.StringPtr("SUB_ID"),
+				ResourceGroup:  to.StringPtr("RG_NAME"),
+				Properties: &DeploymentProperties{
+					Mode: "Incremental",
+					Template: map[string]interface{}{
+						"$schema":        "https://schema.management.azure.com/schemas/2015-01-01/deploymentTemplate.json#",
+						"contentVersion": "1.0.0.0",
+						"resources":      []interface{}{expectedRoleAssignment},
+					},
+				},
+			},
+		},
+	}
+	if diff := cmp.Diff(actual, expected); diff != "" {
+		t.Errorf("unexpected diff while comparing role assignments: %s", diff)
+	}
+
+	cs = getDiskEncryptionSetContainerService(&api.KubernetesConfig{
+		UseManagedIdentity: true,
+	})
+	actual = createDiskEncryptionSetRoleAssignments(cs)
+	if len(actual) != 1 {
+		t.Fatalf("expected a single nested deployment, got %d resources", len(actual))
+	}
+	deployment := actual[0].(DeploymentARM)
+	if diff := cmp.Diff(deployment.Copy, map[string]string{"count": "[sub(variables('masterCount'), variables('masterOffset'))]", "name": "desRoleAssignmentLoop0"}); diff != "" {
+		t.Errorf("unexpected diff while comparing the deployment copy loop: %s", diff)
+	}
+	roleAssignment := deployment.Properties.Template["resources"].([]interface{})[0].(RoleAssignmentARM)
+	if to.String(roleAssignment.PrincipalID) != "[reference(resourceId('Microsoft.Compute/virtualMachines', concat(variables('masterVMNamePrefix'), copyIndex(variables('masterOffset')))), '2017-03-30', 'Full').identity.principalId]" {
+		t.Errorf("unexpected principal %s", to.String(roleAssignment.PrincipalID))
+	}
+
+	cs.Properties.MasterProfile.AvailabilityProfile = api.VirtualMachineScaleSets
+	actual = createDiskEncryptionSetRoleAssignments(cs)
+	if len(actual) != 1 {
+		t.Fatalf("expected a single nested deployment for the master scale set, got %d resources", len(actual))
+	}
+	deployment = actual[0].(DeploymentARM)
+	if deployment.Copy != nil {
+		t.Errorf("expected no copy loop for the master scale set, got %v", deployment.Copy)
+	}
+	if diff := cmp.Diff(deployment.DependsOn, []string{"[concat('Microsoft.Compute/virtualMachineScaleSets/', variables('masterVMNamePrefix'), 'vmss')]"}); diff != "" {
+		t.Errorf("unexpected diff while comparing the deployment dependencies: %s", diff)
+	}
+	roleAssignment = deployment.Properties.Template["resources"].([]interface{})[0].(RoleAssignmentARM)
+	if to.String(roleAssignment.PrincipalID) != "[reference(resourceId('Microsoft.Compute/virtualMachineScaleSets', concat(variables('masterVMNamePrefix'), 'vmss')), '2017-03-30', 'Full').identity.principalId]" {
+		t.Errorf("unexpected principal %s", to.String(roleAssignment.PrincipalID))
+	}
+}
@@ -61,7 +61,10 @@ func GenerateKubeConfig(properties *api.Properties, location string) (string, er
 		properties.OrchestratorProfile.KubernetesConfig != nil &&
 		properties.OrchestratorProfile.KubernetesConfig.PrivateCluster != nil &&
 		to.Bool(properties.OrchestratorProfile.KubernetesConfig.PrivateCluster.Enabled) {
-		if properties.MasterProfile.Count > 1 {
+		if fqdn := properties.GetPrivateAPIServerFQDN(); fqdn != "" {
+			// the private DNS zone resolves the API server FQDN inside the linked VNETs
+			kubeconfig = strings.Replace(kubeconfig, "{{WrapAsVerbatim \"reference(concat('Microsoft.Network/publicIPAddresses/', variables('masterPublicIPAddressName'))).dnsSettings.fqdn\"}}", fqdn, -1)
+		} else if properties.MasterProfile.Count > 1 {
 			// more than 1 master, use the internal lb IP
 			firstMasterIP := net.ParseIP(properties.MasterProfile.FirstConsecutiveStaticIP).To4()
 			if firstMasterIP == nil {
@@ -216,8 +219,13 @@ func makeExtensionScriptCommands(extension *api.Extension, extensionProfiles []*
 	}
 
 	extensionsParameterReference := fmt.Sprintf("parameters('%sParameters')", extensionProfile.Name)
-	scriptURL := getExtensionURL(extensionProfile.RootURL, extensionProfile.Name, extensionProfile.Version, extensionProfile.Script, extensionProfile.URLQuery)
 	scriptFilePath := fmt.Sprintf("/opt/azure/containers/extensions/%s/%s", extensionProfile.Name, extensionProfile.Script)
+	if extensionProfile.IsEmbedded() {
+		script := getEmbeddedExtensionScript(extensionProfile)
+		return fmt.Sprintf("- sudo /bin/mkdir -p /opt/azure/containers/extensions/%s \n- echo %s | sudo /usr/bin/base64 -d | sudo /usr/bin/tee %s > /dev/null \n- sudo /bin/chmod 744 %s \n- sudo %s ',%s,' > /var/log/%s-output.log",
+			extensionProfile.Name, script, scriptFilePath, scriptFilePath, scriptFilePath, extensionsParameterReference, extensionProfile.Name)
+	}
+	scriptURL := getExtensionURL(extensionProfile.RootURL, extensionProfile.Name, extensionProfile.Version, extensionProfile.Script, extensionProfile.URLQuery)
 	return fmt.Sprintf("- sudo /usr/bin/curl --retry 5 --retry-delay 10 --retry-max-time 30 -o %s --create-dirs \"%s\" \n- sudo /bin/chmod 744 %s \n- sudo %s ',%s,' > /var/log/%s-output.log",
 		scriptFilePath, scriptURL, scriptFilePath, scriptFilePath, extensionsParameterReference, extensionProfile.Name)
 }
@@ -235,12 +243,25 @@ func makeWindowsExtensionScriptCommands(extension *api.Extension, extensionProfi
 		panic(fmt.Sprintf("%s extension referenced was not found in the extension profile", extension.Name))
 	}
 
-	scriptURL := getExtensionURL(extensionProfile.RootURL, extensionProfile.Name, extensionProfile.Version, extensionProfile.Script, extensionProfile.URLQuery)
 	scriptFileDir := fmt.Sprintf("$env:SystemDrive:/AzureData/extensions/%s", extensionProfile.Name)
 	scriptFilePath := fmt.Sprintf("%s/%s", scriptFileDir, extensionProfile.Script)
+	if extensionProfile.IsEmbedded() {
+		script := getEmbeddedExtensionScript(extensionProfile)
+		return fmt.Sprintf("New-Item -ItemType Directory -Force -Path \"%s\" ; [IO.File]::WriteAllBytes(\"%s\", [Convert]::FromBase64String(\"%s\")) ; powershell \"%s %s\"\n", scriptFileDir, scriptFilePath, script, scriptFilePath, "$preprovisionExtensionParams")
+	}
+	scriptURL := getExtensionURL(extensionProfile.RootURL, extensionProfile.Name, extensionProfile.Version, extensionProfile.Script, extensionProfile.URLQuery)
 	return fmt.Sprintf("New-Item -ItemType Directory -Force -Path \"%s\" ; Invoke-WebRequest -Uri \"%s\" -OutFile \"%s\" ; powershell \"%s %s\"\n", scriptFileDir, scriptURL, scriptFilePath, scriptFilePath, "$preprovisionExtensionParams")
 }
 
+// getEmbeddedExtensionScript returns the base64-encoded preprovision script of an embedded extension
+func getEmbeddedExtensionScript(extensionProfile *api.ExtensionProfile) string {
+	script, err := getExtensionFile(extensionProfile, extensionProfile.Script)
+	if err != nil {
+		panic(err.Error())
+	}
+	return base64.StdEncoding.EncodeToString(script)
+}
+
 func getDCOSWindowsAgentPreprovisionParameters(cs *api.ContainerService, profile *api.AgentPoolProfile) string {
 	extension := profile.PreprovisionExtension
 
@@ -722,30 +743,9 @@ func getContainerAddonsString(properties *api.Properties, sourcePath string) str
 	for _, addonName := range addonNames {
 		setting := settingsMap[addonName]
 		if setting.isEnabled {
-			var input string
-			if setting.rawScript != "" {
-				var err error
-				input, err = getStringFromBase64(setting.rawScript)
-				if err != nil {
-					return ""
-				}
-			} else {
-				orchProfile := properties.OrchestratorProfile
-				versions := strings.Split(orchProfile.OrchestratorVersion, ".")
-				addon := orchProfile.KubernetesConfig.GetAddonByName(addonName)
-				templ := template.New("addon resolver template").Funcs(getAddonFuncMap(addon))
-				addonFile := getCustomDataFilePath(setting.sourceFile, sourcePath, versions[0]+"."+versions[1])
-				addonFileBytes, err := Asset(addonFile)
-				if err != nil {
-					return ""
-				}
-				_, err = templ.Parse(string(addonFileBytes))
-				if err != nil {
-					return ""
-				}
-				var buffer bytes.Buffer
-				templ.Execute(&buffer, addon)
-				input = buffer.String()
+			input, err := getContainerAddonManifest(properties, addonName, setting, sourcePath)
+			if err != nil {
+				return ""
 			}
 			result += getAddonString(input, "/etc/kubernetes/addons", setting.destinationFile)
 		}
@@ -753,6 +753,29 @@ func getContainerAddonsString(properties *api.Properties, sourcePath string) str
 	return result
 }
 
+// getContainerAddonManifest returns the manifest of a container addon, resolving the addon template with the addon settings
+func getContainerAddonManifest(properties *api.Properties, addonName string, setting kubernetesFeatureSetting, sourcePath string) (string, error) {
+	if setting.rawScript != "" {
+		return getStringFromBase64(setting.rawScript)
+	}
+	orchProfile := properties.OrchestratorProfile
+	versions := strings.Split(orchProfile.OrchestratorVersion, ".")
+	addon := orchProfile.KubernetesConfig.GetAddonByName(addonName)
+	templ := template.New("addon resolver template").Funcs(getAddonFuncMap(addon))
+	addonFile := getCustomDataFilePath(setting.sourceFile, sourcePath, versions[0]+"."+versions[1])
+	addonFileBytes, err := Asset(addonFile)
+	if err != nil {
+		return "", err
+	}
+	_, err = templ.Parse(string(addonFileBytes))
+	if err != nil {
+		return "", err
+	}
+	var buffer bytes.Buffer
+	templ.Execute(&buffer, addon)
+	return buffer.String(), nil
+}
+
 func getDCOSAgentProvisionScript(profile *api.AgentPoolProfile, orchProfile *api.OrchestratorProfile, bootstrapIP string) string {
 	// add the provision script
 	scriptname := dcos2Provision
@@ -1033,7 +1056,7 @@ func getAgentPoolLinkedTemplateText(agentPoolProfile *api.AgentPoolProfile, orch
 }
 
 func internalGetPoolLinkedTemplateText(extTargetVMNamePrefix, orchestratorType, loopCount, loopOffset string, extensionProfile *api.ExtensionProfile) (string, error) {
-	dta, e := getLinkedTemplateTextForURL(extensionProfile.RootURL, orchestratorType, extensionProfile.Name, extensionProfile.Version, extensionProfile.URLQuery)
+	dta, e := getLinkedTemplateText(extensionProfile, orchestratorType)
 	if e != nil {
 		return "", e
 	}
@@ -1053,6 +1076,9 @@ func internalGetPoolLinkedTemplateText(extTargetVMNamePrefix, orchestratorType,
 	}
 
 	dta = strings.Replace(dta, "EXTENSION_LOOP_OFFSET", loopOffset, -1)
+	if extensionProfile.IsEmbedded() {
+		return embedLinkedTemplate(dta, extensionProfile)
+	}
 	return dta, nil
 }
 
@@ -1065,19 +1091,19 @@ func validateProfileOptedForExtension(extensionName string, profileExtensions []
 	return false, ""
 }
 
-// getLinkedTemplateTextForURL returns the string data from
+// getLinkedTemplateText returns the string data from
 // template-link.json in the following directory:
-// extensionsRootURL/extensions/extensionName/version
-// It returns an error if the extension cannot be found
-// or loaded.  getLinkedTemplateTextForURL provides the ability
-// to pass a root extensions url for testing
-func getLinkedTemplateTextForURL(rootURL, orchestrator, extensionName, version, query string) (string, error) {
-	supportsExtension, err := orchestratorSupportsExtension(rootURL, orchestrator, extensionName, version, query)
+// extensions/extensionName/version
+// of the root URL, the local directory or the OCI artifact
+// of the extension. It returns an error if the extension
+// cannot be found or loaded.
+func getLinkedTemplateText(extensionProfile *api.ExtensionProfile, orchestrator string) (string, error) {
+	supportsExtension, err := orchestratorSupportsExtension(extensionProfile, orchestrator)
 	if !supportsExtension {
 		return "", errors.Wrap(err, "Extension not supported for orchestrator")
 	}
 
-	templateLinkBytes, err := getExtensionResource(rootURL, extensionName, version, "template-link.json", query)
+	templateLinkBytes, err := getExtensionFile(extensionProfile, "template-link.json")
 	if err != nil {
 		return "", err
 	}
@@ -1085,8 +1111,9 @@ func getLinkedTemplateTextForURL(rootURL, orchestrator, extensionName, version,
 	return string(templateLinkBytes), nil
 }
 
-func orchestratorSupportsExtension(rootURL, orchestrator, extensionName, version, query string) (bool, error) {
-	orchestratorBytes, err := getExtensionResource(rootURL, extensionName, version, "supported-orchestrators.json", query)
+func orchestratorSupportsExtension(extensionProfile *api.ExtensionProfile, orchestrator string) (bool, error) {
+	extensionName, version := extensionProfile.Name, extensionProfile.Version
+	orchestratorBytes, err := getExtensionFile(extensionProfile, "supported-orchestrators.json")
 	if err != nil {
 		return false, err
 	}
@@ -697,6 +697,19 @@ func TestGenerateKubeConfig(t *testing.T) {
 	if err != nil {
 		t.Errorf("Failed to call GenerateKubeConfig with simple Kubernetes config from file: %v", testData)
 	}
+
+	containerService.Properties.OrchestratorProfile.KubernetesConfig.PrivateCluster.PrivateDNSZone = &api.PrivateDNSZone{
+		Enabled: to.BoolPtr(true),
+		Name:    "privatelink.westus2.cloudapp.azure.com",
+	}
+	kubeConfig, err = GenerateKubeConfig(containerService.Properties, "westus2")
+	if err != nil {
+		t.Errorf("Failed to call GenerateKubeConfig with a private DNS zone: %v", err)
+	}
+	expectedServer := fmt.Sprintf("\"server\": \"https://%s.privatelink.westus2.cloudapp.azure.com\"", strings.ToLower(containerService.Properties.MasterProfile.DNSPrefix))
+	if !strings.Contains(kubeConfig, expectedServer) {
+		t.Errorf("expected kubeconfig to contain %s, got %s", expectedServer, kubeConfig)
+	}
 }
 
 func TestGetDataDisks(t *testing.T) {
@@ -0,0 +1,443 @@
+// Copyright (c) Microsoft Corporation. All rights reserved.
+// Licensed under the MIT license.
+
+package engine
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/Azure/aks-engine/pkg/api"
+	"github.com/mitchellh/go-homedir"
+	"github.com/pkg/errors"
+)
+
+const (
+	// ociManifestMediaType is the media type of the manifests of the OCI artifacts holding extensions
+	ociManifestMediaType = "application/vnd.oci.image.manifest.v1+json"
+	// ociTitleAnnotation is the annotation of the layers of an OCI artifact holding their file names, as set by oras
+	ociTitleAnnotation = "org.opencontainers.image.title"
+	// maxExtensionFileSize is the size of the largest extension file read
+	maxExtensionFileSize = 64 << 20
+)
+
+var (
+	// ociArtifacts caches the files of the OCI artifacts pulled, by reference
+	ociArtifacts     = map[string]map[string][]byte{}
+	ociArtifactsLock sync.Mutex
+	// authenticateParamRegex matches the parameters of a WWW-Authenticate header
+	authenticateParamRegex = regexp.MustCompile(`(\w+)="([^"]*)"`)
+)
+
+// getExtensionFile returns a file of an extension for template generation, read from the local directory or the OCI
+// artifact of the extension or downloaded from its root URL, after verifying its checksum
+func getExtensionFile(extensionProfile *api.ExtensionProfile, fileName string) ([]byte, error) {
+	var contents []byte
+	var err error
+	switch {
+	case extensionProfile.Upload:
+		return nil, errors.Errorf("extension %s must be uploaded with aks-engine deploy before generating a template", extensionProfile.Name)
+	case extensionProfile.IsLocal():
+		contents, err = readLocalExtensionFile(extensionProfile, fileName)
+	case extensionProfile.IsOCI():
+		var files map[string][]byte
+		if files, err = pullExtensionArtifact(newOCIClient(), extensionProfile.OCIReference); err == nil {
+			var ok bool
+			if contents, ok = files[fileName]; !ok {
+				err = errors.Errorf("OCI artifact %s of extension %s has no file %s", extensionProfile.OCIReference, extensionProfile.Name, fileName)
+			}
+		}
+	default:
+		contents, err = getExtensionResource(extensionProfile.RootURL, extensionProfile.Name, extensionProfile.Version, fileName, extensionProfile.URLQuery)
+	}
+	if err != nil {
+		return nil, err
+	}
+	if err = verifyExtensionFile(extensionProfile, fileName, contents); err != nil {
+		return nil, err
+	}
+	return contents, nil
+}
+
+// GetExtensionFiles returns all the files of a local or OCI extension by file name, after verifying their checksums
+func GetExtensionFiles(extensionProfile *api.ExtensionProfile) (map[string][]byte, error) {
+	var files map[string][]byte
+	var err error
+	switch {
+	case extensionProfile.IsLocal():
+		dir := getLocalExtensionDir(extensionProfile)
+		var infos []os.FileInfo
+		if infos, err = ioutil.ReadDir(dir); err != nil {
+			return nil, errors.Wrapf(err, "reading the directory of extension %s", extensionProfile.Name)
+		}
+		files = map[string][]byte{}
+		for _, info := range infos {
+			if !info.Mode().IsRegular() {
+				continue
+			}
+			if files[info.Name()], err = readLocalExtensionFile(extensionProfile, info.Name()); err != nil {
+				return nil, err
+			}
+		}
+	case extensionProfile.IsOCI():
+		if files, err = pullExtensionArtifact(newOCIClient(), extensionProfile.OCIReference); err != nil {
+			return nil, err
+		}
+	default:
+		return nil, errors.Errorf("extension %s has neither a localPath nor an ociReference", extensionProfile.Name)
+	}
+
+	for fileName, contents := range files {
+		if err = verifyExtensionFile(extensionProfile, fileName, contents); err != nil {
+			return nil, err
+		}
+	}
+	return files, nil
+}
+
+func getLocalExtensionDir(extensionProfile *api.ExtensionProfile) string {
+	return filepath.Join(extensionProfile.LocalPath, "extensions", extensionProfile.Name, extensionProfile.Version)
+}
+
+func readLocalExtensionFile(extensionProfile *api.ExtensionProfile, fileName string) ([]byte, error) {
+	contents, err := ioutil.ReadFile(filepath.Join(getLocalExtensionDir(extensionProfile), fileName))
+	if err != nil {
+		return nil, errors.Wrapf(err, "reading file %s of extension %s", fileName, extensionProfile.Name)
+	}
+	return contents, nil
+}
+
+// verifyExtensionFile verifies the checksum of a file of an extension. The files of local extensions must all have a
+// checksum, the checksums of the files of other extensions are verified if they are set.
+func verifyExtensionFile(extensionProfile *api.ExtensionProfile, fileName string, contents []byte) error {
+	checksum, ok := extensionProfile.Checksums[fileName]
+	if !ok {
+		if extensionProfile.IsLocal() {
+			return errors.Errorf("extension %s has no checksum for file %s", extensionProfile.Name, fileName)
+		}
+		return nil
+	}
+	digest := sha256.Sum256(contents)
+	if actual := hex.EncodeToString(digest[:]); !strings.EqualFold(actual, checksum) {
+		return errors.Errorf("file %s of extension %s has sha256 checksum %s, expected %s", fileName, extensionProfile.Name, actual, checksum)
+	}
+	return nil
+}
+
+// ociClient pulls artifacts from OCI registries over HTTPS
+type ociClient struct {
+	httpClient *http.Client
+	// credentials returns the user name and the password to pull from a registry, empty for anonymous pulls
+	credentials func(registry string) (string, string)
+}
+
+type ociDescriptor struct {
+	MediaType   string            `json:"mediaType"`
+	Digest      string            `json:"digest"`
+	Size        int64             `json:"size"`
+	Annotations map[string]string `json:"annotations,omitempty"`
+}
+
+type ociManifest struct {
+	Layers []ociDescriptor `json:"layers"`
+}
+
+func newOCIClient() *ociClient {
+	return &ociClient{
+		httpClient: &http.Client{
+			Timeout: 60 * time.Second,
+		},
+		credentials: dockerCredentials,
+	}
+}
+
+// pullExtensionArtifact returns the files of the OCI artifact of an extension by file name, pulling it only once
+func pullExtensionArtifact(client *ociClient, reference string) (map[string][]byte, error) {
+	ociArtifactsLock.Lock()
+	defer ociArtifactsLock.Unlock()
+	if files, ok := ociArtifacts[reference]; ok {
+		return files, nil
+	}
+	files, err := client.pull(reference)
+	if err != nil {
+		return nil, errors.Wrapf(err, "pulling OCI artifact %s", reference)
+	}
+	ociArtifacts[reference] = files
+	return files, nil
+}
+
+// parseOCIReference splits an OCI reference into its registry, its repository and its tag or digest
+func parseOCIReference(reference string) (registry, repository, tagOrDigest string, err error) {
+	parts := strings.SplitN(reference, "/", 2)
+	if len(parts) != 2 || parts[0] == "" {
+		return "", "", "", errors.Errorf("%s is not an OCI reference with a registry", reference)
+	}
+	registry, repository = parts[0], parts[1]
+	if i := strings.Index(repository, "@"); i >= 0 {
+		repository, tagOrDigest = repository[:i], repository[i+1:]
+	} else if i := strings.LastIndex(repository, ":"); i >= 0 {
+		repository, tagOrDigest = repository[:i], repository[i+1:]
+	}
+	if repository == "" || tagOrDigest == "" {
+		return "", "", "", errors.Errorf("%s is not an OCI reference with a repository and a tag or a digest", reference)
+	}
+	return registry, repository, tagOrDigest, nil
+}
+
+// pull returns the files of an OCI artifact by file name, after verifying the digests of its manifest if the reference
+// has one, and of its layers
+func (c *ociClient) pull(reference string) (map[string][]byte, error) {
+	registry, repository, tagOrDigest, err := parseOCIReference(reference)
+	if err != nil {
+		return nil, err
+	}
+
+	manifestURL := fmt.Sprintf("https://%s/v2/%s/manifests/%s", registry, repository, tagOrDigest)
+	manifestBytes, err := c.get(registry, manifestURL, ociManifestMediaType)
+	if err != nil {
+		return nil, err
+	}
+	if strings.Contains(tagOrDigest, ":") {
+		if err = verifyDigest(tagOrDigest, manifestBytes); err != nil {
+			return nil, errors.Wrap(err, "verifying the manifest")
+		}
+	}
+	manifest := ociManifest{}
+	if err = json.Unmarshal(manifestBytes, &manifest); err != nil {
+		return nil, errors.Wrap(err, "parsing the manifest")
+	}
+
+	files := map[string][]byte{}
+	for _, layer := range manifest.Layers {
+		fileName := layer.Annotations[ociTitleAnnotation]
+		if fileName == "" {
+			continue
+		}
+		if fileName != filepath.Base(fileName) || strings.ContainsAny(fileName, `/\`) || fileName == ".." {
+			return nil, errors.Errorf("layer %s has invalid file name %s", layer.Digest, fileName)
+		}
+		blobURL := fmt.Sprintf("https://%s/v2/%s/blobs/%s", registry, repository, layer.Digest)
+		contents, err := c.get(registry, blobURL, "")
+		if err != nil {
+			return nil, err
+		}
+		if err = verifyDigest(layer.Digest, contents); err != nil {
+			return nil, errors.Wrapf(err, "verifying file %s", fileName)
+		}
+		files[fileName] = contents
+	}
+	if len(files) == 0 {
+		return nil, errors.New("the artifact has no layer with a file name")
+	}
+	return files, nil
+}
+
+// get returns the body of a registry request, authenticating with a bearer token or a password when the registry
+// asks for it
+func (c *ociClient) get(registry, requestURL, accept string) ([]byte, error) {
+	res, err := c.do(requestURL, accept, "")
+	if err != nil {
+		return nil, err
+	}
+	if res.StatusCode == http.StatusUnauthorized {
+		challenge := res.Header.Get("WWW-Authenticate")
+		res.Body.Close()
+		authorization, err := c.authorize(registry, challenge)
+		if err != nil {
+			return nil, err
+		}
+		if res, err = c.do(requestURL, accept, authorization); err != nil {
+			return nil, err
+		}
+	}
+	defer res.Body.Close()
+	if res.StatusCode != http.StatusOK {
+		return nil, errors.Errorf("GET %s: unexpected status %s", requestURL, res.Status)
+	}
+	return readLimited(res.Body)
+}
+
+func (c *ociClient) do(requestURL, accept, authorization string) (*http.Response, error) {
+	req, err := http.NewRequest(http.MethodGet, requestURL, nil)
+	if err != nil {
+		return nil, err
+	}
+	if accept != "" {
+		req.Header.Set("Accept", accept)
+	}
+	if authorization != "" {
+		req.Header.Set("Authorization", authorization)
+	}
+	res, err := c.httpClient.Do(req)
+	if err != nil {
+		return nil, errors.Wrapf(err, "GET %s", requestURL)
+	}
+	return res, nil
+}
+
+// authorize returns the Authorization header answering the WWW-Authenticate challenge of a registry
+func (c *ociClient) authorize(registry, challenge string) (string, error) {
+	username, password := c.credentials(registry)
+	scheme := strings.ToLower(strings.SplitN(challenge, " ", 2)[0])
+	switch scheme {
+	case "basic":
+		if username == "" {
+			return "", errors.Errorf("registry %s requires credentials", registry)
+		}
+		return "Basic " + base64.StdEncoding.EncodeToString([]byte(username+":"+password)), nil
+	case "bearer":
+	default:
+		return "", errors.Errorf("registry %s asks for unsupported authentication %q", registry, challenge)
+	}
+
+	params := map[string]string{}
+	for _, match := range authenticateParamRegex.FindAllStringSubmatch(challenge, -1) {
+		params[strings.ToLower(match[1])] = match[2]
+	}
+	if params["realm"] == "" {
+		return "", errors.Errorf("registry %s asks for a bearer token without a realm", registry)
+	}
+	query := url.Values{}
+	for _, param := range []string{"service", "scope"} {
+		if params[param] != "" {
+			query.Set(param, params[param])
+		}
+	}
+	tokenURL := params["realm"] + "?" + query.Encode()
+	req, err := http.NewRequest(http.MethodGet, tokenURL, nil)
+	if err != nil {
+		return "", err
+	}
+	if username != "" {
+		req.SetBasicAuth(username, password)
+	}
+	res, err := c.httpClient.Do(req)
+	if err != nil {
+		return "", errors.Wrapf(err, "getting a token from %s", params["realm"])
+	}
+	defer res.Body.Close()
+	if res.StatusCode != http.StatusOK {
+		return "", errors.Errorf("getting a token from %s: unexpected status %s", params["realm"], res.Status)
+	}
+	body, err := readLimited(res.Body)
+	if err != nil {
+		return "", err
+	}
+	token := struct {
+		Token       string `json:"token"`
+		AccessToken string `json:"access_token"`
+	}{}
+	if err = json.Unmarshal(body, &token); err != nil {
+		return "", errors.Wrapf(err, "parsing the token from %s", params["realm"])
+	}
+	if token.Token == "" {
+		token.Token = token.AccessToken
+	}
+	return "Bearer " + token.Token, nil
+}
+
+// dockerCredentials returns the credentials of a registry stored by docker login, if any. Credential helpers are not
+// supported.
+func dockerCredentials(registry string) (string, string) {
+	dir := os.Getenv("DOCKER_CONFIG")
+	if dir == "" {
+		home, err := homedir.Dir()
+		if err != nil {
+			return "", ""
+		}
+		dir = filepath.Join(home, ".docker")
+	}
+	contents, err := ioutil.ReadFile(filepath.Join(dir, "config.json"))
+	if err != nil {
+		return "", ""
+	}
+	config := struct {
+		Auths map[string]struct {
+			Auth string `json:"auth"`
+		} `json:"auths"`
+	}{}
+	if err = json.Unmarshal(contents, &config); err != nil {
+		return "", ""
+	}
+	auth, err := base64.StdEncoding.DecodeString(config.Auths[registry].Auth)
+	if err != nil {
+		return "", ""
+	}
+	parts := strings.SplitN(string(auth), ":", 2)
+	if len(parts) != 2 {
+		return "", ""
+	}
+	return parts[0], parts[1]
+}
+
+// verifyDigest verifies contents against an OCI digest, which must be a sha256 digest
+func verifyDigest(digest string, contents []byte) error {
+	if !strings.HasPrefix(digest, "sha256:") {
+		return errors.Errorf("unsupported digest %s", digest)
+	}
+	sum := sha256.Sum256(contents)
+	if actual := "sha256:" + hex.EncodeToString(sum[:]); actual != digest {
+		return errors.Errorf("digest is %s, expected %s", actual, digest)
+	}
+	return nil
+}
+
+func readLimited(r io.Reader) ([]byte, error) {
+	contents, err := ioutil.ReadAll(io.LimitReader(r, maxExtensionFileSize+1))
+	if err != nil {
+		return nil, err
+	}
+	if len(contents) > maxExtensionFileSize {
+		return nil, errors.Errorf("more than %d bytes", maxExtensionFileSize)
+	}
+	return contents, nil
+}
+
+// embedLinkedTemplate replaces the link to template.json in the deployment of an embedded extension with the template
+// itself. Embedded templates cannot download the files of their extension from artifactsLocation.
+func embedLinkedTemplate(deployment string, extensionProfile *api.ExtensionProfile) (string, error) {
+	templateBytes, err := getExtensionFile(extensionProfile, "template.json")
+	if err != nil {
+		return "", err
+	}
+	if bytes.Contains(templateBytes, []byte("parameters('artifactsLocation')")) {
+		return "", errors.Errorf("extension %s downloads its files from artifactsLocation, set upload to deploy it from a storage account", extensionProfile.Name)
+	}
+	var template interface{}
+	if err = json.Unmarshal(templateBytes, &template); err != nil {
+		return "", errors.Wrapf(err, "parsing template.json of extension %s", extensionProfile.Name)
+	}
+
+	resource := map[string]interface{}{}
+	if err = json.Unmarshal([]byte(deployment), &resource); err != nil {
+		return "", errors.Wrapf(err, "parsing template-link.json of extension %s", extensionProfile.Name)
+	}
+	properties, ok := resource["properties"].(map[string]interface{})
+	if !ok {
+		return "", errors.Errorf("template-link.json of extension %s has no properties", extensionProfile.Name)
+	}
+	delete(properties, "templateLink")
+	properties["template"] = template
+
+	var buf bytes.Buffer
+	encoder := json.NewEncoder(&buf)
+	encoder.SetEscapeHTML(false)
+	if err = encoder.Encode(resource); err != nil {
+		return "", err
+	}
+	return strings.TrimSuffix(buf.String(), "\n"), nil
+}
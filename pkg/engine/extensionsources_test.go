@@ -0,0 +1,389 @@
+// Copyright (c) Microsoft Corporation. All rights reserved.
+// Licensed under the MIT license.
+
+package engine
+
+import (
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/Azure/aks-engine/pkg/api"
+)
+
+const (
+	testTemplateLink = `{
+    "name": "[concat(EXTENSION_TARGET_VM_NAME_PREFIX, copyIndex(EXTENSION_LOOP_OFFSET), 'Embedded')]",
+    "type": "Microsoft.Resources/deployments",
+    "copy": {
+        "count": "EXTENSION_LOOP_COUNT",
+        "name": "embeddedExtensionLoop"
+    },
+    "properties": {
+        "mode": "Incremental",
+        "templateLink": {
+            "uri": "EXTENSION_URL_REPLACEextensions/embedded/v1/template.json",
+            "contentVersion": "1.0.0.0"
+        },
+        "parameters": {
+            "extensionParameters": {
+                "value": "EXTENSION_PARAMETERS_REPLACE"
+            }
+        }
+    }
+}`
+	testTemplate = `{"contentVersion": "1.0.0.0", "resources": [{"type": "Microsoft.Compute/virtualMachines/extensions", "name": "[concat('vm', '/cse')]"}]}`
+)
+
+func checksum(contents string) string {
+	digest := sha256.Sum256([]byte(contents))
+	return hex.EncodeToString(digest[:])
+}
+
+// newLocalExtension writes the files of an extension into a temporary directory, and returns its profile with their
+// checksums
+func newLocalExtension(t *testing.T, files map[string]string) *api.ExtensionProfile {
+	dir, err := ioutil.TempDir("", "extensions")
+	if err != nil {
+		t.Fatal(err)
+	}
+	extensionProfile := &api.ExtensionProfile{
+		Name:      "embedded",
+		Version:   "v1",
+		LocalPath: dir,
+		Checksums: map[string]string{},
+	}
+	extensionDir := filepath.Join(dir, "extensions", "embedded", "v1")
+	if err = os.MkdirAll(extensionDir, 0755); err != nil {
+		t.Fatal(err)
+	}
+	for name, contents := range files {
+		if err = ioutil.WriteFile(filepath.Join(extensionDir, name), []byte(contents), 0644); err != nil {
+			t.Fatal(err)
+		}
+		extensionProfile.Checksums[name] = checksum(contents)
+	}
+	return extensionProfile
+}
+
+func TestGetExtensionFileLocal(t *testing.T) {
+	extensionProfile := newLocalExtension(t, map[string]string{"script.sh": "echo hello", "template.json": testTemplate})
+	defer os.RemoveAll(extensionProfile.LocalPath)
+
+	contents, err := getExtensionFile(extensionProfile, "script.sh")
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if string(contents) != "echo hello" {
+		t.Errorf("expected to read %q, but got %q", "echo hello", contents)
+	}
+
+	extensionProfile.Checksums["script.sh"] = checksum("echo bye")
+	expected := fmt.Sprintf("file script.sh of extension embedded has sha256 checksum %s, expected %s", checksum("echo hello"), checksum("echo bye"))
+	if _, err = getExtensionFile(extensionProfile, "script.sh"); err == nil || err.Error() != expected {
+		t.Errorf("expected error %q, but got %v", expected, err)
+	}
+
+	delete(extensionProfile.Checksums, "script.sh")
+	expected = "extension embedded has no checksum for file script.sh"
+	if _, err = getExtensionFile(extensionProfile, "script.sh"); err == nil || err.Error() != expected {
+		t.Errorf("expected error %q, but got %v", expected, err)
+	}
+
+	extensionProfile.Upload = true
+	expected = "extension embedded must be uploaded with aks-engine deploy before generating a template"
+	if _, err = getExtensionFile(extensionProfile, "template.json"); err == nil || err.Error() != expected {
+		t.Errorf("expected error %q, but got %v", expected, err)
+	}
+}
+
+func TestGetExtensionFileRootURL(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/extensions/remote/v1/script.sh" || r.URL.RawQuery != "sv=1" {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		fmt.Fprint(w, "echo hello")
+	}))
+	defer server.Close()
+
+	extensionProfile := &api.ExtensionProfile{
+		Name:     "remote",
+		Version:  "v1",
+		RootURL:  server.URL + "/",
+		URLQuery: "sv=1",
+	}
+	if _, err := getExtensionFile(extensionProfile, "script.sh"); err != nil {
+		t.Errorf("unexpected error without checksums: %s", err)
+	}
+
+	extensionProfile.Checksums = map[string]string{"script.sh": checksum("echo hello")}
+	if _, err := getExtensionFile(extensionProfile, "script.sh"); err != nil {
+		t.Errorf("unexpected error with a valid checksum: %s", err)
+	}
+
+	extensionProfile.Checksums["script.sh"] = checksum("echo bye")
+	if _, err := getExtensionFile(extensionProfile, "script.sh"); err == nil {
+		t.Error("expected an error with an invalid checksum")
+	}
+}
+
+func TestGetExtensionFilesLocal(t *testing.T) {
+	extensionProfile := newLocalExtension(t, map[string]string{"script.sh": "echo hello", "template.json": testTemplate})
+	defer os.RemoveAll(extensionProfile.LocalPath)
+
+	files, err := GetExtensionFiles(extensionProfile)
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if len(files) != 2 || string(files["script.sh"]) != "echo hello" || string(files["template.json"]) != testTemplate {
+		t.Errorf("unexpected files %v", files)
+	}
+
+	delete(extensionProfile.Checksums, "template.json")
+	if _, err = GetExtensionFiles(extensionProfile); err == nil {
+		t.Error("expected an error for a file without a checksum")
+	}
+}
+
+func TestParseOCIReference(t *testing.T) {
+	digest := "sha256:" + strings.Repeat("a", 64)
+	cases := []struct {
+		reference, registry, repository, tagOrDigest string
+	}{
+		{"myregistry.azurecr.io/extensions/hello:v1", "myregistry.azurecr.io", "extensions/hello", "v1"},
+		{"localhost:5000/hello:v1", "localhost:5000", "hello", "v1"},
+		{"myregistry.azurecr.io/hello@" + digest, "myregistry.azurecr.io", "hello", digest},
+	}
+	for _, c := range cases {
+		registry, repository, tagOrDigest, err := parseOCIReference(c.reference)
+		if err != nil {
+			t.Errorf("unexpected error parsing %s: %s", c.reference, err)
+			continue
+		}
+		if registry != c.registry || repository != c.repository || tagOrDigest != c.tagOrDigest {
+			t.Errorf("expected %s to be parsed into %s, %s, %s, but got %s, %s, %s", c.reference, c.registry, c.repository, c.tagOrDigest, registry, repository, tagOrDigest)
+		}
+	}
+
+	for _, reference := range []string{"hello:v1", "myregistry.azurecr.io/hello", "/hello:v1"} {
+		if _, _, _, err := parseOCIReference(reference); err == nil {
+			t.Errorf("expected an error parsing %s", reference)
+		}
+	}
+}
+
+// newTestRegistry returns a registry serving an artifact with files in repository extensions/embedded, behind bearer
+// token authentication, and the digest of its manifest
+func newTestRegistry(t *testing.T, files map[string]string) (*httptest.Server, string) {
+	manifest := ociManifest{}
+	blobs := map[string]string{}
+	for name, contents := range files {
+		digest := "sha256:" + checksum(contents)
+		blobs[digest] = contents
+		manifest.Layers = append(manifest.Layers, ociDescriptor{
+			MediaType:   "application/vnd.oci.image.layer.v1.tar",
+			Digest:      digest,
+			Size:        int64(len(contents)),
+			Annotations: map[string]string{ociTitleAnnotation: name},
+		})
+	}
+	manifestBytes, err := json.Marshal(manifest)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var server *httptest.Server
+	server = httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/token" {
+			if r.URL.Query().Get("scope") != "repository:extensions/embedded:pull" {
+				w.WriteHeader(http.StatusForbidden)
+				return
+			}
+			fmt.Fprint(w, `{"token": "pull-token"}`)
+			return
+		}
+		if r.Header.Get("Authorization") != "Bearer pull-token" {
+			w.Header().Set("WWW-Authenticate", fmt.Sprintf(`Bearer realm="%s/token",service="registry",scope="repository:extensions/embedded:pull"`, server.URL))
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		switch {
+		// the manifest is served for any digest, for the client to verify it
+		case r.URL.Path == "/v2/extensions/embedded/manifests/v1" || strings.HasPrefix(r.URL.Path, "/v2/extensions/embedded/manifests/sha256:"):
+			if r.Header.Get("Accept") != ociManifestMediaType {
+				w.WriteHeader(http.StatusNotAcceptable)
+				return
+			}
+			w.Write(manifestBytes)
+		case strings.HasPrefix(r.URL.Path, "/v2/extensions/embedded/blobs/"):
+			blob, ok := blobs[strings.TrimPrefix(r.URL.Path, "/v2/extensions/embedded/blobs/")]
+			if !ok {
+				w.WriteHeader(http.StatusNotFound)
+				return
+			}
+			fmt.Fprint(w, blob)
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	return server, "sha256:" + checksum(string(manifestBytes))
+}
+
+func newTestOCIClient(server *httptest.Server) *ociClient {
+	return &ociClient{
+		httpClient:  server.Client(),
+		credentials: func(string) (string, string) { return "", "" },
+	}
+}
+
+func TestOCIClientPull(t *testing.T) {
+	server, manifestDigest := newTestRegistry(t, map[string]string{"script.sh": "echo hello", "template.json": testTemplate})
+	defer server.Close()
+	registry := strings.TrimPrefix(server.URL, "https://")
+
+	files, err := newTestOCIClient(server).pull(registry + "/extensions/embedded:v1")
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if len(files) != 2 || string(files["script.sh"]) != "echo hello" || string(files["template.json"]) != testTemplate {
+		t.Errorf("unexpected files %v", files)
+	}
+
+	if _, err = newTestOCIClient(server).pull(registry + "/extensions/embedded:v2"); err == nil {
+		t.Error("expected an error pulling an unknown tag")
+	}
+
+	if _, err = newTestOCIClient(server).pull(registry + "/extensions/embedded@" + manifestDigest); err != nil {
+		t.Errorf("unexpected error pulling the digest of the manifest: %s", err)
+	}
+}
+
+func TestOCIClientPullShouldVerifyTheManifest(t *testing.T) {
+	server, _ := newTestRegistry(t, map[string]string{"script.sh": "echo hello"})
+	defer server.Close()
+	registry := strings.TrimPrefix(server.URL, "https://")
+
+	wrongDigest := "sha256:" + strings.Repeat("0", 64)
+	if _, err := newTestOCIClient(server).pull(registry + "/extensions/embedded@" + wrongDigest); err == nil || !strings.HasPrefix(err.Error(), "verifying the manifest") {
+		t.Errorf("expected an error verifying the manifest, but got %v", err)
+	}
+}
+
+func TestOCIClientPullShouldVerifyTheLayers(t *testing.T) {
+	server, _ := newTestRegistry(t, map[string]string{"../script.sh": "echo hello"})
+	defer server.Close()
+	registry := strings.TrimPrefix(server.URL, "https://")
+
+	if _, err := newTestOCIClient(server).pull(registry + "/extensions/embedded:v1"); err == nil || !strings.Contains(err.Error(), "invalid file name ../script.sh") {
+		t.Errorf("expected an error for an invalid file name, but got %v", err)
+	}
+}
+
+func TestVerifyDigest(t *testing.T) {
+	if err := verifyDigest("sha256:"+checksum("echo hello"), []byte("echo hello")); err != nil {
+		t.Errorf("unexpected error: %s", err)
+	}
+	if err := verifyDigest("sha256:"+checksum("echo bye"), []byte("echo hello")); err == nil {
+		t.Error("expected an error for a wrong digest")
+	}
+	if err := verifyDigest("sha512:abc", []byte("echo hello")); err == nil || err.Error() != "unsupported digest sha512:abc" {
+		t.Errorf("expected an error for an unsupported digest, but got %v", err)
+	}
+}
+
+func TestDockerCredentials(t *testing.T) {
+	dir, err := ioutil.TempDir("", "docker")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	auth := base64.StdEncoding.EncodeToString([]byte("user:pass:word"))
+	config := fmt.Sprintf(`{"auths": {"myregistry.azurecr.io": {"auth": "%s"}}}`, auth)
+	if err = ioutil.WriteFile(filepath.Join(dir, "config.json"), []byte(config), 0600); err != nil {
+		t.Fatal(err)
+	}
+	defer os.Setenv("DOCKER_CONFIG", os.Getenv("DOCKER_CONFIG"))
+	os.Setenv("DOCKER_CONFIG", dir)
+
+	if username, password := dockerCredentials("myregistry.azurecr.io"); username != "user" || password != "pass:word" {
+		t.Errorf("expected credentials user and pass:word, but got %s and %s", username, password)
+	}
+	if username, password := dockerCredentials("otherregistry.azurecr.io"); username != "" || password != "" {
+		t.Errorf("expected no credentials, but got %s and %s", username, password)
+	}
+}
+
+func TestInternalGetPoolLinkedTemplateTextEmbedded(t *testing.T) {
+	extensionProfile := newLocalExtension(t, map[string]string{
+		"supported-orchestrators.json": `["Kubernetes"]`,
+		"template-link.json":           testTemplateLink,
+		"template.json":                testTemplate,
+	})
+	defer os.RemoveAll(extensionProfile.LocalPath)
+
+	actual, err := internalGetPoolLinkedTemplateText("variables('masterVMNamePrefix')", api.Kubernetes, "1", "", extensionProfile)
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	deployment := map[string]interface{}{}
+	if err = json.Unmarshal([]byte(actual), &deployment); err != nil {
+		t.Fatalf("unexpected error parsing %s: %s", actual, err)
+	}
+	properties := deployment["properties"].(map[string]interface{})
+	if _, ok := properties["templateLink"]; ok {
+		t.Errorf("expected the template link to be removed from %s", actual)
+	}
+	template, ok := properties["template"].(map[string]interface{})
+	if !ok || template["contentVersion"] != "1.0.0.0" {
+		t.Errorf("expected the template to be embedded into %s", actual)
+	}
+	if deployment["name"] != "[concat(variables('masterVMNamePrefix'), copyIndex(), 'Embedded')]" {
+		t.Errorf("expected the placeholders to be replaced in %s", actual)
+	}
+
+	extensionProfile.Checksums["template.json"] = checksum(testTemplate + " ")
+	if _, err = internalGetPoolLinkedTemplateText("variables('masterVMNamePrefix')", api.Kubernetes, "1", "", extensionProfile); err == nil {
+		t.Error("expected an error for an invalid checksum")
+	}
+}
+
+func TestEmbedLinkedTemplateWithArtifacts(t *testing.T) {
+	template := `{"variables": {"initScriptUrl": "[concat(parameters('artifactsLocation'), 'extensions/embedded/v1/script.sh')]"}}`
+	extensionProfile := newLocalExtension(t, map[string]string{"template.json": template})
+	defer os.RemoveAll(extensionProfile.LocalPath)
+
+	expected := "extension embedded downloads its files from artifactsLocation, set upload to deploy it from a storage account"
+	if _, err := embedLinkedTemplate(testTemplateLink, extensionProfile); err == nil || err.Error() != expected {
+		t.Errorf("expected error %q, but got %v", expected, err)
+	}
+}
+
+func TestMakeExtensionScriptCommandsEmbedded(t *testing.T) {
+	extensionProfile := newLocalExtension(t, map[string]string{"script.sh": "echo hello"})
+	defer os.RemoveAll(extensionProfile.LocalPath)
+	extensionProfile.Script = "script.sh"
+	script := base64.StdEncoding.EncodeToString([]byte("echo hello"))
+
+	actual := makeExtensionScriptCommands(&api.Extension{Name: "embedded"}, []*api.ExtensionProfile{extensionProfile}, "")
+	expected := fmt.Sprintf("- sudo /bin/mkdir -p /opt/azure/containers/extensions/embedded \n- echo %s | sudo /usr/bin/base64 -d | sudo /usr/bin/tee /opt/azure/containers/extensions/embedded/script.sh > /dev/null \n- sudo /bin/chmod 744 /opt/azure/containers/extensions/embedded/script.sh \n- sudo /opt/azure/containers/extensions/embedded/script.sh ',parameters('embeddedParameters'),' > /var/log/embedded-output.log", script)
+	if actual != expected {
+		t.Errorf("expected to get %s, but got %s instead", expected, actual)
+	}
+
+	actual = makeWindowsExtensionScriptCommands(&api.Extension{Name: "embedded"}, []*api.ExtensionProfile{extensionProfile}, "")
+	expected = fmt.Sprintf(`New-Item -ItemType Directory -Force -Path "$env:SystemDrive:/AzureData/extensions/embedded" ; [IO.File]::WriteAllBytes("$env:SystemDrive:/AzureData/extensions/embedded/script.sh", [Convert]::FromBase64String("%s")) ; powershell "$env:SystemDrive:/AzureData/extensions/embedded/script.sh $preprovisionExtensionParams"
+`, script)
+	if actual != expected {
+		t.Errorf("expected to get %s, but got %s instead", expected, actual)
+	}
+}
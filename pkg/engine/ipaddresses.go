@@ -4,6 +4,9 @@
 package engine
 
 import (
+	"fmt"
+
+	"github.com/Azure/aks-engine/pkg/api"
 	"github.com/Azure/azure-sdk-for-go/services/network/mgmt/2018-08-01/network"
 	"github.com/Azure/go-autorest/autorest/to"
 )
@@ -30,6 +33,31 @@ func CreatePublicIPAddress() PublicIPAddressARM {
 	}
 }
 
+func createMasterPublicIPv6Address(cs *api.ContainerService) PublicIPAddressARM {
+	// Basic SKU IPv6 public IP addresses can only be allocated dynamically
+	allocationMethod := network.Dynamic
+	if cs.Properties.OrchestratorProfile.KubernetesConfig.LoadBalancerSku == "Standard" {
+		allocationMethod = network.Static
+	}
+	return PublicIPAddressARM{
+		ARMResource: ARMResource{
+			APIVersion: "[variables('apiVersionNetwork')]",
+		},
+		PublicIPAddress: network.PublicIPAddress{
+			Location: to.StringPtr("[variables('location')]"),
+			Name:     to.StringPtr("[variables('masterPublicIPv6AddressName')]"),
+			PublicIPAddressPropertiesFormat: &network.PublicIPAddressPropertiesFormat{
+				PublicIPAddressVersion:   network.IPv6,
+				PublicIPAllocationMethod: allocationMethod,
+			},
+			Sku: &network.PublicIPAddressSku{
+				Name: "[variables('loadBalancerSku')]",
+			},
+			Type: to.StringPtr("Microsoft.Network/publicIPAddresses"),
+		},
+	}
+}
+
 func createJumpboxPublicIPAddress() PublicIPAddressARM {
 	return PublicIPAddressARM{
 		ARMResource: ARMResource{
@@ -51,3 +79,22 @@ func createJumpboxPublicIPAddress() PublicIPAddressARM {
 		},
 	}
 }
+
+func createAgentOutboundPublicIPAddress(index int) PublicIPAddressARM {
+	return PublicIPAddressARM{
+		ARMResource: ARMResource{
+			APIVersion: "[variables('apiVersionNetwork')]",
+		},
+		PublicIPAddress: network.PublicIPAddress{
+			Location: to.StringPtr("[variables('location')]"),
+			Name:     to.StringPtr(fmt.Sprintf("[concat(variables('agentOutboundIPNamePrefix'), %d)]", index)),
+			PublicIPAddressPropertiesFormat: &network.PublicIPAddressPropertiesFormat{
+				PublicIPAllocationMethod: network.Static,
+			},
+			Sku: &network.PublicIPAddressSku{
+				Name: "[variables('loadBalancerSku')]",
+			},
+			Type: to.StringPtr("Microsoft.Network/publicIPAddresses"),
+		},
+	}
+}
@@ -11,7 +11,7 @@ import (
 	"github.com/Azure/go-autorest/autorest/to"
 )
 
-func CreateLoadBalancer(masterCount int, isVMSS bool) LoadBalancerARM {
+func CreateLoadBalancer(masterCount int, isVMSS, isIPv6DualStack bool) LoadBalancerARM {
 
 	loadBalancer := LoadBalancerARM{
 		ARMResource: ARMResource{
@@ -125,6 +125,41 @@ func CreateLoadBalancer(masterCount int, isVMSS bool) LoadBalancerARM {
 		loadBalancer.InboundNatRules = &inboundNATRules
 	}
 
+	if isIPv6DualStack {
+		loadBalancer.DependsOn = append(loadBalancer.DependsOn, "[concat('Microsoft.Network/publicIPAddresses/', variables('masterPublicIPv6AddressName'))]")
+		*loadBalancer.BackendAddressPools = append(*loadBalancer.BackendAddressPools, network.BackendAddressPool{
+			Name: to.StringPtr("[variables('masterLbIPv6BackendPoolName')]"),
+		})
+		*loadBalancer.FrontendIPConfigurations = append(*loadBalancer.FrontendIPConfigurations, network.FrontendIPConfiguration{
+			Name: to.StringPtr("[variables('masterLbIPv6ConfigName')]"),
+			FrontendIPConfigurationPropertiesFormat: &network.FrontendIPConfigurationPropertiesFormat{
+				PublicIPAddress: &network.PublicIPAddress{
+					ID: to.StringPtr("[resourceId('Microsoft.Network/publicIpAddresses',variables('masterPublicIPv6AddressName'))]"),
+				},
+			},
+		})
+		*loadBalancer.LoadBalancingRules = append(*loadBalancer.LoadBalancingRules, network.LoadBalancingRule{
+			Name: to.StringPtr("LBRuleHTTPSIPv6"),
+			LoadBalancingRulePropertiesFormat: &network.LoadBalancingRulePropertiesFormat{
+				FrontendIPConfiguration: &network.SubResource{
+					ID: to.StringPtr("[variables('masterLbIPv6ConfigID')]"),
+				},
+				BackendAddressPool: &network.SubResource{
+					ID: to.StringPtr("[concat(variables('masterLbID'), '/backendAddressPools/', variables('masterLbIPv6BackendPoolName'))]"),
+				},
+				Protocol:             network.TransportProtocolTCP,
+				FrontendPort:         to.Int32Ptr(443),
+				BackendPort:          to.Int32Ptr(443),
+				EnableFloatingIP:     to.BoolPtr(false),
+				IdleTimeoutInMinutes: to.Int32Ptr(5),
+				LoadDistribution:     network.Default,
+				Probe: &network.SubResource{
+					ID: to.StringPtr("[concat(variables('masterLbID'),'/probes/tcpHTTPSProbe')]"),
+				},
+			},
+		})
+	}
+
 	return loadBalancer
 }
 
@@ -211,3 +246,79 @@ func CreateMasterInternalLoadBalancer(cs *api.ContainerService) LoadBalancerARM
 
 	return loadBalancerARM
 }
+
+// CreateAgentLoadBalancer returns the standard load balancer the agent nodes use for egress. It shares the cluster name
+// with the load balancer the cloud provider manages for Kubernetes services, so services add their rules to it.
+func CreateAgentLoadBalancer(cs *api.ContainerService) LoadBalancerARM {
+	egressProfile := cs.Properties.OrchestratorProfile.KubernetesConfig.EgressProfile
+
+	var dependencies []string
+	var frontendIPConfigurations []network.FrontendIPConfiguration
+	var outboundFrontendIPConfigurations []network.SubResource
+	if egressProfile.OutboundIPPrefixID != "" {
+		frontendIPConfigurations = append(frontendIPConfigurations, network.FrontendIPConfiguration{
+			Name: to.StringPtr("outboundIPConfig0"),
+			FrontendIPConfigurationPropertiesFormat: &network.FrontendIPConfigurationPropertiesFormat{
+				PublicIPPrefix: &network.SubResource{
+					ID: to.StringPtr(egressProfile.OutboundIPPrefixID),
+				},
+			},
+		})
+		outboundFrontendIPConfigurations = append(outboundFrontendIPConfigurations, network.SubResource{
+			ID: to.StringPtr("[concat(variables('agentLbID'), '/frontendIPConfigurations/outboundIPConfig0')]"),
+		})
+	} else {
+		for i := 0; i < egressProfile.OutboundIPCount; i++ {
+			dependencies = append(dependencies, fmt.Sprintf("[concat('Microsoft.Network/publicIPAddresses/', variables('agentOutboundIPNamePrefix'), %d)]", i))
+			frontendIPConfigurations = append(frontendIPConfigurations, network.FrontendIPConfiguration{
+				Name: to.StringPtr(fmt.Sprintf("outboundIPConfig%d", i)),
+				FrontendIPConfigurationPropertiesFormat: &network.FrontendIPConfigurationPropertiesFormat{
+					PublicIPAddress: &network.PublicIPAddress{
+						ID: to.StringPtr(fmt.Sprintf("[resourceId('Microsoft.Network/publicIpAddresses', concat(variables('agentOutboundIPNamePrefix'), %d))]", i)),
+					},
+				},
+			})
+			outboundFrontendIPConfigurations = append(outboundFrontendIPConfigurations, network.SubResource{
+				ID: to.StringPtr(fmt.Sprintf("[concat(variables('agentLbID'), '/frontendIPConfigurations/outboundIPConfig%d')]", i)),
+			})
+		}
+	}
+
+	return LoadBalancerARM{
+		ARMResource: ARMResource{
+			APIVersion: "[variables('apiVersionNetwork')]",
+			DependsOn:  dependencies,
+		},
+		LoadBalancer: network.LoadBalancer{
+			Location: to.StringPtr("[variables('location')]"),
+			Name:     to.StringPtr("[variables('agentLbName')]"),
+			LoadBalancerPropertiesFormat: &network.LoadBalancerPropertiesFormat{
+				BackendAddressPools: &[]network.BackendAddressPool{
+					{
+						Name: to.StringPtr("[variables('agentLbBackendPoolName')]"),
+					},
+				},
+				FrontendIPConfigurations: &frontendIPConfigurations,
+				OutboundRules: &[]network.OutboundRule{
+					{
+						Name: to.StringPtr("LBOutboundRule"),
+						OutboundRulePropertiesFormat: &network.OutboundRulePropertiesFormat{
+							FrontendIPConfigurations: &outboundFrontendIPConfigurations,
+							BackendAddressPool: &network.SubResource{
+								ID: to.StringPtr("[concat(variables('agentLbID'), '/backendAddressPools/', variables('agentLbBackendPoolName'))]"),
+							},
+							Protocol:               network.Protocol1All,
+							AllocatedOutboundPorts: to.Int32Ptr(int32(egressProfile.AllocatedOutboundPorts)),
+							IdleTimeoutInMinutes:   to.Int32Ptr(int32(egressProfile.IdleTimeoutInMinutes)),
+							EnableTCPReset:         egressProfile.EnableTCPReset,
+						},
+					},
+				},
+			},
+			Sku: &network.LoadBalancerSku{
+				Name: "[variables('loadBalancerSku')]",
+			},
+			Type: to.StringPtr("Microsoft.Network/loadBalancers"),
+		},
+	}
+}
@@ -15,7 +15,7 @@ import (
 )
 
 func TestCreateLoadBalancer(t *testing.T) {
-	actual := CreateLoadBalancer(1, false)
+	actual := CreateLoadBalancer(1, false, false)
 
 	expected := LoadBalancerARM{
 		ARMResource: ARMResource{
@@ -107,7 +107,7 @@ func TestCreateLoadBalancer(t *testing.T) {
 }
 
 func TestCreateLoadBalancerVMSS(t *testing.T) {
-	actual := CreateLoadBalancer(1, true)
+	actual := CreateLoadBalancer(1, true, false)
 
 	expected := LoadBalancerARM{
 		ARMResource: ARMResource{
@@ -199,6 +199,173 @@ func TestCreateLoadBalancerVMSS(t *testing.T) {
 
 }
 
+func TestCreateLoadBalancerIPv6DualStack(t *testing.T) {
+	actual := CreateLoadBalancer(1, false, true)
+	expected := CreateLoadBalancer(1, false, false)
+
+	expected.DependsOn = append(expected.DependsOn, "[concat('Microsoft.Network/publicIPAddresses/', variables('masterPublicIPv6AddressName'))]")
+	*expected.BackendAddressPools = append(*expected.BackendAddressPools, network.BackendAddressPool{
+		Name: to.StringPtr("[variables('masterLbIPv6BackendPoolName')]"),
+	})
+	*expected.FrontendIPConfigurations = append(*expected.FrontendIPConfigurations, network.FrontendIPConfiguration{
+		Name: to.StringPtr("[variables('masterLbIPv6ConfigName')]"),
+		FrontendIPConfigurationPropertiesFormat: &network.FrontendIPConfigurationPropertiesFormat{
+			PublicIPAddress: &network.PublicIPAddress{
+				ID: to.StringPtr("[resourceId('Microsoft.Network/publicIpAddresses',variables('masterPublicIPv6AddressName'))]"),
+			},
+		},
+	})
+	*expected.LoadBalancingRules = append(*expected.LoadBalancingRules, network.LoadBalancingRule{
+		Name: to.StringPtr("LBRuleHTTPSIPv6"),
+		LoadBalancingRulePropertiesFormat: &network.LoadBalancingRulePropertiesFormat{
+			FrontendIPConfiguration: &network.SubResource{
+				ID: to.StringPtr("[variables('masterLbIPv6ConfigID')]"),
+			},
+			BackendAddressPool: &network.SubResource{
+				ID: to.StringPtr("[concat(variables('masterLbID'), '/backendAddressPools/', variables('masterLbIPv6BackendPoolName'))]"),
+			},
+			Protocol:             network.TransportProtocolTCP,
+			FrontendPort:         to.Int32Ptr(443),
+			BackendPort:          to.Int32Ptr(443),
+			EnableFloatingIP:     to.BoolPtr(false),
+			IdleTimeoutInMinutes: to.Int32Ptr(5),
+			LoadDistribution:     network.Default,
+			Probe: &network.SubResource{
+				ID: to.StringPtr("[concat(variables('masterLbID'),'/probes/tcpHTTPSProbe')]"),
+			},
+		},
+	})
+
+	diff := cmp.Diff(actual, expected)
+
+	if diff != "" {
+		t.Errorf("unexpected error while comparing load balancers: %s", diff)
+	}
+}
+
+func TestCreateAgentLoadBalancer(t *testing.T) {
+	cs := &api.ContainerService{
+		Properties: &api.Properties{
+			OrchestratorProfile: &api.OrchestratorProfile{
+				OrchestratorType: api.Kubernetes,
+				KubernetesConfig: &api.KubernetesConfig{
+					LoadBalancerSku: "Standard",
+					EgressProfile: &api.EgressProfile{
+						Type:                   api.EgressTypeLoadBalancer,
+						OutboundIPCount:        2,
+						AllocatedOutboundPorts: 1024,
+						IdleTimeoutInMinutes:   30,
+						EnableTCPReset:         to.BoolPtr(true),
+					},
+				},
+			},
+		},
+	}
+
+	actual := CreateAgentLoadBalancer(cs)
+
+	expected := LoadBalancerARM{
+		ARMResource: ARMResource{
+			APIVersion: "[variables('apiVersionNetwork')]",
+			DependsOn: []string{
+				"[concat('Microsoft.Network/publicIPAddresses/', variables('agentOutboundIPNamePrefix'), 0)]",
+				"[concat('Microsoft.Network/publicIPAddresses/', variables('agentOutboundIPNamePrefix'), 1)]",
+			},
+		},
+		LoadBalancer: network.LoadBalancer{
+			Location: to.StringPtr("[variables('location')]"),
+			Name:     to.StringPtr("[variables('agentLbName')]"),
+			LoadBalancerPropertiesFormat: &network.LoadBalancerPropertiesFormat{
+				BackendAddressPools: &[]network.BackendAddressPool{
+					{
+						Name: to.StringPtr("[variables('agentLbBackendPoolName')]"),
+					},
+				},
+				FrontendIPConfigurations: &[]network.FrontendIPConfiguration{
+					{
+						Name: to.StringPtr("outboundIPConfig0"),
+						FrontendIPConfigurationPropertiesFormat: &network.FrontendIPConfigurationPropertiesFormat{
+							PublicIPAddress: &network.PublicIPAddress{
+								ID: to.StringPtr("[resourceId('Microsoft.Network/publicIpAddresses', concat(variables('agentOutboundIPNamePrefix'), 0))]"),
+							},
+						},
+					},
+					{
+						Name: to.StringPtr("outboundIPConfig1"),
+						FrontendIPConfigurationPropertiesFormat: &network.FrontendIPConfigurationPropertiesFormat{
+							PublicIPAddress: &network.PublicIPAddress{
+								ID: to.StringPtr("[resourceId('Microsoft.Network/publicIpAddresses', concat(variables('agentOutboundIPNamePrefix'), 1))]"),
+							},
+						},
+					},
+				},
+				OutboundRules: &[]network.OutboundRule{
+					{
+						Name: to.StringPtr("LBOutboundRule"),
+						OutboundRulePropertiesFormat: &network.OutboundRulePropertiesFormat{
+							FrontendIPConfigurations: &[]network.SubResource{
+								{
+									ID: to.StringPtr("[concat(variables('agentLbID'), '/frontendIPConfigurations/outboundIPConfig0')]"),
+								},
+								{
+									ID: to.StringPtr("[concat(variables('agentLbID'), '/frontendIPConfigurations/outboundIPConfig1')]"),
+								},
+							},
+							BackendAddressPool: &network.SubResource{
+								ID: to.StringPtr("[concat(variables('agentLbID'), '/backendAddressPools/', variables('agentLbBackendPoolName'))]"),
+							},
+							Protocol:               network.Protocol1All,
+							AllocatedOutboundPorts: to.Int32Ptr(1024),
+							IdleTimeoutInMinutes:   to.Int32Ptr(30),
+							EnableTCPReset:         to.BoolPtr(true),
+						},
+					},
+				},
+			},
+			Sku: &network.LoadBalancerSku{
+				Name: "[variables('loadBalancerSku')]",
+			},
+			Type: to.StringPtr("Microsoft.Network/loadBalancers"),
+		},
+	}
+
+	diff := cmp.Diff(actual, expected)
+
+	if diff != "" {
+		t.Errorf("unexpected error while comparing load balancers: %s", diff)
+	}
+
+	// Test the agent load balancer with an existing public IP prefix
+	prefixID := "/subscriptions/SUB_ID/resourceGroups/RG_NAME/providers/Microsoft.Network/publicIPPrefixes/PREFIX_NAME"
+	cs.Properties.OrchestratorProfile.KubernetesConfig.EgressProfile.OutboundIPCount = 0
+	cs.Properties.OrchestratorProfile.KubernetesConfig.EgressProfile.OutboundIPPrefixID = prefixID
+
+	actual = CreateAgentLoadBalancer(cs)
+
+	expected.DependsOn = nil
+	expected.FrontendIPConfigurations = &[]network.FrontendIPConfiguration{
+		{
+			Name: to.StringPtr("outboundIPConfig0"),
+			FrontendIPConfigurationPropertiesFormat: &network.FrontendIPConfigurationPropertiesFormat{
+				PublicIPPrefix: &network.SubResource{
+					ID: to.StringPtr(prefixID),
+				},
+			},
+		},
+	}
+	(*expected.OutboundRules)[0].FrontendIPConfigurations = &[]network.SubResource{
+		{
+			ID: to.StringPtr("[concat(variables('agentLbID'), '/frontendIPConfigurations/outboundIPConfig0')]"),
+		},
+	}
+
+	diff = cmp.Diff(actual, expected)
+
+	if diff != "" {
+		t.Errorf("unexpected error while comparing load balancers: %s", diff)
+	}
+}
+
 func TestCreateMasterInternalLoadBalancer(t *testing.T) {
 	// Test without custom VNET
 	cs := &api.ContainerService{
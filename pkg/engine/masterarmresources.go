@@ -35,23 +35,32 @@ func createKubernetesMasterResourcesVMAS(cs *api.ContainerService) []interface{}
 	masterNsg := CreateNetworkSecurityGroup(cs)
 	masterResources = append(masterResources, masterNsg)
 
-	if cs.Properties.OrchestratorProfile.RequireRouteTable() {
-		masterResources = append(masterResources, createRouteTable())
+	if cs.Properties.OrchestratorProfile.HasRouteTable() {
+		masterResources = append(masterResources, createRouteTable(cs))
 	}
 
 	kubernetesConfig := cs.Properties.OrchestratorProfile.KubernetesConfig
+	isIPv6DualStack := cs.Properties.FeatureFlags.IsFeatureEnabled("EnableIPv6DualStack")
 
 	if !cs.Properties.OrchestratorProfile.IsPrivateCluster() {
 		publicIPAddress := CreatePublicIPAddress()
-		loadBalancer := CreateLoadBalancer(cs.Properties.MasterProfile.Count, false)
+		loadBalancer := CreateLoadBalancer(cs.Properties.MasterProfile.Count, false, isIPv6DualStack)
 		masterNic := CreateNetworkInterfaces(cs)
 
 		masterResources = append(masterResources, publicIPAddress, loadBalancer, masterNic)
 
+		if isIPv6DualStack {
+			masterResources = append(masterResources, createMasterPublicIPv6Address(cs))
+		}
+
 	} else {
 		masterNic := createPrivateClusterNetworkInterface(cs)
 		masterResources = append(masterResources, masterNic)
 
+		if kubernetesConfig.IsPrivateDNSZoneEnabled() {
+			masterResources = append(masterResources, createPrivateDNSZoneResources(cs)...)
+		}
+
 		var provisionJumpbox bool
 
 		if kubernetesConfig != nil {
@@ -68,7 +77,7 @@ func createKubernetesMasterResourcesVMAS(cs *api.ContainerService) []interface{}
 				jumpBoxStorage := createJumpboxStorageAccount()
 				masterResources = append(masterResources, jumpBoxStorage)
 			}
-			jumpboxNSG := createJumpboxNSG()
+			jumpboxNSG := createJumpboxNSG(cs)
 			jumpboxNIC := createJumpboxNetworkInterface(cs)
 			jumpboxPublicIP := createJumpboxPublicIPAddress()
 			masterResources = append(masterResources, jumpboxNSG, jumpboxNIC, jumpboxPublicIP)
@@ -130,8 +139,8 @@ func createKubernetesMasterResourcesVMSS(cs *api.ContainerService) []interface{}
 	masterNSG := CreateNetworkSecurityGroup(cs)
 	masterResources = append(masterResources, masterNSG)
 
-	if cs.Properties.OrchestratorProfile.RequireRouteTable() {
-		masterResources = append(masterResources, createRouteTable())
+	if cs.Properties.OrchestratorProfile.HasRouteTable() {
+		masterResources = append(masterResources, createRouteTable(cs))
 	}
 	if !cs.Properties.MasterProfile.IsCustomVNET() {
 		masterVNET := createVirtualNetworkVMSS(cs)
@@ -143,10 +152,15 @@ func createKubernetesMasterResourcesVMSS(cs *api.ContainerService) []interface{}
 		masterResources = append(masterResources, internalLb)
 	}
 
+	isIPv6DualStack := cs.Properties.FeatureFlags.IsFeatureEnabled("EnableIPv6DualStack")
 	publicIPAddress := CreatePublicIPAddress()
-	loadBalancer := CreateLoadBalancer(cs.Properties.MasterProfile.Count, true)
+	loadBalancer := CreateLoadBalancer(cs.Properties.MasterProfile.Count, true, isIPv6DualStack)
 	masterResources = append(masterResources, publicIPAddress, loadBalancer)
 
+	if isIPv6DualStack {
+		masterResources = append(masterResources, createMasterPublicIPv6Address(cs))
+	}
+
 	kubernetesConfig := cs.Properties.OrchestratorProfile.KubernetesConfig
 
 	var isKMSEnabled bool
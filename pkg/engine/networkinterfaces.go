@@ -88,6 +88,14 @@ func CreateNetworkInterfaces(cs *api.ContainerService) NetworkInterfaceARM {
 		}
 	}
 
+	if cs.Properties.FeatureFlags.IsFeatureEnabled("EnableIPv6DualStack") {
+		ipConfigurations = append(ipConfigurations, getIPv6NICIPConfig("[variables('vnetSubnetID')]", []network.BackendAddressPool{
+			{
+				ID: to.StringPtr("[concat(variables('masterLbID'), '/backendAddressPools/', variables('masterLbIPv6BackendPoolName'))]"),
+			},
+		}))
+	}
+
 	linuxProfile := cs.Properties.LinuxProfile
 	if linuxProfile != nil && linuxProfile.HasCustomNodesDNS() {
 		nicProperties.DNSSettings = &network.InterfaceDNSSettings{
@@ -180,6 +188,10 @@ func createPrivateClusterNetworkInterface(cs *api.ContainerService) NetworkInter
 		}
 	}
 
+	if cs.Properties.FeatureFlags.IsFeatureEnabled("EnableIPv6DualStack") {
+		ipConfigurations = append(ipConfigurations, getIPv6NICIPConfig("[variables('vnetSubnetID')]", nil))
+	}
+
 	nicProperties := network.InterfacePropertiesFormat{
 		IPConfigurations: &ipConfigurations,
 	}
@@ -285,11 +297,16 @@ func createAgentVMASNetworkInterface(cs *api.ContainerService, profile *api.Agen
 	var dependencies []string
 
 	if isCustomVNet {
-		dependencies = append(dependencies, "[variables('nsgID')]")
+		dependencies = append(dependencies, getAgentPoolNSGDependency(profile))
 	} else {
 		dependencies = append(dependencies, "[variables('vnetID')]")
 	}
 
+	isLoadBalancerEgress := cs.Properties.OrchestratorProfile.KubernetesConfig.IsLoadBalancerEgress()
+	if isLoadBalancerEgress {
+		dependencies = append(dependencies, "[variables('agentLbID')]")
+	}
+
 	armResource.DependsOn = dependencies
 
 	networkInterface := network.Interface{
@@ -302,7 +319,7 @@ func createAgentVMASNetworkInterface(cs *api.ContainerService, profile *api.Agen
 
 	if isCustomVNet {
 		networkInterface.NetworkSecurityGroup = &network.SecurityGroup{
-			ID: to.StringPtr("[variables('nsgID')]"),
+			ID: to.StringPtr(getAgentPoolNSGID(profile)),
 		}
 	}
 
@@ -325,18 +342,29 @@ func createAgentVMASNetworkInterface(cs *api.ContainerService, profile *api.Agen
 		ipConfig.Subnet = &network.Subnet{
 			ID: to.StringPtr(fmt.Sprintf("[variables('%sVnetSubnetID')]", profile.Name)),
 		}
+		var backendAddressPools []network.BackendAddressPool
 		if !isWindows {
 			if profile.Role == "Infra" {
-				ipConfig.LoadBalancerBackendAddressPools = &[]network.BackendAddressPool{
-					{
-						ID: to.StringPtr("[concat(resourceId('Microsoft.Network/loadBalancers', variables('routerLBName')), '/backendAddressPools/backend')]"),
-					},
-				}
+				backendAddressPools = append(backendAddressPools, network.BackendAddressPool{
+					ID: to.StringPtr("[concat(resourceId('Microsoft.Network/loadBalancers', variables('routerLBName')), '/backendAddressPools/backend')]"),
+				})
 			}
 		}
+		if isLoadBalancerEgress && i == 1 {
+			backendAddressPools = append(backendAddressPools, network.BackendAddressPool{
+				ID: to.StringPtr("[concat(variables('agentLbID'), '/backendAddressPools/', variables('agentLbBackendPoolName'))]"),
+			})
+		}
+		if backendAddressPools != nil {
+			ipConfig.LoadBalancerBackendAddressPools = &backendAddressPools
+		}
 		ipConfigurations = append(ipConfigurations, ipConfig)
 	}
 
+	if cs.Properties.FeatureFlags.IsFeatureEnabled("EnableIPv6DualStack") {
+		ipConfigurations = append(ipConfigurations, getIPv6NICIPConfig(fmt.Sprintf("[variables('%sVnetSubnetID')]", profile.Name), nil))
+	}
+
 	networkInterface.IPConfigurations = &ipConfigurations
 
 	if !isAzureCNI && !cs.Properties.IsAzureStackCloud() {
@@ -366,3 +394,23 @@ func getSecondaryNICIPConfigs(n int) []network.InterfaceIPConfiguration {
 	}
 	return ipConfigurations
 }
+
+// getIPv6NICIPConfig returns the IPv6 ip configuration added to NICs of IPv4/IPv6 dual-stack clusters,
+// the IPv4 ip configuration stays primary as Azure requires
+func getIPv6NICIPConfig(subnetID string, lbBackendAddressPools []network.BackendAddressPool) network.InterfaceIPConfiguration {
+	ipConfig := network.InterfaceIPConfiguration{
+		Name: to.StringPtr("ipconfigv6"),
+		InterfaceIPConfigurationPropertiesFormat: &network.InterfaceIPConfigurationPropertiesFormat{
+			Primary:                   to.BoolPtr(false),
+			PrivateIPAddressVersion:   network.IPv6,
+			PrivateIPAllocationMethod: network.Dynamic,
+			Subnet: &network.Subnet{
+				ID: to.StringPtr(subnetID),
+			},
+		},
+	}
+	if len(lbBackendAddressPools) > 0 {
+		ipConfig.LoadBalancerBackendAddressPools = &lbBackendAddressPools
+	}
+	return ipConfig
+}
@@ -528,6 +528,76 @@ func TestCreatePrivateClusterNetworkInterface(t *testing.T) {
 	}
 }
 
+func TestCreateNICIPv6DualStack(t *testing.T) {
+	cs := &api.ContainerService{
+		Properties: &api.Properties{
+			MasterProfile: &api.MasterProfile{
+				Count:     1,
+				DNSPrefix: "myprefix1",
+				VMSize:    "Standard_DS2_v2",
+			},
+			OrchestratorProfile: &api.OrchestratorProfile{
+				OrchestratorType: api.Kubernetes,
+				KubernetesConfig: &api.KubernetesConfig{
+					NetworkPlugin: "kubenet",
+				},
+			},
+			FeatureFlags: &api.FeatureFlags{
+				EnableIPv6DualStack: true,
+			},
+			LinuxProfile: &api.LinuxProfile{},
+		},
+	}
+
+	expectedMasterIPv6Config := network.InterfaceIPConfiguration{
+		Name: to.StringPtr("ipconfigv6"),
+		InterfaceIPConfigurationPropertiesFormat: &network.InterfaceIPConfigurationPropertiesFormat{
+			LoadBalancerBackendAddressPools: &[]network.BackendAddressPool{
+				{
+					ID: to.StringPtr("[concat(variables('masterLbID'), '/backendAddressPools/', variables('masterLbIPv6BackendPoolName'))]"),
+				},
+			},
+			Primary:                   to.BoolPtr(false),
+			PrivateIPAddressVersion:   network.IPv6,
+			PrivateIPAllocationMethod: network.Dynamic,
+			Subnet: &network.Subnet{
+				ID: to.StringPtr("[variables('vnetSubnetID')]"),
+			},
+		},
+	}
+
+	nic := CreateNetworkInterfaces(cs)
+	ipConfigs := *nic.IPConfigurations
+	if len(ipConfigs) != 2 || !to.Bool(ipConfigs[0].Primary) {
+		t.Fatalf("expected a primary IPv4 and an IPv6 ip configuration, got %d ip configurations", len(ipConfigs))
+	}
+	if diff := cmp.Diff(ipConfigs[1], expectedMasterIPv6Config); diff != "" {
+		t.Errorf("unexpected diff while comparing master IPv6 ip configurations: %s", diff)
+	}
+
+	nic = createPrivateClusterNetworkInterface(cs)
+	ipConfigs = *nic.IPConfigurations
+	expectedMasterIPv6Config.LoadBalancerBackendAddressPools = nil
+	if diff := cmp.Diff(ipConfigs[len(ipConfigs)-1], expectedMasterIPv6Config); diff != "" {
+		t.Errorf("unexpected diff while comparing private cluster IPv6 ip configurations: %s", diff)
+	}
+
+	profile := &api.AgentPoolProfile{
+		Name:           "fooAgent",
+		OSType:         "Linux",
+		IPAddressCount: 1,
+	}
+	nic = createAgentVMASNetworkInterface(cs, profile)
+	ipConfigs = *nic.IPConfigurations
+	expectedMasterIPv6Config.Subnet.ID = to.StringPtr("[variables('fooAgentVnetSubnetID')]")
+	if len(ipConfigs) != 2 {
+		t.Fatalf("expected a primary IPv4 and an IPv6 ip configuration, got %d ip configurations", len(ipConfigs))
+	}
+	if diff := cmp.Diff(ipConfigs[1], expectedMasterIPv6Config); diff != "" {
+		t.Errorf("unexpected diff while comparing agent IPv6 ip configurations: %s", diff)
+	}
+}
+
 func TestCreateJumpboxNIC(t *testing.T) {
 	cs := &api.ContainerService{
 		Properties: &api.Properties{
@@ -784,3 +854,112 @@ func TestCreateAgentVMASNIC(t *testing.T) {
 	}
 
 }
+
+func TestCreateAgentVMASNICLoadBalancerEgress(t *testing.T) {
+	cs := &api.ContainerService{
+		Properties: &api.Properties{
+			OrchestratorProfile: &api.OrchestratorProfile{
+				OrchestratorType: api.Kubernetes,
+				KubernetesConfig: &api.KubernetesConfig{
+					NetworkPlugin:   "azure",
+					LoadBalancerSku: "Standard",
+					EgressProfile: &api.EgressProfile{
+						Type:            api.EgressTypeLoadBalancer,
+						OutboundIPCount: 1,
+					},
+				},
+			},
+		},
+	}
+
+	profile := &api.AgentPoolProfile{
+		Name:           "fooAgent",
+		OSType:         "Linux",
+		Role:           "Infra",
+		IPAddressCount: 2,
+	}
+
+	actual := createAgentVMASNetworkInterface(cs, profile)
+
+	expectedDependsOn := []string{
+		"[variables('vnetID')]",
+		"[variables('agentLbID')]",
+	}
+	if diff := cmp.Diff(actual.DependsOn, expectedDependsOn); diff != "" {
+		t.Errorf("unexpected diff while comparing dependencies: %s", diff)
+	}
+
+	routerPool := network.BackendAddressPool{
+		ID: to.StringPtr("[concat(resourceId('Microsoft.Network/loadBalancers', variables('routerLBName')), '/backendAddressPools/backend')]"),
+	}
+	agentPool := network.BackendAddressPool{
+		ID: to.StringPtr("[concat(variables('agentLbID'), '/backendAddressPools/', variables('agentLbBackendPoolName'))]"),
+	}
+	ipConfigurations := *actual.IPConfigurations
+	if diff := cmp.Diff(ipConfigurations[0].LoadBalancerBackendAddressPools, &[]network.BackendAddressPool{routerPool, agentPool}); diff != "" {
+		t.Errorf("unexpected diff while comparing the primary ipconfig backend pools: %s", diff)
+	}
+	if diff := cmp.Diff(ipConfigurations[1].LoadBalancerBackendAddressPools, &[]network.BackendAddressPool{routerPool}); diff != "" {
+		t.Errorf("unexpected diff while comparing the secondary ipconfig backend pools: %s", diff)
+	}
+}
+
+func TestCreateAgentVMASNICAgentPoolNetworkSecurityGroup(t *testing.T) {
+	cs := &api.ContainerService{
+		Properties: &api.Properties{
+			OrchestratorProfile: &api.OrchestratorProfile{
+				OrchestratorType: api.Kubernetes,
+				KubernetesConfig: &api.KubernetesConfig{
+					NetworkPlugin: "azure",
+				},
+			},
+		},
+	}
+
+	cases := []struct {
+		name               string
+		profile            *api.AgentPoolProfile
+		expectedDependency string
+		expectedNSGID      string
+	}{
+		{
+			name: "cluster nsg",
+			profile: &api.AgentPoolProfile{
+				Name:         "fooAgent",
+				VnetSubnetID: "/subscriptions/SUB_ID/resourceGroups/RG_NAME/providers/Microsoft.Network/virtualNetworks/VNET_NAME/subnets/fooAgent",
+			},
+			expectedDependency: "[variables('nsgID')]",
+			expectedNSGID:      "[variables('nsgID')]",
+		},
+		{
+			name: "dedicated nsg",
+			profile: &api.AgentPoolProfile{
+				Name:                                "fooAgent",
+				VnetSubnetID:                        "/subscriptions/SUB_ID/resourceGroups/RG_NAME/providers/Microsoft.Network/virtualNetworks/VNET_NAME/subnets/fooAgent",
+				EnableDedicatedNetworkSecurityGroup: to.BoolPtr(true),
+			},
+			expectedDependency: "[variables('fooAgentNSGID')]",
+			expectedNSGID:      "[variables('fooAgentNSGID')]",
+		},
+		{
+			name: "existing nsg",
+			profile: &api.AgentPoolProfile{
+				Name:                   "fooAgent",
+				VnetSubnetID:           "/subscriptions/SUB_ID/resourceGroups/RG_NAME/providers/Microsoft.Network/virtualNetworks/VNET_NAME/subnets/fooAgent",
+				NetworkSecurityGroupID: "/subscriptions/SUB_ID/resourceGroups/RG_NAME/providers/Microsoft.Network/networkSecurityGroups/fooAgent-nsg",
+			},
+			expectedDependency: "[variables('nsgID')]",
+			expectedNSGID:      "[variables('fooAgentNSGID')]",
+		},
+	}
+
+	for _, c := range cases {
+		actual := createAgentVMASNetworkInterface(cs, c.profile)
+		if diff := cmp.Diff(actual.DependsOn, []string{c.expectedDependency}); diff != "" {
+			t.Errorf("%s: unexpected diff while comparing dependencies: %s", c.name, diff)
+		}
+		if diff := cmp.Diff(actual.NetworkSecurityGroup, &network.SecurityGroup{ID: to.StringPtr(c.expectedNSGID)}); diff != "" {
+			t.Errorf("%s: unexpected diff while comparing the network security group: %s", c.name, diff)
+		}
+	}
+}
@@ -4,6 +4,8 @@
 package engine
 
 import (
+	"fmt"
+
 	"github.com/Azure/aks-engine/pkg/api"
 	"github.com/Azure/azure-sdk-for-go/services/network/mgmt/2018-08-01/network"
 	"github.com/Azure/go-autorest/autorest/to"
@@ -14,6 +16,11 @@ func CreateNetworkSecurityGroup(cs *api.ContainerService) NetworkSecurityGroupAR
 		APIVersion: "[variables('apiVersionNetwork')]",
 	}
 
+	nsp := cs.Properties.NetworkSecurityProfile
+	if nsp == nil {
+		nsp = &api.NetworkSecurityProfile{}
+	}
+
 	sshRule := network.SecurityRule{
 		Name: to.StringPtr("allow_ssh"),
 		SecurityRulePropertiesFormat: &network.SecurityRulePropertiesFormat{
@@ -24,10 +31,10 @@ func CreateNetworkSecurityGroup(cs *api.ContainerService) NetworkSecurityGroupAR
 			Direction:                network.SecurityRuleDirectionInbound,
 			Priority:                 to.Int32Ptr(101),
 			Protocol:                 network.SecurityRuleProtocolTCP,
-			SourceAddressPrefix:      to.StringPtr("*"),
 			SourcePortRange:          to.StringPtr("*"),
 		},
 	}
+	sshRule.SourceAddressPrefix, sshRule.SourceAddressPrefixes = getAddressPrefixes(nsp.SSHSourceAddressPrefixes)
 
 	kubeTLSRule := network.SecurityRule{
 		Name: to.StringPtr("allow_kube_tls"),
@@ -39,15 +46,16 @@ func CreateNetworkSecurityGroup(cs *api.ContainerService) NetworkSecurityGroupAR
 			Direction:                network.SecurityRuleDirectionInbound,
 			Priority:                 to.Int32Ptr(100),
 			Protocol:                 network.SecurityRuleProtocolTCP,
-			SourceAddressPrefix:      to.StringPtr("*"),
 			SourcePortRange:          to.StringPtr("*"),
 		},
 	}
+	kubeTLSRule.SourceAddressPrefix, kubeTLSRule.SourceAddressPrefixes = getAddressPrefixes(nsp.APIServerSourceAddressPrefixes)
 
-	securityRules := []network.SecurityRule{
-		sshRule,
-		kubeTLSRule,
+	securityRules := []network.SecurityRule{}
+	if !nsp.DisableSSH {
+		securityRules = append(securityRules, sshRule)
 	}
+	securityRules = append(securityRules, kubeTLSRule)
 
 	if cs.Properties.HasWindows() {
 		rdpRule := network.SecurityRule{
@@ -60,10 +68,10 @@ func CreateNetworkSecurityGroup(cs *api.ContainerService) NetworkSecurityGroupAR
 				Direction:                network.SecurityRuleDirectionInbound,
 				Priority:                 to.Int32Ptr(102),
 				Protocol:                 network.SecurityRuleProtocolTCP,
-				SourceAddressPrefix:      to.StringPtr("*"),
 				SourcePortRange:          to.StringPtr("*"),
 			},
 		}
+		rdpRule.SourceAddressPrefix, rdpRule.SourceAddressPrefixes = getAddressPrefixes(nsp.RDPSourceAddressPrefixes)
 
 		securityRules = append(securityRules, rdpRule)
 	}
@@ -137,6 +145,8 @@ func CreateNetworkSecurityGroup(cs *api.ContainerService) NetworkSecurityGroupAR
 		securityRules = append(securityRules, allowVnetOutbound)
 	}
 
+	securityRules = append(securityRules, getCustomSecurityRules(nsp)...)
+
 	nsg := network.SecurityGroup{
 		Location: to.StringPtr("[variables('location')]"),
 		Name:     to.StringPtr("[variables('nsgName')]"),
@@ -152,26 +162,29 @@ func CreateNetworkSecurityGroup(cs *api.ContainerService) NetworkSecurityGroupAR
 	}
 }
 
-func createJumpboxNSG() NetworkSecurityGroupARM {
+func createJumpboxNSG(cs *api.ContainerService) NetworkSecurityGroupARM {
 	armResource := ARMResource{
 		APIVersion: "[variables('apiVersionNetwork')]",
 	}
 
-	securityRules := []network.SecurityRule{
-		{
-			Name: to.StringPtr("default-allow-ssh"),
-			SecurityRulePropertiesFormat: &network.SecurityRulePropertiesFormat{
-				Priority:                 to.Int32Ptr(1000),
-				Protocol:                 network.SecurityRuleProtocolTCP,
-				Access:                   network.SecurityRuleAccessAllow,
-				Direction:                network.SecurityRuleDirectionInbound,
-				SourceAddressPrefix:      to.StringPtr("*"),
-				SourcePortRange:          to.StringPtr("*"),
-				DestinationAddressPrefix: to.StringPtr("*"),
-				DestinationPortRange:     to.StringPtr("22"),
-			},
+	sshRule := network.SecurityRule{
+		Name: to.StringPtr("default-allow-ssh"),
+		SecurityRulePropertiesFormat: &network.SecurityRulePropertiesFormat{
+			Priority:                 to.Int32Ptr(1000),
+			Protocol:                 network.SecurityRuleProtocolTCP,
+			Access:                   network.SecurityRuleAccessAllow,
+			Direction:                network.SecurityRuleDirectionInbound,
+			SourcePortRange:          to.StringPtr("*"),
+			DestinationAddressPrefix: to.StringPtr("*"),
+			DestinationPortRange:     to.StringPtr("22"),
 		},
 	}
+	var sshSourceAddressPrefixes []string
+	if cs.Properties.NetworkSecurityProfile != nil {
+		sshSourceAddressPrefixes = cs.Properties.NetworkSecurityProfile.SSHSourceAddressPrefixes
+	}
+	sshRule.SourceAddressPrefix, sshRule.SourceAddressPrefixes = getAddressPrefixes(sshSourceAddressPrefixes)
+	securityRules := []network.SecurityRule{sshRule}
 	nsg := network.SecurityGroup{
 		Location: to.StringPtr("[variables('location')]"),
 		Name:     to.StringPtr("[variables('nsgName')]"),
@@ -186,16 +199,31 @@ func createJumpboxNSG() NetworkSecurityGroupARM {
 	}
 }
 
-func createHostedMasterNSG() NetworkSecurityGroupARM {
+func createHostedMasterNSG(cs *api.ContainerService) NetworkSecurityGroupARM {
+	return newCustomRulesNSG(cs, "[variables('nsgName')]")
+}
+
+// createAgentPoolNetworkSecurityGroup returns the dedicated network security group of an agent pool.
+// Agent nodes only need the Azure default rules, which allow traffic within the VNET and from load balancers.
+func createAgentPoolNetworkSecurityGroup(cs *api.ContainerService, profile *api.AgentPoolProfile) NetworkSecurityGroupARM {
+	return newCustomRulesNSG(cs, fmt.Sprintf("[variables('%sNSGName')]", profile.Name))
+}
+
+// newCustomRulesNSG returns a network security group holding only the custom rules of the network security profile
+func newCustomRulesNSG(cs *api.ContainerService, name string) NetworkSecurityGroupARM {
 	armResource := ARMResource{
 		APIVersion: "[variables('apiVersionNetwork')]",
 	}
+	securityRules := []network.SecurityRule{}
+	if cs.Properties.NetworkSecurityProfile != nil {
+		securityRules = append(securityRules, getCustomSecurityRules(cs.Properties.NetworkSecurityProfile)...)
+	}
 	nsg := network.SecurityGroup{
 		Location: to.StringPtr("[variables('location')]"),
-		Name:     to.StringPtr("[variables('nsgName')]"),
+		Name:     to.StringPtr(name),
 		Type:     to.StringPtr("Microsoft.Network/networkSecurityGroups"),
 		SecurityGroupPropertiesFormat: &network.SecurityGroupPropertiesFormat{
-			SecurityRules: &[]network.SecurityRule{},
+			SecurityRules: &securityRules,
 		},
 	}
 
@@ -204,3 +232,65 @@ func createHostedMasterNSG() NetworkSecurityGroupARM {
 		SecurityGroup: nsg,
 	}
 }
+
+// getAgentPoolNSGID returns the network security group the network interfaces of an agent pool in a custom VNET use
+func getAgentPoolNSGID(profile *api.AgentPoolProfile) string {
+	if profile.HasOwnNetworkSecurityGroup() {
+		return fmt.Sprintf("[variables('%sNSGID')]", profile.Name)
+	}
+	return "[variables('nsgID')]"
+}
+
+// getAgentPoolNSGDependency returns the network security group resource an agent pool in a custom VNET depends on,
+// an existing network security group is not part of the template
+func getAgentPoolNSGDependency(profile *api.AgentPoolProfile) string {
+	if profile.IsDedicatedNetworkSecurityGroupEnabled() {
+		return fmt.Sprintf("[variables('%sNSGID')]", profile.Name)
+	}
+	return "[variables('nsgID')]"
+}
+
+// getCustomSecurityRules returns the user defined rules of a network security profile
+func getCustomSecurityRules(nsp *api.NetworkSecurityProfile) []network.SecurityRule {
+	var securityRules []network.SecurityRule
+	for _, r := range nsp.SecurityRules {
+		rule := network.SecurityRule{
+			Name: to.StringPtr(r.Name),
+			SecurityRulePropertiesFormat: &network.SecurityRulePropertiesFormat{
+				Access:               network.SecurityRuleAccess(r.Access),
+				DestinationPortRange: to.StringPtr(getPortRange(r.DestinationPortRange)),
+				Direction:            network.SecurityRuleDirection(r.Direction),
+				Priority:             to.Int32Ptr(r.Priority),
+				Protocol:             network.SecurityRuleProtocol(r.Protocol),
+				SourcePortRange:      to.StringPtr(getPortRange(r.SourcePortRange)),
+			},
+		}
+		if r.Description != "" {
+			rule.Description = to.StringPtr(r.Description)
+		}
+		rule.SourceAddressPrefix, rule.SourceAddressPrefixes = getAddressPrefixes(r.SourceAddressPrefixes)
+		rule.DestinationAddressPrefix, rule.DestinationAddressPrefixes = getAddressPrefixes(r.DestinationAddressPrefixes)
+		securityRules = append(securityRules, rule)
+	}
+	return securityRules
+}
+
+// getAddressPrefixes returns the single address prefix or the list of address prefixes a security rule should use,
+// as ARM does not accept both. No prefixes means any address.
+func getAddressPrefixes(prefixes []string) (*string, *[]string) {
+	switch len(prefixes) {
+	case 0:
+		return to.StringPtr("*"), nil
+	case 1:
+		return to.StringPtr(prefixes[0]), nil
+	default:
+		return nil, &prefixes
+	}
+}
+
+func getPortRange(portRange string) string {
+	if portRange == "" {
+		return "*"
+	}
+	return portRange
+}
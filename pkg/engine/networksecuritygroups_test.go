@@ -179,13 +179,32 @@ func TestCreateJumpboxNSG(t *testing.T) {
 		},
 	}
 
-	actual := createJumpboxNSG()
+	cs := &api.ContainerService{
+		Properties: &api.Properties{},
+	}
+	actual := createJumpboxNSG(cs)
 
 	diff := cmp.Diff(actual, expected)
 
 	if diff != "" {
 		t.Errorf("unexpected diff while comparing nsgs : %s", diff)
 	}
+
+	// the jumpbox allows SSH from the same sources as the masters
+	cs.Properties.NetworkSecurityProfile = &api.NetworkSecurityProfile{
+		SSHSourceAddressPrefixes: []string{"10.0.0.0/8", "192.168.0.0/16"},
+	}
+	rule := (*expected.SecurityRules)[0]
+	rule.SourceAddressPrefix = nil
+	rule.SourceAddressPrefixes = &[]string{"10.0.0.0/8", "192.168.0.0/16"}
+	expected.SecurityRules = &[]network.SecurityRule{rule}
+	actual = createJumpboxNSG(cs)
+
+	diff = cmp.Diff(actual, expected)
+
+	if diff != "" {
+		t.Errorf("unexpected diff while comparing nsgs : %s", diff)
+	}
 }
 
 func TestCreateHostedMasterNSG(t *testing.T) {
@@ -203,7 +222,192 @@ func TestCreateHostedMasterNSG(t *testing.T) {
 		},
 	}
 
-	actual := createHostedMasterNSG()
+	cs := &api.ContainerService{
+		Properties: &api.Properties{},
+	}
+	actual := createHostedMasterNSG(cs)
+
+	diff := cmp.Diff(actual, expected)
+
+	if diff != "" {
+		t.Errorf("unexpected diff while comparing nsgs : %s", diff)
+	}
+
+	// Test create hosted master nsg with custom rules
+
+	cs.Properties.NetworkSecurityProfile = &api.NetworkSecurityProfile{
+		SecurityRules: []api.NetworkSecurityRule{
+			{
+				Name:                       "deny_smtp",
+				Priority:                   300,
+				Direction:                  "Outbound",
+				Access:                     "Deny",
+				Protocol:                   "Tcp",
+				DestinationAddressPrefixes: []string{"Internet"},
+				DestinationPortRange:       "25",
+			},
+		},
+	}
+	actual = createHostedMasterNSG(cs)
+
+	expected.SecurityRules = &[]network.SecurityRule{
+		{
+			Name: to.StringPtr("deny_smtp"),
+			SecurityRulePropertiesFormat: &network.SecurityRulePropertiesFormat{
+				Access:                   network.SecurityRuleAccessDeny,
+				DestinationAddressPrefix: to.StringPtr("Internet"),
+				DestinationPortRange:     to.StringPtr("25"),
+				Direction:                network.SecurityRuleDirectionOutbound,
+				Priority:                 to.Int32Ptr(300),
+				Protocol:                 network.SecurityRuleProtocolTCP,
+				SourceAddressPrefix:      to.StringPtr("*"),
+				SourcePortRange:          to.StringPtr("*"),
+			},
+		},
+	}
+
+	diff = cmp.Diff(actual, expected)
+
+	if diff != "" {
+		t.Errorf("unexpected diff while comparing nsgs : %s", diff)
+	}
+}
+
+func TestCreateNetworkSecurityGroupWithNetworkSecurityProfile(t *testing.T) {
+	cs := &api.ContainerService{
+		Properties: &api.Properties{
+			AgentPoolProfiles: []*api.AgentPoolProfile{
+				{
+					Name:   "fooAgent",
+					OSType: "Windows",
+				},
+			},
+			FeatureFlags: &api.FeatureFlags{},
+			NetworkSecurityProfile: &api.NetworkSecurityProfile{
+				DisableSSH:                     true,
+				APIServerSourceAddressPrefixes: []string{"203.0.113.0/24", "198.51.100.0/24"},
+				RDPSourceAddressPrefixes:       []string{"203.0.113.10"},
+				SecurityRules: []api.NetworkSecurityRule{
+					{
+						Name:                  "allow_prometheus",
+						Description:           "Allow Prometheus scrapes",
+						Priority:              200,
+						Direction:             "Inbound",
+						Access:                "Allow",
+						Protocol:              "Tcp",
+						SourceAddressPrefixes: []string{"10.0.0.0/8"},
+						DestinationPortRange:  "9090",
+					},
+				},
+			},
+		},
+	}
+
+	actual := CreateNetworkSecurityGroup(cs)
+
+	expected := []network.SecurityRule{
+		{
+			Name: to.StringPtr("allow_kube_tls"),
+			SecurityRulePropertiesFormat: &network.SecurityRulePropertiesFormat{
+				Access:                   network.SecurityRuleAccessAllow,
+				Description:              to.StringPtr("Allow kube-apiserver (tls) traffic to master"),
+				DestinationAddressPrefix: to.StringPtr("*"),
+				DestinationPortRange:     to.StringPtr("443-443"),
+				Direction:                network.SecurityRuleDirectionInbound,
+				Priority:                 to.Int32Ptr(100),
+				Protocol:                 network.SecurityRuleProtocolTCP,
+				SourceAddressPrefixes:    &[]string{"203.0.113.0/24", "198.51.100.0/24"},
+				SourcePortRange:          to.StringPtr("*"),
+			},
+		},
+		{
+			Name: to.StringPtr("allow_rdp"),
+			SecurityRulePropertiesFormat: &network.SecurityRulePropertiesFormat{
+				Access:                   network.SecurityRuleAccessAllow,
+				Description:              to.StringPtr("Allow RDP traffic to master"),
+				DestinationAddressPrefix: to.StringPtr("*"),
+				DestinationPortRange:     to.StringPtr("3389-3389"),
+				Direction:                network.SecurityRuleDirectionInbound,
+				Priority:                 to.Int32Ptr(102),
+				Protocol:                 network.SecurityRuleProtocolTCP,
+				SourceAddressPrefix:      to.StringPtr("203.0.113.10"),
+				SourcePortRange:          to.StringPtr("*"),
+			},
+		},
+		{
+			Name: to.StringPtr("allow_prometheus"),
+			SecurityRulePropertiesFormat: &network.SecurityRulePropertiesFormat{
+				Access:                   network.SecurityRuleAccessAllow,
+				Description:              to.StringPtr("Allow Prometheus scrapes"),
+				DestinationAddressPrefix: to.StringPtr("*"),
+				DestinationPortRange:     to.StringPtr("9090"),
+				Direction:                network.SecurityRuleDirectionInbound,
+				Priority:                 to.Int32Ptr(200),
+				Protocol:                 network.SecurityRuleProtocolTCP,
+				SourceAddressPrefix:      to.StringPtr("10.0.0.0/8"),
+				SourcePortRange:          to.StringPtr("*"),
+			},
+		},
+	}
+
+	diff := cmp.Diff(*actual.SecurityRules, expected)
+
+	if diff != "" {
+		t.Errorf("unexpected diff while comparing nsgs : %s", diff)
+	}
+}
+
+func TestCreateAgentPoolNetworkSecurityGroup(t *testing.T) {
+	cs := &api.ContainerService{
+		Properties: &api.Properties{
+			NetworkSecurityProfile: &api.NetworkSecurityProfile{
+				SecurityRules: []api.NetworkSecurityRule{
+					{
+						Name:                  "allow_https",
+						Priority:              300,
+						Direction:             "Inbound",
+						Access:                "Allow",
+						Protocol:              "Tcp",
+						SourceAddressPrefixes: []string{"10.0.0.0/8"},
+						DestinationPortRange:  "443",
+					},
+				},
+			},
+		},
+	}
+	profile := &api.AgentPoolProfile{
+		Name:                                "pool1",
+		EnableDedicatedNetworkSecurityGroup: to.BoolPtr(true),
+	}
+
+	actual := createAgentPoolNetworkSecurityGroup(cs, profile)
+	expected := NetworkSecurityGroupARM{
+		ARMResource: ARMResource{
+			APIVersion: "[variables('apiVersionNetwork')]",
+		},
+		SecurityGroup: network.SecurityGroup{
+			Location: to.StringPtr("[variables('location')]"),
+			Name:     to.StringPtr("[variables('pool1NSGName')]"),
+			Type:     to.StringPtr("Microsoft.Network/networkSecurityGroups"),
+			SecurityGroupPropertiesFormat: &network.SecurityGroupPropertiesFormat{
+				SecurityRules: &[]network.SecurityRule{
+					{
+						Name: to.StringPtr("allow_https"),
+						SecurityRulePropertiesFormat: &network.SecurityRulePropertiesFormat{
+							Access:                   network.SecurityRuleAccessAllow,
+							DestinationAddressPrefix: to.StringPtr("*"),
+							DestinationPortRange:     to.StringPtr("443"),
+							Direction:                network.SecurityRuleDirectionInbound,
+							Priority:                 to.Int32Ptr(300),
+							Protocol:                 network.SecurityRuleProtocolTCP,
+							SourceAddressPrefix:      to.StringPtr("10.0.0.0/8"),
+							SourcePortRange:          to.StringPtr("*"),
+						},
+					},
+				},
+			},
+		},
+	}
 
 	diff := cmp.Diff(actual, expected)
 
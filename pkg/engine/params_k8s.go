@@ -25,7 +25,7 @@ func assignKubernetesParameters(properties *api.Properties, parametersMap params
 	if orchestratorProfile.IsKubernetes() {
 
 		k8sVersion := orchestratorProfile.OrchestratorVersion
-		k8sComponents := api.K8sComponentsByVersionMap[k8sVersion]
+		k8sComponents := orchestratorProfile.GetK8sComponents()
 		kubernetesConfig := orchestratorProfile.KubernetesConfig
 		kubernetesImageBase := kubernetesConfig.KubernetesImageBase
 		hyperKubeImageBase := kubernetesConfig.KubernetesImageBase
@@ -51,6 +51,11 @@ func assignKubernetesParameters(properties *api.Properties, parametersMap params
 
 			addValue(parametersMap, "kubeDNSServiceIP", kubernetesConfig.DNSServiceIP)
 			addValue(parametersMap, "kubernetesHyperkubeSpec", kubernetesHyperkubeSpec)
+			kubernetesKubeProxySpec := kubernetesHyperkubeSpec
+			if kubeProxy, ok := k8sComponents["kube-proxy"]; ok {
+				kubernetesKubeProxySpec = kubernetesImageBase + kubeProxy
+			}
+			addValue(parametersMap, "kubernetesKubeProxySpec", kubernetesKubeProxySpec)
 			if kubernetesConfig.PrivateAzureRegistryServer != "" {
 				addValue(parametersMap, "privateAzureRegistryServer", kubernetesConfig.PrivateAzureRegistryServer)
 			}
@@ -110,14 +115,14 @@ func assignKubernetesParameters(properties *api.Properties, parametersMap params
 			addValue(parametersMap, "networkPolicy", kubernetesConfig.NetworkPolicy)
 			addValue(parametersMap, "networkPlugin", kubernetesConfig.NetworkPlugin)
 			addValue(parametersMap, "containerRuntime", kubernetesConfig.ContainerRuntime)
-			addValue(parametersMap, "containerdDownloadURLBase", cloudSpecConfig.KubernetesSpecConfig.ContainerdDownloadURLBase)
-			addValue(parametersMap, "cniPluginsURL", cloudSpecConfig.KubernetesSpecConfig.CNIPluginsDownloadURL)
+			addValue(parametersMap, "containerdDownloadURLBase", kubernetesConfig.GetContainerdDownloadURLBase(cloudSpecConfig))
+			addValue(parametersMap, "cniPluginsURL", kubernetesConfig.GetCNIPluginsURL(cloudSpecConfig))
 			addValue(parametersMap, "vnetCniLinuxPluginsURL", kubernetesConfig.GetAzureCNIURLLinux(cloudSpecConfig))
 			addValue(parametersMap, "vnetCniWindowsPluginsURL", kubernetesConfig.GetAzureCNIURLWindows(cloudSpecConfig))
 			addValue(parametersMap, "gchighthreshold", kubernetesConfig.GCHighThreshold)
 			addValue(parametersMap, "gclowthreshold", kubernetesConfig.GCLowThreshold)
-			addValue(parametersMap, "etcdDownloadURLBase", cloudSpecConfig.KubernetesSpecConfig.EtcdDownloadURLBase)
-			addValue(parametersMap, "etcdVersion", kubernetesConfig.EtcdVersion)
+			addValue(parametersMap, "etcdDownloadURLBase", kubernetesConfig.GetEtcdDownloadURLBase(cloudSpecConfig))
+			addValue(parametersMap, "etcdVersion", orchestratorProfile.GetEtcdVersion())
 			addValue(parametersMap, "etcdDiskSizeGB", kubernetesConfig.EtcdDiskSizeGB)
 			addValue(parametersMap, "etcdEncryptionKey", kubernetesConfig.EtcdEncryptionKey)
 			if kubernetesConfig.PrivateJumpboxProvision() {
@@ -0,0 +1,150 @@
+// Copyright (c) Microsoft Corporation. All rights reserved.
+// Licensed under the MIT license.
+
+package engine
+
+import (
+	"fmt"
+
+	"github.com/Azure/aks-engine/pkg/api"
+	"github.com/Azure/azure-sdk-for-go/services/network/mgmt/2018-08-01/network"
+	"github.com/Azure/go-autorest/autorest/to"
+)
+
+const (
+	apiVersionPrivateDNS    = "2018-09-01"
+	apiVersionDeployments   = "2018-05-01"
+	privateDNSZoneRecordTTL = 300
+)
+
+// createPrivateDNSZoneResources returns the private DNS zone of a private cluster, the A record of
+// the API server and the links to the cluster VNET and any extra VNETs. When the zone already
+// exists the record and links are created by a nested deployment scoped to the zone's resource group.
+func createPrivateDNSZoneResources(cs *api.ContainerService) []interface{} {
+	zone := cs.Properties.OrchestratorProfile.KubernetesConfig.PrivateCluster.PrivateDNSZone
+
+	var resources []interface{}
+	if !zone.IsExistingZone() {
+		resources = append(resources, createPrivateDNSZone())
+	}
+	resources = append(resources, createPrivateDNSZoneARecord(zone))
+	for _, link := range createPrivateDNSZoneVirtualNetworkLinks(cs) {
+		resources = append(resources, link)
+	}
+
+	if !zone.IsExistingZone() {
+		return resources
+	}
+	return []interface{}{createPrivateDNSZoneDeployment(cs, resources)}
+}
+
+func createPrivateDNSZone() PrivateDNSZoneARM {
+	return PrivateDNSZoneARM{
+		ARMResource: ARMResource{
+			APIVersion: apiVersionPrivateDNS,
+		},
+		PrivateDNSZone: PrivateDNSZone{
+			Name:     to.StringPtr("[variables('privateDNSZoneName')]"),
+			Type:     to.StringPtr("Microsoft.Network/privateDnsZones"),
+			Location: to.StringPtr("global"),
+		},
+	}
+}
+
+func createPrivateDNSZoneARecord(zone *api.PrivateDNSZone) PrivateDNSZoneRecordSetARM {
+	var dependencies []string
+	if !zone.IsExistingZone() {
+		dependencies = append(dependencies, "[resourceId('Microsoft.Network/privateDnsZones', variables('privateDNSZoneName'))]")
+	}
+
+	return PrivateDNSZoneRecordSetARM{
+		ARMResource: ARMResource{
+			APIVersion: apiVersionPrivateDNS,
+			DependsOn:  dependencies,
+		},
+		PrivateDNSZoneRecordSet: PrivateDNSZoneRecordSet{
+			Name: to.StringPtr("[concat(variables('privateDNSZoneName'), '/', variables('masterFqdnPrefix'))]"),
+			Type: to.StringPtr("Microsoft.Network/privateDnsZones/A"),
+			PrivateDNSZoneRecordSetProperties: &PrivateDNSZoneRecordSetProperties{
+				TTL: to.Int64Ptr(privateDNSZoneRecordTTL),
+				ARecords: &[]PrivateDNSZoneARecord{
+					{
+						IPv4Address: to.StringPtr("[variables('kubernetesAPIServerIP')]"),
+					},
+				},
+			},
+		},
+	}
+}
+
+func createPrivateDNSZoneVirtualNetworkLinks(cs *api.ContainerService) []VirtualNetworkLinkARM {
+	zone := cs.Properties.OrchestratorProfile.KubernetesConfig.PrivateCluster.PrivateDNSZone
+
+	var dependencies []string
+	if !zone.IsExistingZone() {
+		dependencies = append(dependencies, "[resourceId('Microsoft.Network/privateDnsZones', variables('privateDNSZoneName'))]")
+		if !cs.Properties.MasterProfile.IsCustomVNET() {
+			dependencies = append(dependencies, "[variables('vnetID')]")
+		}
+	}
+
+	links := []VirtualNetworkLinkARM{
+		newVirtualNetworkLink("[concat(variables('privateDNSZoneName'), '/', variables('masterFqdnPrefix'), '-vnet-link')]", "[variables('privateDNSZoneVnetID')]", dependencies),
+	}
+	for i, id := range zone.VirtualNetworkIDs {
+		name := fmt.Sprintf("[concat(variables('privateDNSZoneName'), '/', variables('masterFqdnPrefix'), '-vnet-link-%d')]", i)
+		links = append(links, newVirtualNetworkLink(name, id, dependencies))
+	}
+	return links
+}
+
+func newVirtualNetworkLink(name, vnetID string, dependencies []string) VirtualNetworkLinkARM {
+	return VirtualNetworkLinkARM{
+		ARMResource: ARMResource{
+			APIVersion: apiVersionPrivateDNS,
+			DependsOn:  dependencies,
+		},
+		VirtualNetworkLink: VirtualNetworkLink{
+			Name:     to.StringPtr(name),
+			Type:     to.StringPtr("Microsoft.Network/privateDnsZones/virtualNetworkLinks"),
+			Location: to.StringPtr("global"),
+			VirtualNetworkLinkProperties: &VirtualNetworkLinkProperties{
+				VirtualNetwork: &network.SubResource{
+					ID: to.StringPtr(vnetID),
+				},
+				RegistrationEnabled: to.BoolPtr(false),
+			},
+		},
+	}
+}
+
+// createPrivateDNSZoneDeployment wraps resources in a nested deployment targeting the subscription
+// and resource group of an existing private DNS zone. The inline template is evaluated in the
+// scope of the outer template, so it can keep referring to the outer variables.
+func createPrivateDNSZoneDeployment(cs *api.ContainerService, resources []interface{}) DeploymentARM {
+	var dependencies []string
+	if !cs.Properties.MasterProfile.IsCustomVNET() {
+		dependencies = append(dependencies, "[variables('vnetID')]")
+	}
+
+	return DeploymentARM{
+		ARMResource: ARMResource{
+			APIVersion: apiVersionDeployments,
+			DependsOn:  dependencies,
+		},
+		Deployment: Deployment{
+			Name:           to.StringPtr("[concat(variables('masterFqdnPrefix'), '-private-dns-zone')]"),
+			Type:           to.StringPtr("Microsoft.Resources/deployments"),
+			SubscriptionID: to.StringPtr("[variables('privateDNSZoneSubscriptionID')]"),
+			ResourceGroup:  to.StringPtr("[variables('privateDNSZoneResourceGroup')]"),
+			Properties: &DeploymentProperties{
+				Mode: "Incremental",
+				Template: map[string]interface{}{
+					"$schema":        "https://schema.management.azure.com/schemas/2015-01-01/deploymentTemplate.json#",
+					"contentVersion": "1.0.0.0",
+					"resources":      resources,
+				},
+			},
+		},
+	}
+}
@@ -0,0 +1,178 @@
+// Copyright (c) Microsoft Corporation. All rights reserved.
+// Licensed under the MIT license.
+
+package engine
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/Azure/aks-engine/pkg/api"
+	"github.com/Azure/azure-sdk-for-go/services/network/mgmt/2018-08-01/network"
+	"github.com/Azure/go-autorest/autorest/to"
+	"github.com/google/go-cmp/cmp"
+)
+
+func getPrivateDNSZoneContainerService(zone *api.PrivateDNSZone) *api.ContainerService {
+	return &api.ContainerService{
+		Properties: &api.Properties{
+			MasterProfile: &api.MasterProfile{
+				Count:     1,
+				DNSPrefix: "mycluster",
+			},
+			OrchestratorProfile: &api.OrchestratorProfile{
+				OrchestratorType: api.Kubernetes,
+				KubernetesConfig: &api.KubernetesConfig{
+					PrivateCluster: &api.PrivateCluster{
+						Enabled:        to.BoolPtr(true),
+						PrivateDNSZone: zone,
+					},
+				},
+			},
+		},
+	}
+}
+
+func TestCreatePrivateDNSZoneResources(t *testing.T) {
+	cs := getPrivateDNSZoneContainerService(&api.PrivateDNSZone{
+		Enabled:           to.BoolPtr(true),
+		Name:              "privatelink.westus2.cloudapp.azure.com",
+		VirtualNetworkIDs: []string{"/subscriptions/SUB_ID/resourceGroups/RG_NAME/providers/Microsoft.Network/virtualNetworks/hub"},
+	})
+
+	actual := createPrivateDNSZoneResources(cs)
+	zoneDependency := "[resourceId('Microsoft.Network/privateDnsZones', variables('privateDNSZoneName'))]"
+	expected := []interface{}{
+		PrivateDNSZoneARM{
+			ARMResource: ARMResource{
+				APIVersion: "2018-09-01",
+			},
+			PrivateDNSZone: PrivateDNSZone{
+				Name:     to.StringPtr("[variables('privateDNSZoneName')]"),
+				Type:     to.StringPtr("Microsoft.Network/privateDnsZones"),
+				Location: to.StringPtr("global"),
+			},
+		},
+		PrivateDNSZoneRecordSetARM{
+			ARMResource: ARMResource{
+				APIVersion: "2018-09-01",
+				DependsOn:  []string{zoneDependency},
+			},
+			PrivateDNSZoneRecordSet: PrivateDNSZoneRecordSet{
+				Name: to.StringPtr("[concat(variables('privateDNSZoneName'), '/', variables('masterFqdnPrefix'))]"),
+				Type: to.StringPtr("Microsoft.Network/privateDnsZones/A"),
+				PrivateDNSZoneRecordSetProperties: &PrivateDNSZoneRecordSetProperties{
+					TTL: to.Int64Ptr(300),
+					ARecords: &[]PrivateDNSZoneARecord{
+						{
+							IPv4Address: to.StringPtr("[variables('kubernetesAPIServerIP')]"),
+						},
+					},
+				},
+			},
+		},
+		VirtualNetworkLinkARM{
+			ARMResource: ARMResource{
+				APIVersion: "2018-09-01",
+				DependsOn:  []string{zoneDependency, "[variables('vnetID')]"},
+			},
+			VirtualNetworkLink: VirtualNetworkLink{
+				Name:     to.StringPtr("[concat(variables('privateDNSZoneName'), '/', variables('masterFqdnPrefix'), '-vnet-link')]"),
+				Type:     to.StringPtr("Microsoft.Network/privateDnsZones/virtualNetworkLinks"),
+				Location: to.StringPtr("global"),
+				VirtualNetworkLinkProperties: &VirtualNetworkLinkProperties{
+					VirtualNetwork: &network.SubResource{
+						ID: to.StringPtr("[variables('privateDNSZoneVnetID')]"),
+					},
+					RegistrationEnabled: to.BoolPtr(false),
+				},
+			},
+		},
+		VirtualNetworkLinkARM{
+			ARMResource: ARMResource{
+				APIVersion: "2018-09-01",
+				DependsOn:  []string{zoneDependency, "[variables('vnetID')]"},
+			},
+			VirtualNetworkLink: VirtualNetworkLink{
+				Name:     to.StringPtr("[concat(variables('privateDNSZoneName'), '/', variables('masterFqdnPrefix'), '-vnet-link-0')]"),
+				Type:     to.StringPtr("Microsoft.Network/privateDnsZones/virtualNetworkLinks"),
+				Location: to.StringPtr("global"),
+				VirtualNetworkLinkProperties: &VirtualNetworkLinkProperties{
+					VirtualNetwork: &network.SubResource{
+						ID: to.StringPtr("/subscriptions/SUB_ID/resourceGroups/RG_NAME/providers/Microsoft.Network/virtualNetworks/hub"),
+					},
+					RegistrationEnabled: to.BoolPtr(false),
+				},
+			},
+		},
+	}
+
+	diff := cmp.Diff(actual, expected)
+
+	if diff != "" {
+		t.Errorf("unexpected diff while comparing: %s", diff)
+	}
+}
+
+func TestCreatePrivateDNSZoneResourcesExistingZone(t *testing.T) {
+	cs := getPrivateDNSZoneContainerService(&api.PrivateDNSZone{
+		Enabled: to.BoolPtr(true),
+		ID:      "/subscriptions/SUB_ID/resourceGroups/dns/providers/Microsoft.Network/privateDnsZones/privatelink.westus2.cloudapp.azure.com",
+		Name:    "privatelink.westus2.cloudapp.azure.com",
+	})
+	cs.Properties.MasterProfile.VnetSubnetID = "/subscriptions/SUB_ID/resourceGroups/RG_NAME/providers/Microsoft.Network/virtualNetworks/VNET_NAME/subnets/SUBNET_NAME"
+
+	actual := createPrivateDNSZoneResources(cs)
+	if len(actual) != 1 {
+		t.Fatalf("expected a single nested deployment, got %d resources", len(actual))
+	}
+	deployment, ok := actual[0].(DeploymentARM)
+	if !ok {
+		t.Fatalf("expected a DeploymentARM, got %T", actual[0])
+	}
+	if deployment.DependsOn != nil {
+		t.Errorf("expected no dependencies for a custom VNET, got %v", deployment.DependsOn)
+	}
+	if to.String(deployment.SubscriptionID) != "[variables('privateDNSZoneSubscriptionID')]" ||
+		to.String(deployment.ResourceGroup) != "[variables('privateDNSZoneResourceGroup')]" {
+		t.Errorf("unexpected deployment scope %s/%s", to.String(deployment.SubscriptionID), to.String(deployment.ResourceGroup))
+	}
+
+	nested := deployment.Properties.Template["resources"].([]interface{})
+	if len(nested) != 2 {
+		t.Fatalf("expected the A record and the VNET link in the nested template, got %d resources", len(nested))
+	}
+	if _, ok := nested[0].(PrivateDNSZoneRecordSetARM); !ok {
+		t.Errorf("expected the first nested resource to be the A record, got %T", nested[0])
+	}
+	link, ok := nested[1].(VirtualNetworkLinkARM)
+	if !ok {
+		t.Fatalf("expected the second nested resource to be a VNET link, got %T", nested[1])
+	}
+	if link.DependsOn != nil {
+		t.Errorf("expected no dependencies on a resource in another resource group, got %v", link.DependsOn)
+	}
+}
+
+func TestPrivateDNSZoneMarshalJSON(t *testing.T) {
+	cs := getPrivateDNSZoneContainerService(&api.PrivateDNSZone{
+		Enabled: to.BoolPtr(true),
+		Name:    "privatelink.westus2.cloudapp.azure.com",
+	})
+
+	b, err := json.Marshal(createPrivateDNSZoneResources(cs))
+	if err != nil {
+		t.Fatalf("unexpected error marshaling private DNS zone resources: %s", err)
+	}
+	actual := string(b)
+	for _, expected := range []string{
+		`"apiVersion":"2018-09-01"`,
+		`"type":"Microsoft.Network/privateDnsZones/A","properties":{"ttl":300,"aRecords":[{"ipv4Address":"[variables('kubernetesAPIServerIP')]"}]}`,
+		`"properties":{"virtualNetwork":{"id":"[variables('privateDNSZoneVnetID')]"},"registrationEnabled":false}`,
+	} {
+		if !strings.Contains(actual, expected) {
+			t.Errorf("expected %s to contain %s", actual, expected)
+		}
+	}
+}
@@ -0,0 +1,49 @@
+// Copyright (c) Microsoft Corporation. All rights reserved.
+// Licensed under the MIT license.
+
+package engine
+
+import (
+	"github.com/Azure/aks-engine/pkg/api"
+	"github.com/Azure/go-autorest/autorest/to"
+)
+
+// apiVersionComputeHostGroup is the first compute API version that accepts a dedicated host group on scale sets
+const apiVersionComputeHostGroup = "2020-06-01"
+
+// proximityPlacementGroupDependency is the dependency on the proximity placement group created by aks-engine
+const proximityPlacementGroupDependency = "[concat('Microsoft.Compute/proximityPlacementGroups/', variables('proximityPlacementGroupName'))]"
+
+// createProximityPlacementGroup creates the proximity placement group shared by the masters and agent pools
+// that set enableProximityPlacementGroup.
+func createProximityPlacementGroup() ProximityPlacementGroupARM {
+	return ProximityPlacementGroupARM{
+		ARMResource: ARMResource{
+			APIVersion: "[variables('apiVersionCompute')]",
+		},
+		ProximityPlacementGroup: ProximityPlacementGroup{
+			Name:     to.StringPtr("[variables('proximityPlacementGroupName')]"),
+			Type:     to.StringPtr("Microsoft.Compute/proximityPlacementGroups"),
+			Location: to.StringPtr("[variables('location')]"),
+			Properties: &ProximityPlacementGroupProperties{
+				ProximityPlacementGroupType: "Standard",
+			},
+		},
+	}
+}
+
+// getMasterProximityPlacementGroupID returns the proximity placement group of the masters, or an empty string.
+func getMasterProximityPlacementGroupID(m *api.MasterProfile) string {
+	if m.IsProximityPlacementGroupEnabled() {
+		return "[variables('proximityPlacementGroupID')]"
+	}
+	return m.ProximityPlacementGroupID
+}
+
+// getAgentProximityPlacementGroupID returns the proximity placement group of the agent pool, or an empty string.
+func getAgentProximityPlacementGroupID(profile *api.AgentPoolProfile) string {
+	if profile.IsProximityPlacementGroupEnabled() {
+		return "[variables('proximityPlacementGroupID')]"
+	}
+	return profile.ProximityPlacementGroupID
+}
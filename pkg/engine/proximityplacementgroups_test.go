@@ -0,0 +1,155 @@
+// Copyright (c) Microsoft Corporation. All rights reserved.
+// Licensed under the MIT license.
+
+package engine
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/Azure/aks-engine/pkg/api"
+	"github.com/Azure/azure-sdk-for-go/services/compute/mgmt/2018-10-01/compute"
+	"github.com/Azure/go-autorest/autorest/to"
+	"github.com/google/go-cmp/cmp"
+)
+
+const (
+	testProximityPlacementGroupID = "/subscriptions/SUB_ID/resourceGroups/RG_NAME/providers/Microsoft.Compute/proximityPlacementGroups/ppg"
+	testHostGroupID               = "/subscriptions/SUB_ID/resourceGroups/RG_NAME/providers/Microsoft.Compute/hostGroups/hg"
+)
+
+func TestCreateProximityPlacementGroup(t *testing.T) {
+	expected := ProximityPlacementGroupARM{
+		ARMResource: ARMResource{
+			APIVersion: "[variables('apiVersionCompute')]",
+		},
+		ProximityPlacementGroup: ProximityPlacementGroup{
+			Name:     to.StringPtr("[variables('proximityPlacementGroupName')]"),
+			Type:     to.StringPtr("Microsoft.Compute/proximityPlacementGroups"),
+			Location: to.StringPtr("[variables('location')]"),
+			Properties: &ProximityPlacementGroupProperties{
+				ProximityPlacementGroupType: "Standard",
+			},
+		},
+	}
+
+	actual := createProximityPlacementGroup()
+
+	diff := cmp.Diff(actual, expected)
+	if diff != "" {
+		t.Errorf("unexpected diff while comparing proximity placement groups: %s", diff)
+	}
+
+	b, err := json.Marshal(actual)
+	if err != nil {
+		t.Fatalf("unexpected error marshaling the proximity placement group: %s", err)
+	}
+	if !strings.Contains(string(b), `"apiVersion":"[variables('apiVersionCompute')]"`) {
+		t.Errorf("expected the marshaled proximity placement group to keep its apiVersion, got %s", b)
+	}
+}
+
+func TestGetProximityPlacementGroupID(t *testing.T) {
+	if id := getMasterProximityPlacementGroupID(&api.MasterProfile{}); id != "" {
+		t.Errorf("expected no master proximity placement group, got %s", id)
+	}
+	if id := getMasterProximityPlacementGroupID(&api.MasterProfile{EnableProximityPlacementGroup: to.BoolPtr(true)}); id != "[variables('proximityPlacementGroupID')]" {
+		t.Errorf("expected the created proximity placement group, got %s", id)
+	}
+	if id := getAgentProximityPlacementGroupID(&api.AgentPoolProfile{ProximityPlacementGroupID: testProximityPlacementGroupID}); id != testProximityPlacementGroupID {
+		t.Errorf("expected proximity placement group %s, got %s", testProximityPlacementGroupID, id)
+	}
+}
+
+func TestAvailabilitySetARMMarshalJSONProximityPlacementGroup(t *testing.T) {
+	avSet := AvailabilitySetARM{
+		ARMResource: ARMResource{
+			APIVersion: "[variables('apiVersionCompute')]",
+		},
+		AvailabilitySet: compute.AvailabilitySet{
+			Name: to.StringPtr("avset"),
+		},
+	}
+
+	b, err := json.Marshal(avSet)
+	if err != nil {
+		t.Fatalf("unexpected error marshaling the availability set: %s", err)
+	}
+	if strings.Contains(string(b), "proximityPlacementGroup") {
+		t.Errorf("expected no proximity placement group, got %s", b)
+	}
+
+	avSet.ProximityPlacementGroupID = testProximityPlacementGroupID
+	b, err = json.Marshal(avSet)
+	if err != nil {
+		t.Fatalf("unexpected error marshaling the availability set: %s", err)
+	}
+	expected := `"properties":{"proximityPlacementGroup":{"id":"` + testProximityPlacementGroupID + `"}}`
+	if !strings.Contains(string(b), expected) {
+		t.Errorf("expected %s in the marshaled availability set, got %s", expected, b)
+	}
+}
+
+func TestVirtualMachineScaleSetARMMarshalJSONPlacement(t *testing.T) {
+	vmss := VirtualMachineScaleSetARM{
+		ARMResource: ARMResource{
+			APIVersion: apiVersionComputeHostGroup,
+		},
+		VirtualMachineScaleSet: compute.VirtualMachineScaleSet{
+			Name: to.StringPtr("vmss"),
+			VirtualMachineScaleSetProperties: &compute.VirtualMachineScaleSetProperties{
+				Overprovision: to.BoolPtr(false),
+			},
+		},
+		ProximityPlacementGroupID: "[variables('proximityPlacementGroupID')]",
+		HostGroupID:               testHostGroupID,
+	}
+
+	b, err := json.Marshal(vmss)
+	if err != nil {
+		t.Fatalf("unexpected error marshaling the scale set: %s", err)
+	}
+	actual := string(b)
+	for _, expected := range []string{
+		`"apiVersion":"2020-06-01"`,
+		`"overprovision":false`,
+		`"proximityPlacementGroup":{"id":"[variables('proximityPlacementGroupID')]"}`,
+		`"hostGroup":{"id":"` + testHostGroupID + `"}`,
+	} {
+		if !strings.Contains(actual, expected) {
+			t.Errorf("expected %s in the marshaled scale set, got %s", expected, actual)
+		}
+	}
+}
+
+func TestCreateResourcesProximityPlacementGroup(t *testing.T) {
+	cs := &api.ContainerService{
+		Properties: &api.Properties{
+			MasterProfile: &api.MasterProfile{
+				EnableProximityPlacementGroup: to.BoolPtr(true),
+			},
+		},
+	}
+
+	avSet := CreateAvailabilitySet(cs, true)
+	if avSet.ProximityPlacementGroupID != "[variables('proximityPlacementGroupID')]" {
+		t.Errorf("expected the master availability set in the created proximity placement group, got %s", avSet.ProximityPlacementGroupID)
+	}
+	if diff := cmp.Diff(avSet.DependsOn, []string{proximityPlacementGroupDependency}); diff != "" {
+		t.Errorf("unexpected diff while comparing the master availability set dependencies: %s", diff)
+	}
+
+	profile := &api.AgentPoolProfile{
+		Name:                      "pool1",
+		StorageProfile:            api.ManagedDisks,
+		ProximityPlacementGroupID: testProximityPlacementGroupID,
+	}
+	avSet = createAgentAvailabilitySets(profile)
+	if avSet.ProximityPlacementGroupID != testProximityPlacementGroupID {
+		t.Errorf("expected agent availability set proximity placement group %s, got %s", testProximityPlacementGroupID, avSet.ProximityPlacementGroupID)
+	}
+	if len(avSet.DependsOn) != 0 {
+		t.Errorf("expected the agent availability set not to depend on the created proximity placement group, got %v", avSet.DependsOn)
+	}
+}
@@ -4,12 +4,13 @@
 package engine
 
 import (
+	"github.com/Azure/aks-engine/pkg/api"
 	"github.com/Azure/azure-sdk-for-go/services/network/mgmt/2018-08-01/network"
 	"github.com/Azure/go-autorest/autorest/to"
 )
 
-func createRouteTable() RouteTableARM {
-	return RouteTableARM{
+func createRouteTable(cs *api.ContainerService) RouteTableARM {
+	routeTable := RouteTableARM{
 		ARMResource: ARMResource{
 			APIVersion: "[variables('apiVersionNetwork')]",
 		},
@@ -19,4 +20,23 @@ func createRouteTable() RouteTableARM {
 			Type:     to.StringPtr("Microsoft.Network/routeTables"),
 		},
 	}
+
+	kubernetesConfig := cs.Properties.OrchestratorProfile.KubernetesConfig
+	if kubernetesConfig.IsUserDefinedRoutingEgress() {
+		// The cloud provider route controller only reconciles routes within the cluster subnet, so it leaves this route alone
+		routeTable.RouteTablePropertiesFormat = &network.RouteTablePropertiesFormat{
+			Routes: &[]network.Route{
+				{
+					Name: to.StringPtr("egress-default-route"),
+					RoutePropertiesFormat: &network.RoutePropertiesFormat{
+						AddressPrefix:    to.StringPtr("0.0.0.0/0"),
+						NextHopType:      network.RouteNextHopTypeVirtualAppliance,
+						NextHopIPAddress: to.StringPtr(kubernetesConfig.EgressProfile.FirewallIPAddress),
+					},
+				},
+			},
+		}
+	}
+
+	return routeTable
 }
@@ -6,14 +6,57 @@ package engine
 import (
 	"testing"
 
+	"github.com/Azure/aks-engine/pkg/api"
 	"github.com/Azure/azure-sdk-for-go/services/network/mgmt/2018-08-01/network"
 	"github.com/Azure/go-autorest/autorest/to"
 	"github.com/google/go-cmp/cmp"
 )
 
 func TestCreateRouteTable(t *testing.T) {
+	cs := &api.ContainerService{
+		Properties: &api.Properties{
+			OrchestratorProfile: &api.OrchestratorProfile{
+				OrchestratorType: api.Kubernetes,
+				KubernetesConfig: &api.KubernetesConfig{},
+			},
+		},
+	}
+
+	actual := createRouteTable(cs)
+	expected := RouteTableARM{
+		ARMResource: ARMResource{
+			APIVersion: "[variables('apiVersionNetwork')]",
+		},
+		RouteTable: network.RouteTable{
+			Location: to.StringPtr("[variables('location')]"),
+			Name:     to.StringPtr("[variables('routeTableName')]"),
+			Type:     to.StringPtr("Microsoft.Network/routeTables"),
+		},
+	}
+
+	diff := cmp.Diff(actual, expected)
+
+	if diff != "" {
+		t.Errorf("unexpected diff while comparing: %s", diff)
+	}
+}
+
+func TestCreateRouteTableUserDefinedRoutingEgress(t *testing.T) {
+	cs := &api.ContainerService{
+		Properties: &api.Properties{
+			OrchestratorProfile: &api.OrchestratorProfile{
+				OrchestratorType: api.Kubernetes,
+				KubernetesConfig: &api.KubernetesConfig{
+					EgressProfile: &api.EgressProfile{
+						Type:              api.EgressTypeUserDefinedRouting,
+						FirewallIPAddress: "10.241.0.4",
+					},
+				},
+			},
+		},
+	}
 
-	actual := createRouteTable()
+	actual := createRouteTable(cs)
 	expected := RouteTableARM{
 		ARMResource: ARMResource{
 			APIVersion: "[variables('apiVersionNetwork')]",
@@ -22,6 +65,18 @@ func TestCreateRouteTable(t *testing.T) {
 			Location: to.StringPtr("[variables('location')]"),
 			Name:     to.StringPtr("[variables('routeTableName')]"),
 			Type:     to.StringPtr("Microsoft.Network/routeTables"),
+			RouteTablePropertiesFormat: &network.RouteTablePropertiesFormat{
+				Routes: &[]network.Route{
+					{
+						Name: to.StringPtr("egress-default-route"),
+						RoutePropertiesFormat: &network.RoutePropertiesFormat{
+							AddressPrefix:    to.StringPtr("0.0.0.0/0"),
+							NextHopType:      network.RouteNextHopTypeVirtualAppliance,
+							NextHopIPAddress: to.StringPtr("10.241.0.4"),
+						},
+					},
+				},
+			},
 		},
 	}
 
@@ -309,6 +309,9 @@ func (t *TemplateGenerator) getTemplateFuncMap(cs *api.ContainerService) templat
 			}
 			return buf.String()
 		},
+		"GetAgentKubernetesTaints": func(profile *api.AgentPoolProfile) string {
+			return strings.Join(profile.Taints, ",")
+		},
 		"GetKubeletConfigKeyVals": func(kc *api.KubernetesConfig) string {
 			if kc == nil {
 				return ""
@@ -491,6 +494,9 @@ func (t *TemplateGenerator) getTemplateFuncMap(cs *api.ContainerService) templat
 		"IsHostedBootstrap": func() bool {
 			return false
 		},
+		"GetKubeProxyCommand": func() string {
+			return getKubeProxyCommand(cs.Properties.OrchestratorProfile)
+		},
 		"IsFeatureEnabled": func(feature string) bool {
 			return cs.Properties.FeatureFlags.IsFeatureEnabled(feature)
 		},
@@ -861,6 +867,24 @@ func (t *TemplateGenerator) getTemplateFuncMap(cs *api.ContainerService) templat
 		"HasCiliumNetworkPlugin": func() bool {
 			return cs.Properties.OrchestratorProfile.KubernetesConfig.NetworkPlugin == NetworkPluginCilium
 		},
+		"IsHTTPProxyEnabled": func() bool {
+			return cs.Properties.IsHTTPProxyEnabled()
+		},
+		"GetHTTPProxy": func() string {
+			return cs.Properties.HTTPProxyConfig.HTTPProxy
+		},
+		"GetHTTPSProxy": func() string {
+			return cs.Properties.HTTPProxyConfig.HTTPSProxy
+		},
+		"GetHTTPProxyNoProxy": func() string {
+			return strings.Join(cs.Properties.GetHTTPProxyNoProxy(), ",")
+		},
+		"HasHTTPProxyTrustedCA": func() bool {
+			return cs.Properties.IsHTTPProxyEnabled() && cs.Properties.HTTPProxyConfig.TrustedCA != ""
+		},
+		"GetHTTPProxyTrustedCA": func() string {
+			return cs.Properties.HTTPProxyConfig.TrustedCA
+		},
 		"HasCustomNodesDNS": func() bool {
 			return cs.Properties.LinuxProfile.HasCustomNodesDNS()
 		},
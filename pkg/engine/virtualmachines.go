@@ -30,6 +30,8 @@ func CreateVirtualMachine(cs *api.ContainerService) VirtualMachineARM {
 	dependencies = append(dependencies, dependentNIC)
 	if !hasAvailabilityZones {
 		dependencies = append(dependencies, "[concat('Microsoft.Compute/availabilitySets/',variables('masterAvailabilitySet'))]")
+	} else if cs.Properties.MasterProfile.IsProximityPlacementGroupEnabled() {
+		dependencies = append(dependencies, proximityPlacementGroupDependency)
 	}
 	if isStorageAccount {
 		dependencies = append(dependencies, "[variables('masterStorageAccountName')]")
@@ -195,9 +197,16 @@ func CreateVirtualMachine(cs *api.ContainerService) VirtualMachineARM {
 
 	virtualMachine.VirtualMachineProperties = vmProperties
 
+	diskEncryptionSetID := cs.Properties.MasterProfile.DiskEncryptionSetID
+	if diskEncryptionSetID != "" {
+		armResource.APIVersion = apiVersionComputeDiskEncryptionSet
+	}
+
 	return VirtualMachineARM{
-		ARMResource:    armResource,
-		VirtualMachine: virtualMachine,
+		ARMResource:               armResource,
+		VirtualMachine:            virtualMachine,
+		DiskEncryptionSetID:       diskEncryptionSetID,
+		ProximityPlacementGroupID: getMasterProximityPlacementGroupID(cs.Properties.MasterProfile),
 	}
 }
 
@@ -325,7 +334,7 @@ func createAgentAvailabilitySetVM(cs *api.ContainerService, profile *api.AgentPo
 
 	tags := map[string]*string{
 		"creationSource":   to.StringPtr(fmt.Sprintf("[concat(parameters('generatorCode'), '-', variables('%[1]sVMNamePrefix'), copyIndex(variables('%[1]sOffset')))]", profile.Name)),
-		"orchestrator":     to.StringPtr("[variables('orchestratorNameVersionTag')]"),
+		"orchestrator":     to.StringPtr(getAgentPoolOrchestratorTag(cs, profile)),
 		"aksEngineVersion": to.StringPtr("[parameters('aksEngineVersion')]"),
 		"poolName":         to.StringPtr(profile.Name),
 	}
@@ -501,13 +510,26 @@ func createAgentAvailabilitySetVM(cs *api.ContainerService, profile *api.AgentPo
 		osDisk.DiskSizeGB = to.Int32Ptr(int32(profile.OSDiskSizeGB))
 	}
 
+	if profile.IsEphemeralOSDisk() {
+		osDisk.Caching = compute.CachingTypesReadOnly
+		osDisk.DiffDiskSettings = &compute.DiffDiskSettings{
+			Option: compute.Local,
+		}
+	}
+
 	storageProfile.OsDisk = &osDisk
 
 	virtualMachine.StorageProfile = &storageProfile
 
+	if profile.DiskEncryptionSetID != "" {
+		armResource.APIVersion = apiVersionComputeDiskEncryptionSet
+	}
+
 	return VirtualMachineARM{
-		ARMResource:    armResource,
-		VirtualMachine: virtualMachine,
+		ARMResource:               armResource,
+		VirtualMachine:            virtualMachine,
+		DiskEncryptionSetID:       profile.DiskEncryptionSetID,
+		ProximityPlacementGroupID: getAgentProximityPlacementGroupID(profile),
 	}
 }
 
@@ -280,4 +280,32 @@ func TestCreateAgentAvailabilitySetVM(t *testing.T) {
 	if diff != "" {
 		t.Errorf("unexpected diff while expecting equal structs: %s", diff)
 	}
+
+	// Test with an ephemeral OS disk
+	profile.StorageProfile = api.ManagedDisks
+	profile.OSDiskSizeGB = 0
+	profile.OSDiskType = api.OSDiskTypeEphemeral
+
+	actualVM = createAgentAvailabilitySetVM(cs, profile)
+
+	expectedOSDisk := &compute.OSDisk{
+		CreateOption: compute.DiskCreateOptionTypesFromImage,
+		Caching:      compute.CachingTypesReadOnly,
+		DiffDiskSettings: &compute.DiffDiskSettings{
+			Option: compute.Local,
+		},
+	}
+	if diff = cmp.Diff(actualVM.StorageProfile.OsDisk, expectedOSDisk); diff != "" {
+		t.Errorf("unexpected diff while comparing the ephemeral OS disk: %s", diff)
+	}
+
+	// Test with a disk encryption set
+	profile.OSDiskType = ""
+	profile.DiskEncryptionSetID = "/subscriptions/SUB_ID/resourceGroups/RG_NAME/providers/Microsoft.Compute/diskEncryptionSets/des"
+
+	actualVM = createAgentAvailabilitySetVM(cs, profile)
+
+	if actualVM.APIVersion != "2019-07-01" || actualVM.DiskEncryptionSetID != profile.DiskEncryptionSetID {
+		t.Errorf("expected the VM to use the disk encryption set with API version 2019-07-01, got %s and %s", actualVM.DiskEncryptionSetID, actualVM.APIVersion)
+	}
 }
@@ -50,6 +50,10 @@ func CreateMasterVMSS(cs *api.ContainerService) VirtualMachineScaleSetARM {
 
 	dependencies = append(dependencies, "[variables('masterLbID')]")
 
+	if masterProfile.IsProximityPlacementGroupEnabled() {
+		dependencies = append(dependencies, proximityPlacementGroupDependency)
+	}
+
 	armResource := ARMResource{
 		APIVersion: "[variables('apiVersionCompute')]",
 		DependsOn:  dependencies,
@@ -146,6 +150,14 @@ func CreateMasterVMSS(cs *api.ContainerService) VirtualMachineScaleSetARM {
 		ipConfig.VirtualMachineScaleSetIPConfigurationProperties = &ipConfigProps
 		ipConfigurations = append(ipConfigurations, ipConfig)
 	}
+
+	if cs.Properties.FeatureFlags.IsFeatureEnabled("EnableIPv6DualStack") {
+		ipConfigurations = append(ipConfigurations, getVMSSIPv6IPConfig("[variables('vnetSubnetIDMaster')]", []compute.SubResource{
+			{
+				ID: to.StringPtr("[concat(variables('masterLbID'), '/backendAddressPools/', variables('masterLbIPv6BackendPoolName'))]"),
+			},
+		}))
+	}
 	netintconfig.IPConfigurations = &ipConfigurations
 
 	if linuxProfile != nil && linuxProfile.HasCustomNodesDNS() {
@@ -272,7 +284,7 @@ func CreateMasterVMSS(cs *api.ContainerService) VirtualMachineScaleSetARM {
 	if cs.Properties.MasterProfile != nil && cs.Properties.MasterProfile.IsCoreOS() {
 		ncBinary = "ncat"
 	}
-	if !cs.Properties.FeatureFlags.IsFeatureEnabled("BlockOutboundInternet") {
+	if !cs.Properties.FeatureFlags.IsFeatureEnabled("BlockOutboundInternet") && !cs.Properties.IsHTTPProxyEnabled() {
 		if cs.GetCloudSpecConfig().CloudName == api.AzureChinaCloud {
 			registry = `gcr.azk8s.cn 80`
 		} else {
@@ -324,9 +336,19 @@ func CreateMasterVMSS(cs *api.ContainerService) VirtualMachineScaleSetARM {
 
 	virtualMachine.VirtualMachineScaleSetProperties = vmProperties
 
+	if masterProfile.DiskEncryptionSetID != "" {
+		armResource.APIVersion = apiVersionComputeDiskEncryptionSet
+	}
+	if masterProfile.HostGroupID != "" {
+		armResource.APIVersion = apiVersionComputeHostGroup
+	}
+
 	return VirtualMachineScaleSetARM{
-		ARMResource:            armResource,
-		VirtualMachineScaleSet: virtualMachine,
+		ARMResource:               armResource,
+		VirtualMachineScaleSet:    virtualMachine,
+		DiskEncryptionSetID:       masterProfile.DiskEncryptionSetID,
+		ProximityPlacementGroupID: getMasterProximityPlacementGroupID(masterProfile),
+		HostGroupID:               masterProfile.HostGroupID,
 	}
 }
 
@@ -337,7 +359,7 @@ func CreateAgentVMSS(cs *api.ContainerService, profile *api.AgentPoolProfile) Vi
 	var dependencies []string
 
 	if profile.IsCustomVNET() {
-		dependencies = append(dependencies, "[variables('nsgID')]")
+		dependencies = append(dependencies, getAgentPoolNSGDependency(profile))
 	} else {
 		dependencies = append(dependencies, "[variables('vnetID')]")
 	}
@@ -346,6 +368,14 @@ func CreateAgentVMSS(cs *api.ContainerService, profile *api.AgentPoolProfile) Vi
 	k8sConfig := orchProfile.KubernetesConfig
 	linuxProfile := cs.Properties.LinuxProfile
 
+	if k8sConfig.IsLoadBalancerEgress() {
+		dependencies = append(dependencies, "[variables('agentLbID')]")
+	}
+
+	if profile.IsProximityPlacementGroupEnabled() {
+		dependencies = append(dependencies, proximityPlacementGroupDependency)
+	}
+
 	armResource.DependsOn = dependencies
 
 	var resourceNameSuffix *string
@@ -357,7 +387,7 @@ func CreateAgentVMSS(cs *api.ContainerService, profile *api.AgentPoolProfile) Vi
 	}
 	tags := map[string]*string{
 		"creationSource":     to.StringPtr(fmt.Sprintf("[concat(parameters('generatorCode'), '-', variables('%sVMNamePrefix'))]", profile.Name)),
-		"orchestrator":       to.StringPtr("[variables('orchestratorNameVersionTag')]"),
+		"orchestrator":       to.StringPtr(getAgentPoolOrchestratorTag(cs, profile)),
 		"aksEngineVersion":   to.StringPtr("[parameters('aksEngineVersion')]"),
 		"poolName":           to.StringPtr(profile.Name),
 		"resourceNameSuffix": resourceNameSuffix,
@@ -428,7 +458,7 @@ func CreateAgentVMSS(cs *api.ContainerService, profile *api.AgentPoolProfile) Vi
 
 	if profile.IsCustomVNET() {
 		vmssNICConfig.NetworkSecurityGroup = &compute.SubResource{
-			ID: to.StringPtr("[variables('nsgID')]"),
+			ID: to.StringPtr(getAgentPoolNSGID(profile)),
 		}
 	}
 
@@ -456,10 +486,22 @@ func CreateAgentVMSS(cs *api.ContainerService, profile *api.AgentPoolProfile) Vi
 				}
 				ipconfig.PublicIPAddressConfiguration = publicIPAddressConfiguration
 			}
+
+			if k8sConfig.IsLoadBalancerEgress() {
+				ipconfig.LoadBalancerBackendAddressPools = &[]compute.SubResource{
+					{
+						ID: to.StringPtr("[concat(variables('agentLbID'), '/backendAddressPools/', variables('agentLbBackendPoolName'))]"),
+					},
+				}
+			}
 		}
 		ipConfigurations = append(ipConfigurations, ipconfig)
 	}
 
+	if cs.Properties.FeatureFlags.IsFeatureEnabled("EnableIPv6DualStack") {
+		ipConfigurations = append(ipConfigurations, getVMSSIPv6IPConfig(fmt.Sprintf("[variables('%sVnetSubnetID')]", profile.Name), nil))
+	}
+
 	vmssNICConfig.IPConfigurations = &ipConfigurations
 
 	if linuxProfile != nil && linuxProfile.HasCustomNodesDNS() && !profile.IsWindows() {
@@ -582,6 +624,13 @@ func CreateAgentVMSS(cs *api.ContainerService, profile *api.AgentPoolProfile) Vi
 		osDisk.DiskSizeGB = to.Int32Ptr(int32(profile.OSDiskSizeGB))
 	}
 
+	if profile.IsEphemeralOSDisk() {
+		osDisk.Caching = compute.CachingTypesReadOnly
+		osDisk.DiffDiskSettings = &compute.DiffDiskSettings{
+			Option: compute.Local,
+		}
+	}
+
 	vmssStorageProfile.OsDisk = &osDisk
 
 	vmssVMProfile.StorageProfile = &vmssStorageProfile
@@ -596,7 +645,7 @@ func CreateAgentVMSS(cs *api.ContainerService, profile *api.AgentPoolProfile) Vi
 	}
 	featureFlags := cs.Properties.FeatureFlags
 
-	if !featureFlags.IsFeatureEnabled("BlockOutboundInternet") {
+	if !featureFlags.IsFeatureEnabled("BlockOutboundInternet") && !cs.Properties.IsHTTPProxyEnabled() {
 		if cs.GetCloudSpecConfig().CloudName == api.AzureChinaCloud {
 			registry = `gcr.azk8s.cn 80`
 		} else {
@@ -629,7 +678,7 @@ func CreateAgentVMSS(cs *api.ContainerService, profile *api.AgentPoolProfile) Vi
 		nVidiaEnabled := strconv.FormatBool(common.IsNvidiaEnabledSKU(profile.VMSize))
 		sgxEnabled := strconv.FormatBool(common.IsSgxEnabledSKU(profile.VMSize))
 
-		commandExec := fmt.Sprintf("[concat('retrycmd_if_failure() { r=$1; w=$2; t=$3; shift && shift && shift; for i in $(seq 1 $r); do timeout $t ${@}; [ $? -eq 0  ] && break || if [ $i -eq $r ]; then return 1; else sleep $w; fi; done }; %s for i in $(seq 1 1200); do if [ -f /opt/azure/containers/provision.sh ]; then break; fi; if [ $i -eq 1200 ]; then exit 100; else sleep 1; fi; done; ', variables('provisionScriptParametersCommon'),' GPU_NODE=%s SGX_NODE=%s /usr/bin/nohup /bin/bash -c \"/bin/bash /opt/azure/containers/provision.sh >> /var/log/azure/cluster-provision.log 2>&1%s\"')]", outBoundCmd, nVidiaEnabled, sgxEnabled, runInBackground)
+		commandExec := fmt.Sprintf("[concat('retrycmd_if_failure() { r=$1; w=$2; t=$3; shift && shift && shift; for i in $(seq 1 $r); do timeout $t ${@}; [ $? -eq 0  ] && break || if [ $i -eq $r ]; then return 1; else sleep $w; fi; done }; %s for i in $(seq 1 1200); do if [ -f /opt/azure/containers/provision.sh ]; then break; fi; if [ $i -eq 1200 ]; then exit 100; else sleep 1; fi; done; ', variables('provisionScriptParametersCommon'),%s' GPU_NODE=%s SGX_NODE=%s /usr/bin/nohup /bin/bash -c \"/bin/bash /opt/azure/containers/provision.sh >> /var/log/azure/cluster-provision.log 2>&1%s\"')]", outBoundCmd, getAgentPoolProvisionScriptParameters(cs, profile), nVidiaEnabled, sgxEnabled, runInBackground)
 		vmssCSE = compute.VirtualMachineScaleSetExtension{
 			Name: to.StringPtr("vmssCSE"),
 			VirtualMachineScaleSetExtensionProperties: &compute.VirtualMachineScaleSetExtensionProperties{
@@ -683,10 +732,39 @@ func CreateAgentVMSS(cs *api.ContainerService, profile *api.AgentPoolProfile) Vi
 	vmssProperties.VirtualMachineProfile = &vmssVMProfile
 	virtualMachineScaleSet.VirtualMachineScaleSetProperties = &vmssProperties
 
+	if profile.DiskEncryptionSetID != "" {
+		armResource.APIVersion = apiVersionComputeDiskEncryptionSet
+	}
+	if profile.HostGroupID != "" {
+		armResource.APIVersion = apiVersionComputeHostGroup
+	}
+
 	return VirtualMachineScaleSetARM{
-		ARMResource:            armResource,
-		VirtualMachineScaleSet: virtualMachineScaleSet,
+		ARMResource:               armResource,
+		VirtualMachineScaleSet:    virtualMachineScaleSet,
+		DiskEncryptionSetID:       profile.DiskEncryptionSetID,
+		ProximityPlacementGroupID: getAgentProximityPlacementGroupID(profile),
+		HostGroupID:               profile.HostGroupID,
+	}
+}
+
+// getVMSSIPv6IPConfig returns the IPv6 ip configuration added to scale sets of IPv4/IPv6 dual-stack clusters,
+// the IPv4 ip configuration stays primary as Azure requires
+func getVMSSIPv6IPConfig(subnetID string, lbBackendAddressPools []compute.SubResource) compute.VirtualMachineScaleSetIPConfiguration {
+	ipConfig := compute.VirtualMachineScaleSetIPConfiguration{
+		Name: to.StringPtr("ipconfigv6"),
+		VirtualMachineScaleSetIPConfigurationProperties: &compute.VirtualMachineScaleSetIPConfigurationProperties{
+			Primary:                 to.BoolPtr(false),
+			PrivateIPAddressVersion: compute.IPv6,
+			Subnet: &compute.APIEntityReference{
+				ID: to.StringPtr(subnetID),
+			},
+		},
+	}
+	if len(lbBackendAddressPools) > 0 {
+		ipConfig.LoadBalancerBackendAddressPools = &lbBackendAddressPools
 	}
+	return ipConfig
 }
 
 func getVMSSDataDisks(profile *api.AgentPoolProfile) *[]compute.VirtualMachineScaleSetDataDisk {
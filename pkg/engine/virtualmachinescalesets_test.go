@@ -441,6 +441,87 @@ func TestCreateAgentVMSS(t *testing.T) {
 	if diff != "" {
 		t.Errorf("unexpected diff while expecting equal structs: %s", diff)
 	}
+
+	// Test AgentVMSS with egress through the agent load balancer
+	cs.Properties.OrchestratorProfile.KubernetesConfig.LoadBalancerSku = "Standard"
+	cs.Properties.OrchestratorProfile.KubernetesConfig.EgressProfile = &api.EgressProfile{
+		Type:            api.EgressTypeLoadBalancer,
+		OutboundIPCount: 1,
+	}
+
+	actual = CreateAgentVMSS(cs, cs.Properties.AgentPoolProfiles[0])
+
+	expected.DependsOn = append(expected.DependsOn, "[variables('agentLbID')]")
+	ipConfigurations := *(*expected.VirtualMachineProfile.NetworkProfile.NetworkInterfaceConfigurations)[0].IPConfigurations
+	ipConfigurations[0].LoadBalancerBackendAddressPools = &[]compute.SubResource{
+		{
+			ID: to.StringPtr("[concat(variables('agentLbID'), '/backendAddressPools/', variables('agentLbBackendPoolName'))]"),
+		},
+	}
+
+	diff = cmp.Diff(actual, expected)
+
+	if diff != "" {
+		t.Errorf("unexpected diff while expecting equal structs: %s", diff)
+	}
+
+	// Test AgentVMSS with a dedicated network security group in a custom VNET
+	cs.Properties.OrchestratorProfile.KubernetesConfig.EgressProfile = nil
+	profile := cs.Properties.AgentPoolProfiles[0]
+	profile.VnetSubnetID = "/subscriptions/SUB_ID/resourceGroups/RG_NAME/providers/Microsoft.Network/virtualNetworks/VNET_NAME/subnets/agentpool1"
+	profile.EnableDedicatedNetworkSecurityGroup = to.BoolPtr(true)
+
+	actual = CreateAgentVMSS(cs, profile)
+
+	if actual.DependsOn[0] != "[variables('agentpool1NSGID')]" {
+		t.Errorf("expected the scale set to depend on the agent pool network security group, got %v", actual.DependsOn)
+	}
+	nicConfig := (*actual.VirtualMachineProfile.NetworkProfile.NetworkInterfaceConfigurations)[0]
+	if diff = cmp.Diff(nicConfig.NetworkSecurityGroup, &compute.SubResource{ID: to.StringPtr("[variables('agentpool1NSGID')]")}); diff != "" {
+		t.Errorf("unexpected diff while comparing the network security group: %s", diff)
+	}
+
+	// Test AgentVMSS with an ephemeral OS disk
+	profile.OSDiskType = api.OSDiskTypeEphemeral
+
+	actual = CreateAgentVMSS(cs, profile)
+
+	expectedOSDisk := &compute.VirtualMachineScaleSetOSDisk{
+		CreateOption: compute.DiskCreateOptionTypesFromImage,
+		Caching:      compute.CachingTypesReadOnly,
+		DiffDiskSettings: &compute.DiffDiskSettings{
+			Option: compute.Local,
+		},
+	}
+	if diff = cmp.Diff(actual.VirtualMachineProfile.StorageProfile.OsDisk, expectedOSDisk); diff != "" {
+		t.Errorf("unexpected diff while comparing the ephemeral OS disk: %s", diff)
+	}
+
+	// Test AgentVMSS with a disk encryption set
+	profile.OSDiskType = ""
+	profile.DiskEncryptionSetID = "/subscriptions/SUB_ID/resourceGroups/RG_NAME/providers/Microsoft.Compute/diskEncryptionSets/des"
+
+	actual = CreateAgentVMSS(cs, profile)
+
+	if actual.APIVersion != "2019-07-01" || actual.DiskEncryptionSetID != profile.DiskEncryptionSetID {
+		t.Errorf("expected the scale set to use the disk encryption set with API version 2019-07-01, got %s and %s", actual.DiskEncryptionSetID, actual.APIVersion)
+	}
+
+	// Test AgentVMSS in a proximity placement group on a dedicated host group
+	profile.EnableProximityPlacementGroup = to.BoolPtr(true)
+	profile.HostGroupID = "/subscriptions/SUB_ID/resourceGroups/RG_NAME/providers/Microsoft.Compute/hostGroups/hg"
+
+	actual = CreateAgentVMSS(cs, profile)
+
+	if actual.APIVersion != "2020-06-01" || actual.HostGroupID != profile.HostGroupID {
+		t.Errorf("expected the scale set to use the host group with API version 2020-06-01, got %s and %s", actual.HostGroupID, actual.APIVersion)
+	}
+	if actual.ProximityPlacementGroupID != "[variables('proximityPlacementGroupID')]" {
+		t.Errorf("expected the scale set in the created proximity placement group, got %s", actual.ProximityPlacementGroupID)
+	}
+	if actual.DependsOn[len(actual.DependsOn)-1] != proximityPlacementGroupDependency {
+		t.Errorf("expected the scale set to depend on the created proximity placement group, got %v", actual.DependsOn)
+	}
 }
 
 func TestCreateAgentVMSSHostedMasterProfile(t *testing.T) {
@@ -686,3 +767,35 @@ func getIPConfigs() *[]compute.VirtualMachineScaleSetIPConfiguration {
 	}
 	return &ipConfigs
 }
+
+func TestGetVMSSIPv6IPConfig(t *testing.T) {
+	expected := compute.VirtualMachineScaleSetIPConfiguration{
+		Name: to.StringPtr("ipconfigv6"),
+		VirtualMachineScaleSetIPConfigurationProperties: &compute.VirtualMachineScaleSetIPConfigurationProperties{
+			Primary:                 to.BoolPtr(false),
+			PrivateIPAddressVersion: compute.IPv6,
+			Subnet: &compute.APIEntityReference{
+				ID: to.StringPtr("[variables('agentpoolVnetSubnetID')]"),
+			},
+		},
+	}
+
+	actual := getVMSSIPv6IPConfig("[variables('agentpoolVnetSubnetID')]", nil)
+
+	if diff := cmp.Diff(actual, expected); diff != "" {
+		t.Errorf("unexpected diff while comparing IPv6 ip configurations: %s", diff)
+	}
+
+	pools := []compute.SubResource{
+		{
+			ID: to.StringPtr("[concat(variables('masterLbID'), '/backendAddressPools/', variables('masterLbIPv6BackendPoolName'))]"),
+		},
+	}
+	expected.LoadBalancerBackendAddressPools = &pools
+
+	actual = getVMSSIPv6IPConfig("[variables('agentpoolVnetSubnetID')]", pools)
+
+	if diff := cmp.Diff(actual, expected); diff != "" {
+		t.Errorf("unexpected diff while comparing IPv6 ip configurations: %s", diff)
+	}
+}
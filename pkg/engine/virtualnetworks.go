@@ -15,7 +15,7 @@ func CreateVirtualNetwork(cs *api.ContainerService) VirtualNetworkARM {
 		"[concat('Microsoft.Network/networkSecurityGroups/', variables('nsgName'))]",
 	}
 
-	requireRouteTable := cs.Properties.OrchestratorProfile.RequireRouteTable()
+	requireRouteTable := cs.Properties.OrchestratorProfile.HasRouteTable()
 	if requireRouteTable {
 		dependencies = append(dependencies, "[concat('Microsoft.Network/routeTables/', variables('routeTableName'))]")
 	}
@@ -41,15 +41,22 @@ func CreateVirtualNetwork(cs *api.ContainerService) VirtualNetworkARM {
 		}
 	}
 
+	addressPrefixes := []string{
+		"[parameters('vnetCidr')]",
+	}
+
+	if cs.Properties.FeatureFlags.IsFeatureEnabled("EnableIPv6DualStack") {
+		addressPrefixes = append(addressPrefixes, api.DefaultVNETCIDRIPv6)
+		setDualStackSubnetAddressPrefixes(&subnet, api.DefaultKubernetesMasterSubnetIPv6)
+	}
+
 	virtualNetwork := network.VirtualNetwork{
 		Location: to.StringPtr("[variables('location')]"),
 		Name:     to.StringPtr("[variables('virtualNetworkName')]"),
 		Type:     to.StringPtr("Microsoft.Network/virtualNetworks"),
 		VirtualNetworkPropertiesFormat: &network.VirtualNetworkPropertiesFormat{
 			AddressSpace: &network.AddressSpace{
-				AddressPrefixes: &[]string{
-					"[parameters('vnetCidr')]",
-				},
+				AddressPrefixes: &addressPrefixes,
 			},
 			Subnets: &[]network.Subnet{
 				subnet,
@@ -69,7 +76,7 @@ func createVirtualNetworkVMSS(cs *api.ContainerService) VirtualNetworkARM {
 		"[concat('Microsoft.Network/networkSecurityGroups/', variables('nsgName'))]",
 	}
 
-	requireRouteTable := cs.Properties.OrchestratorProfile.RequireRouteTable()
+	requireRouteTable := cs.Properties.OrchestratorProfile.HasRouteTable()
 	if requireRouteTable {
 		dependencies = append(dependencies, "[concat('Microsoft.Network/routeTables/', variables('routeTableName'))]")
 	}
@@ -111,15 +118,23 @@ func createVirtualNetworkVMSS(cs *api.ContainerService) VirtualNetworkARM {
 		}
 	}
 
+	addressPrefixes := []string{
+		"[parameters('vnetCidr')]",
+	}
+
+	if cs.Properties.FeatureFlags.IsFeatureEnabled("EnableIPv6DualStack") {
+		addressPrefixes = append(addressPrefixes, api.DefaultVNETCIDRIPv6)
+		setDualStackSubnetAddressPrefixes(&subnetMaster, api.DefaultKubernetesMasterSubnetIPv6)
+		setDualStackSubnetAddressPrefixes(&subnetAgent, api.DefaultKubernetesAgentSubnetVMSSIPv6)
+	}
+
 	virtualNetwork := network.VirtualNetwork{
 		Location: to.StringPtr("[variables('location')]"),
 		Name:     to.StringPtr("[variables('virtualNetworkName')]"),
 		Type:     to.StringPtr("Microsoft.Network/virtualNetworks"),
 		VirtualNetworkPropertiesFormat: &network.VirtualNetworkPropertiesFormat{
 			AddressSpace: &network.AddressSpace{
-				AddressPrefixes: &[]string{
-					"[parameters('vnetCidr')]",
-				},
+				AddressPrefixes: &addressPrefixes,
 			},
 			Subnets: &[]network.Subnet{
 				subnetMaster,
@@ -142,8 +157,8 @@ func createHostedMasterVirtualNetwork(cs *api.ContainerService) VirtualNetworkAR
 		"[concat('Microsoft.Network/networkSecurityGroups/', variables('nsgName'))]",
 	}
 
-	isAzureCNI := cs.Properties.OrchestratorProfile.IsAzureCNI()
-	if !isAzureCNI {
+	hasRouteTable := !cs.Properties.OrchestratorProfile.IsAzureCNI() || cs.Properties.OrchestratorProfile.KubernetesConfig.IsUserDefinedRoutingEgress()
+	if hasRouteTable {
 		dependencies = append(dependencies, "[concat('Microsoft.Network/routeTables/', variables('routeTableName'))]")
 	}
 
@@ -159,7 +174,7 @@ func createHostedMasterVirtualNetwork(cs *api.ContainerService) VirtualNetworkAR
 		},
 	}
 
-	if !isAzureCNI {
+	if hasRouteTable {
 		subnet.RouteTable = &network.RouteTable{
 			ID: to.StringPtr("[variables('routeTableID')]"),
 		}
@@ -188,3 +203,13 @@ func createHostedMasterVirtualNetwork(cs *api.ContainerService) VirtualNetworkAR
 		VirtualNetwork: virtualNetwork,
 	}
 }
+
+// setDualStackSubnetAddressPrefixes moves the IPv4 prefix of a subnet to AddressPrefixes next to an IPv6 prefix,
+// ARM does not accept both AddressPrefix and AddressPrefixes on the same subnet
+func setDualStackSubnetAddressPrefixes(subnet *network.Subnet, ipv6Prefix string) {
+	subnet.AddressPrefixes = &[]string{
+		to.String(subnet.AddressPrefix),
+		ipv6Prefix,
+	}
+	subnet.AddressPrefix = nil
+}
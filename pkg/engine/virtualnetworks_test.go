@@ -220,6 +220,55 @@ func TestCreateVirtualNetworkVMSS(t *testing.T) {
 	}
 }
 
+func TestCreateVirtualNetworkIPv6DualStack(t *testing.T) {
+	cs := &api.ContainerService{
+		Properties: &api.Properties{
+			OrchestratorProfile: &api.OrchestratorProfile{
+				OrchestratorType: "Kubernetes",
+				KubernetesConfig: &api.KubernetesConfig{
+					NetworkPlugin: "kubenet",
+				},
+			},
+			FeatureFlags: &api.FeatureFlags{
+				EnableIPv6DualStack: true,
+			},
+		},
+	}
+
+	expectedAddressPrefixes := &[]string{
+		"[parameters('vnetCidr')]",
+		api.DefaultVNETCIDRIPv6,
+	}
+
+	vnet := CreateVirtualNetwork(cs)
+	if diff := cmp.Diff(vnet.AddressSpace.AddressPrefixes, expectedAddressPrefixes); diff != "" {
+		t.Errorf("unexpected diff while comparing vnet address prefixes: %s", diff)
+	}
+	subnet := (*vnet.Subnets)[0]
+	if subnet.AddressPrefix != nil {
+		t.Errorf("expected dual-stack subnet to only set AddressPrefixes, got AddressPrefix %s", *subnet.AddressPrefix)
+	}
+	expectedSubnetPrefixes := &[]string{
+		"[parameters('masterSubnet')]",
+		api.DefaultKubernetesMasterSubnetIPv6,
+	}
+	if diff := cmp.Diff(subnet.AddressPrefixes, expectedSubnetPrefixes); diff != "" {
+		t.Errorf("unexpected diff while comparing subnet address prefixes: %s", diff)
+	}
+
+	vnet = createVirtualNetworkVMSS(cs)
+	if diff := cmp.Diff(vnet.AddressSpace.AddressPrefixes, expectedAddressPrefixes); diff != "" {
+		t.Errorf("unexpected diff while comparing vmss vnet address prefixes: %s", diff)
+	}
+	expectedSubnetPrefixes = &[]string{
+		"[parameters('agentSubnet')]",
+		api.DefaultKubernetesAgentSubnetVMSSIPv6,
+	}
+	if diff := cmp.Diff((*vnet.Subnets)[1].AddressPrefixes, expectedSubnetPrefixes); diff != "" {
+		t.Errorf("unexpected diff while comparing vmss agent subnet address prefixes: %s", diff)
+	}
+}
+
 func TestCreateHostedMasterVirtualNetwork(t *testing.T) {
 	// Test Hosted Master VNet without RouteTable
 	cs := &api.ContainerService{
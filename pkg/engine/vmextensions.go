@@ -55,7 +55,7 @@ func CreateCustomScriptExtension(cs *api.ContainerService) VirtualMachineExtensi
 	if cs.Properties.MasterProfile != nil && cs.Properties.MasterProfile.IsCoreOS() {
 		ncBinary = "ncat"
 	}
-	if !cs.Properties.FeatureFlags.IsFeatureEnabled("BlockOutboundInternet") {
+	if !cs.Properties.FeatureFlags.IsFeatureEnabled("BlockOutboundInternet") && !cs.Properties.IsHTTPProxyEnabled() {
 		if cs.GetCloudSpecConfig().CloudName == api.AzureChinaCloud {
 			registry = `gcr.azk8s.cn 80`
 		} else {
@@ -101,7 +101,7 @@ func createAgentVMASCustomScriptExtension(cs *api.ContainerService, profile *api
 	if profile.IsCoreOS() {
 		ncBinary = "ncat"
 	}
-	if !cs.Properties.FeatureFlags.IsFeatureEnabled("BlockOutboundInternet") {
+	if !cs.Properties.FeatureFlags.IsFeatureEnabled("BlockOutboundInternet") && !cs.Properties.IsHTTPProxyEnabled() {
 		if cs.GetCloudSpecConfig().CloudName == api.AzureChinaCloud {
 			registry = `gcr.azk8s.cn 80`
 		} else {
@@ -140,7 +140,7 @@ func createAgentVMASCustomScriptExtension(cs *api.ContainerService, profile *api
 		vmExtension.Publisher = to.StringPtr("Microsoft.Azure.Extensions")
 		vmExtension.VirtualMachineExtensionProperties.Type = to.StringPtr("CustomScript")
 		vmExtension.TypeHandlerVersion = to.StringPtr("2.0")
-		commandExec := fmt.Sprintf("[concat('retrycmd_if_failure() { r=$1; w=$2; t=$3; shift && shift && shift; for i in $(seq 1 $r); do timeout $t ${@}; [ $? -eq 0  ] && break || if [ $i -eq $r ]; then return 1; else sleep $w; fi; done }; %s for i in $(seq 1 1200); do if [ -f /opt/azure/containers/provision.sh ]; then break; fi; if [ $i -eq 1200 ]; then exit 100; else sleep 1; fi; done; ', variables('provisionScriptParametersCommon'),' GPU_NODE=%s SGX_NODE=%s /usr/bin/nohup /bin/bash -c \"/bin/bash /opt/azure/containers/provision.sh >> /var/log/azure/cluster-provision.log 2>&1%s\"')]", outBoundCmd, nVidiaEnabled, sgxEnabled, runInBackground)
+		commandExec := fmt.Sprintf("[concat('retrycmd_if_failure() { r=$1; w=$2; t=$3; shift && shift && shift; for i in $(seq 1 $r); do timeout $t ${@}; [ $? -eq 0  ] && break || if [ $i -eq $r ]; then return 1; else sleep $w; fi; done }; %s for i in $(seq 1 1200); do if [ -f /opt/azure/containers/provision.sh ]; then break; fi; if [ $i -eq 1200 ]; then exit 100; else sleep 1; fi; done; ', variables('provisionScriptParametersCommon'),%s' GPU_NODE=%s SGX_NODE=%s /usr/bin/nohup /bin/bash -c \"/bin/bash /opt/azure/containers/provision.sh >> /var/log/azure/cluster-provision.log 2>&1%s\"')]", outBoundCmd, getAgentPoolProvisionScriptParameters(cs, profile), nVidiaEnabled, sgxEnabled, runInBackground)
 		vmExtension.ProtectedSettings = &map[string]interface{}{
 			"commandToExecute": commandExec,
 		}
@@ -206,3 +206,33 @@ func CreateAgentVMASAKSBillingExtension(cs *api.ContainerService, profile *api.A
 		VirtualMachineExtension: vmExtension,
 	}
 }
+
+// getAgentPoolProvisionScriptParameters returns the provision script parameters that override
+// provisionScriptParametersCommon for an agent pool, so azure.json names the pool's own network resources
+// and the pool's nodes install the pool's own Kubernetes version
+func getAgentPoolProvisionScriptParameters(cs *api.ContainerService, profile *api.AgentPoolProfile) string {
+	var params string
+	if cs.Properties.AgentPoolHasOwnOrchestratorVersion(profile) {
+		var hyperkubeImageBase string
+		if cs.Properties.OrchestratorProfile.KubernetesConfig != nil {
+			hyperkubeImageBase = cs.Properties.OrchestratorProfile.KubernetesConfig.KubernetesImageBase
+		}
+		hyperkubeSpec := hyperkubeImageBase + cs.Properties.OrchestratorProfile.GetK8sComponentsForVersion(profile.OrchestratorVersion)["hyperkube"]
+		params += fmt.Sprintf("' KUBERNETES_VERSION=%s HYPERKUBE_URL=%s',", profile.OrchestratorVersion, hyperkubeSpec)
+	}
+	if profile.HasOwnNetworkSecurityGroup() {
+		params += fmt.Sprintf("' NETWORK_SECURITY_GROUP=',variables('%sNSGName'),", profile.Name)
+	}
+	if profile.HasOwnRouteTable() {
+		params += fmt.Sprintf("' ROUTE_TABLE=',variables('%sRouteTableName'),' ROUTE_TABLE_RESOURCE_GROUP=',variables('%sRouteTableResourceGroup'),", profile.Name, profile.Name)
+	}
+	return params
+}
+
+// getAgentPoolOrchestratorTag returns the orchestrator tag of the agent pool VMs, which records the pool's own Kubernetes version
+func getAgentPoolOrchestratorTag(cs *api.ContainerService, profile *api.AgentPoolProfile) string {
+	if cs.Properties.AgentPoolHasOwnOrchestratorVersion(profile) {
+		return fmt.Sprintf("%s:%s", cs.Properties.OrchestratorProfile.OrchestratorType, profile.OrchestratorVersion)
+	}
+	return "[variables('orchestratorNameVersionTag')]"
+}
@@ -273,6 +273,23 @@ func TestCreateCustomScriptExtension(t *testing.T) {
 	if diff != "" {
 		t.Errorf("unexpected diff while expecting equal structs: %s", diff)
 	}
+
+	// Test with an HTTP proxy, direct outbound connectivity is not checked
+	cs.Location = "westus2"
+	cs.Properties.HTTPProxyConfig = &api.HTTPProxyConfig{
+		HTTPProxy: "http://proxy.contoso.com:3128",
+	}
+	cse = CreateCustomScriptExtension(cs)
+
+	expectedCSE.ProtectedSettings = &map[string]interface{}{
+		"commandToExecute": `[concat('retrycmd_if_failure() { r=$1; w=$2; t=$3; shift && shift && shift; for i in $(seq 1 $r); do timeout $t ${@}; [ $? -eq 0  ] && break || if [ $i -eq $r ]; then return 1; else sleep $w; fi; done };  for i in $(seq 1 1200); do if [ -f /opt/azure/containers/provision.sh ]; then break; fi; if [ $i -eq 1200 ]; then exit 100; else sleep 1; fi; done; ', variables('provisionScriptParametersCommon'),' ',variables('provisionScriptParametersMaster'), ' /usr/bin/nohup /bin/bash -c "/bin/bash /opt/azure/containers/provision.sh >> /var/log/azure/cluster-provision.log 2>&1"')]`,
+	}
+
+	diff = cmp.Diff(cse, expectedCSE)
+
+	if diff != "" {
+		t.Errorf("unexpected diff while expecting equal structs: %s", diff)
+	}
 }
 
 func TestCreateAgentVMASCustomScriptExtension(t *testing.T) {
@@ -367,3 +384,84 @@ func TestCreateAgentVMASCustomScriptExtension(t *testing.T) {
 		t.Errorf("unexpected diff while expecting equal structs: %s", diff)
 	}
 }
+
+func TestGetAgentPoolProvisionScriptParameters(t *testing.T) {
+	cases := []struct {
+		name     string
+		profile  *api.AgentPoolProfile
+		expected string
+	}{
+		{
+			name:     "cluster network resources",
+			profile:  &api.AgentPoolProfile{Name: "sample"},
+			expected: "",
+		},
+		{
+			name: "dedicated network security group",
+			profile: &api.AgentPoolProfile{
+				Name:                                "sample",
+				EnableDedicatedNetworkSecurityGroup: to.BoolPtr(true),
+			},
+			expected: "' NETWORK_SECURITY_GROUP=',variables('sampleNSGName'),",
+		},
+		{
+			name: "existing network security group and route table",
+			profile: &api.AgentPoolProfile{
+				Name:                   "sample",
+				NetworkSecurityGroupID: "/subscriptions/SUB_ID/resourceGroups/RG_NAME/providers/Microsoft.Network/networkSecurityGroups/sample-nsg",
+				RouteTableID:           "/subscriptions/SUB_ID/resourceGroups/RG_NAME/providers/Microsoft.Network/routeTables/sample-routetable",
+			},
+			expected: "' NETWORK_SECURITY_GROUP=',variables('sampleNSGName'),' ROUTE_TABLE=',variables('sampleRouteTableName'),' ROUTE_TABLE_RESOURCE_GROUP=',variables('sampleRouteTableResourceGroup'),",
+		},
+		{
+			name:     "control plane version",
+			profile:  &api.AgentPoolProfile{Name: "sample", OrchestratorVersion: "1.14.1"},
+			expected: "",
+		},
+		{
+			name:     "agent pool lagging the control plane",
+			profile:  &api.AgentPoolProfile{Name: "sample", OrchestratorVersion: "1.13.5"},
+			expected: "' KUBERNETES_VERSION=1.13.5 HYPERKUBE_URL=k8s.gcr.io/hyperkube-amd64:v1.13.5',",
+		},
+	}
+
+	cs := &api.ContainerService{
+		Properties: &api.Properties{
+			OrchestratorProfile: &api.OrchestratorProfile{
+				OrchestratorType:    api.Kubernetes,
+				OrchestratorVersion: "1.14.1",
+				KubernetesConfig: &api.KubernetesConfig{
+					KubernetesImageBase: "k8s.gcr.io/",
+				},
+			},
+		},
+	}
+	for _, c := range cases {
+		if actual := getAgentPoolProvisionScriptParameters(cs, c.profile); actual != c.expected {
+			t.Errorf("%s: expected %s, got %s", c.name, c.expected, actual)
+		}
+	}
+
+	cs.Properties.OrchestratorProfile.KubernetesConfig.ComponentOverrides = map[string]string{"hyperkube": "hyperkube-amd64:v1.13.5-custom"}
+	expected := "' KUBERNETES_VERSION=1.13.5 HYPERKUBE_URL=k8s.gcr.io/hyperkube-amd64:v1.13.5-custom',"
+	if actual := getAgentPoolProvisionScriptParameters(cs, &api.AgentPoolProfile{Name: "sample", OrchestratorVersion: "1.13.5"}); actual != expected {
+		t.Errorf("hyperkube component override: expected %s, got %s", expected, actual)
+	}
+}
+
+func TestGetAgentPoolOrchestratorTag(t *testing.T) {
+	cs := &api.ContainerService{
+		Properties: &api.Properties{
+			OrchestratorProfile: &api.OrchestratorProfile{
+				OrchestratorType:    api.Kubernetes,
+				OrchestratorVersion: "1.14.1",
+			},
+		},
+	}
+	if actual := getAgentPoolOrchestratorTag(cs, &api.AgentPoolProfile{Name: "sample"}); actual != "[variables('orchestratorNameVersionTag')]" {
+		t.Errorf("expected the cluster orchestrator tag, got %s", actual)
+	}
+	if actual := getAgentPoolOrchestratorTag(cs, &api.AgentPoolProfile{Name: "sample", OrchestratorVersion: "1.13.5"}); actual != "Kubernetes:1.13.5" {
+		t.Errorf("expected orchestrator tag Kubernetes:1.13.5, got %s", actual)
+	}
+}
@@ -149,6 +149,54 @@ func AcceleratedNetworkingSupported(sku string) bool {
 	}
 }
 
+// vmSizeCacheSizesGB maps VM sizes to the size of their local cache in GiB, which bounds the size of an ephemeral OS disk
+var vmSizeCacheSizesGB = map[string]int{
+	"Standard_DS1_v2":  43,
+	"Standard_DS2_v2":  86,
+	"Standard_DS3_v2":  172,
+	"Standard_DS4_v2":  344,
+	"Standard_DS5_v2":  688,
+	"Standard_DS11_v2": 72,
+	"Standard_DS12_v2": 144,
+	"Standard_DS13_v2": 288,
+	"Standard_DS14_v2": 576,
+	"Standard_DS15_v2": 720,
+	"Standard_D2s_v3":  50,
+	"Standard_D4s_v3":  100,
+	"Standard_D8s_v3":  200,
+	"Standard_D16s_v3": 400,
+	"Standard_D32s_v3": 800,
+	"Standard_D48s_v3": 1200,
+	"Standard_D64s_v3": 1600,
+	"Standard_E2s_v3":  50,
+	"Standard_E4s_v3":  100,
+	"Standard_E8s_v3":  200,
+	"Standard_E16s_v3": 400,
+	"Standard_E20s_v3": 500,
+	"Standard_E32s_v3": 800,
+	"Standard_E48s_v3": 1200,
+	"Standard_E64s_v3": 1600,
+	"Standard_F1s":     12,
+	"Standard_F2s":     24,
+	"Standard_F4s":     48,
+	"Standard_F8s":     96,
+	"Standard_F16s":    192,
+	"Standard_F2s_v2":  32,
+	"Standard_F4s_v2":  64,
+	"Standard_F8s_v2":  128,
+	"Standard_F16s_v2": 256,
+	"Standard_F32s_v2": 512,
+	"Standard_F48s_v2": 768,
+	"Standard_F64s_v2": 1024,
+	"Standard_F72s_v2": 1152,
+}
+
+// GetVMSizeCacheSizeGB returns the cache size in GiB of the VmSKU, and false if the VmSKU is not known to support ephemeral OS disks
+func GetVMSizeCacheSizeGB(sku string) (int, bool) {
+	size, ok := vmSizeCacheSizesGB[sku]
+	return size, ok
+}
+
 // GetHomeDir attempts to get the home dir from env
 func GetHomeDir() string {
 	if runtime.GOOS == "windows" {
@@ -236,6 +236,47 @@ func TestAcceleratedNetworkingSupported(t *testing.T) {
 	}
 }
 
+func TestGetVMSizeCacheSizeGB(t *testing.T) {
+	cases := []struct {
+		input             string
+		expectedSize      int
+		expectedSupported bool
+	}{
+		{
+			input:             "Standard_DS2_v2",
+			expectedSize:      86,
+			expectedSupported: true,
+		},
+		{
+			input:             "Standard_D4s_v3",
+			expectedSize:      100,
+			expectedSupported: true,
+		},
+		{
+			input:             "Standard_F72s_v2",
+			expectedSize:      1152,
+			expectedSupported: true,
+		},
+		{
+			input:             "Standard_D2_v2",
+			expectedSize:      0,
+			expectedSupported: false,
+		},
+		{
+			input:             "",
+			expectedSize:      0,
+			expectedSupported: false,
+		},
+	}
+
+	for _, c := range cases {
+		size, supported := GetVMSizeCacheSizeGB(c.input)
+		if c.expectedSize != size || c.expectedSupported != supported {
+			t.Fatalf("GetVMSizeCacheSizeGB returned unexpected result for %s: expected (%d, %t) but got (%d, %t)", c.input, c.expectedSize, c.expectedSupported, size, supported)
+		}
+	}
+}
+
 func TestEqualError(t *testing.T) {
 	testcases := []struct {
 		errA     error
@@ -0,0 +1,5 @@
+// Copyright (c) Microsoft Corporation. All rights reserved.
+// Licensed under the MIT license.
+
+// Package lint reports best-practice warnings for cluster definitions that pass validation.
+package lint
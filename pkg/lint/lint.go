@@ -0,0 +1,134 @@
+// Copyright (c) Microsoft Corporation. All rights reserved.
+// Licensed under the MIT license.
+
+package lint
+
+import (
+	"sort"
+	"strings"
+
+	"github.com/Azure/aks-engine/pkg/api"
+	"github.com/pkg/errors"
+)
+
+// Severity describes how risky a lint finding is
+type Severity string
+
+const (
+	// SeverityInfo is used for findings that are worth knowing about but rarely need action
+	SeverityInfo Severity = "info"
+	// SeverityWarning is used for choices that are valid but not recommended
+	SeverityWarning Severity = "warning"
+	// SeverityError is used for choices that are very likely to produce a broken cluster
+	SeverityError Severity = "error"
+)
+
+// CheckFunc inspects a cluster definition and returns one message per problem found
+type CheckFunc func(cs *api.ContainerService) []string
+
+// Rule is a single best-practice check
+type Rule struct {
+	ID          string
+	Severity    Severity
+	Description string
+	Check       CheckFunc
+}
+
+// Finding is a single problem reported by a Rule
+type Finding struct {
+	RuleID   string   `json:"ruleId"`
+	Severity Severity `json:"severity"`
+	Message  string   `json:"message"`
+}
+
+// Linter runs a set of rules against a cluster definition
+type Linter struct {
+	rules map[string]Rule
+}
+
+// NewLinter returns a Linter with the default rules registered
+func NewLinter() *Linter {
+	l := &Linter{
+		rules: map[string]Rule{},
+	}
+	for _, r := range DefaultRules() {
+		// the default rule set is static, a duplicate ID is a programming error
+		if err := l.Register(r); err != nil {
+			panic(err)
+		}
+	}
+	return l
+}
+
+// Register adds a rule to the Linter, rule IDs must be unique
+func (l *Linter) Register(r Rule) error {
+	if r.ID == "" {
+		return errors.New("lint rule ID cannot be empty")
+	}
+	if r.Check == nil {
+		return errors.Errorf("lint rule %s has no check", r.ID)
+	}
+	id := strings.ToUpper(r.ID)
+	if _, ok := l.rules[id]; ok {
+		return errors.Errorf("lint rule %s is already registered", r.ID)
+	}
+	r.ID = id
+	l.rules[id] = r
+	return nil
+}
+
+// Rules returns the registered rules sorted by ID
+func (l *Linter) Rules() []Rule {
+	rules := make([]Rule, 0, len(l.rules))
+	for _, r := range l.rules {
+		rules = append(rules, r)
+	}
+	sort.Slice(rules, func(i, j int) bool {
+		return rules[i].ID < rules[j].ID
+	})
+	return rules
+}
+
+// Run executes every registered rule that is not suppressed by the cluster definition's
+// lintProfile, and returns the findings sorted by rule ID
+func (l *Linter) Run(cs *api.ContainerService) []Finding {
+	findings := []Finding{}
+	if cs == nil || cs.Properties == nil {
+		return findings
+	}
+	suppressed := suppressedRules(cs.Properties)
+	for _, r := range l.Rules() {
+		if suppressed[r.ID] {
+			continue
+		}
+		for _, msg := range r.Check(cs) {
+			findings = append(findings, Finding{
+				RuleID:   r.ID,
+				Severity: r.Severity,
+				Message:  msg,
+			})
+		}
+	}
+	return findings
+}
+
+// HasErrors returns true if any of the findings has SeverityError
+func HasErrors(findings []Finding) bool {
+	for _, f := range findings {
+		if f.Severity == SeverityError {
+			return true
+		}
+	}
+	return false
+}
+
+func suppressedRules(p *api.Properties) map[string]bool {
+	suppressed := map[string]bool{}
+	if p.LintProfile == nil {
+		return suppressed
+	}
+	for _, id := range p.LintProfile.SuppressedRules {
+		suppressed[strings.ToUpper(strings.TrimSpace(id))] = true
+	}
+	return suppressed
+}
@@ -0,0 +1,80 @@
+// Copyright (c) Microsoft Corporation. All rights reserved.
+// Licensed under the MIT license.
+
+package lint
+
+import (
+	"testing"
+
+	"github.com/Azure/aks-engine/pkg/api"
+)
+
+func TestLinterRegister(t *testing.T) {
+	l := NewLinter()
+	if len(l.Rules()) != len(DefaultRules()) {
+		t.Fatalf("expected %d default rules, got %d", len(DefaultRules()), len(l.Rules()))
+	}
+
+	noop := func(cs *api.ContainerService) []string { return nil }
+	if err := l.Register(Rule{ID: "", Check: noop}); err == nil {
+		t.Fatalf("expected an error registering a rule without an ID")
+	}
+	if err := l.Register(Rule{ID: "CUSTOM001"}); err == nil {
+		t.Fatalf("expected an error registering a rule without a check")
+	}
+	if err := l.Register(Rule{ID: RuleSingleMaster, Check: noop}); err == nil {
+		t.Fatalf("expected an error registering a duplicate rule ID")
+	}
+	if err := l.Register(Rule{ID: "custom001", Severity: SeverityError, Check: noop}); err != nil {
+		t.Fatalf("unexpected error registering a custom rule: %s", err)
+	}
+	if err := l.Register(Rule{ID: "CUSTOM001", Check: noop}); err == nil {
+		t.Fatalf("expected rule IDs to be case-insensitive")
+	}
+
+	rules := l.Rules()
+	for i := 1; i < len(rules); i++ {
+		if rules[i-1].ID > rules[i].ID {
+			t.Fatalf("expected rules to be sorted by ID, got %s before %s", rules[i-1].ID, rules[i].ID)
+		}
+	}
+}
+
+func TestLinterRun(t *testing.T) {
+	cs := api.CreateMockContainerService("testcluster", "1.13.5", 1, 3, false)
+
+	findings := NewLinter().Run(cs)
+	if !hasFinding(findings, RuleSingleMaster) {
+		t.Fatalf("expected a %s finding for a single master cluster, got %v", RuleSingleMaster, findings)
+	}
+
+	cs.Properties.LintProfile = &api.LintProfile{
+		SuppressedRules: []string{"akse001 "},
+	}
+	findings = NewLinter().Run(cs)
+	if hasFinding(findings, RuleSingleMaster) {
+		t.Fatalf("expected %s to be suppressed by the lintProfile, got %v", RuleSingleMaster, findings)
+	}
+
+	if findings := NewLinter().Run(nil); len(findings) != 0 {
+		t.Fatalf("expected no findings for a nil container service, got %v", findings)
+	}
+}
+
+func TestHasErrors(t *testing.T) {
+	if HasErrors([]Finding{{RuleID: "A", Severity: SeverityWarning}, {RuleID: "B", Severity: SeverityInfo}}) {
+		t.Fatalf("expected HasErrors to be false without error findings")
+	}
+	if !HasErrors([]Finding{{RuleID: "A", Severity: SeverityWarning}, {RuleID: "B", Severity: SeverityError}}) {
+		t.Fatalf("expected HasErrors to be true with an error finding")
+	}
+}
+
+func hasFinding(findings []Finding, ruleID string) bool {
+	for _, f := range findings {
+		if f.RuleID == ruleID {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,221 @@
+// Copyright (c) Microsoft Corporation. All rights reserved.
+// Licensed under the MIT license.
+
+package lint
+
+import (
+	"fmt"
+	"net"
+	"strconv"
+
+	"github.com/Azure/aks-engine/pkg/api"
+	"github.com/Azure/aks-engine/pkg/api/common"
+)
+
+// Rule IDs for the default rule set
+const (
+	RuleSingleMaster        = "AKSE001"
+	RuleEtcdDiskTooSmall    = "AKSE002"
+	RuleRBACDisabled        = "AKSE003"
+	RulePublicKubelet       = "AKSE004"
+	RuleDeprecatedAddon     = "AKSE005"
+	RuleMixedZones          = "AKSE006"
+	RuleOverlappingNetworks = "AKSE007"
+)
+
+// deprecatedAddons lists addons that are still supported but should not be used for new clusters
+var deprecatedAddons = map[string]bool{
+	api.DefaultHeapsterAddonName:    api.DefaultHeapsterAddonEnabled,
+	api.DefaultTillerAddonName:      api.DefaultTillerAddonEnabled,
+	api.DefaultReschedulerAddonName: api.DefaultReschedulerAddonEnabled,
+}
+
+// DefaultRules returns the rules run by `aks-engine lint`
+func DefaultRules() []Rule {
+	return []Rule{
+		{
+			ID:          RuleSingleMaster,
+			Severity:    SeverityWarning,
+			Description: "a single master is not highly available",
+			Check:       checkSingleMaster,
+		},
+		{
+			ID:          RuleEtcdDiskTooSmall,
+			Severity:    SeverityWarning,
+			Description: "the etcd disk is smaller than recommended for the number of nodes",
+			Check:       checkEtcdDiskSize,
+		},
+		{
+			ID:          RuleRBACDisabled,
+			Severity:    SeverityWarning,
+			Description: "RBAC is disabled",
+			Check:       checkRBACDisabled,
+		},
+		{
+			ID:          RulePublicKubelet,
+			Severity:    SeverityWarning,
+			Description: "the kubelet API is reachable without authentication",
+			Check:       checkPublicKubelet,
+		},
+		{
+			ID:          RuleDeprecatedAddon,
+			Severity:    SeverityInfo,
+			Description: "a deprecated addon is enabled",
+			Check:       checkDeprecatedAddons,
+		},
+		{
+			ID:          RuleMixedZones,
+			Severity:    SeverityWarning,
+			Description: "some agent pools use availability zones and others do not",
+			Check:       checkMixedZones,
+		},
+		{
+			ID:          RuleOverlappingNetworks,
+			Severity:    SeverityWarning,
+			Description: "serviceCidr, clusterSubnet and vnetCidr overlap",
+			Check:       checkOverlappingNetworks,
+		},
+	}
+}
+
+func kubernetesConfig(cs *api.ContainerService) *api.KubernetesConfig {
+	o := cs.Properties.OrchestratorProfile
+	if o == nil || !o.IsKubernetes() {
+		return nil
+	}
+	return o.KubernetesConfig
+}
+
+func checkSingleMaster(cs *api.ContainerService) []string {
+	m := cs.Properties.MasterProfile
+	if m != nil && m.Count == 1 {
+		return []string{"masterProfile.count is 1, the control plane will be unavailable while the master is down or being upgraded; use 3 or 5 masters for production clusters"}
+	}
+	return nil
+}
+
+// recommendedEtcdDiskSizeGB mirrors the etcd disk defaults applied by SetPropertiesDefaults
+func recommendedEtcdDiskSizeGB(totalNodes int) string {
+	switch {
+	case totalNodes > 20:
+		return api.DefaultEtcdDiskSizeGT20Nodes
+	case totalNodes > 10:
+		return api.DefaultEtcdDiskSizeGT10Nodes
+	case totalNodes > 3:
+		return api.DefaultEtcdDiskSizeGT3Nodes
+	default:
+		return api.DefaultEtcdDiskSize
+	}
+}
+
+func checkEtcdDiskSize(cs *api.ContainerService) []string {
+	k := kubernetesConfig(cs)
+	if k == nil || k.EtcdDiskSizeGB == "" {
+		return nil
+	}
+	size, err := strconv.Atoi(k.EtcdDiskSizeGB)
+	if err != nil {
+		return []string{fmt.Sprintf("etcdDiskSizeGB %q is not a number", k.EtcdDiskSizeGB)}
+	}
+	nodes := cs.Properties.TotalNodes()
+	recommended := recommendedEtcdDiskSizeGB(nodes)
+	// the recommended values are constants, they always parse
+	min, _ := strconv.Atoi(recommended)
+	if size < min {
+		return []string{fmt.Sprintf("etcdDiskSizeGB is %d but %sGB is recommended for a cluster with %d nodes", size, recommended, nodes)}
+	}
+	return nil
+}
+
+func checkRBACDisabled(cs *api.ContainerService) []string {
+	k := kubernetesConfig(cs)
+	if k != nil && k.EnableRbac != nil && !*k.EnableRbac {
+		return []string{"enableRbac is false, every authenticated user and service account will have full access to the cluster"}
+	}
+	return nil
+}
+
+func checkPublicKubelet(cs *api.ContainerService) []string {
+	k := kubernetesConfig(cs)
+	if k == nil {
+		return nil
+	}
+	var msgs []string
+	if k.EnableSecureKubelet != nil && !*k.EnableSecureKubelet {
+		msgs = append(msgs, "enableSecureKubelet is false, the kubelet API will accept unauthenticated requests")
+	}
+	if v, ok := k.KubeletConfig["--anonymous-auth"]; ok && v == "true" {
+		msgs = append(msgs, "kubeletConfig sets --anonymous-auth=true, the kubelet API will accept unauthenticated requests")
+	}
+	if v, ok := k.KubeletConfig["--read-only-port"]; ok && v != "" && v != "0" {
+		msgs = append(msgs, fmt.Sprintf("kubeletConfig sets --read-only-port=%s, pod and node information will be served without authentication", v))
+	}
+	return msgs
+}
+
+func checkDeprecatedAddons(cs *api.ContainerService) []string {
+	k := kubernetesConfig(cs)
+	if k == nil {
+		return nil
+	}
+	var msgs []string
+	for _, name := range []string{api.DefaultHeapsterAddonName, api.DefaultTillerAddonName, api.DefaultReschedulerAddonName} {
+		addon := k.GetAddonByName(name)
+		if addon.IsEnabled(deprecatedAddons[name]) {
+			msgs = append(msgs, fmt.Sprintf("the %s addon is enabled and is deprecated, consider disabling it", name))
+		}
+	}
+	return msgs
+}
+
+func checkMixedZones(cs *api.ContainerService) []string {
+	var zoned, unzoned []string
+	for _, pool := range cs.Properties.AgentPoolProfiles {
+		if pool.HasAvailabilityZones() {
+			zoned = append(zoned, pool.Name)
+		} else {
+			unzoned = append(unzoned, pool.Name)
+		}
+	}
+	if len(zoned) > 0 && len(unzoned) > 0 {
+		return []string{fmt.Sprintf("agent pools %v use availability zones but %v do not", zoned, unzoned)}
+	}
+	return nil
+}
+
+func checkOverlappingNetworks(cs *api.ContainerService) []string {
+	k := kubernetesConfig(cs)
+	if k == nil {
+		return nil
+	}
+	type namedCIDR struct {
+		name  string
+		value string
+	}
+	// the IPv4 CIDRs of IPv4/IPv6 dual-stack clusters are checked against the IPv4 vnetCidr
+	cidrs := []namedCIDR{
+		{"serviceCidr", common.GetIPv4CIDR(k.ServiceCIDR)},
+	}
+	// with Azure CNI pods are allocated addresses from the VNET, so clusterSubnet is expected to be part of it
+	if !cs.Properties.OrchestratorProfile.IsAzureCNI() {
+		cidrs = append(cidrs, namedCIDR{"clusterSubnet", common.GetIPv4CIDR(k.ClusterSubnet)})
+	}
+	if cs.Properties.MasterProfile != nil {
+		cidrs = append(cidrs, namedCIDR{"vnetCidr", cs.Properties.MasterProfile.VnetCidr})
+	}
+
+	var msgs []string
+	for i := 0; i < len(cidrs); i++ {
+		for j := i + 1; j < len(cidrs); j++ {
+			_, a, errA := net.ParseCIDR(cidrs[i].value)
+			_, b, errB := net.ParseCIDR(cidrs[j].value)
+			if errA != nil || errB != nil {
+				continue
+			}
+			if a.Contains(b.IP) || b.Contains(a.IP) {
+				msgs = append(msgs, fmt.Sprintf("%s %s overlaps with %s %s", cidrs[i].name, cidrs[i].value, cidrs[j].name, cidrs[j].value))
+			}
+		}
+	}
+	return msgs
+}
@@ -0,0 +1,214 @@
+// Copyright (c) Microsoft Corporation. All rights reserved.
+// Licensed under the MIT license.
+
+package lint
+
+import (
+	"testing"
+
+	"github.com/Azure/aks-engine/pkg/api"
+	"github.com/Azure/go-autorest/autorest/to"
+)
+
+func TestDefaultRules(t *testing.T) {
+	cases := []struct {
+		name     string
+		ruleID   string
+		mutate   func(cs *api.ContainerService)
+		expected int
+	}{
+		{
+			name:     "three masters",
+			ruleID:   RuleSingleMaster,
+			mutate:   func(cs *api.ContainerService) { cs.Properties.MasterProfile.Count = 3 },
+			expected: 0,
+		},
+		{
+			name:     "single master",
+			ruleID:   RuleSingleMaster,
+			mutate:   func(cs *api.ContainerService) {},
+			expected: 1,
+		},
+		{
+			name:   "etcd disk too small for 25 nodes",
+			ruleID: RuleEtcdDiskTooSmall,
+			mutate: func(cs *api.ContainerService) {
+				cs.Properties.AgentPoolProfiles[0].Count = 24
+				cs.Properties.OrchestratorProfile.KubernetesConfig.EtcdDiskSizeGB = "512"
+			},
+			expected: 1,
+		},
+		{
+			name:   "etcd disk large enough",
+			ruleID: RuleEtcdDiskTooSmall,
+			mutate: func(cs *api.ContainerService) {
+				cs.Properties.AgentPoolProfiles[0].Count = 24
+				cs.Properties.OrchestratorProfile.KubernetesConfig.EtcdDiskSizeGB = "2048"
+			},
+			expected: 0,
+		},
+		{
+			name:   "etcd disk size unset",
+			ruleID: RuleEtcdDiskTooSmall,
+			mutate: func(cs *api.ContainerService) {
+				cs.Properties.AgentPoolProfiles[0].Count = 24
+				cs.Properties.OrchestratorProfile.KubernetesConfig.EtcdDiskSizeGB = ""
+			},
+			expected: 0,
+		},
+		{
+			name:   "rbac disabled",
+			ruleID: RuleRBACDisabled,
+			mutate: func(cs *api.ContainerService) {
+				cs.Properties.OrchestratorProfile.KubernetesConfig.EnableRbac = to.BoolPtr(false)
+			},
+			expected: 1,
+		},
+		{
+			name:   "rbac unset",
+			ruleID: RuleRBACDisabled,
+			mutate: func(cs *api.ContainerService) {
+				cs.Properties.OrchestratorProfile.KubernetesConfig.EnableRbac = nil
+			},
+			expected: 0,
+		},
+		{
+			name:   "public kubelet",
+			ruleID: RulePublicKubelet,
+			mutate: func(cs *api.ContainerService) {
+				k := cs.Properties.OrchestratorProfile.KubernetesConfig
+				k.EnableSecureKubelet = to.BoolPtr(false)
+				k.KubeletConfig["--anonymous-auth"] = "true"
+				k.KubeletConfig["--read-only-port"] = "10255"
+			},
+			expected: 3,
+		},
+		{
+			name:   "secure kubelet",
+			ruleID: RulePublicKubelet,
+			mutate: func(cs *api.ContainerService) {
+				cs.Properties.OrchestratorProfile.KubernetesConfig.KubeletConfig["--read-only-port"] = "0"
+			},
+			expected: 0,
+		},
+		{
+			name:   "default addons",
+			ruleID: RuleDeprecatedAddon,
+			mutate: func(cs *api.ContainerService) {
+				cs.Properties.OrchestratorProfile.KubernetesConfig.Addons = nil
+			},
+			expected: 2,
+		},
+		{
+			name:   "deprecated addons disabled",
+			ruleID: RuleDeprecatedAddon,
+			mutate: func(cs *api.ContainerService) {
+				cs.Properties.OrchestratorProfile.KubernetesConfig.Addons = []api.KubernetesAddon{
+					{Name: api.DefaultHeapsterAddonName, Enabled: to.BoolPtr(false)},
+					{Name: api.DefaultTillerAddonName, Enabled: to.BoolPtr(false)},
+				}
+			},
+			expected: 0,
+		},
+		{
+			name:   "rescheduler enabled",
+			ruleID: RuleDeprecatedAddon,
+			mutate: func(cs *api.ContainerService) {
+				cs.Properties.OrchestratorProfile.KubernetesConfig.Addons = []api.KubernetesAddon{
+					{Name: api.DefaultHeapsterAddonName, Enabled: to.BoolPtr(false)},
+					{Name: api.DefaultTillerAddonName, Enabled: to.BoolPtr(false)},
+					{Name: api.DefaultReschedulerAddonName, Enabled: to.BoolPtr(true)},
+				}
+			},
+			expected: 1,
+		},
+		{
+			name:   "mixed zones",
+			ruleID: RuleMixedZones,
+			mutate: func(cs *api.ContainerService) {
+				cs.Properties.AgentPoolProfiles[0].AvailabilityZones = []string{"1", "2"}
+				cs.Properties.AgentPoolProfiles = append(cs.Properties.AgentPoolProfiles, &api.AgentPoolProfile{Name: "nozones", Count: 1})
+			},
+			expected: 1,
+		},
+		{
+			name:   "all zones",
+			ruleID: RuleMixedZones,
+			mutate: func(cs *api.ContainerService) {
+				cs.Properties.AgentPoolProfiles[0].AvailabilityZones = []string{"1", "2"}
+				cs.Properties.AgentPoolProfiles = append(cs.Properties.AgentPoolProfiles, &api.AgentPoolProfile{Name: "zones", Count: 1, AvailabilityZones: []string{"3"}})
+			},
+			expected: 0,
+		},
+		{
+			name:   "service cidr overlaps cluster subnet and vnet",
+			ruleID: RuleOverlappingNetworks,
+			mutate: func(cs *api.ContainerService) {
+				k := cs.Properties.OrchestratorProfile.KubernetesConfig
+				k.ServiceCIDR = "10.244.0.0/24"
+				k.ClusterSubnet = "10.244.0.0/16"
+				cs.Properties.MasterProfile.VnetCidr = "10.244.0.0/8"
+			},
+			expected: 3,
+		},
+		{
+			name:   "cluster subnet inside the vnet with azure cni",
+			ruleID: RuleOverlappingNetworks,
+			mutate: func(cs *api.ContainerService) {
+				k := cs.Properties.OrchestratorProfile.KubernetesConfig
+				k.NetworkPlugin = api.NetworkPluginAzure
+				k.ServiceCIDR = "172.16.0.0/16"
+				k.ClusterSubnet = "10.240.0.0/16"
+				cs.Properties.MasterProfile.VnetCidr = "10.0.0.0/8"
+			},
+			expected: 0,
+		},
+		{
+			name:   "distinct networks",
+			ruleID: RuleOverlappingNetworks,
+			mutate: func(cs *api.ContainerService) {
+				k := cs.Properties.OrchestratorProfile.KubernetesConfig
+				k.ServiceCIDR = "172.16.0.0/16"
+				k.ClusterSubnet = "10.244.0.0/16"
+				cs.Properties.MasterProfile.VnetCidr = "10.0.0.0/12"
+			},
+			expected: 0,
+		},
+		{
+			name:   "dual-stack service cidr overlaps cluster subnet",
+			ruleID: RuleOverlappingNetworks,
+			mutate: func(cs *api.ContainerService) {
+				k := cs.Properties.OrchestratorProfile.KubernetesConfig
+				k.ServiceCIDR = "10.244.0.0/24,fd00::/108"
+				k.ClusterSubnet = "10.244.0.0/16,fc00::/48"
+				cs.Properties.MasterProfile.VnetCidr = "10.0.0.0/12"
+			},
+			expected: 1,
+		},
+	}
+
+	rules := map[string]Rule{}
+	for _, r := range DefaultRules() {
+		rules[r.ID] = r
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			cs := api.CreateMockContainerService("testcluster", "1.13.5", 1, 1, false)
+			c.mutate(cs)
+			msgs := rules[c.ruleID].Check(cs)
+			if len(msgs) != c.expected {
+				t.Fatalf("expected %d messages from %s, got %d: %v", c.expected, c.ruleID, len(msgs), msgs)
+			}
+		})
+	}
+}
+
+func TestDefaultRulesIgnoreOtherOrchestrators(t *testing.T) {
+	cs := api.CreateMockContainerService("testcluster", "1.13.5", 3, 1, false)
+	cs.Properties.OrchestratorProfile.OrchestratorType = api.DCOS
+	cs.Properties.OrchestratorProfile.KubernetesConfig.EnableRbac = to.BoolPtr(false)
+	if findings := NewLinter().Run(cs); len(findings) != 0 {
+		t.Fatalf("expected no findings for a DCOS cluster, got %v", findings)
+	}
+}
@@ -0,0 +1,359 @@
+// Copyright (c) Microsoft Corporation. All rights reserved.
+// Licensed under the MIT license.
+
+package clusterdelete
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/Azure/aks-engine/pkg/armhelpers"
+	"github.com/Azure/azure-sdk-for-go/services/resources/mgmt/2018-05-01/resources"
+	"github.com/pkg/errors"
+	"github.com/sirupsen/logrus"
+)
+
+const (
+	// resourceNameSuffixTag is the tag of the machines of a cluster set to the cluster ID, or to its first
+	// windowsResourceNamePrefixLength characters on Windows machines
+	resourceNameSuffixTag           = "resourceNameSuffix"
+	windowsResourceNamePrefixLength = 5
+	// subscriptionScopeTemplate is the scope the role assignments of the cluster identities are listed at
+	subscriptionScopeTemplate = "/subscriptions/%s"
+)
+
+// deletionOrder are the resource types in the order their resources are deleted, the resources referring to others
+// first. The resources of a group are deleted at the same time, and the resources of other types last, such as the
+// network security groups and the route tables the subnets of the virtual networks refer to.
+var deletionOrder = [][]string{
+	{"Microsoft.Compute/virtualMachineScaleSets", "Microsoft.Compute/virtualMachines"},
+	{"Microsoft.Network/networkInterfaces", "Microsoft.Compute/disks"},
+	{"Microsoft.Network/loadBalancers", "Microsoft.Compute/availabilitySets"},
+	{"Microsoft.Network/publicIPAddresses", "Microsoft.Network/virtualNetworks"},
+}
+
+// Deleter deletes a Kubernetes cluster deployed by aks-engine, with the role assignments of its identities and its
+// service principal if aks-engine created it
+type Deleter struct {
+	Client         armhelpers.AKSEngineClient
+	Logger         *logrus.Entry
+	SubscriptionID string
+	ResourceGroup  string
+	// ClusterResourcesOnly deletes the resources of the cluster instead of its resource group, for clusters sharing
+	// their resource group with other resources, a custom virtual network for instance
+	ClusterResourcesOnly bool
+	// ClusterID identifies the machines of the cluster, which are tagged with it
+	ClusterID string
+	// ResourceNames are the names of the other resources of the cluster, its user-assigned identity for instance
+	ResourceNames []string
+	// IncludeCandidates deletes the candidates of the plan with the resources of the cluster
+	IncludeCandidates bool
+	// ServicePrincipalObjectID, ApplicationObjectID and ApplicationName identify the service principal of the cluster
+	// and its application, which are deleted if ApplicationObjectID is set
+	ServicePrincipalObjectID string
+	ApplicationObjectID      string
+	ApplicationName          string
+}
+
+// Plan is what a Deleter deletes
+type Plan struct {
+	// ResourceGroup is the resource group deleted, if the whole group is
+	ResourceGroup string
+	// Resources are the IDs of the resources deleted, in groups deleted one after the other
+	Resources [][]string
+	// Candidates are the IDs of the resources named after the cluster, or tagged with the prefix of its ID of the
+	// Windows machines, that are not deleted unless IncludeCandidates is set, since other resources may match too
+	Candidates []string
+	// RoleAssignments are the IDs of the role assignments of the identities deleted
+	RoleAssignments []string
+	// ApplicationObjectID is the object ID of the application deleted with its service principal, if any
+	ApplicationObjectID string
+}
+
+// Plan lists the resources, the role assignments and the application the deletion of the cluster deletes
+func (d *Deleter) Plan(ctx context.Context) (*Plan, error) {
+	plan := &Plan{ApplicationObjectID: d.ApplicationObjectID}
+	if !d.ClusterResourcesOnly {
+		plan.ResourceGroup = d.ResourceGroup
+	}
+
+	all := map[string]resources.GenericResource{}
+	selected := map[string]resources.GenericResource{}
+	candidates := map[string]resources.GenericResource{}
+	page, err := d.Client.ListResources(ctx, d.ResourceGroup)
+	for ; err == nil && page.NotDone(); err = page.Next() {
+		for _, r := range page.Values() {
+			if r.ID == nil {
+				continue
+			}
+			id := strings.ToLower(*r.ID)
+			all[id] = r
+			switch {
+			case !d.ClusterResourcesOnly || d.isClusterResource(r):
+				selected[id] = r
+			case d.isCandidate(r):
+				if d.IncludeCandidates {
+					selected[id] = r
+				} else {
+					candidates[id] = r
+				}
+			}
+		}
+	}
+	if err != nil {
+		return nil, errors.Wrapf(err, "listing the resources of resource group %s", d.ResourceGroup)
+	}
+
+	principals, err := d.principals(ctx, all, selected)
+	if err != nil {
+		return nil, err
+	}
+	if plan.ApplicationObjectID != "" && d.ServicePrincipalObjectID != "" {
+		principals[d.ServicePrincipalObjectID] = true
+	}
+	if plan.RoleAssignments, err = d.roleAssignments(ctx, principals); err != nil {
+		return nil, err
+	}
+
+	plan.Resources = groupResources(selected)
+	for id, r := range candidates {
+		// the network interfaces and the disks of the machines of the cluster are selected with them
+		if _, ok := selected[id]; !ok {
+			plan.Candidates = append(plan.Candidates, *r.ID)
+		}
+	}
+	sort.Strings(plan.Candidates)
+	return plan, nil
+}
+
+// isClusterResource returns whether r is a resource of the cluster: a machine tagged with the cluster ID, or a
+// resource named in ResourceNames
+func (d *Deleter) isClusterResource(r resources.GenericResource) bool {
+	if suffix := r.Tags[resourceNameSuffixTag]; suffix != nil && *suffix == d.ClusterID {
+		return true
+	}
+	if r.Name == nil {
+		return false
+	}
+	for _, name := range d.ResourceNames {
+		if strings.EqualFold(*r.Name, name) {
+			return true
+		}
+	}
+	return false
+}
+
+// isCandidate returns whether r may be a resource of the cluster: a resource named after the cluster ID, or a machine
+// tagged with the prefix of the cluster ID of the Windows machines, which other clusters may share
+func (d *Deleter) isCandidate(r resources.GenericResource) bool {
+	if suffix := r.Tags[resourceNameSuffixTag]; suffix != nil && len(*suffix) == windowsResourceNamePrefixLength && strings.HasPrefix(d.ClusterID, *suffix) {
+		return true
+	}
+	return r.Name != nil && strings.Contains(strings.ToLower(*r.Name), strings.ToLower(d.ClusterID))
+}
+
+// principals returns the identities of the machines selected for deletion, and the user-assigned identities selected
+// for deletion. The disks and the network interfaces of the machines, which are not all named after the cluster, are
+// selected from all the resources of the group along with them.
+func (d *Deleter) principals(ctx context.Context, all, selected map[string]resources.GenericResource) (map[string]bool, error) {
+	principals := map[string]bool{}
+	addUserAssigned := func(identityID string, principalID *string) {
+		if _, ok := selected[strings.ToLower(identityID)]; ok && principalID != nil {
+			principals[*principalID] = true
+		}
+	}
+	selectResource := func(id *string) {
+		if id == nil {
+			return
+		}
+		if r, ok := all[strings.ToLower(*id)]; ok {
+			selected[strings.ToLower(*id)] = r
+		}
+	}
+
+	vmPage, err := d.Client.ListVirtualMachines(ctx, d.ResourceGroup)
+	for ; err == nil && vmPage.NotDone(); err = vmPage.Next() {
+		for _, vm := range vmPage.Values() {
+			if vm.ID == nil {
+				continue
+			}
+			if _, ok := selected[strings.ToLower(*vm.ID)]; !ok {
+				continue
+			}
+			if vm.VirtualMachineProperties != nil {
+				if profile := vm.VirtualMachineProperties.NetworkProfile; profile != nil && profile.NetworkInterfaces != nil {
+					for _, nic := range *profile.NetworkInterfaces {
+						selectResource(nic.ID)
+					}
+				}
+				if profile := vm.VirtualMachineProperties.StorageProfile; profile != nil {
+					if profile.OsDisk != nil && profile.OsDisk.ManagedDisk != nil {
+						selectResource(profile.OsDisk.ManagedDisk.ID)
+					}
+					if profile.DataDisks != nil {
+						for _, disk := range *profile.DataDisks {
+							if disk.ManagedDisk != nil {
+								selectResource(disk.ManagedDisk.ID)
+							}
+						}
+					}
+				}
+			}
+			if vm.Identity != nil {
+				if vm.Identity.PrincipalID != nil {
+					principals[*vm.Identity.PrincipalID] = true
+				}
+				for id, identity := range vm.Identity.UserAssignedIdentities {
+					if identity != nil {
+						addUserAssigned(id, identity.PrincipalID)
+					}
+				}
+			}
+		}
+	}
+	if err != nil {
+		return nil, errors.Wrapf(err, "listing the virtual machines of resource group %s", d.ResourceGroup)
+	}
+
+	vmssPage, err := d.Client.ListVirtualMachineScaleSets(ctx, d.ResourceGroup)
+	for ; err == nil && vmssPage.NotDone(); err = vmssPage.Next() {
+		for _, vmss := range vmssPage.Values() {
+			if vmss.ID == nil || vmss.Identity == nil {
+				continue
+			}
+			if _, ok := selected[strings.ToLower(*vmss.ID)]; !ok {
+				continue
+			}
+			if vmss.Identity.PrincipalID != nil {
+				principals[*vmss.Identity.PrincipalID] = true
+			}
+			for id, identity := range vmss.Identity.UserAssignedIdentities {
+				if identity != nil {
+					addUserAssigned(id, identity.PrincipalID)
+				}
+			}
+		}
+	}
+	if err != nil {
+		return nil, errors.Wrapf(err, "listing the virtual machine scale sets of resource group %s", d.ResourceGroup)
+	}
+	return principals, nil
+}
+
+// roleAssignments returns the IDs of the role assignments of principals in the subscription
+func (d *Deleter) roleAssignments(ctx context.Context, principals map[string]bool) ([]string, error) {
+	ids := []string{}
+	seen := map[string]bool{}
+	scope := fmt.Sprintf(subscriptionScopeTemplate, d.SubscriptionID)
+	for _, principal := range sortedKeys(principals) {
+		page, err := d.Client.ListRoleAssignmentsForPrincipal(ctx, scope, principal)
+		for ; err == nil && page.NotDone(); err = page.Next() {
+			for _, roleAssignment := range page.Values() {
+				if roleAssignment.ID != nil && !seen[*roleAssignment.ID] {
+					seen[*roleAssignment.ID] = true
+					ids = append(ids, *roleAssignment.ID)
+				}
+			}
+		}
+		if err != nil {
+			return nil, errors.Wrapf(err, "listing the role assignments of principal %s", principal)
+		}
+	}
+	return ids, nil
+}
+
+// groupResources returns the IDs of the selected resources in groups following deletionOrder
+func groupResources(selected map[string]resources.GenericResource) [][]string {
+	groups := make([][]string, len(deletionOrder)+1)
+	for _, r := range selected {
+		group := len(deletionOrder)
+		for i, types := range deletionOrder {
+			for _, t := range types {
+				if r.Type != nil && strings.EqualFold(*r.Type, t) {
+					group = i
+				}
+			}
+		}
+		groups[group] = append(groups[group], *r.ID)
+	}
+
+	grouped := [][]string{}
+	for _, group := range groups {
+		if len(group) > 0 {
+			sort.Strings(group)
+			grouped = append(grouped, group)
+		}
+	}
+	return grouped
+}
+
+func sortedKeys(m map[string]bool) []string {
+	keys := []string{}
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// Delete deletes what plan lists: the role assignments, then the resource group or the resources, and then the
+// application if they are all deleted. It carries on after a failed deletion, and returns an error listing them all.
+func (d *Deleter) Delete(ctx context.Context, plan *Plan) error {
+	var lock sync.Mutex
+	failures := []string{}
+	fail := func(err error) {
+		lock.Lock()
+		defer lock.Unlock()
+		d.Logger.Error(err)
+		failures = append(failures, err.Error())
+	}
+
+	for _, id := range plan.RoleAssignments {
+		d.Logger.Infof("Deleting role assignment %s", id)
+		if _, err := d.Client.DeleteRoleAssignmentByID(ctx, id); err != nil {
+			fail(errors.Wrapf(err, "deleting role assignment %s", id))
+		}
+	}
+
+	if plan.ResourceGroup != "" {
+		d.Logger.Infof("Deleting resource group %s", plan.ResourceGroup)
+		if err := d.Client.DeleteResourceGroup(ctx, plan.ResourceGroup); err != nil {
+			fail(errors.Wrapf(err, "deleting resource group %s", plan.ResourceGroup))
+		}
+	} else {
+		for _, group := range plan.Resources {
+			var wg sync.WaitGroup
+			for _, id := range group {
+				wg.Add(1)
+				go func(id string) {
+					defer wg.Done()
+					d.Logger.Infof("Deleting %s", id)
+					if err := d.Client.DeleteResourceByID(ctx, id); err != nil {
+						fail(errors.Wrapf(err, "deleting %s", id))
+					}
+				}(id)
+			}
+			wg.Wait()
+		}
+	}
+
+	if plan.ApplicationObjectID != "" {
+		if len(failures) > 0 {
+			// the cluster may still be running with the service principal
+			d.Logger.Warnf("Not deleting application %s, as the cluster is not entirely deleted", plan.ApplicationObjectID)
+		} else {
+			d.Logger.Infof("Deleting application %s and its service principal", plan.ApplicationObjectID)
+			if _, err := d.Client.DeleteApp(ctx, d.ApplicationName, plan.ApplicationObjectID); err != nil {
+				fail(errors.Wrapf(err, "deleting application %s", plan.ApplicationObjectID))
+			}
+		}
+	}
+
+	if len(failures) > 0 {
+		return errors.Errorf("deleting the cluster: %s", strings.Join(failures, "; "))
+	}
+	return nil
+}
@@ -0,0 +1,290 @@
+// Copyright (c) Microsoft Corporation. All rights reserved.
+// Licensed under the MIT license.
+
+package clusterdelete
+
+import (
+	"context"
+	"path"
+	"sync"
+	"testing"
+
+	"github.com/Azure/aks-engine/pkg/armhelpers"
+	"github.com/Azure/azure-sdk-for-go/services/authorization/mgmt/2015-07-01/authorization"
+	"github.com/Azure/azure-sdk-for-go/services/compute/mgmt/2018-10-01/compute"
+	"github.com/Azure/azure-sdk-for-go/services/resources/mgmt/2018-05-01/resources"
+	"github.com/Azure/go-autorest/autorest"
+	"github.com/Azure/go-autorest/autorest/to"
+	. "github.com/onsi/gomega"
+	"github.com/pkg/errors"
+	log "github.com/sirupsen/logrus"
+)
+
+const (
+	rgID          = "/subscriptions/sub/resourceGroups/rg"
+	masterVM      = rgID + "/providers/Microsoft.Compute/virtualMachines/k8s-master-12345678-0"
+	windowsVM     = rgID + "/providers/Microsoft.Compute/virtualMachines/12345k8s010"
+	windowsNIC    = rgID + "/providers/Microsoft.Network/networkInterfaces/12345k8s010-nic-0"
+	windowsDisk   = rgID + "/providers/Microsoft.Compute/disks/12345k8s010_OsDisk_1_0123456789"
+	masterNIC     = rgID + "/providers/Microsoft.Network/networkInterfaces/k8s-master-12345678-nic-0"
+	masterLB      = rgID + "/providers/Microsoft.Network/loadBalancers/k8s-master-lb-12345678"
+	masterIP      = rgID + "/providers/Microsoft.Network/publicIPAddresses/k8s-master-ip-testcluster-12345678"
+	nsg           = rgID + "/providers/Microsoft.Network/networkSecurityGroups/k8s-master-12345678-nsg"
+	identity      = rgID + "/providers/Microsoft.ManagedIdentity/userAssignedIdentities/testcluster-identity"
+	customVNET    = rgID + "/providers/Microsoft.Network/virtualNetworks/custom-vnet"
+	otherVM       = rgID + "/providers/Microsoft.Compute/virtualMachines/other-vm"
+	otherNIC      = rgID + "/providers/Microsoft.Network/networkInterfaces/other-vm-nic"
+	spPrincipal   = "sp-principal"
+	applicationID = "app-object-id"
+)
+
+type fakeClient struct {
+	armhelpers.MockAKSEngineClient
+	sync.Mutex
+	// roleAssignments are the IDs of the role assignments of each principal
+	roleAssignments map[string][]string
+	// failDeleting are the IDs of the resources failing to be deleted
+	failDeleting map[string]bool
+	// deleted are the IDs of what was deleted, in order
+	deleted []string
+}
+
+func (c *fakeClient) ListRoleAssignmentsForPrincipal(ctx context.Context, scope string, principalID string) (armhelpers.RoleAssignmentListResultPage, error) {
+	if scope != "/subscriptions/sub" {
+		return nil, errors.Errorf("unexpected scope %s", scope)
+	}
+	roleAssignments := []authorization.RoleAssignment{}
+	for _, id := range c.roleAssignments[principalID] {
+		roleAssignments = append(roleAssignments, authorization.RoleAssignment{ID: to.StringPtr(id)})
+	}
+	return &armhelpers.MockRoleAssignmentListResultPage{
+		Fn: func(authorization.RoleAssignmentListResult) (authorization.RoleAssignmentListResult, error) {
+			return authorization.RoleAssignmentListResult{}, nil
+		},
+		Ralr: authorization.RoleAssignmentListResult{Value: &roleAssignments},
+	}, nil
+}
+
+func (c *fakeClient) record(id string) error {
+	c.Lock()
+	defer c.Unlock()
+	if c.failDeleting[id] {
+		return errors.New("conflict")
+	}
+	c.deleted = append(c.deleted, id)
+	return nil
+}
+
+func (c *fakeClient) DeleteRoleAssignmentByID(ctx context.Context, roleAssignmentID string) (authorization.RoleAssignment, error) {
+	return authorization.RoleAssignment{}, c.record(roleAssignmentID)
+}
+
+func (c *fakeClient) DeleteResourceGroup(ctx context.Context, resourceGroup string) error {
+	return c.record(resourceGroup)
+}
+
+func (c *fakeClient) DeleteResourceByID(ctx context.Context, resourceID string) error {
+	return c.record(resourceID)
+}
+
+func (c *fakeClient) DeleteApp(ctx context.Context, applicationName, applicationObjectID string) (autorest.Response, error) {
+	return autorest.Response{}, c.record(applicationObjectID)
+}
+
+func genericResource(id, resourceType string, tags map[string]*string) resources.GenericResource {
+	return resources.GenericResource{ID: to.StringPtr(id), Name: to.StringPtr(path.Base(id)), Type: to.StringPtr(resourceType), Tags: tags}
+}
+
+func newFakeClient() *fakeClient {
+	c := &fakeClient{
+		roleAssignments: map[string][]string{
+			"master-principal":   {"ra-master"},
+			"identity-principal": {"ra-identity"},
+			"other-principal":    {"ra-other"},
+			spPrincipal:          {"ra-sp", "ra-master"},
+		},
+	}
+	c.FakeListResourcesResult = func() []resources.GenericResource {
+		return []resources.GenericResource{
+			genericResource(masterVM, "Microsoft.Compute/virtualMachines", map[string]*string{"resourceNameSuffix": to.StringPtr("12345678")}),
+			genericResource(windowsVM, "Microsoft.Compute/virtualMachines", map[string]*string{"resourceNameSuffix": to.StringPtr("12345")}),
+			genericResource(windowsNIC, "Microsoft.Network/networkInterfaces", nil),
+			genericResource(windowsDisk, "Microsoft.Compute/disks", nil),
+			genericResource(masterNIC, "Microsoft.Network/networkInterfaces", nil),
+			genericResource(masterLB, "Microsoft.Network/loadBalancers", nil),
+			genericResource(masterIP, "Microsoft.Network/publicIPAddresses", nil),
+			genericResource(nsg, "Microsoft.Network/networkSecurityGroups", nil),
+			genericResource(identity, "Microsoft.ManagedIdentity/userAssignedIdentities", nil),
+			genericResource(customVNET, "Microsoft.Network/virtualNetworks", nil),
+			genericResource(otherVM, "Microsoft.Compute/virtualMachines", map[string]*string{"resourceNameSuffix": to.StringPtr("87654321")}),
+			genericResource(otherNIC, "Microsoft.Network/networkInterfaces", nil),
+		}
+	}
+	c.FakeListVirtualMachineResult = func() []compute.VirtualMachine {
+		return []compute.VirtualMachine{
+			{
+				ID: to.StringPtr(masterVM),
+				VirtualMachineProperties: &compute.VirtualMachineProperties{
+					NetworkProfile: &compute.NetworkProfile{
+						NetworkInterfaces: &[]compute.NetworkInterfaceReference{{ID: to.StringPtr(masterNIC)}},
+					},
+				},
+				Identity: &compute.VirtualMachineIdentity{
+					PrincipalID: to.StringPtr("master-principal"),
+					UserAssignedIdentities: map[string]*compute.VirtualMachineIdentityUserAssignedIdentitiesValue{
+						identity: {PrincipalID: to.StringPtr("identity-principal")},
+					},
+				},
+			},
+			{
+				ID: to.StringPtr(windowsVM),
+				VirtualMachineProperties: &compute.VirtualMachineProperties{
+					NetworkProfile: &compute.NetworkProfile{
+						NetworkInterfaces: &[]compute.NetworkInterfaceReference{{ID: to.StringPtr(windowsNIC)}},
+					},
+					StorageProfile: &compute.StorageProfile{
+						OsDisk: &compute.OSDisk{ManagedDisk: &compute.ManagedDiskParameters{ID: to.StringPtr(windowsDisk)}},
+					},
+				},
+			},
+			{
+				ID:       to.StringPtr(otherVM),
+				Identity: &compute.VirtualMachineIdentity{PrincipalID: to.StringPtr("other-principal")},
+				VirtualMachineProperties: &compute.VirtualMachineProperties{
+					NetworkProfile: &compute.NetworkProfile{
+						NetworkInterfaces: &[]compute.NetworkInterfaceReference{{ID: to.StringPtr(otherNIC)}},
+					},
+				},
+			},
+		}
+	}
+	return c
+}
+
+func newDeleter(client *fakeClient) *Deleter {
+	return &Deleter{
+		Client:         client,
+		Logger:         log.NewEntry(log.New()),
+		SubscriptionID: "sub",
+		ResourceGroup:  "rg",
+		ClusterID:      "12345678",
+		ResourceNames:  []string{"testcluster-identity"},
+	}
+}
+
+func TestPlanShouldDeleteTheResourceGroup(t *testing.T) {
+	g := NewGomegaWithT(t)
+	deleter := newDeleter(newFakeClient())
+	deleter.ServicePrincipalObjectID = spPrincipal
+	deleter.ApplicationObjectID = applicationID
+
+	plan, err := deleter.Plan(context.Background())
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(plan.ResourceGroup).To(Equal("rg"))
+	g.Expect(plan.ApplicationObjectID).To(Equal(applicationID))
+	// every machine of the group is deleted with the group
+	g.Expect(plan.RoleAssignments).To(Equal([]string{"ra-identity", "ra-master", "ra-other", "ra-sp"}))
+	g.Expect(plan.Resources).To(Equal([][]string{
+		{windowsVM, masterVM, otherVM},
+		{windowsDisk, windowsNIC, masterNIC, otherNIC},
+		{masterLB},
+		{masterIP, customVNET},
+		{identity, nsg},
+	}))
+}
+
+func TestPlanShouldSelectTheClusterResources(t *testing.T) {
+	g := NewGomegaWithT(t)
+	deleter := newDeleter(newFakeClient())
+	deleter.ClusterResourcesOnly = true
+	// the service principal was not created by aks-engine
+	deleter.ServicePrincipalObjectID = spPrincipal
+
+	plan, err := deleter.Plan(context.Background())
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(plan.ResourceGroup).To(BeEmpty())
+	g.Expect(plan.ApplicationObjectID).To(BeEmpty())
+	g.Expect(plan.RoleAssignments).To(Equal([]string{"ra-identity", "ra-master"}))
+	g.Expect(plan.Resources).To(Equal([][]string{
+		{masterVM},
+		{masterNIC},
+		{identity},
+	}))
+	// the resources only named after the cluster, and the machines tagged with the prefix of its ID, are not deleted,
+	// the name of the disk of the Windows machine contains the cluster ID by chance
+	g.Expect(plan.Candidates).To(Equal([]string{windowsDisk, windowsVM, masterLB, nsg, masterIP}))
+}
+
+func TestPlanShouldSelectTheCandidatesIfIncluded(t *testing.T) {
+	g := NewGomegaWithT(t)
+	deleter := newDeleter(newFakeClient())
+	deleter.ClusterResourcesOnly = true
+	deleter.IncludeCandidates = true
+
+	plan, err := deleter.Plan(context.Background())
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(plan.Resources).To(Equal([][]string{
+		{windowsVM, masterVM},
+		{windowsDisk, windowsNIC, masterNIC},
+		{masterLB},
+		{masterIP},
+		{identity, nsg},
+	}))
+	g.Expect(plan.Candidates).To(BeEmpty())
+}
+
+func TestPlanShouldFailIfTheResourcesCannotBeListed(t *testing.T) {
+	g := NewGomegaWithT(t)
+	client := newFakeClient()
+	client.FailListResources = true
+
+	_, err := newDeleter(client).Plan(context.Background())
+	g.Expect(err).To(HaveOccurred())
+	g.Expect(err.Error()).To(Equal("listing the resources of resource group rg: ListResources failed"))
+}
+
+func TestDeleteShouldDeleteTheResourceGroup(t *testing.T) {
+	g := NewGomegaWithT(t)
+	client := newFakeClient()
+
+	err := newDeleter(client).Delete(context.Background(), &Plan{
+		ResourceGroup:       "rg",
+		Resources:           [][]string{{masterVM}},
+		RoleAssignments:     []string{"ra-master", "ra-sp"},
+		ApplicationObjectID: applicationID,
+	})
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(client.deleted).To(Equal([]string{"ra-master", "ra-sp", "rg", applicationID}))
+}
+
+func TestDeleteShouldDeleteTheResourcesInOrder(t *testing.T) {
+	g := NewGomegaWithT(t)
+	client := newFakeClient()
+
+	err := newDeleter(client).Delete(context.Background(), &Plan{
+		Resources:       [][]string{{windowsVM, masterVM}, {windowsNIC, masterNIC}, {masterLB}},
+		RoleAssignments: []string{"ra-master"},
+	})
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(client.deleted).To(HaveLen(6))
+	g.Expect(client.deleted[0]).To(Equal("ra-master"))
+	g.Expect(client.deleted[1:3]).To(ConsistOf(windowsVM, masterVM))
+	g.Expect(client.deleted[3:5]).To(ConsistOf(windowsNIC, masterNIC))
+	g.Expect(client.deleted[5]).To(Equal(masterLB))
+}
+
+func TestDeleteShouldCarryOnAfterFailures(t *testing.T) {
+	g := NewGomegaWithT(t)
+	client := newFakeClient()
+	client.failDeleting = map[string]bool{"ra-master": true, nsg: true}
+
+	err := newDeleter(client).Delete(context.Background(), &Plan{
+		Resources:           [][]string{{masterVM}, {masterLB, nsg}, {identity}},
+		RoleAssignments:     []string{"ra-master", "ra-identity"},
+		ApplicationObjectID: applicationID,
+	})
+	g.Expect(err).To(HaveOccurred())
+	g.Expect(err.Error()).To(Equal("deleting the cluster: deleting role assignment ra-master: conflict; deleting " + nsg + ": conflict"))
+	// the application is kept for the cluster not entirely deleted
+	g.Expect(client.deleted).To(Equal([]string{"ra-identity", masterVM, masterLB, identity}))
+}
@@ -0,0 +1,6 @@
+// Copyright (c) Microsoft Corporation. All rights reserved.
+// Licensed under the MIT license.
+
+// Package clusterdelete deletes Kubernetes clusters, along with the role assignments of their identities and the
+// service principal aks-engine created for them.
+package clusterdelete
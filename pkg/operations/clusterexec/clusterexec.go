@@ -0,0 +1,221 @@
+// Copyright (c) Microsoft Corporation. All rights reserved.
+// Licensed under the MIT license.
+
+package clusterexec
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"strconv"
+	"strings"
+
+	"github.com/Azure/aks-engine/pkg/operations/remotessh"
+	"github.com/pkg/errors"
+	"github.com/sirupsen/logrus"
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/labels"
+)
+
+const (
+	// listNodesCmd lists the nodes of the cluster from a master
+	listNodesCmd = "kubectl get nodes -o json"
+	// linuxScriptCmd runs the script read from stdin on Linux nodes
+	linuxScriptCmd = "bash -s"
+	// windowsScriptCmd runs the script read from stdin on Windows nodes
+	windowsScriptCmd = "powershell -NoProfile -NonInteractive -Command -"
+	// DefaultConcurrency is the default number of nodes a command runs on at the same time
+	DefaultConcurrency = 10
+)
+
+// Node is a node of the cluster commands run on
+type Node struct {
+	Name string
+	// Pool is the agent pool of the node, empty for masters
+	Pool    string
+	Master  bool
+	Windows bool
+	Labels  map[string]string
+	// Host is how the node is reached over SSH
+	Host *remotessh.Host
+}
+
+// Selector selects nodes of the cluster. The zero value selects every node.
+type Selector struct {
+	// Masters selects the masters
+	Masters bool
+	// Pools selects the agents of these pools. Every node is selected if neither Masters nor Pools is set.
+	Pools []string
+	// OS restricts the selection to the nodes running this operating system, linux or windows
+	OS string
+	// Labels restricts the selection to the nodes whose labels match this label selector
+	Labels labels.Selector
+}
+
+// Matches returns whether the selector selects node
+func (s *Selector) Matches(node Node) bool {
+	if s.Masters || len(s.Pools) > 0 {
+		inPools := false
+		for _, pool := range s.Pools {
+			inPools = inPools || (!node.Master && strings.EqualFold(node.Pool, pool))
+		}
+		if !inPools && !(s.Masters && node.Master) {
+			return false
+		}
+	}
+	if s.OS != "" && strings.EqualFold(s.OS, "windows") != node.Windows {
+		return false
+	}
+	return s.Labels == nil || s.Labels.Matches(labels.Set(node.Labels))
+}
+
+// Result is the outcome of a command on a node
+type Result struct {
+	Node string `json:"node"`
+	// ExitCode is the exit code of the command, or -1 if it could not run
+	ExitCode int    `json:"exitCode"`
+	Stdout   string `json:"stdout"`
+	Stderr   string `json:"stderr"`
+	// Error is why the command could not run, or why it failed
+	Error string `json:"error,omitempty"`
+}
+
+// Executor runs commands on the nodes of a Kubernetes cluster over SSH. The masters are reached through the master
+// load balancer, and the agents registered in the cluster through a master. The Windows agents are reached as the
+// Windows admin user, and must have SSH enabled.
+type Executor struct {
+	Logger     *logrus.Entry
+	MasterFQDN string
+	// SSHPorts are the ports of the master load balancer forwarding SSH to each master, in master order
+	SSHPorts []int
+	// MasterVMPrefix is the name of the master VMs without their index
+	MasterVMPrefix string
+	LinuxUser      string
+	WindowsUser    string
+	SSHKey         []byte
+	// KnownHostsFile is the known_hosts file the host keys of the nodes are verified against, they are not verified if empty
+	KnownHostsFile string
+	// Concurrency is the number of nodes a command runs on at the same time, it defaults to DefaultConcurrency
+	Concurrency int
+	// Run runs a command on a host over SSH, as LinuxUser unless the host sets its user. It defaults to remotessh.Run.
+	Run func(host *remotessh.Host, cmd string, stdin io.Reader, stdout, stderr io.Writer) error
+}
+
+// ListNodes lists the nodes registered in the cluster matching selector, from the first master able to
+func (e *Executor) ListNodes(selector Selector) ([]Node, error) {
+	var err error
+	for _, port := range e.SSHPorts {
+		master := &remotessh.Host{Address: e.MasterFQDN, Port: port}
+		var out bytes.Buffer
+		if err = e.run()(master, listNodesCmd, nil, &out, nil); err != nil {
+			e.Logger.Warnf("listing the nodes of the cluster from %s:%d: %v", master.Address, port, err)
+			continue
+		}
+		var nodeList v1.NodeList
+		if err = json.Unmarshal(out.Bytes(), &nodeList); err != nil {
+			return nil, errors.Wrap(err, "parsing the node list")
+		}
+		nodes := []Node{}
+		for _, n := range nodeList.Items {
+			node := e.newNode(n, master)
+			if node.Host != nil && selector.Matches(node) {
+				nodes = append(nodes, node)
+			}
+		}
+		return nodes, nil
+	}
+	return nil, errors.Wrap(err, "listing the nodes of the cluster")
+}
+
+// newNode returns the node n, reached through the master load balancer if it is a master, or else from master
+func (e *Executor) newNode(n v1.Node, master *remotessh.Host) Node {
+	node := Node{
+		Name:    n.Name,
+		Pool:    n.Labels["agentpool"],
+		Master:  n.Labels["kubernetes.io/role"] == "master" || strings.HasPrefix(n.Name, e.MasterVMPrefix),
+		Windows: n.Labels["beta.kubernetes.io/os"] == "windows" || n.Labels["kubernetes.io/os"] == "windows",
+		Labels:  n.Labels,
+	}
+	if node.Master {
+		node.Pool = ""
+		if i, err := strconv.Atoi(strings.TrimPrefix(n.Name, e.MasterVMPrefix)); err == nil && i >= 0 && i < len(e.SSHPorts) {
+			node.Host = &remotessh.Host{Address: e.MasterFQDN, Port: e.SSHPorts[i]}
+			return node
+		}
+	}
+	for _, address := range n.Status.Addresses {
+		if address.Type == v1.NodeInternalIP {
+			node.Host = &remotessh.Host{Address: address.Address, Port: 22, JumpHost: master}
+			if node.Windows {
+				node.Host.User = e.WindowsUser
+			}
+			break
+		}
+	}
+	return node
+}
+
+// Exec runs command on nodes, or script if command is empty, on at most Concurrency nodes at the same time. Scripts
+// run with bash on Linux nodes, and with PowerShell on Windows nodes. The results are in the order of nodes.
+func (e *Executor) Exec(nodes []Node, command string, script []byte) []Result {
+	concurrency := e.Concurrency
+	if concurrency <= 0 {
+		concurrency = DefaultConcurrency
+	}
+	results := make([]Result, len(nodes))
+	sem := make(chan struct{}, concurrency)
+	done := make(chan struct{})
+	for i := range nodes {
+		go func(i int) {
+			sem <- struct{}{}
+			defer func() {
+				<-sem
+				done <- struct{}{}
+			}()
+			results[i] = e.exec(nodes[i], command, script)
+		}(i)
+	}
+	for range nodes {
+		<-done
+	}
+	return results
+}
+
+func (e *Executor) exec(node Node, command string, script []byte) Result {
+	var stdin io.Reader
+	if command == "" {
+		command = linuxScriptCmd
+		if node.Windows {
+			command = windowsScriptCmd
+		}
+		stdin = bytes.NewReader(script)
+	}
+
+	e.Logger.Debugf("Running %q on node %s", command, node.Name)
+	var stdout, stderr bytes.Buffer
+	err := e.run()(node.Host, command, stdin, &stdout, &stderr)
+	result := Result{
+		Node:     node.Name,
+		ExitCode: remotessh.ExitCode(err),
+		Stdout:   stdout.String(),
+		Stderr:   stderr.String(),
+	}
+	if err != nil {
+		e.Logger.Warnf("running the command on node %s: %v", node.Name, err)
+		result.Error = err.Error()
+	}
+	return result
+}
+
+func (e *Executor) run() func(host *remotessh.Host, cmd string, stdin io.Reader, stdout, stderr io.Writer) error {
+	if e.Run != nil {
+		return e.Run
+	}
+	return func(host *remotessh.Host, cmd string, stdin io.Reader, stdout, stderr io.Writer) error {
+		config, err := remotessh.ClientConfig(e.LinuxUser, e.SSHKey, e.KnownHostsFile)
+		if err != nil {
+			return err
+		}
+		return remotessh.Run(host, config, cmd, stdin, stdout, stderr)
+	}
+}
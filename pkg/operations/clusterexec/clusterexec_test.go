@@ -0,0 +1,214 @@
+// Copyright (c) Microsoft Corporation. All rights reserved.
+// Licensed under the MIT license.
+
+package clusterexec
+
+import (
+	"fmt"
+	"io"
+	"io/ioutil"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/Azure/aks-engine/pkg/operations"
+	"github.com/Azure/aks-engine/pkg/operations/remotessh"
+	. "github.com/onsi/gomega"
+	"github.com/pkg/errors"
+	log "github.com/sirupsen/logrus"
+	"k8s.io/apimachinery/pkg/labels"
+)
+
+const nodeList = `{
+  "kind": "NodeList",
+  "apiVersion": "v1",
+  "items": [
+    {
+      "metadata": {"name": "k8s-master-12345678-0", "labels": {"kubernetes.io/role": "master", "beta.kubernetes.io/os": "linux"}},
+      "status": {"addresses": [{"type": "InternalIP", "address": "10.255.255.5"}]}
+    },
+    {
+      "metadata": {"name": "k8s-master-12345678-1", "labels": {"kubernetes.io/role": "master", "beta.kubernetes.io/os": "linux"}},
+      "status": {"addresses": [{"type": "InternalIP", "address": "10.255.255.6"}]}
+    },
+    {
+      "metadata": {"name": "k8s-agentpool1-12345678-0", "labels": {"kubernetes.io/role": "agent", "agentpool": "agentpool1", "beta.kubernetes.io/os": "linux", "storageprofile": "managed"}},
+      "status": {"addresses": [{"type": "Hostname", "address": "k8s-agentpool1-12345678-0"}, {"type": "InternalIP", "address": "10.240.0.4"}]}
+    },
+    {
+      "metadata": {"name": "k8s-agentpool1-12345678-1", "labels": {"kubernetes.io/role": "agent", "agentpool": "agentpool1", "beta.kubernetes.io/os": "linux"}},
+      "status": {"addresses": [{"type": "InternalIP", "address": "10.240.0.5"}]}
+    },
+    {
+      "metadata": {"name": "1234k8s010", "labels": {"kubernetes.io/role": "agent", "agentpool": "windowspool", "beta.kubernetes.io/os": "windows"}},
+      "status": {"addresses": [{"type": "InternalIP", "address": "10.240.0.6"}]}
+    }
+  ]
+}`
+
+type fakeCluster struct {
+	sync.Mutex
+	// failMasters are the ports of the masters failing every command
+	failMasters map[int]bool
+	// failNodes are the addresses of the nodes failing every command
+	failNodes map[string]bool
+	// running is the number of commands running, and maxRunning the most of them running at the same time
+	running    int
+	maxRunning int
+}
+
+func newFakeExecutor(cluster *fakeCluster) *Executor {
+	return &Executor{
+		Logger:         log.NewEntry(log.New()),
+		MasterFQDN:     "testcluster.westus2.cloudapp.azure.com",
+		SSHPorts:       operations.MasterSSHPorts(2),
+		MasterVMPrefix: "k8s-master-12345678-",
+		LinuxUser:      "azureuser",
+		WindowsUser:    "azureuser-windows",
+		Run: func(host *remotessh.Host, cmd string, stdin io.Reader, stdout, stderr io.Writer) error {
+			if cmd == listNodesCmd {
+				if cluster.failMasters[host.Port] {
+					return errors.New("unreachable")
+				}
+				io.WriteString(stdout, nodeList)
+				return nil
+			}
+
+			cluster.Lock()
+			cluster.running++
+			if cluster.running > cluster.maxRunning {
+				cluster.maxRunning = cluster.running
+			}
+			cluster.Unlock()
+			time.Sleep(10 * time.Millisecond)
+			defer func() {
+				cluster.Lock()
+				cluster.running--
+				cluster.Unlock()
+			}()
+
+			if cluster.failNodes[host.Address] {
+				return errors.New("unreachable")
+			}
+			input := ""
+			if stdin != nil {
+				b, _ := ioutil.ReadAll(stdin)
+				input = " " + string(b)
+			}
+			user := host.User
+			if user == "" {
+				user = "azureuser"
+			}
+			io.WriteString(stdout, fmt.Sprintf("%s@%s:%d %s%s", user, host.Address, host.Port, cmd, input))
+			return nil
+		},
+	}
+}
+
+func nodeNames(nodes []Node) []string {
+	names := []string{}
+	for _, node := range nodes {
+		names = append(names, node.Name)
+	}
+	return names
+}
+
+func TestListNodes(t *testing.T) {
+	g := NewGomegaWithT(t)
+	executor := newFakeExecutor(&fakeCluster{})
+
+	nodes, err := executor.ListNodes(Selector{})
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(nodeNames(nodes)).To(Equal([]string{"k8s-master-12345678-0", "k8s-master-12345678-1", "k8s-agentpool1-12345678-0", "k8s-agentpool1-12345678-1", "1234k8s010"}))
+
+	master := &remotessh.Host{Address: "testcluster.westus2.cloudapp.azure.com", Port: 22}
+	g.Expect(nodes[0].Master).To(BeTrue())
+	g.Expect(nodes[0].Host).To(Equal(master))
+	g.Expect(nodes[1].Host).To(Equal(&remotessh.Host{Address: "testcluster.westus2.cloudapp.azure.com", Port: 2201}))
+	g.Expect(nodes[2].Pool).To(Equal("agentpool1"))
+	g.Expect(nodes[2].Host).To(Equal(&remotessh.Host{Address: "10.240.0.4", Port: 22, JumpHost: master}))
+	g.Expect(nodes[4].Windows).To(BeTrue())
+	g.Expect(nodes[4].Host).To(Equal(&remotessh.Host{Address: "10.240.0.6", Port: 22, User: "azureuser-windows", JumpHost: master}))
+}
+
+func TestListNodesShouldSelectNodes(t *testing.T) {
+	g := NewGomegaWithT(t)
+	executor := newFakeExecutor(&fakeCluster{})
+	storageSelector, err := labels.Parse("storageprofile=managed")
+	g.Expect(err).NotTo(HaveOccurred())
+
+	cases := []struct {
+		selector Selector
+		expected []string
+	}{
+		{Selector{Masters: true}, []string{"k8s-master-12345678-0", "k8s-master-12345678-1"}},
+		{Selector{Pools: []string{"AgentPool1"}}, []string{"k8s-agentpool1-12345678-0", "k8s-agentpool1-12345678-1"}},
+		{Selector{Masters: true, Pools: []string{"windowspool"}}, []string{"k8s-master-12345678-0", "k8s-master-12345678-1", "1234k8s010"}},
+		{Selector{OS: "windows"}, []string{"1234k8s010"}},
+		{Selector{OS: "linux", Pools: []string{"agentpool1", "windowspool"}}, []string{"k8s-agentpool1-12345678-0", "k8s-agentpool1-12345678-1"}},
+		{Selector{Labels: storageSelector}, []string{"k8s-agentpool1-12345678-0"}},
+		{Selector{Masters: true, Labels: storageSelector}, []string{}},
+	}
+	for _, c := range cases {
+		nodes, err := executor.ListNodes(c.selector)
+		g.Expect(err).NotTo(HaveOccurred())
+		g.Expect(nodeNames(nodes)).To(Equal(c.expected))
+	}
+}
+
+func TestListNodesShouldTryEveryMaster(t *testing.T) {
+	g := NewGomegaWithT(t)
+	cluster := &fakeCluster{failMasters: map[int]bool{22: true}}
+
+	nodes, err := newFakeExecutor(cluster).ListNodes(Selector{Pools: []string{"agentpool1"}})
+	g.Expect(err).NotTo(HaveOccurred())
+	// the agents are reached through the master that listed them
+	g.Expect(nodes[0].Host.JumpHost).To(Equal(&remotessh.Host{Address: "testcluster.westus2.cloudapp.azure.com", Port: 2201}))
+
+	cluster.failMasters[2201] = true
+	_, err = newFakeExecutor(cluster).ListNodes(Selector{})
+	g.Expect(err).To(HaveOccurred())
+	g.Expect(err.Error()).To(Equal("listing the nodes of the cluster: unreachable"))
+}
+
+func TestExec(t *testing.T) {
+	g := NewGomegaWithT(t)
+	cluster := &fakeCluster{failNodes: map[string]bool{"10.240.0.5": true}}
+	executor := newFakeExecutor(cluster)
+	nodes, err := executor.ListNodes(Selector{})
+	g.Expect(err).NotTo(HaveOccurred())
+
+	results := executor.Exec(nodes, "uptime", nil)
+	g.Expect(results).To(Equal([]Result{
+		{Node: "k8s-master-12345678-0", Stdout: "azureuser@testcluster.westus2.cloudapp.azure.com:22 uptime"},
+		{Node: "k8s-master-12345678-1", Stdout: "azureuser@testcluster.westus2.cloudapp.azure.com:2201 uptime"},
+		{Node: "k8s-agentpool1-12345678-0", Stdout: "azureuser@10.240.0.4:22 uptime"},
+		{Node: "k8s-agentpool1-12345678-1", ExitCode: -1, Error: "unreachable"},
+		{Node: "1234k8s010", Stdout: "azureuser-windows@10.240.0.6:22 uptime"},
+	}))
+}
+
+func TestExecShouldRunScripts(t *testing.T) {
+	g := NewGomegaWithT(t)
+	executor := newFakeExecutor(&fakeCluster{})
+	nodes, err := executor.ListNodes(Selector{Pools: []string{"agentpool1", "windowspool"}})
+	g.Expect(err).NotTo(HaveOccurred())
+
+	results := executor.Exec(nodes, "", []byte("hostname"))
+	g.Expect(results).To(HaveLen(3))
+	g.Expect(results[0].Stdout).To(Equal("azureuser@10.240.0.4:22 bash -s hostname"))
+	g.Expect(results[2].Stdout).To(Equal("azureuser-windows@10.240.0.6:22 powershell -NoProfile -NonInteractive -Command - hostname"))
+}
+
+func TestExecShouldBoundConcurrency(t *testing.T) {
+	g := NewGomegaWithT(t)
+	cluster := &fakeCluster{}
+	executor := newFakeExecutor(cluster)
+	executor.Concurrency = 2
+	nodes, err := executor.ListNodes(Selector{})
+	g.Expect(err).NotTo(HaveOccurred())
+
+	results := executor.Exec(nodes, "uptime", nil)
+	g.Expect(results).To(HaveLen(5))
+	g.Expect(cluster.maxRunning).To(BeNumerically("<=", 2))
+}
@@ -0,0 +1,5 @@
+// Copyright (c) Microsoft Corporation. All rights reserved.
+// Licensed under the MIT license.
+
+// Package clusterexec runs commands and scripts on a selection of the nodes of Kubernetes clusters.
+package clusterexec
@@ -0,0 +1,318 @@
+// Copyright (c) Microsoft Corporation. All rights reserved.
+// Licensed under the MIT license.
+
+package clusterlogs
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/Azure/aks-engine/pkg/armhelpers"
+	"github.com/Azure/aks-engine/pkg/operations"
+	"github.com/Azure/azure-sdk-for-go/services/compute/mgmt/2018-10-01/compute"
+	"github.com/Azure/azure-sdk-for-go/services/network/mgmt/2018-08-01/network"
+	"github.com/Azure/go-autorest/autorest/to"
+	"github.com/pkg/errors"
+	"github.com/sirupsen/logrus"
+)
+
+// collectScript writes to stdout a gzipped tarball of the diagnostic logs of a node. The secrets of
+// /etc/kubernetes/azure.json are redacted in it, and in every other file collected.
+const collectScript = `set -e
+LOGS=$(mktemp -d)
+trap 'rm -rf $LOGS' EXIT
+collect() {
+  if [ -e "$1" ]; then
+    mkdir -p "$LOGS$(dirname $1)"
+    cp -r "$1" "$LOGS$1"
+  fi
+}
+collect /var/log/azure
+collect /var/log/cloud-init.log
+collect /var/log/cloud-init-output.log
+collect /var/log/waagent.log
+collect /etc/kubernetes/azure.json
+mkdir -p $LOGS/journal
+for unit in kubelet docker etcd; do
+  if systemctl cat $unit >/dev/null 2>&1; then
+    journalctl -u $unit --no-pager > $LOGS/journal/$unit.log
+  fi
+done
+python3 - "$LOGS" <<'EOF'
+import json, os, sys
+root = sys.argv[1]
+path = os.path.join(root, "etc/kubernetes/azure.json")
+secrets = []
+if os.path.exists(path):
+    try:
+        with open(path) as f:
+            config = json.load(f)
+    except ValueError:
+        os.remove(path)
+    else:
+        for key, value in config.items():
+            if ("secret" in key.lower() or "password" in key.lower()) and isinstance(value, str) and value:
+                secrets += [value, json.dumps(value)[1:-1]]
+                config[key] = "<redacted>"
+        with open(path, "w") as f:
+            json.dump(config, f, indent=4, sort_keys=True)
+for dirpath, _, filenames in os.walk(root):
+    for name in filenames:
+        p = os.path.join(dirpath, name)
+        with open(p, "rb") as f:
+            content = f.read()
+        scrubbed = content
+        for secret in secrets:
+            scrubbed = scrubbed.replace(secret.encode(), b"<redacted>")
+        if scrubbed != content:
+            with open(p, "wb") as f:
+                f.write(scrubbed)
+EOF
+tar -czf - -C $LOGS .
+`
+
+// collectCmd runs collectScript, read from stdin
+const collectCmd = "sudo bash -s"
+
+// LogsCollector collects the diagnostic logs of the nodes of a Kubernetes cluster over SSH. The masters are reached
+// through the master load balancer, and the Linux agents through a master, at the private IP address of their
+// network interface in Azure Resource Manager.
+type LogsCollector struct {
+	Logger     *logrus.Entry
+	MasterFQDN string
+	// SSHPorts are the ports of the master load balancer forwarding SSH to each master, in master order
+	SSHPorts []int
+	// MasterVMPrefix is the name of the master VMs without their index
+	MasterVMPrefix string
+	// Client lists the agent VMs and scale sets of AgentPools in ResourceGroup
+	Client        armhelpers.AKSEngineClient
+	ResourceGroup string
+	AgentPools    []AgentPool
+	SSHUser       string
+	SSHKey        []byte
+	// KnownHostsFile is the known_hosts file the host keys of the nodes are verified against, they are not verified if empty
+	KnownHostsFile string
+	// RemoteRun runs a command on a master over SSH, it defaults to operations.RemoteRunWithIO
+	RemoteRun func(user string, addr string, port int, sshKey []byte, knownHostsFile string, cmd string, stdin io.Reader, stdout io.Writer) error
+	// RemoteRunThroughHost runs a command on an agent over SSH through a master, it defaults to operations.RemoteRunThroughHostWithIO
+	RemoteRunThroughHost func(user string, hostAddr string, hostPort int, addr string, sshKey []byte, knownHostsFile string, cmd string, stdin io.Reader, stdout io.Writer) error
+}
+
+// AgentPool is an agent pool of the cluster
+type AgentPool struct {
+	// VMPrefix is the name of the scale set of the agent pool, or the name of its VMs without their index
+	VMPrefix                  string
+	IsVirtualMachineScaleSets bool
+	IsWindows                 bool
+}
+
+// Collect collects the logs of every master and Linux agent as a gzipped tarball, and calls save with the name of the
+// node and its logs. The logs of Windows agents are not collected, the nodes skipped are logged at the end.
+// The nodes that fail do not stop the collection, they are reported in the error returned at the end.
+func (c *LogsCollector) Collect(save func(node string, logs []byte) error) error {
+	failed := []string{}
+	collect := func(node string, run func(stdout io.Writer) error) bool {
+		c.Logger.Infof("Collecting logs from node %s", node)
+		var logs bytes.Buffer
+		if err := run(&logs); err != nil {
+			c.Logger.Warnf("collecting logs from node %s: %v", node, err)
+			failed = append(failed, node)
+			return false
+		}
+		if err := save(node, logs.Bytes()); err != nil {
+			c.Logger.Warnf("saving logs of node %s: %v", node, err)
+			failed = append(failed, node)
+		}
+		return true
+	}
+
+	// the agents are reached through the first master reached
+	hostPort := 0
+	for i, port := range c.SSHPorts {
+		port := port
+		reached := collect(fmt.Sprintf("%s%d", c.MasterVMPrefix, i), func(stdout io.Writer) error {
+			return c.remoteRun()(c.SSHUser, c.MasterFQDN, port, c.SSHKey, c.KnownHostsFile, collectCmd, strings.NewReader(collectScript), stdout)
+		})
+		if reached && hostPort == 0 {
+			hostPort = port
+		}
+	}
+
+	agents, skipped, err := c.listAgents()
+	if err != nil {
+		c.Logger.Warnf("the logs of the agents are not collected: %v", err)
+		failed = append(failed, "agents")
+	}
+	for _, agent := range agents {
+		agent := agent
+		collect(agent.Name, func(stdout io.Writer) error {
+			if agent.Err != nil {
+				return agent.Err
+			}
+			if hostPort == 0 {
+				return errors.New("no master could be reached")
+			}
+			return c.remoteRunThroughHost()(c.SSHUser, c.MasterFQDN, hostPort, agent.Address, c.SSHKey, c.KnownHostsFile, collectCmd, strings.NewReader(collectScript), stdout)
+		})
+	}
+
+	if len(skipped) > 0 {
+		c.Logger.Infof("The logs of these Windows nodes are not collected: %s", strings.Join(skipped, ", "))
+	}
+	if len(failed) > 0 {
+		return errors.Errorf("collecting logs failed for %s", strings.Join(failed, ", "))
+	}
+	return nil
+}
+
+// agent is a Linux agent VM, reached at the private IP address of its primary network interface
+type agent struct {
+	Name    string
+	Address string
+	// Err is the error looking up the address of the agent
+	Err error
+}
+
+// listAgents lists the VMs of the agent pools from Azure Resource Manager. The Linux agents are returned with their
+// address, and the names of the Windows agents are returned apart.
+func (c *LogsCollector) listAgents() ([]agent, []string, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), armhelpers.DefaultARMOperationTimeout)
+	defer cancel()
+
+	agents := []agent{}
+	windowsAgents := []string{}
+	vmPage, err := c.Client.ListVirtualMachines(ctx, c.ResourceGroup)
+	for err == nil && vmPage.NotDone() {
+		for _, vm := range vmPage.Values() {
+			name := to.String(vm.Name)
+			pool := c.getAgentPool(name, false)
+			if pool == nil {
+				continue
+			}
+			if pool.IsWindows {
+				windowsAgents = append(windowsAgents, name)
+				continue
+			}
+			var networkProfile *compute.NetworkProfile
+			if vm.VirtualMachineProperties != nil {
+				networkProfile = vm.NetworkProfile
+			}
+			a := agent{Name: name}
+			a.Address, a.Err = c.getPrivateIPAddress(ctx, "", "", networkProfile)
+			agents = append(agents, a)
+		}
+		err = vmPage.Next()
+	}
+	if err != nil {
+		return agents, windowsAgents, errors.Wrap(err, "listing the VMs of the cluster")
+	}
+
+	vmssPage, err := c.Client.ListVirtualMachineScaleSets(ctx, c.ResourceGroup)
+	for err == nil && vmssPage.NotDone() {
+		for _, vmss := range vmssPage.Values() {
+			vmssName := to.String(vmss.Name)
+			pool := c.getAgentPool(vmssName, true)
+			if pool == nil {
+				continue
+			}
+			var vmPage armhelpers.VirtualMachineScaleSetVMListResultPage
+			vmPage, err = c.Client.ListVirtualMachineScaleSetVMs(ctx, c.ResourceGroup, vmssName)
+			for err == nil && vmPage.NotDone() {
+				for _, vm := range vmPage.Values() {
+					// the nodes of scale sets are named after the computer name of their VM
+					name := to.String(vm.Name)
+					var networkProfile *compute.NetworkProfile
+					if vm.VirtualMachineScaleSetVMProperties != nil {
+						if vm.OsProfile != nil && vm.OsProfile.ComputerName != nil {
+							name = *vm.OsProfile.ComputerName
+						}
+						networkProfile = vm.NetworkProfile
+					}
+					if pool.IsWindows {
+						windowsAgents = append(windowsAgents, name)
+						continue
+					}
+					a := agent{Name: name}
+					a.Address, a.Err = c.getPrivateIPAddress(ctx, vmssName, to.String(vm.InstanceID), networkProfile)
+					agents = append(agents, a)
+				}
+				err = vmPage.NextWithContext(ctx)
+			}
+			if err != nil {
+				return agents, windowsAgents, errors.Wrapf(err, "listing the VMs of scale set %s", vmssName)
+			}
+		}
+		err = vmssPage.NextWithContext(ctx)
+	}
+	if err != nil {
+		return agents, windowsAgents, errors.Wrap(err, "listing the scale sets of the cluster")
+	}
+	return agents, windowsAgents, nil
+}
+
+// getAgentPool returns the agent pool of a VM or scale set, nil if it does not belong to an agent pool of the cluster
+func (c *LogsCollector) getAgentPool(name string, isVirtualMachineScaleSet bool) *AgentPool {
+	for i, pool := range c.AgentPools {
+		if pool.IsVirtualMachineScaleSets != isVirtualMachineScaleSet {
+			continue
+		}
+		if name == pool.VMPrefix || !isVirtualMachineScaleSet && strings.HasPrefix(name, pool.VMPrefix) {
+			return &c.AgentPools[i]
+		}
+	}
+	return nil
+}
+
+// getPrivateIPAddress returns the private IP address of the primary network interface of a VM, or of a VM of
+// a scale set if virtualMachineScaleSet is set
+func (c *LogsCollector) getPrivateIPAddress(ctx context.Context, virtualMachineScaleSet, instanceID string, networkProfile *compute.NetworkProfile) (string, error) {
+	var nicID string
+	if networkProfile != nil && networkProfile.NetworkInterfaces != nil {
+		for _, nic := range *networkProfile.NetworkInterfaces {
+			// the primary network interface is the only one if it is not flagged
+			if nicID == "" || nic.NetworkInterfaceReferenceProperties != nil && to.Bool(nic.Primary) {
+				nicID = to.String(nic.ID)
+			}
+		}
+	}
+	if nicID == "" {
+		return "", errors.New("the VM has no network interface")
+	}
+	nicName := nicID[strings.LastIndex(nicID, "/")+1:]
+
+	var nic network.Interface
+	var err error
+	if virtualMachineScaleSet != "" {
+		nic, err = c.Client.GetVirtualMachineScaleSetNetworkInterface(ctx, c.ResourceGroup, virtualMachineScaleSet, instanceID, nicName)
+	} else {
+		nic, err = c.Client.GetNetworkInterface(ctx, c.ResourceGroup, nicName)
+	}
+	if err != nil {
+		return "", errors.Wrapf(err, "getting network interface %s", nicName)
+	}
+	if nic.InterfacePropertiesFormat != nil && nic.IPConfigurations != nil {
+		for _, ipConfig := range *nic.IPConfigurations {
+			if ipConfig.InterfaceIPConfigurationPropertiesFormat != nil && to.Bool(ipConfig.Primary) && to.String(ipConfig.PrivateIPAddress) != "" {
+				return *ipConfig.PrivateIPAddress, nil
+			}
+		}
+	}
+	return "", errors.Errorf("network interface %s has no primary private IP address", nicName)
+}
+
+func (c *LogsCollector) remoteRun() func(user string, addr string, port int, sshKey []byte, knownHostsFile string, cmd string, stdin io.Reader, stdout io.Writer) error {
+	if c.RemoteRun == nil {
+		return operations.RemoteRunWithIO
+	}
+	return c.RemoteRun
+}
+
+func (c *LogsCollector) remoteRunThroughHost() func(user string, hostAddr string, hostPort int, addr string, sshKey []byte, knownHostsFile string, cmd string, stdin io.Reader, stdout io.Writer) error {
+	if c.RemoteRunThroughHost == nil {
+		return operations.RemoteRunThroughHostWithIO
+	}
+	return c.RemoteRunThroughHost
+}
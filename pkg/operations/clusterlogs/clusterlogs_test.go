@@ -0,0 +1,231 @@
+// Copyright (c) Microsoft Corporation. All rights reserved.
+// Licensed under the MIT license.
+
+package clusterlogs
+
+import (
+	"bytes"
+	"io"
+	"io/ioutil"
+	"testing"
+
+	"github.com/Azure/aks-engine/pkg/armhelpers"
+	"github.com/Azure/aks-engine/pkg/operations"
+	"github.com/Azure/azure-sdk-for-go/services/compute/mgmt/2018-10-01/compute"
+	"github.com/Azure/azure-sdk-for-go/services/network/mgmt/2018-08-01/network"
+	"github.com/Azure/go-autorest/autorest/to"
+	. "github.com/onsi/gomega"
+	"github.com/pkg/errors"
+	log "github.com/sirupsen/logrus"
+)
+
+const nicIDPrefix = "/subscriptions/SUB_ID/resourceGroups/RG_NAME/providers/Microsoft.Network/networkInterfaces/"
+
+// privateIPAddresses are the private IP addresses of the network interfaces of the fake cluster, by name
+var privateIPAddresses = map[string]string{
+	"k8s-agentpool1-12345678-nic-0": "10.240.0.4",
+	"k8s-agentpool1-12345678-nic-1": "10.240.0.6",
+	"1234k8s010-nic-0":              "10.240.0.5",
+	"k8s-agentpool3-12345678-vmss":  "10.240.0.7",
+}
+
+func makeVM(name, nicName string, osType compute.OperatingSystemTypes) compute.VirtualMachine {
+	return compute.VirtualMachine{
+		Name: to.StringPtr(name),
+		VirtualMachineProperties: &compute.VirtualMachineProperties{
+			StorageProfile: &compute.StorageProfile{
+				OsDisk: &compute.OSDisk{OsType: osType},
+			},
+			NetworkProfile: &compute.NetworkProfile{
+				NetworkInterfaces: &[]compute.NetworkInterfaceReference{
+					{ID: to.StringPtr(nicIDPrefix + nicName)},
+				},
+			},
+		},
+	}
+}
+
+type fakeCluster struct {
+	// failMasters are the ports of the masters failing every command
+	failMasters map[int]bool
+	// failAgents are the addresses of the agents failing every command
+	failAgents map[string]bool
+	// agents are the agent addresses reached, with the port of the master they are reached through
+	agents map[string]int
+	client *armhelpers.MockAKSEngineClient
+	logs   bytes.Buffer
+}
+
+func newFakeCollector(cluster *fakeCluster, masterCount int) *LogsCollector {
+	cluster.agents = map[string]int{}
+	cluster.client = &armhelpers.MockAKSEngineClient{
+		FakeListVirtualMachineResult: func() []compute.VirtualMachine {
+			return []compute.VirtualMachine{
+				makeVM("k8s-master-12345678-0", "k8s-master-12345678-nic-0", compute.Linux),
+				makeVM("k8s-agentpool1-12345678-0", "k8s-agentpool1-12345678-nic-0", compute.Linux),
+				makeVM("1234k8s010", "1234k8s010-nic-0", compute.Windows),
+				makeVM("k8s-agentpool1-12345678-1", "k8s-agentpool1-12345678-nic-1", compute.Linux),
+				// an agent of another cluster in the resource group
+				makeVM("k8s-agentpool1-87654321-0", "k8s-agentpool1-87654321-nic-0", compute.Linux),
+			}
+		},
+		FakeListVirtualMachineScaleSetsResult: func() []compute.VirtualMachineScaleSet {
+			return []compute.VirtualMachineScaleSet{{Name: to.StringPtr("k8s-agentpool3-12345678-vmss")}}
+		},
+		FakeListVirtualMachineScaleSetVMsResult: func() []compute.VirtualMachineScaleSetVM {
+			return []compute.VirtualMachineScaleSetVM{
+				{
+					Name:       to.StringPtr("k8s-agentpool3-12345678-vmss_0"),
+					InstanceID: to.StringPtr("0"),
+					VirtualMachineScaleSetVMProperties: &compute.VirtualMachineScaleSetVMProperties{
+						OsProfile: &compute.OSProfile{ComputerName: to.StringPtr("k8s-agentpool3-12345678-vmss000000")},
+						NetworkProfile: &compute.NetworkProfile{
+							NetworkInterfaces: &[]compute.NetworkInterfaceReference{
+								{ID: to.StringPtr("/subscriptions/SUB_ID/resourceGroups/RG_NAME/providers/Microsoft.Compute/virtualMachineScaleSets/k8s-agentpool3-12345678-vmss/virtualMachines/0/networkInterfaces/k8s-agentpool3-12345678-vmss")},
+							},
+						},
+					},
+				},
+			}
+		},
+		FakeGetNetworkInterfaceResult: func(virtualMachineScaleSet, instanceID, nicName string) network.Interface {
+			return armhelpers.MakeFakeNetworkInterface(nicName, privateIPAddresses[nicName])
+		},
+	}
+	logger := log.New()
+	logger.Out = &cluster.logs
+	return &LogsCollector{
+		Logger:         log.NewEntry(logger),
+		MasterFQDN:     "testcluster.westus2.cloudapp.azure.com",
+		SSHPorts:       operations.MasterSSHPorts(masterCount),
+		MasterVMPrefix: "k8s-master-12345678-",
+		Client:         cluster.client,
+		ResourceGroup:  "RG_NAME",
+		AgentPools: []AgentPool{
+			{VMPrefix: "k8s-agentpool1-12345678-"},
+			{VMPrefix: "1234k8s01", IsWindows: true},
+			{VMPrefix: "k8s-agentpool3-12345678-vmss", IsVirtualMachineScaleSets: true},
+		},
+		SSHUser: "azureuser",
+		RemoteRun: func(user string, addr string, port int, sshKey []byte, knownHostsFile string, cmd string, stdin io.Reader, stdout io.Writer) error {
+			if cluster.failMasters[port] {
+				return errors.New("unreachable")
+			}
+			b, _ := ioutil.ReadAll(stdin)
+			if cmd != collectCmd || string(b) != collectScript {
+				return errors.New("unexpected script")
+			}
+			io.WriteString(stdout, "logs")
+			return nil
+		},
+		RemoteRunThroughHost: func(user string, hostAddr string, hostPort int, addr string, sshKey []byte, knownHostsFile string, cmd string, stdin io.Reader, stdout io.Writer) error {
+			cluster.agents[addr] = hostPort
+			if cluster.failAgents[addr] {
+				return errors.New("unreachable")
+			}
+			io.WriteString(stdout, "logs of "+addr)
+			return nil
+		},
+	}
+}
+
+func TestCollect(t *testing.T) {
+	g := NewGomegaWithT(t)
+	cluster := &fakeCluster{}
+	saved := map[string]string{}
+
+	err := newFakeCollector(cluster, 2).Collect(func(node string, logs []byte) error {
+		saved[node] = string(logs)
+		return nil
+	})
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(saved).To(Equal(map[string]string{
+		"k8s-master-12345678-0":              "logs",
+		"k8s-master-12345678-1":              "logs",
+		"k8s-agentpool1-12345678-0":          "logs of 10.240.0.4",
+		"k8s-agentpool1-12345678-1":          "logs of 10.240.0.6",
+		"k8s-agentpool3-12345678-vmss000000": "logs of 10.240.0.7",
+	}))
+	g.Expect(cluster.agents).To(Equal(map[string]int{"10.240.0.4": 22, "10.240.0.6": 22, "10.240.0.7": 22}))
+	g.Expect(cluster.logs.String()).To(ContainSubstring("The logs of these Windows nodes are not collected: 1234k8s010"))
+}
+
+func TestCollectShouldContinueWhenNodesFail(t *testing.T) {
+	g := NewGomegaWithT(t)
+	cluster := &fakeCluster{
+		failMasters: map[int]bool{22: true},
+		failAgents:  map[string]bool{"10.240.0.4": true},
+	}
+	saved := []string{}
+
+	err := newFakeCollector(cluster, 2).Collect(func(node string, logs []byte) error {
+		saved = append(saved, node)
+		return nil
+	})
+	g.Expect(err).To(HaveOccurred())
+	g.Expect(err.Error()).To(Equal("collecting logs failed for k8s-master-12345678-0, k8s-agentpool1-12345678-0"))
+	g.Expect(saved).To(Equal([]string{"k8s-master-12345678-1", "k8s-agentpool1-12345678-1", "k8s-agentpool3-12345678-vmss000000"}))
+	// the agents are reached through the first master reached
+	g.Expect(cluster.agents).To(Equal(map[string]int{"10.240.0.4": 2201, "10.240.0.6": 2201, "10.240.0.7": 2201}))
+}
+
+func TestCollectShouldReportAgentsWhenNoMasterIsReached(t *testing.T) {
+	g := NewGomegaWithT(t)
+	cluster := &fakeCluster{
+		failMasters: map[int]bool{22: true},
+	}
+
+	err := newFakeCollector(cluster, 1).Collect(func(node string, logs []byte) error {
+		return nil
+	})
+	g.Expect(err).To(HaveOccurred())
+	g.Expect(err.Error()).To(Equal("collecting logs failed for k8s-master-12345678-0, k8s-agentpool1-12345678-0, k8s-agentpool1-12345678-1, k8s-agentpool3-12345678-vmss000000"))
+	g.Expect(cluster.agents).To(BeEmpty())
+}
+
+func TestCollectShouldReportAgentsWhenVMsCannotBeListed(t *testing.T) {
+	g := NewGomegaWithT(t)
+	cluster := &fakeCluster{}
+	collector := newFakeCollector(cluster, 1)
+	cluster.client.FailListVirtualMachines = true
+
+	err := collector.Collect(func(node string, logs []byte) error {
+		return nil
+	})
+	g.Expect(err).To(HaveOccurred())
+	g.Expect(err.Error()).To(Equal("collecting logs failed for agents"))
+	g.Expect(cluster.agents).To(BeEmpty())
+}
+
+func TestCollectShouldReportAgentsWithoutAddress(t *testing.T) {
+	g := NewGomegaWithT(t)
+	cluster := &fakeCluster{}
+	collector := newFakeCollector(cluster, 1)
+	cluster.client.FakeGetNetworkInterfaceResult = func(virtualMachineScaleSet, instanceID, nicName string) network.Interface {
+		if virtualMachineScaleSet != "" {
+			return network.Interface{Name: to.StringPtr(nicName)}
+		}
+		return armhelpers.MakeFakeNetworkInterface(nicName, privateIPAddresses[nicName])
+	}
+
+	err := collector.Collect(func(node string, logs []byte) error {
+		return nil
+	})
+	g.Expect(err).To(HaveOccurred())
+	g.Expect(err.Error()).To(Equal("collecting logs failed for k8s-agentpool3-12345678-vmss000000"))
+	g.Expect(cluster.logs.String()).To(ContainSubstring("network interface k8s-agentpool3-12345678-vmss has no primary private IP address"))
+	g.Expect(cluster.agents).To(Equal(map[string]int{"10.240.0.4": 22, "10.240.0.6": 22}))
+}
+
+func TestCollectShouldReportNodesFailingToSave(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	err := newFakeCollector(&fakeCluster{}, 1).Collect(func(node string, logs []byte) error {
+		if node == "k8s-agentpool1-12345678-1" {
+			return errors.New("disk full")
+		}
+		return nil
+	})
+	g.Expect(err).To(HaveOccurred())
+	g.Expect(err.Error()).To(Equal("collecting logs failed for k8s-agentpool1-12345678-1"))
+}
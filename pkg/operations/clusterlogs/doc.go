@@ -0,0 +1,5 @@
+// Copyright (c) Microsoft Corporation. All rights reserved.
+// Licensed under the MIT license.
+
+// Package clusterlogs collects the diagnostic logs of the nodes of Kubernetes clusters.
+package clusterlogs
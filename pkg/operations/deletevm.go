@@ -9,6 +9,7 @@ import (
 
 	"github.com/Azure/aks-engine/pkg/armhelpers"
 	"github.com/Azure/aks-engine/pkg/armhelpers/utils"
+	"github.com/Azure/azure-sdk-for-go/services/compute/mgmt/2018-10-01/compute"
 	azStorage "github.com/Azure/azure-sdk-for-go/storage"
 	"github.com/pkg/errors"
 	log "github.com/sirupsen/logrus"
@@ -39,6 +40,8 @@ func CleanDeleteVirtualMachine(az armhelpers.AKSEngineClient, logger *log.Entry,
 	}
 
 	osDiskName := vm.VirtualMachineProperties.StorageProfile.OsDisk.Name
+	diffDiskSettings := vm.VirtualMachineProperties.StorageProfile.OsDisk.DiffDiskSettings
+	isEphemeralOSDisk := diffDiskSettings != nil && diffDiskSettings.Option == compute.Local
 
 	var nicName string
 	nicID := (*vm.VirtualMachineProperties.NetworkProfile.NetworkInterfaces)[0].ID
@@ -85,7 +88,10 @@ func CleanDeleteVirtualMachine(az armhelpers.AKSEngineClient, logger *log.Entry,
 			return err
 		}
 	} else if managedDisk != nil {
-		if osDiskName == nil {
+		if isEphemeralOSDisk {
+			// ephemeral OS disks live on the VM cache and are deleted along with the VM
+			logger.Infof("skipping deletion of ephemeral os disk for VM %s/%s", resourceGroup, name)
+		} else if osDiskName == nil {
 			logger.Warnf("osDisk is not set for VM %s/%s", resourceGroup, name)
 		} else {
 			logger.Infof("deleting managed disk: %s/%s", resourceGroup, *osDiskName)
@@ -0,0 +1,5 @@
+// Copyright (c) Microsoft Corporation. All rights reserved.
+// Licensed under the MIT license.
+
+// Package etcdbackup takes and restores snapshots of the etcd cluster of Kubernetes clusters.
+package etcdbackup
@@ -0,0 +1,175 @@
+// Copyright (c) Microsoft Corporation. All rights reserved.
+// Licensed under the MIT license.
+
+package etcdbackup
+
+import (
+	"bytes"
+	"io"
+	"strings"
+	"time"
+
+	"github.com/Azure/aks-engine/pkg/operations"
+	"github.com/pkg/errors"
+	"github.com/sirupsen/logrus"
+)
+
+const (
+	remoteSnapshotPath = "/tmp/etcd-snapshot.db"
+	etcdctl            = "ETCDCTL_API=3 etcdctl --endpoints=https://127.0.0.1:2379 --cacert=/etc/kubernetes/certs/ca.crt --cert=/etc/kubernetes/certs/etcdclient.crt --key=/etc/kubernetes/certs/etcdclient.key"
+	healthCheckRetries = 30
+)
+
+var healthCheckInterval = 10 * time.Second
+
+// stopAPIServerScript stops the kube-apiserver static pod of a master by moving its manifest out of the kubelet
+// manifests directory, and waits for kube-apiserver to exit. It is read from stdin, so that its own command line
+// does not match the kube-apiserver process.
+var stopAPIServerScript = `set -e
+if [ -f /etc/kubernetes/manifests/kube-apiserver.yaml ]; then
+  mv /etc/kubernetes/manifests/kube-apiserver.yaml /etc/kubernetes/kube-apiserver.yaml.before-restore
+fi
+for i in $(seq 1 60); do
+  if ! pgrep -f "[k]ube-apiserver " >/dev/null; then
+    exit 0
+  fi
+  sleep 5
+done
+echo "kube-apiserver is still running" >&2
+exit 1
+`
+
+// startAPIServerScript puts back the kube-apiserver manifest moved by stopAPIServerScript
+var startAPIServerScript = `set -e
+if [ -f /etc/kubernetes/kube-apiserver.yaml.before-restore ]; then
+  mv /etc/kubernetes/kube-apiserver.yaml.before-restore /etc/kubernetes/manifests/kube-apiserver.yaml
+fi
+`
+
+// restoreScript rebuilds the etcd data directory of a master from the snapshot at remoteSnapshotPath,
+// using the member settings of /etc/default/etcd. The previous data is kept in member.before-restore.
+var restoreScript = `set -e
+eval "set -- $(sed -n 's/^DAEMON_ARGS=//p' /etc/default/etcd)"
+while [ $# -gt 0 ]; do
+  case "$1" in
+    --name) NAME="$2"; shift ;;
+    --initial-cluster) INITIAL_CLUSTER="$2"; shift ;;
+    --initial-cluster-token) INITIAL_CLUSTER_TOKEN="$2"; shift ;;
+    --initial-advertise-peer-urls) PEER_URLS="$2"; shift ;;
+    --data-dir) DATA_DIR="$2"; shift ;;
+  esac
+  shift
+done
+rm -rf "${DATA_DIR}.restore"
+ETCDCTL_API=3 etcdctl snapshot restore ` + remoteSnapshotPath + ` --name "$NAME" --initial-cluster "$INITIAL_CLUSTER" --initial-cluster-token "$INITIAL_CLUSTER_TOKEN" --initial-advertise-peer-urls "$PEER_URLS" --data-dir "${DATA_DIR}.restore"
+rm -rf "${DATA_DIR}/member.before-restore"
+if [ -d "${DATA_DIR}/member" ]; then
+  mv "${DATA_DIR}/member" "${DATA_DIR}/member.before-restore"
+fi
+mv "${DATA_DIR}.restore/member" "${DATA_DIR}/member"
+rm -rf "${DATA_DIR}.restore" ` + remoteSnapshotPath + `
+chown -R etcd:etcd "${DATA_DIR}"
+`
+
+// EtcdBackup takes and restores snapshots of the etcd cluster running on the masters of a Kubernetes cluster
+type EtcdBackup struct {
+	Logger     *logrus.Entry
+	MasterFQDN string
+	// SSHPorts are the ports of the master load balancer forwarding SSH to each master, in master order
+	SSHPorts []int
+	SSHUser  string
+	SSHKey   []byte
+	// KnownHostsFile is the known_hosts file the host keys of the masters are verified against, they are not verified if empty
+	KnownHostsFile string
+	// RemoteRun runs a command on a master over SSH, it defaults to operations.RemoteRunWithIO
+	RemoteRun func(user string, addr string, port int, sshKey []byte, knownHostsFile string, cmd string, stdin io.Reader, stdout io.Writer) error
+}
+
+// Save takes a snapshot of etcd from the first healthy member and returns it
+func (b *EtcdBackup) Save() ([]byte, error) {
+	for i, port := range b.SSHPorts {
+		if err := b.run(port, "sudo bash -c '"+etcdctl+" endpoint health'", nil, nil); err != nil {
+			b.Logger.Warnf("etcd member on master %d is not healthy: %v", i, err)
+			continue
+		}
+		b.Logger.Infof("Taking a snapshot of etcd from master %d", i)
+		var snapshot bytes.Buffer
+		cmd := "sudo bash -c '" + etcdctl + " snapshot save " + remoteSnapshotPath + " >/dev/null && cat " + remoteSnapshotPath + "; rc=$?; rm -f " + remoteSnapshotPath + "; exit $rc'"
+		if err := b.run(port, cmd, nil, &snapshot); err != nil {
+			return nil, errors.Wrapf(err, "taking a snapshot of etcd from master %d", i)
+		}
+		return snapshot.Bytes(), nil
+	}
+	return nil, errors.New("no healthy etcd member found")
+}
+
+// Restore rebuilds every etcd member from the snapshot. The snapshot is copied to all the masters and checked
+// before anything is changed, then the API servers and etcd are stopped on all the masters, the members are
+// rebuilt, and the API servers are started again once etcd is healthy.
+func (b *EtcdBackup) Restore(snapshot []byte) error {
+	for i, port := range b.SSHPorts {
+		b.Logger.Infof("Copying the etcd snapshot to master %d", i)
+		if err := b.run(port, "sudo bash -c 'cat > "+remoteSnapshotPath+"'", bytes.NewReader(snapshot), nil); err != nil {
+			return errors.Wrapf(err, "copying the snapshot to master %d", i)
+		}
+		if err := b.run(port, "sudo bash -c 'ETCDCTL_API=3 etcdctl snapshot status "+remoteSnapshotPath+"'", nil, nil); err != nil {
+			return errors.Wrapf(err, "checking the snapshot on master %d", i)
+		}
+	}
+	for i, port := range b.SSHPorts {
+		b.Logger.Infof("Stopping the API server on master %d", i)
+		if err := b.run(port, "sudo bash -s", strings.NewReader(stopAPIServerScript), nil); err != nil {
+			return errors.Wrapf(err, "stopping the API server on master %d", i)
+		}
+	}
+	for i, port := range b.SSHPorts {
+		b.Logger.Infof("Stopping etcd on master %d", i)
+		if err := b.run(port, "sudo systemctl stop etcd", nil, nil); err != nil {
+			return errors.Wrapf(err, "stopping etcd on master %d", i)
+		}
+	}
+	for i, port := range b.SSHPorts {
+		b.Logger.Infof("Restoring the etcd snapshot on master %d", i)
+		if err := b.run(port, "sudo bash -s", strings.NewReader(restoreScript), nil); err != nil {
+			return errors.Wrapf(err, "restoring the snapshot on master %d", i)
+		}
+	}
+	// the members wait for each other to elect a leader, so none of them is waited for on start
+	for i, port := range b.SSHPorts {
+		b.Logger.Infof("Starting etcd on master %d", i)
+		if err := b.run(port, "sudo systemctl start --no-block etcd", nil, nil); err != nil {
+			return errors.Wrapf(err, "starting etcd on master %d", i)
+		}
+	}
+
+	if err := b.waitForHealthyEtcd(); err != nil {
+		return err
+	}
+	for i, port := range b.SSHPorts {
+		b.Logger.Infof("Starting the API server on master %d", i)
+		if err := b.run(port, "sudo bash -s", strings.NewReader(startAPIServerScript), nil); err != nil {
+			return errors.Wrapf(err, "starting the API server on master %d", i)
+		}
+	}
+	return nil
+}
+
+func (b *EtcdBackup) waitForHealthyEtcd() error {
+	var err error
+	for retry := 0; retry < healthCheckRetries; retry++ {
+		if err = b.run(b.SSHPorts[0], "sudo bash -c '"+etcdctl+" endpoint health'", nil, nil); err == nil {
+			b.Logger.Infof("etcd is healthy")
+			return nil
+		}
+		time.Sleep(healthCheckInterval)
+	}
+	return errors.Wrap(err, "waiting for etcd to be healthy")
+}
+
+func (b *EtcdBackup) run(port int, cmd string, stdin io.Reader, stdout io.Writer) error {
+	remoteRun := b.RemoteRun
+	if remoteRun == nil {
+		remoteRun = operations.RemoteRunWithIO
+	}
+	return remoteRun(b.SSHUser, b.MasterFQDN, port, b.SSHKey, b.KnownHostsFile, cmd, stdin, stdout)
+}
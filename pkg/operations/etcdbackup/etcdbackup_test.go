@@ -0,0 +1,123 @@
+// Copyright (c) Microsoft Corporation. All rights reserved.
+// Licensed under the MIT license.
+
+package etcdbackup
+
+import (
+	"io"
+	"io/ioutil"
+	"strings"
+	"testing"
+
+	"github.com/Azure/aks-engine/pkg/operations"
+	. "github.com/onsi/gomega"
+	"github.com/pkg/errors"
+	log "github.com/sirupsen/logrus"
+)
+
+type fakeMaster struct {
+	healthy         bool
+	corruptSnapshot bool
+	commands        []string
+	stdin           []string
+}
+
+func newFakeBackup(masters ...*fakeMaster) *EtcdBackup {
+	return &EtcdBackup{
+		Logger:     log.NewEntry(log.New()),
+		MasterFQDN: "testcluster.westus2.cloudapp.azure.com",
+		SSHPorts:   operations.MasterSSHPorts(len(masters)),
+		SSHUser:    "azureuser",
+		RemoteRun: func(user string, addr string, port int, sshKey []byte, knownHostsFile string, cmd string, stdin io.Reader, stdout io.Writer) error {
+			master := masters[0]
+			if port != 22 {
+				master = masters[port-2200]
+			}
+			master.commands = append(master.commands, cmd)
+			if stdin != nil {
+				b, _ := ioutil.ReadAll(stdin)
+				master.stdin = append(master.stdin, string(b))
+			}
+			if strings.Contains(cmd, "endpoint health") && !master.healthy {
+				return errors.New("unhealthy")
+			}
+			if strings.Contains(cmd, "snapshot status") && master.corruptSnapshot {
+				return errors.New("invalid snapshot")
+			}
+			if strings.Contains(cmd, "snapshot save") {
+				io.WriteString(stdout, "snapshot")
+			}
+			return nil
+		},
+	}
+}
+
+func TestSave(t *testing.T) {
+	g := NewGomegaWithT(t)
+	unhealthy, healthy, other := &fakeMaster{}, &fakeMaster{healthy: true}, &fakeMaster{healthy: true}
+
+	snapshot, err := newFakeBackup(unhealthy, healthy, other).Save()
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(string(snapshot)).To(Equal("snapshot"))
+	g.Expect(unhealthy.commands).To(HaveLen(1))
+	g.Expect(healthy.commands).To(HaveLen(2))
+	g.Expect(healthy.commands[1]).To(ContainSubstring("snapshot save /tmp/etcd-snapshot.db"))
+	g.Expect(other.commands).To(BeEmpty())
+
+	_, err = newFakeBackup(&fakeMaster{}).Save()
+	g.Expect(err).To(HaveOccurred())
+	g.Expect(err.Error()).To(Equal("no healthy etcd member found"))
+}
+
+func TestRestore(t *testing.T) {
+	g := NewGomegaWithT(t)
+	masters := []*fakeMaster{{healthy: true}, {healthy: true}, {healthy: true}}
+
+	err := newFakeBackup(masters...).Restore([]byte("snapshot"))
+	g.Expect(err).NotTo(HaveOccurred())
+	for i, master := range masters {
+		g.Expect(master.commands[0]).To(Equal("sudo bash -c 'cat > /tmp/etcd-snapshot.db'"))
+		g.Expect(master.commands[1]).To(Equal("sudo bash -c 'ETCDCTL_API=3 etcdctl snapshot status /tmp/etcd-snapshot.db'"))
+		g.Expect(master.commands[2]).To(Equal("sudo bash -s"))
+		g.Expect(master.commands[3]).To(Equal("sudo systemctl stop etcd"))
+		g.Expect(master.commands[4]).To(Equal("sudo bash -s"))
+		g.Expect(master.commands[5]).To(Equal("sudo systemctl start --no-block etcd"))
+		g.Expect(master.stdin).To(Equal([]string{"snapshot", stopAPIServerScript, restoreScript, startAPIServerScript}))
+		if i == 0 {
+			g.Expect(master.commands).To(HaveLen(8))
+			g.Expect(master.commands[6]).To(ContainSubstring("endpoint health"))
+			g.Expect(master.commands[7]).To(Equal("sudo bash -s"))
+		} else {
+			g.Expect(master.commands).To(HaveLen(7))
+			g.Expect(master.commands[6]).To(Equal("sudo bash -s"))
+		}
+	}
+}
+
+func TestRestoreShouldNotChangeAnythingIfTheSnapshotIsInvalid(t *testing.T) {
+	g := NewGomegaWithT(t)
+	masters := []*fakeMaster{{healthy: true}, {healthy: true, corruptSnapshot: true}, {healthy: true}}
+
+	err := newFakeBackup(masters...).Restore([]byte("snapshot"))
+	g.Expect(err).To(HaveOccurred())
+	g.Expect(err.Error()).To(ContainSubstring("checking the snapshot on master 1"))
+	for _, master := range masters {
+		for _, cmd := range master.commands {
+			g.Expect(cmd).NotTo(ContainSubstring("systemctl stop"))
+		}
+		g.Expect(master.stdin).NotTo(ContainElement(stopAPIServerScript))
+	}
+}
+
+func TestRestoreShouldFailIfEtcdIsNotHealthy(t *testing.T) {
+	g := NewGomegaWithT(t)
+	healthCheckInterval = 0
+
+	master := &fakeMaster{}
+
+	err := newFakeBackup(master).Restore([]byte("snapshot"))
+	g.Expect(err).To(HaveOccurred())
+	g.Expect(err.Error()).To(ContainSubstring("waiting for etcd to be healthy"))
+	// the API servers stay stopped rather than serving an unhealthy etcd
+	g.Expect(master.stdin).NotTo(ContainElement(startAPIServerScript))
+}
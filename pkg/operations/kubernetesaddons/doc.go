@@ -0,0 +1,5 @@
+// Copyright (c) Microsoft Corporation. All rights reserved.
+// Licensed under the MIT license.
+
+// Package kubernetesaddons updates the addons running on existing Kubernetes clusters.
+package kubernetesaddons
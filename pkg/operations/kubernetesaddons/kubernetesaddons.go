@@ -0,0 +1,234 @@
+// Copyright (c) Microsoft Corporation. All rights reserved.
+// Licensed under the MIT license.
+
+package kubernetesaddons
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/Azure/aks-engine/pkg/engine"
+	"github.com/Azure/aks-engine/pkg/operations"
+	"github.com/pkg/errors"
+	"github.com/sirupsen/logrus"
+)
+
+const (
+	addonsDir     = "/etc/kubernetes/addons"
+	changedMarker = "changed"
+
+	azureJSONPath        = "/etc/kubernetes/azure.json"
+	aciConnectorCertPath = "/etc/kubernetes/certs/aci-connector-cert.pem"
+	aciConnectorKeyPath  = "/etc/kubernetes/certs/aci-connector-key.pem"
+
+	clusterAutoscalerAddonFile = "cluster-autoscaler-deployment.yaml"
+	aciConnectorAddonFile      = "aci-connector-deployment.yaml"
+)
+
+// azureJSON holds the settings of /etc/kubernetes/azure.json on the masters the placeholders of the addon manifests
+// only known on the masters are resolved from
+type azureJSON struct {
+	ClientID                    string `json:"aadClientId"`
+	ClientSecret                string `json:"aadClientSecret"`
+	SubscriptionID              string `json:"subscriptionId"`
+	TenantID                    string `json:"tenantId"`
+	ResourceGroup               string `json:"resourceGroup"`
+	VMType                      string `json:"vmType"`
+	PrimaryScaleSetName         string `json:"primaryScaleSetName"`
+	UseManagedIdentityExtension bool   `json:"useManagedIdentityExtension"`
+}
+
+// AddonsApplier updates the addons running on a Kubernetes cluster from their manifests, over SSH to the masters
+type AddonsApplier struct {
+	Logger     *logrus.Entry
+	MasterFQDN string
+	// SSHPorts are the ports of the master load balancer forwarding SSH to each master, in master order
+	SSHPorts []int
+	SSHUser  string
+	SSHKey   []byte
+	// KnownHostsFile is the known_hosts file the host keys of the masters are verified against, they are not verified if empty
+	KnownHostsFile string
+	// RemoteRun runs a command on a master over SSH, it defaults to operations.RemoteRunWithIO
+	RemoteRun func(user string, addr string, port int, sshKey []byte, knownHostsFile string, cmd string, stdin io.Reader, stdout io.Writer) error
+}
+
+// Apply removes the manifests of the disabled addons from the masters and deletes their objects, then writes the manifests
+// of the enabled addons to every master and applies the ones that changed
+func (a *AddonsApplier) Apply(manifests []engine.AddonManifest) error {
+	for _, m := range manifests {
+		if !m.Enabled {
+			if err := a.remove(m.File); err != nil {
+				return errors.Wrapf(err, "removing addon %s", m.File)
+			}
+		}
+	}
+
+	changed := []string{}
+	for i, port := range a.SSHPorts {
+		var settings *azureJSON
+		for _, m := range manifests {
+			if !m.Enabled {
+				continue
+			}
+			content := m.Content
+			if file := m.File; file == clusterAutoscalerAddonFile || file == aciConnectorAddonFile {
+				if settings == nil {
+					var err error
+					if settings, err = a.readAzureJSON(port); err != nil {
+						return errors.Wrapf(err, "reading %s on master %d", azureJSONPath, i)
+					}
+				}
+				var err error
+				if content, err = a.resolveMasterPlaceholders(port, file, content, settings); err != nil {
+					return errors.Wrapf(err, "resolving the placeholders of addon %s on master %d", file, i)
+				}
+			}
+			var out bytes.Buffer
+			if err := a.run(port, "sudo bash -c 'cat > "+tmpPath(m.File)+"'", strings.NewReader(content), nil); err != nil {
+				return errors.Wrapf(err, "copying addon %s to master %d", m.File, i)
+			}
+			if err := a.run(port, "sudo bash -s", strings.NewReader(updateScript(m)), &out); err != nil {
+				return errors.Wrapf(err, "updating addon %s on master %d", m.File, i)
+			}
+			if i == 0 && strings.TrimSpace(out.String()) == changedMarker {
+				changed = append(changed, m.File)
+			}
+		}
+	}
+
+	// the addon manager does not update the objects of the EnsureExists addons, they are applied explicitly
+	for _, file := range changed {
+		a.Logger.Infof("Applying addon %s", file)
+		if err := a.run(a.SSHPorts[0], "kubectl apply -f "+addonsDir+"/"+file, nil, nil); err != nil {
+			return errors.Wrapf(err, "applying addon %s", file)
+		}
+	}
+	if len(changed) == 0 {
+		a.Logger.Infof("All the enabled addons are up to date")
+	}
+	return nil
+}
+
+// remove deletes the manifest of a disabled addon from every master, so that no addon manager recreates its objects, then deletes its objects
+func (a *AddonsApplier) remove(file string) error {
+	var manifest bytes.Buffer
+	path := addonsDir + "/" + file
+	if err := a.run(a.SSHPorts[0], fmt.Sprintf("sudo bash -c 'if [ -f %s ]; then cat %s; fi'", path, path), nil, &manifest); err != nil {
+		return errors.Wrap(err, "reading the addon manifest on master 0")
+	}
+	if manifest.Len() == 0 {
+		return nil
+	}
+
+	a.Logger.Infof("Removing addon %s", file)
+	for i, port := range a.SSHPorts {
+		if err := a.run(port, "sudo rm -f "+path, nil, nil); err != nil {
+			return errors.Wrapf(err, "removing the addon manifest from master %d", i)
+		}
+	}
+	return a.run(a.SSHPorts[0], "kubectl delete --ignore-not-found -f -", &manifest, nil)
+}
+
+func (a *AddonsApplier) run(port int, cmd string, stdin io.Reader, stdout io.Writer) error {
+	remoteRun := a.RemoteRun
+	if remoteRun == nil {
+		remoteRun = operations.RemoteRunWithIO
+	}
+	return remoteRun(a.SSHUser, a.MasterFQDN, port, a.SSHKey, a.KnownHostsFile, cmd, stdin, stdout)
+}
+
+// readAzureJSON reads the settings of /etc/kubernetes/azure.json on a master
+func (a *AddonsApplier) readAzureJSON(port int) (*azureJSON, error) {
+	var out bytes.Buffer
+	if err := a.run(port, "sudo cat "+azureJSONPath, nil, &out); err != nil {
+		return nil, err
+	}
+	settings := &azureJSON{}
+	if err := json.Unmarshal(out.Bytes(), settings); err != nil {
+		return nil, errors.Wrapf(err, "parsing %s", azureJSONPath)
+	}
+	return settings, nil
+}
+
+// resolveMasterPlaceholders resolves the placeholders of the cluster-autoscaler and aci-connector manifests the master
+// provisioning script resolves from the settings of the master, to the same values
+func (a *AddonsApplier) resolveMasterPlaceholders(port int, file, content string, settings *azureJSON) (string, error) {
+	var replacements []string
+	switch file {
+	case clusterAutoscalerAddonFile:
+		if settings.UseManagedIdentityExtension {
+			replacements = append(replacements,
+				"<volMounts>", "- mountPath: /var/lib/waagent/\n          name: waagent\n          readOnly: true",
+				"<vols>", "- hostPath:\n          path: /var/lib/waagent/\n        name: waagent",
+				"<hostNet>", "hostNetwork: true")
+		} else {
+			replacements = append(replacements, "<volMounts>", "", "<vols>", "", "<hostNet>", "")
+		}
+		// the provisioning script encodes the output of echo, with a trailing newline
+		encode := func(s string) string {
+			return base64.StdEncoding.EncodeToString([]byte(s + "\n"))
+		}
+		replacements = append(replacements,
+			"<clientID>", encode(settings.ClientID),
+			"<clientSec>", encode(settings.ClientSecret),
+			"<subID>", encode(settings.SubscriptionID),
+			"<tenantID>", encode(settings.TenantID),
+			"<rg>", encode(settings.ResourceGroup),
+			"<vmType>", encode(settings.VMType),
+			"<vmssName>", settings.PrimaryScaleSetName)
+	case aciConnectorAddonFile:
+		credentials := fmt.Sprintf(`{"clientId": "%s", "clientSecret": "%s", "tenantId": "%s", "subscriptionId": "%s", "activeDirectoryEndpointUrl": "https://login.microsoftonline.com","resourceManagerEndpointUrl": "https://management.azure.com/", "activeDirectoryGraphResourceId": "https://graph.windows.net/", "sqlManagementEndpointUrl": "https://management.core.windows.net:8443/", "galleryEndpointUrl": "https://gallery.azure.com/", "managementEndpointUrl": "https://management.core.windows.net/"}`,
+			settings.ClientID, settings.ClientSecret, settings.TenantID, settings.SubscriptionID)
+		cert, key, err := a.readACIConnectorCertificate(port)
+		if err != nil {
+			return "", err
+		}
+		replacements = append(replacements,
+			"<creds>", base64.StdEncoding.EncodeToString([]byte(credentials)),
+			"<rgName>", settings.ResourceGroup,
+			"<cert>", base64.StdEncoding.EncodeToString(cert),
+			"<key>", base64.StdEncoding.EncodeToString(key))
+	}
+	return strings.NewReplacer(replacements...).Replace(content), nil
+}
+
+// readACIConnectorCertificate reads the certificate and key of the aci-connector on a master, creating them first if
+// the addon was not enabled when the master was provisioned
+func (a *AddonsApplier) readACIConnectorCertificate(port int) ([]byte, []byte, error) {
+	create := fmt.Sprintf(`sudo bash -c 'if [ ! -f %s ]; then openssl req -newkey rsa:4096 -new -nodes -x509 -days 3650 -keyout %s -out %s -subj "/C=US/ST=CA/L=virtualkubelet/O=virtualkubelet/OU=virtualkubelet/CN=virtualkubelet"; fi'`,
+		aciConnectorCertPath, aciConnectorKeyPath, aciConnectorCertPath)
+	if err := a.run(port, create, nil, nil); err != nil {
+		return nil, nil, errors.Wrap(err, "creating the aci-connector certificate")
+	}
+	var cert, key bytes.Buffer
+	if err := a.run(port, "sudo cat "+aciConnectorCertPath, nil, &cert); err != nil {
+		return nil, nil, errors.Wrap(err, "reading the aci-connector certificate")
+	}
+	if err := a.run(port, "sudo cat "+aciConnectorKeyPath, nil, &key); err != nil {
+		return nil, nil, errors.Wrap(err, "reading the aci-connector key")
+	}
+	return cert.Bytes(), key.Bytes(), nil
+}
+
+func tmpPath(file string) string {
+	return "/tmp/aks-engine-addon-" + file
+}
+
+// updateScript replaces the manifest in the addons directory with the addon manifest copied to tmpPath if they differ
+func updateScript(m engine.AddonManifest) string {
+	return `set -e
+NEW=` + tmpPath(m.File) + `
+ADDON=` + addonsDir + "/" + m.File + `
+chmod 0644 $NEW
+if cmp -s $NEW $ADDON; then
+  rm -f $NEW
+else
+  mv $NEW $ADDON
+  echo ` + changedMarker + `
+fi
+`
+}
@@ -0,0 +1,173 @@
+// Copyright (c) Microsoft Corporation. All rights reserved.
+// Licensed under the MIT license.
+
+package kubernetesaddons
+
+import (
+	"encoding/base64"
+	"io"
+	"io/ioutil"
+	"strings"
+	"testing"
+
+	"github.com/Azure/aks-engine/pkg/engine"
+	"github.com/Azure/aks-engine/pkg/operations"
+	. "github.com/onsi/gomega"
+	"github.com/pkg/errors"
+	log "github.com/sirupsen/logrus"
+)
+
+const testAzureJSON = `{
+    "tenantId": "tenant",
+    "subscriptionId": "subscription",
+    "aadClientId": "client",
+    "aadClientSecret": "secret",
+    "resourceGroup": "rg",
+    "vmType": "vmss",
+    "primaryScaleSetName": "k8s-agentpool1-vmss",
+    "useManagedIdentityExtension": false
+}`
+
+type fakeMaster struct {
+	// addons are the manifests in /etc/kubernetes/addons
+	addons   map[string]string
+	commands []string
+	stdin    []string
+	fail     string
+}
+
+func newFakeApplier(masters ...*fakeMaster) *AddonsApplier {
+	return &AddonsApplier{
+		Logger:     log.NewEntry(log.New()),
+		MasterFQDN: "testcluster.westus2.cloudapp.azure.com",
+		SSHPorts:   operations.MasterSSHPorts(len(masters)),
+		SSHUser:    "azureuser",
+		RemoteRun: func(user string, addr string, port int, sshKey []byte, knownHostsFile string, cmd string, stdin io.Reader, stdout io.Writer) error {
+			master := masters[0]
+			if port != 22 {
+				master = masters[port-2200]
+			}
+			master.commands = append(master.commands, cmd)
+			var in string
+			if stdin != nil {
+				b, _ := ioutil.ReadAll(stdin)
+				in = string(b)
+				master.stdin = append(master.stdin, in)
+			}
+			if master.fail != "" && strings.Contains(cmd, master.fail) {
+				return errors.New("failed")
+			}
+			switch cmd {
+			case "sudo cat " + azureJSONPath:
+				io.WriteString(stdout, testAzureJSON)
+			case "sudo cat " + aciConnectorCertPath:
+				io.WriteString(stdout, "cert")
+			case "sudo cat " + aciConnectorKeyPath:
+				io.WriteString(stdout, "key")
+			}
+			for file, content := range master.addons {
+				switch {
+				case strings.HasPrefix(cmd, "sudo bash -c 'if [ -f "+addonsDir+"/"+file+" ]"):
+					io.WriteString(stdout, content)
+				case cmd == "sudo rm -f "+addonsDir+"/"+file:
+					delete(master.addons, file)
+				case cmd == "sudo bash -s" && strings.Contains(in, "ADDON="+addonsDir+"/"+file+"\n") && content == master.stdin[len(master.stdin)-2]:
+					return nil
+				}
+			}
+			if cmd == "sudo bash -s" {
+				io.WriteString(stdout, changedMarker+"\n")
+			}
+			return nil
+		},
+	}
+}
+
+func TestApply(t *testing.T) {
+	g := NewGomegaWithT(t)
+	masters := []*fakeMaster{
+		{addons: map[string]string{"kube-proxy-daemonset.yaml": "kube-proxy", "kube-tiller-deployment.yaml": "tiller"}},
+		{addons: map[string]string{"kube-proxy-daemonset.yaml": "kube-proxy", "kube-tiller-deployment.yaml": "tiller"}},
+	}
+	manifests := []engine.AddonManifest{
+		{File: "kube-proxy-daemonset.yaml", Enabled: true, Content: "kube-proxy"},
+		{File: "kube-dns-deployment.yaml"},
+		{File: "cluster-autoscaler-deployment.yaml", Enabled: true, Content: "cluster-autoscaler <clientID> <vmssName> <hostNet>"},
+		{File: "kube-tiller-deployment.yaml"},
+	}
+
+	err := newFakeApplier(masters...).Apply(manifests)
+	g.Expect(err).NotTo(HaveOccurred())
+
+	g.Expect(masters[0].commands).To(Equal([]string{
+		"sudo bash -c 'if [ -f /etc/kubernetes/addons/kube-dns-deployment.yaml ]; then cat /etc/kubernetes/addons/kube-dns-deployment.yaml; fi'",
+		"sudo bash -c 'if [ -f /etc/kubernetes/addons/kube-tiller-deployment.yaml ]; then cat /etc/kubernetes/addons/kube-tiller-deployment.yaml; fi'",
+		"sudo rm -f /etc/kubernetes/addons/kube-tiller-deployment.yaml",
+		"kubectl delete --ignore-not-found -f -",
+		"sudo bash -c 'cat > /tmp/aks-engine-addon-kube-proxy-daemonset.yaml'",
+		"sudo bash -s",
+		"sudo cat /etc/kubernetes/azure.json",
+		"sudo bash -c 'cat > /tmp/aks-engine-addon-cluster-autoscaler-deployment.yaml'",
+		"sudo bash -s",
+		"kubectl apply -f /etc/kubernetes/addons/cluster-autoscaler-deployment.yaml",
+	}))
+	g.Expect(masters[0].stdin[0]).To(Equal("tiller"))
+	g.Expect(masters[0].stdin).To(ContainElement("cluster-autoscaler Y2xpZW50Cg== k8s-agentpool1-vmss "))
+	g.Expect(masters[1].commands).To(Equal([]string{
+		"sudo rm -f /etc/kubernetes/addons/kube-tiller-deployment.yaml",
+		"sudo bash -c 'cat > /tmp/aks-engine-addon-kube-proxy-daemonset.yaml'",
+		"sudo bash -s",
+		"sudo cat /etc/kubernetes/azure.json",
+		"sudo bash -c 'cat > /tmp/aks-engine-addon-cluster-autoscaler-deployment.yaml'",
+		"sudo bash -s",
+	}))
+	g.Expect(masters[1].addons).NotTo(HaveKey("kube-tiller-deployment.yaml"))
+}
+
+func TestApplyShouldFailIfAMasterFails(t *testing.T) {
+	g := NewGomegaWithT(t)
+	masters := []*fakeMaster{{}, {fail: "sudo bash -s"}}
+	manifests := []engine.AddonManifest{
+		{File: "kube-proxy-daemonset.yaml", Enabled: true, Content: "kube-proxy"},
+	}
+
+	err := newFakeApplier(masters...).Apply(manifests)
+	g.Expect(err).To(HaveOccurred())
+	g.Expect(err.Error()).To(Equal("updating addon kube-proxy-daemonset.yaml on master 1: failed"))
+	for _, cmd := range masters[0].commands {
+		g.Expect(cmd).NotTo(HavePrefix("kubectl apply"))
+	}
+}
+
+func TestUpdateScript(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	script := updateScript(engine.AddonManifest{File: "kube-tiller-deployment.yaml", Enabled: true})
+	g.Expect(script).To(ContainSubstring("NEW=/tmp/aks-engine-addon-kube-tiller-deployment.yaml\n"))
+	g.Expect(script).To(ContainSubstring("ADDON=/etc/kubernetes/addons/kube-tiller-deployment.yaml\n"))
+	g.Expect(script).NotTo(ContainSubstring("azure.json"))
+}
+
+func TestResolveMasterPlaceholders(t *testing.T) {
+	g := NewGomegaWithT(t)
+	master := &fakeMaster{}
+	a := newFakeApplier(master)
+	settings, err := a.readAzureJSON(22)
+	g.Expect(err).NotTo(HaveOccurred())
+
+	content, err := a.resolveMasterPlaceholders(22, clusterAutoscalerAddonFile, "<clientID> <clientSec> <subID> <tenantID> <rg> <vmType> <vmssName> [<volMounts>] [<vols>] [<hostNet>]", settings)
+	g.Expect(err).NotTo(HaveOccurred())
+	// the provisioning script encodes the output of echo, with a trailing newline
+	g.Expect(content).To(Equal("Y2xpZW50Cg== c2VjcmV0Cg== c3Vic2NyaXB0aW9uCg== dGVuYW50Cg== cmcK dm1zcwo= k8s-agentpool1-vmss [] [] []"))
+
+	settings.UseManagedIdentityExtension = true
+	content, err = a.resolveMasterPlaceholders(22, clusterAutoscalerAddonFile, "<volMounts>\n<vols>\n<hostNet>", settings)
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(content).To(Equal("- mountPath: /var/lib/waagent/\n          name: waagent\n          readOnly: true\n- hostPath:\n          path: /var/lib/waagent/\n        name: waagent\nhostNetwork: true"))
+
+	content, err = a.resolveMasterPlaceholders(22, aciConnectorAddonFile, "<creds> <rgName> <cert> <key>", settings)
+	g.Expect(err).NotTo(HaveOccurred())
+	creds := `{"clientId": "client", "clientSecret": "secret", "tenantId": "tenant", "subscriptionId": "subscription", "activeDirectoryEndpointUrl": "https://login.microsoftonline.com","resourceManagerEndpointUrl": "https://management.azure.com/", "activeDirectoryGraphResourceId": "https://graph.windows.net/", "sqlManagementEndpointUrl": "https://management.core.windows.net:8443/", "galleryEndpointUrl": "https://gallery.azure.com/", "managementEndpointUrl": "https://management.core.windows.net/"}`
+	g.Expect(content).To(Equal(base64.StdEncoding.EncodeToString([]byte(creds)) + " rg Y2VydA== a2V5"))
+	g.Expect(master.commands).To(ContainElement(HavePrefix("sudo bash -c 'if [ ! -f /etc/kubernetes/certs/aci-connector-cert.pem ]; then openssl req")))
+}
@@ -0,0 +1,204 @@
+// Copyright (c) Microsoft Corporation. All rights reserved.
+// Licensed under the MIT license.
+
+package kubernetesupgrade
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/Azure/aks-engine/pkg/api"
+	"github.com/Azure/aks-engine/pkg/api/common"
+	"github.com/Azure/aks-engine/pkg/armhelpers"
+	"github.com/pkg/errors"
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+const mirrorPodAnnotation = "kubernetes.io/config.mirror"
+
+// removedWorkloadAPIs lists the workload API versions that Kubernetes stops serving, and the version that removes them
+var removedWorkloadAPIs = []struct {
+	kind       string
+	apiVersion string
+	removedIn  string
+}{
+	{"Deployment", "extensions/v1beta1", "1.16.0"},
+	{"Deployment", "apps/v1beta1", "1.16.0"},
+	{"Deployment", "apps/v1beta2", "1.16.0"},
+	{"DaemonSet", "extensions/v1beta1", "1.16.0"},
+	{"DaemonSet", "apps/v1beta2", "1.16.0"},
+	{"StatefulSet", "apps/v1beta1", "1.16.0"},
+	{"StatefulSet", "apps/v1beta2", "1.16.0"},
+}
+
+// addonImageComponents maps the addons whose images come from the Kubernetes components to the component keys of
+// their containers, which do not always match the addon name
+var addonImageComponents = map[string][]string{
+	api.DefaultHeapsterAddonName:           {"heapster", "addonresizer"},
+	api.DefaultTillerAddonName:             {api.DefaultTillerAddonName},
+	api.DefaultACIConnectorAddonName:       {api.DefaultACIConnectorAddonName},
+	api.DefaultClusterAutoscalerAddonName:  {api.DefaultClusterAutoscalerAddonName},
+	api.DefaultDashboardAddonName:          {api.DefaultDashboardAddonName},
+	api.DefaultReschedulerAddonName:        {api.DefaultReschedulerAddonName},
+	api.DefaultMetricsServerAddonName:      {api.DefaultMetricsServerAddonName},
+	api.NVIDIADevicePluginAddonName:        {api.NVIDIADevicePluginAddonName},
+	api.AzureCNINetworkMonitoringAddonName: {api.AzureCNINetworkMonitoringAddonName},
+}
+
+// UpgradePreflight checks a cluster for conditions that are likely to break its upgrade
+type UpgradePreflight struct {
+	Client    armhelpers.KubernetesClient
+	DataModel *api.ContainerService
+	// CurrentVersion is the Kubernetes version the cluster runs before the upgrade
+	CurrentVersion string
+	// TargetVersions are the Kubernetes versions the cluster goes through, in order
+	TargetVersions []string
+}
+
+// Run runs the preflight checks and returns a description of each issue found
+func (p *UpgradePreflight) Run() ([]string, error) {
+	var issues []string
+	for _, check := range []func() ([]string, error){
+		p.checkNodes,
+		p.checkPodDisruptionBudgets,
+		p.checkUnmanagedPods,
+		p.checkRemovedAPIs,
+		p.checkAddonImages,
+	} {
+		found, err := check()
+		if err != nil {
+			return nil, err
+		}
+		issues = append(issues, found...)
+	}
+	return issues, nil
+}
+
+// checkNodes reports the nodes that are not Ready
+func (p *UpgradePreflight) checkNodes() ([]string, error) {
+	nodeList, err := p.Client.ListNodes()
+	if err != nil {
+		return nil, errors.Wrap(err, "listing the cluster nodes")
+	}
+	var issues []string
+	for i := range nodeList.Items {
+		if !isNodeReady(&nodeList.Items[i]) {
+			issues = append(issues, fmt.Sprintf("node %s is not Ready", nodeList.Items[i].Name))
+		}
+	}
+	return issues, nil
+}
+
+// checkPodDisruptionBudgets reports the Pod Disruption Budgets that would block draining a node
+func (p *UpgradePreflight) checkPodDisruptionBudgets() ([]string, error) {
+	pdbList, err := p.Client.ListPodDisruptionBudgets()
+	if err != nil {
+		return nil, errors.Wrap(err, "listing the pod disruption budgets")
+	}
+	var issues []string
+	for _, pdb := range pdbList.Items {
+		if pdb.Status.ExpectedPods > 0 && pdb.Status.PodDisruptionsAllowed == 0 {
+			issues = append(issues, fmt.Sprintf("pod disruption budget %s/%s allows no disruptions, draining its pods will fail", pdb.Namespace, pdb.Name))
+		}
+	}
+	return issues, nil
+}
+
+// checkUnmanagedPods reports the running pods that no controller recreates once their node is drained
+func (p *UpgradePreflight) checkUnmanagedPods() ([]string, error) {
+	podList, err := p.Client.ListAllPods()
+	if err != nil {
+		return nil, errors.Wrap(err, "listing the cluster pods")
+	}
+	var issues []string
+	for i := range podList.Items {
+		pod := &podList.Items[i]
+		if _, found := pod.Annotations[mirrorPodAnnotation]; found {
+			continue
+		}
+		if pod.Status.Phase == v1.PodSucceeded || pod.Status.Phase == v1.PodFailed {
+			continue
+		}
+		if metav1.GetControllerOf(pod) == nil {
+			issues = append(issues, fmt.Sprintf("pod %s/%s is not managed by a controller and will be lost when its node is drained", pod.Namespace, pod.Name))
+		}
+	}
+	return issues, nil
+}
+
+// checkRemovedAPIs reports the workloads last applied with an API version that a target version no longer serves
+func (p *UpgradePreflight) checkRemovedAPIs() ([]string, error) {
+	var workloads []metav1.ObjectMeta
+	var kinds []string
+	deployments, err := p.Client.ListDeployments()
+	if err != nil {
+		return nil, errors.Wrap(err, "listing the deployments")
+	}
+	for _, d := range deployments.Items {
+		workloads, kinds = append(workloads, d.ObjectMeta), append(kinds, "Deployment")
+	}
+	daemonSets, err := p.Client.ListDaemonSets()
+	if err != nil {
+		return nil, errors.Wrap(err, "listing the daemon sets")
+	}
+	for _, ds := range daemonSets.Items {
+		workloads, kinds = append(workloads, ds.ObjectMeta), append(kinds, "DaemonSet")
+	}
+	statefulSets, err := p.Client.ListStatefulSets()
+	if err != nil {
+		return nil, errors.Wrap(err, "listing the stateful sets")
+	}
+	for _, ss := range statefulSets.Items {
+		workloads, kinds = append(workloads, ss.ObjectMeta), append(kinds, "StatefulSet")
+	}
+
+	targetVersion := p.TargetVersions[len(p.TargetVersions)-1]
+	var issues []string
+	for i, workload := range workloads {
+		// the api server serves every object with all its API versions, only the last applied configuration
+		// tells which one the workload is managed with
+		lastApplied, found := workload.Annotations[v1.LastAppliedConfigAnnotation]
+		if !found {
+			continue
+		}
+		var typeMeta metav1.TypeMeta
+		if err := json.Unmarshal([]byte(lastApplied), &typeMeta); err != nil {
+			continue
+		}
+		for _, removed := range removedWorkloadAPIs {
+			if removed.kind == kinds[i] && removed.apiVersion == typeMeta.APIVersion && common.IsKubernetesVersionGe(targetVersion, removed.removedIn) {
+				issues = append(issues, fmt.Sprintf("%s %s/%s uses API version %s, which Kubernetes version %s removes", kinds[i], workload.Namespace, workload.Name, removed.apiVersion, removed.removedIn))
+			}
+		}
+	}
+	return issues, nil
+}
+
+// checkAddonImages reports the enabled addons whose image has no entry for a target version
+func (p *UpgradePreflight) checkAddonImages() ([]string, error) {
+	o := p.DataModel.Properties.OrchestratorProfile
+	if o.KubernetesConfig == nil {
+		return nil, nil
+	}
+	var issues []string
+	for _, addon := range o.KubernetesConfig.Addons {
+		if !addon.IsEnabled(false) {
+			continue
+		}
+		// only the addons whose image comes from the Kubernetes components are checked
+		componentKeys, found := addonImageComponents[addon.Name]
+		if !found {
+			continue
+		}
+		for _, version := range p.TargetVersions {
+			components := o.GetK8sComponentsForVersion(version)
+			for _, key := range componentKeys {
+				if components[key] == "" {
+					issues = append(issues, fmt.Sprintf("addon %s has no %s image for Kubernetes version %s", addon.Name, key, version))
+				}
+			}
+		}
+	}
+	return issues, nil
+}
@@ -0,0 +1,144 @@
+// Copyright (c) Microsoft Corporation. All rights reserved.
+// Licensed under the MIT license.
+
+package kubernetesupgrade
+
+import (
+	"github.com/Azure/aks-engine/pkg/api"
+	"github.com/Azure/aks-engine/pkg/armhelpers"
+	"github.com/Azure/go-autorest/autorest/to"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	appsv1 "k8s.io/api/apps/v1"
+	v1 "k8s.io/api/core/v1"
+	policy "k8s.io/api/policy/v1beta1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+var _ = Describe("Upgrade preflight checks", func() {
+	var (
+		client    *armhelpers.MockKubernetesClient
+		preflight *UpgradePreflight
+	)
+
+	BeforeEach(func() {
+		readyNode := v1.Node{}
+		readyNode.Name = "k8s-master-1234"
+		readyNode.Status.Conditions = []v1.NodeCondition{{Type: v1.NodeReady, Status: v1.ConditionTrue}}
+		client = &armhelpers.MockKubernetesClient{
+			NodeList: &v1.NodeList{Items: []v1.Node{readyNode}},
+		}
+		preflight = &UpgradePreflight{
+			Client:         client,
+			DataModel:      api.CreateMockContainerService("testcluster", "1.13.5", 3, 2, false),
+			CurrentVersion: "1.13.5",
+			TargetVersions: []string{"1.14.1"},
+		}
+	})
+
+	It("Should not report issues for a healthy cluster", func() {
+		issues, err := preflight.Run()
+		Expect(err).NotTo(HaveOccurred())
+		Expect(issues).To(BeEmpty())
+	})
+
+	It("Should report nodes that are not Ready", func() {
+		client.NodeList = nil
+
+		issues, err := preflight.Run()
+		Expect(err).NotTo(HaveOccurred())
+		Expect(issues).To(Equal([]string{"node k8s-agentpool3-1234 is not Ready"}))
+	})
+
+	It("Should report pod disruption budgets that allow no disruptions", func() {
+		blocking := policy.PodDisruptionBudget{}
+		blocking.Namespace, blocking.Name = "default", "blocking"
+		blocking.Status.ExpectedPods = 2
+		empty := policy.PodDisruptionBudget{}
+		empty.Namespace, empty.Name = "default", "empty"
+		client.PodDisruptionBudgetList = &policy.PodDisruptionBudgetList{Items: []policy.PodDisruptionBudget{blocking, empty}}
+
+		issues, err := preflight.Run()
+		Expect(err).NotTo(HaveOccurred())
+		Expect(issues).To(Equal([]string{"pod disruption budget default/blocking allows no disruptions, draining its pods will fail"}))
+	})
+
+	It("Should report running pods that are not managed by a controller", func() {
+		unmanaged := v1.Pod{}
+		unmanaged.Namespace, unmanaged.Name = "default", "unmanaged"
+		mirror := v1.Pod{}
+		mirror.Namespace, mirror.Name = "kube-system", "kube-apiserver"
+		mirror.Annotations = map[string]string{mirrorPodAnnotation: "hash"}
+		completed := v1.Pod{}
+		completed.Namespace, completed.Name = "default", "completed"
+		completed.Status.Phase = v1.PodSucceeded
+		managed := v1.Pod{}
+		managed.Namespace, managed.Name = "default", "managed"
+		managed.OwnerReferences = []metav1.OwnerReference{{Kind: "ReplicaSet", Name: "managed", Controller: to.BoolPtr(true)}}
+		client.PodsList = &v1.PodList{Items: []v1.Pod{unmanaged, mirror, completed, managed}}
+
+		issues, err := preflight.Run()
+		Expect(err).NotTo(HaveOccurred())
+		Expect(issues).To(Equal([]string{"pod default/unmanaged is not managed by a controller and will be lost when its node is drained"}))
+	})
+
+	It("Should report workloads applied with a removed API version", func() {
+		deployment := appsv1.Deployment{}
+		deployment.Namespace, deployment.Name = "default", "web"
+		deployment.Annotations = map[string]string{v1.LastAppliedConfigAnnotation: `{"apiVersion":"extensions/v1beta1","kind":"Deployment"}`}
+		current := appsv1.Deployment{}
+		current.Namespace, current.Name = "default", "api"
+		current.Annotations = map[string]string{v1.LastAppliedConfigAnnotation: `{"apiVersion":"apps/v1","kind":"Deployment"}`}
+		client.DeploymentList = &appsv1.DeploymentList{Items: []appsv1.Deployment{deployment, current}}
+
+		issues, err := preflight.Run()
+		Expect(err).NotTo(HaveOccurred())
+		Expect(issues).To(BeEmpty())
+
+		preflight.TargetVersions = []string{"1.16.0"}
+		issues, err = preflight.Run()
+		Expect(err).NotTo(HaveOccurred())
+		Expect(issues).To(ContainElement("Deployment default/web uses API version extensions/v1beta1, which Kubernetes version 1.16.0 removes"))
+		Expect(issues).NotTo(ContainElement(ContainSubstring("default/api")))
+	})
+
+	It("Should report addons without an image for a target version", func() {
+		preflight.DataModel.Properties.OrchestratorProfile.KubernetesConfig.Addons = []api.KubernetesAddon{
+			{Name: api.DefaultDashboardAddonName, Enabled: to.BoolPtr(true)},
+		}
+		preflight.TargetVersions = []string{"1.14.1", "1.99.0"}
+
+		issues, err := preflight.Run()
+		Expect(err).NotTo(HaveOccurred())
+		Expect(issues).To(Equal([]string{"addon kubernetes-dashboard has no kubernetes-dashboard image for Kubernetes version 1.99.0"}))
+
+		preflight.DataModel.Properties.OrchestratorProfile.KubernetesConfig.ComponentOverrides = map[string]string{
+			api.DefaultDashboardAddonName: "kubernetes-dashboard-amd64:v1.10.1",
+		}
+		issues, err = preflight.Run()
+		Expect(err).NotTo(HaveOccurred())
+		Expect(issues).To(BeEmpty())
+	})
+
+	It("Should check every image of an addon", func() {
+		preflight.DataModel.Properties.OrchestratorProfile.KubernetesConfig.Addons = []api.KubernetesAddon{
+			{Name: api.DefaultHeapsterAddonName, Enabled: to.BoolPtr(true)},
+		}
+		preflight.DataModel.Properties.OrchestratorProfile.KubernetesConfig.ComponentOverrides = map[string]string{
+			"heapster": "heapster-amd64:v1.5.4",
+		}
+		preflight.TargetVersions = []string{"1.99.0"}
+
+		issues, err := preflight.Run()
+		Expect(err).NotTo(HaveOccurred())
+		Expect(issues).To(Equal([]string{"addon heapster has no addonresizer image for Kubernetes version 1.99.0"}))
+	})
+
+	It("Should fail if the cluster cannot be queried", func() {
+		client.FailListPodDisruptionBudgets = true
+
+		_, err := preflight.Run()
+		Expect(err).To(HaveOccurred())
+		Expect(err.Error()).To(ContainSubstring("ListPodDisruptionBudgets failed"))
+	})
+})
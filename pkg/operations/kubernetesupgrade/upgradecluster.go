@@ -74,6 +74,14 @@ type UpgradeCluster struct {
 	StepTimeout     *time.Duration
 	UpgradeWorkFlow UpgradeWorkFlow
 	Force           bool
+	// NodeImageOnly rolls the agent nodes onto the latest OS image without changing the Kubernetes version
+	NodeImageOnly bool
+	// ControlPlaneOnly upgrades the masters and leaves the agent pools on their current version
+	ControlPlaneOnly bool
+	// AgentPoolsOnly upgrades the agent pools in AgentPoolsToUpgrade and leaves the masters on their current version
+	AgentPoolsOnly bool
+	// ValidateUpgrade checks that every node is Ready and on its desired Kubernetes version once the upgrade completes
+	ValidateUpgrade bool
 }
 
 // MasterVMNamePrefix is the prefix for all master VM names for Kubernetes clusters
@@ -93,13 +101,34 @@ func (uc *UpgradeCluster) UpgradeCluster(az armhelpers.AKSEngineClient, kubeConf
 	}
 
 	upgradeVersion := uc.DataModel.Properties.OrchestratorProfile.OrchestratorVersion
-	uc.Logger.Infof("Upgrading to Kubernetes version %s", upgradeVersion)
+	switch {
+	case uc.NodeImageOnly:
+		uc.Logger.Infof("Refreshing the node images")
+	case uc.AgentPoolsOnly:
+		uc.Logger.Infof("Upgrading the agent pools to their desired Kubernetes version")
+	default:
+		uc.Logger.Infof("Upgrading to Kubernetes version %s", upgradeVersion)
+	}
 
-	if err := uc.getUpgradeWorkflow(kubeConfig, aksEngineVersion).RunUpgrade(); err != nil {
+	upgradeWorkflow := uc.getUpgradeWorkflow(kubeConfig, aksEngineVersion)
+	if err := upgradeWorkflow.RunUpgrade(); err != nil {
 		return err
 	}
 
-	uc.Logger.Infof("Cluster upgraded successfully to Kubernetes version %s", upgradeVersion)
+	if uc.ValidateUpgrade {
+		if err := upgradeWorkflow.Validate(); err != nil {
+			return err
+		}
+	}
+
+	switch {
+	case uc.NodeImageOnly:
+		uc.Logger.Infof("Node images refreshed successfully")
+	case uc.AgentPoolsOnly:
+		uc.Logger.Infof("Agent pools upgraded successfully")
+	default:
+		uc.Logger.Infof("Cluster upgraded successfully to Kubernetes version %s", upgradeVersion)
+	}
 	return nil
 }
 
@@ -109,11 +138,13 @@ func (uc *UpgradeCluster) getUpgradeWorkflow(kubeConfig string, aksEngineVersion
 	}
 	u := &Upgrader{}
 	u.Init(uc.Translator, uc.Logger, uc.ClusterTopology, uc.Client, kubeConfig, uc.StepTimeout, aksEngineVersion)
+	u.NodeImageOnly = uc.NodeImageOnly
+	u.AgentPoolsOnly = uc.AgentPoolsOnly
 	return u
 }
 
 func (uc *UpgradeCluster) getClusterNodeStatus(az armhelpers.AKSEngineClient, resourceGroup, kubeConfig string) error {
-	goalVersion := uc.DataModel.Properties.OrchestratorProfile.OrchestratorVersion
+	controlPlaneVersion := uc.DataModel.Properties.OrchestratorProfile.OrchestratorVersion
 
 	ctx, cancel := context.WithTimeout(context.Background(), armhelpers.DefaultARMOperationTimeout)
 	defer cancel()
@@ -151,6 +182,26 @@ func (uc *UpgradeCluster) getClusterNodeStatus(az armhelpers.AKSEngineClient, re
 					scaleSetToUpgrade.IsWindows = true
 					uc.Logger.Infof("Set isWindows flag for vmss %s.", *vmScaleSet.Name)
 				}
+				poolName := uc.getScaleSetPoolName(scaleSetToUpgrade)
+				if uc.isTargetedUpgrade() && !uc.AgentPoolsToUpgrade[poolName] {
+					uc.Logger.Infof("Skipping VMSS %s as it does not belong to a pool to upgrade.", *vmScaleSet.Name)
+					continue
+				}
+				if uc.NodeImageOnly {
+					for _, vm := range vmScaleSetVMsPage.Values() {
+						uc.Logger.Infof("VM %s in VMSS %s will be reimaged.", *vm.Name, *vmScaleSet.Name)
+						scaleSetToUpgrade.VMsToUpgrade = append(
+							scaleSetToUpgrade.VMsToUpgrade,
+							AgentPoolScaleSetVM{
+								Name:       *vm.VirtualMachineScaleSetVMProperties.OsProfile.ComputerName,
+								InstanceID: *vm.InstanceID,
+							},
+						)
+					}
+					uc.AgentPoolScaleSetsToUpgrade = append(uc.AgentPoolScaleSetsToUpgrade, scaleSetToUpgrade)
+					continue
+				}
+				goalVersion := uc.getPoolGoalVersion(poolName)
 				for _, vm := range vmScaleSetVMsPage.Values() {
 					currentVersion := uc.getNodeVersion(kubeClient, strings.ToLower(*vm.Name), vm.Tags)
 					if uc.Force {
@@ -197,9 +248,30 @@ func (uc *UpgradeCluster) getClusterNodeStatus(az armhelpers.AKSEngineClient, re
 					*vm.Name, uc.NameSuffix)
 				continue
 			}
+			isMaster := strings.Contains(*(vm.Name), MasterVMNamePrefix)
+			goalVersion := controlPlaneVersion
+			if !isMaster {
+				if uc.ControlPlaneOnly {
+					uc.Logger.Infof("Skipping agent VM: %s for upgrade as only the control plane is upgraded.", *vm.Name)
+					continue
+				}
+				if vm.Tags != nil && vm.Tags["poolName"] != nil {
+					goalVersion = uc.getPoolGoalVersion(*vm.Tags["poolName"])
+				}
+			}
 			currentVersion := uc.getNodeVersion(kubeClient, strings.ToLower(*vm.Name), vm.Tags)
 
-			if uc.Force {
+			if isMaster && (uc.AgentPoolsOnly || uc.NodeImageOnly) {
+				if currentVersion == "" {
+					currentVersion = "Unknown"
+				}
+				uc.addVMToFinishedSets(vm, currentVersion)
+			} else if uc.NodeImageOnly {
+				if currentVersion == "" {
+					currentVersion = "Unknown"
+				}
+				uc.addVMToUpgradeSets(vm, currentVersion)
+			} else if uc.Force {
 				if currentVersion == "" {
 					currentVersion = "Unknown"
 				}
@@ -212,7 +284,7 @@ func (uc *UpgradeCluster) getClusterNodeStatus(az armhelpers.AKSEngineClient, re
 				// If the current version is different than the desired version then we add the VM to the list of VMs to upgrade.
 				if currentVersion != goalVersion {
 					if !uc.DataModel.Properties.IsHostedMasterProfile() {
-						if err := uc.upgradable(currentVersion); err != nil {
+						if err := uc.upgradable(currentVersion, goalVersion); err != nil {
 							return err
 						}
 					}
@@ -227,12 +299,42 @@ func (uc *UpgradeCluster) getClusterNodeStatus(az armhelpers.AKSEngineClient, re
 	return nil
 }
 
-func (uc *UpgradeCluster) upgradable(currentVersion string) error {
+// isTargetedUpgrade returns true if only the masters or agent pools in AgentPoolsToUpgrade are upgraded.
+func (uc *UpgradeCluster) isTargetedUpgrade() bool {
+	return uc.ControlPlaneOnly || uc.AgentPoolsOnly || uc.NodeImageOnly
+}
+
+// getScaleSetPoolName returns the name of the pool of the scale set, or an empty string.
+func (uc *UpgradeCluster) getScaleSetPoolName(vmss AgentPoolScaleSet) string {
+	var poolName string
+	var err error
+	if vmss.IsWindows {
+		poolName, err = utils.WindowsVmssNameParts(vmss.Name)
+	} else {
+		poolName, _, err = utils.VmssNameParts(vmss.Name)
+	}
+	if err != nil {
+		uc.Logger.Warnf("Couldn't determine pool membership for VMSS: %s.", vmss.Name)
+		return ""
+	}
+	return poolName
+}
+
+// getPoolGoalVersion returns the Kubernetes version the nodes of the pool are upgraded to.
+func (uc *UpgradeCluster) getPoolGoalVersion(poolName string) string {
+	for _, agentPool := range uc.DataModel.Properties.AgentPoolProfiles {
+		if agentPool.Name == poolName {
+			return uc.DataModel.Properties.GetAgentPoolOrchestratorVersion(agentPool)
+		}
+	}
+	return uc.DataModel.Properties.OrchestratorProfile.OrchestratorVersion
+}
+
+func (uc *UpgradeCluster) upgradable(currentVersion, targetVersion string) error {
 	nodeVersion := &api.OrchestratorProfile{
 		OrchestratorType:    api.Kubernetes,
 		OrchestratorVersion: currentVersion,
 	}
-	targetVersion := uc.DataModel.Properties.OrchestratorProfile.OrchestratorVersion
 
 	orch, err := api.GetOrchestratorVersionProfile(nodeVersion, uc.DataModel.Properties.HasWindows())
 	if err != nil {
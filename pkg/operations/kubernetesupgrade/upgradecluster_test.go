@@ -5,6 +5,7 @@ package kubernetesupgrade
 
 import (
 	"context"
+	"errors"
 	"os"
 	"testing"
 
@@ -14,6 +15,7 @@ import (
 	"github.com/Azure/aks-engine/pkg/i18n"
 	. "github.com/Azure/aks-engine/pkg/test"
 	"github.com/Azure/azure-sdk-for-go/services/compute/mgmt/2018-10-01/compute"
+	"github.com/Azure/go-autorest/autorest/to"
 	. "github.com/onsi/ginkgo"
 	. "github.com/onsi/gomega"
 	log "github.com/sirupsen/logrus"
@@ -352,6 +354,110 @@ var _ = Describe("Upgrade Kubernetes cluster tests", func() {
 		})
 	})
 
+	Context("When refreshing the node images of a cluster", func() {
+		var (
+			cs         *api.ContainerService
+			uc         UpgradeCluster
+			mockClient armhelpers.MockAKSEngineClient
+		)
+
+		BeforeEach(func() {
+			mockClient = armhelpers.MockAKSEngineClient{}
+			cs = api.CreateMockContainerService("testcluster", "1.9.10", 3, 3, false)
+			uc = UpgradeCluster{
+				Translator: &i18n.Translator{},
+				Logger:     log.NewEntry(log.New()),
+			}
+			mockClient.FakeListVirtualMachineScaleSetsResult = func() []compute.VirtualMachineScaleSet {
+				agentScalesetName := "k8s-agentpool1-12345678-vmss"
+				otherScalesetName := "k8s-agentpool2-12345678-vmss"
+				sku := compute.Sku{}
+				location := "eastus"
+				return []compute.VirtualMachineScaleSet{
+					{
+						Name:                             &agentScalesetName,
+						Sku:                              &sku,
+						Location:                         &location,
+						VirtualMachineScaleSetProperties: &compute.VirtualMachineScaleSetProperties{},
+					},
+					{
+						Name:                             &otherScalesetName,
+						Sku:                              &sku,
+						Location:                         &location,
+						VirtualMachineScaleSetProperties: &compute.VirtualMachineScaleSetProperties{},
+					},
+				}
+			}
+			uc.Client = &mockClient
+			uc.ClusterTopology = ClusterTopology{}
+			uc.SubscriptionID = "DEC923E3-1EF1-4745-9516-37906D56DEC4"
+			uc.ResourceGroup = "TestRg"
+			uc.DataModel = cs
+			uc.NameSuffix = "12345678"
+			uc.AgentPoolsToUpgrade = map[string]bool{"agentpool1": true}
+			uc.NodeImageOnly = true
+			uc.UpgradeWorkFlow = fakeUpgradeWorkflow{}
+		})
+		It("Should reimage all VMs of the selected scale sets regardless of version", func() {
+			mockClient.FakeListVirtualMachineScaleSetVMsResult = func() []compute.VirtualMachineScaleSetVM {
+				return []compute.VirtualMachineScaleSetVM{
+					mockClient.MakeFakeVirtualMachineScaleSetVM("Kubernetes:1.9.10"),
+					mockClient.MakeFakeVirtualMachineScaleSetVM("Kubernetes:"),
+				}
+			}
+
+			err := uc.UpgradeCluster(&mockClient, "kubeConfig", TestAKSEngineVersion)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(uc.AgentPoolScaleSetsToUpgrade).To(HaveLen(1))
+			Expect(uc.AgentPoolScaleSetsToUpgrade[0].Name).To(Equal("k8s-agentpool1-12345678-vmss"))
+			Expect(uc.AgentPoolScaleSetsToUpgrade[0].VMsToUpgrade).To(HaveLen(2))
+		})
+		It("Should replace agent VMs already on the desired version and skip masters", func() {
+			mockClient.FakeListVirtualMachineResult = func() []compute.VirtualMachine {
+				return []compute.VirtualMachine{
+					mockClient.MakeFakeVirtualMachine("k8s-master-12345678-0", "Kubernetes:1.9.10"),
+					mockClient.MakeFakeVirtualMachine("k8s-agentpool1-12345678-0", "Kubernetes:1.9.10"),
+				}
+			}
+
+			err := uc.UpgradeCluster(&mockClient, "kubeConfig", TestAKSEngineVersion)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(*uc.MasterVMs).To(HaveLen(0))
+			Expect(*uc.UpgradedMasterVMs).To(HaveLen(1))
+			Expect(*uc.AgentPools["agentpool1"].AgentVMs).To(HaveLen(1))
+		})
+		It("Should reimage scale set VMs on the latest model and update the others", func() {
+			mockClient.FakeListVirtualMachineScaleSetVMsResult = func() []compute.VirtualMachineScaleSetVM {
+				vm := mockClient.MakeFakeVirtualMachineScaleSetVM("Kubernetes:1.9.10")
+				vm.LatestModelApplied = to.BoolPtr(true)
+				return []compute.VirtualMachineScaleSetVM{vm}
+			}
+			mockClient.FakeListVirtualMachineResult = func() []compute.VirtualMachine {
+				return []compute.VirtualMachine{}
+			}
+			mockClient.MockKubernetesClient = &armhelpers.MockKubernetesClient{}
+			mockClient.FailReimageVirtualMachineScaleSetVM = true
+			uc.UpgradeWorkFlow = nil
+
+			err := uc.UpgradeCluster(&mockClient, "kubeConfig", TestAKSEngineVersion)
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(ContainSubstring("ReimageVirtualMachineScaleSetVM failed"))
+
+			mockClient.FakeListVirtualMachineScaleSetVMsResult = func() []compute.VirtualMachineScaleSetVM {
+				vm := mockClient.MakeFakeVirtualMachineScaleSetVM("Kubernetes:1.9.10")
+				vm.LatestModelApplied = to.BoolPtr(false)
+				return []compute.VirtualMachineScaleSetVM{vm}
+			}
+			mockClient.FailReimageVirtualMachineScaleSetVM = false
+			mockClient.FailUpdateVirtualMachineScaleSetVMs = true
+			uc.AgentPoolScaleSetsToUpgrade = nil
+
+			err = uc.UpgradeCluster(&mockClient, "kubeConfig", TestAKSEngineVersion)
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(ContainSubstring("UpdateVirtualMachineScaleSetVMs failed"))
+		})
+	})
+
 	Context("When upgrading a cluster with windows VMSS VMs", func() {
 		var (
 			cs         *api.ContainerService
@@ -541,6 +647,148 @@ var _ = Describe("Upgrade Kubernetes cluster tests", func() {
 		})
 	})
 
+	Context("When upgrading selected pools", func() {
+		var (
+			cs         *api.ContainerService
+			uc         UpgradeCluster
+			mockClient armhelpers.MockAKSEngineClient
+		)
+
+		BeforeEach(func() {
+			mockClient = armhelpers.MockAKSEngineClient{}
+			cs = api.CreateMockContainerService("testcluster", "1.9.10", 3, 3, false)
+			uc = UpgradeCluster{
+				Translator: &i18n.Translator{},
+				Logger:     log.NewEntry(log.New()),
+			}
+
+			uc.Client = &mockClient
+			uc.ClusterTopology = ClusterTopology{}
+			uc.SubscriptionID = "DEC923E3-1EF1-4745-9516-37906D56DEC4"
+			uc.ResourceGroup = "TestRg"
+			uc.DataModel = cs
+			uc.NameSuffix = "12345678"
+			uc.UpgradeWorkFlow = fakeUpgradeWorkflow{}
+			mockClient.FakeListVirtualMachineResult = func() []compute.VirtualMachine {
+				return []compute.VirtualMachine{
+					mockClient.MakeFakeVirtualMachine("k8s-master-12345678-0", "Kubernetes:1.9.7"),
+					mockClient.MakeFakeVirtualMachine("k8s-agentpool1-12345678-0", "Kubernetes:1.9.7"),
+				}
+			}
+		})
+		It("Should skip agent VMs when upgrading the control plane only", func() {
+			uc.AgentPoolsToUpgrade = map[string]bool{MasterPoolName: true}
+			uc.ControlPlaneOnly = true
+
+			err := uc.UpgradeCluster(&mockClient, "kubeConfig", TestAKSEngineVersion)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(*uc.MasterVMs).To(HaveLen(1))
+			Expect(uc.AgentPools).To(BeEmpty())
+		})
+		It("Should skip master VMs when upgrading agent pools only", func() {
+			uc.AgentPoolsToUpgrade = map[string]bool{"agentpool1": true}
+			uc.AgentPoolsOnly = true
+
+			err := uc.UpgradeCluster(&mockClient, "kubeConfig", TestAKSEngineVersion)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(*uc.MasterVMs).To(HaveLen(0))
+			Expect(*uc.UpgradedMasterVMs).To(HaveLen(1))
+			Expect(*uc.AgentPools["agentpool1"].AgentVMs).To(HaveLen(1))
+		})
+		It("Should skip agent VMs already on the agent pool version", func() {
+			cs.Properties.AgentPoolProfiles[0].OrchestratorVersion = "1.9.7"
+			uc.AgentPoolsToUpgrade = map[string]bool{MasterPoolName: true, "agentpool1": true}
+
+			err := uc.UpgradeCluster(&mockClient, "kubeConfig", TestAKSEngineVersion)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(*uc.MasterVMs).To(HaveLen(1))
+			Expect(*uc.AgentPools["agentpool1"].AgentVMs).To(HaveLen(0))
+			Expect(*uc.AgentPools["agentpool1"].UpgradedAgentVMs).To(HaveLen(1))
+		})
+		It("Should only include the scale sets of the selected agent pools", func() {
+			mockClient.FakeListVirtualMachineScaleSetsResult = func() []compute.VirtualMachineScaleSet {
+				agentScalesetName := "k8s-agentpool1-12345678-vmss"
+				otherScalesetName := "k8s-agentpool2-12345678-vmss"
+				sku := compute.Sku{}
+				location := "eastus"
+				return []compute.VirtualMachineScaleSet{
+					{
+						Name:                             &agentScalesetName,
+						Sku:                              &sku,
+						Location:                         &location,
+						VirtualMachineScaleSetProperties: &compute.VirtualMachineScaleSetProperties{},
+					},
+					{
+						Name:                             &otherScalesetName,
+						Sku:                              &sku,
+						Location:                         &location,
+						VirtualMachineScaleSetProperties: &compute.VirtualMachineScaleSetProperties{},
+					},
+				}
+			}
+			mockClient.FakeListVirtualMachineScaleSetVMsResult = func() []compute.VirtualMachineScaleSetVM {
+				return []compute.VirtualMachineScaleSetVM{
+					mockClient.MakeFakeVirtualMachineScaleSetVM("Kubernetes:1.9.7"),
+				}
+			}
+			uc.AgentPoolsToUpgrade = map[string]bool{"agentpool1": true}
+			uc.AgentPoolsOnly = true
+
+			err := uc.UpgradeCluster(&mockClient, "kubeConfig", TestAKSEngineVersion)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(uc.AgentPoolScaleSetsToUpgrade).To(HaveLen(1))
+			Expect(uc.AgentPoolScaleSetsToUpgrade[0].Name).To(Equal("k8s-agentpool1-12345678-vmss"))
+			Expect(uc.AgentPoolScaleSetsToUpgrade[0].VMsToUpgrade).To(HaveLen(1))
+		})
+	})
+
+	Context("When validating the upgrade", func() {
+		var (
+			cs         *api.ContainerService
+			mockClient armhelpers.MockAKSEngineClient
+		)
+
+		BeforeEach(func() {
+			mockClient = armhelpers.MockAKSEngineClient{}
+			cs = api.CreateMockContainerService("testcluster", "1.9.10", 3, 3, false)
+		})
+		It("Should return the validation error of the upgrade workflow", func() {
+			uc := UpgradeCluster{
+				Translator: &i18n.Translator{},
+				Logger:     log.NewEntry(log.New()),
+			}
+			uc.Client = &mockClient
+			uc.ClusterTopology = ClusterTopology{}
+			uc.SubscriptionID = "DEC923E3-1EF1-4745-9516-37906D56DEC4"
+			uc.ResourceGroup = "TestRg"
+			uc.DataModel = cs
+			uc.NameSuffix = "12345678"
+			uc.ValidateUpgrade = true
+			uc.UpgradeWorkFlow = fakeUpgradeWorkflow{ValidateError: errors.New("node is not Ready")}
+
+			err := uc.UpgradeCluster(&mockClient, "kubeConfig", TestAKSEngineVersion)
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(Equal("node is not Ready"))
+		})
+		It("Should fail if a node is not Ready", func() {
+			u := &Upgrader{}
+			u.Init(&i18n.Translator{}, log.NewEntry(log.New()), ClusterTopology{DataModel: cs}, &mockClient, "kubeConfig", nil, TestAKSEngineVersion)
+
+			err := u.Validate()
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(Equal("node k8s-agentpool3-1234 is not Ready"))
+		})
+		It("Should fail if the nodes cannot be listed", func() {
+			mockClient.MockKubernetesClient = &armhelpers.MockKubernetesClient{FailListNodes: true}
+			u := &Upgrader{}
+			u.Init(&i18n.Translator{}, log.NewEntry(log.New()), ClusterTopology{DataModel: cs}, &mockClient, "kubeConfig", nil, TestAKSEngineVersion)
+
+			err := u.Validate()
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(ContainSubstring("ListNodes failed"))
+		})
+	})
+
 	It("Should not fail if no managed identity is returned by azure during upgrade operation", func() {
 		cs := api.CreateMockContainerService("testcluster", "1.9.11", 3, 2, false)
 		cs.Properties.OrchestratorProfile.KubernetesConfig = &api.KubernetesConfig{}
@@ -681,4 +929,64 @@ var _ = Describe("Upgrade Kubernetes cluster tests", func() {
 
 		Expect(len(newNode.Spec.Taints)).To(Equal(2))
 	})
+
+	It("Tests CopyCustomNodeProperties reconciles agent pool taints", func() {
+		cs := api.CreateMockContainerService("testcluster", "1.13.5", 1, 1, false)
+		cs.Properties.AgentPoolProfiles[0].Name = "agentpool1"
+		cs.Properties.AgentPoolProfiles[0].Taints = []string{"dedicated=gpu:NoSchedule"}
+
+		u := &Upgrader{}
+		u.Init(&i18n.Translator{}, log.NewEntry(log.New()), ClusterTopology{DataModel: cs}, nil, "", nil, TestAKSEngineVersion)
+
+		mockClient := &armhelpers.MockAKSEngineClient{MockKubernetesClient: &armhelpers.MockKubernetesClient{}}
+		mockClient.MockKubernetesClient.UpdateNodeFunc = func(node *v1.Node) (*v1.Node, error) {
+			return node, nil
+		}
+
+		oldNode := &v1.Node{}
+		oldNode.Annotations = map[string]string{"kubernetes.azure.com/managed-taints": "dedicated=cpu:NoSchedule,spot:PreferNoSchedule"}
+		oldNode.Spec.Taints = []v1.Taint{
+			{Key: "dedicated", Value: "cpu", Effect: v1.TaintEffectNoSchedule},
+			{Key: "spot", Effect: v1.TaintEffectPreferNoSchedule},
+			{Key: "custom", Value: "oldnode", Effect: v1.TaintEffectNoExecute},
+		}
+
+		newNode := &v1.Node{}
+		newNode.Labels = map[string]string{"agentpool": "agentpool1"}
+
+		err := u.copyCustomNodeProperties(mockClient.MockKubernetesClient, "oldnode", oldNode, "newnode", newNode)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(newNode.Spec.Taints).To(Equal([]v1.Taint{
+			{Key: "dedicated", Value: "gpu", Effect: v1.TaintEffectNoSchedule},
+			{Key: "custom", Value: "newnode", Effect: v1.TaintEffectNoExecute},
+		}))
+		Expect(newNode.Annotations["kubernetes.azure.com/managed-taints"]).To(Equal("dedicated=gpu:NoSchedule"))
+
+		newNode = &v1.Node{}
+		newNode.Labels = map[string]string{"agentpool": "agentpool1"}
+		newNode.Spec.Taints = []v1.Taint{
+			{Key: "registered", Value: "true", Effect: v1.TaintEffectNoSchedule},
+			{Key: "custom", Value: "registered", Effect: v1.TaintEffectNoExecute},
+		}
+		err = u.copyCustomNodeProperties(mockClient.MockKubernetesClient, "oldnode", oldNode, "newnode", newNode)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(newNode.Spec.Taints).To(Equal([]v1.Taint{
+			{Key: "dedicated", Value: "gpu", Effect: v1.TaintEffectNoSchedule},
+			{Key: "registered", Value: "true", Effect: v1.TaintEffectNoSchedule},
+			{Key: "custom", Value: "registered", Effect: v1.TaintEffectNoExecute},
+		}))
+
+		cs.Properties.AgentPoolProfiles[0].Taints = nil
+		newNode = &v1.Node{}
+		newNode.Labels = map[string]string{"agentpool": "agentpool1"}
+		oldNode.Annotations = map[string]string{"kubernetes.azure.com/managed-taints": "dedicated=gpu:NoSchedule"}
+		oldNode.Spec.Taints = []v1.Taint{
+			{Key: "dedicated", Value: "gpu", Effect: v1.TaintEffectNoSchedule},
+		}
+
+		err = u.copyCustomNodeProperties(mockClient.MockKubernetesClient, "oldnode", oldNode, "newnode", newNode)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(newNode.Spec.Taints).To(BeEmpty())
+		Expect(newNode.Annotations).NotTo(HaveKey("kubernetes.azure.com/managed-taints"))
+	})
 })
@@ -14,6 +14,7 @@ import (
 	"github.com/pkg/errors"
 
 	"github.com/Azure/aks-engine/pkg/api"
+	"github.com/Azure/aks-engine/pkg/api/common"
 	"github.com/Azure/aks-engine/pkg/armhelpers"
 	"github.com/Azure/aks-engine/pkg/armhelpers/utils"
 	"github.com/Azure/aks-engine/pkg/engine"
@@ -33,8 +34,15 @@ type Upgrader struct {
 	kubeConfig       string
 	stepTimeout      *time.Duration
 	AKSEngineVersion string
+	// NodeImageOnly skips the masters and reimages the agent scale set VMs instead of replacing them
+	NodeImageOnly bool
+	// AgentPoolsOnly skips the masters
+	AgentPoolsOnly bool
 }
 
+// managedTaintsAnnotation records the agent pool taints applied to a node from the API model
+const managedTaintsAnnotation = "kubernetes.azure.com/managed-taints"
+
 type vmStatus int
 
 const (
@@ -65,8 +73,10 @@ func (ku *Upgrader) Init(translator *i18n.Translator, logger *logrus.Entry, clus
 func (ku *Upgrader) RunUpgrade() error {
 	ctx, cancel := context.WithTimeout(context.Background(), 90*time.Minute)
 	defer cancel()
-	if err := ku.upgradeMasterNodes(ctx); err != nil {
-		return err
+	if !ku.NodeImageOnly && !ku.AgentPoolsOnly {
+		if err := ku.upgradeMasterNodes(ctx); err != nil {
+			return err
+		}
 	}
 
 	if err := ku.upgradeAgentScaleSets(ctx); err != nil {
@@ -78,6 +88,31 @@ func (ku *Upgrader) RunUpgrade() error {
 
 // Validate will run validation post upgrade
 func (ku *Upgrader) Validate() error {
+	client, err := ku.getKubernetesClient()
+	if err != nil {
+		return ku.Translator.Errorf("error getting Kubernetes client: %v", err)
+	}
+	nodeList, err := client.ListNodes()
+	if err != nil {
+		return ku.Translator.Errorf("error listing the cluster nodes: %v", err)
+	}
+
+	properties := ku.DataModel.Properties
+	desiredVersions := map[string]bool{properties.OrchestratorProfile.OrchestratorVersion: true}
+	for _, pool := range properties.AgentPoolProfiles {
+		desiredVersions[properties.GetAgentPoolOrchestratorVersion(pool)] = true
+	}
+	for i := range nodeList.Items {
+		node := &nodeList.Items[i]
+		if !isNodeReady(node) {
+			return ku.Translator.Errorf("node %s is not Ready", node.Name)
+		}
+		nodeVersion := strings.TrimPrefix(node.Status.NodeInfo.KubeletVersion, "v")
+		if !desiredVersions[nodeVersion] {
+			return ku.Translator.Errorf("node %s is running Kubernetes version %s, which is not a desired version of the cluster", node.Name, nodeVersion)
+		}
+	}
+	ku.logger.Infof("All %d nodes are Ready", len(nodeList.Items))
 	return nil
 }
 
@@ -465,6 +500,10 @@ func (ku *Upgrader) upgradeAgentScaleSets(ctx context.Context) error {
 		}
 	}
 
+	if ku.NodeImageOnly {
+		return ku.reimageAgentScaleSets(ctx)
+	}
+
 	for _, vmssToUpgrade := range ku.ClusterTopology.AgentPoolScaleSetsToUpgrade {
 		ku.logger.Infof("Upgrading VMSS %s", vmssToUpgrade.Name)
 
@@ -595,6 +634,112 @@ func (ku *Upgrader) upgradeAgentScaleSets(ctx context.Context) error {
 	return nil
 }
 
+// reimageAgentScaleSets rolls the agent scale set VMs onto the OS image of the scale set model one VM at a time.
+// VMs that are not on the latest model are updated to it, the others have their OS disk reimaged.
+func (ku *Upgrader) reimageAgentScaleSets(ctx context.Context) error {
+	for _, vmssToUpgrade := range ku.ClusterTopology.AgentPoolScaleSetsToUpgrade {
+		ku.logger.Infof("Reimaging VMSS %s", vmssToUpgrade.Name)
+
+		if len(vmssToUpgrade.VMsToUpgrade) == 0 {
+			ku.logger.Infof("No VMs to reimage for VMSS %s, skipping", vmssToUpgrade.Name)
+			continue
+		}
+
+		latestModelApplied, err := ku.getLatestModelAppliedInVMSS(ctx, ku.ClusterTopology.ResourceGroup, vmssToUpgrade.Name)
+		if err != nil {
+			return err
+		}
+
+		for _, vmToUpgrade := range vmssToUpgrade.VMsToUpgrade {
+			client, err := ku.getKubernetesClient()
+			if err != nil {
+				ku.logger.Errorf("Error getting Kubernetes client: %v", err)
+				return err
+			}
+
+			nodeName := strings.ToLower(vmToUpgrade.Name)
+			ku.logger.Infof("Draining node %s", vmToUpgrade.Name)
+			if err = operations.SafelyDrainNodeWithClient(client, ku.logger, nodeName, cordonDrainTimeout); err != nil {
+				ku.logger.Errorf("Error draining VM in VMSS: %v", err)
+				return err
+			}
+
+			if latestModelApplied[vmToUpgrade.InstanceID] {
+				ku.logger.Infof("Reimaging VM %s in VMSS %s", vmToUpgrade.Name, vmssToUpgrade.Name)
+				err = ku.Client.ReimageVirtualMachineScaleSetVM(ctx, ku.ClusterTopology.ResourceGroup, vmssToUpgrade.Name, vmToUpgrade.InstanceID)
+			} else {
+				ku.logger.Infof("Updating VM %s in VMSS %s to the latest model", vmToUpgrade.Name, vmssToUpgrade.Name)
+				err = ku.Client.UpdateVirtualMachineScaleSetVMs(ctx, ku.ClusterTopology.ResourceGroup, vmssToUpgrade.Name, []string{vmToUpgrade.InstanceID})
+			}
+			if err != nil {
+				ku.logger.Errorf("Failed to reimage VM %s in VMSS %s", vmToUpgrade.Name, vmssToUpgrade.Name)
+				return err
+			}
+
+			if err = ku.waitForNodeReadyAndUncordon(client, nodeName); err != nil {
+				return err
+			}
+			ku.logger.Infof("Successfully reimaged VM %s in VMSS %s", vmToUpgrade.Name, vmssToUpgrade.Name)
+		}
+		ku.logger.Infof("Completed reimaging VMSS %s", vmssToUpgrade.Name)
+	}
+
+	ku.logger.Infoln("Completed reimaging all VMSS")
+
+	return nil
+}
+
+// getLatestModelAppliedInVMSS returns whether each VM of the scale set, by instance ID, runs the latest scale set model.
+func (ku *Upgrader) getLatestModelAppliedInVMSS(ctx context.Context, resourceGroup string, vmScaleSetName string) (map[string]bool, error) {
+	latestModelApplied := make(map[string]bool)
+	for vmScaleSetVMsPage, err := ku.Client.ListVirtualMachineScaleSetVMs(ctx, resourceGroup, vmScaleSetName); vmScaleSetVMsPage.NotDone(); err = vmScaleSetVMsPage.Next() {
+		if err != nil {
+			return nil, err
+		}
+
+		for _, vm := range vmScaleSetVMsPage.Values() {
+			if vm.InstanceID == nil || vm.VirtualMachineScaleSetVMProperties == nil || vm.LatestModelApplied == nil {
+				continue
+			}
+			latestModelApplied[*vm.InstanceID] = *vm.LatestModelApplied
+		}
+	}
+	return latestModelApplied, nil
+}
+
+// waitForNodeReadyAndUncordon waits for a reimaged node to report Ready, then makes it schedulable again.
+func (ku *Upgrader) waitForNodeReadyAndUncordon(client armhelpers.KubernetesClient, nodeName string) error {
+	timeout := defaultTimeout
+	if ku.stepTimeout != nil {
+		timeout = *ku.stepTimeout
+	}
+
+	retryTimer := time.NewTimer(time.Millisecond)
+	timeoutTimer := time.NewTimer(timeout)
+	for {
+		select {
+		case <-timeoutTimer.C:
+			retryTimer.Stop()
+			return ku.Translator.Errorf("Node %s was not ready within %v", nodeName, timeout)
+		case <-retryTimer.C:
+			node, err := client.GetNode(nodeName)
+			if err != nil {
+				ku.logger.Infof("Agent node: %s status error: %v", nodeName, err)
+				retryTimer.Reset(retry)
+			} else if isNodeReady(node) {
+				timeoutTimer.Stop()
+				ku.logger.Infof("Agent node: %s is ready, uncordoning", nodeName)
+				node.Spec.Unschedulable = false
+				_, err = client.UpdateNode(node)
+				return err
+			} else {
+				ku.logger.Infof("Agent node: %s not ready yet...", nodeName)
+				retryTimer.Reset(retry)
+			}
+		}
+	}
+}
+
 func (ku *Upgrader) generateUpgradeTemplate(upgradeContainerService *api.ContainerService, aksEngineVersion string) (map[string]interface{}, map[string]interface{}, error) {
 	var err error
 	ctx := engine.Context{
@@ -698,19 +843,84 @@ func (ku *Upgrader) copyCustomNodeProperties(client armhelpers.KubernetesClient,
 		}
 	}
 
-	// copy Taints from old node to new node
-	if oldNode.Spec.Taints != nil {
-		newNode.Spec.Taints = append([]v1.Taint{}, oldNode.Spec.Taints...)
-		for i := range newNode.Spec.Taints {
-			newNode.Spec.Taints[i].Value = strings.Replace(newNode.Spec.Taints[i].Value, oldNodeName, newNodeName, -1)
+	// merge the Taints of the old node into those the new node registered with, by key and effect. The agent pool
+	// taints in the API model take precedence, then the taints of the new node, and taints a previous upgrade applied
+	// from the API model but which were since removed are dropped.
+	poolTaints := ku.getAgentPoolTaints(newNode)
+	previousPoolTaints := map[string]bool{}
+	if oldNode.Annotations != nil && oldNode.Annotations[managedTaintsAnnotation] != "" {
+		for _, s := range strings.Split(oldNode.Annotations[managedTaintsAnnotation], ",") {
+			if t, err := common.ParseTaint(s); err == nil {
+				previousPoolTaints[t.Key+":"+t.Effect] = true
+			}
 		}
 	}
+	if oldNode.Spec.Taints != nil || len(poolTaints) > 0 {
+		taints := append([]v1.Taint{}, poolTaints...)
+		for _, taint := range newNode.Spec.Taints {
+			if !hasTaint(taints, taint.Key+":"+string(taint.Effect)) {
+				taints = append(taints, taint)
+			}
+		}
+		newNode.Spec.Taints = taints
+		for _, taint := range oldNode.Spec.Taints {
+			key := taint.Key + ":" + string(taint.Effect)
+			if previousPoolTaints[key] || hasTaint(newNode.Spec.Taints, key) {
+				continue
+			}
+			taint.Value = strings.Replace(taint.Value, oldNodeName, newNodeName, -1)
+			newNode.Spec.Taints = append(newNode.Spec.Taints, taint)
+		}
+	}
+	if len(poolTaints) > 0 {
+		if newNode.Annotations == nil {
+			newNode.Annotations = map[string]string{}
+		}
+		var applied []string
+		for _, taint := range poolTaints {
+			applied = append(applied, common.Taint{Key: taint.Key, Value: taint.Value, Effect: string(taint.Effect)}.String())
+		}
+		newNode.Annotations[managedTaintsAnnotation] = strings.Join(applied, ",")
+	} else {
+		delete(newNode.Annotations, managedTaintsAnnotation)
+	}
 
 	_, err := client.UpdateNode(newNode)
 
 	return err
 }
 
+// getAgentPoolTaints returns the taints the API model sets on the agent pool of a node
+func (ku *Upgrader) getAgentPoolTaints(node *v1.Node) []v1.Taint {
+	if ku.DataModel == nil || ku.DataModel.Properties == nil || node.Labels == nil {
+		return nil
+	}
+	var taints []v1.Taint
+	for _, profile := range ku.DataModel.Properties.AgentPoolProfiles {
+		if profile.Name != node.Labels["agentpool"] {
+			continue
+		}
+		for _, s := range profile.Taints {
+			t, err := common.ParseTaint(s)
+			if err != nil {
+				ku.logger.Warningf("Ignoring invalid taint %s of agent pool %s: %v", s, profile.Name, err)
+				continue
+			}
+			taints = append(taints, v1.Taint{Key: t.Key, Value: t.Value, Effect: v1.TaintEffect(t.Effect)})
+		}
+	}
+	return taints
+}
+
+func hasTaint(taints []v1.Taint, keyAndEffect string) bool {
+	for _, taint := range taints {
+		if taint.Key+":"+string(taint.Effect) == keyAndEffect {
+			return true
+		}
+	}
+	return false
+}
+
 func (ku *Upgrader) getKubernetesClient() (armhelpers.KubernetesClient, error) {
 	getClientTimeout := 10 * time.Second
 
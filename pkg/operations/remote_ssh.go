@@ -5,43 +5,53 @@ package operations
 
 import (
 	"bytes"
-	"fmt"
-	"log"
-	"net"
+	"io"
 
-	"golang.org/x/crypto/ssh"
+	"github.com/Azure/aks-engine/pkg/operations/remotessh"
 )
 
-// RemoteRun executes remote command
+// RemoteRun executes remote command, without verifying the host key
 func RemoteRun(user string, addr string, port int, sshKey []byte, cmd string) (string, error) {
-	// Create the Signer for this private key.
-	signer, err := ssh.ParsePrivateKey(sshKey)
+	var b bytes.Buffer
+	err := RemoteRunWithIO(user, addr, port, sshKey, "", cmd, nil, &b)
+	return b.String(), err
+}
+
+// RemoteRunWithIO executes remote command, streaming stdin to the command and its output to stdout. The host key is
+// verified against the known_hosts file at knownHostsFile, or not verified if knownHostsFile is empty.
+func RemoteRunWithIO(user string, addr string, port int, sshKey []byte, knownHostsFile string, cmd string, stdin io.Reader, stdout io.Writer) error {
+	config, err := remotessh.ClientConfig(user, sshKey, knownHostsFile)
 	if err != nil {
-		log.Fatalf("unable to parse private key: %v", err)
+		return err
 	}
+	return remotessh.Run(&remotessh.Host{Address: addr, Port: port}, config, cmd, stdin, stdout, nil)
+}
 
-	// Authentication
-	config := &ssh.ClientConfig{
-		User: user,
-		Auth: []ssh.AuthMethod{
-			ssh.PublicKeys(signer),
-		},
-		HostKeyCallback: func(string, net.Addr, ssh.PublicKey) error { return nil },
-	}
-	// Connect
-	client, err := ssh.Dial("tcp", fmt.Sprintf("%s:%d", addr, port), config)
+// RemoteRunThroughHostWithIO executes remote command on addr, reaching it over SSH from the host at hostAddr:hostPort,
+// streaming stdin to the command and its output to stdout. The host keys of both hosts are verified against the
+// known_hosts file at knownHostsFile, or not verified if knownHostsFile is empty.
+func RemoteRunThroughHostWithIO(user string, hostAddr string, hostPort int, addr string, sshKey []byte, knownHostsFile string, cmd string, stdin io.Reader, stdout io.Writer) error {
+	config, err := remotessh.ClientConfig(user, sshKey, knownHostsFile)
 	if err != nil {
-		return "", err
+		return err
 	}
-	// Create a session. It is one session per command.
-	session, err := client.NewSession()
-	if err != nil {
-		return "", err
+	host := &remotessh.Host{
+		Address:  addr,
+		Port:     22,
+		JumpHost: &remotessh.Host{Address: hostAddr, Port: hostPort},
 	}
-	defer session.Close()
-	var b bytes.Buffer
-	session.Stdout = &b // get output
+	return remotessh.Run(host, config, cmd, stdin, stdout, nil)
+}
 
-	err = session.Run(cmd)
-	return b.String(), err
+// MasterSSHPorts returns the ports of the master load balancer forwarding SSH to each of masterCount masters
+func MasterSSHPorts(masterCount int) []int {
+	ports := []int{}
+	for i := 0; i < masterCount; i++ {
+		if i == 0 {
+			ports = append(ports, 22)
+		} else {
+			ports = append(ports, 2200+i)
+		}
+	}
+	return ports
 }
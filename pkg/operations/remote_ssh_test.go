@@ -0,0 +1,16 @@
+// Copyright (c) Microsoft Corporation. All rights reserved.
+// Licensed under the MIT license.
+
+package operations
+
+import (
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Master SSH ports tests", func() {
+	It("Should return port 22 for the first master and the NAT ports for the others", func() {
+		Expect(MasterSSHPorts(1)).To(Equal([]int{22}))
+		Expect(MasterSSHPorts(3)).To(Equal([]int{22, 2201, 2202}))
+	})
+})
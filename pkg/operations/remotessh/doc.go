@@ -0,0 +1,5 @@
+// Copyright (c) Microsoft Corporation. All rights reserved.
+// Licensed under the MIT license.
+
+// Package remotessh runs commands on the machines of a cluster over SSH, directly or through jump hosts.
+package remotessh
@@ -0,0 +1,122 @@
+// Copyright (c) Microsoft Corporation. All rights reserved.
+// Licensed under the MIT license.
+
+package remotessh
+
+import (
+	"io"
+	"net"
+	"strconv"
+
+	"github.com/pkg/errors"
+	"golang.org/x/crypto/ssh"
+	"golang.org/x/crypto/ssh/knownhosts"
+)
+
+// Host is a machine reachable over SSH, directly or through a jump host
+type Host struct {
+	Address string
+	Port    int
+	// User is the user to connect as, overriding the user of the client configuration if set
+	User string
+	// JumpHost is the host the connection to this host is made from, if any
+	JumpHost *Host
+}
+
+func (h *Host) hostport() string {
+	return net.JoinHostPort(h.Address, strconv.Itoa(h.Port))
+}
+
+// ClientConfig returns the configuration authenticating as user with the private key sshKey. The host keys are
+// verified against the known_hosts file at knownHostsFile, failing on unknown and mismatched keys, or not verified if
+// knownHostsFile is empty, which callers only pass when the verification is explicitly skipped.
+func ClientConfig(user string, sshKey []byte, knownHostsFile string) (*ssh.ClientConfig, error) {
+	signer, err := ssh.ParsePrivateKey(sshKey)
+	if err != nil {
+		return nil, errors.Wrap(err, "parsing the private key")
+	}
+
+	hostKeyCallback := ssh.InsecureIgnoreHostKey()
+	if knownHostsFile != "" {
+		if hostKeyCallback, err = knownhosts.New(knownHostsFile); err != nil {
+			return nil, errors.Wrapf(err, "reading known hosts file %s", knownHostsFile)
+		}
+	}
+	return &ssh.ClientConfig{
+		User: user,
+		Auth: []ssh.AuthMethod{
+			ssh.PublicKeys(signer),
+		},
+		HostKeyCallback: hostKeyCallback,
+	}, nil
+}
+
+// Dial connects to host, through its jump hosts if any. Closing the client returned closes the connections to the
+// jump hosts as well.
+func Dial(host *Host, config *ssh.ClientConfig) (*ssh.Client, error) {
+	hostConfig := config
+	if host.User != "" {
+		c := *config
+		c.User = host.User
+		hostConfig = &c
+	}
+	if host.JumpHost == nil {
+		client, err := ssh.Dial("tcp", host.hostport(), hostConfig)
+		return client, errors.Wrapf(err, "connecting to %s", host.hostport())
+	}
+
+	jumpClient, err := Dial(host.JumpHost, config)
+	if err != nil {
+		return nil, err
+	}
+	conn, err := jumpClient.Dial("tcp", host.hostport())
+	if err != nil {
+		jumpClient.Close()
+		return nil, errors.Wrapf(err, "connecting to %s from %s", host.hostport(), host.JumpHost.hostport())
+	}
+	c, chans, reqs, err := ssh.NewClientConn(conn, host.hostport(), hostConfig)
+	if err != nil {
+		conn.Close()
+		jumpClient.Close()
+		return nil, errors.Wrapf(err, "connecting to %s from %s", host.hostport(), host.JumpHost.hostport())
+	}
+	client := ssh.NewClient(c, chans, reqs)
+	go func() {
+		client.Wait()
+		jumpClient.Close()
+	}()
+	return client, nil
+}
+
+// Run runs cmd on host, streaming stdin to the command and its output to stdout and stderr, which are discarded if nil
+func Run(host *Host, config *ssh.ClientConfig, cmd string, stdin io.Reader, stdout, stderr io.Writer) error {
+	client, err := Dial(host, config)
+	if err != nil {
+		return err
+	}
+	defer client.Close()
+
+	// Create a session. It is one session per command.
+	session, err := client.NewSession()
+	if err != nil {
+		return errors.Wrap(err, "opening an SSH session")
+	}
+	defer session.Close()
+	session.Stdin = stdin
+	session.Stdout = stdout
+	session.Stderr = stderr
+
+	return session.Run(cmd)
+}
+
+// ExitCode returns the exit code of the command that failed with err returned by Run, 0 if err is nil, or -1 if the
+// command did not exit, because the connection to the host failed for instance
+func ExitCode(err error) int {
+	if err == nil {
+		return 0
+	}
+	if exitErr, ok := errors.Cause(err).(*ssh.ExitError); ok {
+		return exitErr.ExitStatus()
+	}
+	return -1
+}
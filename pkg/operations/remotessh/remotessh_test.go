@@ -0,0 +1,232 @@
+// Copyright (c) Microsoft Corporation. All rights reserved.
+// Licensed under the MIT license.
+
+package remotessh
+
+import (
+	"bytes"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/pem"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net"
+	"os"
+	"strconv"
+	"strings"
+	"testing"
+
+	. "github.com/onsi/gomega"
+	"golang.org/x/crypto/ssh"
+	"golang.org/x/crypto/ssh/knownhosts"
+)
+
+func newPrivateKey(g *GomegaWithT) []byte {
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	g.Expect(err).NotTo(HaveOccurred())
+	return pem.EncodeToMemory(&pem.Block{Type: "RSA PRIVATE KEY", Bytes: x509.MarshalPKCS1PrivateKey(key)})
+}
+
+// fakeServer is an SSH server echoing the commands it runs and their stdin, and forwarding TCP connections.
+// The command "fail" writes to stderr and exits with 3.
+type fakeServer struct {
+	listener net.Listener
+	hostKey  ssh.Signer
+}
+
+func newFakeServer(g *GomegaWithT, clientKey []byte) *fakeServer {
+	hostKey, err := ssh.ParsePrivateKey(newPrivateKey(g))
+	g.Expect(err).NotTo(HaveOccurred())
+	clientSigner, err := ssh.ParsePrivateKey(clientKey)
+	g.Expect(err).NotTo(HaveOccurred())
+	config := &ssh.ServerConfig{
+		PublicKeyCallback: func(conn ssh.ConnMetadata, key ssh.PublicKey) (*ssh.Permissions, error) {
+			if conn.User() == "azureuser" && bytes.Equal(key.Marshal(), clientSigner.PublicKey().Marshal()) {
+				return nil, nil
+			}
+			return nil, fmt.Errorf("unknown key")
+		},
+	}
+	config.AddHostKey(hostKey)
+
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	g.Expect(err).NotTo(HaveOccurred())
+	s := &fakeServer{listener: listener, hostKey: hostKey}
+	go func() {
+		for {
+			conn, err := listener.Accept()
+			if err != nil {
+				return
+			}
+			go s.serve(conn, config)
+		}
+	}()
+	return s
+}
+
+func (s *fakeServer) host() *Host {
+	addr := s.listener.Addr().(*net.TCPAddr)
+	return &Host{Address: addr.IP.String(), Port: addr.Port}
+}
+
+func (s *fakeServer) serve(conn net.Conn, config *ssh.ServerConfig) {
+	_, chans, reqs, err := ssh.NewServerConn(conn, config)
+	if err != nil {
+		return
+	}
+	go ssh.DiscardRequests(reqs)
+	for newChannel := range chans {
+		switch newChannel.ChannelType() {
+		case "session":
+			go serveSession(newChannel)
+		case "direct-tcpip":
+			go serveForward(newChannel)
+		default:
+			newChannel.Reject(ssh.UnknownChannelType, "unsupported")
+		}
+	}
+}
+
+func serveSession(newChannel ssh.NewChannel) {
+	channel, reqs, err := newChannel.Accept()
+	if err != nil {
+		return
+	}
+	defer channel.Close()
+	for req := range reqs {
+		if req.Type != "exec" {
+			req.Reply(false, nil)
+			continue
+		}
+		req.Reply(true, nil)
+		cmd := string(req.Payload[4:])
+		status := uint32(0)
+		if cmd == "fail" {
+			io.WriteString(channel.Stderr(), "failed")
+			status = 3
+		} else {
+			stdin, _ := ioutil.ReadAll(channel)
+			io.WriteString(channel, cmd+" "+string(stdin))
+		}
+		channel.SendRequest("exit-status", false, ssh.Marshal(struct{ Status uint32 }{status}))
+		return
+	}
+}
+
+func serveForward(newChannel ssh.NewChannel) {
+	var payload struct {
+		Host       string
+		Port       uint32
+		OriginHost string
+		OriginPort uint32
+	}
+	if err := ssh.Unmarshal(newChannel.ExtraData(), &payload); err != nil {
+		newChannel.Reject(ssh.ConnectionFailed, err.Error())
+		return
+	}
+	conn, err := net.Dial("tcp", net.JoinHostPort(payload.Host, strconv.Itoa(int(payload.Port))))
+	if err != nil {
+		newChannel.Reject(ssh.ConnectionFailed, err.Error())
+		return
+	}
+	channel, reqs, err := newChannel.Accept()
+	if err != nil {
+		conn.Close()
+		return
+	}
+	go ssh.DiscardRequests(reqs)
+	go func() {
+		io.Copy(conn, channel)
+		conn.Close()
+	}()
+	io.Copy(channel, conn)
+	channel.Close()
+}
+
+func TestRun(t *testing.T) {
+	g := NewGomegaWithT(t)
+	key := newPrivateKey(g)
+	server := newFakeServer(g, key)
+	defer server.listener.Close()
+	config, err := ClientConfig("azureuser", key, "")
+	g.Expect(err).NotTo(HaveOccurred())
+
+	var stdout, stderr bytes.Buffer
+	err = Run(server.host(), config, "cat", strings.NewReader("input"), &stdout, &stderr)
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(ExitCode(err)).To(Equal(0))
+	g.Expect(stdout.String()).To(Equal("cat input"))
+	g.Expect(stderr.String()).To(BeEmpty())
+
+	stdout.Reset()
+	err = Run(server.host(), config, "fail", nil, &stdout, &stderr)
+	g.Expect(err).To(HaveOccurred())
+	g.Expect(ExitCode(err)).To(Equal(3))
+	g.Expect(stdout.String()).To(BeEmpty())
+	g.Expect(stderr.String()).To(Equal("failed"))
+
+	// the user of the host overrides the user of the configuration
+	host := server.host()
+	host.User = "unknown"
+	err = Run(host, config, "hostname", nil, nil, nil)
+	g.Expect(err).To(HaveOccurred())
+	g.Expect(err.Error()).To(ContainSubstring("unable to authenticate"))
+	g.Expect(config.User).To(Equal("azureuser"))
+}
+
+func TestRunThroughJumpHost(t *testing.T) {
+	g := NewGomegaWithT(t)
+	key := newPrivateKey(g)
+	jumpHost := newFakeServer(g, key)
+	defer jumpHost.listener.Close()
+	server := newFakeServer(g, key)
+	defer server.listener.Close()
+	config, err := ClientConfig("azureuser", key, "")
+	g.Expect(err).NotTo(HaveOccurred())
+
+	host := server.host()
+	host.JumpHost = jumpHost.host()
+	var stdout bytes.Buffer
+	g.Expect(Run(host, config, "hostname", nil, &stdout, nil)).To(Succeed())
+	g.Expect(stdout.String()).To(Equal("hostname "))
+
+	// the jump host cannot reach the host
+	server.listener.Close()
+	err = Run(host, config, "hostname", nil, &stdout, nil)
+	g.Expect(err).To(HaveOccurred())
+	g.Expect(err.Error()).To(HavePrefix(fmt.Sprintf("connecting to %s:%d from %s:%d", host.Address, host.Port, host.JumpHost.Address, host.JumpHost.Port)))
+	g.Expect(ExitCode(err)).To(Equal(-1))
+}
+
+func TestRunShouldVerifyHostKeys(t *testing.T) {
+	g := NewGomegaWithT(t)
+	key := newPrivateKey(g)
+	server := newFakeServer(g, key)
+	defer server.listener.Close()
+	otherServer := newFakeServer(g, key)
+	defer otherServer.listener.Close()
+
+	dir, err := ioutil.TempDir("", "knownhosts")
+	g.Expect(err).NotTo(HaveOccurred())
+	defer os.RemoveAll(dir)
+	knownHostsFile := dir + "/known_hosts"
+	host := server.host()
+	line := knownhosts.Line([]string{knownhosts.Normalize(host.hostport())}, server.hostKey.PublicKey())
+	g.Expect(ioutil.WriteFile(knownHostsFile, []byte(line+"\n"), 0600)).To(Succeed())
+
+	config, err := ClientConfig("azureuser", key, knownHostsFile)
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(Run(host, config, "hostname", nil, nil, nil)).To(Succeed())
+
+	err = Run(otherServer.host(), config, "hostname", nil, nil, nil)
+	g.Expect(err).To(HaveOccurred())
+	g.Expect(err.Error()).To(ContainSubstring("knownhosts: key is unknown"))
+	g.Expect(ExitCode(err)).To(Equal(-1))
+
+	_, err = ClientConfig("azureuser", key, dir+"/missing")
+	g.Expect(err).To(HaveOccurred())
+	_, err = ClientConfig("azureuser", []byte("key"), "")
+	g.Expect(err).To(HaveOccurred())
+}
@@ -34,4 +34,11 @@ var _ = Describe("Scale down vms operation tests", func() {
 		errs := ScaleDownVMs(&mockClient, log.NewEntry(log.New()), "sid", "rg", "k8s-agent-F8EADCCF-0", "k8s-agent-F8EADCCF-3", "k8s-agent-F8EADCCF-2", "k8s-agent-F8EADCCF-4")
 		Expect(errs).To(BeNil())
 	})
+	It("Should not delete the managed disk of a vm with an ephemeral os disk", func() {
+		mockClient := armhelpers.MockAKSEngineClient{}
+		mockClient.ShouldUseEphemeralOSDisk = true
+		mockClient.FailDeleteManagedDisk = true
+		errs := ScaleDownVMs(&mockClient, log.NewEntry(log.New()), "sid", "rg", "k8s-agent-F8EADCCF-0", "k8s-agent-F8EADCCF-3")
+		Expect(errs).To(BeNil())
+	})
 })